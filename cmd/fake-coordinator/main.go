@@ -0,0 +1,72 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command fake-coordinator serves an in-memory fake of the m3coordinator
+// admin API, to try out the operator's placement and namespace handling
+// locally.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin/fake"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_listenAddr        string
+	_autoMarkAvailable bool
+	_debugLog          bool
+)
+
+func init() {
+	flag.StringVar(&_listenAddr, "listen-addr", ":7201", "address to serve the admin API on")
+	flag.BoolVar(&_autoMarkAvailable, "auto-mark-available", true, "mark shards available as soon as they're assigned, as if nodes bootstrapped instantly")
+	flag.BoolVar(&_debugLog, "debug", false, "log every request")
+	flag.Parse()
+}
+
+func main() {
+	cfg := zap.NewDevelopmentConfig()
+	cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	if _debugLog {
+		cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building logger: %v", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	coordinator := fake.NewCoordinator(
+		fake.WithLogger(logger),
+		fake.WithAutoMarkAvailable(_autoMarkAvailable))
+
+	logger.Info("serving fake coordinator", zap.String("addr", _listenAddr))
+	if err := http.ListenAndServe(_listenAddr, coordinator); err != nil {
+		logger.Fatal("error serving", zap.Error(err))
+	}
+}
@@ -21,29 +21,38 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	// No-op import to register assets.
 	_ "github.com/m3db/m3db-operator/pkg/assets"
 	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
-	informers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
 	"github.com/m3db/m3db-operator/pkg/controller"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/webhook"
 
 	"github.com/m3db/m3x/instrument"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
-	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/uber-go/tally"
 	promreporter "github.com/uber-go/tally/prometheus"
@@ -68,6 +77,39 @@ var (
 	_humanTime           bool
 	_manageCRD           bool
 	_enableCRDValidation bool
+
+	_leaderElect              bool
+	_leaderElectNamespace     string
+	_leaderElectName          string
+	_leaderElectLeaseDuration time.Duration
+	_leaderElectRenewDeadline time.Duration
+	_leaderElectRetryPeriod   time.Duration
+
+	_webhook         bool
+	_webhookAddr     string
+	_webhookCertFile string
+	_webhookKeyFile  string
+
+	_crdStorageVersion          string
+	_conversionWebhookService   string
+	_conversionWebhookNamespace string
+	_conversionWebhookCABundle  string
+
+	_watchNamespaces string
+	_clusterSelector string
+
+	_metadataSnapshotInterval  time.Duration
+	_metadataSnapshotRetention int
+
+	_requireSecureAdminAPI bool
+
+	_adminTimeout         time.Duration
+	_adminMaxRetries      int
+	_adminRetryMinWait    time.Duration
+	_adminRetryMaxWait    time.Duration
+	_adminBreakerFailures int
+	_adminBreakerCooldown time.Duration
+	_adminPlacementSet    bool
 )
 
 func init() {
@@ -80,6 +122,32 @@ func init() {
 	flag.BoolVar(&_manageCRD, "manage-crd", true, "create and update the operator's CRD specs")
 	// Disabled by default until openAPI validation is more tested.
 	flag.BoolVar(&_enableCRDValidation, "enable-crd-validation", false, "enable openAPI validation of the CR")
+	flag.BoolVar(&_leaderElect, "leader-elect", false, "elect a leader among operator replicas, only the leader will manage clusters")
+	flag.StringVar(&_leaderElectNamespace, "leader-elect-namespace", "", "namespace of the leader election lock, defaults to $POD_NAMESPACE")
+	flag.StringVar(&_leaderElectName, "leader-elect-name", "m3db-operator", "name of the configmap used as the leader election lock")
+	flag.DurationVar(&_leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "how long standby replicas wait before taking over from a leader that has stopped renewing its lease")
+	flag.DurationVar(&_leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "how long the leader will retry renewing its lease before giving up leadership")
+	flag.DurationVar(&_leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "how often replicas try to acquire or renew the lease")
+	flag.BoolVar(&_webhook, "webhook", false, "serve the admission webhooks")
+	flag.StringVar(&_webhookAddr, "webhook-addr", ":8443", "address to serve the admission webhooks on")
+	flag.StringVar(&_webhookCertFile, "webhook-cert-file", "", "TLS certificate for the admission webhooks")
+	flag.StringVar(&_webhookKeyFile, "webhook-key-file", "", "TLS private key for the admission webhooks")
+	flag.StringVar(&_crdStorageVersion, "crd-storage-version", "", "API version to store the operator's custom resources in, existing resources are migrated to it on startup; defaults to v1alpha1")
+	flag.StringVar(&_conversionWebhookService, "conversion-webhook-service", "", "name of the service fronting the webhooks; when set, the CRDs convert between API versions with the webhook and v1beta1 is served")
+	flag.StringVar(&_conversionWebhookNamespace, "conversion-webhook-namespace", "", "namespace of the conversion webhook service, defaults to $POD_NAMESPACE")
+	flag.StringVar(&_conversionWebhookCABundle, "conversion-webhook-ca-bundle", "", "base64 encoded CA bundle the API server verifies the conversion webhook's certificate with")
+	flag.StringVar(&_watchNamespaces, "watch-namespaces", "", "comma separated namespaces to manage clusters in, defaults to all namespaces")
+	flag.StringVar(&_clusterSelector, "cluster-selector", "", "label selector of the M3DBClusters to manage, defaults to all clusters")
+	flag.DurationVar(&_metadataSnapshotInterval, "metadata-snapshot-interval", 10*time.Minute, "how often to snapshot each cluster's placement and namespaces to a configmap, 0 disables snapshots")
+	flag.IntVar(&_metadataSnapshotRetention, "metadata-snapshot-retention", 5, "number of metadata snapshots to keep per cluster")
+	flag.BoolVar(&_requireSecureAdminAPI, "require-secure-admin-api", false, "refuse to manage clusters whose coordinator admin API isn't called over TLS with credentials")
+	flag.DurationVar(&_adminTimeout, "admin-timeout", 30*time.Second, "timeout of each attempt of a coordinator admin API request, 0 disables it")
+	flag.IntVar(&_adminMaxRetries, "admin-max-retries", 4, "number of times a failed coordinator admin API request is retried")
+	flag.DurationVar(&_adminRetryMinWait, "admin-retry-min-wait", time.Second, "minimum backoff between retries of coordinator admin API requests")
+	flag.DurationVar(&_adminRetryMaxWait, "admin-retry-max-wait", 30*time.Second, "maximum backoff between retries of coordinator admin API requests")
+	flag.IntVar(&_adminBreakerFailures, "admin-breaker-failures", 5, "consecutive failed requests after which a cluster's coordinator is considered unavailable and requests to it fail fast, 0 disables circuit breaking")
+	flag.DurationVar(&_adminBreakerCooldown, "admin-breaker-cooldown", 30*time.Second, "how often a request is let through to probe an unavailable coordinator")
+	flag.BoolVar(&_adminPlacementSet, "admin-placement-set", false, "whether the coordinators serve the placement set API, which changing the replication factor and restoring metadata snapshots require")
 	flag.Parse()
 }
 
@@ -168,9 +236,21 @@ func main() {
 
 	stopCh := make(chan struct{})
 
-	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, _informerSyncDuration)
+	watch := controller.WatchConfiguration{
+		ClusterSelector: _clusterSelector,
+	}
+	for _, ns := range strings.Split(_watchNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			watch.Namespaces = append(watch.Namespaces, ns)
+		}
+	}
+
+	kubeInformerFactory, m3dbClusterInformerFactory, err := controller.NewInformerFactories(kubeClient, crdClient, _informerSyncDuration, watch)
+	if err != nil {
+		logger.Fatal("failed to create informers", zap.Error(err))
+	}
+	// Nodes aren't namespaced, pod identity needs them cluster-wide.
 	nodeLister := kubeInformerFactory.Core().V1().Nodes().Lister()
-	m3dbClusterInformerFactory := informers.NewSharedInformerFactory(crdClient, _informerSyncDuration)
 
 	clusterLogger := logger.With(zap.String("controller", "m3db-cluster-controller"))
 	idLogger := logger.With(zap.String("component", "pod-identity-provider"))
@@ -185,6 +265,47 @@ func main() {
 	config := controller.Configuration{
 		ManageCRD:        _manageCRD,
 		EnableValidation: _enableCRDValidation,
+		StorageVersion:   _crdStorageVersion,
+		Watch:            watch,
+
+		MetadataSnapshotInterval:  _metadataSnapshotInterval,
+		MetadataSnapshotRetention: _metadataSnapshotRetention,
+
+		RequireSecureAdminAPI: _requireSecureAdminAPI,
+		AdminClient: controller.AdminClientConfiguration{
+			Timeout: _adminTimeout,
+			RetryPolicy: &m3admin.RetryPolicy{
+				MaxRetries: _adminMaxRetries,
+				MinWait:    _adminRetryMinWait,
+				MaxWait:    _adminRetryMaxWait,
+			},
+			PlacementSet: _adminPlacementSet,
+		},
+	}
+	if _adminBreakerFailures > 0 {
+		config.AdminClient.CircuitBreaker = &m3admin.CircuitBreakerOptions{
+			FailureThreshold: _adminBreakerFailures,
+			Cooldown:         _adminBreakerCooldown,
+		}
+	}
+
+	if _conversionWebhookService != "" {
+		namespace := _conversionWebhookNamespace
+		if namespace == "" {
+			namespace = os.Getenv("POD_NAMESPACE")
+		}
+
+		caBundle, err := base64.StdEncoding.DecodeString(_conversionWebhookCABundle)
+		if err != nil {
+			logger.Fatal("failed to decode conversion webhook CA bundle", zap.Error(err))
+		}
+
+		config.ConversionWebhook = &k8sops.ConversionWebhook{
+			ServiceNamespace: namespace,
+			ServiceName:      _conversionWebhookService,
+			Path:             webhook.ConvertPath,
+			CABundle:         caBundle,
+		}
 	}
 
 	opts := []controller.Option{
@@ -213,6 +334,8 @@ func main() {
 	go kubeInformerFactory.Start(stopCh)
 	go m3dbClusterInformerFactory.Start(stopCh)
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Trap the INT and TERM signals
 	signalChan := make(chan os.Signal, 2)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
@@ -220,13 +343,126 @@ func main() {
 		<-signalChan
 		logger.Warn("received shutdown signal, sending to workers")
 		close(stopCh)
+		cancel()
 		<-signalChan
 		logger.Warn("received second signal, exiting immediately")
 		os.Exit(1)
 	}()
 
-	if err := controller.Run(2, stopCh); err != nil {
-		logger.Fatal("error running controller", zap.Error(err))
+	// Every replica serves the webhooks, not only the leader, so that the
+	// webhook service has endpoints while the leader is failing over.
+	if _webhook {
+		server, err := webhook.New(
+			webhook.WithLogger(logger.With(zap.String("component", "webhook"))),
+			webhook.WithAddress(_webhookAddr),
+			webhook.WithCertFile(_webhookCertFile),
+			webhook.WithKeyFile(_webhookKeyFile),
+			webhook.WithKubeClient(kubeClient),
+			webhook.WithCRDClient(crdClient),
+		)
+		if err != nil {
+			logger.Fatal("failed to create webhook server", zap.Error(err))
+		}
+
+		go func() {
+			if err := server.Run(ctx); err != nil {
+				logger.Fatal("error serving webhooks", zap.Error(err))
+			}
+		}()
+	}
+
+	run := func(stopCh <-chan struct{}) {
+		if err := controller.Run(2, stopCh); err != nil {
+			logger.Fatal("error running controller", zap.Error(err))
+		}
+	}
+
+	if !_leaderElect {
+		run(stopCh)
+		return
+	}
+
+	runWithLeaderElection(ctx, logger, kubeClient, run)
+}
+
+// runWithLeaderElection blocks until the operator is elected leader, then
+// calls run with a channel that is closed once either ctx is cancelled or
+// leadership is lost. The operator exits if it loses leadership so that it
+// restarts as a standby with fresh state.
+func runWithLeaderElection(ctx context.Context, logger *zap.Logger, kubeClient kubernetes.Interface, run func(<-chan struct{})) {
+	id, err := os.Hostname()
+	if err != nil {
+		logger.Fatal("unable to determine leader election identity", zap.Error(err))
+	}
+
+	namespace := _leaderElectNamespace
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		logger.Fatal("leader election namespace must be set with -leader-elect-namespace or $POD_NAMESPACE")
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: kubeClient.CoreV1().Events(namespace),
+	})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: _leaderElectName})
+
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock,
+		namespace,
+		_leaderElectName,
+		kubeClient.CoreV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
+			EventRecorder: recorder,
+		})
+	if err != nil {
+		logger.Fatal("failed to create leader election lock", zap.Error(err))
+	}
+
+	logger = logger.With(zap.String("identity", id), zap.String("lock", lock.Describe()))
+
+	var (
+		started = make(chan struct{})
+		stopped = make(chan struct{})
+	)
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: _leaderElectLeaseDuration,
+		RenewDeadline: _leaderElectRenewDeadline,
+		RetryPeriod:   _leaderElectRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				close(started)
+				defer close(stopped)
+				logger.Info("became leader, starting controller")
+				run(ctx.Done())
+			},
+			OnStoppedLeading: func() {
+				logger.Info("stopped leading")
+			},
+			OnNewLeader: func(identity string) {
+				logger.Info("observed new leader", zap.String("leader", identity))
+			},
+		},
+	})
+	if err != nil {
+		logger.Fatal("failed to create leader elector", zap.Error(err))
+	}
+
+	logger.Info("waiting to become leader")
+	elector.Run(ctx)
+
+	// Wait for the controller to finish any in-flight work before exiting.
+	select {
+	case <-started:
+		<-stopped
+	default:
+	}
+
+	if ctx.Err() == nil {
+		logger.Fatal("lost leader election lease")
 	}
 }
 
@@ -23,6 +23,7 @@
 package e2e
 
 import (
+	"context"
 	"sort"
 	"testing"
 	"time"
@@ -51,7 +52,7 @@ func TestCreateCluster_Regional(t *testing.T) {
 	require.NoError(t, err)
 
 	err = wait.Poll(placementCheckInterval, placementCheckTimeout, func() (bool, error) {
-		pl, err := cl.Get()
+		pl, err := cl.Get(context.Background())
 		if err != nil {
 			h.Logger.Warn("error fetching placement", zap.Error(err))
 			return false, nil
@@ -90,7 +91,7 @@ func TestCreateCluster_Zonal(t *testing.T) {
 	require.NoError(t, err)
 
 	err = wait.Poll(placementCheckInterval, placementCheckTimeout, func() (bool, error) {
-		pl, err := cl.Get()
+		pl, err := cl.Get(context.Background())
 		if err != nil {
 			h.Logger.Warn("error fetching placement", zap.Error(err))
 			return false, nil
@@ -24,6 +24,7 @@ package harness
 
 import (
 	"fmt"
+	"strconv"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
@@ -41,8 +42,10 @@ func (h *Harness) NewPlacementClient(cluster *myspec.M3DBCluster) (placement.Cli
 	env := k8sops.DefaultM3ClusterEnvironmentName(cluster)
 	adminCl := m3admin.NewClient(
 		m3admin.WithEnvironment(env),
+		m3admin.WithZone(k8sops.ServiceZone(cluster)),
 	)
-	url := fmt.Sprintf(proxyBaseURLFmt, h.Namespace, svc.Name, "7201")
+	port := strconv.Itoa(int(k8sops.ClusterPorts(cluster).Coordinator))
+	url := fmt.Sprintf(proxyBaseURLFmt, h.Namespace, svc.Name, port)
 	h.Logger.Sugar().Infof("calling url '%s' with env '%s'", url, env)
 	cl, err := placement.NewClient(
 		placement.WithClient(adminCl),
@@ -0,0 +1,239 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
+	crdscheme "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/scheme"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var errConflict = errors.New("the object has been modified; please apply your changes to the latest version and try again")
+
+// apiServer emulates the object metadata handling of the API server that the
+// fake clientsets leave out and the controller depends on: UIDs, resource
+// versions (and conflicts on stale updates), generations bumped on spec
+// changes, and deletion being deferred until an object's finalizers are
+// removed. Everything else is left to the clientsets' object tracker.
+type apiServer struct {
+	tracker k8stesting.ObjectTracker
+	clock   clock.Clock
+	// seq is shared by the API servers of a simulation so that resource
+	// versions are unique across them, as they'd be in etcd.
+	seq *uint64
+}
+
+func newKubeClientset(clock clock.Clock, seq *uint64) *kubefake.Clientset {
+	tracker := k8stesting.NewObjectTracker(kubescheme.Scheme, kubescheme.Codecs.UniversalDecoder())
+	cs := &kubefake.Clientset{}
+	installReactors(&cs.Fake, &apiServer{tracker: tracker, clock: clock, seq: seq})
+	return cs
+}
+
+func newCRDClientset(clock clock.Clock, seq *uint64) *crdfake.Clientset {
+	tracker := k8stesting.NewObjectTracker(crdscheme.Scheme, crdscheme.Codecs.UniversalDecoder())
+	cs := &crdfake.Clientset{}
+	installReactors(&cs.Fake, &apiServer{tracker: tracker, clock: clock, seq: seq})
+	return cs
+}
+
+func installReactors(fake *k8stesting.Fake, s *apiServer) {
+	fake.AddReactor("*", "*", s.react)
+	fake.AddReactor("*", "*", k8stesting.ObjectReaction(s.tracker))
+	fake.AddWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w, err := s.tracker.Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		return true, w, nil
+	})
+}
+
+func (s *apiServer) next() uint64 {
+	return atomic.AddUint64(s.seq, 1)
+}
+
+// react handles creates, updates and deletes of objects and their status,
+// leaving reads and other subresources to the object tracker.
+func (s *apiServer) react(action k8stesting.Action) (bool, runtime.Object, error) {
+	switch sub := action.GetSubresource(); sub {
+	case "", "status":
+	default:
+		return false, nil, nil
+	}
+
+	switch action := action.(type) {
+	case k8stesting.CreateActionImpl:
+		if action.GetSubresource() != "" {
+			return false, nil, nil
+		}
+		obj, err := s.create(action)
+		return true, obj, err
+	case k8stesting.UpdateActionImpl:
+		obj, err := s.update(action)
+		return true, obj, err
+	case k8stesting.DeleteActionImpl:
+		return true, nil, s.delete(action)
+	}
+
+	return false, nil, nil
+}
+
+func (s *apiServer) create(action k8stesting.CreateActionImpl) (runtime.Object, error) {
+	obj := action.GetObject().DeepCopyObject()
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.GetName() == "" && m.GetGenerateName() != "" {
+		m.SetName(fmt.Sprintf("%s%d", m.GetGenerateName(), s.next()))
+	}
+	gvr, ns := action.GetResource(), objectNamespace(action, m)
+	m.SetSelfLink(selfLink(gvr, ns, m.GetName()))
+	m.SetUID(types.UID(fmt.Sprintf("uid-%d", s.next())))
+	m.SetResourceVersion(fmt.Sprint(s.next()))
+	m.SetGeneration(1)
+	m.SetCreationTimestamp(metav1.NewTime(s.clock.Now()))
+	m.SetDeletionTimestamp(nil)
+
+	if err := s.tracker.Create(gvr, obj, ns); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *apiServer) update(action k8stesting.UpdateActionImpl) (runtime.Object, error) {
+	obj := action.GetObject().DeepCopyObject()
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	gvr, ns := action.GetResource(), objectNamespace(action, m)
+	old, err := s.tracker.Get(gvr, ns, m.GetName())
+	if err != nil {
+		return nil, err
+	}
+	oldMeta, err := meta.Accessor(old)
+	if err != nil {
+		return nil, err
+	}
+
+	// Updates without a resource version are unconditional.
+	if rv := m.GetResourceVersion(); rv != "" && rv != oldMeta.GetResourceVersion() {
+		return nil, kerrors.NewConflict(gvr.GroupResource(), m.GetName(), errConflict)
+	}
+
+	m.SetUID(oldMeta.GetUID())
+	m.SetCreationTimestamp(oldMeta.GetCreationTimestamp())
+	m.SetDeletionTimestamp(oldMeta.GetDeletionTimestamp())
+	m.SetGeneration(oldMeta.GetGeneration())
+	if action.GetSubresource() == "" && specChanged(old, obj) {
+		m.SetGeneration(oldMeta.GetGeneration() + 1)
+	}
+	m.SetResourceVersion(fmt.Sprint(s.next()))
+
+	if m.GetDeletionTimestamp() != nil && len(m.GetFinalizers()) == 0 {
+		return obj, s.tracker.Delete(gvr, ns, m.GetName())
+	}
+
+	if err := s.tracker.Update(gvr, obj, ns); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *apiServer) delete(action k8stesting.DeleteActionImpl) error {
+	gvr, ns, name := action.GetResource(), action.GetNamespace(), action.GetName()
+	obj, err := s.tracker.Get(gvr, ns, name)
+	if err != nil {
+		return err
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+
+	if len(m.GetFinalizers()) == 0 {
+		return s.tracker.Delete(gvr, ns, name)
+	}
+
+	// Objects with finalizers are only marked for deletion, they're deleted
+	// once the finalizers are removed.
+	if m.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	now := metav1.NewTime(s.clock.Now())
+	m.SetDeletionTimestamp(&now)
+	m.SetResourceVersion(fmt.Sprint(s.next()))
+	return s.tracker.Update(gvr, obj, ns)
+}
+
+// objectNamespace returns the namespace of an object being written. Events are
+// written to their own namespace by clients that aren't scoped to one, which
+// the fake clientsets send without a namespace.
+func objectNamespace(action k8stesting.Action, m metav1.Object) string {
+	if ns := action.GetNamespace(); ns != "" {
+		return ns
+	}
+	return m.GetNamespace()
+}
+
+// selfLink returns the path of an object, which the event recorder requires
+// to reference it.
+func selfLink(gvr schema.GroupVersionResource, namespace, name string) string {
+	prefix := "/apis/" + gvr.Group + "/" + gvr.Version
+	if gvr.Group == "" {
+		prefix = "/api/" + gvr.Version
+	}
+	if namespace != "" {
+		prefix += "/namespaces/" + namespace
+	}
+	return prefix + "/" + gvr.Resource + "/" + name
+}
+
+// specChanged returns true if the objects have a Spec field and it differs.
+func specChanged(old, new runtime.Object) bool {
+	oldSpec := reflect.Indirect(reflect.ValueOf(old)).FieldByName("Spec")
+	newSpec := reflect.Indirect(reflect.ValueOf(new)).FieldByName("Spec")
+	if !oldSpec.IsValid() || !newSpec.IsValid() {
+		return false
+	}
+	return !apiequality.Semantic.DeepEqual(oldSpec.Interface(), newSpec.Interface())
+}
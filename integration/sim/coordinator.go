@@ -0,0 +1,80 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin/fake"
+
+	"go.uber.org/zap"
+)
+
+// coordinators is an http.RoundTripper serving the admin API requests sent to
+// each cluster's coordinator service from a fake coordinator of its own,
+// without any network connections.
+type coordinators struct {
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	byHost map[string]*fake.Coordinator
+}
+
+func newCoordinators(logger *zap.Logger) *coordinators {
+	return &coordinators{
+		logger: logger,
+		byHost: make(map[string]*fake.Coordinator),
+	}
+}
+
+// get returns the coordinator serving a host, creating it on first use.
+func (c *coordinators) get(host string) *fake.Coordinator {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	coord, ok := c.byHost[host]
+	if !ok {
+		coord = fake.NewCoordinator(fake.WithLogger(c.logger.With(zap.String("coordinator", host))))
+		c.byHost[host] = coord
+	}
+	return coord
+}
+
+// all returns every coordinator that has been used.
+func (c *coordinators) all() []*fake.Coordinator {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	coords := make([]*fake.Coordinator, 0, len(c.byHost))
+	for _, coord := range c.byHost {
+		coords = append(coords, coord)
+	}
+	return coords
+}
+
+func (c *coordinators) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	rec := httptest.NewRecorder()
+	c.get(r.URL.Host).ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sim runs the operator's controller against an emulated Kubernetes
+// cluster and fake coordinators, entirely in memory. The fake clientsets are
+// given the API server behavior the controller depends on, StatefulSets are
+// reconciled into pods as the StatefulSet controller would, and the shards
+// assigned to pods are bootstrapped, with the pods unready while they are as
+// with M3DB's readiness probe. This makes it possible to write
+// deterministic scenario tests of whole cluster lifecycles which run in
+// seconds, without a Kubernetes cluster, etcd or M3DB.
+//
+// Pods aren't scheduled to nodes, so clusters must not use pod identity
+// sources that read the pod's node.
+package sim
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	// No-op import to register assets.
+	_ "github.com/m3db/m3db-operator/pkg/assets"
+	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
+	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
+	crdinformers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
+	"github.com/m3db/m3db-operator/pkg/controller"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/fake"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultInterval = 10 * time.Millisecond
+	defaultWorkers  = 2
+
+	// Clusters are reconciled on changes to their objects, the resyncs are a
+	// backstop as they are for the operator.
+	resyncPeriod = time.Second
+)
+
+// Option configures a Simulator.
+type Option interface {
+	execute(*options)
+}
+
+type options struct {
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+type optionFn func(o *options)
+
+func (fn optionFn) execute(o *options) {
+	fn(o)
+}
+
+// WithLogger sets the logger of the simulation and the controller. If not set
+// a noop logger will be used.
+func WithLogger(l *zap.Logger) Option {
+	return optionFn(func(o *options) {
+		o.logger = l
+	})
+}
+
+// WithInterval sets how often StatefulSets are synced and shards bootstrapped,
+// bootstrapping takes two intervals. Defaults to 10ms.
+func WithInterval(d time.Duration) Option {
+	return optionFn(func(o *options) {
+		o.interval = d
+	})
+}
+
+// Simulator runs a controller against an emulated cluster.
+type Simulator struct {
+	logger       *zap.Logger
+	interval     time.Duration
+	kubeClient   *kubefake.Clientset
+	crdClient    *crdfake.Clientset
+	coordinators *coordinators
+
+	kubeInformers kubeinformers.SharedInformerFactory
+	crdInformers  crdinformers.SharedInformerFactory
+	controller    *controller.Controller
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New returns a Simulator with an empty cluster. It must be started before
+// any objects are created.
+func New(opts ...Option) (*Simulator, error) {
+	o := &options{
+		logger:   zap.NewNop(),
+		interval: defaultInterval,
+	}
+	for _, opt := range opts {
+		opt.execute(o)
+	}
+
+	var (
+		seq        uint64
+		kubeClient = newKubeClientset(clock.RealClock{}, &seq)
+		crdClient  = newCRDClientset(clock.RealClock{}, &seq)
+		coords     = newCoordinators(o.logger)
+	)
+
+	kubeInformers := kubeinformers.NewSharedInformerFactory(kubeClient, resyncPeriod)
+	crdInformers := crdinformers.NewSharedInformerFactory(crdClient, resyncPeriod)
+
+	kclient, err := k8sops.New(
+		k8sops.WithKClient(kubeClient),
+		k8sops.WithCRDClient(crdClient),
+		k8sops.WithLogger(o.logger))
+	if err != nil {
+		return nil, err
+	}
+
+	idProvider, err := podidentity.NewProvider(
+		podidentity.WithNodeLister(kubeInformers.Core().V1().Nodes().Lister()),
+		podidentity.WithLogger(o.logger))
+	if err != nil {
+		return nil, err
+	}
+
+	ctrl, err := controller.New(
+		controller.WithLogger(o.logger),
+		controller.WithScope(tally.NoopScope),
+		controller.WithKClient(kclient),
+		controller.WithKubeClient(kubeClient),
+		controller.WithCRDClient(crdClient),
+		controller.WithKubeInformerFactory(kubeInformers),
+		controller.WithM3DBClusterInformerFactory(crdInformers),
+		controller.WithPodIdentityProvider(idProvider),
+		controller.WithConfig(controller.Configuration{
+			AdminClient: controller.AdminClientConfiguration{
+				// The fake coordinators answer straight away, failed requests are
+				// retried when the cluster is requeued.
+				RetryPolicy: &m3admin.RetryPolicy{},
+				// The fake coordinators serve the placement set API.
+				PlacementSet: true,
+			},
+		}),
+		controller.WithAdminClientOptions(m3admin.WithTransport(coords)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Simulator{
+		logger:        o.logger,
+		interval:      o.interval,
+		kubeClient:    kubeClient,
+		crdClient:     crdClient,
+		coordinators:  coords,
+		kubeInformers: kubeInformers,
+		crdInformers:  crdInformers,
+		controller:    ctrl,
+		stopCh:        make(chan struct{}),
+	}, nil
+}
+
+// Start starts the controller and the emulated cluster.
+func (s *Simulator) Start() {
+	s.kubeInformers.Start(s.stopCh)
+	s.crdInformers.Start(s.stopCh)
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		if err := s.controller.Run(defaultWorkers, s.stopCh); err != nil {
+			s.logger.Error("error running controller", zap.Error(err))
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		wait.Until(s.sync, s.interval, s.stopCh)
+	}()
+}
+
+// Stop stops the controller and the emulated cluster, and waits for them to
+// exit.
+func (s *Simulator) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *Simulator) sync() {
+	for _, fn := range []func() error{
+		s.collectGarbage,
+		s.syncStatefulSets,
+		s.bootstrapShards,
+	} {
+		if err := fn(); err != nil {
+			s.logger.Warn("error syncing simulated cluster", zap.Error(err))
+		}
+	}
+}
+
+// KubeClient returns the client of the emulated cluster's Kubernetes objects.
+func (s *Simulator) KubeClient() kubernetes.Interface {
+	return s.kubeClient
+}
+
+// CRDClient returns the client of the emulated cluster's operator objects.
+func (s *Simulator) CRDClient() clientset.Interface {
+	return s.crdClient
+}
+
+// Coordinator returns the fake coordinator that serves a cluster's admin API
+// requests.
+func (s *Simulator) Coordinator(cluster *myspec.M3DBCluster) (*fake.Coordinator, error) {
+	u, err := url.Parse(k8sops.CoordinatorURL(cluster))
+	if err != nil {
+		return nil, err
+	}
+	return s.coordinators.get(u.Host), nil
+}
+
+// UpdateCluster applies fn to the latest version of a cluster and updates it,
+// retrying on conflicts with the operator's own updates.
+func (s *Simulator) UpdateCluster(namespace, name string, fn func(*myspec.M3DBCluster)) (*myspec.M3DBCluster, error) {
+	client := s.crdClient.OperatorV1alpha1().M3DBClusters(namespace)
+	for {
+		cluster, err := client.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		fn(cluster)
+		cluster, err = client.Update(cluster)
+		if kerrors.IsConflict(err) {
+			continue
+		}
+		return cluster, err
+	}
+}
+
+// DeletePod deletes a pod, which the StatefulSet it belongs to replaces with
+// a new pod of the same name but a different identity, as when a pod is
+// rescheduled to another node.
+func (s *Simulator) DeletePod(namespace, name string) error {
+	return s.kubeClient.CoreV1().Pods(namespace).Delete(name, &metav1.DeleteOptions{})
+}
+
+// WaitFor polls cond until it returns true or the timeout passes. Errors from
+// cond don't stop the polling, the last one is included in the timeout error.
+func (s *Simulator) WaitFor(timeout time.Duration, cond func() (bool, error)) error {
+	var lastErr error
+	err := wait.PollImmediate(s.interval, timeout, func() (bool, error) {
+		done, err := cond()
+		lastErr = err
+		return done, nil
+	})
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		return fmt.Errorf("%v: %v", err, lastErr)
+	}
+	return err
+}
@@ -0,0 +1,391 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sim
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin/fake"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNamespace = "sim"
+	testTimeout   = 30 * time.Second
+)
+
+var testGroups = []string{"group-a", "group-b", "group-c"}
+
+func newTestSimulator(t *testing.T) *Simulator {
+	s, err := New()
+	require.NoError(t, err)
+	s.Start()
+	return s
+}
+
+func newTestCluster(name string, instances int32, namespaces ...string) *myspec.M3DBCluster {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: myspec.ClusterSpec{
+			Image:             "quay.io/m3db/m3dbnode:latest",
+			ReplicationFactor: int32(len(testGroups)),
+			NumberOfShards:    8,
+			EtcdEndpoints:     []string{"http://etcd:2379"},
+		},
+	}
+	for _, group := range testGroups {
+		cluster.Spec.IsolationGroups = append(cluster.Spec.IsolationGroups, myspec.IsolationGroup{
+			Name:         group,
+			NumInstances: instances,
+		})
+	}
+	for _, ns := range namespaces {
+		cluster.Spec.Namespaces = append(cluster.Spec.Namespaces, myspec.Namespace{
+			Name:   ns,
+			Preset: "10s:2d",
+		})
+	}
+	return cluster
+}
+
+func createCluster(t *testing.T, s *Simulator, cluster *myspec.M3DBCluster) *fake.Coordinator {
+	_, err := s.CRDClient().OperatorV1alpha1().M3DBClusters(cluster.Namespace).Create(cluster)
+	require.NoError(t, err)
+
+	coord, err := s.Coordinator(cluster)
+	require.NoError(t, err)
+	return coord
+}
+
+func setInstances(t *testing.T, s *Simulator, cluster *myspec.M3DBCluster, instances int32) {
+	_, err := s.UpdateCluster(cluster.Namespace, cluster.Name, func(cluster *myspec.M3DBCluster) {
+		for i := range cluster.Spec.IsolationGroups {
+			cluster.Spec.IsolationGroups[i].NumInstances = instances
+		}
+	})
+	require.NoError(t, err)
+}
+
+// waitForCluster waits until every isolation group of the cluster has the
+// given number of ready pods, each of which is in the placement with all of
+// its shards available.
+func waitForCluster(t *testing.T, s *Simulator, cluster *myspec.M3DBCluster, coord *fake.Coordinator, instances int32) {
+	err := s.WaitFor(testTimeout, func() (bool, error) {
+		pl, ok := coord.Placement(placement.ServiceM3DB)
+		if !ok {
+			return false, fmt.Errorf("no placement")
+		}
+
+		selector := klabels.SelectorFromSet(map[string]string{
+			labels.Cluster:   cluster.Name,
+			labels.Component: labels.ComponentM3DBNode,
+		})
+		sets, err := s.KubeClient().AppsV1().StatefulSets(cluster.Namespace).
+			List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return false, err
+		}
+		if n := len(sets.Items); n != len(cluster.Spec.IsolationGroups) {
+			return false, fmt.Errorf("cluster has %d statefulsets", n)
+		}
+
+		setsByGroup := make(map[string]appsv1.StatefulSet, len(sets.Items))
+		for _, set := range sets.Items {
+			setsByGroup[set.Labels[labels.IsolationGroup]] = set
+		}
+
+		for _, group := range cluster.Spec.IsolationGroups {
+			set, ok := setsByGroup[group.Name]
+			if !ok {
+				return false, fmt.Errorf("no statefulset for isolation group %s", group.Name)
+			}
+			name := set.Name
+			if *set.Spec.Replicas != instances || set.Status.Replicas != instances ||
+				set.Status.ReadyReplicas != instances {
+				return false, fmt.Errorf("statefulset %s has %d/%d ready replicas of %d",
+					name, set.Status.ReadyReplicas, set.Status.Replicas, *set.Spec.Replicas)
+			}
+
+			for ordinal := int32(0); ordinal < instances; ordinal++ {
+				podName := fmt.Sprintf("%s-%d", name, ordinal)
+				pod, err := s.KubeClient().CoreV1().Pods(cluster.Namespace).Get(podName, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				inst, ok := pl.Instance(pod.Annotations[podidentity.AnnotationKeyPodIdentity])
+				if !ok {
+					return false, fmt.Errorf("pod %s not in placement", podName)
+				}
+				if inst.Shards().NumShardsForState(shard.Available) != inst.Shards().NumShards() {
+					return false, fmt.Errorf("pod %s is bootstrapping", podName)
+				}
+			}
+		}
+
+		if n := pl.NumInstances(); n != len(cluster.Spec.IsolationGroups)*int(instances) {
+			return false, fmt.Errorf("placement has %d instances", n)
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestScaleUp(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("scale-up", 1, "metrics")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	setInstances(t, s, cluster, 2)
+	waitForCluster(t, s, cluster, coord, 2)
+
+	pl, _ := coord.Placement(placement.ServiceM3DB)
+	for _, inst := range pl.Instances() {
+		// Each group's 8 shards are spread across its 2 instances.
+		assert.Equal(t, 4, inst.Shards().NumShards(), inst.ID())
+	}
+}
+
+func TestScaleDown(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("scale-down", 2, "metrics")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 2)
+
+	setInstances(t, s, cluster, 1)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	// The highest ordinal pods are removed.
+	for i := range testGroups {
+		name := k8sops.StatefulSetName(cluster.Name, i) + "-1"
+		_, err := s.KubeClient().CoreV1().Pods(testNamespace).Get(name, metav1.GetOptions{})
+		assert.True(t, kerrors.IsNotFound(err), name)
+	}
+}
+
+func TestReplacePod(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("replace", 1, "metrics")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	podName := k8sops.StatefulSetName(cluster.Name, 0) + "-0"
+	pod, err := s.KubeClient().CoreV1().Pods(testNamespace).Get(podName, metav1.GetOptions{})
+	require.NoError(t, err)
+	oldID := pod.Annotations[podidentity.AnnotationKeyPodIdentity]
+	require.NotEmpty(t, oldID)
+
+	require.NoError(t, s.DeletePod(testNamespace, podName))
+	err = s.WaitFor(testTimeout, func() (bool, error) {
+		pod, err := s.KubeClient().CoreV1().Pods(testNamespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		newID, ok := pod.Annotations[podidentity.AnnotationKeyPodIdentity]
+		return ok && newID != oldID, nil
+	})
+	require.NoError(t, err)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	pl, _ := coord.Placement(placement.ServiceM3DB)
+	_, ok := pl.Instance(oldID)
+	assert.False(t, ok)
+}
+
+func TestMigrateIsolationGroup(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("migrate", 1, "metrics")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	// Replace the first group with a new one, shifting the others in the spec.
+	cluster, err := s.UpdateCluster(testNamespace, cluster.Name, func(cluster *myspec.M3DBCluster) {
+		cluster.Spec.IsolationGroups = append(cluster.Spec.IsolationGroups[1:], myspec.IsolationGroup{
+			Name:         "group-d",
+			NumInstances: 1,
+		})
+	})
+	require.NoError(t, err)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	// The remaining groups keep their sets, and the removed group's set is gone.
+	sets := s.KubeClient().AppsV1().StatefulSets(testNamespace)
+	for i, group := range []string{"", "group-b", "group-c", "group-d"} {
+		set, err := sets.Get(k8sops.StatefulSetName(cluster.Name, i), metav1.GetOptions{})
+		if group == "" {
+			assert.True(t, kerrors.IsNotFound(err))
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, group, set.Labels[labels.IsolationGroup])
+	}
+
+	pl, _ := coord.Placement(placement.ServiceM3DB)
+	for _, inst := range pl.Instances() {
+		assert.NotEqual(t, "group-a", inst.IsolationGroup())
+	}
+
+	err = s.WaitFor(testTimeout, func() (bool, error) {
+		cluster, err := s.CRDClient().OperatorV1alpha1().M3DBClusters(testNamespace).
+			Get(cluster.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+		return ok && cond.Status == corev1.ConditionFalse, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestIncreaseReplicationFactor(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("rf", 1, "metrics")
+	cluster.Spec.ReplicationFactor = 2
+	cluster.Spec.IsolationGroups = cluster.Spec.IsolationGroups[:2]
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	cluster, err := s.UpdateCluster(testNamespace, cluster.Name, func(cluster *myspec.M3DBCluster) {
+		cluster.Spec.ReplicationFactor = 3
+		cluster.Spec.IsolationGroups = append(cluster.Spec.IsolationGroups, myspec.IsolationGroup{
+			Name:         testGroups[2],
+			NumInstances: 1,
+		})
+	})
+	require.NoError(t, err)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	pl, _ := coord.Placement(placement.ServiceM3DB)
+	assert.Equal(t, 3, pl.ReplicaFactor())
+	for _, inst := range pl.Instances() {
+		assert.Equal(t, 8, inst.Shards().NumShards())
+	}
+
+	err = s.WaitFor(testTimeout, func() (bool, error) {
+		cluster, err := s.CRDClient().OperatorV1alpha1().M3DBClusters(testNamespace).
+			Get(cluster.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionReplicationFactorChanging)
+		return ok && cond.Status == corev1.ConditionFalse &&
+			cluster.Status.ReplicationFactor == 3, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestNamespaces(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("namespaces", 1, "metrics-a", "metrics-b")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	waitForNamespaces := func(names ...string) {
+		err := s.WaitFor(testTimeout, func() (bool, error) {
+			namespaces := coord.Namespaces()
+			if len(namespaces) != len(names) {
+				return false, fmt.Errorf("got namespaces %v", namespaces)
+			}
+			for _, name := range names {
+				if _, ok := namespaces[name]; !ok {
+					return false, fmt.Errorf("no namespace %s", name)
+				}
+			}
+			return true, nil
+		})
+		require.NoError(t, err)
+	}
+	waitForNamespaces("metrics-a", "metrics-b")
+
+	_, err := s.UpdateCluster(cluster.Namespace, cluster.Name, func(cluster *myspec.M3DBCluster) {
+		cluster.Spec.Namespaces = []myspec.Namespace{
+			{Name: "metrics-b", Preset: "10s:2d"},
+			{Name: "metrics-c", Preset: "1m:40d"},
+		}
+	})
+	require.NoError(t, err)
+	waitForNamespaces("metrics-b", "metrics-c")
+}
+
+func TestDeleteCluster(t *testing.T) {
+	s := newTestSimulator(t)
+	defer s.Stop()
+
+	cluster := newTestCluster("delete", 1, "metrics")
+	coord := createCluster(t, s, cluster)
+	waitForCluster(t, s, cluster, coord, 1)
+
+	clusters := s.CRDClient().OperatorV1alpha1().M3DBClusters(testNamespace)
+	require.NoError(t, clusters.Delete(cluster.Name, &metav1.DeleteOptions{}))
+
+	// The operator's finalizer keeps the cluster around until its placement and
+	// namespaces are deleted.
+	err := s.WaitFor(testTimeout, func() (bool, error) {
+		_, err := clusters.Get(cluster.Name, metav1.GetOptions{})
+		return kerrors.IsNotFound(err), err
+	})
+	require.NoError(t, err)
+
+	_, ok := coord.Placement(placement.ServiceM3DB)
+	assert.False(t, ok)
+	assert.Empty(t, coord.Namespaces())
+
+	err = s.WaitFor(testTimeout, func() (bool, error) {
+		pods, err := s.KubeClient().CoreV1().Pods(testNamespace).List(metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		return len(pods.Items) == 0, nil
+	})
+	require.NoError(t, err)
+}
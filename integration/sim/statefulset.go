@@ -0,0 +1,348 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.uber.org/zap"
+)
+
+const podNameLabel = "statefulset.kubernetes.io/pod-name"
+
+// syncStatefulSets does a pass of the StatefulSet controller over every set.
+func (s *Simulator) syncStatefulSets() error {
+	sets, err := s.kubeClient.AppsV1().StatefulSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range sets.Items {
+		if err := s.syncStatefulSet(&sets.Items[i]); err != nil {
+			return fmt.Errorf("error syncing statefulset %s: %v", sets.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// syncStatefulSet creates or deletes at most one of a set's pods, lowest
+// missing ordinal first when scaling up and highest ordinal first when scaling
+// down as with the OrderedReady pod management policy, then updates the set's
+// status. Pods are ready as soon as they're created unless they're
+// bootstrapping, and pod template changes are considered rolled out straight
+// away without recreating the pods.
+func (s *Simulator) syncStatefulSet(set *appsv1.StatefulSet) error {
+	pods, err := s.statefulSetPods(set)
+	if err != nil {
+		return err
+	}
+
+	replicas := int32(1)
+	if set.Spec.Replicas != nil {
+		replicas = *set.Spec.Replicas
+	}
+
+	highest := int32(-1)
+	for ordinal := range pods {
+		if ordinal > highest {
+			highest = ordinal
+		}
+	}
+
+	if highest >= replicas {
+		pod := pods[highest]
+		s.logger.Debug("deleting pod", zap.String("pod", pod.Name))
+		if err := s.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		delete(pods, highest)
+	} else {
+		for ordinal := int32(0); ordinal < replicas; ordinal++ {
+			if _, ok := pods[ordinal]; ok {
+				continue
+			}
+			pod, err := s.kubeClient.CoreV1().Pods(set.Namespace).Create(newStatefulSetPod(set, ordinal))
+			if err != nil {
+				return err
+			}
+			s.logger.Debug("created pod", zap.String("pod", pod.Name))
+			pods[ordinal] = pod
+			break
+		}
+	}
+
+	var ready int32
+	for _, pod := range pods {
+		if podReady(pod) {
+			ready++
+		}
+	}
+
+	revision := fmt.Sprintf("%s-%d", set.Name, set.Generation)
+	status := appsv1.StatefulSetStatus{
+		ObservedGeneration: set.Generation,
+		Replicas:           int32(len(pods)),
+		ReadyReplicas:      ready,
+		CurrentReplicas:    int32(len(pods)),
+		UpdatedReplicas:    int32(len(pods)),
+		CurrentRevision:    revision,
+		UpdateRevision:     revision,
+	}
+	if apiequality.Semantic.DeepEqual(set.Status, status) {
+		return nil
+	}
+
+	set = set.DeepCopy()
+	set.Status = status
+	_, err = s.kubeClient.AppsV1().StatefulSets(set.Namespace).UpdateStatus(set)
+	if kerrors.IsConflict(err) {
+		// The operator updated the set since it was listed, the status is
+		// updated on the next pass.
+		return nil
+	}
+	return err
+}
+
+// statefulSetPods returns a set's pods by ordinal.
+func (s *Simulator) statefulSetPods(set *appsv1.StatefulSet) (map[int32]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := s.kubeClient.CoreV1().Pods(set.Namespace).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byOrdinal := make(map[int32]*corev1.Pod)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !metav1.IsControlledBy(pod, set) {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, set.Name+"-"))
+		if err != nil {
+			return nil, fmt.Errorf("pod %s has no ordinal", pod.Name)
+		}
+		byOrdinal[int32(ordinal)] = pod
+	}
+	return byOrdinal, nil
+}
+
+func newStatefulSetPod(set *appsv1.StatefulSet, ordinal int32) *corev1.Pod {
+	name := fmt.Sprintf("%s-%d", set.Name, ordinal)
+	template := set.Spec.Template.DeepCopy()
+
+	labels := template.Labels
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[podNameLabel] = name
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   set.Namespace,
+			Labels:      labels,
+			Annotations: template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(set, appsv1.SchemeGroupVersion.WithKind("StatefulSet")),
+			},
+		},
+		Spec: template.Spec,
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	pod.Spec.Hostname = name
+	pod.Spec.Subdomain = set.Spec.ServiceName
+	return pod
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// bootstrapShards emulates M3DB nodes bootstrapping the shards they're
+// assigned, along with their readiness probe which fails while they do: pods
+// whose instances have initializing shards are made unready, and on the next
+// pass their shards are marked available and they're made ready again.
+// Instances are matched to pods by the identity the operator annotates pods
+// with.
+func (s *Simulator) bootstrapShards() error {
+	pods, err := s.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	podsByID := make(map[string]*corev1.Pod)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if id, ok := pod.Annotations[podidentity.AnnotationKeyPodIdentity]; ok {
+			podsByID[id] = pod
+		}
+	}
+
+	bootstrapping := make(map[string]bool)
+	for _, coord := range s.coordinators.all() {
+		pl, ok := coord.Placement(placement.ServiceM3DB)
+		if !ok {
+			continue
+		}
+
+		var bootstrapped []string
+		for _, inst := range pl.Instances() {
+			pod, ok := podsByID[inst.ID()]
+			if !ok || inst.Shards().NumShardsForState(shard.Initializing) == 0 {
+				continue
+			}
+			if podReady(pod) {
+				bootstrapping[inst.ID()] = true
+				continue
+			}
+			bootstrapped = append(bootstrapped, inst.ID())
+		}
+		if len(bootstrapped) == 0 {
+			continue
+		}
+
+		if err := coord.MarkAvailable(placement.ServiceM3DB, bootstrapped...); err != nil {
+			// The placement changed since it was read, the instances are
+			// bootstrapped on the next pass.
+			s.logger.Debug("error marking instances available", zap.Error(err))
+			for _, id := range bootstrapped {
+				bootstrapping[id] = true
+			}
+		}
+	}
+
+	for id, pod := range podsByID {
+		if err := s.setPodReady(pod, !bootstrapping[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPodReady updates the ready condition of a pod if it differs.
+func (s *Simulator) setPodReady(pod *corev1.Pod, ready bool) error {
+	if podReady(pod) == ready {
+		return nil
+	}
+
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	pod = pod.DeepCopy()
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodReady {
+			pod.Status.Conditions[i].Status = status
+		}
+	}
+
+	_, err := s.kubeClient.CoreV1().Pods(pod.Namespace).UpdateStatus(pod)
+	if kerrors.IsConflict(err) || kerrors.IsNotFound(err) {
+		// Updated or deleted since it was listed, retried on the next pass.
+		return nil
+	}
+	return err
+}
+
+// collectGarbage deletes the StatefulSets of clusters that no longer exist and
+// the pods of sets that no longer exist, as the garbage collector would.
+func (s *Simulator) collectGarbage() error {
+	sets, err := s.kubeClient.AppsV1().StatefulSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	setUIDs := make(map[string]bool)
+	for i := range sets.Items {
+		set := &sets.Items[i]
+		setUIDs[string(set.UID)] = true
+
+		owner := metav1.GetControllerOf(set)
+		if owner == nil || owner.APIVersion != myspec.SchemeGroupVersion.String() {
+			continue
+		}
+		cluster, err := s.crdClient.OperatorV1alpha1().M3DBClusters(set.Namespace).Get(owner.Name, metav1.GetOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && cluster.UID == owner.UID {
+			continue
+		}
+
+		s.logger.Debug("deleting orphaned statefulset", zap.String("statefulSet", set.Name))
+		err = s.kubeClient.AppsV1().StatefulSets(set.Namespace).Delete(set.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+		delete(setUIDs, string(set.UID))
+	}
+
+	pods, err := s.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "StatefulSet" || setUIDs[string(owner.UID)] {
+			continue
+		}
+
+		s.logger.Debug("deleting orphaned pod", zap.String("pod", pod.Name))
+		err := s.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
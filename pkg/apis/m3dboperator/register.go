@@ -46,12 +46,46 @@ const (
 
 	// Version sets the version of the custom resource
 	Version = "v1alpha1"
+
+	// VersionV1beta1 is the beta version of the custom resources
+	VersionV1beta1 = "v1beta1"
+
+	// NamespaceResourceKind is the kind of the namespace custom resource
+	NamespaceResourceKind = "M3DBNamespace"
+
+	// NamespaceResourcePlural is the plural form of the namespace custom
+	// resource kind
+	NamespaceResourcePlural = "m3dbnamespaces"
+
+	// BackupResourceKind is the kind of the backup custom resource
+	BackupResourceKind = "M3DBBackup"
+
+	// BackupResourcePlural is the plural form of the backup custom resource
+	// kind
+	BackupResourcePlural = "m3dbbackups"
+
+	// RestoreResourceKind is the kind of the restore custom resource
+	RestoreResourceKind = "M3DBRestore"
+
+	// RestoreResourcePlural is the plural form of the restore custom resource
+	// kind
+	RestoreResourcePlural = "m3dbrestores"
 )
 
 var (
 	// Name is the fully qualified name of the custom resource
 	Name = fmt.Sprintf("%s.%s", ResourcePlural, GroupName)
 
+	// NamespaceName is the fully qualified name of the namespace custom
+	// resource
+	NamespaceName = fmt.Sprintf("%s.%s", NamespaceResourcePlural, GroupName)
+
+	// BackupName is the fully qualified name of the backup custom resource
+	BackupName = fmt.Sprintf("%s.%s", BackupResourcePlural, GroupName)
+
+	// RestoreName is the fully qualified name of the restore custom resource
+	RestoreName = fmt.Sprintf("%s.%s", RestoreResourcePlural, GroupName)
+
 	// SchemeGroupVersion is the schema version of the group
 	SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
 )
@@ -0,0 +1,238 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupPhase is the phase of a backup.
+type BackupPhase string
+
+const (
+	// BackupPhasePending indicates the backup is waiting for its cluster to be
+	// in a state it can be backed up from.
+	BackupPhasePending BackupPhase = "Pending"
+
+	// BackupPhaseRunning indicates the nodes' data is being uploaded.
+	BackupPhaseRunning BackupPhase = "Running"
+
+	// BackupPhaseCompleted indicates every node's data and the manifest were
+	// uploaded.
+	BackupPhaseCompleted BackupPhase = "Completed"
+
+	// BackupPhaseFailed indicates the backup failed and won't be retried.
+	BackupPhaseFailed BackupPhase = "Failed"
+)
+
+// RestorePhase is the phase of a restore.
+type RestorePhase string
+
+const (
+	// RestorePhasePending indicates the restore is waiting for its backup to
+	// complete.
+	RestorePhasePending RestorePhase = "Pending"
+
+	// RestorePhaseProvisioning indicates the restored cluster was created and
+	// the restore is waiting for its placement to be available.
+	RestorePhaseProvisioning RestorePhase = "Provisioning"
+
+	// RestorePhaseSeeding indicates the restored cluster's pods are stopped
+	// and its volumes are being seeded from the backup.
+	RestorePhaseSeeding RestorePhase = "Seeding"
+
+	// RestorePhaseStarting indicates the volumes were seeded and the cluster's
+	// pods are being started one isolation group at a time.
+	RestorePhaseStarting RestorePhase = "Starting"
+
+	// RestorePhaseCompleted indicates the cluster's pods were started and
+	// bootstrapped from the seeded volumes.
+	RestorePhaseCompleted RestorePhase = "Completed"
+
+	// RestorePhaseFailed indicates the restore failed and won't be retried.
+	RestorePhaseFailed RestorePhase = "Failed"
+)
+
+// M3DBBackup is a backup of the data of an M3DB cluster's nodes to an
+// S3-compatible object store.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type M3DBBackup struct {
+	metav1.TypeMeta `json:",inline"`
+	// +k8s:openapi-gen=false
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              M3DBBackupSpec   `json:"spec"`
+	Status            M3DBBackupStatus `json:"status,omitempty"`
+}
+
+// M3DBBackupList represents a list of M3DB backups.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type M3DBBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []M3DBBackup `json:"items"`
+}
+
+// M3DBBackupSpec defines the cluster to back up and where to. A backup is
+// taken once; changes to the spec after it started are ignored.
+// +k8s:openapi-gen=true
+type M3DBBackupSpec struct {
+	// ClusterName is the name of the M3DBCluster, in the same Kubernetes
+	// namespace, to back up.
+	ClusterName string `json:"clusterName"`
+
+	// Store is the object store the backup is uploaded to.
+	Store ObjectStore `json:"store"`
+
+	// Image is the image of the jobs uploading the nodes' data. It must
+	// provide the MinIO client, mc, and a shell. Defaults to minio/mc:latest.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// ObjectStore defines an S3-compatible object store, such as MinIO or S3.
+// +k8s:openapi-gen=true
+type ObjectStore struct {
+	// Endpoint is the host and optional port of the store, e.g.
+	// minio.minio:9000 or s3.amazonaws.com.
+	Endpoint string `json:"endpoint"`
+
+	// Insecure connects to the store over plain HTTP rather than HTTPS.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Bucket is the bucket backups are stored in.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the keys of every backup in the bucket.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretName is the name of a secret, in the same Kubernetes
+	// namespace, holding the store's access key and secret key under the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// M3DBBackupStatus contains the current state of a backup.
+// +k8s:openapi-gen=true
+type M3DBBackupStatus struct {
+	// Phase is the phase of the backup.
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	// Message is a human-friendly message about the phase.
+	Message string `json:"message,omitempty"`
+
+	// Location is the URL of the backup in the object store, e.g.
+	// s3://bucket/prefix/namespace/backup.
+	Location string `json:"location,omitempty"`
+
+	// StartTime is the time the upload started.
+	StartTime string `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the backup completed or failed.
+	CompletionTime string `json:"completionTime,omitempty"`
+
+	// TotalNodes is the number of nodes being backed up.
+	TotalNodes int32 `json:"totalNodes,omitempty"`
+
+	// CompletedNodes is the number of nodes whose data was uploaded.
+	CompletedNodes int32 `json:"completedNodes,omitempty"`
+}
+
+// M3DBRestore creates an M3DB cluster from a backup, seeding its nodes'
+// volumes with the backed up data.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type M3DBRestore struct {
+	metav1.TypeMeta `json:",inline"`
+	// +k8s:openapi-gen=false
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              M3DBRestoreSpec   `json:"spec"`
+	Status            M3DBRestoreStatus `json:"status,omitempty"`
+}
+
+// M3DBRestoreList represents a list of M3DB restores.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:openapi-gen=true
+type M3DBRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []M3DBRestore `json:"items"`
+}
+
+// M3DBRestoreSpec defines the backup to restore and the cluster to restore it
+// to. A restore runs once; changes to the spec after it started are ignored.
+// +k8s:openapi-gen=true
+type M3DBRestoreSpec struct {
+	// BackupName is the name of the M3DBBackup, in the same Kubernetes
+	// namespace, to restore.
+	BackupName string `json:"backupName"`
+
+	// ClusterName is the name of the cluster to create. It must not exist.
+	// Defaults to the name of the backed up cluster.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// Image is the image of the jobs downloading the nodes' data. It must
+	// provide the MinIO client, mc, and a shell. Defaults to the backup's
+	// image.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// M3DBRestoreStatus contains the current state of a restore.
+// +k8s:openapi-gen=true
+type M3DBRestoreStatus struct {
+	// Phase is the phase of the restore.
+	Phase RestorePhase `json:"phase,omitempty"`
+
+	// Message is a human-friendly message about the phase.
+	Message string `json:"message,omitempty"`
+
+	// ClusterName is the name of the restored cluster.
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// StartTime is the time the restored cluster was created.
+	StartTime string `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the restore completed or failed.
+	CompletionTime string `json:"completionTime,omitempty"`
+
+	// TotalNodes is the number of nodes being seeded.
+	TotalNodes int32 `json:"totalNodes,omitempty"`
+
+	// SeededNodes is the number of nodes whose volume was seeded.
+	SeededNodes int32 `json:"seededNodes,omitempty"`
+}
+
+// IsFinished returns true if the backup completed or failed.
+func (s *M3DBBackupStatus) IsFinished() bool {
+	return s.Phase == BackupPhaseCompleted || s.Phase == BackupPhaseFailed
+}
+
+// IsFinished returns true if the restore completed or failed.
+func (s *M3DBRestoreStatus) IsFinished() bool {
+	return s.Phase == RestorePhaseCompleted || s.Phase == RestorePhaseFailed
+}
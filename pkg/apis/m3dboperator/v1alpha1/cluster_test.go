@@ -42,6 +42,10 @@ func TestStatus(t *testing.T) {
 			cond: ClusterConditionPodBootstrapping,
 			f:    func(s *M3DBStatus) bool { return s.HasPodBootstrapping() },
 		},
+		{
+			cond: ClusterConditionReady,
+			f:    func(s *M3DBStatus) bool { return s.IsReady() },
+		},
 	} {
 		t.Run(string(test.cond), func(t *testing.T) {
 			status := &M3DBStatus{}
@@ -157,3 +161,13 @@ func TestGetByName(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, a, g)
 }
+
+func TestNumInstances(t *testing.T) {
+	spec := &ClusterSpec{}
+	group := IsolationGroup{Name: "a", NumInstances: 3}
+	assert.Equal(t, int32(3), spec.NumInstances(group))
+
+	n := int32(5)
+	spec.InstancesPerIsolationGroup = &n
+	assert.Equal(t, int32(5), spec.NumInstances(group))
+}
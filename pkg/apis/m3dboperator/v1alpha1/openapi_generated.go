@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 // Copyright (c) 2019 Uber Technologies, Inc.
@@ -34,14 +35,38 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition": schema_pkg_apis_m3dboperator_v1alpha1_ClusterCondition(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterSpec":      schema_pkg_apis_m3dboperator_v1alpha1_ClusterSpec(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup":   schema_pkg_apis_m3dboperator_v1alpha1_IsolationGroup(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBCluster":      schema_pkg_apis_m3dboperator_v1alpha1_M3DBCluster(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBClusterList":  schema_pkg_apis_m3dboperator_v1alpha1_M3DBClusterList(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBStatus":       schema_pkg_apis_m3dboperator_v1alpha1_M3DBStatus(ref),
-		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NodeAffinityTerm": schema_pkg_apis_m3dboperator_v1alpha1_NodeAffinityTerm(ref),
-		"k8s.io/api/core/v1.AWSElasticBlockStoreVolumeSource":                           schema_k8sio_api_core_v1_AWSElasticBlockStoreVolumeSource(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAPISpec":        schema_pkg_apis_m3dboperator_v1alpha1_AdminAPISpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthHeader":     schema_pkg_apis_m3dboperator_v1alpha1_AdminAuthHeader(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthSpec":       schema_pkg_apis_m3dboperator_v1alpha1_AdminAuthSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminTLSSpec":        schema_pkg_apis_m3dboperator_v1alpha1_AdminTLSSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatedNamespace": schema_pkg_apis_m3dboperator_v1alpha1_AggregatedNamespace(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatorSpec":      schema_pkg_apis_m3dboperator_v1alpha1_AggregatorSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AutoReplaceSpec":     schema_pkg_apis_m3dboperator_v1alpha1_AutoReplaceSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition":    schema_pkg_apis_m3dboperator_v1alpha1_ClusterCondition(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterSpec":         schema_pkg_apis_m3dboperator_v1alpha1_ClusterSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.CoordinatorSpec":     schema_pkg_apis_m3dboperator_v1alpha1_CoordinatorSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup":      schema_pkg_apis_m3dboperator_v1alpha1_IsolationGroup(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackup":          schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackup(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupList":      schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupList(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupSpec":      schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupStatus":    schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupStatus(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBCluster":         schema_pkg_apis_m3dboperator_v1alpha1_M3DBCluster(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBClusterList":     schema_pkg_apis_m3dboperator_v1alpha1_M3DBClusterList(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespace":       schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespace(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceList":   schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceList(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceSpec":   schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceStatus": schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceStatus(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestore":         schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestore(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreList":     schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreList(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreSpec":     schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreStatus":   schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreStatus(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBStatus":          schema_pkg_apis_m3dboperator_v1alpha1_M3DBStatus(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NamespaceCondition":  schema_pkg_apis_m3dboperator_v1alpha1_NamespaceCondition(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NodeAffinityTerm":    schema_pkg_apis_m3dboperator_v1alpha1_NodeAffinityTerm(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ObjectStore":         schema_pkg_apis_m3dboperator_v1alpha1_ObjectStore(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.PortsSpec":           schema_pkg_apis_m3dboperator_v1alpha1_PortsSpec(ref),
+		"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ProbeOptions":        schema_pkg_apis_m3dboperator_v1alpha1_ProbeOptions(ref),
+		"k8s.io/api/core/v1.AWSElasticBlockStoreVolumeSource":                              schema_k8sio_api_core_v1_AWSElasticBlockStoreVolumeSource(ref),
 		"k8s.io/api/core/v1.Affinity":                                    schema_k8sio_api_core_v1_Affinity(ref),
 		"k8s.io/api/core/v1.AttachedVolume":                              schema_k8sio_api_core_v1_AttachedVolume(ref),
 		"k8s.io/api/core/v1.AvoidPods":                                   schema_k8sio_api_core_v1_AvoidPods(ref),
@@ -277,6 +302,264 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 	}
 }
 
+func schema_pkg_apis_m3dboperator_v1alpha1_AdminAPISpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AdminAPISpec configures how the operator connects to the coordinator admin API of a cluster. Secrets are read from the cluster's namespace, and changes to them are picked up on the next reconcile of the cluster.",
+				Properties: map[string]spec.Schema{
+					"tls": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TLS makes the operator call the admin API over HTTPS.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminTLSSpec"),
+						},
+					},
+					"auth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Auth configures the credentials sent with every admin API call.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthSpec"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminTLSSpec"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AdminAuthHeader(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AdminAuthHeader is a header of admin API calls whose value is read from a Secret.",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the header.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"valueFrom": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ValueFrom selects the key of a Secret holding the value of the header.",
+							Ref:         ref("k8s.io/api/core/v1.SecretKeySelector"),
+						},
+					},
+				},
+				Required: []string{"name", "valueFrom"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.SecretKeySelector"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AdminAuthSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AdminAuthSpec configures the credentials of the operator's admin API calls.",
+				Properties: map[string]spec.Schema{
+					"bearerTokenSecret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BearerTokenSecret selects the key of a Secret holding a token sent as a bearer token in the Authorization header.",
+							Ref:         ref("k8s.io/api/core/v1.SecretKeySelector"),
+						},
+					},
+					"headers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Headers are additional headers whose values are read from Secrets, for authentication schemes other than bearer tokens.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthHeader"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAuthHeader", "k8s.io/api/core/v1.SecretKeySelector"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AdminTLSSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AdminTLSSpec configures the TLS connections of the operator to the admin API.",
+				Properties: map[string]spec.Schema{
+					"caSecret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CASecret selects the key of a Secret holding the PEM encoded CA bundle the coordinator's certificate is verified with. Defaults to the system roots.",
+							Ref:         ref("k8s.io/api/core/v1.SecretKeySelector"),
+						},
+					},
+					"clientCertSecret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClientCertSecret is the name of a kubernetes.io/tls Secret holding the client certificate and key the operator presents to the coordinator.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"serverName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ServerName is the name the coordinator's certificate is verified against. Defaults to the host of the coordinator service.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.SecretKeySelector"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AggregatedNamespace(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AggregatedNamespace is an M3DB namespace storing metrics aggregated at a given resolution.",
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the M3DB namespace.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resolution": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Resolution is the resolution of the aggregated metrics, e.g. \"1m\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Retention is the retention of the namespace, e.g. \"720h\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "resolution", "retention"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AggregatorSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AggregatorSpec defines an m3aggregator cluster. Aggregators run as one StatefulSet per isolation group, and each shard set is mirrored across the isolation groups: the i-th pod of every group owns the same shards.",
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image specifies which docker image to use for the aggregators. Defaults to quay.io/m3db/m3aggregator:latest.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"numberOfShards": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NumberOfShards is the number of shards of the aggregator placement and of the m3msg topics feeding and draining the aggregators. Defaults to 64.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"replicationFactor": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicationFactor is the number of mirrors of each shard set. It must equal the number of isolation groups.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"isolationGroups": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IsolationGroups define the groups aggregators are spread across. Every group must have the same number of instances, which is the number of shard sets.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup"),
+									},
+								},
+							},
+						},
+					},
+					"namespaces": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespaces are the aggregated M3DB namespaces the aggregators write rollups to. Each must also be defined as a namespace of the cluster.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatedNamespace"),
+									},
+								},
+							},
+						},
+					},
+					"configMapName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConfigMapName specifies the ConfigMap to use for the aggregators. If unset a default configmap will be used.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"containerResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainerResources defines memory / cpu constraints for each aggregator container.",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+				},
+				Required: []string{"replicationFactor", "isolationGroups"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatedNamespace", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup", "k8s.io/api/core/v1.ResourceRequirements"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_AutoReplaceSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "AutoReplaceSpec configures the replacement of failed M3DB pods. A pod has failed if its node is NotReady or has been deleted, if it's stuck in the Unknown phase, or if it's stuck Pending because its local volume is pinned to a node it can't run on, e.g. after being evicted from a drained node. Failed pods are deleted so that their StatefulSet recreates them, and each new pod then replaces the failed pod's instance in the placement if its identity differs.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"gracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GracePeriodSeconds is how long a pod must have failed for before it's replaced. Defaults to 600.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"deletePersistentVolumeClaim": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletePersistentVolumeClaim deletes the data volume claim of a failed pod along with the pod, so that its replacement gets a new volume. Required to replace pods whose volumes are local to a failed node.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_m3dboperator_v1alpha1_ClusterCondition(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -373,6 +656,13 @@ func schema_pkg_apis_m3dboperator_v1alpha1_ClusterSpec(ref common.ReferenceCallb
 							},
 						},
 					},
+					"instancesPerIsolationGroup": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InstancesPerIsolationGroup, if set, overrides the numInstances of every isolation group. It's the field the cluster's scale subresource changes, so that the cluster can be scaled with kubectl scale or a HorizontalPodAutoscaler.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"namespaces": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Namespaces specifies the namespaces this cluster will hold.",
@@ -400,6 +690,13 @@ func schema_pkg_apis_m3dboperator_v1alpha1_ClusterSpec(ref common.ReferenceCallb
 							},
 						},
 					},
+					"serviceZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ServiceZone is the zone of the cluster's M3 KV service. It selects the etcd cluster of the default configmaps and is the default zone of the cluster's placement instances. Defaults to \"embedded\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"keepEtcdDataOnDelete": {
 						SchemaProps: spec.SchemaProps{
 							Description: "KeepEtcdDataOnDelete determines whether the operator will remove cluster metadata (placement + namespaces) in etcd when the cluster is deleted. Unless true, etcd data will be cleared when the cluster is deleted.",
@@ -494,64 +791,341 @@ func schema_pkg_apis_m3dboperator_v1alpha1_ClusterSpec(ref common.ReferenceCallb
 							Format:      "",
 						},
 					},
+					"probeOptions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ProbeOptions configures the liveness and readiness probes of M3DB pods.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ProbeOptions"),
+						},
+					},
+					"coordinator": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Coordinator, if set, runs m3coordinator as a Deployment separate from the cluster's M3DB nodes rather than embedded in every node. The operator then sends its own admin calls to the separate coordinators.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.CoordinatorSpec"),
+						},
+					},
+					"aggregator": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Aggregator, if set, runs an m3aggregator cluster alongside the M3DB nodes and sets up the aggregator placement and the m3msg topics connecting it to the coordinators. Requires the coordinator section to be set.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatorSpec"),
+						},
+					},
+					"autoReplace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoReplace, if set, has the operator replace instances whose pods have failed, e.g. because their node is NotReady or their local volume is gone, once they've been failed for the configured grace period.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AutoReplaceSpec"),
+						},
+					},
+					"ports": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ports configures the ports M3DB nodes and coordinators listen on, and the address they listen on them. Defaults to the standard M3 ports on all addresses.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.PortsSpec"),
+						},
+					},
+					"adminAPI": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AdminAPI configures TLS and authentication for the operator's calls to the coordinator admin API of the cluster. Calls are made over plain HTTP without credentials if unset.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAPISpec"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.Namespace", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.PodIdentityConfig", "k8s.io/api/core/v1.PersistentVolumeClaim", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration"},
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AdminAPISpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AggregatorSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.AutoReplaceSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.CoordinatorSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.IsolationGroup", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.Namespace", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.PodIdentityConfig", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.PortsSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ProbeOptions", "k8s.io/api/core/v1.PersistentVolumeClaim", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration"},
 	}
 }
 
-func schema_pkg_apis_m3dboperator_v1alpha1_IsolationGroup(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_m3dboperator_v1alpha1_CoordinatorSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "IsolationGroup defines the name of zone as well attributes for the zone configuration",
-				Type:        []string{"object"},
+				Description: "CoordinatorSpec defines an m3coordinator Deployment run separately from the cluster's M3DB nodes, so that query and write traffic scale separately from storage.",
 				Properties: map[string]spec.Schema{
-					"name": {
+					"image": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name is the value that will be used in StatefulSet labels, pod labels, and M3DB placement \"isolationGroup\" fields.",
+							Description: "Image specifies which docker image to use for the coordinators. Defaults to quay.io/m3db/m3coordinator:latest.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"nodeAffinityTerms": {
-						SchemaProps: spec.SchemaProps{
-							Description: "NodeAffinityTerms is an array of NodeAffinityTerm requirements, which are ANDed together to indicate what nodes an isolation group can be assigned to.",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NodeAffinityTerm"),
-									},
-								},
-							},
-						},
-					},
-					"numInstances": {
+					"replicas": {
 						SchemaProps: spec.SchemaProps{
-							Description: "NumInstances defines the number of instances.",
+							Description: "Replicas is the number of coordinator instances. Defaults to 1.",
 							Type:        []string{"integer"},
 							Format:      "int32",
 						},
 					},
-					"storageClassName": {
+					"configMapName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "StorageClassName is the name of the StorageClass to use for this isolation group. This allows ensuring that PVs will be created in the same zone as the pinned statefulset on Kubernetes < 1.12 (when topology aware volume scheduling was introduced). Only has effect if the clusters `dataDirVolumeClaimTemplate` is non-nil. If set, the volume claim template will have its storageClassName field overridden per-isolationgroup. If unset the storageClassName of the volumeClaimTemplate will be used.",
+							Description: "ConfigMapName specifies the ConfigMap to use for the coordinators. If unset a default configmap pointing the coordinators at the cluster's etcd endpoints will be used.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-				},
-				Required: []string{"name", "numInstances"},
-			},
+					"containerResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContainerResources defines memory / cpu constraints for each coordinator container.",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.ResourceRequirements"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_IsolationGroup(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "IsolationGroup defines the name of zone as well attributes for the zone configuration",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the value that will be used in StatefulSet labels, pod labels, and M3DB placement \"isolationGroup\" fields.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"nodeAffinityTerms": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NodeAffinityTerms is an array of NodeAffinityTerm requirements, which are ANDed together to indicate what nodes an isolation group can be assigned to.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NodeAffinityTerm"),
+									},
+								},
+							},
+						},
+					},
+					"numInstances": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NumInstances defines the number of instances. Overridden by the cluster's instancesPerIsolationGroup if set.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"storageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StorageClassName is the name of the StorageClass to use for this isolation group. This allows ensuring that PVs will be created in the same zone as the pinned statefulset on Kubernetes < 1.12 (when topology aware volume scheduling was introduced). Only has effect if the clusters `dataDirVolumeClaimTemplate` is non-nil. If set, the volume claim template will have its storageClassName field overridden per-isolationgroup. If unset the storageClassName of the volumeClaimTemplate will be used.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"zone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Zone is the zone of the group's placement instances. Defaults to the cluster's serviceZone.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"weight": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Weight is the weight of the group's placement instances, shards are spread across instances in proportion to their weights. Defaults to 100.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+				Required: []string{"name", "numInstances"},
+			},
 		},
 		Dependencies: []string{
 			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NodeAffinityTerm"},
 	}
 }
 
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackup(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBBackup is a backup of the data of an M3DB cluster's nodes to an S3-compatible object store.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupStatus"),
+						},
+					},
+				},
+				Required: []string{"spec"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackupStatus"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBBackupList represents a list of M3DB backups.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackup"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackup", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBBackupSpec defines the cluster to back up and where to. A backup is taken once; changes to the spec after it started are ignored.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the M3DBCluster, in the same Kubernetes namespace, to back up.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"store": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Store is the object store the backup is uploaded to.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ObjectStore"),
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image is the image of the jobs uploading the nodes' data. It must provide the MinIO client, mc, and a shell. Defaults to minio/mc:latest.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"clusterName", "store"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ObjectStore"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBBackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBBackupStatus contains the current state of a backup.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase is the phase of the backup.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human-friendly message about the phase.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"location": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Location is the URL of the backup in the object store, e.g. s3://bucket/prefix/namespace/backup.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartTime is the time the upload started.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletionTime is the time the backup completed or failed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"totalNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TotalNodes is the number of nodes being backed up.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"completedNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletedNodes is the number of nodes whose data was uploaded.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
 func schema_pkg_apis_m3dboperator_v1alpha1_M3DBCluster(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -645,65 +1219,499 @@ func schema_pkg_apis_m3dboperator_v1alpha1_M3DBClusterList(ref common.ReferenceC
 	}
 }
 
-func schema_pkg_apis_m3dboperator_v1alpha1_M3DBStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespace(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "M3DBStatus contains the current state the M3DB cluster along with a human readable message",
+				Description: "M3DBNamespace defines an M3DB namespace in an M3DB cluster, allowing namespaces to be managed independently of the cluster's spec.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"state": {
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "State is a enum of green, yellow, and red denoting the health of the cluster",
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"conditions": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Various conditions about the cluster.",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition"),
-									},
-								},
-							},
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"message": {
+					"spec": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Message is a human readable message indicating why the cluster is in it's current state",
-							Type:        []string{"string"},
-							Format:      "",
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceSpec"),
 						},
 					},
-					"observedGeneration": {
+					"status": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ObservedGeneration is the last generation of the cluster the controller observed. Kubernetes will automatically increment metadata.Generation every time the cluster spec is changed.",
-							Type:        []string{"integer"},
-							Format:      "int64",
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceStatus"),
 						},
 					},
 				},
+				Required: []string{"spec"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition"},
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespaceStatus"},
 	}
 }
 
-func schema_pkg_apis_m3dboperator_v1alpha1_NodeAffinityTerm(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceList(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "NodeAffinityTerm represents a node label and a set of label values, any of which can be matched to assign a pod to a node.",
+				Description: "M3DBNamespaceList represents a list of M3DB namespaces.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"key": {
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Key is the label of the node.",
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespace"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespace", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBNamespaceSpec defines the desired state of an M3DB namespace.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the M3DBCluster, in the same Kubernetes namespace, that the namespace belongs to.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the namespace in M3DB. Defaults to the name of the M3DBNamespace object; set it if the M3DB namespace name is not a valid Kubernetes object name, such as metrics-10s:2d.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"preset": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Preset indicates preset namespace options.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"options": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Options points to optional custom namespace configuration.",
+							Ref:         ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NamespaceOptions"),
+						},
+					},
+				},
+				Required: []string{"clusterName"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NamespaceOptions"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBNamespaceStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBNamespaceStatus contains the current state of an M3DB namespace.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Various conditions about the namespace.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NamespaceCondition"),
+									},
+								},
+							},
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the last generation of the namespace the controller observed.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.NamespaceCondition"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestore(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBRestore creates an M3DB cluster from a backup, seeding its nodes' volumes with the backed up data.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreStatus"),
+						},
+					},
+				},
+				Required: []string{"spec"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreSpec", "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestoreStatus"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBRestoreList represents a list of M3DB restores.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestore"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"items"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestore", "k8s.io/apimachinery/pkg/apis/meta/v1.ListMeta"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBRestoreSpec defines the backup to restore and the cluster to restore it to. A restore runs once; changes to the spec after it started are ignored.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"backupName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BackupName is the name of the M3DBBackup, in the same Kubernetes namespace, to restore.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the cluster to create. It must not exist. Defaults to the name of the backed up cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image is the image of the jobs downloading the nodes' data. It must provide the MinIO client, mc, and a shell. Defaults to the backup's image.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"backupName"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBRestoreStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBRestoreStatus contains the current state of a restore.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase is the phase of the restore.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human-friendly message about the phase.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"clusterName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterName is the name of the restored cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartTime is the time the restored cluster was created.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletionTime is the time the restore completed or failed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"totalNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TotalNodes is the number of nodes being seeded.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"seededNodes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SeededNodes is the number of nodes whose volume was seeded.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_M3DBStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "M3DBStatus contains the current state the M3DB cluster along with a human readable message",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"state": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State is a enum of green, yellow, and red denoting the health of the cluster",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Various conditions about the cluster.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition"),
+									},
+								},
+							},
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Message is a human readable message indicating why the cluster is in it's current state",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the last generation of the cluster the controller observed. Kubernetes will automatically increment metadata.Generation every time the cluster spec is changed.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replicas is the number of instances per isolation group the cluster currently runs, in its smallest isolation group. It's the replica count reported by the cluster's scale subresource.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"readyInstances": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReadyInstances is the number of ready M3DB pods across all isolation groups.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"replicationFactor": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicationFactor is the replication factor of the cluster's placement. When the spec's replication factor is increased, it's only updated once every replica of the new replication factor is available.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"labelSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LabelSelector selects the cluster's M3DB pods. It's the selector reported by the cluster's scale subresource.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.ClusterCondition"},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_NamespaceCondition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NamespaceCondition represents various conditions the namespace can be in.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of namespace condition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the condition (True, False, Unknown).",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastUpdateTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Last time this condition was updated.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Last time this condition transitioned from one status to another.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason this condition last changed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Human-friendly message about this condition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_NodeAffinityTerm(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "NodeAffinityTerm represents a node label and a set of label values, any of which can be matched to assign a pod to a node.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Key is the label of the node.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -729,6 +1737,159 @@ func schema_pkg_apis_m3dboperator_v1alpha1_NodeAffinityTerm(ref common.Reference
 	}
 }
 
+func schema_pkg_apis_m3dboperator_v1alpha1_ObjectStore(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ObjectStore defines an S3-compatible object store, such as MinIO or S3.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"endpoint": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Endpoint is the host and optional port of the store, e.g. minio.minio:9000 or s3.amazonaws.com.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"insecure": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Insecure connects to the store over plain HTTP rather than HTTPS.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"bucket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Bucket is the bucket backups are stored in.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"prefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prefix is prepended to the keys of every backup in the bucket.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CredentialsSecretName is the name of a secret, in the same Kubernetes namespace, holding the store's access key and secret key under the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"endpoint", "bucket", "credentialsSecretName"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_PortsSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PortsSpec configures the ports of M3DB nodes and coordinators. Unset ports default to the standard M3 ports. The ports flow into the pods' container ports, probes and default configs, the cluster's Services, the placement endpoints of M3DB instances and the operator's calls to the coordinator.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"listenAddress": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ListenAddress is the IP address the ports are bound on. Defaults to 0.0.0.0.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"client": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Client is the port M3DB serves clients and peers on, also the endpoint of its placement instance. Defaults to 9000.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"cluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Cluster is the cluster port of M3DB. Defaults to 9001.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"httpNode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTPNode is the HTTP port of M3DB, which serves the health checks. Defaults to 9002.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"httpCluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTPCluster is the HTTP cluster port of M3DB. Defaults to 9003.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"debug": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Debug is the debug port of M3DB. Defaults to 9004.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"coordinator": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Coordinator is the API port of the coordinator, whether embedded in M3DB nodes or run separately. Defaults to 7201.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"coordinatorMetrics": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CoordinatorMetrics is the metrics port of the coordinator. Defaults to 7203.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_m3dboperator_v1alpha1_ProbeOptions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ProbeOptions configures the liveness and readiness probes of M3DB pods.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"timeoutSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeoutSeconds is the number of seconds after which a probe times out.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"initialDelaySeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "InitialDelaySeconds is the number of seconds after a container has started before probes are initiated.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failureThreshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailureThreshold is the number of consecutive failures after which a probe is considered failed.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_k8sio_api_core_v1_AWSElasticBlockStoreVolumeSource(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -59,6 +59,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&M3DBCluster{},
 		&M3DBClusterList{},
+		&M3DBNamespace{},
+		&M3DBNamespaceList{},
+		&M3DBBackup{},
+		&M3DBBackupList{},
+		&M3DBRestore{},
+		&M3DBRestoreList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
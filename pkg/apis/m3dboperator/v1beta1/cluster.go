@@ -0,0 +1,616 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterConditionType represents the various type of cluster conditions.
+type ClusterConditionType string
+
+// IsolationGroups is a slice of IsolationGroup. IsolationGroups satisfies the
+// sort.Sort interface, sorting by name.
+type IsolationGroups []IsolationGroup
+
+func (g IsolationGroups) Len() int           { return len(g) }
+func (g IsolationGroups) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+func (g IsolationGroups) Less(i, j int) bool { return g[i].Name < g[j].Name }
+
+const (
+	// ClusterConditionPlacementInitialized indicates an initial placement has
+	// been created for the cluster.
+	ClusterConditionPlacementInitialized ClusterConditionType = "PlacementInitialized"
+
+	// ClusterConditionPodBootstrapping indicates there is a pod bootstrapping.
+	ClusterConditionPodBootstrapping ClusterConditionType = "PodBootstrapping"
+
+	// ClusterConditionReady indicates every shard in the cluster has a majority
+	// of its replicas available, i.e. the cluster can serve reads and writes.
+	ClusterConditionReady ClusterConditionType = "Ready"
+
+	// ClusterConditionProgressing indicates the operator is working towards the
+	// cluster spec: StatefulSets are being created, resized or updated, or
+	// instances are bootstrapping.
+	ClusterConditionProgressing ClusterConditionType = "Progressing"
+
+	// ClusterConditionDegraded indicates the cluster is running with reduced
+	// redundancy, i.e. some shards have fewer available replicas than the
+	// replication factor.
+	ClusterConditionDegraded ClusterConditionType = "Degraded"
+
+	// ClusterConditionNamespaceUpdateRejected indicates the spec of one or more
+	// namespaces changes options that can't be changed once the namespace has
+	// been created (such as its block size).
+	ClusterConditionNamespaceUpdateRejected ClusterConditionType = "NamespaceUpdateRejected"
+
+	// ClusterConditionIsolationGroupDecommissioning indicates an isolation group
+	// removed from the spec is being drained from the placement before its
+	// StatefulSet, volumes and pod disruption budget are deleted.
+	ClusterConditionIsolationGroupDecommissioning ClusterConditionType = "IsolationGroupDecommissioning"
+
+	// ClusterConditionReplicationFactorChanging indicates the replication
+	// factor of the cluster's spec differs from its placement's, and a replica
+	// of every shard is being added to the placement.
+	ClusterConditionReplicationFactorChanging ClusterConditionType = "ReplicationFactorChanging"
+)
+
+// M3DBCluster defines the cluster
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type M3DBCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Type              string      `json:"type"`
+	Spec              ClusterSpec `json:"spec"`
+	Status            M3DBStatus  `json:"status,omitempty"`
+}
+
+// M3DBClusterList represents a list of M3DB Clusters
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type M3DBClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []M3DBCluster `json:"items"`
+}
+
+// M3DBStatus contains the current state the M3DB cluster along with a human
+// readable message
+type M3DBStatus struct {
+	// State is a enum of green, yellow, and red denoting the health of the
+	// cluster
+	State M3DBState `json:"state,omitempty"`
+
+	// Various conditions about the cluster.
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	// Message is a human readable message indicating why the cluster is in it's
+	// current state
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the last generation of the cluster the controller
+	// observed. Kubernetes will automatically increment metadata.Generation every
+	// time the cluster spec is changed.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Replicas is the number of instances per isolation group the cluster
+	// currently runs, in its smallest isolation group. It's the replica count
+	// reported by the cluster's scale subresource.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyInstances is the number of ready M3DB pods across all isolation
+	// groups.
+	ReadyInstances int32 `json:"readyInstances,omitempty"`
+
+	// ReplicationFactor is the replication factor of the cluster's placement.
+	// When the spec's replication factor is increased, it's only updated once
+	// every replica of the new replication factor is available.
+	ReplicationFactor int32 `json:"replicationFactor,omitempty"`
+
+	// LabelSelector selects the cluster's M3DB pods. It's the selector reported
+	// by the cluster's scale subresource.
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+func (s *M3DBStatus) hasConditionTrue(cond ClusterConditionType) bool {
+	for _, c := range s.Conditions {
+		if c.Type == cond && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// HasInitializedPlacement returns true if the conditions indicate an initial
+// placement has been created.
+func (s *M3DBStatus) HasInitializedPlacement() bool {
+	return s.hasConditionTrue(ClusterConditionPlacementInitialized)
+}
+
+// HasPodBootstrapping returns true if conditions indicate a pod is currently
+// bootstrapping.
+func (s *M3DBStatus) HasPodBootstrapping() bool {
+	return s.hasConditionTrue(ClusterConditionPodBootstrapping)
+}
+
+// IsReady returns true if conditions indicate the cluster is ready.
+func (s *M3DBStatus) IsReady() bool {
+	return s.hasConditionTrue(ClusterConditionReady)
+}
+
+// GetCondition returns the specified cluster condition if it exists with a bool
+// indicating whether it was found.
+func (s *M3DBStatus) GetCondition(checkCond ClusterConditionType) (ClusterCondition, bool) {
+	for _, cond := range s.Conditions {
+		if cond.Type == checkCond {
+			return cond, true
+		}
+	}
+	return ClusterCondition{}, false
+}
+
+// UpdateCondition updates one of the status's conditions, replacing the state
+// of cond.Type if it exists or adding the condition if it doesn't exist.
+func (s *M3DBStatus) UpdateCondition(newCond ClusterCondition) {
+	for i, cond := range s.Conditions {
+		if cond.Type == newCond.Type {
+			s.Conditions[i] = newCond
+			return
+		}
+	}
+
+	s.Conditions = append(s.Conditions, newCond)
+}
+
+// ClusterCondition represents various conditions the cluster can be in.
+type ClusterCondition struct {
+	// Type of cluster condition.
+	Type ClusterConditionType `json:"type,omitempty"`
+
+	// Status of the condition (True, False, Unknown).
+	Status corev1.ConditionStatus `json:"status,omitempty"`
+
+	// Last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// Last time this condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason this condition last changed.
+	Reason string `json:"reason,omitempty"`
+
+	// Human-friendly message about this condition.
+	Message string `json:"message,omitempty"`
+}
+
+// M3DBState contains the state of the M3DB cluster
+type M3DBState string
+
+const (
+	// GreenState indicates a healthy state of the M3DB cluster
+	GreenState M3DBState = "green"
+
+	// YellowState indicates a caution state of the M3DB cluster
+	YellowState M3DBState = "yellow"
+
+	// RedState indicates a critical state of the M3DB cluster
+	RedState M3DBState = "red"
+)
+
+// ClusterSpec defines the desired state for a M3 cluster to be converge to.
+type ClusterSpec struct {
+	// Image specifies which docker image to use with the cluster
+	Image string `json:"image,omitempty"`
+
+	// ReplicationFactor defines how many replicas
+	ReplicationFactor int32 `json:"replicationFactor,omitempty"`
+
+	// NumberOfShards defines how many shards in total
+	NumberOfShards int32 `json:"numberOfShards,omitempty"`
+
+	// IsolationGroups specifies a map of key-value pairs. Defines which isolation groups
+	// to deploy persistent volumes for data nodes
+	IsolationGroups []IsolationGroup `json:"isolationGroups,omitempty"`
+
+	// InstancesPerIsolationGroup, if set, overrides the numInstances of every
+	// isolation group. It's the field the cluster's scale subresource changes, so
+	// that the cluster can be scaled with kubectl scale or a
+	// HorizontalPodAutoscaler.
+	// +optional
+	InstancesPerIsolationGroup *int32 `json:"instancesPerIsolationGroup,omitempty"`
+
+	// Namespaces specifies the namespaces this cluster will hold.
+	Namespaces []Namespace `json:"namespaces,omitempty"`
+
+	// EtcdEndpoints defines the etcd endpoints to use for service discovery. Must
+	// be set if no custom configmap is defined. If set, etcd endpoints will be
+	// templated in to the default configmap template.
+	// +optional
+	EtcdEndpoints []string `json:"etcdEndpoints,omitempty"`
+
+	// ServiceZone is the zone of the cluster's M3 KV service. It selects the etcd
+	// cluster of the default configmaps and is the default zone of the
+	// cluster's placement instances. Defaults to "embedded".
+	// +optional
+	ServiceZone string `json:"serviceZone,omitempty"`
+
+	// KeepEtcdDataOnDelete determines whether the operator will remove cluster
+	// metadata (placement + namespaces) in etcd when the cluster is deleted.
+	// Unless true, etcd data will be cleared when the cluster is deleted.
+	// +optional
+	KeepEtcdDataOnDelete bool `json:"keepEtcdDataOnDelete,omitempty"`
+
+	// ConfigMapRef references the ConfigMap, in the cluster's namespace, to use
+	// for this cluster. If unset a default configmap with template variables for
+	// etcd endpoints will be used. See "Configuring M3DB" in the docs for more.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// PodIdentityConfig sets the configuration for pod identity. If unset only
+	// pod name and UID will be used.
+	// +optional
+	PodIdentityConfig *PodIdentityConfig `json:"podIdentityConfig,omitempty"`
+
+	// Resources defines memory / cpu constraints for each container in the
+	// cluster.
+	// +optional
+	ContainerResources corev1.ResourceRequirements `json:"containerResources,omitempty"`
+
+	// DataDirVolumeClaimTemplate is the volume claim template for an M3DB
+	// instance's data. It claims PersistentVolumes for cluster storage, volumes
+	// are dynamically provisioned by when the StorageClass is defined.
+	// +optional
+	DataDirVolumeClaimTemplate *corev1.PersistentVolumeClaim `json:"dataDirVolumeClaimTemplate,omitempty"`
+
+	// PodSecurityContext allows the user to specify an optional security context
+	// for pods.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// SecurityContext allows the user to specify a container-level security
+	// context.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Labels sets the base labels that will be applied to resources created by
+	// the cluster. // TODO(schallert): design doc on labeling scheme.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations sets the base annotations that will be applied to resources created by
+	// the cluster.
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations"`
+
+	// Tolerations sets the tolerations that will be applied to all M3DB pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName sets the priority class for all M3DB pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ProbeOptions configures the liveness and readiness probes of M3DB pods.
+	// +optional
+	ProbeOptions *ProbeOptions `json:"probeOptions,omitempty"`
+
+	// Coordinator, if set, runs m3coordinator as a Deployment separate from the
+	// cluster's M3DB nodes rather than embedded in every node. The operator
+	// then sends its own admin calls to the separate coordinators.
+	// +optional
+	Coordinator *CoordinatorSpec `json:"coordinator,omitempty"`
+
+	// Aggregator, if set, runs an m3aggregator cluster alongside the M3DB nodes
+	// and sets up the aggregator placement and the m3msg topics connecting it
+	// to the coordinators. Requires the coordinator section to be set.
+	// +optional
+	Aggregator *AggregatorSpec `json:"aggregator,omitempty"`
+
+	// AutoReplace, if set, has the operator replace instances whose pods have
+	// failed, e.g. because their node is NotReady or their local volume is
+	// gone, once they've been failed for the configured grace period.
+	// +optional
+	AutoReplace *AutoReplaceSpec `json:"autoReplace,omitempty"`
+
+	// Ports configures the ports M3DB nodes and coordinators listen on, and the
+	// address they listen on them. Defaults to the standard M3 ports on all
+	// addresses.
+	// +optional
+	Ports *PortsSpec `json:"ports,omitempty"`
+
+	// AdminAPI configures TLS and authentication for the operator's calls to the
+	// coordinator admin API of the cluster. Calls are made over plain HTTP
+	// without credentials if unset.
+	// +optional
+	AdminAPI *AdminAPISpec `json:"adminAPI,omitempty"`
+}
+
+// NumInstances returns the number of instances the isolation group should run.
+func (s *ClusterSpec) NumInstances(group IsolationGroup) int32 {
+	if s.InstancesPerIsolationGroup != nil {
+		return *s.InstancesPerIsolationGroup
+	}
+	return group.NumInstances
+}
+
+// CoordinatorSpec defines an m3coordinator Deployment run separately from the
+// cluster's M3DB nodes, so that query and write traffic scale separately from
+// storage.
+type CoordinatorSpec struct {
+	// Image specifies which docker image to use for the coordinators. Defaults
+	// to quay.io/m3db/m3coordinator:latest.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of coordinator instances. Defaults to 1.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ConfigMapName specifies the ConfigMap to use for the coordinators. If
+	// unset a default configmap pointing the coordinators at the cluster's etcd
+	// endpoints will be used.
+	// +optional
+	ConfigMapName *string `json:"configMapName,omitempty"`
+
+	// ContainerResources defines memory / cpu constraints for each coordinator
+	// container.
+	// +optional
+	ContainerResources corev1.ResourceRequirements `json:"containerResources,omitempty"`
+}
+
+// AggregatorSpec defines an m3aggregator cluster. Aggregators run as one
+// StatefulSet per isolation group, and each shard set is mirrored across the
+// isolation groups: the i-th pod of every group owns the same shards.
+type AggregatorSpec struct {
+	// Image specifies which docker image to use for the aggregators. Defaults
+	// to quay.io/m3db/m3aggregator:latest.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// NumberOfShards is the number of shards of the aggregator placement and
+	// of the m3msg topics feeding and draining the aggregators. Defaults to 64.
+	// +optional
+	NumberOfShards int32 `json:"numberOfShards,omitempty"`
+
+	// ReplicationFactor is the number of mirrors of each shard set. It must
+	// equal the number of isolation groups.
+	ReplicationFactor int32 `json:"replicationFactor"`
+
+	// IsolationGroups define the groups aggregators are spread across. Every
+	// group must have the same number of instances, which is the number of
+	// shard sets.
+	IsolationGroups IsolationGroups `json:"isolationGroups"`
+
+	// Namespaces are the aggregated M3DB namespaces the aggregators write
+	// rollups to. Each must also be defined as a namespace of the cluster.
+	// +optional
+	Namespaces []AggregatedNamespace `json:"namespaces,omitempty"`
+
+	// ConfigMapName specifies the ConfigMap to use for the aggregators. If
+	// unset a default configmap will be used.
+	// +optional
+	ConfigMapName *string `json:"configMapName,omitempty"`
+
+	// ContainerResources defines memory / cpu constraints for each aggregator
+	// container.
+	// +optional
+	ContainerResources corev1.ResourceRequirements `json:"containerResources,omitempty"`
+}
+
+// AggregatedNamespace is an M3DB namespace storing metrics aggregated at a
+// given resolution.
+type AggregatedNamespace struct {
+	// Name is the name of the M3DB namespace.
+	Name string `json:"name"`
+
+	// Resolution is the resolution of the aggregated metrics, e.g. "1m".
+	Resolution string `json:"resolution"`
+
+	// Retention is the retention of the namespace, e.g. "720h".
+	Retention string `json:"retention"`
+}
+
+// AutoReplaceSpec configures the replacement of failed M3DB pods. A pod has
+// failed if its node is NotReady or has been deleted, if it's stuck in the
+// Unknown phase, or if it's stuck Pending because its local volume is pinned to
+// a node it can't run on, e.g. after being evicted from a drained node. Failed
+// pods are deleted so that their StatefulSet recreates them, and each new pod
+// then replaces the failed pod's instance in the placement if its identity
+// differs.
+type AutoReplaceSpec struct {
+	// GracePeriodSeconds is how long a pod must have failed for before it's
+	// replaced. Defaults to 600.
+	// +optional
+	GracePeriodSeconds int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// DeletePersistentVolumeClaim deletes the data volume claim of a failed pod
+	// along with the pod, so that its replacement gets a new volume. Required
+	// to replace pods whose volumes are local to a failed node.
+	// +optional
+	DeletePersistentVolumeClaim bool `json:"deletePersistentVolumeClaim,omitempty"`
+}
+
+// PortsSpec configures the ports of M3DB nodes and coordinators. Unset ports
+// default to the standard M3 ports. The ports flow into the pods' container
+// ports, probes and default configs, the cluster's Services, the placement
+// endpoints of M3DB instances and the operator's calls to the coordinator.
+type PortsSpec struct {
+	// ListenAddress is the IP address the ports are bound on. Defaults to
+	// 0.0.0.0.
+	// +optional
+	ListenAddress string `json:"listenAddress,omitempty"`
+
+	// Client is the port M3DB serves clients and peers on, also the endpoint of
+	// its placement instance. Defaults to 9000.
+	// +optional
+	Client int32 `json:"client,omitempty"`
+
+	// Cluster is the cluster port of M3DB. Defaults to 9001.
+	// +optional
+	Cluster int32 `json:"cluster,omitempty"`
+
+	// HTTPNode is the HTTP port of M3DB, which serves the health checks.
+	// Defaults to 9002.
+	// +optional
+	HTTPNode int32 `json:"httpNode,omitempty"`
+
+	// HTTPCluster is the HTTP cluster port of M3DB. Defaults to 9003.
+	// +optional
+	HTTPCluster int32 `json:"httpCluster,omitempty"`
+
+	// Debug is the debug port of M3DB. Defaults to 9004.
+	// +optional
+	Debug int32 `json:"debug,omitempty"`
+
+	// Coordinator is the API port of the coordinator, whether embedded in M3DB
+	// nodes or run separately. Defaults to 7201.
+	// +optional
+	Coordinator int32 `json:"coordinator,omitempty"`
+
+	// CoordinatorMetrics is the metrics port of the coordinator. Defaults to
+	// 7203.
+	// +optional
+	CoordinatorMetrics int32 `json:"coordinatorMetrics,omitempty"`
+}
+
+// ProbeOptions configures the liveness and readiness probes of M3DB pods.
+type ProbeOptions struct {
+	// TimeoutSeconds is the number of seconds after which a probe times out.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// InitialDelaySeconds is the number of seconds after a container has
+	// started before probes are initiated.
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures after which a
+	// probe is considered failed.
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// NodeAffinityTerm represents a node label and a set of label values, any of
+// which can be matched to assign a pod to a node.
+type NodeAffinityTerm struct {
+	// Key is the label of the node.
+	Key string `json:"key"`
+
+	// Values is an array of values, any of which a node can have for a pod to be
+	// assigned to it.
+	Values []string `json:"values"`
+}
+
+// IsolationGroup defines the name of zone as well attributes for the zone configuration
+type IsolationGroup struct {
+	// Name is the value that will be used in StatefulSet labels, pod labels, and
+	// M3DB placement "isolationGroup" fields.
+	Name string `json:"name"`
+
+	// NodeAffinityTerms is an array of NodeAffinityTerm requirements, which are
+	// ANDed together to indicate what nodes an isolation group can be assigned
+	// to.
+	NodeAffinityTerms []NodeAffinityTerm `json:"nodeAffinityTerms,omitempty"`
+
+	// NumInstances defines the number of instances. Overridden by the cluster's
+	// instancesPerIsolationGroup if set.
+	NumInstances int32 `json:"numInstances"`
+
+	// StorageClassName is the name of the StorageClass to use for this isolation
+	// group. This allows ensuring that PVs will be created in the same zone as
+	// the pinned statefulset on Kubernetes < 1.12 (when topology aware volume
+	// scheduling was introduced). Only has effect if the clusters
+	// `dataDirVolumeClaimTemplate` is non-nil. If set, the volume claim template
+	// will have its storageClassName field overridden per-isolationgroup. If
+	// unset the storageClassName of the volumeClaimTemplate will be used.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Zone is the zone of the group's placement instances. Defaults to the
+	// cluster's serviceZone.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+
+	// Weight is the weight of the group's placement instances, shards are
+	// spread across instances in proportion to their weights. Defaults to 100.
+	// +optional
+	Weight uint32 `json:"weight,omitempty"`
+}
+
+// GetByName fetches an IsolationGroup by name.
+func (g IsolationGroups) GetByName(name string) (IsolationGroup, bool) {
+	for _, group := range g {
+		if group.Name == name {
+			return group, true
+		}
+	}
+	return IsolationGroup{}, false
+}
+
+// AdminAPISpec configures how the operator connects to the coordinator admin
+// API of a cluster. Secrets are read from the cluster's namespace, and changes
+// to them are picked up on the next reconcile of the cluster.
+type AdminAPISpec struct {
+	// TLS makes the operator call the admin API over HTTPS.
+	// +optional
+	TLS *AdminTLSSpec `json:"tls,omitempty"`
+
+	// Auth configures the credentials sent with every admin API call.
+	// +optional
+	Auth *AdminAuthSpec `json:"auth,omitempty"`
+}
+
+// AdminTLSSpec configures the TLS connections of the operator to the admin
+// API.
+type AdminTLSSpec struct {
+	// CASecret selects the key of a Secret holding the PEM encoded CA bundle
+	// the coordinator's certificate is verified with. Defaults to the system
+	// roots.
+	// +optional
+	CASecret *corev1.SecretKeySelector `json:"caSecret,omitempty"`
+
+	// ClientCertSecret is the name of a kubernetes.io/tls Secret holding the
+	// client certificate and key the operator presents to the coordinator.
+	// +optional
+	ClientCertSecret string `json:"clientCertSecret,omitempty"`
+
+	// ServerName is the name the coordinator's certificate is verified
+	// against. Defaults to the host of the coordinator service.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// AdminAuthSpec configures the credentials of the operator's admin API calls.
+type AdminAuthSpec struct {
+	// BearerTokenSecret selects the key of a Secret holding a token sent as
+	// a bearer token in the Authorization header.
+	// +optional
+	BearerTokenSecret *corev1.SecretKeySelector `json:"bearerTokenSecret,omitempty"`
+
+	// Headers are additional headers whose values are read from Secrets, for
+	// authentication schemes other than bearer tokens.
+	// +optional
+	Headers []AdminAuthHeader `json:"headers,omitempty"`
+}
+
+// AdminAuthHeader is a header of admin API calls whose value is read from a
+// Secret.
+type AdminAuthHeader struct {
+	// Name is the name of the header.
+	Name string `json:"name"`
+
+	// ValueFrom selects the key of a Secret holding the value of the header.
+	ValueFrom corev1.SecretKeySelector `json:"valueFrom"`
+}
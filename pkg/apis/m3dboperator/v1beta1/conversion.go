@@ -0,0 +1,276 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+// v1alpha1 is the hub version that all other versions convert to and from;
+// it's the version the operator works with and the version objects are stored
+// in by default. Fields that are represented the same way in both versions are
+// copied through their JSON encoding, the remaining ones are converted
+// explicitly.
+
+// ConvertTo converts the cluster to its v1alpha1 representation.
+func (c *M3DBCluster) ConvertTo(dst *v1alpha1.M3DBCluster) error {
+	*dst = v1alpha1.M3DBCluster{}
+	if err := convertJSON(c, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = v1alpha1.SchemeGroupVersion.String()
+
+	dst.Spec.ConfigMapName = nil
+	if ref := c.Spec.ConfigMapRef; ref != nil {
+		name := ref.Name
+		dst.Spec.ConfigMapName = &name
+	}
+
+	for i := range c.Spec.Namespaces {
+		convertNamespaceOptionsTo(c.Spec.Namespaces[i].Options, dst.Spec.Namespaces[i].Options)
+	}
+
+	dst.Status.Conditions = nil
+	for _, cond := range c.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha1.ClusterCondition{
+			Type:               v1alpha1.ClusterConditionType(cond.Type),
+			Status:             cond.Status,
+			LastUpdateTime:     formatTime(cond.LastUpdateTime),
+			LastTransitionTime: formatTime(cond.LastTransitionTime),
+			Reason:             cond.Reason,
+			Message:            cond.Message,
+		})
+	}
+
+	return nil
+}
+
+// ConvertFrom converts a v1alpha1 cluster to this version.
+func (c *M3DBCluster) ConvertFrom(src *v1alpha1.M3DBCluster) error {
+	// Clear the fields that can't be decoded into this version before copying
+	// the rest.
+	copied := src.DeepCopy()
+	copied.Spec.ConfigMapName = nil
+	copied.Status.Conditions = nil
+	for _, ns := range copied.Spec.Namespaces {
+		clearNamespaceOptionDurations(ns.Options)
+	}
+
+	*c = M3DBCluster{}
+	if err := convertJSON(copied, c); err != nil {
+		return err
+	}
+	c.APIVersion = SchemeGroupVersion.String()
+
+	if name := src.Spec.ConfigMapName; name != nil {
+		c.Spec.ConfigMapRef = &corev1.LocalObjectReference{Name: *name}
+	}
+
+	for i, ns := range src.Spec.Namespaces {
+		if err := convertNamespaceOptionsFrom(ns.Options, c.Spec.Namespaces[i].Options); err != nil {
+			return pkgerrors.WithMessagef(err, "namespace '%s'", ns.Name)
+		}
+	}
+
+	for _, cond := range src.Status.Conditions {
+		converted := ClusterCondition{
+			Type:    ClusterConditionType(cond.Type),
+			Status:  cond.Status,
+			Reason:  cond.Reason,
+			Message: cond.Message,
+		}
+
+		var err error
+		if converted.LastUpdateTime, err = parseTime(cond.LastUpdateTime); err != nil {
+			return pkgerrors.WithMessagef(err, "condition %s", cond.Type)
+		}
+		if converted.LastTransitionTime, err = parseTime(cond.LastTransitionTime); err != nil {
+			return pkgerrors.WithMessagef(err, "condition %s", cond.Type)
+		}
+
+		c.Status.Conditions = append(c.Status.Conditions, converted)
+	}
+
+	return nil
+}
+
+// ConvertTo converts the namespace to its v1alpha1 representation.
+func (n *M3DBNamespace) ConvertTo(dst *v1alpha1.M3DBNamespace) error {
+	*dst = v1alpha1.M3DBNamespace{}
+	if err := convertJSON(n, dst); err != nil {
+		return err
+	}
+	dst.APIVersion = v1alpha1.SchemeGroupVersion.String()
+
+	convertNamespaceOptionsTo(n.Spec.Options, dst.Spec.Options)
+
+	dst.Status.Conditions = nil
+	for _, cond := range n.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha1.NamespaceCondition{
+			Type:               v1alpha1.NamespaceConditionType(cond.Type),
+			Status:             cond.Status,
+			LastUpdateTime:     formatTime(cond.LastUpdateTime),
+			LastTransitionTime: formatTime(cond.LastTransitionTime),
+			Reason:             cond.Reason,
+			Message:            cond.Message,
+		})
+	}
+
+	return nil
+}
+
+// ConvertFrom converts a v1alpha1 namespace to this version.
+func (n *M3DBNamespace) ConvertFrom(src *v1alpha1.M3DBNamespace) error {
+	copied := src.DeepCopy()
+	copied.Status.Conditions = nil
+	clearNamespaceOptionDurations(copied.Spec.Options)
+
+	*n = M3DBNamespace{}
+	if err := convertJSON(copied, n); err != nil {
+		return err
+	}
+	n.APIVersion = SchemeGroupVersion.String()
+
+	if err := convertNamespaceOptionsFrom(src.Spec.Options, n.Spec.Options); err != nil {
+		return err
+	}
+
+	for _, cond := range src.Status.Conditions {
+		converted := NamespaceCondition{
+			Type:    NamespaceConditionType(cond.Type),
+			Status:  cond.Status,
+			Reason:  cond.Reason,
+			Message: cond.Message,
+		}
+
+		var err error
+		if converted.LastUpdateTime, err = parseTime(cond.LastUpdateTime); err != nil {
+			return pkgerrors.WithMessagef(err, "condition %s", cond.Type)
+		}
+		if converted.LastTransitionTime, err = parseTime(cond.LastTransitionTime); err != nil {
+			return pkgerrors.WithMessagef(err, "condition %s", cond.Type)
+		}
+
+		n.Status.Conditions = append(n.Status.Conditions, converted)
+	}
+
+	return nil
+}
+
+func convertJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// convertNamespaceOptionsTo sets the durations of dst, which must have been
+// copied from src, from src.
+func convertNamespaceOptionsTo(src *NamespaceOptions, dst *v1alpha1.NamespaceOptions) {
+	if src == nil {
+		return
+	}
+
+	// Durations are always set, as v1alpha1 requires every duration to be
+	// present and parseable.
+	dst.RetentionOptions.RetentionPeriod = src.RetentionOptions.RetentionPeriod.Duration.String()
+	dst.RetentionOptions.BlockSize = src.RetentionOptions.BlockSize.Duration.String()
+	dst.RetentionOptions.BufferFuture = src.RetentionOptions.BufferFuture.Duration.String()
+	dst.RetentionOptions.BufferPast = src.RetentionOptions.BufferPast.Duration.String()
+	dst.RetentionOptions.BlockDataExpiryAfterNotAccessPeriod = src.RetentionOptions.BlockDataExpiryAfterNotAccessPeriod.Duration.String()
+	dst.IndexOptions.BlockSize = src.IndexOptions.BlockSize.Duration.String()
+}
+
+// convertNamespaceOptionsFrom sets the durations of dst, which must have been
+// copied from src, from src.
+func convertNamespaceOptionsFrom(src *v1alpha1.NamespaceOptions, dst *NamespaceOptions) error {
+	if src == nil {
+		return nil
+	}
+
+	for _, d := range []struct {
+		field string
+		src   string
+		dst   *metav1.Duration
+	}{
+		{"retentionOptions.retentionPeriod", src.RetentionOptions.RetentionPeriod, &dst.RetentionOptions.RetentionPeriod},
+		{"retentionOptions.blockSize", src.RetentionOptions.BlockSize, &dst.RetentionOptions.BlockSize},
+		{"retentionOptions.bufferFuture", src.RetentionOptions.BufferFuture, &dst.RetentionOptions.BufferFuture},
+		{"retentionOptions.bufferPast", src.RetentionOptions.BufferPast, &dst.RetentionOptions.BufferPast},
+		{"retentionOptions.blockDataExpiryAfterNotAccessPeriod", src.RetentionOptions.BlockDataExpiryAfterNotAccessPeriod, &dst.RetentionOptions.BlockDataExpiryAfterNotAccessPeriod},
+		{"indexOptions.blockSize", src.IndexOptions.BlockSize, &dst.IndexOptions.BlockSize},
+	} {
+		if d.src == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(d.src)
+		if err != nil {
+			return pkgerrors.WithMessagef(err, "invalid %s", d.field)
+		}
+		d.dst.Duration = parsed
+	}
+
+	return nil
+}
+
+func clearNamespaceOptionDurations(opts *v1alpha1.NamespaceOptions) {
+	if opts == nil {
+		return
+	}
+
+	opts.RetentionOptions.RetentionPeriod = ""
+	opts.RetentionOptions.BlockSize = ""
+	opts.RetentionOptions.BufferFuture = ""
+	opts.RetentionOptions.BufferPast = ""
+	opts.RetentionOptions.BlockDataExpiryAfterNotAccessPeriod = ""
+	opts.IndexOptions.BlockSize = ""
+}
+
+// formatTime formats a time the way v1alpha1 conditions are written by the
+// operator.
+func formatTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) (metav1.Time, error) {
+	if s == "" {
+		return metav1.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return metav1.Time{}, err
+	}
+	return metav1.NewTime(t), nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_updateTime     = metav1.NewTime(time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC))
+	_transitionTime = metav1.NewTime(time.Date(2019, 3, 1, 11, 0, 0, 0, time.UTC))
+)
+
+func newNamespaceOptions() *NamespaceOptions {
+	return &NamespaceOptions{
+		BootstrapEnabled: true,
+		FlushEnabled:     true,
+		RetentionOptions: RetentionOptions{
+			RetentionPeriod:                     metav1.Duration{Duration: 48 * time.Hour},
+			BlockSize:                           metav1.Duration{Duration: 2 * time.Hour},
+			BufferFuture:                        metav1.Duration{Duration: 10 * time.Minute},
+			BufferPast:                          metav1.Duration{Duration: 10 * time.Minute},
+			BlockDataExpiry:                     true,
+			BlockDataExpiryAfterNotAccessPeriod: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		IndexOptions: IndexOptions{
+			Enabled:   true,
+			BlockSize: metav1.Duration{Duration: 2 * time.Hour},
+		},
+	}
+}
+
+func newCluster() *M3DBCluster {
+	return &M3DBCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "M3DBCluster",
+			APIVersion: SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster",
+			Namespace:   "fake",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: ClusterSpec{
+			Image:             "quay.io/m3db/m3dbnode:latest",
+			ReplicationFactor: 3,
+			NumberOfShards:    256,
+			IsolationGroups: []IsolationGroup{
+				{Name: "a", NumInstances: 1},
+				{Name: "b", NumInstances: 1},
+				{Name: "c", NumInstances: 1},
+			},
+			Namespaces: []Namespace{
+				{Name: "metrics-10s:2d", Preset: "10s:2d"},
+				{Name: "custom", Options: newNamespaceOptions()},
+			},
+			ConfigMapRef:      &corev1.LocalObjectReference{Name: "m3-config"},
+			PodIdentityConfig: &PodIdentityConfig{Sources: []PodIdentitySource{PodIdentitySourceNodeName}},
+			ProbeOptions:      &ProbeOptions{TimeoutSeconds: 30},
+		},
+		Status: M3DBStatus{
+			State: GreenState,
+			Conditions: []ClusterCondition{
+				{
+					Type:               ClusterConditionPlacementInitialized,
+					Status:             corev1.ConditionTrue,
+					LastUpdateTime:     _updateTime,
+					LastTransitionTime: _transitionTime,
+					Reason:             "foo",
+					Message:            "bar",
+				},
+			},
+			ObservedGeneration: 2,
+		},
+	}
+}
+
+func newNamespace() *M3DBNamespace {
+	return &M3DBNamespace{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "M3DBNamespace",
+			APIVersion: SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "fake",
+		},
+		Spec: M3DBNamespaceSpec{
+			ClusterName: "cluster",
+			Options:     newNamespaceOptions(),
+		},
+		Status: M3DBNamespaceStatus{
+			Conditions: []NamespaceCondition{
+				{
+					Type:               NamespaceConditionReady,
+					Status:             corev1.ConditionTrue,
+					LastUpdateTime:     _updateTime,
+					LastTransitionTime: _transitionTime,
+				},
+			},
+			ObservedGeneration: 1,
+		},
+	}
+}
+
+func TestClusterConvertTo(t *testing.T) {
+	cluster := newCluster()
+
+	alpha := &v1alpha1.M3DBCluster{}
+	require.NoError(t, cluster.ConvertTo(alpha))
+
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.String(), alpha.APIVersion)
+	assert.Equal(t, "cluster", alpha.Name)
+	assert.Equal(t, "m3-config", *alpha.Spec.ConfigMapName)
+	assert.Equal(t, "48h0m0s", alpha.Spec.Namespaces[1].Options.RetentionOptions.RetentionPeriod)
+	assert.Equal(t, "2019-03-01T12:00:00Z", alpha.Status.Conditions[0].LastUpdateTime)
+	assert.Equal(t, "2019-03-01T11:00:00Z", alpha.Status.Conditions[0].LastTransitionTime)
+	assert.Equal(t, cluster.Spec.IsolationGroups[0].Name, alpha.Spec.IsolationGroups[0].Name)
+	assert.True(t, alpha.Status.HasInitializedPlacement())
+}
+
+func TestClusterRoundTrip(t *testing.T) {
+	cluster := newCluster()
+
+	alpha := &v1alpha1.M3DBCluster{}
+	require.NoError(t, cluster.ConvertTo(alpha))
+	roundTripped := &M3DBCluster{}
+	require.NoError(t, roundTripped.ConvertFrom(alpha))
+	assert.Equal(t, cluster, roundTripped)
+
+	alphaRoundTripped := &v1alpha1.M3DBCluster{}
+	require.NoError(t, roundTripped.ConvertTo(alphaRoundTripped))
+	assert.Equal(t, alpha, alphaRoundTripped)
+
+	// Unset fields stay unset.
+	cluster.Spec.ConfigMapRef = nil
+	cluster.Status.Conditions = nil
+	require.NoError(t, cluster.ConvertTo(alpha))
+	assert.Nil(t, alpha.Spec.ConfigMapName)
+	assert.Nil(t, alpha.Status.Conditions)
+	require.NoError(t, roundTripped.ConvertFrom(alpha))
+	assert.Equal(t, cluster, roundTripped)
+}
+
+func TestClusterConvertFromInvalid(t *testing.T) {
+	alpha := &v1alpha1.M3DBCluster{}
+	require.NoError(t, newCluster().ConvertTo(alpha))
+	alpha.Spec.Namespaces[1].Options.RetentionOptions.BufferPast = "10 minutes"
+
+	err := (&M3DBCluster{}).ConvertFrom(alpha)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retentionOptions.bufferPast")
+
+	require.NoError(t, newCluster().ConvertTo(alpha))
+	alpha.Status.Conditions[0].LastUpdateTime = "yesterday"
+	assert.Error(t, (&M3DBCluster{}).ConvertFrom(alpha))
+}
+
+func TestNamespaceRoundTrip(t *testing.T) {
+	ns := newNamespace()
+
+	alpha := &v1alpha1.M3DBNamespace{}
+	require.NoError(t, ns.ConvertTo(alpha))
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.String(), alpha.APIVersion)
+	assert.Equal(t, "2h0m0s", alpha.Spec.Options.IndexOptions.BlockSize)
+	assert.Equal(t, "2019-03-01T12:00:00Z", alpha.Status.Conditions[0].LastUpdateTime)
+
+	roundTripped := &M3DBNamespace{}
+	require.NoError(t, roundTripped.ConvertFrom(alpha))
+	assert.Equal(t, ns, roundTripped)
+
+	ns.Spec.Options = nil
+	ns.Spec.Preset = "10s:2d"
+	require.NoError(t, ns.ConvertTo(alpha))
+	require.NoError(t, roundTripped.ConvertFrom(alpha))
+	assert.Equal(t, ns, roundTripped)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceConditionType represents the various type of namespace conditions.
+type NamespaceConditionType string
+
+const (
+	// NamespaceConditionReady indicates the namespace exists in its cluster and
+	// its options match the spec.
+	NamespaceConditionReady NamespaceConditionType = "Ready"
+)
+
+// M3DBNamespace defines an M3DB namespace in an M3DB cluster, allowing
+// namespaces to be managed independently of the cluster's spec.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type M3DBNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              M3DBNamespaceSpec   `json:"spec"`
+	Status            M3DBNamespaceStatus `json:"status,omitempty"`
+}
+
+// M3DBNamespaceList represents a list of M3DB namespaces.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type M3DBNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []M3DBNamespace `json:"items"`
+}
+
+// M3DBNamespaceSpec defines the desired state of an M3DB namespace.
+type M3DBNamespaceSpec struct {
+	// ClusterName is the name of the M3DBCluster, in the same Kubernetes
+	// namespace, that the namespace belongs to.
+	ClusterName string `json:"clusterName"`
+
+	// Name is the name of the namespace in M3DB. Defaults to the name of the
+	// M3DBNamespace object; set it if the M3DB namespace name is not a valid
+	// Kubernetes object name, such as metrics-10s:2d.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Preset indicates preset namespace options.
+	Preset string `json:"preset,omitempty"`
+
+	// Options points to optional custom namespace configuration.
+	// +optional
+	Options *NamespaceOptions `json:"options,omitempty"`
+}
+
+// M3DBNamespaceStatus contains the current state of an M3DB namespace.
+type M3DBNamespaceStatus struct {
+	// Various conditions about the namespace.
+	Conditions []NamespaceCondition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the last generation of the namespace the controller
+	// observed.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// NamespaceCondition represents various conditions the namespace can be in.
+type NamespaceCondition struct {
+	// Type of namespace condition.
+	Type NamespaceConditionType `json:"type,omitempty"`
+
+	// Status of the condition (True, False, Unknown).
+	Status corev1.ConditionStatus `json:"status,omitempty"`
+
+	// Last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// Last time this condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason this condition last changed.
+	Reason string `json:"reason,omitempty"`
+
+	// Human-friendly message about this condition.
+	Message string `json:"message,omitempty"`
+}
+
+// NamespaceName returns the name of the namespace in M3DB.
+func (n *M3DBNamespace) NamespaceName() string {
+	if n.Spec.Name != "" {
+		return n.Spec.Name
+	}
+	return n.Name
+}
+
+// NamespaceSpec returns the namespace in the form used by a cluster spec.
+func (n *M3DBNamespace) NamespaceSpec() Namespace {
+	return Namespace{
+		Name:    n.NamespaceName(),
+		Preset:  n.Spec.Preset,
+		Options: n.Spec.Options,
+	}
+}
+
+// GetCondition returns the specified namespace condition if it exists with a
+// bool indicating whether it was found.
+func (s *M3DBNamespaceStatus) GetCondition(checkCond NamespaceConditionType) (NamespaceCondition, bool) {
+	for _, cond := range s.Conditions {
+		if cond.Type == checkCond {
+			return cond, true
+		}
+	}
+	return NamespaceCondition{}, false
+}
+
+// UpdateCondition updates one of the status's conditions, replacing the state
+// of cond.Type if it exists or adding the condition if it doesn't exist.
+func (s *M3DBNamespaceStatus) UpdateCondition(newCond NamespaceCondition) {
+	for i, cond := range s.Conditions {
+		if cond.Type == newCond.Type {
+			s.Conditions[i] = newCond
+			return
+		}
+	}
+
+	s.Conditions = append(s.Conditions, newCond)
+}
@@ -26,6 +26,6 @@ import (
 )
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x13\x00	\x00default-config.tmplUT\x05\x00\x01\x80Cm8\xa4T\xdfo\xdbF\x0c~\xd7_Ad\xcf\xb6l7^\xd2{k\x9d\x16\x18\x90u\xc6\xb2>\x1b\xe7;J\xbe\xf5tTy<5N\x90\xff}8I\xfe\x91\x05\xc60\x14~\x91\xc9O\x1f\xbf\x8f\xa4h\x88\xd8\xba\xa0\x85X\x15\x00\xdeE\xc1\xf0\xc1Z\xc6\x18s\x00@\xf6-*\xb82\x14*W_\xf5\xa1N\xfb\x94c\xb3i\xffS7\x8b\xd9<g<\x19\xed\x87\xb7\x82n0\xb6\xda\xe0\xc829E\x14X\xact\xf2\xd2'\x0e\x05R\xd0u\xcdXkA;&\x18\x05\x838\n\n\xaeow\x05@\x83\xc2\xce\x8c\x8c\xd1P\x8bj\x84\xb6\x8c\x95{\xece\x1e\xfd\x0cZ[\xa6\x06e\x87)\x1e\xb0;\x1d\xacG^k\xd9)(G\xce1\xf7\xda?\x9c\x19|7\x14\xd5\xc1\x89{\xd2\x83\xaa\x13\xf7\x98lZ\xefB\xfd\xa7\x16T0\x9f\xce\xfa(>\n\x06\x8bVA\xa0\x80\x05\x80\xe8\xfa\x8f6\x13\x8c\x8a\x9c}0;lP\xc1\xf7D\xd9}a\xb7\xfd,\xa8\xae]\xa8\x07\x90\xc7\x0e\xbd\x02\x17**\xfe\xdd\x89\xffc\xf1\xa7\x0cX\x14\xed|Vx\xb1S\xefg\xb3\xfc\x96\xf1)\n\xf2\xfdE\xd0\xbc\x00\xd8\x89\xb4_\xc8\xe2e\xd4bD\xad\xfe\x8b.O\xc7\xe26\xd5\x97!\xd7Y\xf5\x8e\xa2\xfcv7\xb4\x891\x92\xef\x90\x15T\xcec\x1f\xca\x0f\xc7\x95\x1a\x9a\x87b\xca\xe6\x9d\xdd\x96-\xd9\x89\xb3y!e_\x1e\x1e\x0e+\xec\x1a\xa4$\n\x96MQ\xf4\xf6\x1d\x06\x19\x98~\xb0\x13\\Q\x88\xbd2\xb3\xbf\x1f&\xd9\xe8\xbf\x89\x0f\x0c\x8c\xda\xbe\x85\xa4\x10\xf3\xe0ds\xc4\x16\x00\xb5Y#\x1b\x0c\xa2\xeb<\xa3\xd9,\x07\xfb\x1a_\xf0\xc7\x03\xb2\xc3\xf8!\xee\x83Q \x9c\xf0M\xee\xde5N\xd6\xc8\x0fh(\xd8Lp}\xbb\xbc\xf9\xf5\x0d\xee\xa36\xdf\xa8\xaa\xee\x12\x8f\xab\xb2hb\xae\xb4%\x92(\xac\xdb\xc1\xdc\xf1o\x8b|\xdc\xbe\xfc\xb5\xe7N\xc6}\x14l\xce\x82\x86\x9a\xc6\x89\xa7\xfa,\xd6\"r<\xfb\x9f\x82\xcb\x1b\xaa\xbd{B\xbb\x11j\xc9S=t\xa9:\xab\x10R\xb3f2\x18#q\\#\xaf\xd6_\xf3\xb4\xe7\x8be\x0f9V:\xbd\xf1K\xbfIQ\x95\xa5%\x13\xa7y\xa6SG%\xb58X\xd4~S'g\xb1\xd4\x9dv^o\x9dw\xb2\xdf\x98\xd3T66\xf1\x18.\x8f\xac\x8c\x928|\x0dU\xf2\x95\xf3\x1e\xedg\xe2\x151\xa7VV\xbd\x86{\xaa?\xe7^(\xa8\xb4\x8f\xd8o\xc7kq\x95Oq\xf7\xbb~\xfc\xb8\x97\x0c[.\xae\x17\xb7\xb7\xa7\xcc\xa7\x0ey\xaf`>4\xe9{\xc2\x84'SF{\x93|\xaf\xff\xaf\xfe\x8cV\xee\xf1x?\x01\xa2{B\x05\x8b\xd9\xfb\x9b\xf9r\x91K\x1fZ\x98\xc7\x93\xaf\xc3z\xbc\x9be\xa7\xb9\xf4n\xdb\xaf\xfa\xa01\x9f\xfb\xf1\xd2\"w\xce\x9cU\xc5\xd0)\xb8z~\x86\xe9\xa7\xd0\xc1\xcb\xcb\xd51\xf3D\x01\x15`\xb3Ek\xcfu\x8c\x0c\xd0\xd3\x9f\xc4\x9b\x1d\xde9~U\xfe[w*#\xc6\x8e_\xfe\xab\xdd\xbaP$\xeb\xb2-\xb9 Q\x15\xcf\xcf\x13`\x1dj\xcc\x1a\xc7(\xbc\xbc\x9c\x81'\xd9\xc1\x04\xa6\xbd\xfe\xfc\x84\xc1f\xc4?\x01\x00\x00\xff\xffPK\x07\x08\x91\x19\xad^\x1b\x03\x00\x00\x12\x07\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x13\x00	\x00default-config.yamlUT\x05\x00\x01\x80Cm8\xa4TMo\xe36\x10\xbd\xebW\x10{O,+q\x93\xf0\xb6\x9b\xf4P \xdd\x1aM{\x0eh\xf2Ib\x97\xe2h\xc9\xa1\x13\xe7\xd7\x17\x94dYi`\xb4\xc5\xc2\x07K3O\xef\xbd\xf9 5Q0\xd6+\xa6 \x0b!\x9c\x8d\x0c\xff\xd9\x98\x80\x18s@\x08>\xf4\x90\xe2\x93&_\xdb\xe6\xd3\x10\xda+\x97r\xac\xbc\x1c~\xf2\xa6*\xd79\xe3H+7~\xe5U\x87\xd8+\x8d\x89\xe5\xe2\x14\x91\xc2\xa0V\xc9\xf1\x908\n$\xaf\x9a&\xa0Q\x0c3%\x02\x18\x9e-y)\xaeo\xdbB\x88\x0e\x1c\xac\x9e\x18\xa3\xa6\x1er\x82\xf6\x01\xb5}\x1dl\xce\xf5\x8c^\xfb@\x1d\xb8E\x8aGl\xab\xbcq\x08[\xc5\xad\x14\xab\x89s\xca\xbd\xaf_,\n\xbc\x1aE\x95\xb7l\xdf\xd4\xe8\xea\xc4=%\xbb\xdeY\xdf\xfc\xae\x18R\xac/\xcb!\x8aW\x8670Rx\xf2(\x84`\xd5\xfc\xd6g\x82\xc9\x915O\xbaE\x07)\xbe'\xca\xd5\x17f7\xcc\x82\x9a\xc6\xfaf\x049\xec\xe1\xa4\xb0\xbe\xa6\xe2\x9f\x9d\xf8?%\xfeP\x01\x06\xac\xac\xcb\x0e\xcfv\xea\xae,\xf3W\xda\xa5\xc8\x08\x8fgA\xebB\x88\x96\xb9\xffJ\x06\xe7Q\xd5\x84\xba\xff7\xba<\x1d\x83]j\xceC\xae\xb3\xeb\x96\"\xff\xf20\xb6) \x92\xdb#HQ[\x87!\x94\x1f\xe6\x95\x1a\x9b\x07\xd6\xab\xee\xca\xecV=\x99\x0bk\xf2B\xf2au|8\xae\xb0\xed@\x89\xa5\xd8tE1\x94o\xe1ydz	\x96qO>\x0e\xce\xf4\xe1q\x9cd\xa7\xfe\xa2pd\x08P\xe6#$\xf9\x98\x07\xc7\xcf3\xb6\x10\xa2\xd1[\x04\x0d\xcf\xaa\xc93*\xcb\x1c\x1c4\xbe\xe2\xe5	\xc1\"~\x8e\x07\xaf\xa5\xe0\x90\xf0!\xf7h;\xcb[\x84'h\xf2&\x13\\\xdfnn~\xfa\x80\xfb\xa2\xf47\xaa\xeb\x87\x14\xa6U\xa9\xba\x98\x95vD\x1c9\xa8~,n~\xed\x11\xe6\xed\xcb\xa7=w2\x1e\"\xa3[\x045u\x9deG\xcd\"\xd6\x03!.\xde\x93\xb7yC\x95\xb3o0\xcfL=9j\xc6.\xd5\x0b\x05\x9f\xbam \x8d\x18)\xc4-\xc2\xfd\xf6\xcf<\xedu\xb5\x19\xfa\x7f\x14\x1a?\xa8]\x8a\xed\xaf\xea\xf5\xcb\x81\x11\xa5\xd8T\xd7\xd5\xed\xed)\xf3\xf3\x1e\xe1 \xc5z\xb4\xf1=!\xe1$\xa4\x95\xd3\xc9\x0dM\xf8c\xb8\xa8j\xfb:\xdfPBD\xfb\x06)\xaa\xf2\xeef\xbd\xa9\xb2\xf4\xd1dn@>\x7f\xdb\xe9fZ\xedUX9\xbb\x1b\x96i\xf4\x98/\xd4\xe9.C\xd8[\xbdP\x85\xdf\xcf\xd7\xe43\xfc~N\xbc\x91\x87\x14\xe8v0fic\"\x10\x03\xfb\xc9\xbbn\xf1`\xc3;\xf5o'2\xb06\xd3\xd1z7\xbc3\"\xd9\x96\xe9\xc9z^\xa03>\x1fQ\xb9\xcaG\xc5\\\x94\x97\xf9OVW7wg1\xeb\xff\x80\xa9\x16\x98\xbf\x03\x00\x00\xff\xffPK\x07\x08\xd5\x85\xf3L\xc2\x02\x00\x00\xa5\x06\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(\x91\x19\xad^\x1b\x03\x00\x00\x12\x07\x00\x00\x13\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00default-config.tmplUT\x05\x00\x01\x80Cm8PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(\xd5\x85\xf3L\xc2\x02\x00\x00\xa5\x06\x00\x00\x13\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81e\x03\x00\x00default-config.yamlUT\x05\x00\x01\x80Cm8PK\x05\x06\x00\x00\x00\x00\x02\x00\x02\x00\x94\x00\x00\x00q\x06\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x1e\x00	\x00default-aggregator-config.tmplUT\x05\x00\x01\x80Cm8\xc4X[O\xe4:\x12~\xcf\xaf\xb0F\xda\xc7\x864=\xa0]\xbf1\x0d\xcc\xb23@\x8b\xee\x1d\xa4]\xadF\xc6\xa9$\xde\x8e\xed\xa8\xec4\xf4 \xfe\xfbQ9\x97\x0e}	sF\xe7\xe8\x98\x07H\xf9s\xb9\xeeU\xa6\xb0Y\xa6L\xc6#\xc6\nXA\xc1\x992\xa9\x8d\"\x0d\x1e\x95tDw\xd2\x96@\x7f0V\"\xa4\xea\x993=\x11Y\x86\x90	o1\"\xb2\xd5\xe0s\xa8\x02\x9e1k.\x11-rf\xac\x81p0\x17&)\x00g\xc2\xe7\x9c\x1d7\xcc\xc3N\xa1\x9c\x07s\x9e$\x08\xceq\x16\x1f\x85\x1f~\x16\xc7'a\xdf+\x0d\xb8X\x97\xc0Y\xae\x9c\xb7\x19\nMB	\xa3\xbc\xfa!\xbc\xb2\x86\xf7\x04\x08[\xba,\x94\xc9\xee\x85\x07\xce\xc6Gq\xc4\x18<{0	$\x8dH\x91\x9eh\x17\x94v\x80+@\xfe\x8e(\xc4\x821\x04\x8f\xeb\x1a\xca\x98\x16\xcf\x9f\x84\\\xda4\xe5l\x1c\xbb\x86\xfa\x7f\xe5= g\x1e+R\\Z\xe3*\xdd\xf2\xd7\xe0\x9c\xc8`fm\xd1rq\xea\x07\xc9x6\xf9\xfb\xc7(\xca\xbd/y4$\xc78\")D\xb2P\x1al\xe59;\x0b7?\xa1\xf2\xf0\x86\x16-W\xd3B\x81\xf1\xc4\x0f\xbcL\xe87c`V\x9c}xyaG\x97f\xc5^_?\x04\xea\x0fk\xa0!\xff\xc7\x1a\xe8\xe8d\x1b%a\xc7\xdd\x8cI!s\xb8P\xc8\xd9\xf1J\xe0q\xa1\x1e\x8f\xf5d\xb9\n\xdc\xe8\xb6iQ9\x0f\xd8\x84\xc3\xe8\xe0\x0d$QRZe\xbc\xe3\xd1\xcb\xcb\x88\xa10\x19\x90t\x0d\x95\xbd\xbe6\xc0\x11\x1d\x1f\xb1\xa3 \x1dA\xc1$\xb4\x1bae(F\xeeJ\n\x85p\xe3r5\xb5&UY\xa7\xb3Bk4\x19\xe3gu\x0f\x06\xfd&\x8a\n\xdc\x0c\xf0&\x84\xebW\xa5\x95\x9f\x01\xceAZ\x93|\x815\xafa\xa3U\xc0\x8dJ\xc0Q\x1d\xd8\xa3\x82\xa0\x81\xe0\x02\xf8\xa78r\x16\xb7\xb8[x\xea!\x1ac\xee\xbb\xda\xc0\xd3\xdb;e\x8d\xdds\xf70\xcf}\x97\xdf\xda\x00}\x10\xa8\xab\xf2\xa2\xc2&\xd7\xe2(\xda$?\x998\xb7\xce__\xf0&E\x9c-(\xa1\xfafo\xdd\xf0M\xe0\xad\xd0\xc0\xd9\xcd\xe4\xfc\xf3\xe7\xfb\xcb\xcf\xe7\x8b\xbb\xfb\xef\xff\xbc\x9b/\xbe__D\x8c)\xe3\xbc0\x12Zf\xbe\xcez\xeb\xfcwE6l\xafU\xd6PAh\xa2K\xda\xcax\xc0\x05\n\xe3R\x8b\xfa*lr\x06\xba\xf4\xeb^	\xd9\xdewU\x9a\xaa\xe7\x00\xc8D\x95\xc1A\x06\xb2K\xa4V\xa4P=\xc2wWGh\x95h\x93J\xb6\xd9N+\x18\xb4\xf7\xcd\x98\xb7\xa5\x92\xb5\x15B\xd0\x9d7:Y\\\xd0\x8e;\xba6\x198\xdf\xe5`\xef\xd4\xbcN\xc7\xbb\x15 \xaa\xa4\xa9\xc8\xed:\x9ca\xf5\x1a\xce\x82z\x95\x85\x90\xa0;]\xdb\xa5\xdc\xdc\x8b\x0c\x92\xae\xaa\xed\xe0\x07%3B\x83+\x85l\xfd\xb5\xe7<g\xc7\x1d\xaf\x9eV{\xea\xe5n\xd5li\xb4\x9e\x84\x07\xd4\x02\x97\xdb7\x15\xf6\x89\xea\xe8\xc9\x169WYN\xf4\xd3\xa8'\xcc\x8d0\"k\x9d\xf6\xb6\x94\xf4\x94\xd9#\xf2{&>\xe4\xa2\xee\xe6\x07\xe1e\xbe	\x97%\xac\xf7\x94^J\x13JJ/\xf3\xae\xe2\xd7\xfd'\x17.\xafC_W\xa8+\x9c\x90\xba\x8fU\x9a\x02\xb6\xd9\xfb	R\x8b0\xcf\x05&\xd3\xca\xdb\x90\xa8\xe3X\xef\xe0\xceS\x0f\xd8\xc1\xd2t?\xea\xca\xe2U\xe5+\x0c\xad'\xa9\xab\xcbA\xe4L8\xbf\x85\xa3\xd6\x85\xe0Tf6\x9a\x90,iQ\xb9\xa0\x9c\x1b\xf4\xc5\xaf\xda{\xc3\xfe\x0b\xac\xaf\xb4\xe7\xcc\x91\xa6\x0e\xfc\xf1\xdf\x92\xe3\xb0\xbb\x85\x9b\x01:\xe5\xfc=I\xbe\xf1\x8f\xa2	D\x14\xbd) \xd6\xed\x1c\xf0X\x13\xaf\x84\xf44\x07\x9d\x84!d{l8i\xd1Z<\xd7\xbc\x1dg\x13j\xd8\x05H2\xdb\x1b\xf5[b{}\x01\"\x01\xdc\x8a\x01\xc6vM\xda\x0d'\xde\x17u\xb5wD\xed\xb7\xf8\xb6\xe2\xd6\x01\xbe\xa7\xe1\xffzt\xb7b\xef\xb3ua\xe5\xb2\x99't)Tf\xfe4\x0bS\xd8\xafz\x83\xd9\x9eY\x8d1\x99\xd3\xf4\xf1G\xcbp\xfa\xbbd\xa8\xf3\xe1\xaf\x95\xa1\xf5\xc6\xdc\x0b\x0f\xd3\x1c\xe4\xf2\x9a:\xecJ\x14\x9c\x8dkNnS\x1b\x02\xe0.M\x1dx\xce&!\xd8B\x0e\xbd	^I\xa0\xcb\x15\xe0\xbacQ\x8f\xca\x97F<\x16oz\x8b\x16\xcf\xff\n[]\xbf\x18\xd5\x0c7Bt\xea\xf4\xd03@\x19*\xc1\xf8(>tn\x1c\x0f\x1d\x8c\x8fN\x0f]8\xd6\x03\x17\x0e\x9d\x8b\x7f\xf5`>x\xee\xa4>h*\xfd`q	H\x15j:\xfb\xf7\x86\xe5N\xedjm\xdfX\x80^/\xa1B\xcf\xc3\x03\xe4\xb4\x163\xb5(!\xb9\xa2\xb3\x0f\xca$\xf6\xa9\xde\x1do\x9c\xca\xfb\xaf\xb9\x9e\x83\x92\xb5\x11ZI\xaa\x85`\x9a\xc7\xc6\xa6\xfd\xf7'\xa6z\xed\xedU\x8c\x1d\x9a\xa3h\xd5\x1d\xea-mG\x91q\xdc\xae]\xdcM=N\x0c\x02w\x07\xb6\x9f\x1a\xdaZ\x02$\xbd\x8a\xd8\xae\xf7\x87\xb7\xa1GX\x7f\xbdW\x83\xdf\x1d\x9c\x86\x86\xa7\xc1\x01j`\x88\xda\x19\xa4\x84s>G[eM\xb4\xc0V\x8a\xa7\x16\x9f\x04&\xcd\x7f\x1d\x82\x0b\xa7\xd68\x7f\x01\x85X7\xb1\x08\xc6\xe3z\xb1\xf8\xdadB\xf8\xdc\xaeD!\xb7\xb4x\xa6\x16\x88\x9fh \"\xd7\xce\x00\x1f\xc8\x89\x9c\x8d?\x92o\x13HEU\xf8\xb9\xb7\x18LR(\x19\xfa\xec\x7f\xffW\x9f\xbe\xad\xf4T\xc8\x1c\x92\xb9\xadP\xc2\x1c\xbc\xe3\x8c&\xa7D9)0\xb9\x15\xb7\x1b\xef\xd6O\xc2\xaeX\x05\xb16\xcf\xf9z,\xfd\x18\xff\xe3,\xea\x9e%\x97\x05\xe8\x03\x08\xaf\xf4\xe0~x\x95\x1c\xdc\xffm\x00PK\x07\x08\x9bZt\xde\x7f\x05\x00\x00\xb9\x11\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x13\x00	\x00default-config.tmplUT\x05\x00\x01\x80Cm8\xa4UKo\xe36\x10\xbe\xebW\x0c\xd2\xb3\xad\xc4M\xba)o\xbbI\x16-\x90\xdd\x1aM\xf6\xd2\x8bA\x93#\x99]\x8a\xa3%\x87\xda8\x82\xfe{A=,\xbb\x01\n\xac\xab\x135\x8fo>\xce\x83\xd3\xb6`\nX>T[\xd4\x1a\xf5\x1d\x91\xd7\xc6I&\x0f\x8b\xae\xcb\xd4\xfc/2\x00k\x02\xa3{\xaf\xb5\xc7\x10\x92\x00\x80\xf75\n\xb8P\xe4\nS^\xf4\xa2F\xda\x98dm\x0b\xcb5y\x0e\xcb\xc7c?\xe8:1\xab\x8e#v]\x02\xb0\xa4\xa4\x1d\xc0\x9d\xac0\xd4R\xe1\x18l1K\x04h,d\xb4\xdc\x1bN<\xa2\x93e\xe9\xb1\x94\x8czTxdtl\xc8	\xb8\xbe\xdde\x00\x15\xb27jD\x0c\x8aj\x14\xa3i\xed\xb10/\xfdm\x0e\xa4\x12#\x80\xdaS\x85\xbc\xc38\xba\x01\xec\xa4\xd3\x16\xfdZ\xf2N@>b\x8e\xba\xd34\xc1\x8f\xe6\xe1\xd3\x00\x06]\xd7\xe3\x05\xe9\x0c\x9bW9\xdcaf2*\xab\xda\x1aW\xfe)\x19\x05\\-/{)\xbe0:\x8dZ\x80#\x87\x19\x00\xcb\xf2\x8f:\x01\x8c\xfc\x8d~R;\xacP\xc0\xb7H)WY\xdb\x02:\xdd\x17]o\x93\x91\xa5\xb24\xaeLG\x00\x8b\x0dZ\x01\xc6\x15\x94\xfd;\x85?\x92\x9b\xffu\x17\x8d,\x8dMd\xcfL\xb15\xe8xH\xab\xb210\xfa\xc7s*5\xb8\x0e8;\xe6\xfa3i<\x07\xe8\xb7\xe7\xe7u\xf2\x9d\x91F\xe8s\xc1N\x98i\xdc\xc6\xf2\x1c\xa4\xfb\xe4\x988%R\x14\xf8\xf7\xfb\xa1\x07<\x06\xb2\x0dz\x01\x85\xb1\xa9\xa9\xa0?L\x03Q\x0f\x93\x80\xac\xf2\xeag\xbd\xcdk\xd2\x0b\xa3\xd3\xe8\xf1>\x9f\x0e\xa3-\x9b\n)\xb2\x80\x9b*KaT_\x99\x01\xe9\xbb7\x8cw\xe4BO]\xed\x1f\x87\xd6\xab\xe4\xdf\xe4'\x04\x8fR\xbf5\x89.\xa4\xc1\xe1\xcd\xc16\x03(\xd5\x1a\xbdB\xc7\xb2L\x03ry\x99\x84}\x8c\xcf\xf8\xfd	\xbd\xc1\xf0>\xec\x9d\x12\xc0>\xe2\x1b\xdd\xa3\xa9\x0c\xaf\xd1?\xa1\"\xa7\x13\xc0\xf5\xed\xcd\xbb_\xde\xd8}\x90\xea+\x15\xc5}\xf4\xe3\x9c\xae\xaa\x90\"m\x898\xb0\x97\xf5p\xb9\xc3o\x8d\xfe\xf0\x94\x00,\xfaL\x86}`\xac\x8e\x84\x8a\xaa\xca\xb0\xa5\xf2HV#\xfa\xe9\x9dI\x8e\xd1\x99\xf4<Hk^Qo\x98j\xb2T\x0ey.\x8e\"\xb8X\xad=)\x0c\x81|X\xa3\xbf[\x7f\x11p\xb9\xbcZ\xdd\xf4\xa6\x87H\xb3\xc7O}o\x07\x91\xe7\x9aTX\xa6\x9a.\x0d\xe5T\xe3pEi7e4\x1as\xd9Hc\xe5\xd6X\xc3\xfb\x8d\x9a\xab\xb2\xd1\xd1\x8f\xe2\xfc\x80\xea\x91\xa3w_\\\x11ma\xacE\xfd\x91\xfc\x1dy\x1fk\xbe\xeb9<R\xf9\xd1X\x0c\x02\ni\x03f\xd9\x1br\x85\x8da\xf7I\xbe|\xd8s2\xbbY]\xafnog\xcdC\x83~/\xe0jH\xd2\xb7\x88\xf1\xd0\xa2\x00JZ\x15m\xcf\xff\xb9_\x18\x85y9l\n\x80`^Q\xc0\xea\xf2\xd7wW7\xab,\x9bS\x98\xca\x93\x9e\xfa\xf5\xb8!\xf2F\xfa\xdc\x9am\xdf\xea\xc9p\xd8\x7f\xe3NA\xdf\x18u\x14\x15]3\xae\xc3\x07\xd7\x8c[n\xf8^\xc9M\x9b\xf2/rx\xa2\x9b`\xa0\x8f1I\x95T;\xbc7\xfe\x84\xc3\xd7f\x8e\xc5J\x8f\xef\xc0I\x83\xfdW\xa4\xc4P\xd7d\x1c\x07\x91\xb5\xed\x02\xbct%\xc2\xf2a\x92N\xabh\x02\xbbHF\xcb\x1e\xa3m\x17\x80NC\xd7e\xff\x0c\x00PK\x07\x082\xb6\x0fOS\x03\x00\x00L\x08\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x13\x00	\x00default-config.yamlUT\x05\x00\x01\x80Cm8\xa4T\xc1r\xe36\x0c\xbd\xeb+0{O,+q\x1d\xf3\xb6\x9b\xf4\xd0\x99t\xebi\xdas\x86&\x9f$v)RK\x82N\x9c\xaf\xefP\x92e\xa7\xa9\xa7\xedt|0\x05<><\x80\x00\x94\xf7A\x1b'\xd9\x07Q\x10Y\x13\x19\xee\xb3\xd6\x011f\x03\x11\x1fz\x08\xfa\xa4\xbc\xabM\xf3i0\xed\xa5M\xd9V^\x0f?\xb1\xae\xcae\xf6X\xaf\xa4\x1do9\xd9!\xf6Rab\xb9:Y\x04i\xd42Y\x1e\x80\xc7\x00\xc9\xc9\xa6	h$CO\x8e\x00\x86c\xe3\x9d\xa0\xdb\xbb\xb6 \xea\xc0\xc1\xa8\x891*\xdfCL\xd0>\xa06\xaf\x83\xcc9\x9f\xac\x88\xa8\x0f\xbe\x03\xb7H\xd35\xa2V:m\x11\xb6\x92[A\x8b\x89s\xf2\xbd\xcf\x9f\xce\x12\xbc\x19\x10Q:\xc3\xe6M\x8e\xaaN\xdc\x93\xb3\xeb\xadq\xcd\xaf\x92!hy]\x0eV\xbc2\x9c\x86\x16\xe4\xbcCA\xc4\xb2\xf9\xa5\xcf\x04\x93\"\xa3\x9fT\x8b\x0e\x82\xbe'\x9f\xb3/\xf4.{\xaco\x1a\xe3\x9a|$\xb2\xd8\xc3\n2\xae\xf6\xc5_+\xf1_R\xfc_	h\xb046+\xbcX\xa9MY\xe6[\xca\xa6\xc8\x08\x8f\x7f_\xceMY.\x0b\xa2\x96\xb9\xff\xea5.\xa3\xaa	u\xffOt\xf9u4v\xa9\xb9\xccu\x9b\xeb\xd6\xfa\xc8?=\x8c%\x0d\x88\xde\xee\x11\x04\xd5\xc6\xe6\x87\xa1\xe1pl\x93~\xec\x0f\xb0Zt7z\xb7\xe8\xbd\xbe2:7$\x1f\x16\xc7\xc3\x84e\xd3\xc1'\x16\xb4\xea\x8a\x1cFY\x03\xc7#\xd3K0\x8c{\xef\xe2\xa0L\x1d\x1e\xc7\x97\xec\xe4\x1f>\x1c\x19\x02\xa4\xfe\x08I.\xe6~\xe7\xe7\x19[\x105j\x8b\xa0\xe0X6\xb9\xc9\xca2\x1b\x87\x18_\xf1\xf2\x84`\x10?\xc7\x83S\x828$|\xf0=\x9a\xce\xf0\x16\xe1	\xca;\x9d	n\xefV\xeb\x1f>\xe0\xbeH\xf5\xcd\xd7\xf5C\nS\xafW]\xcc\x91v\xdes\xe4 \xfb1\xb9\xf9\xb3G\x98\x07\x8c\xe8j\xa8d<DFwfT\xbe\xeb\x0c[\xdf\x9c\xd9z \x1c\xa7/_L\xce\xe4\x11\x93\xd6\xbcA?\xb3\xef\xbd\xf5\xcdX\xe7\xfa,\x82K\xdd6x\x85\x18}\x88[\x84\xfb\xed\xef\xb9\xbf\x96\xd5*\xab\x9c\x03\x8d\x17j\x9bb\xfb\xb3|\xfdr`DA\xab\xea\xb6\xba\xbb;y~\xdc#\x1c\x04-G\x19\xdf\x13\xd2\xdc\x04DJZ\x95\xecP\x84\xdf\x86EU\x9b\xd7yC\x11E\xf3\x06AU\xb9Y/WUQ\x9cD\xe6\x02\xe4\x15\xb3\x9d6\xd3b/\xc3\xc2\x9a\xdd\xd0L\x198.\xd4i\x97!\xec\x8d:\x8b\n\xb7\x9f\xd7\xe43\xdc~v\xbcy\x07A\xe8v\xd0\xfa\\\xc6D@\x03\xfb\x11\xac\xa4j\xf1`\xc2\xbb\xe8\xdfNd`\xa5\xa7\xd1z\xf7x\x17\x82dY\xba\xf7\xc6\xf1\x19:\xe3\xf3\x88\x8aE\x1e\x15}U^\xe7?Q\xdd\xac7\x171\xcb\x7f\x81\xa9\xae\xc1J\x8b\xeaf\xbd)\xfe\x1c\x00PK\x07\x08\xd5\x85\xf3L\xd0\x02\x00\x00\xa5\x06\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x00\x00!(\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x1f\x00	\x00default-coordinator-config.tmplUT\x05\x00\x01\x80Cm8\x94UKs\xdb6\x10\xbe\xe3W\xec\xe8n\x8a\xa9\x92&\x83[\xea\xe6\xd0\x99\xb4\xf1X=\xf5\xd2A\x80\x15\x08\x0b\x0f\x16\x00)\xcb\x1a\xfe\xf7\x0e@R|\xc8\xad'\xd4\x05\xdc\xd7\xf7-\xf6\xe3J\xab\x10\xd1~\x16\xc2c\x08\x94\x00\xc4s\x8d\x146\xdc\xd9\x83\x92\x1b\x02\xd02\xdd$\xcb\xe5\x02\xc5\x83\xf31\x14_\xe79\xd0utr\xdd;\xe7\x85\xb2,:\x0f]\xb7!D;)\x95\x95\xa9\xb2\xc6\x165\x05e\x0f\x8e\x10\x83\xd1+\x9e\x11\x03w5\xa6\x03@\xed\xf1\xa0\x9e3\xfc\xb5N\xe2P{g0V\xd8\xe4\x04\x80\x8aY\xa1\xd1?\xb0XQ\xd8\x0e\xb5r\x85e?\xf0\xa3\xa4\x7f\xefKA\xd7\x11\x80\xc0\xac\x8a\xea\x85E\xe5,\x9dq\xc8.Ske\xe5#\x8bH\xe1]Q\x12\x00|\x8eh\x05\n\n\xd6Y$$2\xf9\xadN\xb9\x99\xb4\x12{^\xa1A\n\xff4.\xa2 \x84\xeb&D\xf4\xd9{\x07\x96\x19\x0c5\xe38\xb4\xb8\xb0Q\x10x`\x8d\x8e\x83k\x1cSc\x99\x94\x1e%K\x05G\x97\xc7\x886\xe1Rx\xff\xa9\"\x97\xcb\x1dxf%B\xf1\xf9\x1a\xfc\xc7\x15\xado\xf5\x06/\x8f;E\xe51.Q\xff\x1f3g>\x8e\xef\x8bt\x8f\xc1\xe9f\x116\x1ar\\\xa2\x8aV\x8c\x94\xb8Vh\xe3x\x1d\xbd\"\xc77\x80\x80\xbeU|\x10N\xff\xa0m\x07\xa1~\xb1\xed\x02\x19\xe0\xc5\xd9\xb1\xad\xbf\x9c]\xb65\x15\x03\xb3\x13\xdfgv\xcex\x85\xbf*Oa\xdb2\xbf\xd5\xea\xfb\xd6\xec\x8e\xed,\x02#\x17\xf7\xb3I\x8e\xcf\xdd\x1b\x88\x90:\xad\x9d\xb21\xd0\xf9\x8c\xbe\x8c\xd6i0c\xc1M\n+^\xbb)\x80\x93W\x11\xef\x9d\x0dY\xff\xfc\xfc\xb5\xff\xd8\x0c{r^\xc5\xf3\x00\xec\x91\x89\xdb\xa0\xc6\x86\xa4\xfa\xf8\xf75:\x01\xa9\xc3$\x98\xfc5\x13\"\xdc\xc9f\xe5\xe7[\xf7h\\\xc4)$\xd9\xd6C\xeb\xb7\x89\xd9\x99 \x07\n\xf9<\x0d\xad\xf6N4\x1c\x87\xe4Y/\x0b\x0b@t\xb5\xe2I\x8f\xc3\x8dN\xb0\x7f&O(~\xb3\x12C\\\x8du\xc8\xdb\xf7\xd3\xfd\xd6\xa2\xf7J,$\xf3\xb64\x06a)\xef\xacIr\xfc/\x81\x01\xd4\x9aq4\xb3\xee\xc7G\x85}d2m\x86\xe8\x1b|=\xe7\x0d\x8e\xb7\xeb\xe15\\\xd8^9,\xa2\x0c\x86\xc0$>8\xa7\xd7\xcd\x07\xf5\x926\xd8\xcf\xbbO\xefW\x9e\x13\x8b\xe8\x0d\xf3\xc7u\n\x80v'\ne\xf1\xd3\x8d\xa3R\xb2J\x9e\x0f\x84\xa8<\x90\x94\xdb\x9f\xc6\x89\x9e\x9c?\xa2OT\xf6=tY\x96i}\x02\xb8zN0\xac\xbc\x1e\xa3?\x8fT\x0c{~L\xab\x1a\x03\x85\xdd`{R1\x81Lw\xac\x9d\xdcg\xb5\xf6[\xba,\xcawd!\xc0\xb4B&\xa1\xad\xfe:6e\x91\x7f\xf4\xe3\x87\xf2\xe38\xe8\x05\x89L\xe3\x17\xc6\x8f\xeepHL\xc3\x10\xb4\xa2r\xb9\xdc\x01Z\x01]G\xfe\x1d\x00PK\x07\x08\x15O=\x99\xd5\x02\x00\x00n\x07\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(\x9bZt\xde\x7f\x05\x00\x00\xb9\x11\x00\x00\x1e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00default-aggregator-config.tmplUT\x05\x00\x01\x80Cm8PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(2\xb6\x0fOS\x03\x00\x00L\x08\x00\x00\x13\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xd4\x05\x00\x00default-config.tmplUT\x05\x00\x01\x80Cm8PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(\xd5\x85\xf3L\xd0\x02\x00\x00\xa5\x06\x00\x00\x13\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81q	\x00\x00default-config.yamlUT\x05\x00\x01\x80Cm8PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x00\x00!(\x15O=\x99\xd5\x02\x00\x00n\x07\x00\x00\x1f\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x8b\x0c\x00\x00default-coordinator-config.tmplUT\x05\x00\x01\x80Cm8PK\x05\x06\x00\x00\x00\x00\x04\x00\x04\x00?\x01\x00\x00\xb6\x0f\x00\x00\x00\x00"
 	fs.Register(data)
 }
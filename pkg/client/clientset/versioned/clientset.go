@@ -24,6 +24,7 @@ package versioned
 
 import (
 	operatorv1alpha1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1alpha1"
+	operatorv1beta1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -32,8 +33,9 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	OperatorV1alpha1() operatorv1alpha1.OperatorV1alpha1Interface
+	OperatorV1beta1() operatorv1beta1.OperatorV1beta1Interface
 	// Deprecated: please explicitly pick a version if possible.
-	Operator() operatorv1alpha1.OperatorV1alpha1Interface
+	Operator() operatorv1beta1.OperatorV1beta1Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -41,6 +43,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	operatorV1alpha1 *operatorv1alpha1.OperatorV1alpha1Client
+	operatorV1beta1  *operatorv1beta1.OperatorV1beta1Client
 }
 
 // OperatorV1alpha1 retrieves the OperatorV1alpha1Client
@@ -48,10 +51,15 @@ func (c *Clientset) OperatorV1alpha1() operatorv1alpha1.OperatorV1alpha1Interfac
 	return c.operatorV1alpha1
 }
 
+// OperatorV1beta1 retrieves the OperatorV1beta1Client
+func (c *Clientset) OperatorV1beta1() operatorv1beta1.OperatorV1beta1Interface {
+	return c.operatorV1beta1
+}
+
 // Deprecated: Operator retrieves the default version of OperatorClient.
 // Please explicitly pick a version.
-func (c *Clientset) Operator() operatorv1alpha1.OperatorV1alpha1Interface {
-	return c.operatorV1alpha1
+func (c *Clientset) Operator() operatorv1beta1.OperatorV1beta1Interface {
+	return c.operatorV1beta1
 }
 
 // Discovery retrieves the DiscoveryClient
@@ -74,6 +82,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.operatorV1beta1, err = operatorv1beta1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -87,6 +99,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.operatorV1alpha1 = operatorv1alpha1.NewForConfigOrDie(c)
+	cs.operatorV1beta1 = operatorv1beta1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -96,6 +109,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.operatorV1alpha1 = operatorv1alpha1.New(c)
+	cs.operatorV1beta1 = operatorv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -26,6 +26,8 @@ import (
 	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
 	operatorv1alpha1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1alpha1"
 	fakeoperatorv1alpha1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1alpha1/fake"
+	operatorv1beta1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1beta1"
+	fakeoperatorv1beta1 "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/typed/m3dboperator/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -80,7 +82,12 @@ func (c *Clientset) OperatorV1alpha1() operatorv1alpha1.OperatorV1alpha1Interfac
 	return &fakeoperatorv1alpha1.FakeOperatorV1alpha1{Fake: &c.Fake}
 }
 
-// Operator retrieves the OperatorV1alpha1Client
-func (c *Clientset) Operator() operatorv1alpha1.OperatorV1alpha1Interface {
-	return &fakeoperatorv1alpha1.FakeOperatorV1alpha1{Fake: &c.Fake}
+// OperatorV1beta1 retrieves the OperatorV1beta1Client
+func (c *Clientset) OperatorV1beta1() operatorv1beta1.OperatorV1beta1Interface {
+	return &fakeoperatorv1beta1.FakeOperatorV1beta1{Fake: &c.Fake}
+}
+
+// Operator retrieves the OperatorV1beta1Client
+func (c *Clientset) Operator() operatorv1beta1.OperatorV1beta1Interface {
+	return &fakeoperatorv1beta1.FakeOperatorV1beta1{Fake: &c.Fake}
 }
@@ -24,6 +24,7 @@ package fake
 
 import (
 	operatorv1alpha1 "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	operatorv1beta1 "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -36,6 +37,7 @@ var codecs = serializer.NewCodecFactory(scheme)
 var parameterCodec = runtime.NewParameterCodec(scheme)
 var localSchemeBuilder = runtime.SchemeBuilder{
 	operatorv1alpha1.AddToScheme,
+	operatorv1beta1.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
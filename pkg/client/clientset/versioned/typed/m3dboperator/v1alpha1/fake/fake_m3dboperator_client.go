@@ -32,10 +32,22 @@ type FakeOperatorV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeOperatorV1alpha1) M3DBBackups(namespace string) v1alpha1.M3DBBackupInterface {
+	return &FakeM3DBBackups{c, namespace}
+}
+
 func (c *FakeOperatorV1alpha1) M3DBClusters(namespace string) v1alpha1.M3DBClusterInterface {
 	return &FakeM3DBClusters{c, namespace}
 }
 
+func (c *FakeOperatorV1alpha1) M3DBNamespaces(namespace string) v1alpha1.M3DBNamespaceInterface {
+	return &FakeM3DBNamespaces{c, namespace}
+}
+
+func (c *FakeOperatorV1alpha1) M3DBRestores(namespace string) v1alpha1.M3DBRestoreInterface {
+	return &FakeM3DBRestores{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeOperatorV1alpha1) RESTClient() rest.Interface {
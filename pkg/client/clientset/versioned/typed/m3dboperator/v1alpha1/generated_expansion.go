@@ -22,4 +22,10 @@
 
 package v1alpha1
 
+type M3DBBackupExpansion interface{}
+
 type M3DBClusterExpansion interface{}
+
+type M3DBNamespaceExpansion interface{}
+
+type M3DBRestoreExpansion interface{}
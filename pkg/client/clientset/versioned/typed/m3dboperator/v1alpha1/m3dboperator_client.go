@@ -31,7 +31,10 @@ import (
 
 type OperatorV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	M3DBBackupsGetter
 	M3DBClustersGetter
+	M3DBNamespacesGetter
+	M3DBRestoresGetter
 }
 
 // OperatorV1alpha1Client is used to interact with features provided by the operator.m3db.io group.
@@ -39,10 +42,22 @@ type OperatorV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *OperatorV1alpha1Client) M3DBBackups(namespace string) M3DBBackupInterface {
+	return newM3DBBackups(c, namespace)
+}
+
 func (c *OperatorV1alpha1Client) M3DBClusters(namespace string) M3DBClusterInterface {
 	return newM3DBClusters(c, namespace)
 }
 
+func (c *OperatorV1alpha1Client) M3DBNamespaces(namespace string) M3DBNamespaceInterface {
+	return newM3DBNamespaces(c, namespace)
+}
+
+func (c *OperatorV1alpha1Client) M3DBRestores(namespace string) M3DBRestoreInterface {
+	return newM3DBRestores(c, namespace)
+}
+
 // NewForConfig creates a new OperatorV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*OperatorV1alpha1Client, error) {
 	config := *c
@@ -26,6 +26,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	v1beta1 "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -57,8 +58,20 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=operator.m3db.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("m3dbbackups"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBBackups().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("m3dbclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBClusters().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("m3dbnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBNamespaces().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("m3dbrestores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1alpha1().M3DBRestores().Informer()}, nil
+
+		// Group=operator.m3db.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("m3dbclusters"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1beta1().M3DBClusters().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("m3dbnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Operator().V1beta1().M3DBNamespaces().Informer()}, nil
 
 	}
 
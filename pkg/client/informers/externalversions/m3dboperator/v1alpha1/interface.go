@@ -28,8 +28,14 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// M3DBBackups returns a M3DBBackupInformer.
+	M3DBBackups() M3DBBackupInformer
 	// M3DBClusters returns a M3DBClusterInformer.
 	M3DBClusters() M3DBClusterInformer
+	// M3DBNamespaces returns a M3DBNamespaceInformer.
+	M3DBNamespaces() M3DBNamespaceInformer
+	// M3DBRestores returns a M3DBRestoreInformer.
+	M3DBRestores() M3DBRestoreInformer
 }
 
 type version struct {
@@ -43,7 +49,22 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// M3DBBackups returns a M3DBBackupInformer.
+func (v *version) M3DBBackups() M3DBBackupInformer {
+	return &m3DBBackupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // M3DBClusters returns a M3DBClusterInformer.
 func (v *version) M3DBClusters() M3DBClusterInformer {
 	return &m3DBClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// M3DBNamespaces returns a M3DBNamespaceInformer.
+func (v *version) M3DBNamespaces() M3DBNamespaceInformer {
+	return &m3DBNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// M3DBRestores returns a M3DBRestoreInformer.
+func (v *version) M3DBRestores() M3DBRestoreInformer {
+	return &m3DBRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
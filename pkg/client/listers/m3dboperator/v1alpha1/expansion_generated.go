@@ -22,6 +22,14 @@
 
 package v1alpha1
 
+// M3DBBackupListerExpansion allows custom methods to be added to
+// M3DBBackupLister.
+type M3DBBackupListerExpansion interface{}
+
+// M3DBBackupNamespaceListerExpansion allows custom methods to be added to
+// M3DBBackupNamespaceLister.
+type M3DBBackupNamespaceListerExpansion interface{}
+
 // M3DBClusterListerExpansion allows custom methods to be added to
 // M3DBClusterLister.
 type M3DBClusterListerExpansion interface{}
@@ -29,3 +37,19 @@ type M3DBClusterListerExpansion interface{}
 // M3DBClusterNamespaceListerExpansion allows custom methods to be added to
 // M3DBClusterNamespaceLister.
 type M3DBClusterNamespaceListerExpansion interface{}
+
+// M3DBNamespaceListerExpansion allows custom methods to be added to
+// M3DBNamespaceLister.
+type M3DBNamespaceListerExpansion interface{}
+
+// M3DBNamespaceNamespaceListerExpansion allows custom methods to be added to
+// M3DBNamespaceNamespaceLister.
+type M3DBNamespaceNamespaceListerExpansion interface{}
+
+// M3DBRestoreListerExpansion allows custom methods to be added to
+// M3DBRestoreLister.
+type M3DBRestoreListerExpansion interface{}
+
+// M3DBRestoreNamespaceListerExpansion allows custom methods to be added to
+// M3DBRestoreNamespaceLister.
+type M3DBRestoreNamespaceListerExpansion interface{}
@@ -27,12 +27,14 @@ import (
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/util/eventer"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -84,6 +86,12 @@ func (c *Controller) ensureConfigMap(cluster *myspec.M3DBCluster) error {
 		return err
 	}
 
+	return c.applyConfigMap(cluster, wantCM)
+}
+
+// applyConfigMap creates the configmap, or overwrites the data of an existing
+// configmap if it differs.
+func (c *Controller) applyConfigMap(cluster *myspec.M3DBCluster, wantCM *corev1.ConfigMap) error {
 	cmClient := c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace)
 
 	// Check if there is a configmap that exists. If so, overwrite it with the
@@ -125,3 +133,139 @@ func (c *Controller) ensureConfigMap(cluster *myspec.M3DBCluster) error {
 
 	return err
 }
+
+// ensureCoordinator creates or updates the Deployment and default configmap of
+// the cluster's separate coordinators, or removes them if the cluster no
+// longer runs separate coordinators.
+func (c *Controller) ensureCoordinator(cluster *myspec.M3DBCluster) error {
+	coordinator := cluster.Spec.Coordinator
+	if coordinator == nil {
+		return c.removeCoordinator(cluster)
+	}
+
+	var configHash string
+	if coordinator.ConfigMapName != nil {
+		if *coordinator.ConfigMapName == "" {
+			return errEmptyConfigMap
+		}
+	} else {
+		wantCM, err := k8sops.GenerateCoordinatorConfigMap(cluster)
+		if err != nil {
+			return err
+		}
+
+		if err := c.applyConfigMap(cluster, wantCM); err != nil {
+			return err
+		}
+
+		configHash, err = k8sops.ConfigMapDataHash(wantCM)
+		if err != nil {
+			return err
+		}
+	}
+
+	desired, err := k8sops.GenerateCoordinatorDeployment(cluster)
+	if err != nil {
+		return err
+	}
+
+	// Roll the coordinators when their rendered config changes, e.g. once the
+	// cluster runs aggregators.
+	if configHash != "" {
+		desired.Spec.Template.Annotations = map[string]string{
+			annotations.ConfigHash: configHash,
+		}
+	}
+
+	hash, err := k8sops.DeploymentSpecHash(desired)
+	if err != nil {
+		return err
+	}
+	desired.Annotations[annotations.SpecHash] = hash
+
+	depClient := c.kubeClient.AppsV1().Deployments(cluster.Namespace)
+	dep, err := depClient.Get(desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if _, err := depClient.Create(desired); err != nil {
+			return pkgerrors.WithMessagef(err, "error creating deployment %s", desired.Name)
+		}
+
+		c.logger.Info("created coordinator deployment",
+			zap.String("cluster", cluster.Name),
+			zap.String("deployment", desired.Name))
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate, "created coordinator deployment %s", desired.Name)
+		return nil
+	}
+
+	if dep.Annotations[annotations.SpecHash] == hash {
+		return nil
+	}
+
+	// Merge rather than replace metadata so we don't clobber labels or
+	// annotations added by other tools.
+	dep = dep.DeepCopy()
+	if dep.Labels == nil {
+		dep.Labels = make(map[string]string)
+	}
+	for k, v := range desired.Labels {
+		dep.Labels[k] = v
+	}
+	if dep.Annotations == nil {
+		dep.Annotations = make(map[string]string)
+	}
+	for k, v := range desired.Annotations {
+		dep.Annotations[k] = v
+	}
+	dep.Spec.Replicas = desired.Spec.Replicas
+	dep.Spec.Template = desired.Spec.Template
+
+	if _, err := depClient.Update(dep); err != nil {
+		return pkgerrors.WithMessagef(err, "error updating deployment %s", dep.Name)
+	}
+
+	c.logger.Info("updated coordinator deployment to match cluster spec",
+		zap.String("cluster", cluster.Name),
+		zap.String("deployment", dep.Name),
+		zap.String("hash", hash))
+	c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "updating coordinator deployment %s", dep.Name)
+	return nil
+}
+
+// removeCoordinator deletes the Deployment and default configmap of the
+// cluster's separate coordinators if they exist.
+func (c *Controller) removeCoordinator(cluster *myspec.M3DBCluster) error {
+	depName := k8sops.CoordinatorDeploymentName(cluster.Name)
+	depClient := c.kubeClient.AppsV1().Deployments(cluster.Namespace)
+	dep, err := depClient.Get(depName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only remove a deployment the operator created for this cluster.
+	if !metav1.IsControlledBy(dep, cluster) {
+		return nil
+	}
+
+	if err := depClient.Delete(depName, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessagef(err, "error deleting deployment %s", depName)
+	}
+
+	cmName := k8sops.CoordinatorConfigMapName(cluster.Name)
+	err = c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).Delete(cmName, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessagef(err, "error deleting configmap %s", cmName)
+	}
+
+	c.logger.Info("removed coordinator deployment",
+		zap.String("cluster", cluster.Name),
+		zap.String("deployment", depName))
+	c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulDelete, "removed coordinator deployment %s", depName)
+	return nil
+}
@@ -25,6 +25,9 @@ import (
 	"strings"
 	"testing"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubernetes/utils/pointer"
@@ -34,19 +37,25 @@ import (
 )
 
 func registerValidConfigMap(content string) error {
+	return registerAssets(map[string]string{"default-config.tmpl": content})
+}
+
+// registerAssets registers a zip fs containing the given files.
+func registerAssets(files map[string]string) error {
 	sw := &strings.Builder{}
 	zw := zip.NewWriter(sw)
 
-	// Build a zip fs containing our test config map
-	fw, err := zw.Create("default-config.tmpl")
-	if err != nil {
-		return err
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write([]byte(content))
+		if err != nil {
+			return err
+		}
 	}
-	_, err = fw.Write([]byte(content))
-	if err != nil {
-		return err
-	}
-	err = zw.Close()
+	err := zw.Close()
 	if err != nil {
 		return err
 	}
@@ -117,3 +126,76 @@ func TestEnsureConfigMap_Update(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "new_config_data", cm.Data["m3.yml"])
 }
+
+func TestEnsureCoordinator(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{Replicas: 2}
+	deps := newTestDeps(t, &testOpts{})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	require.NoError(t, registerAssets(map[string]string{
+		"default-config.tmpl":             "node_config",
+		"default-coordinator-config.tmpl": "coordinator_config",
+	}))
+
+	depClient := deps.kubeClient.AppsV1().Deployments(cluster.Namespace)
+	cmClient := deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace)
+
+	require.NoError(t, controller.ensureCoordinator(cluster))
+
+	dep, err := depClient.Get("m3coordinator-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *dep.Spec.Replicas)
+	assert.Equal(t, cluster.Name, dep.OwnerReferences[0].Name)
+
+	cm, err := cmClient.Get("m3coordinator-config-map-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "coordinator_config", cm.Data["m3.yml"])
+
+	// Nothing changes if the spec is unchanged.
+	numActions := len(deps.kubeClient.Actions())
+	require.NoError(t, controller.ensureCoordinator(cluster))
+	for _, action := range deps.kubeClient.Actions()[numActions:] {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+
+	cluster.Spec.Coordinator.Replicas = 3
+	require.NoError(t, controller.ensureCoordinator(cluster))
+	dep, err = depClient.Get("m3coordinator-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), *dep.Spec.Replicas)
+
+	// Removing the coordinator spec removes the coordinators.
+	cluster.Spec.Coordinator = nil
+	require.NoError(t, controller.ensureCoordinator(cluster))
+	_, err = depClient.Get("m3coordinator-cluster-simple", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = cmClient.Get("m3coordinator-config-map-cluster-simple", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	require.NoError(t, controller.ensureCoordinator(cluster))
+}
+
+func TestEnsureCoordinatorCustomConfigMap(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{
+		ConfigMapName: pointer.StringPtr("my-config"),
+	}
+	deps := newTestDeps(t, &testOpts{})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	require.NoError(t, controller.ensureCoordinator(cluster))
+
+	dep, err := deps.kubeClient.AppsV1().Deployments(cluster.Namespace).Get("m3coordinator-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "my-config", dep.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	cms, err := deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cms.Items)
+
+	cluster.Spec.Coordinator.ConfigMapName = pointer.StringPtr("")
+	assert.Equal(t, errEmptyConfigMap, controller.ensureCoordinator(cluster))
+}
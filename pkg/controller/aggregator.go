@@ -0,0 +1,424 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+	"github.com/m3db/m3db-operator/pkg/m3admin/topic"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	appsv1 "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ensureAggregator sets up the cluster's aggregators and the ingest path
+// through them, or removes the aggregators if the cluster no longer runs them.
+// The m3msg topics and placements are set up first since aggregators and
+// coordinators wait for them on startup, then the aggregator StatefulSets are
+// created or scaled to match the placement.
+func (c *Controller) ensureAggregator(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	agg := cluster.Spec.Aggregator
+	if agg == nil {
+		return c.removeAggregator(cluster)
+	}
+
+	if err := c.ensureAggregatorTopics(ctx, cluster); err != nil {
+		return pkgerrors.WithMessage(err, "error ensuring aggregator topics")
+	}
+
+	shardSets, err := c.reconcileAggregatorPlacement(ctx, cluster)
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error reconciling aggregator placement")
+	}
+
+	if err := c.ensureCoordinatorPlacement(ctx, cluster); err != nil {
+		return pkgerrors.WithMessage(err, "error ensuring coordinator placement")
+	}
+
+	var configHash string
+	if agg.ConfigMapName == nil {
+		wantCM, err := k8sops.GenerateAggregatorConfigMap(cluster)
+		if err != nil {
+			return err
+		}
+
+		if err := c.applyConfigMap(cluster, wantCM); err != nil {
+			return err
+		}
+
+		configHash, err = k8sops.ConfigMapDataHash(wantCM)
+		if err != nil {
+			return err
+		}
+	}
+
+	svc, err := k8sops.GenerateAggregatorService(cluster)
+	if err != nil {
+		return err
+	}
+
+	// As for M3DB, the headless service must exist before the StatefulSets.
+	if err := c.k8sclient.EnsureService(cluster, svc); err != nil {
+		return pkgerrors.WithMessagef(err, "error creating service '%s'", svc.Name)
+	}
+
+	for _, group := range agg.IsolationGroups {
+		set, err := k8sops.GenerateAggregatorStatefulSet(cluster, group.Name, shardSets)
+		if err != nil {
+			return err
+		}
+
+		if configHash != "" {
+			set.Spec.Template.Annotations = map[string]string{
+				annotations.ConfigHash: configHash,
+			}
+		}
+
+		if err := c.applyAggregatorStatefulSet(cluster, set); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyAggregatorStatefulSet creates an aggregator StatefulSet, or updates it
+// if its spec hash or size differs from the generated one. Unlike M3DB sets,
+// aggregator sets are updated all at once: each shard set is mirrored across
+// the isolation groups, so rolling one pod at a time across groups doesn't
+// buy any availability.
+func (c *Controller) applyAggregatorStatefulSet(cluster *myspec.M3DBCluster, desired *appsv1.StatefulSet) error {
+	if err := annotateSpecHash(desired); err != nil {
+		return err
+	}
+
+	setClient := c.kubeClient.AppsV1().StatefulSets(cluster.Namespace)
+	set, err := setClient.Get(desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		if _, err := setClient.Create(desired); err != nil {
+			return pkgerrors.WithMessagef(err, "error creating statefulset %s", desired.Name)
+		}
+
+		c.logger.Info("created aggregator statefulset",
+			zap.String("cluster", cluster.Name),
+			zap.String("statefulSet", desired.Name))
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate, "created aggregator statefulset %s", desired.Name)
+		return nil
+	}
+
+	if !metav1.IsControlledBy(set, cluster) {
+		return fmt.Errorf("statefulset %s is not controlled by cluster %s", set.Name, cluster.Name)
+	}
+
+	if set.Annotations[annotations.SpecHash] == desired.Annotations[annotations.SpecHash] &&
+		set.Spec.Replicas != nil && *set.Spec.Replicas == *desired.Spec.Replicas {
+		return nil
+	}
+
+	// Merge rather than replace metadata so we don't clobber labels or
+	// annotations added by other tools.
+	set = set.DeepCopy()
+	if set.Labels == nil {
+		set.Labels = make(map[string]string)
+	}
+	for k, v := range desired.Labels {
+		set.Labels[k] = v
+	}
+	if set.Annotations == nil {
+		set.Annotations = make(map[string]string)
+	}
+	for k, v := range desired.Annotations {
+		set.Annotations[k] = v
+	}
+	set.Spec.Replicas = desired.Spec.Replicas
+	set.Spec.Template = desired.Spec.Template
+
+	if _, err := setClient.Update(set); err != nil {
+		return pkgerrors.WithMessagef(err, "error updating statefulset %s", set.Name)
+	}
+
+	c.logger.Info("updated aggregator statefulset",
+		zap.String("cluster", cluster.Name),
+		zap.String("statefulSet", set.Name),
+		zap.Int32("replicas", *set.Spec.Replicas))
+	c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "updating aggregator statefulset %s", set.Name)
+	return nil
+}
+
+// reconcileAggregatorPlacement initializes the aggregator placement, or adds
+// the shard sets missing from it, and returns the number of shard sets in the
+// placement. Removing shard sets isn't supported: if the spec asks for fewer
+// than the placement has, the aggregators are left at their current size.
+func (c *Controller) reconcileAggregatorPlacement(ctx context.Context, cluster *myspec.M3DBCluster) (int32, error) {
+	agg := cluster.Spec.Aggregator
+	desired := agg.IsolationGroups[0].NumInstances
+	plClient := c.adminClient.servicePlacementClientForCluster(cluster, placement.ServiceM3Aggregator)
+
+	pl, err := plClient.Get(ctx)
+	if err != nil {
+		if pkgerrors.Cause(err) != m3admin.ErrNotFound {
+			return 0, err
+		}
+
+		instances := aggregatorPlacementInstances(cluster, 0, desired)
+		req := &admin.PlacementInitRequest{
+			Instances:         make([]*placementpb.Instance, 0, len(instances)),
+			NumShards:         k8sops.AggregatorNumberOfShards(agg),
+			ReplicationFactor: agg.ReplicationFactor,
+		}
+		for i := range instances {
+			req.Instances = append(req.Instances, &instances[i])
+		}
+
+		if err := plClient.Init(ctx, req); err != nil {
+			return 0, err
+		}
+
+		c.logger.Info("initialized aggregator placement",
+			zap.String("cluster", cluster.Name),
+			zap.Int32("shardSets", desired))
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate, "initialized aggregator placement with %d shard sets", desired)
+		return desired, nil
+	}
+
+	var current int32
+	for _, inst := range pl.Instances() {
+		if id := int32(inst.ShardSetID()); id > current {
+			current = id
+		}
+	}
+
+	if current > desired {
+		c.logger.Warn("not removing aggregator shard sets, scaling down aggregators is not supported",
+			zap.String("cluster", cluster.Name),
+			zap.Int32("current", current),
+			zap.Int32("desired", desired))
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync,
+			"scaling down aggregators from %d to %d shard sets is not supported", current, desired)
+		return current, nil
+	}
+
+	if current == desired {
+		return current, nil
+	}
+
+	if err := plClient.Add(ctx, aggregatorPlacementInstances(cluster, current, desired)...); err != nil {
+		return 0, err
+	}
+
+	c.logger.Info("added aggregator shard sets to placement",
+		zap.String("cluster", cluster.Name),
+		zap.Int32("from", current),
+		zap.Int32("to", desired))
+	c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "scaling aggregators from %d to %d shard sets", current, desired)
+	return desired, nil
+}
+
+// aggregatorPlacementInstances returns the instances of the aggregator shard
+// sets [from, to), mirrored across every isolation group.
+func aggregatorPlacementInstances(cluster *myspec.M3DBCluster, from, to int32) []placementpb.Instance {
+	groups := cluster.Spec.Aggregator.IsolationGroups
+	instances := make([]placementpb.Instance, 0, int(to-from)*len(groups))
+	for ordinal := from; ordinal < to; ordinal++ {
+		for stsID, group := range groups {
+			instances = append(instances, k8sops.AggregatorPlacementInstance(cluster, stsID, group.Name, int(ordinal)))
+		}
+	}
+	return instances
+}
+
+// ensureCoordinatorPlacement initializes the coordinator placement the
+// aggregators deliver aggregated metrics through, if it doesn't exist.
+func (c *Controller) ensureCoordinatorPlacement(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	plClient := c.adminClient.servicePlacementClientForCluster(cluster, placement.ServiceM3Coordinator)
+
+	_, err := plClient.Get(ctx)
+	if err == nil {
+		return nil
+	}
+	if pkgerrors.Cause(err) != m3admin.ErrNotFound {
+		return err
+	}
+
+	inst := k8sops.CoordinatorPlacementInstance(cluster)
+	if err := plClient.Init(ctx, &admin.PlacementInitRequest{
+		Instances: []*placementpb.Instance{&inst},
+	}); err != nil {
+		return err
+	}
+
+	c.logger.Info("initialized coordinator placement", zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate, "initialized coordinator placement")
+	return nil
+}
+
+// ensureAggregatorTopics initializes the m3msg topics carrying metrics from the
+// coordinators to the aggregators and back, and registers their consumers.
+func (c *Controller) ensureAggregatorTopics(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	env := k8sops.DefaultM3ClusterEnvironmentName(cluster)
+	numShards := uint32(k8sops.AggregatorNumberOfShards(cluster.Spec.Aggregator))
+	topics := k8sops.DefaultAggregatorTopics
+	topicClient := c.adminClient.topicClientForCluster(cluster)
+
+	for _, t := range []struct {
+		name     string
+		consumer topic.ConsumerService
+	}{
+		{
+			// Every mirror of a shard set aggregates the shard's metrics.
+			name: topics.Ingest,
+			consumer: topic.ConsumerService{
+				ServiceID: topic.ServiceID{
+					Name:        placement.ServiceM3Aggregator,
+					Environment: env,
+					Zone:        k8sops.ServiceZone(cluster),
+				},
+				ConsumptionType: topic.ConsumptionTypeReplicated,
+			},
+		},
+		{
+			name: topics.Aggregated,
+			consumer: topic.ConsumerService{
+				ServiceID: topic.ServiceID{
+					Name:        placement.ServiceM3Coordinator,
+					Environment: env,
+					Zone:        k8sops.ServiceZone(cluster),
+				},
+				ConsumptionType: topic.ConsumptionTypeShared,
+			},
+		},
+	} {
+		existing, err := topicClient.Get(ctx, t.name)
+		if err != nil {
+			if pkgerrors.Cause(err) != m3admin.ErrNotFound {
+				return err
+			}
+
+			if err := topicClient.Init(ctx, t.name, numShards); err != nil {
+				return err
+			}
+
+			c.logger.Info("initialized topic", zap.String("cluster", cluster.Name), zap.String("topic", t.name))
+			c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate, "initialized topic %s", t.name)
+			existing = &topic.Topic{Name: t.name}
+		}
+
+		if existing.HasConsumer(t.consumer.ServiceID.Name) {
+			continue
+		}
+
+		if err := topicClient.AddConsumer(ctx, t.name, t.consumer); err != nil {
+			return err
+		}
+
+		c.logger.Info("added topic consumer",
+			zap.String("cluster", cluster.Name),
+			zap.String("topic", t.name),
+			zap.String("consumer", t.consumer.ServiceID.Name))
+	}
+
+	return nil
+}
+
+// removeAggregator deletes the StatefulSets, service and default configmap of
+// the cluster's aggregators if they exist. The aggregator placement and topics
+// are left in place, and are deleted along with the cluster's other etcd data.
+func (c *Controller) removeAggregator(cluster *myspec.M3DBCluster) error {
+	setClient := c.kubeClient.AppsV1().StatefulSets(cluster.Namespace)
+	selector := klabels.SelectorFromSet(k8sops.AggregatorLabels(cluster))
+	sets, err := c.statefulSetLister.StatefulSets(cluster.Namespace).List(selector)
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	for _, set := range sets {
+		// Only remove sets the operator created for this cluster.
+		if !metav1.IsControlledBy(set, cluster) {
+			continue
+		}
+
+		if err := setClient.Delete(set.Name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return pkgerrors.WithMessagef(err, "error deleting statefulset %s", set.Name)
+		}
+		removed = true
+	}
+
+	if !removed {
+		return nil
+	}
+
+	svcName := k8sops.AggregatorServiceName(cluster.Name)
+	if err := c.k8sclient.DeleteService(cluster, svcName); err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessagef(err, "error deleting service %s", svcName)
+	}
+
+	cmName := k8sops.AggregatorConfigMapName(cluster.Name)
+	err = c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).Delete(cmName, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessagef(err, "error deleting configmap %s", cmName)
+	}
+
+	c.logger.Info("removed aggregators", zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulDelete, "removed aggregators")
+	return nil
+}
+
+// deleteAggregatorMetadata deletes the aggregator and coordinator placements
+// and the m3msg topics of a cluster running aggregators.
+func (c *Controller) deleteAggregatorMetadata(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	topicClient := c.adminClient.topicClientForCluster(cluster)
+	topics := k8sops.DefaultAggregatorTopics
+	for _, name := range []string{topics.Ingest, topics.Aggregated} {
+		if err := topicClient.Delete(ctx, name); err != nil && pkgerrors.Cause(err) != m3admin.ErrNotFound {
+			return pkgerrors.WithMessagef(err, "error deleting topic %s", name)
+		}
+	}
+
+	for _, service := range []string{placement.ServiceM3Aggregator, placement.ServiceM3Coordinator} {
+		plClient := c.adminClient.servicePlacementClientForCluster(cluster, service)
+		if err := plClient.Delete(ctx); err != nil && pkgerrors.Cause(err) != m3admin.ErrNotFound {
+			return pkgerrors.WithMessagef(err, "error deleting %s placement", service)
+		}
+	}
+
+	c.logger.Info("deleted aggregator placements and topics", zap.String("cluster", cluster.Name))
+	return nil
+}
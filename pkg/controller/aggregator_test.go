@@ -0,0 +1,209 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/topic"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAggregatorTestCluster(t *testing.T, instances int32) *myspec.M3DBCluster {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{Replicas: 1}
+	cluster.Spec.Aggregator = &myspec.AggregatorSpec{
+		ReplicationFactor: 2,
+		IsolationGroups: myspec.IsolationGroups{
+			{Name: "group1", NumInstances: instances},
+			{Name: "group2", NumInstances: instances},
+		},
+	}
+	return cluster
+}
+
+func aggregatorPlacement(shardSets int) placement.Placement {
+	var instances []placement.Instance
+	for i := 0; i < shardSets; i++ {
+		instances = append(instances, placement.NewInstance().
+			SetID("agg").
+			SetShardSetID(uint32(i+1)))
+	}
+	return placement.NewPlacement().SetInstances(instances)
+}
+
+func registerAggregatorAssets(t *testing.T) {
+	require.NoError(t, registerAssets(map[string]string{
+		"default-config.tmpl":             "node_config",
+		"default-coordinator-config.tmpl": "coordinator_config",
+		"default-aggregator-config.tmpl":  "aggregator_config",
+	}))
+}
+
+func expectAggregatorTopics(deps *testDeps) {
+	deps.topicClient.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&topic.Topic{
+		ConsumerServices: []topic.ConsumerService{
+			{ServiceID: topic.ServiceID{Name: "m3aggregator"}},
+			{ServiceID: topic.ServiceID{Name: "m3coordinator"}},
+		},
+	}, nil).AnyTimes()
+	deps.coordPlClient.EXPECT().Get(gomock.Any()).Return(placement.NewPlacement(), nil).AnyTimes()
+}
+
+func TestEnsureAggregatorInit(t *testing.T) {
+	cluster := newAggregatorTestCluster(t, 2)
+	deps := newTestDeps(t, &testOpts{})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+	registerAggregatorAssets(t)
+
+	topics := k8sops.DefaultAggregatorTopics
+	gomock.InOrder(
+		deps.topicClient.EXPECT().Get(gomock.Any(), topics.Ingest).Return(nil, m3admin.ErrNotFound),
+		deps.topicClient.EXPECT().Init(gomock.Any(), topics.Ingest, uint32(64)).Return(nil),
+		deps.topicClient.EXPECT().AddConsumer(gomock.Any(), topics.Ingest, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, svc topic.ConsumerService) error {
+				assert.Equal(t, "m3aggregator", svc.ServiceID.Name)
+				assert.Equal(t, topic.ConsumptionTypeReplicated, svc.ConsumptionType)
+				return nil
+			}),
+		deps.topicClient.EXPECT().Get(gomock.Any(), topics.Aggregated).Return(&topic.Topic{
+			Name: topics.Aggregated,
+			ConsumerServices: []topic.ConsumerService{
+				{ServiceID: topic.ServiceID{Name: "m3coordinator"}},
+			},
+		}, nil),
+	)
+	deps.aggPlClient.EXPECT().Get(gomock.Any()).Return(nil, m3admin.ErrNotFound)
+	deps.aggPlClient.EXPECT().Init(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *admin.PlacementInitRequest) error {
+		assert.Len(t, req.Instances, 4)
+		assert.Equal(t, int32(64), req.NumShards)
+		assert.Equal(t, int32(2), req.ReplicationFactor)
+		return nil
+	})
+	deps.coordPlClient.EXPECT().Get(gomock.Any()).Return(nil, m3admin.ErrNotFound)
+	deps.coordPlClient.EXPECT().Init(gomock.Any(), gomock.Any()).Return(nil)
+
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+
+	cm, err := deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).
+		Get("m3aggregator-config-map-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "aggregator_config", cm.Data["m3.yml"])
+
+	_, err = deps.kubeClient.CoreV1().Services(cluster.Namespace).
+		Get("m3aggregator-cluster-simple", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	for _, name := range []string{"m3aggregator-cluster-simple-rep0", "m3aggregator-cluster-simple-rep1"} {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), *set.Spec.Replicas)
+		assert.NotEmpty(t, set.Spec.Template.Annotations[annotations.ConfigHash])
+	}
+}
+
+func TestEnsureAggregatorScale(t *testing.T) {
+	cluster := newAggregatorTestCluster(t, 1)
+	deps := newTestDeps(t, &testOpts{})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+	registerAggregatorAssets(t)
+	expectAggregatorTopics(deps)
+
+	setClient := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace)
+	deps.aggPlClient.EXPECT().Get(gomock.Any()).Return(aggregatorPlacement(1), nil)
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+	set, err := setClient.Get("m3aggregator-cluster-simple-rep0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), *set.Spec.Replicas)
+
+	// Scaling up adds the new shard set to the placement before growing the
+	// sets.
+	cluster.Spec.Aggregator.IsolationGroups[0].NumInstances = 2
+	cluster.Spec.Aggregator.IsolationGroups[1].NumInstances = 2
+	deps.aggPlClient.EXPECT().Get(gomock.Any()).Return(aggregatorPlacement(1), nil)
+	deps.aggPlClient.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+	for _, name := range []string{"m3aggregator-cluster-simple-rep0", "m3aggregator-cluster-simple-rep1"} {
+		set, err := setClient.Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), *set.Spec.Replicas)
+	}
+
+	// Scaling down isn't supported, the sets keep their size.
+	cluster.Spec.Aggregator.IsolationGroups[0].NumInstances = 1
+	cluster.Spec.Aggregator.IsolationGroups[1].NumInstances = 1
+	deps.aggPlClient.EXPECT().Get(gomock.Any()).Return(aggregatorPlacement(2), nil)
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+	set, err = setClient.Get("m3aggregator-cluster-simple-rep0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *set.Spec.Replicas)
+}
+
+func TestEnsureAggregatorRemove(t *testing.T) {
+	cluster := newAggregatorTestCluster(t, 1)
+	deps := newTestDeps(t, &testOpts{})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+	registerAggregatorAssets(t)
+	expectAggregatorTopics(deps)
+
+	deps.aggPlClient.EXPECT().Get(gomock.Any()).Return(aggregatorPlacement(1), nil)
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+
+	// Wait for the lister to see the sets.
+	selector := klabels.SelectorFromSet(k8sops.AggregatorLabels(cluster))
+	require.NoError(t, wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		sets, err := controller.statefulSetLister.StatefulSets(cluster.Namespace).List(selector)
+		return len(sets) == 2, err
+	}))
+
+	cluster.Spec.Aggregator = nil
+	require.NoError(t, controller.ensureAggregator(context.Background(), cluster))
+
+	_, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).
+		Get("m3aggregator-cluster-simple-rep0", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = deps.kubeClient.CoreV1().Services(cluster.Namespace).
+		Get("m3aggregator-cluster-simple", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = deps.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).
+		Get("m3aggregator-config-map-cluster-simple", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
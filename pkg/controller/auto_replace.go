@@ -0,0 +1,380 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes/utils/pointer"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultAutoReplaceGracePeriod = 10 * time.Minute
+
+// autoReplaceRecheckInterval is how often a failed pod is checked again while
+// it can't be replaced yet.
+const autoReplaceRecheckInterval = 30 * time.Second
+
+// podFailure describes why a pod has failed.
+type podFailure struct {
+	reason string
+	// since is when the pod failed.
+	since time.Time
+	// needsNewVolume is set if the pod can only be replaced by deleting its
+	// volume claim.
+	needsNewVolume bool
+}
+
+func autoReplaceGracePeriod(spec *myspec.AutoReplaceSpec) time.Duration {
+	if spec.GracePeriodSeconds == 0 {
+		return defaultAutoReplaceGracePeriod
+	}
+	return time.Duration(spec.GracePeriodSeconds) * time.Second
+}
+
+// replaceFailedPods deletes the first of the cluster's pods that has been
+// failed for longer than the grace period, so that its StatefulSet recreates
+// it and the new pod replaces the failed pod's instance in the placement. Pods
+// that haven't been failed for long enough are checked again once their grace
+// period is over. A pod is only replaced while every other replica of its
+// shards is available, so that a second failure during the replacement can't
+// lose quorum. It returns true if a pod was deleted.
+func (c *Controller) replaceFailedPods(ctx context.Context, cluster *myspec.M3DBCluster) (bool, error) {
+	spec := cluster.Spec.AutoReplace
+	if spec == nil || !cluster.Status.HasInitializedPlacement() {
+		return false, nil
+	}
+
+	pods, err := c.podLister.Pods(cluster.Namespace).List(m3dbNodeSelector(cluster))
+	if err != nil {
+		return false, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	// Sort so that the same pod is replaced first on every pass.
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	gracePeriod := autoReplaceGracePeriod(spec)
+	var (
+		recheckAfter time.Duration
+		pl           placement.Placement
+	)
+	for _, pod := range pods {
+		failure, err := c.podFailure(pod)
+		if err != nil {
+			return false, pkgerrors.WithMessagef(err, "error checking pod '%s'", pod.Name)
+		}
+		if failure == nil {
+			continue
+		}
+
+		podLogger := c.logger.With(zap.String("pod", pod.Name), zap.String("reason", failure.reason))
+		if failedFor := c.clock.Since(failure.since); failedFor < gracePeriod {
+			podLogger.Info("waiting for grace period before replacing failed pod",
+				zap.Duration("failedFor", failedFor))
+			if remaining := gracePeriod - failedFor; recheckAfter == 0 || remaining < recheckAfter {
+				recheckAfter = remaining
+			}
+			continue
+		}
+
+		if failure.needsNewVolume && !spec.DeletePersistentVolumeClaim {
+			podLogger.Warn("not replacing failed pod, its volume claim must be deleted")
+			c.recorder.WarningEvent(cluster, eventer.ReasonLongerThanUsual,
+				"pod %s has failed (%s) but deletePersistentVolumeClaim is not set", pod.Name, failure.reason)
+			continue
+		}
+
+		if pl == nil {
+			pl, err = c.adminClient.placementClientForCluster(cluster).Get(ctx)
+			if err != nil {
+				podLogger.Warn("not replacing failed pod, can't fetch placement", zap.Error(err))
+				recheckAfter = autoReplaceRecheckInterval
+				break
+			}
+		}
+		if peer, ok := unavailablePeer(cluster, pl, pod); ok {
+			podLogger.Warn("not replacing failed pod until its peers are available", zap.String("peer", peer))
+			if recheckAfter == 0 || autoReplaceRecheckInterval < recheckAfter {
+				recheckAfter = autoReplaceRecheckInterval
+			}
+			continue
+		}
+
+		podLogger.Info("replacing failed pod")
+		if err := c.deleteFailedPod(pod, failure.needsNewVolume); err != nil {
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, "failed to delete failed pod %s: %s", pod.Name, err)
+			return false, err
+		}
+		c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted pod %s to replace it: %s", pod.Name, failure.reason)
+		c.scope.Counter("failed_pod_replaced").Inc(1)
+		return true, nil
+	}
+
+	if recheckAfter > 0 {
+		key, err := cache.MetaNamespaceKeyFunc(cluster)
+		if err != nil {
+			return false, err
+		}
+		c.clusterWorkQueue.AddAfter(key, recheckAfter)
+	}
+
+	return false, nil
+}
+
+// deleteFailedPod deletes the pod and, if it needs a new volume, its data
+// volume claim. Any other failure keeps the claim, so that the pod's data is
+// still there if its node comes back. The pod is deleted without a grace
+// period: a failed node can't confirm that it has stopped the pod, and its
+// StatefulSet won't recreate it until then.
+func (c *Controller) deleteFailedPod(pod *corev1.Pod, deleteClaim bool) error {
+	// The claim is deleted first, it's kept until the pod is gone and won't be
+	// reused by its replacement.
+	if deleteClaim {
+		claim := k8sops.DataVolumeClaimName(pod.Name)
+		err := c.kubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Delete(claim, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return pkgerrors.WithMessagef(err, "error deleting volume claim '%s'", claim)
+		}
+	}
+
+	err := c.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{
+		GracePeriodSeconds: pointer.Int64Ptr(0),
+		Preconditions:      metav1.NewUIDPreconditions(string(pod.UID)),
+	})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessagef(err, "error deleting pod '%s'", pod.Name)
+	}
+
+	return nil
+}
+
+// unavailablePeer returns an instance holding a replica of one of the pod's
+// shards whose replica isn't available. The pod's instances are found by
+// hostname rather than identity, which may depend on a node that's gone.
+func unavailablePeer(cluster *myspec.M3DBCluster, pl placement.Placement, pod *corev1.Pod) (string, bool) {
+	hostname := pod.Name + "." + k8sops.HeadlessServiceName(cluster.Name)
+	for _, inst := range pl.Instances() {
+		if inst.Hostname() != hostname {
+			continue
+		}
+		for _, id := range inst.Shards().AllIDs() {
+			for _, peer := range pl.InstancesForShard(id) {
+				if peer.Hostname() == hostname {
+					continue
+				}
+				if s, ok := peer.Shards().Shard(id); !ok || s.State() != shard.Available {
+					return peer.ID(), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// podFailure returns why the pod has failed, or nil if it hasn't.
+func (c *Controller) podFailure(pod *corev1.Pod) (*podFailure, error) {
+	if nodeName := pod.Spec.NodeName; nodeName != "" {
+		node, err := c.nodeLister.Get(nodeName)
+		if kerrors.IsNotFound(err) {
+			// Pods of deleted nodes are garbage collected by Kubernetes, but their
+			// status isn't updated in the meantime.
+			if since, ok := podNotReadySince(pod); ok {
+				return &podFailure{reason: fmt.Sprintf("node %s no longer exists", nodeName), since: since}, nil
+			}
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if ready, since := nodeReady(node); !ready {
+			return &podFailure{reason: fmt.Sprintf("node %s is not ready", nodeName), since: since}, nil
+		}
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodUnknown:
+		if since, ok := podNotReadySince(pod); ok {
+			return &podFailure{reason: "pod is in phase Unknown", since: since}, nil
+		}
+
+	case corev1.PodPending:
+		// Pods that can't be scheduled for lack of resources aren't helped by
+		// replacing them, only those pinned to a node by their volume are.
+		cond := podCondition(pod, corev1.PodScheduled)
+		if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != corev1.PodReasonUnschedulable {
+			return nil, nil
+		}
+
+		local, err := c.hasLocalVolume(pod)
+		if err != nil {
+			return nil, err
+		}
+		if local {
+			return &podFailure{
+				reason:         "pod can't be scheduled on the node of its local volume",
+				since:          cond.LastTransitionTime.Time,
+				needsNewVolume: true,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// hasLocalVolume returns true if the pod's data volume claim is bound to a
+// volume that can only be used from some nodes.
+func (c *Controller) hasLocalVolume(pod *corev1.Pod) (bool, error) {
+	claim, err := c.claimLister.PersistentVolumeClaims(pod.Namespace).Get(k8sops.DataVolumeClaimName(pod.Name))
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if claim.Spec.VolumeName == "" {
+		return false, nil
+	}
+
+	if local, ok := c.localVolumes.Load(claim.Spec.VolumeName); ok {
+		return local.(bool), nil
+	}
+
+	volume, err := c.kubeClient.CoreV1().PersistentVolumes().Get(claim.Spec.VolumeName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	affinity := volume.Spec.NodeAffinity
+	local := affinity != nil && affinity.Required != nil
+	c.localVolumes.Store(volume.Name, local)
+	return local, nil
+}
+
+func podCondition(pod *corev1.Pod, condType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// podNotReadySince returns when the pod last became not ready, or false if
+// it's ready.
+func podNotReadySince(pod *corev1.Pod) (time.Time, bool) {
+	cond := podCondition(pod, corev1.PodReady)
+	if cond == nil {
+		return pod.CreationTimestamp.Time, true
+	}
+	if cond.Status == corev1.ConditionTrue {
+		return time.Time{}, false
+	}
+	return cond.LastTransitionTime.Time, true
+}
+
+// nodeReady returns whether the node is ready and, if it isn't, since when.
+func nodeReady(node *corev1.Node) (bool, time.Time) {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue, cond.LastTransitionTime.Time
+		}
+	}
+	return false, node.CreationTimestamp.Time
+}
+
+// enqueueNodeClusters enqueues the clusters with pods on the node, so that
+// they can replace pods that failed along with it.
+func (c *Controller) enqueueNodeClusters(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		node, ok = tombstone.Obj.(*corev1.Node)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding tombstone, invalid type"))
+			return
+		}
+	}
+
+	pods, err := c.podLister.List(klabels.Everything())
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error listing pods: %v", err))
+		return
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName == node.Name {
+			c.enqueuePodCluster(pod)
+		}
+	}
+}
+
+// enqueuePodCluster enqueues the cluster of a pod, e.g. once the pod has been
+// evicted.
+func (c *Controller) enqueuePodCluster(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding object, invalid type"))
+			return
+		}
+		pod, ok = tombstone.Obj.(*corev1.Pod)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("error decoding tombstone, invalid type"))
+			return
+		}
+	}
+
+	cluster, err := c.getParentCluster(pod)
+	if err != nil {
+		c.logger.Debug("not enqueueing cluster of pod", zap.String("pod", pod.Name), zap.Error(err))
+		return
+	}
+
+	c.enqueueCluster(cluster)
+}
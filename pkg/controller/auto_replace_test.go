@@ -0,0 +1,303 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+
+	"github.com/m3db/m3/src/cluster/shard"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNode(name string, ready corev1.ConditionStatus, since time.Time) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             ready,
+					LastTransitionTime: metav1.NewTime(since),
+				},
+			},
+		},
+	}
+}
+
+// setUnschedulable makes the pod pending on a volume pinned to a node, as
+// after its node was drained.
+func setUnschedulable(pod *corev1.Pod, since time.Time) (*corev1.PersistentVolumeClaim, *corev1.PersistentVolume) {
+	pod.Spec.NodeName = ""
+	pod.Status.Phase = corev1.PodPending
+	pod.Status.Conditions = []corev1.PodCondition{
+		{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionFalse,
+			Reason:             corev1.PodReasonUnschedulable,
+			LastTransitionTime: metav1.NewTime(since),
+		},
+	}
+
+	volume := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-" + pod.Name},
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{},
+			},
+		},
+	}
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sops.DataVolumeClaimName(pod.Name),
+			Namespace: pod.Namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{VolumeName: volume.Name},
+	}
+	return claim, volume
+}
+
+func TestReplaceFailedPods(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		autoReplace *myspec.AutoReplaceSpec
+		// fail makes the given pod fail and returns any objects it needs.
+		fail func(pod *corev1.Pod) []runtime.Object
+		// peersInitializing leaves the failed pod's peers initializing their
+		// shards.
+		peersInitializing bool
+		expReplaced       bool
+		expClaimDeleted   bool
+	}{
+		{
+			name: "disabled",
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionFalse, now.Add(-time.Hour))}
+			},
+		},
+		{
+			name:        "node not ready",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionUnknown, now.Add(-10*time.Minute))}
+			},
+			expReplaced: true,
+		},
+		{
+			name:        "node not ready keeps claim",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300, DeletePersistentVolumeClaim: true},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionFalse, now.Add(-10*time.Minute))}
+			},
+			expReplaced: true,
+		},
+		{
+			name:        "node not ready with peers initializing",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionFalse, now.Add(-10*time.Minute))}
+			},
+			peersInitializing: true,
+		},
+		{
+			name:        "node not ready within grace period",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionFalse, now.Add(-time.Minute))}
+			},
+		},
+		{
+			name:        "node not ready within default grace period",
+			autoReplace: &myspec.AutoReplaceSpec{},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionFalse, now.Add(-5*time.Minute))}
+			},
+		},
+		{
+			name:        "node deleted",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:               corev1.PodReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+					},
+				}
+				return nil
+			},
+			expReplaced: true,
+		},
+		{
+			name:        "pod unknown",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				pod.Status.Phase = corev1.PodUnknown
+				pod.Status.Conditions = []corev1.PodCondition{
+					{
+						Type:               corev1.PodReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+					},
+				}
+				return []runtime.Object{newTestNode(pod.Spec.NodeName, corev1.ConditionTrue, now.Add(-time.Hour))}
+			},
+			expReplaced: true,
+		},
+		{
+			name:        "pending on local volume",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300, DeletePersistentVolumeClaim: true},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				claim, volume := setUnschedulable(pod, now.Add(-10*time.Minute))
+				return []runtime.Object{claim, volume}
+			},
+			expReplaced:     true,
+			expClaimDeleted: true,
+		},
+		{
+			name:        "pending on local volume without deleting claims",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				claim, volume := setUnschedulable(pod, now.Add(-10*time.Minute))
+				return []runtime.Object{claim, volume}
+			},
+		},
+		{
+			name:        "pending on network volume",
+			autoReplace: &myspec.AutoReplaceSpec{GracePeriodSeconds: 300, DeletePersistentVolumeClaim: true},
+			fail: func(pod *corev1.Pod) []runtime.Object {
+				claim, volume := setUnschedulable(pod, now.Add(-10*time.Minute))
+				volume.Spec.NodeAffinity = nil
+				return []runtime.Object{claim, volume}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster, pods := newBackupTestCluster(t)
+			cluster.Spec.AutoReplace = test.autoReplace
+
+			failed := pods[1]
+			objects := test.fail(failed)
+			for _, pod := range pods {
+				objects = append(objects, pod)
+				if pod != failed {
+					objects = append(objects, newTestNode(pod.Spec.NodeName, corev1.ConditionTrue, now.Add(-time.Hour)))
+				}
+			}
+			claimName := k8sops.DataVolumeClaimName(failed.Name)
+			// Unschedulable pods come with their claim.
+			if failed.Spec.NodeName != "" {
+				objects = append(objects, &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: cluster.Namespace},
+				})
+			}
+
+			deps := newTestDeps(t, &testOpts{
+				kubeObjects: objects,
+				crdObjects:  []runtime.Object{cluster},
+				clock:       clock.NewFakeClock(now),
+			})
+			defer deps.cleanup()
+			c := deps.newController(t)
+
+			state := shard.Available
+			if test.peersInitializing {
+				state = shard.Initializing
+			}
+			identifyPods(deps.idProvider, pods, nil)
+			pl := backupTestPlacement(t, cluster, pods, deps, state)
+			deps.placementClient.EXPECT().Get(gomock.Any()).Return(pl, nil).AnyTimes()
+
+			replaced, err := c.replaceFailedPods(context.Background(), cluster)
+			require.NoError(t, err)
+			assert.Equal(t, test.expReplaced, replaced)
+
+			for _, pod := range pods {
+				_, err := deps.kubeClient.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+				if test.expReplaced && pod == failed {
+					assert.True(t, kerrors.IsNotFound(err), "pod %s should be deleted", pod.Name)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			_, err = deps.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace).Get(claimName, metav1.GetOptions{})
+			if test.expClaimDeleted {
+				assert.True(t, kerrors.IsNotFound(err), "claim should be deleted")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHasLocalVolume(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	claim, volume := setUnschedulable(pods[0], time.Now())
+	unbound := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: k8sops.DataVolumeClaimName(pods[1].Name), Namespace: cluster.Namespace},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{claim, unbound, volume},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+
+	for _, test := range []struct {
+		pod      *corev1.Pod
+		expLocal bool
+	}{
+		{pod: pods[0], expLocal: true},
+		{pod: pods[1]},
+		// No claim at all.
+		{pod: pods[2]},
+	} {
+		local, err := c.hasLocalVolume(test.pod)
+		require.NoError(t, err)
+		assert.Equal(t, test.expLocal, local, test.pod.Name)
+	}
+
+	// Claims come from the informer cache, and volumes are only fetched once.
+	deps.kubeClient.ClearActions()
+	local, err := c.hasLocalVolume(pods[0])
+	require.NoError(t, err)
+	assert.True(t, local)
+	assert.Empty(t, deps.kubeClient.Actions())
+}
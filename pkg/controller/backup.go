@@ -0,0 +1,426 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+	"github.com/m3db/m3db-operator/pkg/validation"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) enqueueBackup(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.backupWorkQueue.AddRateLimited(key)
+	c.scope.Counter("enqueued_backup_event").Inc(int64(1))
+}
+
+// enqueueClusterBackupsAndRestores enqueues the unfinished backups of the
+// given cluster and the unfinished restores creating it.
+func (c *Controller) enqueueClusterBackupsAndRestores(obj interface{}) {
+	cluster, ok := obj.(*myspec.M3DBCluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("expected cluster, got %#v", obj))
+		return
+	}
+
+	backups, err := c.backupLister.M3DBBackups(cluster.Namespace).List(klabels.Everything())
+	if err != nil {
+		c.logger.Error("error listing backups", zap.String("cluster", cluster.Name), zap.Error(err))
+		return
+	}
+	for _, backup := range backups {
+		if backup.Spec.ClusterName == cluster.Name && !backup.Status.IsFinished() {
+			c.enqueueBackup(backup)
+		}
+	}
+
+	restores, err := c.restoreLister.M3DBRestores(cluster.Namespace).List(klabels.Everything())
+	if err != nil {
+		c.logger.Error("error listing restores", zap.String("cluster", cluster.Name), zap.Error(err))
+		return
+	}
+	for _, restore := range restores {
+		if restore.Status.ClusterName == cluster.Name && !restore.Status.IsFinished() {
+			c.enqueueRestore(restore)
+		}
+	}
+}
+
+// enqueueJobOwner enqueues the backup or restore that created a job.
+func (c *Controller) enqueueJobOwner(obj interface{}) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("expected job, got %#v", obj))
+		return
+	}
+
+	ref := metav1.GetControllerOf(job)
+	if ref == nil {
+		return
+	}
+
+	key := job.Namespace + "/" + ref.Name
+	switch ref.Kind {
+	case m3dboperator.BackupResourceKind:
+		c.backupWorkQueue.AddRateLimited(key)
+	case m3dboperator.RestoreResourceKind:
+		c.restoreWorkQueue.AddRateLimited(key)
+	}
+}
+
+func (c *Controller) runBackupLoop() {
+	for c.processBackupQueueItem() {
+	}
+}
+
+func (c *Controller) processBackupQueueItem() bool {
+	obj, shutdown := c.backupWorkQueue.Get()
+	c.scope.Counter("dequeued_backup_event").Inc(int64(1))
+	if shutdown {
+		return false
+	}
+
+	if c.isStopping() {
+		c.backupWorkQueue.Done(obj)
+		return false
+	}
+
+	// Closure so we can defer workQueue.Done.
+	err := func(obj interface{}) error {
+		defer c.backupWorkQueue.Done(obj)
+
+		ctx, cancel := c.workContext()
+		defer cancel()
+
+		key, ok := obj.(string)
+		if !ok {
+			c.backupWorkQueue.Forget(obj)
+			runtime.HandleError(fmt.Errorf("expected string from queue, got %#v", obj))
+			return nil
+		}
+
+		if err := c.handleBackupEvent(ctx, key); err != nil {
+			return fmt.Errorf("error syncing backup '%s': %v", key, err)
+		}
+
+		c.backupWorkQueue.Forget(obj)
+		c.logger.Info("successfully synced backup", zap.String("key", key))
+
+		return nil
+	}(obj)
+
+	if err != nil {
+		runtime.HandleError(err)
+	}
+
+	return true
+}
+
+func (c *Controller) handleBackupEvent(ctx context.Context, key string) error {
+	kubeNamespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	backup, err := c.backupLister.M3DBBackups(kubeNamespace).Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// give up processing if this backup doesn't exist
+			runtime.HandleError(fmt.Errorf("backup '%s' no longer exists", key))
+			return nil
+		}
+
+		return err
+	}
+
+	// MUST deep copy to avoid corrupting the cache.
+	return c.handleBackupUpdate(ctx, backup.DeepCopy())
+}
+
+// handleBackupUpdate moves a backup through its phases. A pending backup
+// waits for every instance of its cluster to be available, then records the
+// cluster's spec, namespaces and shard assignments in a manifest and starts
+// one job per node uploading the node's data. A running backup completes once
+// every job succeeded, or fails as soon as one failed.
+func (c *Controller) handleBackupUpdate(ctx context.Context, backup *myspec.M3DBBackup) error {
+	if backup.Status.IsFinished() {
+		return nil
+	}
+
+	backupLogger := c.logger.With(
+		zap.String("backup", backup.Name),
+		zap.String("cluster", backup.Spec.ClusterName))
+
+	if backup.Status.Phase == myspec.BackupPhaseRunning {
+		return c.reconcileBackupJobs(backup)
+	}
+
+	if err := validation.ValidateBackup(backup); err != nil {
+		c.recorder.WarningEvent(backup, eventer.ReasonFailSync, "invalid backup: %v", err)
+		return c.failBackup(backup, fmt.Sprintf("invalid backup: %v", err))
+	}
+
+	cluster, err := c.clusterLister.M3DBClusters(backup.Namespace).Get(backup.Spec.ClusterName)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	if kerrors.IsNotFound(err) {
+		unmanaged, err := c.isUnmanagedCluster(backup.Namespace, backup.Spec.ClusterName)
+		if err != nil {
+			return err
+		}
+		if unmanaged {
+			backupLogger.Debug("ignoring backup of unmanaged cluster")
+			return nil
+		}
+		return c.failBackup(backup, fmt.Sprintf("cluster %s does not exist", backup.Spec.ClusterName))
+	}
+
+	if cluster.Spec.DataDirVolumeClaimTemplate == nil {
+		return c.failBackup(backup, fmt.Sprintf("cluster %s has no persistent volumes to back up", cluster.Name))
+	}
+
+	if !cluster.Status.HasInitializedPlacement() {
+		return c.setBackupPending(backup, "waiting for the cluster's placement to be initialized")
+	}
+
+	pl, err := c.adminClient.placementClientForCluster(cluster).Get(ctx)
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching placement")
+	}
+
+	pods, err := c.podLister.Pods(cluster.Namespace).List(m3dbNodeSelector(cluster))
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing pods")
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	// Every instance must be available and match a scheduled pod so that each
+	// shard's data is uploaded from a node holding all of it.
+	instances := make([]k8sops.BackupInstance, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			return c.setBackupPending(backup, fmt.Sprintf("waiting for pod %s to be scheduled", pod.Name))
+		}
+
+		inst, err := c.findPodInPlacement(cluster, pl, pod)
+		if pkgerrors.Cause(err) == errPodNotInPlacement {
+			return c.setBackupPending(backup, fmt.Sprintf("waiting for pod %s to join the placement", pod.Name))
+		}
+		if err != nil {
+			return err
+		}
+		if !inst.IsAvailable() {
+			return c.setBackupPending(backup, fmt.Sprintf("waiting for instance %s to be available", inst.ID()))
+		}
+
+		instances = append(instances, k8sops.BackupInstance{
+			ID:             inst.ID(),
+			PodName:        pod.Name,
+			IsolationGroup: inst.IsolationGroup(),
+			Shards:         inst.Shards().AllIDs(),
+		})
+	}
+	if len(instances) != pl.NumInstances() {
+		return c.setBackupPending(backup, fmt.Sprintf("waiting for the placement's %d instances to have pods, found %d",
+			pl.NumInstances(), len(instances)))
+	}
+
+	resp, err := c.adminClient.namespaceClientForCluster(cluster).List(ctx)
+	if err == nil && resp.Registry == nil {
+		err = errNilNamespaceRegistry
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing namespaces")
+	}
+	namespaces := make([]string, 0, len(resp.Registry.Namespaces))
+	for name := range resp.Registry.Namespaces {
+		namespaces = append(namespaces, name)
+	}
+	sort.Strings(namespaces)
+
+	now := c.clock.Now().UTC().Format(time.RFC3339)
+	manifest := &k8sops.BackupManifest{
+		Cluster:    cluster.Name,
+		Namespace:  cluster.Namespace,
+		CreatedAt:  now,
+		Spec:       cluster.Spec,
+		Namespaces: namespaces,
+		Instances:  instances,
+	}
+
+	cm, err := k8sops.GenerateBackupManifestConfigMap(backup, manifest)
+	if err != nil {
+		return err
+	}
+	if _, err := c.kubeClient.CoreV1().ConfigMaps(backup.Namespace).Create(cm); err != nil && !kerrors.IsAlreadyExists(err) {
+		return pkgerrors.WithMessage(err, "error creating backup manifest")
+	}
+
+	jobs := []*batchv1.Job{k8sops.GenerateBackupManifestJob(backup)}
+	for i, pod := range pods {
+		job, err := k8sops.GenerateBackupJob(backup, pod, i)
+		if err != nil {
+			return pkgerrors.WithMessagef(err, "error generating backup job for pod '%s'", pod.Name)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := c.createJobs(jobs); err != nil {
+		return err
+	}
+
+	backupLogger.Info("started backup", zap.Int("nodes", len(pods)))
+	c.recorder.NormalEvent(backup, eventer.ReasonCreating, "backing up %d nodes to %s",
+		len(pods), k8sops.BackupLocation(backup))
+
+	status := backup.Status.DeepCopy()
+	status.Phase = myspec.BackupPhaseRunning
+	status.Message = fmt.Sprintf("uploading data of %d nodes", len(pods))
+	status.Location = k8sops.BackupLocation(backup)
+	status.StartTime = now
+	status.TotalNodes = int32(len(pods))
+	status.CompletedNodes = 0
+	return c.setBackupStatus(backup, status)
+}
+
+// reconcileBackupJobs updates a running backup from the state of its jobs.
+func (c *Controller) reconcileBackupJobs(backup *myspec.M3DBBackup) error {
+	jobs, err := c.jobLister.Jobs(backup.Namespace).List(klabels.SelectorFromSet(klabels.Set{
+		labels.Component: labels.ComponentBackup,
+		labels.Backup:    backup.Name,
+	}))
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing backup jobs")
+	}
+
+	manifestJob := k8sops.BackupManifestJobName(backup.Name)
+	var (
+		completedNodes   int32
+		manifestUploaded bool
+	)
+	for _, job := range jobs {
+		succeeded, failed := jobFinished(job)
+		if failed {
+			c.recorder.WarningEvent(backup, eventer.ReasonFailSync, "job %s failed", job.Name)
+			return c.failBackup(backup, fmt.Sprintf("job %s failed", job.Name))
+		}
+		if !succeeded {
+			continue
+		}
+		if job.Name == manifestJob {
+			manifestUploaded = true
+		} else {
+			completedNodes++
+		}
+	}
+
+	status := backup.Status.DeepCopy()
+	status.CompletedNodes = completedNodes
+	if manifestUploaded && completedNodes == status.TotalNodes {
+		status.Phase = myspec.BackupPhaseCompleted
+		status.Message = fmt.Sprintf("uploaded data of %d nodes", completedNodes)
+		status.CompletionTime = c.clock.Now().UTC().Format(time.RFC3339)
+		c.recorder.NormalEvent(backup, eventer.ReasonSuccessSync, "backup completed")
+	}
+	return c.setBackupStatus(backup, status)
+}
+
+// createJobs creates the given jobs, ignoring those that already exist.
+func (c *Controller) createJobs(jobs []*batchv1.Job) error {
+	for _, job := range jobs {
+		_, err := c.kubeClient.BatchV1().Jobs(job.Namespace).Create(job)
+		if err != nil && !kerrors.IsAlreadyExists(err) {
+			return pkgerrors.WithMessagef(err, "error creating job '%s'", job.Name)
+		}
+	}
+	return nil
+}
+
+// jobFinished returns whether the job succeeded or failed. Jobs are retried
+// up to their backoff limit before they're marked failed.
+func jobFinished(job *batchv1.Job) (succeeded, failed bool) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			succeeded = true
+		case batchv1.JobFailed:
+			failed = true
+		}
+	}
+	return succeeded, failed
+}
+
+func (c *Controller) setBackupPending(backup *myspec.M3DBBackup, msg string) error {
+	status := backup.Status.DeepCopy()
+	status.Phase = myspec.BackupPhasePending
+	status.Message = msg
+	return c.setBackupStatus(backup, status)
+}
+
+func (c *Controller) failBackup(backup *myspec.M3DBBackup, msg string) error {
+	c.logger.Warn("backup failed", zap.String("backup", backup.Name), zap.String("reason", msg))
+	status := backup.Status.DeepCopy()
+	status.Phase = myspec.BackupPhaseFailed
+	status.Message = msg
+	status.CompletionTime = c.clock.Now().UTC().Format(time.RFC3339)
+	return c.setBackupStatus(backup, status)
+}
+
+// setBackupStatus writes the backup's status if it changed.
+func (c *Controller) setBackupStatus(backup *myspec.M3DBBackup, status *myspec.M3DBBackupStatus) error {
+	if reflect.DeepEqual(status, &backup.Status) {
+		return nil
+	}
+
+	backup.Status = *status
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBBackups(backup.Namespace).UpdateStatus(backup); err != nil {
+		return pkgerrors.WithMessage(err, "error updating backup status")
+	}
+	return nil
+}
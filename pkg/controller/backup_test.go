@@ -0,0 +1,324 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBackupTestCluster returns a cluster with persistent volumes and an
+// initialized placement, and one scheduled pod per isolation group.
+func newBackupTestCluster(t *testing.T) (*myspec.M3DBCluster, []*corev1.Pod) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.DataDirVolumeClaimTemplate = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "m3db-data"},
+	}
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: corev1.ConditionTrue,
+	})
+
+	var pods []*corev1.Pod
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, 1)
+		require.NoError(t, err)
+		for _, pod := range podsForClusterSet(cluster, set, 1) {
+			pod.Spec.NodeName = "node-" + group.Name
+			pods = append(pods, pod)
+		}
+	}
+	return cluster, pods
+}
+
+// backupTestPlacement returns a placement of the pods in which every instance
+// owns every shard in the given state.
+func backupTestPlacement(
+	t *testing.T,
+	cluster *myspec.M3DBCluster,
+	pods []*corev1.Pod,
+	deps *testDeps,
+	state shard.State,
+) placement.Placement {
+	pl := placementFromPods(t, cluster, pods, deps.idProvider)
+	for _, inst := range pl.Instances() {
+		shards := make([]shard.Shard, cluster.Spec.NumberOfShards)
+		for i := range shards {
+			shards[i] = shard.NewShard(uint32(i)).SetState(state)
+		}
+		inst.SetShards(shard.NewShards(shards))
+	}
+	return pl
+}
+
+func newM3DBBackup(name, clusterName string) *myspec.M3DBBackup {
+	return &myspec.M3DBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fake",
+			UID:       "backup-uid",
+		},
+		Spec: myspec.M3DBBackupSpec{
+			ClusterName: clusterName,
+			Store: myspec.ObjectStore{
+				Endpoint:              "minio:9000",
+				Bucket:                "backups",
+				CredentialsSecretName: "minio-creds",
+			},
+		},
+	}
+}
+
+func getM3DBBackup(t *testing.T, deps *testDeps, name string) *myspec.M3DBBackup {
+	backup, err := deps.crdClient.OperatorV1alpha1().M3DBBackups("fake").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return backup
+}
+
+func newFinishedJob(name string, owner metav1.OwnerReference, jobLabels map[string]string,
+	condType batchv1.JobConditionType) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "fake",
+			Labels:          jobLabels,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+	}
+	if condType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: condType, Status: corev1.ConditionTrue}}
+	}
+	return job
+}
+
+func TestHandleBackupUpdateStartsJobs(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	backup := newM3DBBackup("backup-a", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+		crdObjects:  []runtime.Object{cluster, backup},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(backupTestPlacement(t, cluster, pods, deps, shard.Available), nil)
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": {},
+			"aggregated":     {},
+		}},
+	}, nil)
+
+	require.NoError(t, controller.handleBackupUpdate(context.Background(), backup.DeepCopy()))
+
+	backup = getM3DBBackup(t, deps, "backup-a")
+	assert.Equal(t, myspec.BackupPhaseRunning, backup.Status.Phase)
+	assert.Equal(t, "s3://backups/fake/backup-a", backup.Status.Location)
+	assert.Equal(t, int32(3), backup.Status.TotalNodes)
+	assert.NotEmpty(t, backup.Status.StartTime)
+
+	jobs, err := deps.kubeClient.BatchV1().Jobs("fake").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, jobs.Items, 4)
+
+	cm, err := deps.kubeClient.CoreV1().ConfigMaps("fake").
+		Get(k8sops.BackupManifestConfigMapName(backup.Name), metav1.GetOptions{})
+	require.NoError(t, err)
+	manifest, err := k8sops.ParseBackupManifest(cm)
+	require.NoError(t, err)
+	assert.Equal(t, cluster.Name, manifest.Cluster)
+	assert.Equal(t, []string{"aggregated", "metrics-10s:2d"}, manifest.Namespaces)
+	require.Len(t, manifest.Instances, 3)
+	assert.Equal(t, pods[0].Name, manifest.Instances[0].PodName)
+	assert.Len(t, manifest.Instances[0].Shards, 8)
+}
+
+func TestHandleBackupUpdateWaitsForInstances(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	backup := newM3DBBackup("backup-a", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objectsFromPods(pods...),
+		crdObjects:  []runtime.Object{cluster, backup},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(backupTestPlacement(t, cluster, pods, deps, shard.Initializing), nil)
+
+	require.NoError(t, controller.handleBackupUpdate(context.Background(), backup.DeepCopy()))
+
+	backup = getM3DBBackup(t, deps, "backup-a")
+	assert.Equal(t, myspec.BackupPhasePending, backup.Status.Phase)
+	assert.Contains(t, backup.Status.Message, "to be available")
+
+	jobs, err := deps.kubeClient.BatchV1().Jobs("fake").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, jobs.Items)
+}
+
+func TestHandleBackupUpdateFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cluster *myspec.M3DBCluster, backup *myspec.M3DBBackup)
+		expMsg string
+	}{
+		{
+			name: "no volumes",
+			modify: func(cluster *myspec.M3DBCluster, backup *myspec.M3DBBackup) {
+				cluster.Spec.DataDirVolumeClaimTemplate = nil
+			},
+			expMsg: "no persistent volumes",
+		},
+		{
+			name: "no cluster",
+			modify: func(cluster *myspec.M3DBCluster, backup *myspec.M3DBBackup) {
+				backup.Spec.ClusterName = "other"
+			},
+			expMsg: "cluster other does not exist",
+		},
+		{
+			name: "invalid store",
+			modify: func(cluster *myspec.M3DBCluster, backup *myspec.M3DBBackup) {
+				backup.Spec.Store.Bucket = ""
+			},
+			expMsg: "invalid backup",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster, _ := newBackupTestCluster(t)
+			backup := newM3DBBackup("backup-a", cluster.Name)
+			test.modify(cluster, backup)
+
+			deps := newTestDeps(t, &testOpts{
+				crdObjects: []runtime.Object{cluster, backup},
+			})
+			controller := deps.newController(t)
+			defer deps.cleanup()
+
+			require.NoError(t, controller.handleBackupUpdate(context.Background(), backup.DeepCopy()))
+
+			backup = getM3DBBackup(t, deps, "backup-a")
+			assert.Equal(t, myspec.BackupPhaseFailed, backup.Status.Phase)
+			assert.Contains(t, backup.Status.Message, test.expMsg)
+			assert.NotEmpty(t, backup.Status.CompletionTime)
+		})
+	}
+}
+
+func TestReconcileBackupJobs(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeStates   []batchv1.JobConditionType
+		manifestDone bool
+		expPhase     myspec.BackupPhase
+		expCompleted int32
+	}{
+		{
+			name:         "running",
+			nodeStates:   []batchv1.JobConditionType{batchv1.JobComplete, ""},
+			manifestDone: true,
+			expPhase:     myspec.BackupPhaseRunning,
+			expCompleted: 1,
+		},
+		{
+			name:         "manifest pending",
+			nodeStates:   []batchv1.JobConditionType{batchv1.JobComplete, batchv1.JobComplete},
+			expPhase:     myspec.BackupPhaseRunning,
+			expCompleted: 2,
+		},
+		{
+			name:         "completed",
+			nodeStates:   []batchv1.JobConditionType{batchv1.JobComplete, batchv1.JobComplete},
+			manifestDone: true,
+			expPhase:     myspec.BackupPhaseCompleted,
+			expCompleted: 2,
+		},
+		{
+			name:         "failed",
+			nodeStates:   []batchv1.JobConditionType{batchv1.JobComplete, batchv1.JobFailed},
+			manifestDone: true,
+			expPhase:     myspec.BackupPhaseFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := newM3DBBackup("backup-a", "cluster-a")
+			backup.Status.Phase = myspec.BackupPhaseRunning
+			backup.Status.TotalNodes = int32(len(test.nodeStates))
+
+			owner := *k8sops.GenerateBackupOwnerRef(backup)
+			jobLabels := map[string]string{
+				labels.Component: labels.ComponentBackup,
+				labels.Backup:    backup.Name,
+			}
+			var manifestState batchv1.JobConditionType
+			if test.manifestDone {
+				manifestState = batchv1.JobComplete
+			}
+			objects := []runtime.Object{
+				newFinishedJob(k8sops.BackupManifestJobName(backup.Name), owner, jobLabels, manifestState),
+			}
+			for i, state := range test.nodeStates {
+				objects = append(objects, newFinishedJob(k8sops.BackupJobName(backup.Name, i), owner, jobLabels, state))
+			}
+
+			deps := newTestDeps(t, &testOpts{
+				kubeObjects: objects,
+				crdObjects:  []runtime.Object{backup},
+			})
+			controller := deps.newController(t)
+			defer deps.cleanup()
+
+			require.NoError(t, controller.handleBackupUpdate(context.Background(), backup.DeepCopy()))
+
+			backup = getM3DBBackup(t, deps, "backup-a")
+			assert.Equal(t, test.expPhase, backup.Status.Phase)
+			if test.expPhase != myspec.BackupPhaseFailed {
+				assert.Equal(t, test.expCompleted, backup.Status.CompletedNodes)
+			}
+		})
+	}
+}
@@ -21,6 +21,8 @@
 package controller
 
 import (
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -32,15 +34,19 @@ import (
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
 	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+	"github.com/m3db/m3db-operator/pkg/m3admin/topic"
 	"github.com/m3db/m3db-operator/pkg/util/eventer"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	policyv1beta1listers "k8s.io/client-go/listers/policy/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
@@ -62,9 +68,19 @@ type testDeps struct {
 	idProvider        *podidentity.MockProvider
 	statefulSetLister appsv1listers.StatefulSetLister
 	podLister         corev1listers.PodLister
+	nodeLister        corev1listers.NodeLister
 	crdLister         crdlisters.M3DBClusterLister
+	namespaceLister   crdlisters.M3DBNamespaceLister
+	backupLister      crdlisters.M3DBBackupLister
+	restoreLister     crdlisters.M3DBRestoreLister
+	jobLister         batchv1listers.JobLister
+	claimLister       corev1listers.PersistentVolumeClaimLister
+	pdbLister         policyv1beta1listers.PodDisruptionBudgetLister
 	placementClient   *placement.MockClient
 	namespaceClient   *namespace.MockClient
+	aggPlClient       *placement.MockClient
+	coordPlClient     *placement.MockClient
+	topicClient       *topic.MockClient
 	clock             clock.Clock
 	mockController    *gomock.Controller
 	stopCh            chan struct{}
@@ -80,6 +96,15 @@ func (deps *testDeps) newController(t *testing.T) *Controller {
 	m.plClientFn = func(...placement.Option) (placement.Client, error) {
 		return deps.placementClient, nil
 	}
+	m.svcPlClientFn = func(service string, _ ...placement.Option) (placement.Client, error) {
+		if service == placement.ServiceM3Aggregator {
+			return deps.aggPlClient, nil
+		}
+		return deps.coordPlClient, nil
+	}
+	m.topicClientFn = func(...topic.Option) (topic.Client, error) {
+		return deps.topicClient, nil
+	}
 	k8sopsClient, err := k8sops.New(
 		k8sops.WithKClient(deps.kubeClient),
 		k8sops.WithCRDClient(deps.crdClient),
@@ -98,16 +123,61 @@ func (deps *testDeps) newController(t *testing.T) *Controller {
 		crdClient:     deps.crdClient,
 		podIDProvider: deps.idProvider,
 
-		clusterWorkQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName),
-		podWorkQueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName),
-		clusterLister:     deps.crdLister,
-		statefulSetLister: deps.statefulSetLister,
-		podLister:         deps.podLister,
+		clusterWorkQueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName),
+		namespaceWorkQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), namespaceWorkQueueName),
+		podWorkQueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName),
+		backupWorkQueue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), backupWorkQueueName),
+		restoreWorkQueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), restoreWorkQueueName),
+		clusterSelector:    klabels.Everything(),
+		clusterLister:      deps.crdLister,
+		namespaceLister:    deps.namespaceLister,
+		statefulSetLister:  deps.statefulSetLister,
+		podLister:          deps.podLister,
+		nodeLister:         deps.nodeLister,
+		backupLister:       deps.backupLister,
+		restoreLister:      deps.restoreLister,
+		jobLister:          deps.jobLister,
+		claimLister:        deps.claimLister,
+		pdbLister:          deps.pdbLister,
 
+		config: Configuration{
+			AdminClient: AdminClientConfiguration{PlacementSet: true},
+		},
 		recorder: eventer.NewNopPoster(),
 	}
 }
 
+// recordingPoster is an eventer.Poster that records the warning events it's
+// given.
+type recordingPoster struct {
+	sync.Mutex
+	warnings []string
+}
+
+var _ eventer.Poster = (*recordingPoster)(nil)
+
+func (p *recordingPoster) NormalEvent(object runtime.Object, reason, message string, args ...interface{}) {
+}
+
+func (p *recordingPoster) WarningEvent(object runtime.Object, reason, message string, args ...interface{}) {
+	p.Lock()
+	defer p.Unlock()
+	p.warnings = append(p.warnings, fmt.Sprintf(message, args...))
+}
+
+func (p *recordingPoster) warningEvents() []string {
+	p.Lock()
+	defer p.Unlock()
+	return append([]string(nil), p.warnings...)
+}
+
+// newEmptyClusterLister returns a cluster lister backed by an empty cache, as
+// if no clusters were selected by the controller.
+func newEmptyClusterLister() crdlisters.M3DBClusterLister {
+	return crdlisters.NewM3DBClusterLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+}
+
 func (deps *testDeps) cleanup() {
 	if atomic.CompareAndSwapInt32(&deps.closed, 0, 1) {
 		close(deps.stopCh)
@@ -126,6 +196,9 @@ func newTestDeps(t *testing.T, opts *testOpts) *testDeps {
 
 	deps.placementClient = placement.NewMockClient(deps.mockController)
 	deps.namespaceClient = namespace.NewMockClient(deps.mockController)
+	deps.aggPlClient = placement.NewMockClient(deps.mockController)
+	deps.coordPlClient = placement.NewMockClient(deps.mockController)
+	deps.topicClient = topic.NewMockClient(deps.mockController)
 	deps.idProvider = podidentity.NewMockProvider(deps.mockController)
 
 	if deps.clock == nil {
@@ -135,13 +208,27 @@ func newTestDeps(t *testing.T, opts *testOpts) *testDeps {
 	kubeInformers := kubeinformers.NewSharedInformerFactory(deps.kubeClient, 0)
 	sets := kubeInformers.Apps().V1().StatefulSets()
 	pods := kubeInformers.Core().V1().Pods()
+	nodes := kubeInformers.Core().V1().Nodes()
+	jobs := kubeInformers.Batch().V1().Jobs()
+	claims := kubeInformers.Core().V1().PersistentVolumeClaims()
+	pdbs := kubeInformers.Policy().V1beta1().PodDisruptionBudgets()
 
 	crdInformers := crdinformers.NewSharedInformerFactory(deps.crdClient, 0)
 	crds := crdInformers.Operator().V1alpha1().M3DBClusters()
+	nsCRDs := crdInformers.Operator().V1alpha1().M3DBNamespaces()
+	backups := crdInformers.Operator().V1alpha1().M3DBBackups()
+	restores := crdInformers.Operator().V1alpha1().M3DBRestores()
 
 	deps.statefulSetLister = sets.Lister()
 	deps.podLister = pods.Lister()
+	deps.nodeLister = nodes.Lister()
 	deps.crdLister = crds.Lister()
+	deps.namespaceLister = nsCRDs.Lister()
+	deps.backupLister = backups.Lister()
+	deps.restoreLister = restores.Lister()
+	deps.jobLister = jobs.Lister()
+	deps.claimLister = claims.Lister()
+	deps.pdbLister = pdbs.Lister()
 
 	go kubeInformers.Start(deps.stopCh)
 	go crdInformers.Start(deps.stopCh)
@@ -151,7 +238,14 @@ func newTestDeps(t *testing.T, opts *testOpts) *testDeps {
 		warmCh <- cache.WaitForCacheSync(deps.stopCh,
 			sets.Informer().HasSynced,
 			pods.Informer().HasSynced,
+			nodes.Informer().HasSynced,
 			crds.Informer().HasSynced,
+			nsCRDs.Informer().HasSynced,
+			jobs.Informer().HasSynced,
+			backups.Informer().HasSynced,
+			restores.Informer().HasSynced,
+			claims.Informer().HasSynced,
+			pdbs.Informer().HasSynced,
 		)
 	}()
 
@@ -21,10 +21,12 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
@@ -32,24 +34,31 @@ import (
 	samplescheme "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/scheme"
 	clusterlisters "github.com/m3db/m3db-operator/pkg/client/listers/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 	"github.com/m3db/m3db-operator/pkg/util/eventer"
+	"github.com/m3db/m3db-operator/pkg/validation"
 
 	m3placement "github.com/m3db/m3/src/cluster/placement"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
@@ -60,15 +69,16 @@ import (
 )
 
 const (
-	controllerName       = "m3db-controller"
-	clusterWorkQueueName = "m3dbcluster-work-queue"
-	podWorkQueueName     = "pods-work-queue"
+	controllerName         = "m3db-controller"
+	clusterWorkQueueName   = "m3dbcluster-work-queue"
+	namespaceWorkQueueName = "m3dbnamespace-work-queue"
+	podWorkQueueName       = "pods-work-queue"
+	backupWorkQueueName    = "m3dbbackup-work-queue"
+	restoreWorkQueueName   = "m3dbrestore-work-queue"
 )
 
 var (
-	errOrphanedPod         = errors.New("pod does not belong to an m3db cluster")
-	errInvalidNumIsoGroups = errors.New("number of isolationgroups not equal to replication factor")
-	errNonUniqueIsoGroups  = errors.New("isolation group names are not unique")
+	errOrphanedPod = errors.New("pod does not belong to an m3db cluster")
 )
 
 // Configuration contains parameters for the controller.
@@ -79,6 +89,35 @@ type Configuration struct {
 
 	// EnableValidation controls whether OpenAPI validation is enabled on the CRD.
 	EnableValidation bool
+
+	// StorageVersion is the API version the CRDs' objects are persisted in.
+	// When set, existing objects are migrated to it on startup.
+	StorageVersion string
+
+	// ConversionWebhook configures the webhook converting objects between the
+	// CRDs' versions. v1beta1 is only served when it's set.
+	ConversionWebhook *k8sops.ConversionWebhook
+
+	// MetadataSnapshotInterval is how often the placement and namespaces of
+	// each cluster are snapshotted, so that they can be restored if etcd loses
+	// them. Snapshots are disabled if zero.
+	MetadataSnapshotInterval time.Duration
+
+	// MetadataSnapshotRetention is the number of snapshots kept per cluster.
+	// Defaults to 5.
+	MetadataSnapshotRetention int
+
+	// RequireSecureAdminAPI makes the controller refuse to manage clusters
+	// whose coordinator admin API isn't called over TLS with credentials.
+	RequireSecureAdminAPI bool
+
+	// AdminClient configures the clients of the coordinators' admin APIs.
+	AdminClient AdminClientConfiguration
+
+	// Watch restricts the clusters the controller manages. The informer
+	// factories passed to the controller must be restricted the same way, see
+	// NewInformerFactories.
+	Watch WatchConfiguration
 }
 
 // Controller object
@@ -96,16 +135,39 @@ type Controller struct {
 	kubeClient kubernetes.Interface
 	crdClient  clientset.Interface
 
+	clusterSelector    klabels.Selector
 	clusterLister      clusterlisters.M3DBClusterLister
 	clustersSynced     cache.InformerSynced
 	statefulSetLister  appslisters.StatefulSetLister
 	statefulSetsSynced cache.InformerSynced
 	podLister          corelisters.PodLister
 	podsSynced         cache.InformerSynced
-
-	clusterWorkQueue workqueue.RateLimitingInterface
-	podWorkQueue     workqueue.RateLimitingInterface
-	recorder         eventer.Poster
+	nodeLister         corelisters.NodeLister
+	nodesSynced        cache.InformerSynced
+	namespaceLister    clusterlisters.M3DBNamespaceLister
+	namespacesSynced   cache.InformerSynced
+	backupLister       clusterlisters.M3DBBackupLister
+	backupsSynced      cache.InformerSynced
+	restoreLister      clusterlisters.M3DBRestoreLister
+	restoresSynced     cache.InformerSynced
+	jobLister          batchlisters.JobLister
+	jobsSynced         cache.InformerSynced
+	claimLister        corelisters.PersistentVolumeClaimLister
+	claimsSynced       cache.InformerSynced
+	pdbLister          policylisters.PodDisruptionBudgetLister
+	pdbsSynced         cache.InformerSynced
+
+	// localVolumes caches whether persistent volumes, by name, can only be used
+	// from some nodes. A volume's node affinity can't change once it's
+	// created, so it's only fetched once rather than watching every volume.
+	localVolumes sync.Map
+
+	clusterWorkQueue   workqueue.RateLimitingInterface
+	namespaceWorkQueue workqueue.RateLimitingInterface
+	podWorkQueue       workqueue.RateLimitingInterface
+	backupWorkQueue    workqueue.RateLimitingInterface
+	restoreWorkQueue   workqueue.RateLimitingInterface
+	recorder           eventer.Poster
 }
 
 // New creates new instance of Controller
@@ -133,25 +195,52 @@ func New(opts ...Option) (*Controller, error) {
 	}
 
 	adminOpts := []m3admin.Option{m3admin.WithLogger(logger)}
+	adminConfig := options.config.AdminClient
+	if adminConfig.Timeout > 0 {
+		adminOpts = append(adminOpts, m3admin.WithTimeout(adminConfig.Timeout))
+	}
+	if adminConfig.RetryPolicy != nil {
+		adminOpts = append(adminOpts, m3admin.WithRetryPolicy(*adminConfig.RetryPolicy))
+	}
+	adminOpts = append(adminOpts, options.adminClientOpts...)
 	multiClient := newMultiAdminClient(adminOpts, logger)
+	multiClient.breakerOpts = adminConfig.CircuitBreaker
 	if options.kubectlProxy {
 		multiClient.clusterURLFn = clusterURLProxy
 	}
+	multiClient.secretFn = func(namespace, name string) (*corev1.Secret, error) {
+		return kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	}
 
 	statefulSetInformer := kubeInformerFactory.Apps().V1().StatefulSets()
 	podInformer := kubeInformerFactory.Core().V1().Pods()
+	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
+	jobInformer := kubeInformerFactory.Batch().V1().Jobs()
+	claimInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	pdbInformer := kubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets()
 	m3dbClusterInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBClusters()
+	m3dbNamespaceInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBNamespaces()
+	m3dbBackupInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBBackups()
+	m3dbRestoreInformer := m3dbClusterInformerFactory.Operator().V1alpha1().M3DBRestores()
 
 	samplescheme.AddToScheme(scheme.Scheme)
 
 	clusterWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkQueueName)
+	namespaceWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), namespaceWorkQueueName)
 	podWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), podWorkQueueName)
+	backupWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), backupWorkQueueName)
+	restoreWorkQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), restoreWorkQueueName)
 
 	r, err := eventer.NewEventRecorder(eventer.WithClient(kubeClient), eventer.WithLogger(logger), eventer.WithComponent(controllerName))
 	if err != nil {
 		return nil, err
 	}
 
+	clusterSelector, err := options.config.Watch.clusterSelector()
+	if err != nil {
+		return nil, err
+	}
+
 	p := &Controller{
 		lock:          &sync.Mutex{},
 		logger:        logger,
@@ -166,23 +255,49 @@ func New(opts ...Option) (*Controller, error) {
 		kubeClient: kubeClient,
 		crdClient:  crdClient,
 
+		clusterSelector:    clusterSelector,
 		clusterLister:      m3dbClusterInformer.Lister(),
 		clustersSynced:     m3dbClusterInformer.Informer().HasSynced,
 		statefulSetLister:  statefulSetInformer.Lister(),
 		statefulSetsSynced: statefulSetInformer.Informer().HasSynced,
 		podLister:          podInformer.Lister(),
 		podsSynced:         podInformer.Informer().HasSynced,
-
-		clusterWorkQueue: clusterWorkQueue,
-		podWorkQueue:     podWorkQueue,
+		nodeLister:         nodeInformer.Lister(),
+		nodesSynced:        nodeInformer.Informer().HasSynced,
+		namespaceLister:    m3dbNamespaceInformer.Lister(),
+		namespacesSynced:   m3dbNamespaceInformer.Informer().HasSynced,
+		backupLister:       m3dbBackupInformer.Lister(),
+		backupsSynced:      m3dbBackupInformer.Informer().HasSynced,
+		restoreLister:      m3dbRestoreInformer.Lister(),
+		restoresSynced:     m3dbRestoreInformer.Informer().HasSynced,
+		jobLister:          jobInformer.Lister(),
+		jobsSynced:         jobInformer.Informer().HasSynced,
+		claimLister:        claimInformer.Lister(),
+		claimsSynced:       claimInformer.Informer().HasSynced,
+		pdbLister:          pdbInformer.Lister(),
+		pdbsSynced:         pdbInformer.Informer().HasSynced,
+
+		clusterWorkQueue:   clusterWorkQueue,
+		namespaceWorkQueue: namespaceWorkQueue,
+		podWorkQueue:       podWorkQueue,
+		backupWorkQueue:    backupWorkQueue,
+		restoreWorkQueue:   restoreWorkQueue,
 		// TODO(celina): figure out if we actually need a recorder for each namespace
 		recorder: r,
 	}
 
 	m3dbClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: p.enqueueCluster,
+		AddFunc: func(obj interface{}) {
+			p.enqueueCluster(obj)
+			p.enqueueClusterNamespaces(obj)
+			p.enqueueClusterBackupsAndRestores(obj)
+		},
 		UpdateFunc: func(old, new interface{}) {
 			p.enqueueCluster(new)
+			// Namespaces may be waiting on their cluster to become reachable, and
+			// backups and restores on its instances to become available.
+			p.enqueueClusterNamespaces(new)
+			p.enqueueClusterBackupsAndRestores(new)
 		},
 		DeleteFunc: func(obj interface{}) {
 			// TODO(schallert): what do we want to do on delete? clean up the etcd
@@ -209,11 +324,72 @@ func New(opts ...Option) (*Controller, error) {
 		DeleteFunc: p.handleStatefulSetUpdate,
 	})
 
+	m3dbNamespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueNamespace,
+		UpdateFunc: func(old, new interface{}) {
+			p.enqueueNamespace(new)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// No-op, cleanup happens before the deletion finalizer is removed.
+		},
+	})
+
 	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: p.enqueuePod,
 		UpdateFunc: func(old, new interface{}) {
 			p.enqueuePod(new)
 		},
+		// Evicted pods are deleted, their cluster may have to replace them if
+		// they can't be rescheduled.
+		DeleteFunc: p.enqueuePodCluster,
+	})
+
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			// Nodes update their status every few seconds, only changes to their
+			// readiness matter.
+			oldReady, _ := nodeReady(old.(*corev1.Node))
+			newReady, _ := nodeReady(new.(*corev1.Node))
+			if oldReady == newReady {
+				return
+			}
+
+			p.enqueueNodeClusters(new)
+		},
+		DeleteFunc: p.enqueueNodeClusters,
+	})
+
+	m3dbBackupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueBackup,
+		UpdateFunc: func(old, new interface{}) {
+			p.enqueueBackup(new)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// No-op, the backup's jobs are garbage collected.
+		},
+	})
+
+	m3dbRestoreInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueRestore,
+		UpdateFunc: func(old, new interface{}) {
+			p.enqueueRestore(new)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// No-op, the restore's jobs are garbage collected.
+		},
+	})
+
+	jobInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueJobOwner,
+		UpdateFunc: func(old, new interface{}) {
+			oldJob := old.(*batchv1.Job)
+			newJob := new.(*batchv1.Job)
+			if newJob.ResourceVersion == oldJob.ResourceVersion {
+				return
+			}
+
+			p.enqueueJobOwner(new)
+		},
 		DeleteFunc: func(obj interface{}) {
 			// No-op
 		},
@@ -222,36 +398,149 @@ func New(opts ...Option) (*Controller, error) {
 	return p, nil
 }
 
+// ensureCRDs creates or updates the operator's CRDs and, if a storage version
+// is configured, migrates their objects to it.
+func (c *Controller) ensureCRDs() error {
+	opts := k8sops.CRDOptions{
+		EnableValidation:  c.config.EnableValidation,
+		StorageVersion:    c.config.StorageVersion,
+		ConversionWebhook: c.config.ConversionWebhook,
+	}
+
+	if err := c.k8sclient.CreateOrUpdateCRD(m3dboperator.Name, opts); err != nil {
+		return pkgerrors.WithMessage(err, "could not create or update CRD")
+	}
+	if err := c.k8sclient.CreateOrUpdateCRD(m3dboperator.NamespaceName, opts); err != nil {
+		return pkgerrors.WithMessage(err, "could not create or update namespace CRD")
+	}
+	if err := c.k8sclient.CreateOrUpdateCRD(m3dboperator.BackupName, opts); err != nil {
+		return pkgerrors.WithMessage(err, "could not create or update backup CRD")
+	}
+	if err := c.k8sclient.CreateOrUpdateCRD(m3dboperator.RestoreName, opts); err != nil {
+		return pkgerrors.WithMessage(err, "could not create or update restore CRD")
+	}
+
+	if c.config.StorageVersion == "" {
+		return nil
+	}
+
+	// Backups and restores only have a v1alpha1 version.
+	for _, name := range []string{m3dboperator.Name, m3dboperator.NamespaceName} {
+		if err := c.k8sclient.MigrateCRDStorageVersion(name, c.config.StorageVersion); err != nil {
+			return pkgerrors.WithMessagef(err, "could not migrate storage version of CRD '%s'", name)
+		}
+	}
+
+	return nil
+}
+
 // Run drives the controller event loop.
+//
+// Run blocks until stopCh is closed, at which point it shuts down the work
+// queues, cancels the context of in-flight work and waits for it to finish
+// before returning. Once a controller has been stopped it cannot be run again.
 func (c *Controller) Run(nWorkers int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
-	defer c.clusterWorkQueue.ShutDown()
+
+	// Signal workers not to pick up any more items (we may no longer be the
+	// leader) and shut down the queues. Queues panic if shut down twice.
+	var shutdownOnce sync.Once
+	shutdown := func() {
+		shutdownOnce.Do(func() {
+			close(c.doneCh)
+			c.clusterWorkQueue.ShutDown()
+			c.namespaceWorkQueue.ShutDown()
+			c.podWorkQueue.ShutDown()
+			c.backupWorkQueue.ShutDown()
+			c.restoreWorkQueue.ShutDown()
+		})
+	}
+	defer shutdown()
 
 	c.logger.Info("starting Operator controller")
 	if c.config.ManageCRD {
-		if err := c.k8sclient.CreateOrUpdateCRD(m3dboperator.Name, c.config.EnableValidation); err != nil {
-			return pkgerrors.WithMessage(err, "could not create or update CRD")
+		if err := c.ensureCRDs(); err != nil {
+			return err
 		}
 	}
 
 	c.logger.Info("waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.clustersSynced, c.statefulSetsSynced, c.podsSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.clustersSynced, c.statefulSetsSynced, c.podsSynced,
+		c.nodesSynced, c.namespacesSynced, c.backupsSynced, c.restoresSynced, c.jobsSynced, c.claimsSynced, c.pdbsSynced); !ok {
 		return errors.New("caches failed to sync")
 	}
 
 	c.logger.Info("starting workers")
+	var wg sync.WaitGroup
 	for i := 0; i < nWorkers; i++ {
-		go c.runClusterLoop()
-		go c.runPodLoop()
+		wg.Add(5)
+		go func() {
+			defer wg.Done()
+			c.runClusterLoop()
+		}()
+		go func() {
+			defer wg.Done()
+			c.runNamespaceLoop()
+		}()
+		go func() {
+			defer wg.Done()
+			c.runPodLoop()
+		}()
+		go func() {
+			defer wg.Done()
+			c.runBackupLoop()
+		}()
+		go func() {
+			defer wg.Done()
+			c.runRestoreLoop()
+		}()
+	}
+
+	if interval := c.config.MetadataSnapshotInterval; interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(c.snapshotAllClusterMetadata, interval, c.doneCh)
+		}()
 	}
 
 	c.logger.Info("workers started")
 	<-stopCh
 	c.logger.Info("shutting down workers")
 
+	// Wait for in-flight items to finish.
+	shutdown()
+	wg.Wait()
+	c.logger.Info("workers stopped")
+
 	return nil
 }
 
+// isStopping returns true if the controller has been told to stop.
+func (c *Controller) isStopping() bool {
+	select {
+	case <-c.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// workContext returns the context of handling a work queue item. It is
+// cancelled once the controller is told to stop, so that workers don't keep
+// waiting on coordinators after losing leadership.
+func (c *Controller) workContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-c.doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 func (c *Controller) enqueueCluster(obj interface{}) {
 	var key string
 	var err error
@@ -275,10 +564,18 @@ func (c *Controller) processClusterQueueItem() bool {
 		return false
 	}
 
+	if c.isStopping() {
+		c.clusterWorkQueue.Done(obj)
+		return false
+	}
+
 	// Closure so we can defer workQueue.Done.
 	err := func(obj interface{}) error {
 		defer c.clusterWorkQueue.Done(obj)
 
+		ctx, cancel := c.workContext()
+		defer cancel()
+
 		var key string
 		var ok bool
 		if key, ok = obj.(string); !ok {
@@ -287,7 +584,7 @@ func (c *Controller) processClusterQueueItem() bool {
 			return nil
 		}
 
-		if err := c.handleClusterEvent(key); err != nil {
+		if err := c.handleClusterEvent(ctx, key); err != nil {
 			return fmt.Errorf("error syncing cluster '%s': %v", key, err)
 		}
 
@@ -304,7 +601,7 @@ func (c *Controller) processClusterQueueItem() bool {
 	return true
 }
 
-func (c *Controller) handleClusterEvent(key string) error {
+func (c *Controller) handleClusterEvent(ctx context.Context, key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
@@ -326,12 +623,26 @@ func (c *Controller) handleClusterEvent(key string) error {
 		return errors.New("got nil cluster for " + key)
 	}
 
-	return c.handleClusterUpdate(cluster)
+	settled, err := c.handleClusterUpdate(ctx, cluster)
+	// The status is reconciled even if the update failed, so that it reports
+	// why the cluster is degraded.
+	if statusErr := c.reconcileClusterStatus(ctx, namespace, name, settled, err); statusErr != nil {
+		if err == nil {
+			return statusErr
+		}
+		c.logger.Error("error reconciling cluster status",
+			zap.String("cluster", name), zap.Error(statusErr))
+	}
+
+	return err
 }
 
 // We are guaranteed by handleClusterEvent that we will never be passed a nil
 // cluster here.
-func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
+//
+// It returns true once the cluster has reached its desired state, and false if
+// it took a step towards it or is waiting on one to complete.
+func (c *Controller) handleClusterUpdate(ctx context.Context, cluster *myspec.M3DBCluster) (bool, error) {
 	// MUST create a deep copy of the cluster or risk corrupting cache! Technically
 	// only need if we modify, but we frequently do that so let's deep copy to
 	// start and remove unnecessary calls later to optimize if we want.
@@ -347,7 +658,7 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 	if dts := cluster.ObjectMeta.DeletionTimestamp; dts != nil && !dts.IsZero() {
 		if !stringArrayContains(cluster.Finalizers, labels.EtcdDeletionFinalizer) {
 			clusterLogger.Info("no etcd finalizer on cluster, nothing to do")
-			return nil
+			return false, nil
 		}
 
 		// If cluster is set to preserve data, jump straight to removing the
@@ -355,56 +666,97 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		if cluster.Spec.KeepEtcdDataOnDelete {
 			clusterLogger.Info("skipping etcd deletion due to keepEtcdDataOnDelete")
 		} else {
-			if err := c.deleteAllNamespaces(cluster); err != nil {
+			if err := c.deleteAllNamespaces(ctx, cluster); err != nil {
 				clusterLogger.Error("error deleting cluster namespaces", zap.Error(err))
-				return err
+				return false, err
 			}
 
-			if err := c.deletePlacement(cluster); err != nil {
+			if err := c.deletePlacement(ctx, cluster); err != nil {
 				clusterLogger.Error("error deleting cluster placement", zap.Error(err))
-				return err
+				return false, err
+			}
+
+			if cluster.Spec.Aggregator != nil {
+				if err := c.deleteAggregatorMetadata(ctx, cluster); err != nil {
+					clusterLogger.Error("error deleting aggregator placements and topics", zap.Error(err))
+					return false, err
+				}
 			}
 		}
 
 		if _, err := c.removeEtcdFinalizer(cluster); err != nil {
 			clusterLogger.Error("error deleting etcd finalizer", zap.Error(err))
-			return pkgerrors.WithMessage(err, "error removing etcd cluster finalizer")
+			return false, pkgerrors.WithMessage(err, "error removing etcd cluster finalizer")
 		}
 
 		// Exit the control loop once the cluster is deleted and cleaned up.
 		clusterLogger.Info("completed finalizer cleanup")
-		return nil
+		return false, nil
+	}
+
+	cluster, err := c.ensureDefaults(cluster)
+	if err != nil {
+		clusterLogger.Error("failed defaulting cluster", zap.Error(err))
+		return false, err
 	}
 
-	if err := validateIsolationGroups(cluster); err != nil {
-		clusterLogger.Error("failed validating isolationgroups", zap.Error(err))
+	if err := validation.ValidateCluster(cluster); err != nil {
+		clusterLogger.Error("failed validating cluster", zap.Error(err))
 		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, err.Error())
-		return err
+		return false, err
+	}
+
+	if c.config.RequireSecureAdminAPI {
+		if err := validation.ValidateSecureAdminAPI(cluster); err != nil {
+			clusterLogger.Error("refusing to manage cluster with insecure admin API", zap.Error(err))
+			c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, err.Error())
+			return false, err
+		}
 	}
 
 	if !cluster.Spec.KeepEtcdDataOnDelete {
 		var err error
 		cluster, err = c.ensureEtcdFinalizer(cluster)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	if err := c.ensureConfigMap(cluster); err != nil {
 		clusterLogger.Error("failed to ensure configmap", zap.Error(err))
 		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, "failed to ensure configmap: %s", err.Error())
-		return err
+		return false, err
+	}
+
+	if err := c.ensureCoordinator(cluster); err != nil {
+		clusterLogger.Error("failed to ensure coordinator", zap.Error(err))
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, "failed to ensure coordinator: %s", err.Error())
+		return false, err
 	}
 
 	// Per https://v1-10.docs.kubernetes.io/docs/reference/generated/kubernetes-api/v1.10/#statefulsetspec-v1-apps,
 	// headless service MUST exist before statefulset.
 	if err := c.ensureServices(cluster); err != nil {
-		return err
+		return false, err
 	}
 
 	if len(cluster.Spec.IsolationGroups) == 0 {
 		// nothing to do, no groups to create in
-		return nil
+		return true, nil
+	}
+
+	// A restore stops the cluster's pods while it seeds their volumes, and
+	// manages its StatefulSets until they're started again.
+	if restore, ok := cluster.Annotations[annotations.RestoreSeeding]; ok {
+		clusterLogger.Info("waiting for restore to seed cluster", zap.String("restore", restore))
+		return false, nil
+	}
+
+	// The placement existed but was lost along with etcd's data. Restore it
+	// before any sets or pods are reconciled, which would add pods to a new
+	// placement.
+	if c.needsMetadataRestore(ctx, cluster) {
+		return false, c.restoreClusterMetadata(ctx, cluster)
 	}
 
 	// copy since we sort the array
@@ -412,9 +764,28 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 	copy(isoGroups, cluster.Spec.IsolationGroups)
 	sort.Sort(myspec.IsolationGroups(isoGroups))
 
+	// Failed pods keep their StatefulSets from becoming ready, replace them
+	// before waiting on the sets.
+	replaced, err := c.replaceFailedPods(ctx, cluster)
+	if err != nil {
+		clusterLogger.Error("failed to replace failed pods", zap.Error(err))
+		return false, err
+	}
+	if replaced {
+		return false, nil
+	}
+
 	childrenSets, err := c.getChildStatefulSets(cluster)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	// Sets of isolation groups removed from the spec keep their pod disruption
+	// budgets until they're decommissioned, but no new ones are created.
+	currentSets, _ := splitRemovedIsolationGroupSets(isoGroups, childrenSets)
+	if err := c.ensurePodDisruptionBudgets(cluster, currentSets); err != nil {
+		clusterLogger.Error("failed to ensure pod disruption budgets", zap.Error(err))
+		return false, err
 	}
 
 	childrenSetsByName := make(map[string]*appsv1.StatefulSet)
@@ -425,61 +796,77 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		if sts.Spec.Replicas != nil && *sts.Spec.Replicas != sts.Status.ReadyReplicas {
 			// TODO(schallert): figure out what to do if replicas is not set
 			c.logger.Info("waiting for statefulset to be ready", zap.String("name", sts.Name), zap.Int32("ready", sts.Status.ReadyReplicas))
-			return nil
+			return false, nil
+		}
+
+		// If a set is in the middle of rolling out an update, wait for every pod
+		// to be running the new revision before touching anything else.
+		if isStatefulSetRollingOut(sts) {
+			c.logger.Info("waiting for statefulset rollout to complete",
+				zap.String("name", sts.Name),
+				zap.String("currentRevision", sts.Status.CurrentRevision),
+				zap.String("updateRevision", sts.Status.UpdateRevision))
+			return false, nil
 		}
 	}
 
 	// Create any missing statefulsets, at this point all existing stateful sets are bootstrapped.
-	for i := 0; i < len(isoGroups); i++ {
-		name := k8sops.StatefulSetName(cluster.Name, i)
+	setNames := k8sops.StatefulSetNames(cluster, childrenSets)
+	for _, group := range isoGroups {
+		name := setNames[group.Name]
 		_, exists := childrenSetsByName[name]
 		if !exists {
-			sts, err := k8sops.GenerateStatefulSet(cluster, isoGroups[i].Name, isoGroups[i].NumInstances)
+			sts, err := k8sops.GenerateStatefulSet(cluster, name, group.Name, cluster.Spec.NumInstances(group))
 			if err != nil {
-				return err
+				return false, err
+			}
+
+			if err := annotateSpecHash(sts); err != nil {
+				return false, err
 			}
 
 			_, err = c.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Create(sts)
 			if err != nil {
 				c.logger.Error(err.Error())
-				return err
+				return false, err
 			}
 
-			c.logger.Info("created statefulset", zap.String("name", name))
-			return nil
+			c.logger.Info("created statefulset", zap.String("name", name), zap.String("isolationGroup", group.Name))
+			return false, nil
 		}
 	}
 
-	if err := c.reconcileNamespaces(cluster); err != nil {
-		c.recorder.WarningEvent(cluster, eventer.ReasonFailedCreate, "failed to create namespace: %s", err)
+	updatedCluster, err := c.reconcileNamespaces(ctx, cluster)
+	if err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, "failed to reconcile namespaces: %s", err)
 		c.logger.Error("error reconciling namespaces", zap.Error(err))
-		return err
+		return false, err
 	}
+	cluster = updatedCluster
 
-	if len(cluster.Spec.Namespaces) == 0 {
+	if len(cluster.Spec.Namespaces) == 0 && len(c.clusterNamespaces(cluster)) == 0 {
 		c.logger.Warn("cluster has no namespaces defined", zap.String("cluster", cluster.Name))
 		c.recorder.WarningEvent(cluster, eventer.ReasonUnknown, "cluster %s has no namespaces", cluster.Name)
 	}
 
 	if !cluster.Status.HasInitializedPlacement() {
-		cluster, err = c.validatePlacementWithStatus(cluster)
+		cluster, err = c.validatePlacementWithStatus(ctx, cluster)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	// At this point we have the desired number of statefulsets, and every pod
 	// across those sets is bootstrapped. However some may be bootstrapped because
 	// they own no shards. Check to see that all pods are in the placement.
-	selector := klabels.SelectorFromSet(labels.BaseLabels(cluster))
-	pods, err := c.podLister.Pods(cluster.Namespace).List(selector)
+	pods, err := c.podLister.Pods(cluster.Namespace).List(m3dbNodeSelector(cluster))
 	if err != nil {
-		return fmt.Errorf("error listing pods: %v", err)
+		return false, fmt.Errorf("error listing pods: %v", err)
 	}
 
-	placement, err := c.adminClient.placementClientForCluster(cluster).Get()
+	placement, err := c.adminClient.placementClientForCluster(cluster).Get(ctx)
 	if err != nil {
-		return fmt.Errorf("error fetching active placement: %v", err)
+		return false, fmt.Errorf("error fetching active placement: %v", err)
 	}
 
 	c.logger.Info("found placement", zap.Int("currentPods", len(pods)), zap.Int("placementInsts", placement.NumInstances()))
@@ -494,48 +881,73 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 	if ln := len(unavailInsts); ln > 0 {
 		c.logger.Warn("waiting for instances to be available", zap.Strings("instances", unavailInsts))
 		c.recorder.WarningEvent(cluster, eventer.ReasonLongerThanUsual, "current unavailable instances: %d", ln)
-		return nil
+		return false, nil
 	}
 
 	// Determine if any sets aren't at their desired replica count. Maybe we can
 	// reuse the set objects from above but being paranoid for now.
 	childrenSets, err = c.getChildStatefulSets(cluster)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	// Add a replica to the placement before adding any other instances, so that
+	// the isolation group holding it isn't treated as extra capacity.
+	cluster, changed, err := c.reconcileReplicationFactor(ctx, cluster, isoGroups, pods, placement)
+	if err != nil {
+		c.logger.Error("error reconciling replication factor", zap.Error(err))
+		return false, err
+	}
+	if changed {
+		return false, nil
+	}
+
+	// Every pod is ready and every instance is available, so it's safe to roll
+	// out spec changes to the next isolation group (if any need it).
+	updated, err := c.updateStatefulSets(cluster, isoGroups, childrenSets)
+	if err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, "failed to update statefulset: %s", err)
+		return false, err
+	}
+	if updated {
+		return false, nil
 	}
 
 	// check if any pods inside the cluster need to be swapped in
 	leavingInstanceID, podToReplace, err := c.checkPodsForReplacement(cluster, pods, placement)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if podToReplace != nil {
-		err = c.replacePodInPlacement(cluster, placement, leavingInstanceID, podToReplace)
+		err = c.replacePodInPlacement(ctx, cluster, placement, leavingInstanceID, podToReplace)
 		if err != nil {
 			c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, "could not replace instance: "+leavingInstanceID)
-			return err
+			return false, err
 		}
 		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate, "successfully replaced instance: "+leavingInstanceID)
 	}
 
-	for _, set := range childrenSets {
+	// Sets of isolation groups removed from the spec are decommissioned once
+	// the remaining groups are at their desired size.
+	currentSets, removedSets := splitRemovedIsolationGroupSets(isoGroups, childrenSets)
+	for _, set := range currentSets {
 		zone, ok := set.Labels[labels.IsolationGroup]
 		if !ok {
-			return fmt.Errorf("statefulset %s has no isolation-group label", set.Name)
+			return false, fmt.Errorf("statefulset %s has no isolation-group label", set.Name)
 		}
 
 		group, ok := myspec.IsolationGroups(isoGroups).GetByName(zone)
 		if !ok {
-			return fmt.Errorf("zone %s not found in cluster isoGroups %v", zone, isoGroups)
+			return false, fmt.Errorf("zone %s not found in cluster isoGroups %v", zone, isoGroups)
 		}
 
 		if set.Spec.Replicas == nil {
-			return fmt.Errorf("set %s has unset spec replica", set.Name)
+			return false, fmt.Errorf("set %s has unset spec replica", set.Name)
 		}
 
 		// Number of pods we want in the group.
-		desired := group.NumInstances
+		desired := cluster.Spec.NumInstances(group)
 		// Number of pods currently in the group.
 		current := *set.Spec.Replicas
 		// Number of instances in the group AND currently in the placement.
@@ -559,7 +971,7 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 			// absent from the placement, add pods to placement.
 			if inPlacement < current {
 				setLogger.Info("expanding placement for set")
-				return c.expandPlacementForSet(cluster, set, group, placement)
+				return false, c.expandPlacementForSet(ctx, cluster, set, group, placement)
 			}
 		}
 
@@ -567,7 +979,7 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		// trigger a remove so that we can shrink the set.
 		if inPlacement > desired {
 			setLogger.Info("remove instance from placement for set")
-			return c.shrinkPlacementForSet(cluster, set, placement)
+			return false, c.shrinkPlacementForSet(ctx, cluster, set, placement)
 		}
 
 		var newCount int32
@@ -579,17 +991,24 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		setLogger.Info("resizing set, desired != current", zap.Int32("newSize", newCount))
 
 		set.Spec.Replicas = pointer.Int32Ptr(newCount)
-		set, err = c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set)
-		if err != nil {
-			return fmt.Errorf("error updating statefulset %s: %v", set.Name, err)
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			return false, fmt.Errorf("error updating statefulset %s: %v", set.Name, err)
 		}
 
-		return nil
+		return false, nil
 	}
 
-	placement, err = c.adminClient.placementClientForCluster(cluster).Get()
+	decommissioned, err := c.decommissionIsolationGroups(ctx, cluster, isoGroups, removedSets, placement)
 	if err != nil {
-		return fmt.Errorf("error fetching placement: %v", err)
+		return false, err
+	}
+	if decommissioned {
+		return false, nil
+	}
+
+	placement, err = c.adminClient.placementClientForCluster(cluster).Get(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error fetching placement: %v", err)
 	}
 
 	// TODO(celina): possibly do a replacement check here
@@ -597,7 +1016,15 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 	// See if we need to clean up the pod bootstrapping status.
 	cluster, err = c.reconcileBootstrappingStatus(cluster, placement)
 	if err != nil {
-		return fmt.Errorf("error reconciling bootstrap status: %v", err)
+		return false, fmt.Errorf("error reconciling bootstrap status: %v", err)
+	}
+
+	// The M3DB nodes are settled, set up the aggregators (if any) now that the
+	// coordinators can serve the placement and topic APIs.
+	if err := c.ensureAggregator(ctx, cluster); err != nil {
+		c.logger.Error("failed to ensure aggregator", zap.Error(err))
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, "failed to ensure aggregator: %s", err.Error())
+		return false, err
 	}
 
 	c.logger.Info("nothing to do",
@@ -606,7 +1033,7 @@ func (c *Controller) handleClusterUpdate(cluster *myspec.M3DBCluster) error {
 		zap.Int64("generation", cluster.ObjectMeta.Generation),
 		zap.String("rv", cluster.ObjectMeta.ResourceVersion))
 
-	return nil
+	return true, nil
 }
 
 func instancesInIsoGroup(pl m3placement.Placement, isoGroup string) []m3placement.Instance {
@@ -622,8 +1049,7 @@ func instancesInIsoGroup(pl m3placement.Placement, isoGroup string) []m3placemen
 // This func is currently read-only, but if we end up modifying statefulsets
 // we'll have to deepcopy.
 func (c *Controller) getChildStatefulSets(cluster *myspec.M3DBCluster) ([]*appsv1.StatefulSet, error) {
-	labels := labels.BaseLabels(cluster)
-	statefulSets, err := c.statefulSetLister.StatefulSets(cluster.Namespace).List(klabels.Set(labels).AsSelector())
+	statefulSets, err := c.statefulSetLister.StatefulSets(cluster.Namespace).List(m3dbNodeSelector(cluster))
 	if err != nil {
 		runtime.HandleError(fmt.Errorf("error listing statefulsets: %v", err))
 		return nil, err
@@ -639,6 +1065,16 @@ func (c *Controller) getChildStatefulSets(cluster *myspec.M3DBCluster) ([]*appsv
 	return childrenSets, nil
 }
 
+// m3dbNodeSelector selects a cluster's M3DB node objects, leaving out its
+// separate coordinators and aggregators. Objects created before components
+// were labeled have no component label and are selected.
+func m3dbNodeSelector(cluster *myspec.M3DBCluster) klabels.Selector {
+	// The requirement is built from constants, so it can't fail.
+	notOtherComponent, _ := klabels.NewRequirement(labels.Component, selection.NotIn,
+		[]string{labels.ComponentCoordinator, labels.ComponentAggregator})
+	return klabels.SelectorFromSet(labels.BaseLabels(cluster)).Add(*notOtherComponent)
+}
+
 func (c *Controller) handleStatefulSetUpdate(obj interface{}) {
 	var object metav1.Object
 	var ok bool
@@ -699,10 +1135,18 @@ func (c *Controller) processPodQueueItem() bool {
 		return false
 	}
 
+	if c.isStopping() {
+		c.podWorkQueue.Done(obj)
+		return false
+	}
+
 	// Closure so we can defer workQueue.Done.
 	err := func(obj interface{}) error {
 		defer c.podWorkQueue.Done(obj)
 
+		ctx, cancel := c.workContext()
+		defer cancel()
+
 		var key string
 		var ok bool
 		if key, ok = obj.(string); !ok {
@@ -711,7 +1155,7 @@ func (c *Controller) processPodQueueItem() bool {
 			return nil
 		}
 
-		if err := c.handlePodEvent(key); err != nil {
+		if err := c.handlePodEvent(ctx, key); err != nil {
 			return fmt.Errorf("error syncing cluster '%s': %v", key, err)
 		}
 
@@ -728,7 +1172,7 @@ func (c *Controller) processPodQueueItem() bool {
 	return true
 }
 
-func (c *Controller) handlePodEvent(key string) error {
+func (c *Controller) handlePodEvent(ctx context.Context, key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		c.logger.Error("invalid resource key", zap.Error(err))
@@ -759,12 +1203,27 @@ func (c *Controller) handlePodUpdate(pod *corev1.Pod) error {
 		return nil
 	}
 
+	// Separate coordinators and aggregators don't need an identity, they're not
+	// part of the M3DB placement.
+	switch pod.Labels[labels.Component] {
+	case labels.ComponentCoordinator, labels.ComponentAggregator:
+		return nil
+	}
+
 	pod = pod.DeepCopy()
 
 	podLogger := c.logger.With(zap.String("pod", pod.Name))
 	podLogger.Debug("processing pod")
 
 	cluster, err := c.getParentCluster(pod)
+	if kerrors.IsNotFound(err) {
+		clusterName, _ := getClusterValue(pod)
+		unmanaged, unmanagedErr := c.isUnmanagedCluster(pod.Namespace, clusterName)
+		if unmanagedErr == nil && unmanaged {
+			podLogger.Debug("ignoring pod of unmanaged cluster", zap.String("cluster", clusterName))
+			return nil
+		}
+	}
 	if err != nil {
 		podLogger.Error("error getting parent cluster", zap.Error(err))
 		return err
@@ -822,6 +1281,26 @@ func getClusterValue(pod *corev1.Pod) (string, bool) {
 	return cluster, true
 }
 
+// isUnmanagedCluster returns true if the cluster exists but isn't selected by
+// the controller's cluster selector, meaning another operator manages it.
+// Clusters missing from the controller's cache may be either unmanaged or
+// deleted.
+func (c *Controller) isUnmanagedCluster(namespace, name string) (bool, error) {
+	if c.clusterSelector.Empty() {
+		return false, nil
+	}
+
+	cluster, err := c.crdClient.OperatorV1alpha1().M3DBClusters(namespace).Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return !c.clusterSelector.Matches(klabels.Set(cluster.Labels)), nil
+}
+
 func (c *Controller) getParentCluster(pod *corev1.Pod) (*myspec.M3DBCluster, error) {
 	clusterName, found := getClusterValue(pod)
 	if !found {
@@ -835,21 +1314,3 @@ func (c *Controller) getParentCluster(pod *corev1.Pod) (*myspec.M3DBCluster, err
 
 	return cluster, nil
 }
-
-func validateIsolationGroups(cluster *myspec.M3DBCluster) error {
-	groups := cluster.Spec.IsolationGroups
-
-	if cluster.Spec.ReplicationFactor != int32(len(groups)) {
-		return pkgerrors.WithMessagef(errInvalidNumIsoGroups, "replication factor is %d but number of isogroups is %d", cluster.Spec.ReplicationFactor, len(groups))
-	}
-
-	names := make(map[string]struct{}, len(groups))
-	for _, g := range groups {
-		names[g.Name] = struct{}{}
-	}
-
-	if len(names) != len(groups) {
-		return pkgerrors.WithMessagef(errNonUniqueIsoGroups, "found %d isolationGroups but %d unique names", len(groups), len(names))
-	}
-	return nil
-}
@@ -21,12 +21,15 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
 	"testing"
 	"time"
 
+	m3dboperator "github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	clientsetfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
 	m3dbinformers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
@@ -39,6 +42,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -48,7 +52,6 @@ import (
 	kubetesting "k8s.io/client-go/testing"
 
 	"github.com/golang/mock/gomock"
-	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
@@ -87,6 +90,45 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, controller)
 }
 
+func TestEnsureCRDs(t *testing.T) {
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	client := k8sops.NewMockK8sops(mc)
+	c := &Controller{
+		k8sclient: client,
+		config: Configuration{
+			ManageCRD:        true,
+			EnableValidation: true,
+		},
+	}
+
+	opts := k8sops.CRDOptions{EnableValidation: true}
+	client.EXPECT().CreateOrUpdateCRD(m3dboperator.Name, opts).Return(nil)
+	client.EXPECT().CreateOrUpdateCRD(m3dboperator.NamespaceName, opts).Return(nil)
+	client.EXPECT().CreateOrUpdateCRD(m3dboperator.BackupName, opts).Return(nil)
+	client.EXPECT().CreateOrUpdateCRD(m3dboperator.RestoreName, opts).Return(nil)
+	assert.NoError(t, c.ensureCRDs())
+
+	// Configuring a storage version migrates the objects of the cluster and
+	// namespace CRDs, backups and restores only have one version.
+	c.config.StorageVersion = m3dboperator.VersionV1beta1
+	c.config.ConversionWebhook = &k8sops.ConversionWebhook{ServiceNamespace: "ns", ServiceName: "webhook"}
+	opts.StorageVersion = c.config.StorageVersion
+	opts.ConversionWebhook = c.config.ConversionWebhook
+	gomock.InOrder(
+		client.EXPECT().CreateOrUpdateCRD(m3dboperator.Name, opts).Return(nil),
+		client.EXPECT().CreateOrUpdateCRD(m3dboperator.NamespaceName, opts).Return(nil),
+		client.EXPECT().CreateOrUpdateCRD(m3dboperator.BackupName, opts).Return(nil),
+		client.EXPECT().CreateOrUpdateCRD(m3dboperator.RestoreName, opts).Return(nil),
+		client.EXPECT().MigrateCRDStorageVersion(m3dboperator.Name, m3dboperator.VersionV1beta1).Return(nil),
+		client.EXPECT().MigrateCRDStorageVersion(m3dboperator.NamespaceName, m3dboperator.VersionV1beta1).Return(errors.New("test")),
+	)
+	err := c.ensureCRDs()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not migrate storage version")
+}
+
 func TestGetChildStatefulSets(t *testing.T) {
 	tests := []struct {
 		cluster     *metav1.ObjectMeta
@@ -262,6 +304,62 @@ func TestGetParentCluster(t *testing.T) {
 	assert.Equal(t, cluster, parentCluster)
 }
 
+func TestHandlePodUpdateUnmanagedCluster(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: newObjectMeta("foo", map[string]string{"team": "other"}),
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "namespace",
+			Labels: map[string]string{
+				"operator.m3db.io/cluster": "foo",
+			},
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects:  []runtime.Object{cluster},
+		kubeObjects: []runtime.Object{pod},
+	})
+	c := deps.newController(t)
+	defer deps.cleanup()
+
+	c.clusterLister = newEmptyClusterLister()
+
+	// Without a selector a missing cluster is an error.
+	assert.Error(t, c.handlePodUpdate(pod))
+
+	// The pod of a cluster that isn't selected is ignored, the identity
+	// provider mock expects no calls.
+	c.clusterSelector = klabels.SelectorFromSet(klabels.Set{"team": "mine"})
+	assert.NoError(t, c.handlePodUpdate(pod))
+}
+
+func TestHandlePodUpdateCoordinator(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: "namespace",
+			Labels: map[string]string{
+				"operator.m3db.io/cluster":   "foo",
+				"operator.m3db.io/component": "coordinator",
+			},
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{pod},
+	})
+	c := deps.newController(t)
+	defer deps.cleanup()
+
+	// Separate coordinators aren't given an identity, the identity provider
+	// mock expects no calls.
+	assert.NoError(t, c.handlePodUpdate(pod))
+}
+
 func TestHandlePodUpdate(t *testing.T) {
 	cluster := &myspec.M3DBCluster{
 		ObjectMeta: newObjectMeta("foo", nil),
@@ -374,52 +472,38 @@ func TestPodEventLoop(t *testing.T) {
 	}
 }
 
-func TestValidateIsolationGroups(t *testing.T) {
-	tests := []struct {
-		groups   []myspec.IsolationGroup
-		rf       int32
-		doExpErr bool
-		expErr   error
-	}{
-		{
-			rf: 2,
-			groups: []myspec.IsolationGroup{
-				{Name: "foo"},
-				{Name: "bar"},
-			},
-		},
-		{
-			rf: 3,
-			groups: []myspec.IsolationGroup{
-				{Name: "foo"},
-				{Name: "bar"},
-			},
-			expErr:   errInvalidNumIsoGroups,
-			doExpErr: true,
-		},
-		{
-			rf: 2,
-			groups: []myspec.IsolationGroup{
-				{Name: "foo"},
-				{Name: "foo"},
-			},
-			expErr:   errNonUniqueIsoGroups,
-			doExpErr: true,
-		},
-	}
+func TestRunShutsDownWorkers(t *testing.T) {
+	deps := newTestDeps(t, &testOpts{})
+	defer deps.cleanup()
 
-	for _, test := range tests {
-		cluster := newM3DBCluster("ns", "cluster")
-		cluster.Spec.ReplicationFactor = test.rf
-		cluster.Spec.IsolationGroups = test.groups
-		err := validateIsolationGroups(cluster)
-		if test.doExpErr {
-			assert.Error(t, err)
-			assert.Equal(t, test.expErr, pkgerrors.Cause(err))
-		} else {
-			assert.NoError(t, err)
-		}
+	c := deps.newController(t)
+	c.doneCh = make(chan struct{})
+	synced := func() bool { return true }
+	c.clustersSynced, c.statefulSetsSynced, c.podsSynced, c.nodesSynced = synced, synced, synced, synced
+	c.namespacesSynced, c.backupsSynced, c.restoresSynced, c.jobsSynced = synced, synced, synced, synced
+	c.claimsSynced, c.pdbsSynced = synced, synced
+
+	stopCh := make(chan struct{})
+	doneC := make(chan error)
+	go func() {
+		doneC <- c.Run(2, stopCh)
+	}()
+
+	close(stopCh)
+
+	select {
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected controller to stop within 5s")
+	case err := <-doneC:
+		require.NoError(t, err)
 	}
+
+	assert.True(t, c.clusterWorkQueue.ShuttingDown())
+	assert.True(t, c.namespaceWorkQueue.ShuttingDown())
+	assert.True(t, c.podWorkQueue.ShuttingDown())
+	assert.True(t, c.backupWorkQueue.ShuttingDown())
+	assert.True(t, c.restoreWorkQueue.ShuttingDown())
+	assert.True(t, c.isStopping())
 }
 
 func TestHandleUpdateClusterCreatesStatefulSets(t *testing.T) {
@@ -438,7 +522,7 @@ func TestHandleUpdateClusterCreatesStatefulSets(t *testing.T) {
 				"operator.m3db.io/cluster": "cluster1",
 			}),
 			sets: []*metav1.ObjectMeta{
-				newMeta("cluster1-rep0", nil),
+				newMeta("cluster1-rep0", map[string]string{labels.IsolationGroup: "group0"}),
 			},
 			replicationFactor:     3,
 			expCreateStatefulSets: []string{"cluster1-rep1", "cluster1-rep2"},
@@ -451,7 +535,7 @@ func TestHandleUpdateClusterCreatesStatefulSets(t *testing.T) {
 				"operator.m3db.io/cluster": "cluster1",
 			}),
 			sets: []*metav1.ObjectMeta{
-				newMeta("cluster1-rep1", nil),
+				newMeta("cluster1-rep1", map[string]string{labels.IsolationGroup: "group1"}),
 			},
 			replicationFactor:     3,
 			expCreateStatefulSets: []string{"cluster1-rep0", "cluster1-rep2"},
@@ -464,11 +548,24 @@ func TestHandleUpdateClusterCreatesStatefulSets(t *testing.T) {
 				"operator.m3db.io/cluster": "cluster1",
 			}),
 			sets: []*metav1.ObjectMeta{
-				newMeta("cluster1-rep2", nil),
+				newMeta("cluster1-rep2", map[string]string{labels.IsolationGroup: "group2"}),
 			},
 			replicationFactor:     3,
 			expCreateStatefulSets: []string{"cluster1-rep0", "cluster1-rep1"},
 		},
+		{
+			name: "keeps the names of existing stateful sets",
+			cluster: newMeta("cluster1", map[string]string{
+				"foo":                      "bar",
+				"operator.m3db.io/app":     "m3db",
+				"operator.m3db.io/cluster": "cluster1",
+			}),
+			sets: []*metav1.ObjectMeta{
+				newMeta("cluster1-rep0", map[string]string{labels.IsolationGroup: "group1"}),
+			},
+			replicationFactor:     3,
+			expCreateStatefulSets: []string{"cluster1-rep1", "cluster1-rep2"},
+		},
 	}
 
 	for _, test := range tests {
@@ -544,7 +641,7 @@ func TestHandleUpdateClusterCreatesStatefulSets(t *testing.T) {
 
 			var done bool
 			for i := 0; i < 5; i++ {
-				err := c.handleClusterUpdate(cluster)
+				_, err := c.handleClusterUpdate(context.Background(), cluster)
 				require.NoError(t, err)
 
 				expectedMu.Lock()
@@ -0,0 +1,224 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
+	informers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/listwatch"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchConfiguration restricts the objects the operator watches, so that
+// several operators can split clusters between them.
+type WatchConfiguration struct {
+	// Namespaces are the namespaces whose clusters the operator manages. All
+	// namespaces are watched if empty.
+	Namespaces []string
+
+	// ClusterSelector is a label selector of the clusters the operator
+	// manages. All clusters are managed if empty.
+	ClusterSelector string
+}
+
+func (w WatchConfiguration) clusterSelector() (klabels.Selector, error) {
+	selector, err := klabels.Parse(w.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector '%s': %v", w.ClusterSelector, err)
+	}
+	return selector, nil
+}
+
+// NewInformerFactories returns informer factories whose informers for the
+// objects the controller watches are restricted by the given configuration.
+// Pods, StatefulSets, Jobs, PersistentVolumeClaims and PodDisruptionBudgets are
+// additionally restricted to those created by the operator, rather than caching
+// every pod in the watched namespaces.
+func NewInformerFactories(
+	kubeClient kubernetes.Interface,
+	crdClient clientset.Interface,
+	resync time.Duration,
+	config WatchConfiguration,
+) (kubeinformers.SharedInformerFactory, informers.SharedInformerFactory, error) {
+	if _, err := config.clusterSelector(); err != nil {
+		return nil, nil, err
+	}
+
+	operatorSelector := klabels.SelectorFromSet(klabels.Set{labels.App: labels.AppM3DB}).String()
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+
+	kubeFactory := kubeinformers.NewSharedInformerFactory(kubeClient, resync)
+	kubeFactory.InformerFor(&corev1.Pod{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = operatorSelector
+					return client.CoreV1().Pods(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = operatorSelector
+					return client.CoreV1().Pods(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &corev1.Pod{}, resync, indexers)
+	})
+	kubeFactory.InformerFor(&appsv1.StatefulSet{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = operatorSelector
+					return client.AppsV1().StatefulSets(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = operatorSelector
+					return client.AppsV1().StatefulSets(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &appsv1.StatefulSet{}, resync, indexers)
+	})
+	kubeFactory.InformerFor(&batchv1.Job{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = operatorSelector
+					return client.BatchV1().Jobs(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = operatorSelector
+					return client.BatchV1().Jobs(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &batchv1.Job{}, resync, indexers)
+	})
+
+	// Claims created from a StatefulSet's volume claim template carry the
+	// set's selector labels.
+	kubeFactory.InformerFor(&corev1.PersistentVolumeClaim{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = operatorSelector
+					return client.CoreV1().PersistentVolumeClaims(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = operatorSelector
+					return client.CoreV1().PersistentVolumeClaims(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &corev1.PersistentVolumeClaim{}, resync, indexers)
+	})
+	kubeFactory.InformerFor(&policyv1beta1.PodDisruptionBudget{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = operatorSelector
+					return client.PolicyV1beta1().PodDisruptionBudgets(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = operatorSelector
+					return client.PolicyV1beta1().PodDisruptionBudgets(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &policyv1beta1.PodDisruptionBudget{}, resync, indexers)
+	})
+
+	crdFactory := informers.NewSharedInformerFactory(crdClient, resync)
+	crdFactory.InformerFor(&myspec.M3DBCluster{}, func(client clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					opts.LabelSelector = config.ClusterSelector
+					return client.OperatorV1alpha1().M3DBClusters(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = config.ClusterSelector
+					return client.OperatorV1alpha1().M3DBClusters(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &myspec.M3DBCluster{}, resync, indexers)
+	})
+	// Namespaces are matched to clusters by the controller, they don't carry
+	// their cluster's labels.
+	crdFactory.InformerFor(&myspec.M3DBNamespace{}, func(client clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					return client.OperatorV1alpha1().M3DBNamespaces(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					return client.OperatorV1alpha1().M3DBNamespaces(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &myspec.M3DBNamespace{}, resync, indexers)
+	})
+	// Likewise backups and restores reference their cluster by name.
+	crdFactory.InformerFor(&myspec.M3DBBackup{}, func(client clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					return client.OperatorV1alpha1().M3DBBackups(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					return client.OperatorV1alpha1().M3DBBackups(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &myspec.M3DBBackup{}, resync, indexers)
+	})
+	crdFactory.InformerFor(&myspec.M3DBRestore{}, func(client clientset.Interface, resync time.Duration) cache.SharedIndexInformer {
+		lw := listwatch.MultiNamespace(config.Namespaces, func(namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+					return client.OperatorV1alpha1().M3DBRestores(namespace).List(opts)
+				},
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					return client.OperatorV1alpha1().M3DBRestores(namespace).Watch(opts)
+				},
+			}
+		})
+		return cache.NewSharedIndexInformer(lw, &myspec.M3DBRestore{}, resync, indexers)
+	})
+
+	return kubeFactory, crdFactory, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInformerFactories(t *testing.T) {
+	operatorLabels := map[string]string{labels.App: labels.AppM3DB}
+	newPod := func(namespace, name string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: podLabels},
+		}
+	}
+	newCluster := func(namespace, name string, clusterLabels map[string]string) *myspec.M3DBCluster {
+		return &myspec.M3DBCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: clusterLabels},
+		}
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(
+		newPod("a", "pod-a", operatorLabels),
+		newPod("a", "other-a", nil),
+		newPod("b", "pod-b", operatorLabels),
+		newPod("c", "pod-c", operatorLabels),
+	)
+	crdClient := crdfake.NewSimpleClientset(
+		newCluster("a", "mine-a", map[string]string{"team": "mine"}),
+		newCluster("a", "other-a", map[string]string{"team": "other"}),
+		newCluster("b", "mine-b", map[string]string{"team": "mine"}),
+		newCluster("c", "mine-c", map[string]string{"team": "mine"}),
+	)
+
+	kubeFactory, crdFactory, err := NewInformerFactories(kubeClient, crdClient, time.Minute, WatchConfiguration{
+		Namespaces:      []string{"a", "b"},
+		ClusterSelector: "team=mine",
+	})
+	require.NoError(t, err)
+
+	podLister := kubeFactory.Core().V1().Pods().Lister()
+	clusterLister := crdFactory.Operator().V1alpha1().M3DBClusters().Lister()
+	// Namespace informers are requested so they're started with the factory.
+	crdFactory.Operator().V1alpha1().M3DBNamespaces().Lister()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeFactory.Start(stopCh)
+	crdFactory.Start(stopCh)
+	for _, synced := range kubeFactory.WaitForCacheSync(stopCh) {
+		require.True(t, synced)
+	}
+	for _, synced := range crdFactory.WaitForCacheSync(stopCh) {
+		require.True(t, synced)
+	}
+
+	pods, err := podLister.List(klabels.Everything())
+	require.NoError(t, err)
+	var podNames []string
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+	assert.Equal(t, []string{"pod-a", "pod-b"}, podNames)
+
+	clusters, err := clusterLister.List(klabels.Everything())
+	require.NoError(t, err)
+	var clusterNames []string
+	for _, cluster := range clusters {
+		clusterNames = append(clusterNames, cluster.Name)
+	}
+	sort.Strings(clusterNames)
+	assert.Equal(t, []string{"mine-a", "mine-b"}, clusterNames)
+
+	// Objects created after the initial list are watched too.
+	_, err = kubeClient.CoreV1().Pods("b").Create(newPod("b", "pod-b2", operatorLabels))
+	require.NoError(t, err)
+	_, err = kubeClient.CoreV1().Pods("c").Create(newPod("c", "pod-c2", operatorLabels))
+	require.NoError(t, err)
+	require.True(t, cache.WaitForCacheSync(stopCh, func() bool {
+		_, err := podLister.Pods("b").Get("pod-b2")
+		return err == nil
+	}))
+	_, err = podLister.Pods("c").Get("pod-c2")
+	assert.Error(t, err)
+}
+
+func TestNewInformerFactoriesInvalidSelector(t *testing.T) {
+	_, _, err := NewInformerFactories(kubefake.NewSimpleClientset(), crdfake.NewSimpleClientset(),
+		time.Minute, WatchConfiguration{ClusterSelector: "team in (mine"})
+	assert.Error(t, err)
+}
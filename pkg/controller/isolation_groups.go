@@ -0,0 +1,279 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes/utils/pointer"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Reasons for the IsolationGroupDecommissioning condition.
+const (
+	reasonDrainingInstances        = "DrainingInstances"
+	reasonStoppingPods             = "StoppingPods"
+	reasonInsufficientGroups       = "InsufficientIsolationGroups"
+	reasonDecommissionComplete     = "DecommissionComplete"
+	reasonIsolationGroupsRemaining = "IsolationGroupsDecommissioning"
+)
+
+// decommissionRecheckInterval is how often a cluster is synced while the pods
+// of a decommissioned isolation group stop. Pods being deleted don't enqueue
+// their cluster.
+const decommissionRecheckInterval = 10 * time.Second
+
+// splitRemovedIsolationGroupSets splits the cluster's StatefulSets into those
+// of isolation groups in the spec and those of groups that have been removed
+// from it. The removed group sets are sorted by name. Sets without an
+// isolation group label are never considered removed.
+func splitRemovedIsolationGroupSets(isoGroups []myspec.IsolationGroup,
+	sets []*appsv1.StatefulSet) (current, removed []*appsv1.StatefulSet) {
+
+	for _, set := range sets {
+		group, ok := set.Labels[labels.IsolationGroup]
+		if !ok {
+			current = append(current, set)
+			continue
+		}
+
+		if _, ok := myspec.IsolationGroups(isoGroups).GetByName(group); ok {
+			current = append(current, set)
+		} else {
+			removed = append(removed, set)
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].Name < removed[j].Name
+	})
+
+	return current, removed
+}
+
+// decommissionIsolationGroups decommissions isolation groups that have been
+// removed from the cluster's spec, one group at a time. A group's instances
+// are first removed from the placement, and once every shard they owned is
+// available on the remaining groups its StatefulSet, data volume claims and
+// pod disruption budget are deleted. It must only be called once every
+// instance in the placement is available. It returns true if it changed
+// anything, in which case the caller should wait for the next event.
+func (c *Controller) decommissionIsolationGroups(ctx context.Context, cluster *myspec.M3DBCluster,
+	isoGroups []myspec.IsolationGroup, removed []*appsv1.StatefulSet, pl placement.Placement) (bool, error) {
+
+	if len(removed) == 0 {
+		cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+		if !ok || cond.Status == corev1.ConditionFalse {
+			return false, nil
+		}
+
+		_, err := c.setStatus(cluster, myspec.ClusterConditionIsolationGroupDecommissioning,
+			corev1.ConditionFalse, reasonDecommissionComplete, "no isolation groups being decommissioned")
+		return err == nil, err
+	}
+
+	set := removed[0]
+	group := set.Labels[labels.IsolationGroup]
+	logger := c.logger.With(zap.String("statefulSet", set.Name), zap.String("isolationGroup", group))
+
+	if insts := instancesInIsoGroup(pl, group); len(insts) > 0 {
+		return true, c.drainIsolationGroup(ctx, cluster, isoGroups, group, insts, pl)
+	}
+
+	logger.Info("deleting decommissioned isolation group")
+	deleted, err := c.deleteIsolationGroupSet(cluster, set)
+	if err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete,
+			"error deleting isolation group %s: %v", group, err)
+		return false, pkgerrors.WithMessagef(err, "error deleting isolation group '%s'", group)
+	}
+	if !deleted {
+		if err := c.recheckCluster(cluster, decommissionRecheckInterval); err != nil {
+			return false, err
+		}
+		_, err := c.setStatusIfChanged(cluster, myspec.ClusterConditionIsolationGroupDecommissioning,
+			corev1.ConditionTrue, reasonStoppingPods,
+			fmt.Sprintf("stopping pods of isolation group %s before deleting its volumes", group))
+		return true, err
+	}
+
+	c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulDelete,
+		"decommissioned isolation group %s, deleted statefulset %s", group, set.Name)
+
+	if len(removed) > 1 {
+		_, err := c.setStatus(cluster, myspec.ClusterConditionIsolationGroupDecommissioning,
+			corev1.ConditionTrue, reasonIsolationGroupsRemaining,
+			fmt.Sprintf("decommissioned isolation group %s, %d remaining", group, len(removed)-1))
+		return true, err
+	}
+
+	return true, nil
+}
+
+// drainIsolationGroup removes the instances of a removed isolation group from
+// the placement. The coordinator only removes an instance while every shard is
+// available, so one instance is removed per sync, each once the shards of the
+// previous one have moved. It refuses to if the groups left in the placement
+// can't hold every replica of each shard.
+func (c *Controller) drainIsolationGroup(ctx context.Context, cluster *myspec.M3DBCluster,
+	isoGroups []myspec.IsolationGroup, group string, insts []placement.Instance, pl placement.Placement) error {
+
+	remaining := make(map[string]struct{}, len(isoGroups))
+	for _, inst := range pl.Instances() {
+		if _, ok := myspec.IsolationGroups(isoGroups).GetByName(inst.IsolationGroup()); ok {
+			remaining[inst.IsolationGroup()] = struct{}{}
+		}
+	}
+
+	if len(remaining) < pl.ReplicaFactor() {
+		msg := fmt.Sprintf("not removing isolation group %s from placement, only %d isolation groups "+
+			"would remain for replication factor %d", group, len(remaining), pl.ReplicaFactor())
+		c.logger.Warn(msg)
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete, msg)
+		_, err := c.setStatusIfChanged(cluster, myspec.ClusterConditionIsolationGroupDecommissioning,
+			corev1.ConditionTrue, reasonInsufficientGroups, msg)
+		return err
+	}
+
+	id := insts[0].ID()
+	c.logger.Info("removing isolation group instance from placement",
+		zap.String("isolationGroup", group),
+		zap.String("instance", id),
+		zap.Int("remaining", len(insts)-1))
+	if err := c.adminClient.placementClientForCluster(cluster).Remove(ctx, id); err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToDelete,
+			"error removing isolation group %s from placement: %v", group, err)
+		return pkgerrors.WithMessagef(err, "error removing isolation group '%s' from placement", group)
+	}
+
+	c.recorder.NormalEvent(cluster, eventer.ReasonDeleting,
+		"removing instance %s of isolation group %s from placement", id, group)
+
+	_, err := c.setStatus(cluster, myspec.ClusterConditionIsolationGroupDecommissioning,
+		corev1.ConditionTrue, reasonDrainingInstances,
+		fmt.Sprintf("removing instances of isolation group %s from placement, %d remaining", group, len(insts)))
+	return err
+}
+
+// deleteIsolationGroupSet deletes the StatefulSet of a removed isolation group
+// along with its pod disruption budget and data volume claims. The set is
+// scaled to zero first, and its claims are only deleted once its pods are
+// gone. It returns false while it waits for them to stop. The set itself is
+// deleted last so that a failure can be retried on the next sync.
+func (c *Controller) deleteIsolationGroupSet(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet) (bool, error) {
+	if set.Spec.Selector == nil {
+		return false, fmt.Errorf("statefulset '%s' has no selector", set.Name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(set.Spec.Selector)
+	if err != nil {
+		return false, pkgerrors.WithMessagef(err, "invalid selector for statefulset '%s'", set.Name)
+	}
+
+	if set.Spec.Replicas == nil || *set.Spec.Replicas != 0 {
+		set = set.DeepCopy()
+		set.Spec.Replicas = pointer.Int32Ptr(0)
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			return false, pkgerrors.WithMessagef(err, "error stopping statefulset '%s'", set.Name)
+		}
+		c.logger.Info("stopping pods of decommissioned statefulset", zap.String("statefulSet", set.Name))
+		return false, nil
+	}
+
+	pods, err := c.podLister.Pods(set.Namespace).List(selector)
+	if err != nil {
+		return false, pkgerrors.WithMessagef(err, "error listing pods of statefulset '%s'", set.Name)
+	}
+	if set.Status.Replicas != 0 || len(pods) > 0 {
+		c.logger.Info("waiting for pods of decommissioned statefulset to stop",
+			zap.String("statefulSet", set.Name), zap.Int("pods", len(pods)))
+		return false, nil
+	}
+
+	pdbs, err := c.podDisruptionBudgetsForSet(cluster, set)
+	if err != nil {
+		return false, err
+	}
+	pdbClient := c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(cluster.Namespace)
+	for _, pdb := range pdbs {
+		err := pdbClient.Delete(pdb.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return false, pkgerrors.WithMessagef(err, "error deleting pod disruption budget '%s'", pdb.Name)
+		}
+	}
+
+	claims, err := c.claimLister.PersistentVolumeClaims(cluster.Namespace).List(selector)
+	if err != nil {
+		return false, pkgerrors.WithMessagef(err, "error listing volume claims of statefulset '%s'", set.Name)
+	}
+
+	// Claims are named after their pods, which are named after the set.
+	prefix := k8sops.DataVolumeClaimName(set.Name) + "-"
+	claimClient := c.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace)
+	for _, claim := range claims {
+		if !strings.HasPrefix(claim.Name, prefix) {
+			continue
+		}
+
+		err := claimClient.Delete(claim.Name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return false, pkgerrors.WithMessagef(err, "error deleting volume claim '%s'", claim.Name)
+		}
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err = c.kubeClient.AppsV1().StatefulSets(set.Namespace).Delete(set.Name, &metav1.DeleteOptions{
+		Preconditions:     &metav1.Preconditions{UID: &set.UID},
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return false, pkgerrors.WithMessagef(err, "error deleting statefulset '%s'", set.Name)
+	}
+
+	return true, nil
+}
+
+// recheckCluster enqueues the cluster again after the given interval.
+func (c *Controller) recheckCluster(cluster *myspec.M3DBCluster, after time.Duration) error {
+	key, err := cache.MetaNamespaceKeyFunc(cluster)
+	if err != nil {
+		return err
+	}
+	c.clusterWorkQueue.AddAfter(key, after)
+	return nil
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decommissionFixture is a cluster whose isolation group "us-fake1-old" has
+// been replaced by "us-fake1-c", along with a StatefulSet of one pod for each
+// of the four groups. The set of the removed group is the last one.
+func decommissionFixture(t *testing.T) (*myspec.M3DBCluster, []*appsv1.StatefulSet, []*corev1.Pod) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.UID = "abc"
+
+	oldCluster := cluster.DeepCopy()
+	oldCluster.Spec.IsolationGroups = append(oldCluster.Spec.IsolationGroups, myspec.IsolationGroup{
+		Name:         "us-fake1-old",
+		NumInstances: 1,
+	})
+
+	var (
+		sets []*appsv1.StatefulSet
+		pods []*corev1.Pod
+	)
+	for i, group := range oldCluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(oldCluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, 1)
+		require.NoError(t, err)
+		set.Namespace = cluster.Namespace
+		sets = append(sets, set)
+		pods = append(pods, podsForClusterSet(cluster, set, 1)...)
+	}
+
+	return cluster, sets, pods
+}
+
+func TestSplitRemovedIsolationGroupSets(t *testing.T) {
+	cluster, sets, _ := decommissionFixture(t)
+
+	unlabeled := sets[0].DeepCopy()
+	unlabeled.Name = "unlabeled"
+	unlabeled.Labels = nil
+
+	current, removed := splitRemovedIsolationGroupSets(cluster.Spec.IsolationGroups, append(sets, unlabeled))
+	assert.Equal(t, []*appsv1.StatefulSet{sets[0], sets[1], sets[2], unlabeled}, current)
+	assert.Equal(t, []*appsv1.StatefulSet{sets[3]}, removed)
+}
+
+func TestDecommissionIsolationGroupsDrainsInstances(t *testing.T) {
+	cluster, sets, pods := decommissionFixture(t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+
+	identifyPods(deps.idProvider, pods, nil)
+	pl := placementFromPods(t, cluster, pods, deps.idProvider).SetReplicaFactor(3)
+
+	deps.placementClient.EXPECT().Remove(gomock.Any(), `{"name":"cluster-zones-rep3-0","uid":"0"}`)
+
+	changed, err := c.decommissionIsolationGroups(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, sets[3:], pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).
+		Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonDrainingInstances, cond.Reason)
+}
+
+func TestDecommissionIsolationGroupsRemovesOneInstanceAtATime(t *testing.T) {
+	cluster, sets, pods := decommissionFixture(t)
+	// The removed group has two instances.
+	pods = append(pods[:3], podsForClusterSet(cluster, sets[3], 2)...)
+
+	var (
+		lock    sync.Mutex
+		removed []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		removed = append(removed, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"placement": {}}`))
+	}))
+	defer s.Close()
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	// Use a real placement client so that the coordinator sees the requests
+	// the operator would send it.
+	c.adminClient = newTestAdminClient(m3admin.NewClient(), s.URL)
+
+	identifyPods(deps.idProvider, pods, nil)
+	pl := placementFromPods(t, cluster, pods, deps.idProvider).SetReplicaFactor(3)
+	insts := instancesInIsoGroup(pl, "us-fake1-old")
+	require.Len(t, insts, 2)
+
+	changed, err := c.decommissionIsolationGroups(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, sets[3:], pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"DELETE /api/v1/services/m3db/placement/" + insts[0].ID()}, removed)
+}
+
+func TestDecommissionIsolationGroupsInsufficientGroups(t *testing.T) {
+	cluster, sets, pods := decommissionFixture(t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+
+	// The replacement group hasn't been added to the placement yet, so removing
+	// the old one would leave too few groups to hold every replica.
+	identifyPods(deps.idProvider, pods, nil)
+	pl := placementFromPods(t, cluster, []*corev1.Pod{pods[0], pods[1], pods[3]}, deps.idProvider).
+		SetReplicaFactor(3)
+
+	changed, err := c.decommissionIsolationGroups(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, sets[3:], pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).
+		Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonInsufficientGroups, cond.Reason)
+}
+
+func TestDecommissionIsolationGroupsDeletesSet(t *testing.T) {
+	cluster, sets, pods := decommissionFixture(t)
+	removed := sets[3]
+	removed.Status.Replicas = 1
+
+	pdb, err := k8sops.GeneratePodDisruptionBudget(cluster, removed)
+	require.NoError(t, err)
+
+	claim := func(set *appsv1.StatefulSet) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      k8sops.DataVolumeClaimName(set.Name + "-0"),
+				Namespace: cluster.Namespace,
+				Labels:    set.Spec.Selector.MatchLabels,
+			},
+		}
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{removed, pods[3], pdb, claim(removed), claim(sets[0])},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+
+	// The removed group has been drained from the placement.
+	identifyPods(deps.idProvider, pods, nil)
+	pl := placementFromPods(t, cluster, pods[:3], deps.idProvider).SetReplicaFactor(3)
+
+	decommission := func() {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(removed.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		changed, err := c.decommissionIsolationGroups(context.Background(), cluster,
+			cluster.Spec.IsolationGroups, []*appsv1.StatefulSet{set}, pl)
+		require.NoError(t, err)
+		assert.True(t, changed)
+	}
+	assertClaims := func(expected int) {
+		claims, err := deps.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace).List(metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, claims.Items, expected)
+	}
+
+	// The set is scaled down first, its claims are kept while its pods run.
+	decommission()
+	set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(removed.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), *set.Spec.Replicas)
+	assertClaims(2)
+
+	set.Status.Replicas = 0
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).UpdateStatus(set)
+	require.NoError(t, err)
+	decommission()
+	assertClaims(2)
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).
+		Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+	require.True(t, ok)
+	assert.Equal(t, reasonStoppingPods, cond.Reason)
+
+	// Once its pods are gone everything is deleted.
+	require.NoError(t, deps.kubeClient.CoreV1().Pods(cluster.Namespace).Delete(pods[3].Name, &metav1.DeleteOptions{}))
+	waitForCache(t, func() bool {
+		pods, err := deps.podLister.List(klabels.Everything())
+		return err == nil && len(pods) == 0
+	})
+	decommission()
+
+	_, err = deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(removed.Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	_, err = deps.kubeClient.PolicyV1beta1().PodDisruptionBudgets(cluster.Namespace).
+		Get(removed.Name, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	claims, err := deps.kubeClient.CoreV1().PersistentVolumeClaims(cluster.Namespace).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, claims.Items, 1)
+	assert.Equal(t, claim(sets[0]).Name, claims.Items[0].Name)
+}
+
+func TestDecommissionIsolationGroupsComplete(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	pl := placement.NewPlacement()
+
+	// Nothing to do if no group was ever decommissioned.
+	changed, err := c.decommissionIsolationGroups(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, nil, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionIsolationGroupDecommissioning,
+		Status: corev1.ConditionTrue,
+	})
+	changed, err = c.decommissionIsolationGroups(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, nil, pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).
+		Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionIsolationGroupDecommissioning)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, reasonDecommissionComplete, cond.Reason)
+}
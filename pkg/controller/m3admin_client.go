@@ -21,34 +21,80 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
 	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+	"github.com/m3db/m3db-operator/pkg/m3admin/topic"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	m3placement "github.com/m3db/m3/src/cluster/placement"
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
+	corev1 "k8s.io/api/core/v1"
+
+	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-// multiAdminClient wraps multiple m3admin placement and namespace clients based
-// on the cluster they're pointed at.
-type multiAdminClient struct {
-	mu        sync.RWMutex
-	nsClients map[string]namespace.Client
-	plClients map[string]placement.Client
+var errNoSecretAccess = errors.New("admin client has no access to secrets")
+
+// AdminClientConfiguration configures the clients of the coordinators' admin
+// APIs.
+type AdminClientConfiguration struct {
+	// Timeout is the timeout of each attempt of a request. Requests only time
+	// out when the controller stops if zero.
+	Timeout time.Duration
+
+	// RetryPolicy configures how failed requests are retried. The HTTP
+	// client's defaults are used if nil.
+	RetryPolicy *m3admin.RetryPolicy
+
+	// CircuitBreaker configures the breaker shared by the clients of each
+	// cluster's coordinator, so that an unreachable coordinator fails requests
+	// fast rather than tying up workers with retries. Disabled if nil.
+	CircuitBreaker *m3admin.CircuitBreakerOptions
+
+	// PlacementSet is whether the coordinators serve the placement set API,
+	// which isn't part of the admin API of the pinned m3 version. Changing the
+	// replication factor and restoring metadata snapshots both require it, and
+	// are refused if false.
+	PlacementSet bool
+}
 
-	nsClientFn func(...namespace.Option) (namespace.Client, error)
-	plClientFn func(...placement.Option) (placement.Client, error)
+// multiAdminClient wraps multiple m3admin placement, namespace and topic
+// clients based on the cluster they're pointed at.
+type multiAdminClient struct {
+	mu           sync.RWMutex
+	nsClients    map[string]namespace.Client
+	plClients    map[string]placement.Client
+	svcPlClients map[string]placement.Client
+	topicClients map[string]topic.Client
+	// fingerprints holds the fingerprint of the admin API credentials the
+	// cached clients of each cluster were created with.
+	fingerprints map[string]string
+	// breakers holds the circuit breaker shared by all the clients of each
+	// cluster's coordinator. Breakers are disabled if breakerOpts is nil.
+	breakers    map[string]*m3admin.CircuitBreaker
+	breakerOpts *m3admin.CircuitBreakerOptions
+
+	nsClientFn    func(...namespace.Option) (namespace.Client, error)
+	plClientFn    func(...placement.Option) (placement.Client, error)
+	svcPlClientFn func(string, ...placement.Option) (placement.Client, error)
+	topicClientFn func(...topic.Option) (topic.Client, error)
 
 	clusterKeyFn func(*myspec.M3DBCluster, string) string
 	clusterURLFn func(*myspec.M3DBCluster) string
+	secretFn     k8sops.SecretGetter
 
 	adminClientFn func(...m3admin.Option) m3admin.Client
 	adminOpts     []m3admin.Option
@@ -62,10 +108,7 @@ func clusterKey(cluster *myspec.M3DBCluster, url string) string {
 
 // clusterURL returns the URL to hit
 func clusterURL(cluster *myspec.M3DBCluster) string {
-	serviceName := k8sops.CoordinatorServiceName(cluster.Name)
-	urlFmt := "http://%s.%s:%d"
-	url := fmt.Sprintf(urlFmt, serviceName, cluster.Namespace, k8sops.PortM3Coordinator)
-	return url
+	return k8sops.CoordinatorURL(cluster)
 }
 
 func newAdminClient(opts ...m3admin.Option) m3admin.Client {
@@ -76,34 +119,129 @@ func newAdminClient(opts ...m3admin.Option) m3admin.Client {
 // intermediary kubectl proxy.
 func clusterURLProxy(cluster *myspec.M3DBCluster) string {
 	serviceName := k8sops.CoordinatorServiceName(cluster.Name)
+	if k8sops.AdminAPIUsesTLS(cluster) {
+		// Have the API server proxy to the coordinator over HTTPS.
+		serviceName = "https:" + serviceName
+	}
 	urlFmt := "http://localhost:8001/api/v1/namespaces/%s/services/%s:coordinator/proxy"
 	url := fmt.Sprintf(urlFmt, cluster.Namespace, serviceName)
 	return url
 }
 
+func noSecrets(namespace, name string) (*corev1.Secret, error) {
+	return nil, errNoSecretAccess
+}
+
+// newServicePlacementClient returns a placement client for the placement of
+// the given service.
+func newServicePlacementClient(service string, opts ...placement.Option) (placement.Client, error) {
+	return placement.NewClient(append(opts, placement.WithService(service))...)
+}
+
 func newMultiAdminClient(adminOpts []m3admin.Option, logger *zap.Logger) *multiAdminClient {
 	return &multiAdminClient{
 		nsClients:     make(map[string]namespace.Client),
 		plClients:     make(map[string]placement.Client),
+		svcPlClients:  make(map[string]placement.Client),
+		topicClients:  make(map[string]topic.Client),
+		fingerprints:  make(map[string]string),
+		breakers:      make(map[string]*m3admin.CircuitBreaker),
 		nsClientFn:    namespace.NewClient,
 		plClientFn:    placement.NewClient,
+		svcPlClientFn: newServicePlacementClient,
+		topicClientFn: topic.NewClient,
 		clusterKeyFn:  clusterKey,
 		clusterURLFn:  clusterURL,
+		secretFn:      noSecrets,
 		adminClientFn: newAdminClient,
 		adminOpts:     adminOpts,
 		logger:        logger,
 	}
 }
 
-func (m *multiAdminClient) adminClientForCluster(cluster *myspec.M3DBCluster) m3admin.Client {
+func (m *multiAdminClient) adminClientForCluster(
+	cluster *myspec.M3DBCluster,
+	key string,
+	creds k8sops.AdminAPICredentials,
+) m3admin.Client {
 	env := k8sops.DefaultM3ClusterEnvironmentName(cluster)
-	opts := append(m.adminOpts, m3admin.WithEnvironment(env))
+	opts := make([]m3admin.Option, 0, len(m.adminOpts)+5)
+	opts = append(opts, m.adminOpts...)
+	opts = append(opts,
+		m3admin.WithEnvironment(env),
+		m3admin.WithZone(k8sops.ServiceZone(cluster)),
+		m3admin.WithTLSConfig(creds.TLSConfig),
+		m3admin.WithHeaders(creds.Headers))
+	if breaker := m.breakerForCluster(key); breaker != nil {
+		opts = append(opts, m3admin.WithCircuitBreaker(breaker))
+	}
 	return m.adminClientFn(opts...)
 }
 
+// breakerForCluster returns the circuit breaker of a cluster's coordinator, or
+// nil if breakers are disabled. The breaker outlives the cluster's cached
+// clients so that a credential rotation doesn't reset it.
+func (m *multiAdminClient) breakerForCluster(key string) *m3admin.CircuitBreaker {
+	if m.breakerOpts == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	breaker, ok := m.breakers[key]
+	if !ok {
+		breaker = m3admin.NewCircuitBreaker(*m.breakerOpts)
+		m.breakers[key] = breaker
+	}
+	return breaker
+}
+
+// credentialsForCluster loads the admin API credentials of a cluster. If they
+// differ from the ones its cached clients were created with, such as after a
+// Secret was rotated, the cached clients are dropped so that they're recreated
+// with the new credentials.
+func (m *multiAdminClient) credentialsForCluster(
+	cluster *myspec.M3DBCluster,
+	key string,
+) (k8sops.AdminAPICredentials, error) {
+	creds, err := k8sops.LoadAdminAPICredentials(cluster, m.secretFn)
+	if err != nil {
+		return creds, pkgerrors.WithMessagef(err, "error loading admin API credentials of cluster '%s'", cluster.Name)
+	}
+
+	m.mu.RLock()
+	fingerprint, ok := m.fingerprints[key]
+	m.mu.RUnlock()
+	if ok && fingerprint == creds.Fingerprint {
+		return creds, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fingerprint, ok := m.fingerprints[key]; ok && fingerprint != creds.Fingerprint {
+		m.logger.Info("admin API credentials changed, dropping cached clients",
+			zap.String("cluster", cluster.Name))
+		delete(m.nsClients, key)
+		delete(m.plClients, key)
+		delete(m.topicClients, key)
+		for svcKey := range m.svcPlClients {
+			if strings.HasPrefix(svcKey, key+"/") {
+				delete(m.svcPlClients, svcKey)
+			}
+		}
+	}
+	m.fingerprints[key] = creds.Fingerprint
+
+	return creds, nil
+}
+
 func (m *multiAdminClient) namespaceClientForCluster(cluster *myspec.M3DBCluster) namespace.Client {
 	url := m.clusterURLFn(cluster)
 	key := m.clusterKeyFn(cluster, url)
+	creds, err := m.credentialsForCluster(cluster, key)
+	if err != nil {
+		return newErrorNamespaceClient(err)
+	}
 
 	m.mu.RLock()
 	client, ok := m.nsClients[key]
@@ -112,8 +250,8 @@ func (m *multiAdminClient) namespaceClientForCluster(cluster *myspec.M3DBCluster
 		return client
 	}
 
-	adminClient := m.adminClientForCluster(cluster)
-	client, err := m.nsClientFn(
+	adminClient := m.adminClientForCluster(cluster, key, creds)
+	client, err = m.nsClientFn(
 		namespace.WithClient(adminClient),
 		namespace.WithLogger(m.logger),
 		namespace.WithURL(url),
@@ -138,6 +276,10 @@ func (m *multiAdminClient) namespaceClientForCluster(cluster *myspec.M3DBCluster
 func (m *multiAdminClient) placementClientForCluster(cluster *myspec.M3DBCluster) placement.Client {
 	url := m.clusterURLFn(cluster)
 	key := m.clusterKeyFn(cluster, url)
+	creds, err := m.credentialsForCluster(cluster, key)
+	if err != nil {
+		return newErrorPlacementClient(err)
+	}
 
 	m.mu.RLock()
 	client, ok := m.plClients[key]
@@ -146,8 +288,8 @@ func (m *multiAdminClient) placementClientForCluster(cluster *myspec.M3DBCluster
 		return client
 	}
 
-	adminClient := m.adminClientForCluster(cluster)
-	client, err := m.plClientFn(
+	adminClient := m.adminClientForCluster(cluster, key, creds)
+	client, err = m.plClientFn(
 		placement.WithClient(adminClient),
 		placement.WithLogger(m.logger),
 		placement.WithURL(url),
@@ -168,6 +310,86 @@ func (m *multiAdminClient) placementClientForCluster(cluster *myspec.M3DBCluster
 	return client
 }
 
+// servicePlacementClientForCluster returns a client for the placement of
+// another service of the cluster than M3DB, such as its aggregators.
+func (m *multiAdminClient) servicePlacementClientForCluster(
+	cluster *myspec.M3DBCluster,
+	service string,
+) placement.Client {
+	url := m.clusterURLFn(cluster)
+	baseKey := m.clusterKeyFn(cluster, url)
+	key := baseKey + "/" + service
+	creds, err := m.credentialsForCluster(cluster, baseKey)
+	if err != nil {
+		return newErrorPlacementClient(err)
+	}
+
+	m.mu.RLock()
+	client, ok := m.svcPlClients[key]
+	m.mu.RUnlock()
+	if ok {
+		return client
+	}
+
+	adminClient := m.adminClientForCluster(cluster, baseKey, creds)
+	client, err = m.svcPlClientFn(service,
+		placement.WithClient(adminClient),
+		placement.WithLogger(m.logger),
+		placement.WithURL(url),
+	)
+	if err != nil {
+		return newErrorPlacementClient(err)
+	}
+
+	m.mu.Lock()
+	mapClient, ok := m.svcPlClients[key]
+	if ok {
+		client = mapClient
+	} else {
+		m.svcPlClients[key] = client
+	}
+	m.mu.Unlock()
+
+	return client
+}
+
+func (m *multiAdminClient) topicClientForCluster(cluster *myspec.M3DBCluster) topic.Client {
+	url := m.clusterURLFn(cluster)
+	key := m.clusterKeyFn(cluster, url)
+	creds, err := m.credentialsForCluster(cluster, key)
+	if err != nil {
+		return newErrorTopicClient(err)
+	}
+
+	m.mu.RLock()
+	client, ok := m.topicClients[key]
+	m.mu.RUnlock()
+	if ok {
+		return client
+	}
+
+	adminClient := m.adminClientForCluster(cluster, key, creds)
+	client, err = m.topicClientFn(
+		topic.WithClient(adminClient),
+		topic.WithLogger(m.logger),
+		topic.WithURL(url),
+	)
+	if err != nil {
+		return newErrorTopicClient(err)
+	}
+
+	m.mu.Lock()
+	mapClient, ok := m.topicClients[key]
+	if ok {
+		client = mapClient
+	} else {
+		m.topicClients[key] = client
+	}
+	m.mu.Unlock()
+
+	return client
+}
+
 // errorNamespaceClient implements namespace.Client by returning an error that a
 // specified cluster couldn't be found, enabling easier ergonomics for the
 // common pattern of looking up a client and returning an error if one is
@@ -180,15 +402,23 @@ func newErrorNamespaceClient(err error) namespace.Client {
 	return errorNamespaceClient{err: err}
 }
 
-func (c errorNamespaceClient) Create(request *admin.NamespaceAddRequest) error {
+func (c errorNamespaceClient) Create(ctx context.Context, request *admin.NamespaceAddRequest) error {
 	return c.err
 }
 
-func (c errorNamespaceClient) List() (*admin.NamespaceGetResponse, error) {
+func (c errorNamespaceClient) List(context.Context) (*admin.NamespaceGetResponse, error) {
 	return nil, c.err
 }
 
-func (c errorNamespaceClient) Delete(namespace string) error {
+func (c errorNamespaceClient) Get(context.Context, string) (*m3ns.NamespaceOptions, error) {
+	return nil, c.err
+}
+
+func (c errorNamespaceClient) Delete(ctx context.Context, namespace string) error {
+	return c.err
+}
+
+func (c errorNamespaceClient) Update(ctx context.Context, request *namespace.UpdateRequest) error {
 	return c.err
 }
 
@@ -202,26 +432,60 @@ func newErrorPlacementClient(err error) placement.Client {
 	return errorPlacementClient{err: err}
 }
 
-func (c errorPlacementClient) Init(request *admin.PlacementInitRequest) error {
+func (c errorPlacementClient) Init(ctx context.Context, request *admin.PlacementInitRequest) error {
 	return c.err
 }
 
-func (c errorPlacementClient) Get() (placement m3placement.Placement, err error) {
+func (c errorPlacementClient) Get(context.Context) (placement m3placement.Placement, err error) {
 	return nil, c.err
 }
 
-func (c errorPlacementClient) Delete() error {
+func (c errorPlacementClient) Set(context.Context, m3placement.Placement) (m3placement.Placement, error) {
+	return nil, c.err
+}
+
+func (c errorPlacementClient) MarkAvailable(context.Context, string, ...uint32) error {
+	return c.err
+}
+
+func (c errorPlacementClient) Delete(context.Context) error {
+	return c.err
+}
+
+func (c errorPlacementClient) Add(context.Context, ...placementpb.Instance) error {
 	return c.err
 }
 
-func (c errorPlacementClient) Add(placementpb.Instance) error {
+func (c errorPlacementClient) Remove(context.Context, string) error {
 	return c.err
 }
 
-func (c errorPlacementClient) Remove(string) error {
+func (c errorPlacementClient) Replace(context.Context, string, placementpb.Instance) error {
+	return c.err
+}
+
+// errorTopicClient follows the same pattern of errorNamespaceClient for
+// topic.Client.
+type errorTopicClient struct {
+	err error
+}
+
+func newErrorTopicClient(err error) topic.Client {
+	return errorTopicClient{err: err}
+}
+
+func (c errorTopicClient) Init(context.Context, string, uint32) error {
+	return c.err
+}
+
+func (c errorTopicClient) Get(context.Context, string) (*topic.Topic, error) {
+	return nil, c.err
+}
+
+func (c errorTopicClient) AddConsumer(context.Context, string, topic.ConsumerService) error {
 	return c.err
 }
 
-func (c errorPlacementClient) Replace(string, placementpb.Instance) error {
+func (c errorTopicClient) Delete(context.Context, string) error {
 	return c.err
 }
@@ -21,6 +21,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -28,9 +29,11 @@ import (
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
 	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+	"github.com/m3db/m3db-operator/pkg/m3admin/topic"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/golang/mock/gomock"
@@ -71,6 +74,10 @@ func TestClusterURLProxy(t *testing.T) {
 
 	url := clusterURLProxy(cluster)
 	assert.Equal(t, "http://localhost:8001/api/v1/namespaces/foo/services/m3coordinator-a:coordinator/proxy", url)
+
+	cluster.Spec.AdminAPI = &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{}}
+	url = clusterURLProxy(cluster)
+	assert.Equal(t, "http://localhost:8001/api/v1/namespaces/foo/services/https:m3coordinator-a:coordinator/proxy", url)
 }
 
 func TestNewMultiAdminClient(t *testing.T) {
@@ -84,8 +91,13 @@ func TestNewMultiAdminClient(t *testing.T) {
 	for _, v := range []interface{}{
 		m.plClients,
 		m.plClients,
+		m.svcPlClients,
+		m.topicClients,
+		m.breakers,
 		m.plClientFn,
 		m.plClientFn,
+		m.svcPlClientFn,
+		m.topicClientFn,
 		m.clusterKeyFn,
 		m.clusterURLFn,
 		m.adminClientFn,
@@ -133,7 +145,8 @@ func TestNamespaceClientForCluster(t *testing.T) {
 	assert.Equal(t, cl, cl2)
 
 	cl3 := m.namespaceClientForCluster(clusterC)
-	assert.Equal(t, testErr, cl3.Delete("foo"))
+	assert.Equal(t, testErr, cl3.Delete(context.Background(), "foo"))
+	assert.Equal(t, testErr, cl3.Update(context.Background(), &namespace.UpdateRequest{}))
 }
 
 func TestPlacementClientForCluster(t *testing.T) {
@@ -165,21 +178,199 @@ func TestPlacementClientForCluster(t *testing.T) {
 	assert.Equal(t, cl, cl2)
 
 	cl3 := m.placementClientForCluster(clusterC)
-	assert.Equal(t, testErr, cl3.Delete())
+	assert.Equal(t, testErr, cl3.Delete(context.Background()))
+}
+
+func TestServicePlacementClientForCluster(t *testing.T) {
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	m3Client := m3admin.NewMockClient(mc)
+	aggClient := placement.NewMockClient(mc)
+	coordClient := placement.NewMockClient(mc)
+
+	m := newTestAdminClient(m3Client, "http://foo")
+	m.svcPlClientFn = func(service string, _ ...placement.Option) (placement.Client, error) {
+		if service == placement.ServiceM3Aggregator {
+			return aggClient, nil
+		}
+		return coordClient, nil
+	}
+
+	clusterA := newM3DBCluster("ns", "a")
+	clusterB := newM3DBCluster("ns", "b")
+	testErr := errors.New("test")
+
+	cl := m.servicePlacementClientForCluster(clusterA, placement.ServiceM3Aggregator)
+	assert.Equal(t, aggClient, cl)
+	cl = m.servicePlacementClientForCluster(clusterA, placement.ServiceM3Coordinator)
+	assert.Equal(t, coordClient, cl)
+	assert.Equal(t, 2, len(m.svcPlClients))
+
+	m.svcPlClientFn = func(string, ...placement.Option) (placement.Client, error) {
+		return nil, testErr
+	}
+	cl = m.servicePlacementClientForCluster(clusterA, placement.ServiceM3Aggregator)
+	assert.Equal(t, aggClient, cl)
+
+	cl = m.servicePlacementClientForCluster(clusterB, placement.ServiceM3Aggregator)
+	assert.Equal(t, testErr, cl.Delete(context.Background()))
+}
+
+func TestTopicClientForCluster(t *testing.T) {
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	m3Client := m3admin.NewMockClient(mc)
+	topicClient := topic.NewMockClient(mc)
+
+	m := newTestAdminClient(m3Client, "http://foo")
+	m.topicClientFn = func(_ ...topic.Option) (topic.Client, error) {
+		return topicClient, nil
+	}
+
+	clusterA := newM3DBCluster("ns", "a")
+	clusterB := newM3DBCluster("ns", "b")
+	testErr := errors.New("test")
+
+	cl := m.topicClientForCluster(clusterA)
+	assert.Equal(t, topicClient, cl)
+	assert.Equal(t, 1, len(m.topicClients))
+
+	m.topicClientFn = func(_ ...topic.Option) (topic.Client, error) {
+		return nil, testErr
+	}
+	cl = m.topicClientForCluster(clusterB)
+	assert.Equal(t, testErr, cl.Init(context.Background(), "foo", 1))
+	_, err := cl.Get(context.Background(), "foo")
+	assert.Equal(t, testErr, err)
+	assert.Equal(t, testErr, cl.AddConsumer(context.Background(), "foo", topic.ConsumerService{}))
+	assert.Equal(t, testErr, cl.Delete(context.Background(), "foo"))
+}
+
+func TestClientsForClusterCredentialRotation(t *testing.T) {
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	m3Client := m3admin.NewMockClient(mc)
+	m := newTestAdminClient(m3Client, "http://foo")
+
+	token := []byte("foo-token")
+	m.secretFn = func(namespace, name string) (*corev1.Secret, error) {
+		if name != "auth" {
+			return nil, errors.New("not found")
+		}
+		return &corev1.Secret{Data: map[string][]byte{"token": token}}, nil
+	}
+
+	var plCreated, svcPlCreated int
+	m.plClientFn = func(_ ...placement.Option) (placement.Client, error) {
+		plCreated++
+		return placement.NewMockClient(mc), nil
+	}
+	m.svcPlClientFn = func(string, ...placement.Option) (placement.Client, error) {
+		svcPlCreated++
+		return placement.NewMockClient(mc), nil
+	}
+
+	cluster := newM3DBCluster("ns", "a")
+	cluster.Spec.AdminAPI = &myspec.AdminAPISpec{
+		Auth: &myspec.AdminAuthSpec{
+			BearerTokenSecret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "auth"},
+				Key:                  "token",
+			},
+		},
+	}
+
+	cl := m.placementClientForCluster(cluster)
+	svcCl := m.servicePlacementClientForCluster(cluster, placement.ServiceM3Aggregator)
+	assert.Equal(t, cl, m.placementClientForCluster(cluster))
+	assert.Equal(t, svcCl, m.servicePlacementClientForCluster(cluster, placement.ServiceM3Aggregator))
+	assert.Equal(t, 1, plCreated)
+	assert.Equal(t, 1, svcPlCreated)
+
+	// Rotating the token drops the cached clients.
+	token = []byte("bar-token")
+	m.placementClientForCluster(cluster)
+	m.servicePlacementClientForCluster(cluster, placement.ServiceM3Aggregator)
+	assert.Equal(t, 2, plCreated)
+	assert.Equal(t, 2, svcPlCreated)
+
+	// Clusters whose credentials can't be loaded get an error client.
+	other := newM3DBCluster("ns", "b")
+	other.Spec.AdminAPI = &myspec.AdminAPISpec{
+		Auth: &myspec.AdminAuthSpec{
+			BearerTokenSecret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+				Key:                  "token",
+			},
+		},
+	}
+	assert.Error(t, m.placementClientForCluster(other).Delete(context.Background()))
+	assert.Error(t, m.namespaceClientForCluster(other).Delete(context.Background(), "foo"))
+	assert.Error(t, m.topicClientForCluster(other).Delete(context.Background(), "foo"))
+	assert.Equal(t, 2, plCreated)
+}
+
+func TestClientsForClusterCircuitBreaker(t *testing.T) {
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+
+	m3Client := m3admin.NewMockClient(mc)
+	m := newTestAdminClient(m3Client, "http://foo")
+	m.plClientFn = func(_ ...placement.Option) (placement.Client, error) {
+		return placement.NewMockClient(mc), nil
+	}
+	m.svcPlClientFn = func(string, ...placement.Option) (placement.Client, error) {
+		return placement.NewMockClient(mc), nil
+	}
+	m.nsClientFn = func(_ ...namespace.Option) (namespace.Client, error) {
+		return namespace.NewMockClient(mc), nil
+	}
+
+	clusterA := newM3DBCluster("ns", "a")
+	clusterB := newM3DBCluster("ns", "b")
+
+	// Breakers are disabled by default.
+	m.placementClientForCluster(clusterA)
+	assert.Empty(t, m.breakers)
+	assert.Nil(t, m.breakerForCluster("a"))
+
+	// All the clients of a cluster share its breaker.
+	m.breakerOpts = &m3admin.CircuitBreakerOptions{FailureThreshold: 1}
+	m.servicePlacementClientForCluster(clusterA, placement.ServiceM3Aggregator)
+	m.namespaceClientForCluster(clusterA)
+	assert.Len(t, m.breakers, 1)
+	breaker := m.breakerForCluster("a")
+	assert.NotNil(t, breaker)
+
+	m.namespaceClientForCluster(clusterB)
+	assert.Len(t, m.breakers, 2)
+
+	// Opening one cluster's breaker doesn't affect the others.
+	breaker.Record(true)
+	assert.True(t, breaker.Open())
+	assert.False(t, m.breakerForCluster("b").Open())
+	assert.Equal(t, breaker, m.breakerForCluster("a"))
 }
 
 func TestErrorNamespaceClient(t *testing.T) {
 	clErr := errors.New("test")
 	cl := newErrorNamespaceClient(clErr)
 
-	err := cl.Create(nil)
+	err := cl.Create(context.Background(), nil)
 	assert.Equal(t, clErr, err)
 
-	r, err := cl.List()
+	r, err := cl.List(context.Background())
 	assert.Nil(t, r)
 	assert.Equal(t, clErr, err)
 
-	err = cl.Delete("foo")
+	opts, err := cl.Get(context.Background(), "foo")
+	assert.Nil(t, opts)
+	assert.Equal(t, clErr, err)
+
+	err = cl.Delete(context.Background(), "foo")
 	assert.Equal(t, clErr, err)
 }
 
@@ -187,22 +378,29 @@ func TestErrorPlacementClient(t *testing.T) {
 	clErr := errors.New("test")
 	cl := newErrorPlacementClient(clErr)
 
-	err := cl.Init(nil)
+	err := cl.Init(context.Background(), nil)
 	assert.Equal(t, clErr, err)
 
-	pl, err := cl.Get()
+	pl, err := cl.Get(context.Background())
 	assert.Nil(t, pl)
 	assert.Equal(t, clErr, err)
 
-	err = cl.Delete()
+	err = cl.Delete(context.Background())
 	assert.Equal(t, clErr, err)
 
-	err = cl.Add(placementpb.Instance{})
+	err = cl.Add(context.Background(), placementpb.Instance{})
+	assert.Equal(t, clErr, err)
+
+	err = cl.Remove(context.Background(), "foo")
+	assert.Equal(t, clErr, err)
+
+	pl, err = cl.Set(context.Background(), nil)
+	assert.Nil(t, pl)
 	assert.Equal(t, clErr, err)
 
-	err = cl.Remove("foo")
+	err = cl.MarkAvailable(context.Background(), "foo")
 	assert.Equal(t, clErr, err)
 
-	err = cl.Replace("foo", placementpb.Instance{})
+	err = cl.Replace(context.Background(), "foo", placementpb.Instance{})
 	assert.Equal(t, clErr, err)
 }
@@ -0,0 +1,416 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Reasons for an M3DBNamespace's Ready condition.
+const (
+	reasonNamespaceCreated      = "NamespaceCreated"
+	reasonNamespaceReconciled   = "NamespaceReconciled"
+	reasonClusterNotFound       = "ClusterNotFound"
+	reasonClusterUnavailable    = "ClusterUnavailable"
+	reasonNamespaceConflict     = "ConflictingNamespace"
+	reasonInvalidNamespaceSpec  = "InvalidSpec"
+	reasonImmutableOptionChange = "ImmutableOptionChanged"
+)
+
+func (c *Controller) enqueueNamespace(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.namespaceWorkQueue.AddRateLimited(key)
+	c.scope.Counter("enqueued_namespace_event").Inc(int64(1))
+}
+
+// enqueueClusterNamespaces enqueues every M3DBNamespace that belongs to the
+// given cluster.
+func (c *Controller) enqueueClusterNamespaces(obj interface{}) {
+	cluster, ok := obj.(*myspec.M3DBCluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("expected cluster, got %#v", obj))
+		return
+	}
+
+	for _, ns := range c.clusterNamespaces(cluster) {
+		c.enqueueNamespace(ns)
+	}
+}
+
+// clusterNamespaces returns the M3DBNamespaces that reference the cluster.
+func (c *Controller) clusterNamespaces(cluster *myspec.M3DBCluster) []*myspec.M3DBNamespace {
+	all, err := c.namespaceLister.M3DBNamespaces(cluster.Namespace).List(klabels.Everything())
+	if err != nil {
+		c.logger.Error("error listing namespaces", zap.String("cluster", cluster.Name), zap.Error(err))
+		return nil
+	}
+
+	var namespaces []*myspec.M3DBNamespace
+	for _, ns := range all {
+		if ns.Spec.ClusterName == cluster.Name {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+func (c *Controller) runNamespaceLoop() {
+	for c.processNamespaceQueueItem() {
+	}
+}
+
+func (c *Controller) processNamespaceQueueItem() bool {
+	obj, shutdown := c.namespaceWorkQueue.Get()
+	c.scope.Counter("dequeued_namespace_event").Inc(int64(1))
+	if shutdown {
+		return false
+	}
+
+	if c.isStopping() {
+		c.namespaceWorkQueue.Done(obj)
+		return false
+	}
+
+	// Closure so we can defer workQueue.Done.
+	err := func(obj interface{}) error {
+		defer c.namespaceWorkQueue.Done(obj)
+
+		ctx, cancel := c.workContext()
+		defer cancel()
+
+		key, ok := obj.(string)
+		if !ok {
+			c.namespaceWorkQueue.Forget(obj)
+			runtime.HandleError(fmt.Errorf("expected string from queue, got %#v", obj))
+			return nil
+		}
+
+		if err := c.handleNamespaceEvent(ctx, key); err != nil {
+			return fmt.Errorf("error syncing namespace '%s': %v", key, err)
+		}
+
+		c.namespaceWorkQueue.Forget(obj)
+		c.logger.Info("successfully synced namespace", zap.String("key", key))
+
+		return nil
+	}(obj)
+
+	if err != nil {
+		runtime.HandleError(err)
+	}
+
+	return true
+}
+
+func (c *Controller) handleNamespaceEvent(ctx context.Context, key string) error {
+	kubeNamespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	ns, err := c.namespaceLister.M3DBNamespaces(kubeNamespace).Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// give up processing if this namespace doesn't exist
+			runtime.HandleError(fmt.Errorf("namespace '%s' no longer exists", key))
+			return nil
+		}
+
+		return err
+	}
+
+	// MUST deep copy to avoid corrupting the cache.
+	return c.handleNamespaceUpdate(ctx, ns.DeepCopy())
+}
+
+// handleNamespaceUpdate creates or updates the namespace in its cluster, or
+// deletes it from the cluster if the M3DBNamespace is being deleted.
+func (c *Controller) handleNamespaceUpdate(ctx context.Context, ns *myspec.M3DBNamespace) error {
+	nsLogger := c.logger.With(
+		zap.String("m3dbnamespace", ns.Name),
+		zap.String("namespace", ns.NamespaceName()),
+		zap.String("cluster", ns.Spec.ClusterName))
+
+	cluster, err := c.clusterLister.M3DBClusters(ns.Namespace).Get(ns.Spec.ClusterName)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	if kerrors.IsNotFound(err) {
+		// Leave the namespaces of clusters managed by another operator alone,
+		// including their finalizers.
+		unmanaged, err := c.isUnmanagedCluster(ns.Namespace, ns.Spec.ClusterName)
+		if err != nil {
+			return err
+		}
+		if unmanaged {
+			nsLogger.Debug("ignoring namespace of unmanaged cluster")
+			return nil
+		}
+		cluster = nil
+	}
+
+	if dts := ns.DeletionTimestamp; dts != nil && !dts.IsZero() {
+		if !stringArrayContains(ns.Finalizers, labels.NamespaceDeletionFinalizer) {
+			return nil
+		}
+
+		// If the cluster is gone (or going) its namespaces go with it, and if this
+		// namespace conflicts with another definition that definition owns it.
+		if cluster != nil && cluster.DeletionTimestamp == nil && c.namespaceConflict(ns, cluster) == "" {
+			err := c.adminClient.namespaceClientForCluster(cluster).Delete(ctx, ns.NamespaceName())
+			if err != nil && pkgerrors.Cause(err) != m3admin.ErrNotFound {
+				nsLogger.Error("error deleting namespace", zap.Error(err))
+				return pkgerrors.WithMessagef(err, "error deleting namespace '%s'", ns.NamespaceName())
+			}
+			nsLogger.Info("deleted namespace")
+			c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted namespace "+ns.NamespaceName())
+		}
+
+		if _, err := c.removeNamespaceFinalizer(ns); err != nil {
+			return pkgerrors.WithMessage(err, "error removing namespace finalizer")
+		}
+
+		nsLogger.Info("completed namespace finalizer cleanup")
+		return nil
+	}
+
+	ns, err = c.ensureNamespaceFinalizer(ns)
+	if err != nil {
+		return err
+	}
+
+	if cluster == nil {
+		return c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonClusterNotFound,
+			message: fmt.Sprintf("cluster %s does not exist", ns.Spec.ClusterName),
+		})
+	}
+
+	if msg := c.namespaceConflict(ns, cluster); msg != "" {
+		c.recorder.WarningEvent(ns, eventer.ReasonFailSync, msg)
+		return c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonNamespaceConflict,
+			message: msg,
+		})
+	}
+
+	nsClient := c.adminClient.namespaceClientForCluster(cluster)
+	resp, err := nsClient.List(ctx)
+	if err == nil && resp.Registry == nil {
+		err = errNilNamespaceRegistry
+	}
+	if err != nil {
+		nsLogger.Warn("unable to list cluster namespaces", zap.Error(err))
+		if statusErr := c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonClusterUnavailable,
+			message: fmt.Sprintf("unable to list cluster namespaces: %v", err),
+		}); statusErr != nil {
+			return statusErr
+		}
+		return err
+	}
+
+	current, ok := resp.Registry.Namespaces[ns.NamespaceName()]
+	if !ok {
+		req, err := namespace.RequestFromSpec(ns.NamespaceSpec())
+		if err != nil {
+			c.recorder.WarningEvent(ns, eventer.ReasonFailSync, "invalid namespace spec: %v", err)
+			return c.setNamespaceReady(ns, conditionState{
+				status:  corev1.ConditionFalse,
+				reason:  reasonInvalidNamespaceSpec,
+				message: err.Error(),
+			})
+		}
+
+		if err := nsClient.Create(ctx, req); err != nil {
+			nsLogger.Error("error creating namespace", zap.Error(err))
+			return pkgerrors.WithMessagef(err, "error creating namespace '%s'", ns.NamespaceName())
+		}
+
+		nsLogger.Info("created namespace")
+		c.recorder.NormalEvent(ns, eventer.ReasonCreating, "created namespace "+ns.NamespaceName())
+		return c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionTrue,
+			reason:  reasonNamespaceCreated,
+			message: "namespace created",
+		})
+	}
+
+	req, err := namespace.UpdateRequestFromSpec(ns.NamespaceSpec(), current)
+	if pkgerrors.Cause(err) == namespace.ErrImmutableOption {
+		nsLogger.Warn("rejecting namespace update", zap.Error(err))
+		c.recorder.WarningEvent(ns, eventer.ReasonFailedToUpdate, "rejected update: %v", err)
+		return c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonImmutableOptionChange,
+			message: err.Error(),
+		})
+	}
+	if err != nil {
+		c.recorder.WarningEvent(ns, eventer.ReasonFailSync, "invalid namespace spec: %v", err)
+		return c.setNamespaceReady(ns, conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonInvalidNamespaceSpec,
+			message: err.Error(),
+		})
+	}
+
+	if req != nil {
+		if err := nsClient.Update(ctx, req); err != nil {
+			nsLogger.Error("error updating namespace", zap.Error(err))
+			return pkgerrors.WithMessagef(err, "error updating namespace '%s'", ns.NamespaceName())
+		}
+
+		nsLogger.Info("updated namespace")
+		c.recorder.NormalEvent(ns, eventer.ReasonSuccessfulUpdate, "updated namespace "+ns.NamespaceName())
+	}
+
+	return c.setNamespaceReady(ns, conditionState{
+		status:  corev1.ConditionTrue,
+		reason:  reasonNamespaceReconciled,
+		message: "namespace matches spec",
+	})
+}
+
+// namespaceConflict returns a non-empty message if the namespace is already
+// defined in the cluster's spec or by an older M3DBNamespace, in which case
+// this M3DBNamespace must not manage it.
+func (c *Controller) namespaceConflict(ns *myspec.M3DBNamespace, cluster *myspec.M3DBCluster) string {
+	name := ns.NamespaceName()
+	for _, specNs := range cluster.Spec.Namespaces {
+		if specNs.Name == name {
+			return fmt.Sprintf("namespace %s is defined in the spec of cluster %s", name, cluster.Name)
+		}
+	}
+
+	for _, other := range c.clusterNamespaces(cluster) {
+		if other.Name == ns.Name || other.NamespaceName() != name || other.DeletionTimestamp != nil {
+			continue
+		}
+
+		older := other.CreationTimestamp.Before(&ns.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&ns.CreationTimestamp) && other.Name < ns.Name)
+		if older {
+			return fmt.Sprintf("namespace %s is already defined by %s", name, other.Name)
+		}
+	}
+
+	return ""
+}
+
+// setNamespaceReady sets the namespace's Ready condition and observed
+// generation, writing the status only if it changed.
+func (c *Controller) setNamespaceReady(ns *myspec.M3DBNamespace, state conditionState) error {
+	status := ns.Status.DeepCopy()
+	status.ObservedGeneration = ns.Generation
+
+	now := c.clock.Now().UTC().Format(time.RFC3339)
+	cond, ok := status.GetCondition(myspec.NamespaceConditionReady)
+	if !ok {
+		cond = myspec.NamespaceCondition{
+			Type:   myspec.NamespaceConditionReady,
+			Status: corev1.ConditionUnknown,
+		}
+	}
+	if !ok || cond.Status != state.status || cond.Reason != state.reason || cond.Message != state.message {
+		if cond.Status != state.status {
+			cond.LastTransitionTime = now
+		}
+		cond.Status = state.status
+		cond.LastUpdateTime = now
+		cond.Reason = state.reason
+		cond.Message = state.message
+		status.UpdateCondition(cond)
+	}
+
+	if reflect.DeepEqual(status, &ns.Status) {
+		return nil
+	}
+
+	ns.Status = *status
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBNamespaces(ns.Namespace).UpdateStatus(ns); err != nil {
+		return pkgerrors.WithMessage(err, "error updating namespace status")
+	}
+	return nil
+}
+
+func (c *Controller) updateNamespaceFinalizers(ns *myspec.M3DBNamespace) (*myspec.M3DBNamespace, error) {
+	ns, err := c.crdClient.OperatorV1alpha1().M3DBNamespaces(ns.Namespace).Update(ns)
+	if err != nil {
+		return nil, pkgerrors.WithMessage(err, "error updating namespace finalizers")
+	}
+	return ns, nil
+}
+
+// ensureNamespaceFinalizer ensures that the namespace deletion finalizer is
+// present.
+func (c *Controller) ensureNamespaceFinalizer(ns *myspec.M3DBNamespace) (*myspec.M3DBNamespace, error) {
+	if stringArrayContains(ns.Finalizers, labels.NamespaceDeletionFinalizer) {
+		return ns, nil
+	}
+
+	c.logger.Info("adding finalizer to namespace", zap.String("m3dbnamespace", ns.Name))
+	ns.Finalizers = append(ns.Finalizers, labels.NamespaceDeletionFinalizer)
+	return c.updateNamespaceFinalizers(ns)
+}
+
+// removeNamespaceFinalizer ensures the namespace deletion finalizer is absent.
+func (c *Controller) removeNamespaceFinalizer(ns *myspec.M3DBNamespace) (*myspec.M3DBNamespace, error) {
+	if !stringArrayContains(ns.Finalizers, labels.NamespaceDeletionFinalizer) {
+		return ns, nil
+	}
+
+	finalizers := make([]string, 0, len(ns.Finalizers))
+	for _, f := range ns.Finalizers {
+		if f != labels.NamespaceDeletionFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+
+	ns.Finalizers = finalizers
+	return c.updateNamespaceFinalizers(ns)
+}
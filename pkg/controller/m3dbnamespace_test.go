@@ -0,0 +1,268 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newM3DBNamespace(name, clusterName string) *myspec.M3DBNamespace {
+	return &myspec.M3DBNamespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "fake",
+			Generation: 2,
+		},
+		Spec: myspec.M3DBNamespaceSpec{
+			ClusterName: clusterName,
+			Preset:      string(namespace.PresetTenSecondsTwoDaysIndexed),
+		},
+	}
+}
+
+func getM3DBNamespace(t *testing.T, deps *testDeps, name string) *myspec.M3DBNamespace {
+	ns, err := deps.crdClient.OperatorV1alpha1().M3DBNamespaces("fake").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return ns
+}
+
+func assertNamespaceReady(t *testing.T, ns *myspec.M3DBNamespace, status corev1.ConditionStatus, reason string) {
+	cond, ok := ns.Status.GetCondition(myspec.NamespaceConditionReady)
+	require.True(t, ok)
+	assert.Equal(t, status, cond.Status)
+	assert.Equal(t, reason, cond.Reason)
+	assert.Equal(t, ns.Generation, ns.Status.ObservedGeneration)
+}
+
+func TestHandleNamespaceUpdateCreate(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	ns := newM3DBNamespace("foo", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{},
+	}, nil)
+	deps.namespaceClient.EXPECT().Create(gomock.Any(), namespaceMatcher{"foo"}).Return(nil)
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+
+	ns = getM3DBNamespace(t, deps, "foo")
+	assert.Contains(t, ns.Finalizers, labels.NamespaceDeletionFinalizer)
+	assertNamespaceReady(t, ns, corev1.ConditionTrue, reasonNamespaceCreated)
+}
+
+func TestHandleNamespaceUpdateOptions(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	ns := newM3DBNamespace("foo", cluster.Name)
+	ns.Spec.Name = "metrics:2d"
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	req, err := namespace.RequestFromSpec(ns.NamespaceSpec())
+	require.NoError(t, err)
+	current := req.Options
+	current.RetentionOptions.RetentionPeriodNanos = int64(24 * time.Hour)
+
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics:2d": current,
+		}},
+	}, nil)
+	deps.namespaceClient.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *namespace.UpdateRequest) error {
+		assert.Equal(t, "metrics:2d", req.Name)
+		assert.Equal(t, int64(48*time.Hour), req.Options.RetentionOptions.RetentionPeriodNanos)
+		return nil
+	})
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	assertNamespaceReady(t, getM3DBNamespace(t, deps, "foo"), corev1.ConditionTrue, reasonNamespaceReconciled)
+}
+
+func TestHandleNamespaceUpdateImmutable(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	ns := newM3DBNamespace("foo", cluster.Name)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	req, err := namespace.RequestFromSpec(ns.NamespaceSpec())
+	require.NoError(t, err)
+	current := req.Options
+	current.RetentionOptions.BlockSizeNanos = int64(4 * time.Hour)
+
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"foo": current,
+		}},
+	}, nil)
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	assertNamespaceReady(t, getM3DBNamespace(t, deps, "foo"), corev1.ConditionFalse, reasonImmutableOptionChange)
+}
+
+func TestHandleNamespaceUpdateConflicts(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Namespaces = []myspec.Namespace{{Name: "inline", Preset: "10s:2d"}}
+
+	inline := newM3DBNamespace("inline", cluster.Name)
+	older := newM3DBNamespace("older", cluster.Name)
+	older.Spec.Name = "shared"
+	older.CreationTimestamp = metav1.NewTime(time.Unix(100, 0))
+	newer := newM3DBNamespace("newer", cluster.Name)
+	newer.Spec.Name = "shared"
+	newer.CreationTimestamp = metav1.NewTime(time.Unix(200, 0))
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, inline, older, newer},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), inline.DeepCopy()))
+	assertNamespaceReady(t, getM3DBNamespace(t, deps, "inline"), corev1.ConditionFalse, reasonNamespaceConflict)
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), newer.DeepCopy()))
+	assertNamespaceReady(t, getM3DBNamespace(t, deps, "newer"), corev1.ConditionFalse, reasonNamespaceConflict)
+
+	assert.Empty(t, controller.namespaceConflict(older, cluster))
+}
+
+func TestHandleNamespaceUpdateClusterNotFound(t *testing.T) {
+	ns := newM3DBNamespace("foo", "missing")
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	assertNamespaceReady(t, getM3DBNamespace(t, deps, "foo"), corev1.ConditionFalse, reasonClusterNotFound)
+
+	// An unchanged status should not be written again.
+	numActions := len(deps.crdClient.Actions())
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), getM3DBNamespace(t, deps, "foo")))
+	for _, action := range deps.crdClient.Actions()[numActions:] {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+}
+
+func TestHandleNamespaceUpdateUnmanagedCluster(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Labels = map[string]string{"team": "other"}
+	ns := newM3DBNamespace("foo", cluster.Name)
+	ns.Namespace = cluster.Namespace
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// The cluster isn't selected, so it's missing from the controller's cache.
+	controller.clusterSelector = klabels.SelectorFromSet(klabels.Set{"team": "mine"})
+	controller.clusterLister = newEmptyClusterLister()
+
+	numActions := len(deps.crdClient.Actions())
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	for _, action := range deps.crdClient.Actions()[numActions:] {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+
+	// A cluster that doesn't exist at all is reported as missing.
+	require.NoError(t, deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Delete(cluster.Name, nil))
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	updated, err := deps.crdClient.OperatorV1alpha1().M3DBNamespaces(ns.Namespace).Get(ns.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assertNamespaceReady(t, updated, corev1.ConditionFalse, reasonClusterNotFound)
+}
+
+func TestHandleNamespaceDelete(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	ns := newM3DBNamespace("foo", cluster.Name)
+	now := metav1.Now()
+	ns.DeletionTimestamp = &now
+	ns.Finalizers = []string{labels.NamespaceDeletionFinalizer}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, ns},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	deps.namespaceClient.EXPECT().Delete(gomock.Any(), "foo").Return(pkgerrors.WithMessage(m3admin.ErrNotFound, "foo"))
+
+	require.NoError(t, controller.handleNamespaceUpdate(context.Background(), ns.DeepCopy()))
+	assert.NotContains(t, getM3DBNamespace(t, deps, "foo").Finalizers, labels.NamespaceDeletionFinalizer)
+}
+
+func TestPruneNamespacesKeepsManagedNamespaces(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Namespaces = []myspec.Namespace{}
+	managed := newM3DBNamespace("managed", cluster.Name)
+	other := newM3DBNamespace("other", "other-cluster")
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster, managed, other},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"managed": {},
+		"other":   {},
+	}}
+
+	deps.namespaceClient.EXPECT().Delete(gomock.Any(), "other").Return(nil)
+	require.NoError(t, controller.pruneNamespaces(context.Background(), cluster, registry))
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultMetadataSnapshotRetention = 5
+
+var (
+	errNoMetadataSnapshot   = errors.New("placement not found and no metadata snapshot to restore it from")
+	errPlacementSetDisabled = errors.New("placement not found and restoring it requires the placement set API, " +
+		"enable it with -admin-placement-set")
+)
+
+// snapshotAllClusterMetadata snapshots the placement and namespaces of every
+// cluster with an initialized placement.
+func (c *Controller) snapshotAllClusterMetadata() {
+	ctx, cancel := c.workContext()
+	defer cancel()
+
+	clusters, err := c.clusterLister.List(klabels.Everything())
+	if err != nil {
+		c.logger.Error("error listing clusters", zap.Error(err))
+		return
+	}
+
+	for _, cluster := range clusters {
+		if c.isStopping() {
+			return
+		}
+		if cluster.DeletionTimestamp != nil || !cluster.Status.HasInitializedPlacement() {
+			continue
+		}
+		// A placement that's being restored isn't snapshotted until it matches
+		// the snapshot it's restored from.
+		if _, ok := cluster.Annotations[annotations.MetadataRestoring]; ok {
+			continue
+		}
+		if err := c.snapshotClusterMetadata(ctx, cluster); err != nil {
+			c.logger.Warn("error snapshotting cluster metadata", zap.String("cluster", cluster.Name), zap.Error(err))
+		}
+	}
+}
+
+// snapshotClusterMetadata adds a snapshot of the cluster's placement and
+// namespace registry to its metadata ConfigMap, unless they haven't changed
+// since the last snapshot.
+func (c *Controller) snapshotClusterMetadata(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	// Never snapshot a partial view of the cluster's metadata, if either can't
+	// be read we keep the previous snapshot.
+	pl, err := c.adminClient.placementClientForCluster(cluster).Get(ctx)
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching placement")
+	}
+	plProto, err := pl.Proto()
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error converting placement")
+	}
+
+	resp, err := c.adminClient.namespaceClientForCluster(cluster).List(ctx)
+	if err == nil && resp.Registry == nil {
+		err = errNilNamespaceRegistry
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing namespaces")
+	}
+
+	cms := c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace)
+	cm, err := cms.Get(k8sops.MetadataSnapshotConfigMapName(cluster.Name), metav1.GetOptions{})
+	exists := err == nil
+	if kerrors.IsNotFound(err) {
+		cm, err = k8sops.GenerateMetadataSnapshotConfigMap(cluster), nil
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching metadata snapshots")
+	}
+
+	retain := c.config.MetadataSnapshotRetention
+	if retain == 0 {
+		retain = defaultMetadataSnapshotRetention
+	}
+	snapshot := &k8sops.MetadataSnapshot{
+		CreatedAt:        c.clock.Now().UTC().Format(time.RFC3339),
+		PlacementVersion: pl.Version(),
+		Placement:        plProto,
+		Namespaces:       resp.Registry,
+	}
+	added, err := k8sops.AddMetadataSnapshot(cm, snapshot, retain)
+	if err != nil || !added {
+		return err
+	}
+
+	if exists {
+		_, err = cms.Update(cm)
+	} else {
+		_, err = cms.Create(cm)
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error saving metadata snapshot")
+	}
+
+	c.logger.Info("snapshotted cluster metadata", zap.String("cluster", cluster.Name),
+		zap.Int64("version", snapshot.Version), zap.Int("placementVersion", snapshot.PlacementVersion))
+	return nil
+}
+
+// needsMetadataRestore returns whether the cluster's placement was initialized
+// but is no longer found, e.g. because etcd lost its data, or a restore of it
+// is still in progress.
+func (c *Controller) needsMetadataRestore(ctx context.Context, cluster *myspec.M3DBCluster) bool {
+	if _, ok := cluster.Annotations[annotations.MetadataRestoring]; ok {
+		return true
+	}
+	if !cluster.Status.HasInitializedPlacement() {
+		return false
+	}
+	// Any other error is surfaced once the placement is reconciled.
+	_, err := c.adminClient.placementClientForCluster(cluster).Get(ctx)
+	return pkgerrors.Cause(err) == m3admin.ErrNotFound
+}
+
+// restoreClusterMetadata restores the placement and namespaces of a cluster
+// whose placement was lost from the cluster's latest metadata snapshot. It must
+// run before any pods are added to the placement, otherwise they'd be
+// initialized as a new placement.
+//
+// The cluster is first annotated with the snapshot's version, and only
+// restored once the annotation is cached: Init assigns shards anew until the
+// snapshot's placement is set over it, so a restore interrupted in between
+// must not be mistaken for a healthy placement. Without the placement set API
+// nothing is restored, rather than leaving the newly initialized placement.
+func (c *Controller) restoreClusterMetadata(ctx context.Context, cluster *myspec.M3DBCluster) error {
+	clusterLogger := c.logger.With(zap.String("cluster", cluster.Name))
+
+	if !c.config.AdminClient.PlacementSet {
+		clusterLogger.Error(errPlacementSetDisabled.Error())
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, errPlacementSetDisabled.Error())
+		return errPlacementSetDisabled
+	}
+
+	cm, err := c.kubeClient.CoreV1().ConfigMaps(cluster.Namespace).
+		Get(k8sops.MetadataSnapshotConfigMapName(cluster.Name), metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return pkgerrors.WithMessage(err, "error fetching metadata snapshots")
+	}
+
+	restoring, ok := cluster.Annotations[annotations.MetadataRestoring]
+	var snapshot *k8sops.MetadataSnapshot
+	if err == nil {
+		if ok {
+			version, perr := strconv.ParseInt(restoring, 10, 64)
+			if perr != nil {
+				return pkgerrors.WithMessagef(perr, "invalid metadata snapshot version '%s'", restoring)
+			}
+			snapshot, err = k8sops.MetadataSnapshotVersion(cm, version)
+		} else {
+			snapshot, err = k8sops.LatestMetadataSnapshot(cm)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if snapshot == nil {
+		clusterLogger.Error(errNoMetadataSnapshot.Error())
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, errNoMetadataSnapshot.Error())
+		return errNoMetadataSnapshot
+	}
+
+	if !ok {
+		cluster = cluster.DeepCopy()
+		if cluster.Annotations == nil {
+			cluster.Annotations = make(map[string]string)
+		}
+		cluster.Annotations[annotations.MetadataRestoring] = strconv.FormatInt(snapshot.Version, 10)
+		if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster); err != nil {
+			return pkgerrors.WithMessage(err, "error annotating cluster")
+		}
+		clusterLogger.Warn("placement not found, restoring metadata from snapshot",
+			zap.Int64("version", snapshot.Version), zap.String("createdAt", snapshot.CreatedAt))
+		return nil
+	}
+
+	plClient := c.adminClient.placementClientForCluster(cluster)
+	current, err := plClient.Get(ctx)
+	if pkgerrors.Cause(err) == m3admin.ErrNotFound {
+		req := k8sops.PlacementInitRequestFromSnapshot(snapshot)
+		if err := plClient.Init(ctx, req); err != nil {
+			return pkgerrors.WithMessage(err, "error restoring placement")
+		}
+		current, err = plClient.Get(ctx)
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching placement")
+	}
+
+	// Set the snapshot's placement over the initialized one so that every
+	// instance owns the shards it holds the data of.
+	restored, err := m3placement.NewPlacementFromProto(snapshot.Placement)
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error converting snapshot placement")
+	}
+	if _, err := plClient.Set(ctx, restored.SetVersion(current.Version())); err != nil {
+		return pkgerrors.WithMessage(err, "error restoring placement")
+	}
+
+	nsClient := c.adminClient.namespaceClientForCluster(cluster)
+	resp, err := nsClient.List(ctx)
+	if err == nil && resp.Registry == nil {
+		err = errNilNamespaceRegistry
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing namespaces")
+	}
+
+	names := make([]string, 0, len(snapshot.Namespaces.Namespaces))
+	for name := range snapshot.Namespaces.Namespaces {
+		if _, ok := resp.Registry.Namespaces[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		req := &admin.NamespaceAddRequest{
+			Name:    name,
+			Options: snapshot.Namespaces.Namespaces[name],
+		}
+		if err := nsClient.Create(ctx, req); err != nil {
+			return pkgerrors.WithMessagef(err, "error restoring namespace '%s'", name)
+		}
+	}
+
+	cluster = cluster.DeepCopy()
+	delete(cluster.Annotations, annotations.MetadataRestoring)
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster); err != nil {
+		return pkgerrors.WithMessage(err, "error annotating cluster")
+	}
+
+	clusterLogger.Info("restored metadata from snapshot",
+		zap.Int64("version", snapshot.Version), zap.Strings("namespaces", names))
+	c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulCreate,
+		"restored placement and %d namespaces from metadata snapshot %d taken at %s",
+		len(names), snapshot.Version, snapshot.CreatedAt)
+	return nil
+}
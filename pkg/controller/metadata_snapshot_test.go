@@ -0,0 +1,266 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetadataSnapshot(t *testing.T, deps *testDeps, clusterName string) *k8sops.MetadataSnapshot {
+	cm, err := deps.kubeClient.CoreV1().ConfigMaps("fake").
+		Get(k8sops.MetadataSnapshotConfigMapName(clusterName), metav1.GetOptions{})
+	require.NoError(t, err)
+	snapshot, err := k8sops.LatestMetadataSnapshot(cm)
+	require.NoError(t, err)
+	return snapshot
+}
+
+func TestSnapshotClusterMetadata(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	pl := backupTestPlacement(t, cluster, pods, deps, shard.Available)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(pl, nil).Times(3)
+
+	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+		"metrics-10s:2d": {BootstrapEnabled: true},
+	}}
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{Registry: registry}, nil).Times(2)
+
+	require.NoError(t, controller.snapshotClusterMetadata(context.Background(), cluster))
+	snapshot := getMetadataSnapshot(t, deps, cluster.Name)
+	assert.Equal(t, int64(1), snapshot.Version)
+	assert.Len(t, snapshot.Placement.Instances, 3)
+	assert.Contains(t, snapshot.Namespaces.Namespaces, "metrics-10s:2d")
+
+	// Unchanged metadata isn't snapshotted again.
+	require.NoError(t, controller.snapshotClusterMetadata(context.Background(), cluster))
+	assert.Equal(t, int64(1), getMetadataSnapshot(t, deps, cluster.Name).Version)
+
+	// Nor is anything written if the namespaces can't be read.
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(nil, assert.AnError)
+	require.Error(t, controller.snapshotClusterMetadata(context.Background(), cluster))
+	assert.Equal(t, int64(1), getMetadataSnapshot(t, deps, cluster.Name).Version)
+
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(pl, nil)
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": {BootstrapEnabled: true},
+			"metrics-1m:40d": {BootstrapEnabled: true},
+		}},
+	}, nil)
+	require.NoError(t, controller.snapshotClusterMetadata(context.Background(), cluster))
+	snapshot = getMetadataSnapshot(t, deps, cluster.Name)
+	assert.Equal(t, int64(2), snapshot.Version)
+	assert.Len(t, snapshot.Namespaces.Namespaces, 2)
+}
+
+func TestRestoreClusterMetadata(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	pl := backupTestPlacement(t, cluster, pods, deps, shard.Available)
+	// Give every instance a different shard, which Init wouldn't reproduce.
+	for i, inst := range pl.Instances() {
+		inst.SetShards(shard.NewShards([]shard.Shard{shard.NewShard(uint32(i)).SetState(shard.Available)}))
+	}
+	plProto, err := pl.Proto()
+	require.NoError(t, err)
+	plProto.NumShards = uint32(cluster.Spec.NumberOfShards)
+	plProto.ReplicaFactor = uint32(cluster.Spec.ReplicationFactor)
+
+	cm := k8sops.GenerateMetadataSnapshotConfigMap(cluster)
+	_, err = k8sops.AddMetadataSnapshot(cm, &k8sops.MetadataSnapshot{
+		Placement: plProto,
+		Namespaces: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": {BootstrapEnabled: true},
+			"metrics-1m:40d": {BootstrapEnabled: true},
+		}},
+	}, 5)
+	require.NoError(t, err)
+	_, err = deps.kubeClient.CoreV1().ConfigMaps("fake").Create(cm)
+	require.NoError(t, err)
+
+	// The cluster is annotated with the snapshot's version first.
+	require.NoError(t, controller.restoreClusterMetadata(context.Background(), cluster))
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters("fake").Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1", cluster.Annotations[annotations.MetadataRestoring])
+
+	gomock.InOrder(
+		deps.placementClient.EXPECT().Get(gomock.Any()).Return(nil, m3admin.ErrNotFound),
+		deps.placementClient.EXPECT().Init(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *admin.PlacementInitRequest) error {
+			assert.Equal(t, cluster.Spec.NumberOfShards, req.NumShards)
+			assert.Equal(t, cluster.Spec.ReplicationFactor, req.ReplicationFactor)
+			assert.Len(t, req.Instances, 3)
+			return nil
+		}),
+		deps.placementClient.EXPECT().Get(gomock.Any()).Return(placement.NewPlacement().SetVersion(1), nil),
+		// The snapshot's shard assignments are set over the initialized ones.
+		deps.placementClient.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, restored placement.Placement) (placement.Placement, error) {
+			assert.Equal(t, 1, restored.Version())
+			require.Equal(t, len(plProto.Instances), restored.NumInstances())
+			for id, inst := range plProto.Instances {
+				restoredInst, ok := restored.Instance(id)
+				require.True(t, ok)
+				require.Len(t, inst.Shards, 1)
+				assert.Equal(t, []uint32{inst.Shards[0].Id}, restoredInst.Shards().AllIDs())
+			}
+			return restored.SetVersion(2), nil
+		}),
+	)
+	// Namespaces from the cluster's spec may already have been recreated.
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": {},
+		}},
+	}, nil)
+	deps.namespaceClient.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *admin.NamespaceAddRequest) error {
+		assert.Equal(t, "metrics-1m:40d", req.Name)
+		assert.True(t, req.Options.BootstrapEnabled)
+		return nil
+	})
+
+	require.NoError(t, controller.restoreClusterMetadata(context.Background(), cluster))
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters("fake").Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, cluster.Annotations, annotations.MetadataRestoring)
+}
+
+func TestRestoreClusterMetadataResumed(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	// A previous attempt initialized the placement but failed to set it.
+	cluster.Annotations = map[string]string{annotations.MetadataRestoring: "1"}
+
+	cm := k8sops.GenerateMetadataSnapshotConfigMap(cluster)
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	plProto, err := backupTestPlacement(t, cluster, pods, deps, shard.Available).Proto()
+	require.NoError(t, err)
+	_, err = k8sops.AddMetadataSnapshot(cm, &k8sops.MetadataSnapshot{
+		Placement:  plProto,
+		Namespaces: &dbns.Registry{},
+	}, 5)
+	require.NoError(t, err)
+	_, err = deps.kubeClient.CoreV1().ConfigMaps("fake").Update(cm)
+	require.NoError(t, err)
+
+	// The placement isn't initialized again, only set.
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(placement.NewPlacement().SetVersion(4), nil)
+	deps.placementClient.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, restored placement.Placement) (placement.Placement, error) {
+		assert.Equal(t, 4, restored.Version())
+		assert.Equal(t, len(pods), restored.NumInstances())
+		return restored.SetVersion(5), nil
+	})
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{Registry: &dbns.Registry{}}, nil)
+
+	require.NoError(t, controller.restoreClusterMetadata(context.Background(), cluster))
+}
+
+func TestHandleClusterUpdateRestoresMetadataFirst(t *testing.T) {
+	cluster, _ := newBackupTestCluster(t)
+	cluster.Annotations = map[string]string{annotations.MetadataRestoring: "1"}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// Without a snapshot the restore fails, and no StatefulSets are created
+	// in the meantime.
+	settled, err := controller.handleClusterUpdate(context.Background(), cluster)
+	assert.Equal(t, errNoMetadataSnapshot, err)
+	assert.False(t, settled)
+
+	sets, err := deps.kubeClient.AppsV1().StatefulSets("fake").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, sets.Items)
+}
+
+func TestRestoreClusterMetadataNoSnapshot(t *testing.T) {
+	cluster, _ := newBackupTestCluster(t)
+
+	for _, cm := range []*corev1.ConfigMap{nil, k8sops.GenerateMetadataSnapshotConfigMap(cluster)} {
+		var kubeObjects []runtime.Object
+		if cm != nil {
+			kubeObjects = append(kubeObjects, cm)
+		}
+		deps := newTestDeps(t, &testOpts{
+			kubeObjects: kubeObjects,
+			crdObjects:  []runtime.Object{cluster},
+		})
+		controller := deps.newController(t)
+
+		assert.Equal(t, errNoMetadataSnapshot, controller.restoreClusterMetadata(context.Background(), cluster))
+		deps.cleanup()
+	}
+}
+
+func TestRestoreClusterMetadataPlacementSetDisabled(t *testing.T) {
+	cluster, _ := newBackupTestCluster(t)
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{k8sops.GenerateMetadataSnapshotConfigMap(cluster)},
+		crdObjects:  []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	controller := deps.newController(t)
+	controller.config.AdminClient.PlacementSet = false
+
+	// Nothing is initialized, as the snapshot's placement couldn't be set over
+	// it.
+	assert.Equal(t, errPlacementSetDisabled, controller.restoreClusterMetadata(context.Background(), cluster))
+}
@@ -27,6 +27,7 @@ import (
 	informers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
 
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -51,6 +52,7 @@ type options struct {
 	kubeInformerFactory        kubeinformers.SharedInformerFactory
 	m3dbClusterInformerFactory informers.SharedInformerFactory
 	kubectlProxy               bool
+	adminClientOpts            []m3admin.Option
 }
 
 type optionFn func(o *options)
@@ -116,6 +118,14 @@ func WithKubectlProxy(use bool) Option {
 	})
 }
 
+// WithAdminClientOptions sets additional options of the clients used to reach
+// each cluster's coordinator admin API.
+func WithAdminClientOptions(opts ...m3admin.Option) Option {
+	return optionFn(func(o *options) {
+		o.adminClientOpts = append(o.adminClientOpts, opts...)
+	})
+}
+
 // WithPodIdentityProvider sets the pod identity provider.
 func WithPodIdentityProvider(p podidentity.Provider) Option {
 	return optionFn(func(o *options) {
@@ -27,6 +27,7 @@ import (
 	m3dbinformers "github.com/m3db/m3db-operator/pkg/client/informers/externalversions"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
 
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
@@ -57,10 +58,12 @@ func TestOptions(t *testing.T) {
 		WithKubeInformerFactory(kubeinformers.NewSharedInformerFactory(kubeClient, 0)),
 		WithM3DBClusterInformerFactory(m3dbinformers.NewSharedInformerFactory(crdClient, 0)),
 		WithConfig(Configuration{}),
+		WithAdminClientOptions(m3admin.WithZone("zone-a")),
 	} {
 		assert.NotNil(t, o)
 		o.execute(opts)
 	}
 
 	assert.NoError(t, opts.validate())
+	assert.Len(t, opts.adminClientOpts, 1)
 }
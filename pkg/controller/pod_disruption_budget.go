@@ -0,0 +1,164 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"reflect"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ensurePodDisruptionBudgets makes sure each of the cluster's StatefulSets has
+// a PodDisruptionBudget allowing at most one of its pods to be disrupted at a
+// time. Since every set holds a single replica of each shard, voluntary
+// disruptions such as drains can then never take down more than one replica
+// of a shard per isolation group.
+func (c *Controller) ensurePodDisruptionBudgets(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet) error {
+	pdbClient := c.kubeClient.PolicyV1beta1().PodDisruptionBudgets(cluster.Namespace)
+	for _, set := range sets {
+		if set.Spec.Selector == nil {
+			// Without a selector there are no pods to budget for.
+			continue
+		}
+
+		want, err := k8sops.GeneratePodDisruptionBudget(cluster, set)
+		if err != nil {
+			return pkgerrors.WithMessagef(err, "error generating pod disruption budget for '%s'", set.Name)
+		}
+
+		existing, err := c.podDisruptionBudgetsForSet(cluster, set)
+		if err != nil {
+			return err
+		}
+
+		var (
+			current *policyv1beta1.PodDisruptionBudget
+			stale   []*policyv1beta1.PodDisruptionBudget
+		)
+		for _, pdb := range existing {
+			if current == nil && reflect.DeepEqual(pdb.Spec, want.Spec) {
+				current = pdb
+				continue
+			}
+			stale = append(stale, pdb)
+		}
+
+		if current == nil {
+			// The spec of a PodDisruptionBudget can't be updated before
+			// Kubernetes 1.15, so it's replaced. The new budget is created
+			// before the old one is deleted so that the set's pods are never
+			// left without one, which needs a name the old one isn't using.
+			if len(stale) > 0 {
+				if want.Name, err = replacementBudgetName(want, stale); err != nil {
+					return err
+				}
+			}
+
+			_, err := pdbClient.Create(want)
+			if kerrors.IsAlreadyExists(err) {
+				// Either a budget the cluster doesn't own, or one our cache
+				// hasn't seen yet.
+				c.logger.Warn("not creating pod disruption budget that already exists",
+					zap.String("cluster", cluster.Name),
+					zap.String("pdb", want.Name))
+				continue
+			}
+			if err != nil {
+				c.recorder.WarningEvent(cluster, eventer.ReasonFailedCreate,
+					"error creating pod disruption budget '%s': %v", want.Name, err)
+				return pkgerrors.WithMessagef(err, "error creating pod disruption budget '%s'", want.Name)
+			}
+
+			c.logger.Info("created pod disruption budget",
+				zap.String("cluster", cluster.Name),
+				zap.String("pdb", want.Name))
+		}
+
+		for _, pdb := range stale {
+			err := pdbClient.Delete(pdb.Name, &metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &pdb.UID},
+			})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return pkgerrors.WithMessagef(err, "error deleting pod disruption budget '%s'", pdb.Name)
+			}
+
+			c.logger.Info("deleted stale pod disruption budget",
+				zap.String("cluster", cluster.Name),
+				zap.String("pdb", pdb.Name))
+		}
+	}
+
+	return nil
+}
+
+// podDisruptionBudgetsForSet returns the cluster's PodDisruptionBudgets for
+// one of its StatefulSets. Budgets not owned by the cluster are left alone.
+func (c *Controller) podDisruptionBudgetsForSet(
+	cluster *myspec.M3DBCluster,
+	set *appsv1.StatefulSet,
+) ([]*policyv1beta1.PodDisruptionBudget, error) {
+	selector := klabels.SelectorFromSet(klabels.Set{labels.StatefulSet: set.Name})
+	pdbs, err := c.pdbLister.PodDisruptionBudgets(cluster.Namespace).List(selector)
+	if err != nil {
+		return nil, pkgerrors.WithMessagef(err, "error listing pod disruption budgets of '%s'", set.Name)
+	}
+
+	owned := pdbs[:0]
+	for _, pdb := range pdbs {
+		if metav1.IsControlledBy(pdb, cluster) {
+			owned = append(owned, pdb)
+		}
+	}
+	return owned, nil
+}
+
+// replacementBudgetName returns the name of a budget replacing the stale ones.
+// It's named after its set if that name is free, and suffixed with a hash of
+// its spec otherwise.
+func replacementBudgetName(
+	want *policyv1beta1.PodDisruptionBudget,
+	stale []*policyv1beta1.PodDisruptionBudget,
+) (string, error) {
+	for _, pdb := range stale {
+		if pdb.Name != want.Name {
+			continue
+		}
+
+		hash, err := k8sops.PodDisruptionBudgetSpecHash(want)
+		if err != nil {
+			return "", pkgerrors.WithMessagef(err, "error hashing pod disruption budget '%s'", want.Name)
+		}
+		return want.Name + "-" + hash, nil
+	}
+	return want.Name, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsurePodDisruptionBudgets(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.UID = "abc"
+
+	sets := make([]*appsv1.StatefulSet, 0, len(cluster.Spec.IsolationGroups))
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, group.NumInstances)
+		require.NoError(t, err)
+		sets = append(sets, set)
+	}
+
+	// A stale budget that should be replaced, and one not owned by the cluster
+	// that should be left alone.
+	stale, err := k8sops.GeneratePodDisruptionBudget(cluster, sets[0])
+	require.NoError(t, err)
+	maxUnavailable := intstr.FromInt(2)
+	stale.Spec.MaxUnavailable = &maxUnavailable
+
+	unowned := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sets[1].Name,
+			Namespace: cluster.Namespace,
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{stale, unowned},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+
+	require.NoError(t, c.ensurePodDisruptionBudgets(cluster, sets))
+
+	// The stale budget was only deleted once its replacement was created, under
+	// a name of its own.
+	var created, deleted bool
+	for _, action := range deps.kubeClient.Actions() {
+		switch action := action.(type) {
+		case ktesting.CreateAction:
+			pdb := action.GetObject().(*policyv1beta1.PodDisruptionBudget)
+			if pdb.Labels[labels.StatefulSet] == sets[0].Name {
+				assert.NotEqual(t, stale.Name, pdb.Name)
+				created = true
+			}
+		case ktesting.DeleteAction:
+			assert.Equal(t, stale.Name, action.GetName())
+			assert.True(t, created, "stale budget deleted before its replacement was created")
+			deleted = true
+		}
+	}
+	assert.True(t, deleted)
+
+	waitForCache(t, func() bool {
+		for _, set := range []*appsv1.StatefulSet{sets[0], sets[2]} {
+			pdbs, err := c.podDisruptionBudgetsForSet(cluster, set)
+			if err != nil || len(pdbs) != 1 {
+				return false
+			}
+		}
+		return true
+	})
+	// Running again is a no-op, but for trying to create the budget that isn't
+	// owned by the cluster.
+	numActions := len(deps.kubeClient.Actions())
+	require.NoError(t, c.ensurePodDisruptionBudgets(cluster, sets))
+	for _, action := range deps.kubeClient.Actions()[numActions:] {
+		assert.NotEqual(t, "delete", action.GetVerb())
+		if action, ok := action.(ktesting.CreateAction); ok {
+			assert.Equal(t, unowned.Name, action.GetObject().(*policyv1beta1.PodDisruptionBudget).Name)
+		}
+	}
+
+	pdbClient := deps.kubeClient.PolicyV1beta1().PodDisruptionBudgets(cluster.Namespace)
+	pdbs, err := pdbClient.List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, pdbs.Items, len(sets))
+
+	// The unowned budget is left alone.
+	pdb, err := pdbClient.Get(unowned.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, pdb.Spec.Selector)
+
+	for i, set := range sets {
+		if i == 1 {
+			continue
+		}
+
+		pdbs, err := c.podDisruptionBudgetsForSet(cluster, set)
+		require.NoError(t, err)
+		require.Len(t, pdbs, 1)
+		assert.Equal(t, set.Spec.Selector, pdbs[0].Spec.Selector)
+		assert.Equal(t, intstr.FromInt(1), *pdbs[0].Spec.MaxUnavailable)
+	}
+}
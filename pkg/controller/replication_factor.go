@@ -0,0 +1,197 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	m3placement "github.com/m3db/m3db-operator/pkg/m3admin/placement"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	corev1 "k8s.io/api/core/v1"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Reasons for the ReplicationFactorChanging condition.
+const (
+	reasonAddingReplica              = "AddingReplica"
+	reasonAddReplicaFailed           = "AddReplicaFailed"
+	reasonNoReplicaIsolationGroup    = "NoIsolationGroupForReplica"
+	reasonPlacementSetDisabled       = "PlacementSetDisabled"
+	reasonReplicationFactorDecreased = "ReplicationFactorDecreased"
+	reasonReplicationFactorChanged   = "ReplicationFactorChanged"
+)
+
+// reconcileReplicationFactor increases the replication factor of the cluster's
+// placement to that of its spec. The new replica of every shard is held by the
+// isolation group added to the spec along with the new replication factor,
+// once the group's StatefulSet is at its desired size. Its instances are added
+// to the placement with every shard they hold initializing, and stream them
+// from the existing replicas. Only one replica is added at a time. It must only
+// be called once every instance in the placement is available. It returns the
+// cluster with any status it updated, and true if it changed anything or is
+// waiting on the new group's pods, in which case the caller should wait for the
+// next event. A replication factor that can't be
+// reached is reported on the cluster's condition, and the rest of the cluster
+// is still reconciled.
+func (c *Controller) reconcileReplicationFactor(ctx context.Context, cluster *myspec.M3DBCluster,
+	isoGroups []myspec.IsolationGroup, pods []*corev1.Pod, pl placement.Placement) (*myspec.M3DBCluster, bool, error) {
+
+	rf, plRF := int(cluster.Spec.ReplicationFactor), pl.ReplicaFactor()
+
+	if rf == plRF {
+		cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionReplicationFactorChanging)
+		if !ok || cond.Status == corev1.ConditionFalse {
+			return cluster, false, nil
+		}
+
+		// Every instance is available, so the new replicas have bootstrapped.
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate, "replication factor changed to %d", rf)
+		updated, err := c.setStatus(cluster, myspec.ClusterConditionReplicationFactorChanging, corev1.ConditionFalse,
+			reasonReplicationFactorChanged, fmt.Sprintf("replication factor is %d", rf))
+		if err != nil {
+			return nil, false, err
+		}
+		return updated, true, nil
+	}
+
+	if rf < plRF {
+		msg := fmt.Sprintf("replication factor can't be decreased from %d to %d", plRF, rf)
+		updated, err := c.failReplicationFactorChange(cluster, reasonReplicationFactorDecreased, msg)
+		return updated, false, err
+	}
+
+	// The new replica goes to the first group without instances in the
+	// placement.
+	var (
+		group myspec.IsolationGroup
+		found bool
+	)
+	for _, g := range isoGroups {
+		if len(instancesInIsoGroup(pl, g.Name)) == 0 {
+			group, found = g, true
+			break
+		}
+	}
+	if !found {
+		msg := fmt.Sprintf("no isolation group without instances in the placement to hold replica %d", plRF+1)
+		updated, err := c.failReplicationFactorChange(cluster, reasonNoReplicaIsolationGroup, msg)
+		return updated, false, err
+	}
+
+	if !c.config.AdminClient.PlacementSet {
+		msg := fmt.Sprintf("replication factor can't be increased from %d to %d: "+
+			"adding a replica requires the placement set API, enable it with -admin-placement-set", plRF, rf)
+		updated, err := c.failReplicationFactorChange(cluster, reasonPlacementSetDisabled, msg)
+		return updated, false, err
+	}
+
+	var groupPods []*corev1.Pod
+	for _, pod := range pods {
+		if pod.Labels[labels.IsolationGroup] == group.Name {
+			groupPods = append(groupPods, pod)
+		}
+	}
+
+	if desired := cluster.Spec.NumInstances(group); int32(len(groupPods)) < desired {
+		c.logger.Info("waiting for isolation group pods before adding replica",
+			zap.String("isolationGroup", group.Name),
+			zap.Int("pods", len(groupPods)),
+			zap.Int32("desired", desired))
+		return cluster, true, nil
+	}
+
+	insts := make([]placement.Instance, 0, len(groupPods))
+	for _, pod := range groupPods {
+		instPb, err := k8sops.PlacementInstanceFromPod(cluster, pod, c.podIDProvider)
+		if err != nil {
+			return nil, false, pkgerrors.WithMessagef(err, "error creating instance for pod '%s'", pod.Name)
+		}
+		inst, err := placement.NewInstanceFromProto(instPb)
+		if err != nil {
+			return nil, false, pkgerrors.WithMessagef(err, "error creating instance for pod '%s'", pod.Name)
+		}
+		insts = append(insts, inst)
+	}
+
+	newPl, err := m3placement.AddReplica(pl, insts...)
+	if err != nil {
+		msg := fmt.Sprintf("error adding replica on isolation group %s: %v", group.Name, err)
+		if _, statusErr := c.failReplicationFactorChange(cluster, reasonAddReplicaFailed, msg); statusErr != nil {
+			return nil, false, statusErr
+		}
+		return nil, false, pkgerrors.WithMessagef(err, "error adding replica on isolation group '%s'", group.Name)
+	}
+
+	c.logger.Info("adding replica to placement",
+		zap.String("isolationGroup", group.Name),
+		zap.Int("replicationFactor", plRF),
+		zap.Int("newReplicationFactor", plRF+1))
+	if _, err := c.adminClient.placementClientForCluster(cluster).Set(ctx, newPl); err != nil {
+		msg := fmt.Sprintf("error setting placement with replica on isolation group %s: %v", group.Name, err)
+		if _, statusErr := c.failReplicationFactorChange(cluster, reasonAddReplicaFailed, msg); statusErr != nil {
+			return nil, false, statusErr
+		}
+		return nil, false, pkgerrors.WithMessage(err, "error setting placement with new replica")
+	}
+
+	c.recorder.NormalEvent(cluster, eventer.ReasonUpdating,
+		"increasing replication factor from %d to %d, adding replica on isolation group %s", plRF, plRF+1, group.Name)
+
+	cluster, err = c.setStatus(cluster, myspec.ClusterConditionReplicationFactorChanging, corev1.ConditionTrue,
+		reasonAddingReplica, fmt.Sprintf("isolation group %s is bootstrapping replica %d of every shard",
+			group.Name, plRF+1))
+	if err != nil {
+		return nil, true, err
+	}
+
+	cluster, err = c.setStatusPodBootstrapping(cluster, corev1.ConditionTrue, "ReplicaAdded",
+		fmt.Sprintf("adding replica on isolation group %s to placement", group.Name))
+	if err != nil {
+		return nil, true, err
+	}
+	return cluster, true, nil
+}
+
+// failReplicationFactorChange reports that the replication factor can't be
+// changed with a warning event and the ReplicationFactorChanging condition. It
+// does nothing if the condition already reports it, as it's called on every
+// sync until the spec is fixed. It returns the cluster with its updated status.
+func (c *Controller) failReplicationFactorChange(cluster *myspec.M3DBCluster,
+	reason, msg string) (*myspec.M3DBCluster, error) {
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionReplicationFactorChanging)
+	if ok && cond.Status == corev1.ConditionTrue && cond.Reason == reason && cond.Message == msg {
+		return cluster, nil
+	}
+
+	c.logger.Warn(msg, zap.String("cluster", cluster.Name))
+	c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, msg)
+	return c.setStatus(cluster, myspec.ClusterConditionReplicationFactorChanging,
+		corev1.ConditionTrue, reason, msg)
+}
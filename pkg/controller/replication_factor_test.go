@@ -0,0 +1,239 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicationFactorFixture returns a cluster whose replication factor was
+// increased from 2 to 3 by adding its last isolation group, a pod for each of
+// its groups, and a placement with 8 shards held by the pods of the first two
+// groups.
+func replicationFactorFixture(t *testing.T, deps *testDeps,
+	cluster *myspec.M3DBCluster) ([]*corev1.Pod, placement.Placement) {
+
+	var pods []*corev1.Pod
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, 1)
+		require.NoError(t, err)
+		pods = append(pods, podsForClusterSet(cluster, set, 1)...)
+	}
+
+	identifyPods(deps.idProvider, pods, nil)
+	pl := placementFromPods(t, cluster, pods[:2], deps.idProvider)
+	shardIDs := make([]uint32, 8)
+	for i := range shardIDs {
+		shardIDs[i] = uint32(i)
+	}
+	for _, inst := range pl.Instances() {
+		for _, id := range shardIDs {
+			inst.Shards().Add(shard.NewShard(id).SetState(shard.Available))
+		}
+	}
+
+	return pods, pl.SetShards(shardIDs).SetReplicaFactor(2)
+}
+
+func newReplicationFactorCluster(t *testing.T) *myspec.M3DBCluster {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	for i := range cluster.Spec.IsolationGroups {
+		cluster.Spec.IsolationGroups[i].NumInstances = 1
+	}
+	return cluster
+}
+
+func replicationFactorCondition(t *testing.T, deps *testDeps, cluster *myspec.M3DBCluster) myspec.ClusterCondition {
+	cluster, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).
+		Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionReplicationFactorChanging)
+	require.True(t, ok)
+	return cond
+}
+
+func TestReconcileReplicationFactorAddsReplica(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	pods, pl := replicationFactorFixture(t, deps, cluster)
+
+	var newPl placement.Placement
+	deps.placementClient.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, pl placement.Placement) (placement.Placement, error) {
+			newPl = pl
+			return pl, nil
+		})
+
+	updated, changed, err := c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, updated.Status.HasPodBootstrapping())
+
+	require.NotNil(t, newPl)
+	assert.Equal(t, 3, newPl.ReplicaFactor())
+	newInsts := instancesInIsoGroup(newPl, "us-fake1-c")
+	require.Len(t, newInsts, 1)
+	assert.Equal(t, 8, newInsts[0].Shards().NumShardsForState(shard.Initializing))
+
+	cond := replicationFactorCondition(t, deps, cluster)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonAddingReplica, cond.Reason)
+}
+
+func TestReconcileReplicationFactorWaitsForPods(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	deps := newTestDeps(t, &testOpts{})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	pods, pl := replicationFactorFixture(t, deps, cluster)
+
+	// The new group's pod hasn't been created yet.
+	_, changed, err := c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods[:2], pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestReconcileReplicationFactorNoIsolationGroup(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	_, pl := replicationFactorFixture(t, deps, cluster)
+
+	// Without the new group, both groups in the spec already hold a replica.
+	groups := cluster.Spec.IsolationGroups[:2]
+	_, changed, err := c.reconcileReplicationFactor(context.Background(), cluster, groups, nil, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	cond := replicationFactorCondition(t, deps, cluster)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonNoReplicaIsolationGroup, cond.Reason)
+}
+
+func TestReconcileReplicationFactorPlacementSetDisabled(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	c.config.AdminClient.PlacementSet = false
+	pods, pl := replicationFactorFixture(t, deps, cluster)
+
+	// The placement isn't set without the placement set API.
+	_, changed, err := c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	cond := replicationFactorCondition(t, deps, cluster)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonPlacementSetDisabled, cond.Reason)
+}
+
+func TestReconcileReplicationFactorDecreased(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	cluster.Spec.ReplicationFactor = 1
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	recorder := &recordingPoster{}
+	c.recorder = recorder
+	pods, pl := replicationFactorFixture(t, deps, cluster)
+
+	// The rest of the cluster is still reconciled.
+	cluster, changed, err := c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	cond := replicationFactorCondition(t, deps, cluster)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonReplicationFactorDecreased, cond.Reason)
+
+	// The returned cluster carries the condition, so later syncs with it don't
+	// report it again or conflict with the status write.
+	returned, ok := cluster.Status.GetCondition(myspec.ClusterConditionReplicationFactorChanging)
+	require.True(t, ok)
+	assert.Equal(t, reasonReplicationFactorDecreased, returned.Reason)
+	_, changed, err = c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Len(t, recorder.warningEvents(), 1)
+}
+
+func TestReconcileReplicationFactorComplete(t *testing.T) {
+	cluster := newReplicationFactorCluster(t)
+	cluster.Spec.ReplicationFactor = 2
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	defer deps.cleanup()
+	c := deps.newController(t)
+	pods, pl := replicationFactorFixture(t, deps, cluster)
+
+	// Nothing to do if the replication factor never changed.
+	_, changed, err := c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionReplicationFactorChanging,
+		Status: corev1.ConditionTrue,
+		Reason: reasonAddingReplica,
+	})
+	_, changed, err = c.reconcileReplicationFactor(context.Background(), cluster,
+		cluster.Spec.IsolationGroups, pods, pl)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	cond := replicationFactorCondition(t, deps, cluster)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, reasonReplicationFactorChanged, cond.Reason)
+}
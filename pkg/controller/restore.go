@@ -0,0 +1,646 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/util/eventer"
+	"github.com/m3db/m3db-operator/pkg/validation"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes/utils/pointer"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// restoreRecheckInterval is how often a restore checks on its cluster's pods
+// while it waits for them to stop or start.
+const restoreRecheckInterval = 10 * time.Second
+
+func (c *Controller) enqueueRestore(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.restoreWorkQueue.AddRateLimited(key)
+	c.scope.Counter("enqueued_restore_event").Inc(int64(1))
+}
+
+func (c *Controller) runRestoreLoop() {
+	for c.processRestoreQueueItem() {
+	}
+}
+
+func (c *Controller) processRestoreQueueItem() bool {
+	obj, shutdown := c.restoreWorkQueue.Get()
+	c.scope.Counter("dequeued_restore_event").Inc(int64(1))
+	if shutdown {
+		return false
+	}
+
+	if c.isStopping() {
+		c.restoreWorkQueue.Done(obj)
+		return false
+	}
+
+	// Closure so we can defer workQueue.Done.
+	err := func(obj interface{}) error {
+		defer c.restoreWorkQueue.Done(obj)
+
+		ctx, cancel := c.workContext()
+		defer cancel()
+
+		key, ok := obj.(string)
+		if !ok {
+			c.restoreWorkQueue.Forget(obj)
+			runtime.HandleError(fmt.Errorf("expected string from queue, got %#v", obj))
+			return nil
+		}
+
+		if err := c.handleRestoreEvent(ctx, key); err != nil {
+			return fmt.Errorf("error syncing restore '%s': %v", key, err)
+		}
+
+		c.restoreWorkQueue.Forget(obj)
+		c.logger.Info("successfully synced restore", zap.String("key", key))
+
+		return nil
+	}(obj)
+
+	if err != nil {
+		runtime.HandleError(err)
+	}
+
+	return true
+}
+
+func (c *Controller) handleRestoreEvent(ctx context.Context, key string) error {
+	kubeNamespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	restore, err := c.restoreLister.M3DBRestores(kubeNamespace).Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// give up processing if this restore doesn't exist
+			runtime.HandleError(fmt.Errorf("restore '%s' no longer exists", key))
+			return nil
+		}
+
+		return err
+	}
+
+	// MUST deep copy to avoid corrupting the cache.
+	return c.handleRestoreUpdate(ctx, restore.DeepCopy())
+}
+
+// handleRestoreUpdate moves a restore through its phases. Once its backup
+// completed, a pending restore creates a cluster from the backed up spec. When
+// the new cluster's placement is available, the cluster's pods are stopped and
+// each node's volume is seeded with the shards its instance was assigned,
+// copied from a backed up node that owned them. Once every volume is seeded the
+// cluster's pods are started again, one isolation group at a time, and
+// bootstrap from the restored data.
+func (c *Controller) handleRestoreUpdate(ctx context.Context, restore *myspec.M3DBRestore) error {
+	if restore.Status.IsFinished() {
+		return nil
+	}
+
+	// The restored cluster carries the restore's labels, so the restore is
+	// handled by the operator that will manage the cluster.
+	if !c.clusterSelector.Matches(klabels.Set(restore.Labels)) {
+		c.logger.Debug("ignoring restore of unmanaged cluster", zap.String("restore", restore.Name))
+		return nil
+	}
+
+	if err := validation.ValidateRestore(restore); err != nil {
+		c.recorder.WarningEvent(restore, eventer.ReasonFailSync, "invalid restore: %v", err)
+		return c.failRestore(restore, fmt.Sprintf("invalid restore: %v", err))
+	}
+
+	// Once the seeding jobs are created the restore no longer needs its backup's
+	// manifest, the cluster must be started even if the backup was deleted.
+	switch restore.Status.Phase {
+	case myspec.RestorePhaseSeeding:
+		return c.reconcileRestoreJobs(restore)
+	case myspec.RestorePhaseStarting:
+		return c.startRestoredCluster(restore)
+	}
+
+	backup, err := c.backupLister.M3DBBackups(restore.Namespace).Get(restore.Spec.BackupName)
+	if kerrors.IsNotFound(err) {
+		return c.failRestore(restore, fmt.Sprintf("backup %s does not exist", restore.Spec.BackupName))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch backup.Status.Phase {
+	case myspec.BackupPhaseCompleted:
+	case myspec.BackupPhaseFailed:
+		return c.failRestore(restore, fmt.Sprintf("backup %s failed", backup.Name))
+	default:
+		return c.setRestorePending(restore, fmt.Sprintf("waiting for backup %s to complete", backup.Name))
+	}
+
+	cm, err := c.kubeClient.CoreV1().ConfigMaps(backup.Namespace).
+		Get(k8sops.BackupManifestConfigMapName(backup.Name), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return c.failRestore(restore, fmt.Sprintf("manifest of backup %s does not exist", backup.Name))
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching backup manifest")
+	}
+
+	manifest, err := k8sops.ParseBackupManifest(cm)
+	if err != nil {
+		return c.failRestore(restore, fmt.Sprintf("invalid manifest of backup %s: %v", backup.Name, err))
+	}
+
+	if restore.Status.Phase == myspec.RestorePhaseProvisioning {
+		return c.seedRestoredCluster(ctx, restore, backup, manifest)
+	}
+	return c.createRestoredCluster(restore, manifest)
+}
+
+// createRestoredCluster creates the cluster a backup is restored to from the
+// backed up cluster's spec.
+func (c *Controller) createRestoredCluster(restore *myspec.M3DBRestore, manifest *k8sops.BackupManifest) error {
+	name := restore.Spec.ClusterName
+	if name == "" {
+		name = manifest.Cluster
+	}
+
+	// Read the cluster from the API rather than the cache, a stale cache must
+	// not lead us to restore into an existing cluster.
+	existing, err := c.crdClient.OperatorV1alpha1().M3DBClusters(restore.Namespace).Get(name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		// We may have created the cluster but failed to update our status.
+		if existing.Annotations[annotations.RestoredFrom] != restore.Name {
+			return c.failRestore(restore, fmt.Sprintf("cluster %s already exists", name))
+		}
+	case kerrors.IsNotFound(err):
+		clusterLabels := make(map[string]string, len(restore.Labels))
+		for k, v := range restore.Labels {
+			clusterLabels[k] = v
+		}
+
+		cluster := &myspec.M3DBCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: restore.Namespace,
+				Labels:    clusterLabels,
+				Annotations: map[string]string{
+					annotations.RestoredFrom: restore.Name,
+				},
+			},
+			Spec: *manifest.Spec.DeepCopy(),
+		}
+		cluster.Spec.PodIdentityConfig = restoredPodIdentityConfig(cluster.Spec.PodIdentityConfig)
+		if err := validation.ValidateCluster(cluster); err != nil {
+			return c.failRestore(restore, fmt.Sprintf("invalid restored cluster: %v", err))
+		}
+
+		if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(restore.Namespace).Create(cluster); err != nil {
+			return pkgerrors.WithMessagef(err, "error creating cluster '%s'", name)
+		}
+
+		c.logger.Info("created restored cluster", zap.String("restore", restore.Name), zap.String("cluster", name))
+		c.recorder.NormalEvent(restore, eventer.ReasonCreating, "created cluster %s", name)
+	default:
+		return pkgerrors.WithMessagef(err, "error fetching cluster '%s'", name)
+	}
+
+	status := restore.Status.DeepCopy()
+	status.Phase = myspec.RestorePhaseProvisioning
+	status.Message = fmt.Sprintf("waiting for the placement of cluster %s to be available", name)
+	status.ClusterName = name
+	status.StartTime = c.clock.Now().UTC().Format(time.RFC3339)
+	return c.setRestoreStatus(restore, status)
+}
+
+// seedRestoredCluster creates the jobs seeding the restored cluster's volumes
+// once every one of its instances is available. The jobs are paused until the
+// cluster's pods are stopped.
+func (c *Controller) seedRestoredCluster(
+	ctx context.Context,
+	restore *myspec.M3DBRestore,
+	backup *myspec.M3DBBackup,
+	manifest *k8sops.BackupManifest,
+) error {
+	cluster, err := c.restoredCluster(restore)
+	if cluster == nil || err != nil {
+		return err
+	}
+
+	// The restore is enqueued again whenever the cluster is updated, which it
+	// is as its pods become ready.
+	if !cluster.Status.HasInitializedPlacement() {
+		return nil
+	}
+
+	pl, err := c.adminClient.placementClientForCluster(cluster).Get(ctx)
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error fetching placement")
+	}
+
+	var desired int
+	for _, group := range cluster.Spec.IsolationGroups {
+		desired += int(cluster.Spec.NumInstances(group))
+	}
+	if pl.NumInstances() < desired {
+		return nil
+	}
+	for _, inst := range pl.Instances() {
+		if !inst.IsAvailable() {
+			return nil
+		}
+	}
+
+	resp, err := c.adminClient.namespaceClientForCluster(cluster).List(ctx)
+	if err == nil && resp.Registry == nil {
+		err = errNilNamespaceRegistry
+	}
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing namespaces")
+	}
+
+	// Only the data of namespaces the restored cluster defines is restored,
+	// namespaces that were created through the coordinator API or by
+	// M3DBNamespaces must be recreated first.
+	var namespaces, skipped []string
+	for _, ns := range manifest.Namespaces {
+		if _, ok := resp.Registry.Namespaces[ns]; ok {
+			namespaces = append(namespaces, ns)
+		} else {
+			skipped = append(skipped, ns)
+		}
+	}
+	if len(namespaces) == 0 {
+		return c.failRestore(restore, fmt.Sprintf("none of the backed up namespaces exist in cluster %s", cluster.Name))
+	}
+
+	pods, err := c.podLister.Pods(cluster.Namespace).List(m3dbNodeSelector(cluster))
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing pods")
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	var (
+		jobs    []*batchv1.Job
+		missing []uint32
+	)
+	for i, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			return nil
+		}
+
+		inst, err := c.findPodInPlacement(cluster, pl, pod)
+		if pkgerrors.Cause(err) == errPodNotInPlacement {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sources, podMissing := k8sops.RestoreShardSources(manifest, inst.IsolationGroup(), inst.Shards().AllIDs())
+		missing = append(missing, podMissing...)
+		if len(sources) == 0 {
+			continue
+		}
+
+		// Commit logs are only dropped if every shard the node owns is seeded,
+		// otherwise the unflushed writes of the other shards would be lost.
+		fullNode := len(podMissing) == 0
+		job, err := k8sops.GenerateRestoreJob(restore, backup, pod, i, namespaces, sources, fullNode)
+		if err != nil {
+			return pkgerrors.WithMessagef(err, "error generating restore job for pod '%s'", pod.Name)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := c.createJobs(jobs); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("seeding %d nodes", len(jobs))
+	if len(skipped) > 0 {
+		msg += fmt.Sprintf("; skipped namespaces not in cluster: %s", strings.Join(skipped, ", "))
+	}
+	if len(missing) > 0 {
+		msg += fmt.Sprintf("; shards missing from backup: %v", uniqueShards(missing))
+	}
+
+	c.logger.Info("seeding restored cluster", zap.String("restore", restore.Name),
+		zap.String("cluster", cluster.Name), zap.Int("nodes", len(jobs)))
+	c.recorder.NormalEvent(restore, eventer.ReasonUpdating, msg)
+
+	status := restore.Status.DeepCopy()
+	status.Phase = myspec.RestorePhaseSeeding
+	status.Message = msg
+	status.TotalNodes = int32(len(jobs))
+	status.SeededNodes = 0
+	return c.setRestoreStatus(restore, status)
+}
+
+// reconcileRestoreJobs stops the restored cluster's pods, then starts the
+// jobs seeding their volumes and updates the restore from the state of the
+// jobs. Nodes must not run while their volumes are seeded: they would keep
+// writing to the shards being restored.
+func (c *Controller) reconcileRestoreJobs(restore *myspec.M3DBRestore) error {
+	cluster, err := c.restoredCluster(restore)
+	if cluster == nil || err != nil {
+		return err
+	}
+
+	status := restore.Status.DeepCopy()
+	stopped, err := c.stopRestoredCluster(restore, cluster)
+	if err != nil {
+		return err
+	}
+	if !stopped {
+		c.recheckRestore(restore)
+		status.Message = fmt.Sprintf("waiting for the pods of cluster %s to stop", cluster.Name)
+		return c.setRestoreStatus(restore, status)
+	}
+
+	jobs, err := c.jobLister.Jobs(restore.Namespace).List(klabels.SelectorFromSet(klabels.Set{
+		labels.Component: labels.ComponentRestore,
+		labels.Restore:   restore.Name,
+	}))
+	if err != nil {
+		return pkgerrors.WithMessage(err, "error listing restore jobs")
+	}
+
+	var seeded int32
+	for _, job := range jobs {
+		if job.Spec.Parallelism != nil && *job.Spec.Parallelism == 0 {
+			job = job.DeepCopy()
+			job.Spec.Parallelism = pointer.Int32Ptr(1)
+			if _, err := c.kubeClient.BatchV1().Jobs(job.Namespace).Update(job); err != nil {
+				return pkgerrors.WithMessagef(err, "error starting job '%s'", job.Name)
+			}
+			continue
+		}
+
+		succeeded, failed := jobFinished(job)
+		if failed {
+			// The cluster is left stopped, its volumes are only partially seeded.
+			c.recorder.WarningEvent(restore, eventer.ReasonFailSync, "job %s failed", job.Name)
+			return c.failRestore(restore, fmt.Sprintf("job %s failed, cluster %s is left stopped", job.Name, cluster.Name))
+		}
+		if succeeded {
+			seeded++
+		}
+	}
+
+	status.SeededNodes = seeded
+	status.Message = fmt.Sprintf("seeding %d nodes", status.TotalNodes)
+	if seeded < status.TotalNodes {
+		return c.setRestoreStatus(restore, status)
+	}
+
+	c.logger.Info("seeded restored cluster", zap.String("restore", restore.Name), zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(restore, eventer.ReasonUpdating, "seeded %d nodes", seeded)
+
+	status.Phase = myspec.RestorePhaseStarting
+	status.Message = fmt.Sprintf("seeded %d nodes, starting the pods of cluster %s", seeded, cluster.Name)
+	return c.setRestoreStatus(restore, status)
+}
+
+// stopRestoredCluster scales the restored cluster's StatefulSets down to 0
+// replicas, and returns true once none of its pods are left. The cluster is
+// annotated first so that it isn't scaled back up: its sets are only scaled
+// once the annotation is cached, since the cluster may be reconciled from the
+// cache in the meantime.
+func (c *Controller) stopRestoredCluster(restore *myspec.M3DBRestore, cluster *myspec.M3DBCluster) (bool, error) {
+	if cluster.Annotations[annotations.RestoreSeeding] != restore.Name {
+		cluster = cluster.DeepCopy()
+		if cluster.Annotations == nil {
+			cluster.Annotations = make(map[string]string)
+		}
+		cluster.Annotations[annotations.RestoreSeeding] = restore.Name
+		if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster); err != nil {
+			return false, pkgerrors.WithMessagef(err, "error pausing cluster '%s'", cluster.Name)
+		}
+		c.logger.Info("stopping restored cluster", zap.String("restore", restore.Name), zap.String("cluster", cluster.Name))
+		return false, nil
+	}
+
+	sets, err := c.getChildStatefulSets(cluster)
+	if err != nil {
+		return false, err
+	}
+
+	stopped := true
+	for _, set := range sets {
+		if set.Spec.Replicas == nil || *set.Spec.Replicas != 0 {
+			set.Spec.Replicas = pointer.Int32Ptr(0)
+			if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+				return false, pkgerrors.WithMessagef(err, "error stopping statefulset '%s'", set.Name)
+			}
+			stopped = false
+			continue
+		}
+		if set.Status.Replicas != 0 {
+			stopped = false
+		}
+	}
+
+	pods, err := c.podLister.Pods(cluster.Namespace).List(m3dbNodeSelector(cluster))
+	if err != nil {
+		return false, pkgerrors.WithMessage(err, "error listing pods")
+	}
+	return stopped && len(pods) == 0, nil
+}
+
+// startRestoredCluster scales the restored cluster's StatefulSets back up, one
+// isolation group at a time. Once every group's pods are ready the restore
+// completes and the cluster is reconciled again.
+func (c *Controller) startRestoredCluster(restore *myspec.M3DBRestore) error {
+	cluster, err := c.restoredCluster(restore)
+	if cluster == nil || err != nil {
+		return err
+	}
+
+	sets, err := c.getChildStatefulSets(cluster)
+	if err != nil {
+		return err
+	}
+	setsByGroup := make(map[string]*appsv1.StatefulSet, len(sets))
+	for _, set := range sets {
+		setsByGroup[set.Labels[labels.IsolationGroup]] = set
+	}
+
+	isoGroups := make([]myspec.IsolationGroup, len(cluster.Spec.IsolationGroups))
+	copy(isoGroups, cluster.Spec.IsolationGroups)
+	sort.Sort(myspec.IsolationGroups(isoGroups))
+
+	status := restore.Status.DeepCopy()
+	for _, group := range isoGroups {
+		set, ok := setsByGroup[group.Name]
+		if !ok {
+			return fmt.Errorf("no statefulset for isolation group %s", group.Name)
+		}
+
+		desired := cluster.Spec.NumInstances(group)
+		if set.Spec.Replicas == nil || *set.Spec.Replicas != desired {
+			set.Spec.Replicas = pointer.Int32Ptr(desired)
+			if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+				return pkgerrors.WithMessagef(err, "error starting statefulset '%s'", set.Name)
+			}
+			c.logger.Info("starting restored isolation group", zap.String("restore", restore.Name),
+				zap.String("cluster", cluster.Name), zap.String("isolationGroup", group.Name))
+		}
+
+		if set.Status.ReadyReplicas != desired {
+			c.recheckRestore(restore)
+			status.Message = fmt.Sprintf("starting the pods of isolation group %s", group.Name)
+			return c.setRestoreStatus(restore, status)
+		}
+	}
+
+	cluster = cluster.DeepCopy()
+	delete(cluster.Annotations, annotations.RestoreSeeding)
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster); err != nil {
+		return pkgerrors.WithMessagef(err, "error resuming cluster '%s'", cluster.Name)
+	}
+
+	c.logger.Info("restore completed", zap.String("restore", restore.Name), zap.String("cluster", cluster.Name))
+	c.recorder.NormalEvent(restore, eventer.ReasonSuccessSync, "restore completed")
+
+	status.Phase = myspec.RestorePhaseCompleted
+	status.Message = fmt.Sprintf("seeded %d nodes and started the pods of cluster %s", status.SeededNodes, cluster.Name)
+	status.CompletionTime = c.clock.Now().UTC().Format(time.RFC3339)
+	return c.setRestoreStatus(restore, status)
+}
+
+// recheckRestore enqueues the restore again after restoreRecheckInterval.
+// Pods stopping and starting don't always change the cluster's status, so the
+// restore isn't guaranteed to be enqueued by an update of its cluster.
+func (c *Controller) recheckRestore(restore *myspec.M3DBRestore) {
+	key, err := cache.MetaNamespaceKeyFunc(restore)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.restoreWorkQueue.AddAfter(key, restoreRecheckInterval)
+}
+
+// restoredPodIdentityConfig returns the pod identity config of a restored
+// cluster. Its pods are recreated once their volumes are seeded and must keep
+// their identity, so they can't be identified by UID. Unset configs identify
+// pods by UID.
+func restoredPodIdentityConfig(config *myspec.PodIdentityConfig) *myspec.PodIdentityConfig {
+	restored := &myspec.PodIdentityConfig{}
+	if config == nil {
+		return restored
+	}
+	for _, source := range config.Sources {
+		if source != myspec.PodIdentitySourcePodUID {
+			restored.Sources = append(restored.Sources, source)
+		}
+	}
+	return restored
+}
+
+// restoredCluster returns the cluster a restore created. It returns a nil
+// cluster if the cluster isn't cached yet, and fails the restore if it was
+// deleted.
+func (c *Controller) restoredCluster(restore *myspec.M3DBRestore) (*myspec.M3DBCluster, error) {
+	name := restore.Status.ClusterName
+	cluster, err := c.clusterLister.M3DBClusters(restore.Namespace).Get(name)
+	if err == nil {
+		return cluster, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	_, err = c.crdClient.OperatorV1alpha1().M3DBClusters(restore.Namespace).Get(name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, c.failRestore(restore, fmt.Sprintf("cluster %s was deleted", name))
+	}
+	return nil, err
+}
+
+func uniqueShards(shards []uint32) []uint32 {
+	seen := make(map[uint32]struct{}, len(shards))
+	unique := make([]uint32, 0, len(shards))
+	for _, s := range shards {
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			unique = append(unique, s)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
+
+func (c *Controller) setRestorePending(restore *myspec.M3DBRestore, msg string) error {
+	status := restore.Status.DeepCopy()
+	status.Phase = myspec.RestorePhasePending
+	status.Message = msg
+	return c.setRestoreStatus(restore, status)
+}
+
+func (c *Controller) failRestore(restore *myspec.M3DBRestore, msg string) error {
+	c.logger.Warn("restore failed", zap.String("restore", restore.Name), zap.String("reason", msg))
+	status := restore.Status.DeepCopy()
+	status.Phase = myspec.RestorePhaseFailed
+	status.Message = msg
+	status.CompletionTime = c.clock.Now().UTC().Format(time.RFC3339)
+	return c.setRestoreStatus(restore, status)
+}
+
+// setRestoreStatus writes the restore's status if it changed.
+func (c *Controller) setRestoreStatus(restore *myspec.M3DBRestore, status *myspec.M3DBRestoreStatus) error {
+	if reflect.DeepEqual(status, &restore.Status) {
+		return nil
+	}
+
+	restore.Status = *status
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBRestores(restore.Namespace).UpdateStatus(restore); err != nil {
+		return pkgerrors.WithMessage(err, "error updating restore status")
+	}
+	return nil
+}
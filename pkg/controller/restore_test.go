@@ -0,0 +1,492 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newM3DBRestore(name, backupName string) *myspec.M3DBRestore {
+	return &myspec.M3DBRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fake",
+			UID:       "restore-uid",
+		},
+		Spec: myspec.M3DBRestoreSpec{
+			BackupName:  backupName,
+			ClusterName: "restored",
+		},
+	}
+}
+
+func getM3DBRestore(t *testing.T, deps *testDeps, name string) *myspec.M3DBRestore {
+	restore, err := deps.crdClient.OperatorV1alpha1().M3DBRestores("fake").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return restore
+}
+
+// newCompletedBackup returns a completed backup of the cluster and the config
+// map holding its manifest, in which each pod owned every shard.
+func newCompletedBackup(t *testing.T, cluster *myspec.M3DBCluster, pods []*corev1.Pod) (*myspec.M3DBBackup, *corev1.ConfigMap) {
+	backup := newM3DBBackup("backup-a", cluster.Name)
+	backup.Status.Phase = myspec.BackupPhaseCompleted
+
+	manifest := &k8sops.BackupManifest{
+		Cluster:    cluster.Name,
+		Namespace:  cluster.Namespace,
+		Spec:       cluster.Spec,
+		Namespaces: []string{"metrics-10s:2d", "other"},
+	}
+	for _, pod := range pods {
+		shards := make([]uint32, cluster.Spec.NumberOfShards)
+		for i := range shards {
+			shards[i] = uint32(i)
+		}
+		manifest.Instances = append(manifest.Instances, k8sops.BackupInstance{
+			PodName:        pod.Name,
+			IsolationGroup: pod.Labels[labels.IsolationGroup],
+			Shards:         shards,
+		})
+	}
+
+	cm, err := k8sops.GenerateBackupManifestConfigMap(backup, manifest)
+	require.NoError(t, err)
+	return backup, cm
+}
+
+func TestHandleRestoreUpdateCreatesCluster(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	backup, cm := newCompletedBackup(t, cluster, pods)
+	restore := newM3DBRestore("restore-a", backup.Name)
+	restore.Labels = map[string]string{"team": "a"}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: []runtime.Object{cm},
+		crdObjects:  []runtime.Object{backup, restore},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+
+	restored, err := deps.crdClient.OperatorV1alpha1().M3DBClusters("fake").Get("restored", metav1.GetOptions{})
+	require.NoError(t, err)
+	// Pods identified by UID couldn't be stopped while their volumes are seeded.
+	assert.Equal(t, &myspec.PodIdentityConfig{}, restored.Spec.PodIdentityConfig)
+	restored.Spec.PodIdentityConfig = cluster.Spec.PodIdentityConfig
+	assert.Equal(t, cluster.Spec, restored.Spec)
+	assert.Equal(t, "restore-a", restored.Annotations[annotations.RestoredFrom])
+	assert.Equal(t, "a", restored.Labels["team"])
+
+	restore = getM3DBRestore(t, deps, "restore-a")
+	assert.Equal(t, myspec.RestorePhaseProvisioning, restore.Status.Phase)
+	assert.Equal(t, "restored", restore.Status.ClusterName)
+}
+
+func TestHandleRestoreUpdateWaitsAndFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(backup *myspec.M3DBBackup, restore *myspec.M3DBRestore)
+		existing bool
+		expPhase myspec.RestorePhase
+		expMsg   string
+	}{
+		{
+			name: "backup running",
+			modify: func(backup *myspec.M3DBBackup, restore *myspec.M3DBRestore) {
+				backup.Status.Phase = myspec.BackupPhaseRunning
+			},
+			expPhase: myspec.RestorePhasePending,
+			expMsg:   "waiting for backup backup-a",
+		},
+		{
+			name: "backup failed",
+			modify: func(backup *myspec.M3DBBackup, restore *myspec.M3DBRestore) {
+				backup.Status.Phase = myspec.BackupPhaseFailed
+			},
+			expPhase: myspec.RestorePhaseFailed,
+			expMsg:   "backup backup-a failed",
+		},
+		{
+			name: "no backup",
+			modify: func(backup *myspec.M3DBBackup, restore *myspec.M3DBRestore) {
+				restore.Spec.BackupName = "other"
+			},
+			expPhase: myspec.RestorePhaseFailed,
+			expMsg:   "backup other does not exist",
+		},
+		{
+			name: "cluster exists",
+			modify: func(backup *myspec.M3DBBackup, restore *myspec.M3DBRestore) {
+				restore.Spec.ClusterName = ""
+			},
+			existing: true,
+			expPhase: myspec.RestorePhaseFailed,
+			expMsg:   "already exists",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster, pods := newBackupTestCluster(t)
+			backup, cm := newCompletedBackup(t, cluster, pods)
+			restore := newM3DBRestore("restore-a", backup.Name)
+			test.modify(backup, restore)
+
+			crdObjects := []runtime.Object{backup, restore}
+			if test.existing {
+				crdObjects = append(crdObjects, cluster)
+			}
+			deps := newTestDeps(t, &testOpts{
+				kubeObjects: []runtime.Object{cm},
+				crdObjects:  crdObjects,
+			})
+			controller := deps.newController(t)
+			defer deps.cleanup()
+
+			require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+
+			restore = getM3DBRestore(t, deps, "restore-a")
+			assert.Equal(t, test.expPhase, restore.Status.Phase)
+			assert.Contains(t, restore.Status.Message, test.expMsg)
+		})
+	}
+}
+
+func TestHandleRestoreUpdateSeeds(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	backup, cm := newCompletedBackup(t, cluster, pods)
+	restore := newM3DBRestore("restore-a", backup.Name)
+	restore.Status.Phase = myspec.RestorePhaseProvisioning
+	restore.Status.ClusterName = cluster.Name
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: append(objectsFromPods(pods...), cm),
+		crdObjects:  []runtime.Object{cluster, backup, restore},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(backupTestPlacement(t, cluster, pods, deps, shard.Available), nil)
+	deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{
+		Registry: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics-10s:2d": {},
+		}},
+	}, nil)
+
+	require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+
+	restore = getM3DBRestore(t, deps, "restore-a")
+	assert.Equal(t, myspec.RestorePhaseSeeding, restore.Status.Phase)
+	assert.Equal(t, int32(3), restore.Status.TotalNodes)
+	assert.Contains(t, restore.Status.Message, "skipped namespaces not in cluster: other")
+
+	jobs, err := deps.kubeClient.BatchV1().Jobs("fake").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Items, 3)
+	for _, job := range jobs.Items {
+		assert.Equal(t, restore.Name, job.Labels[labels.Restore])
+		// Jobs wait for the cluster's pods to stop.
+		assert.Equal(t, int32(0), *job.Spec.Parallelism)
+		// Every shard of each node is in the backup, so commit logs are
+		// dropped.
+		assert.Contains(t, job.Spec.Template.Spec.Containers[0].Env,
+			corev1.EnvVar{Name: "RESTORE_FULL_NODE", Value: "true"})
+	}
+}
+
+func TestHandleRestoreUpdateWaitsForPlacement(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	backup, cm := newCompletedBackup(t, cluster, pods)
+	restore := newM3DBRestore("restore-a", backup.Name)
+	restore.Status.Phase = myspec.RestorePhaseProvisioning
+	restore.Status.ClusterName = cluster.Name
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: append(objectsFromPods(pods...), cm),
+		crdObjects:  []runtime.Object{cluster, backup, restore},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	identifyPods(deps.idProvider, pods, nil)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(backupTestPlacement(t, cluster, pods[:2], deps, shard.Available), nil)
+
+	require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+	assert.Equal(t, myspec.RestorePhaseProvisioning, getM3DBRestore(t, deps, "restore-a").Status.Phase)
+}
+
+// waitForCache waits for the informer caches to satisfy the condition.
+func waitForCache(t *testing.T, cond func() bool) {
+	for start := time.Now(); !cond(); time.Sleep(10 * time.Millisecond) {
+		require.True(t, time.Since(start) < 5*time.Second, "timeout waiting for informer cache")
+	}
+}
+
+// newSeedingRestore returns a restore of the cluster in the given phase, and
+// the cluster's StatefulSets with the given number of replicas.
+func newSeedingRestore(t *testing.T, cluster *myspec.M3DBCluster, phase myspec.RestorePhase,
+	replicas int32) (*myspec.M3DBRestore, []runtime.Object) {
+
+	restore := newM3DBRestore("restore-a", "backup-a")
+	restore.Status.Phase = phase
+	restore.Status.ClusterName = cluster.Name
+
+	var objects []runtime.Object
+	for _, set := range readySetsForCluster(t, cluster) {
+		set.Spec.Replicas = &replicas
+		set.Status.Replicas = replicas
+		set.Status.ReadyReplicas = replicas
+		objects = append(objects, set)
+	}
+	return restore, objects
+}
+
+func getStatefulSetReplicas(t *testing.T, deps *testDeps, cluster *myspec.M3DBCluster) []int32 {
+	var replicas []int32
+	for i := range cluster.Spec.IsolationGroups {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).
+			Get(k8sops.StatefulSetName(cluster.Name, i), metav1.GetOptions{})
+		require.NoError(t, err)
+		replicas = append(replicas, *set.Spec.Replicas)
+	}
+	return replicas
+}
+
+func TestReconcileRestoreJobsStopsCluster(t *testing.T) {
+	cluster, pods := newBackupTestCluster(t)
+	restore, sets := newSeedingRestore(t, cluster, myspec.RestorePhaseSeeding, 1)
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: append(objectsFromPods(pods...), sets...),
+		crdObjects:  []runtime.Object{cluster, restore},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// The cluster is paused before its sets are scaled down.
+	require.NoError(t, controller.reconcileRestoreJobs(restore.DeepCopy()))
+	paused, err := deps.crdClient.OperatorV1alpha1().M3DBClusters("fake").Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "restore-a", paused.Annotations[annotations.RestoreSeeding])
+	assert.Equal(t, []int32{1, 1, 1}, getStatefulSetReplicas(t, deps, cluster))
+
+	restore = getM3DBRestore(t, deps, "restore-a")
+	assert.Equal(t, myspec.RestorePhaseSeeding, restore.Status.Phase)
+	assert.Contains(t, restore.Status.Message, "waiting for the pods of cluster cluster-simple to stop")
+
+	// Once the paused cluster is cached its sets are scaled down.
+	waitForCache(t, func() bool {
+		cached, err := deps.crdLister.M3DBClusters("fake").Get(cluster.Name)
+		return err == nil && cached.Annotations[annotations.RestoreSeeding] == "restore-a"
+	})
+	require.NoError(t, controller.reconcileRestoreJobs(restore.DeepCopy()))
+	assert.Equal(t, []int32{0, 0, 0}, getStatefulSetReplicas(t, deps, cluster))
+	assert.Equal(t, myspec.RestorePhaseSeeding, getM3DBRestore(t, deps, "restore-a").Status.Phase)
+}
+
+func TestReconcileRestoreJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobStates []batchv1.JobConditionType
+		paused    bool
+		expPhase  myspec.RestorePhase
+		expSeeded int32
+	}{
+		{
+			name:      "paused jobs",
+			jobStates: []batchv1.JobConditionType{"", ""},
+			paused:    true,
+			expPhase:  myspec.RestorePhaseSeeding,
+		},
+		{
+			name:      "seeding",
+			jobStates: []batchv1.JobConditionType{batchv1.JobComplete, ""},
+			expPhase:  myspec.RestorePhaseSeeding,
+			expSeeded: 1,
+		},
+		{
+			name:      "seeded",
+			jobStates: []batchv1.JobConditionType{batchv1.JobComplete, batchv1.JobComplete},
+			expPhase:  myspec.RestorePhaseStarting,
+			expSeeded: 2,
+		},
+		{
+			name:      "failed",
+			jobStates: []batchv1.JobConditionType{batchv1.JobFailed, batchv1.JobComplete},
+			expPhase:  myspec.RestorePhaseFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster, _ := newBackupTestCluster(t)
+			cluster.Annotations = map[string]string{annotations.RestoreSeeding: "restore-a"}
+			restore, kubeObjects := newSeedingRestore(t, cluster, myspec.RestorePhaseSeeding, 0)
+			restore.Status.TotalNodes = int32(len(test.jobStates))
+
+			owner := *k8sops.GenerateRestoreOwnerRef(restore)
+			jobLabels := map[string]string{
+				labels.Component: labels.ComponentRestore,
+				labels.Restore:   restore.Name,
+			}
+			parallelism := int32(1)
+			if test.paused {
+				parallelism = 0
+			}
+			for i, state := range test.jobStates {
+				job := newFinishedJob(k8sops.RestoreJobName(restore.Name, i), owner, jobLabels, state)
+				job.Spec.Parallelism = &parallelism
+				kubeObjects = append(kubeObjects, job)
+			}
+
+			deps := newTestDeps(t, &testOpts{
+				kubeObjects: kubeObjects,
+				crdObjects:  []runtime.Object{cluster, restore},
+			})
+			controller := deps.newController(t)
+			defer deps.cleanup()
+
+			require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+
+			restore = getM3DBRestore(t, deps, "restore-a")
+			assert.Equal(t, test.expPhase, restore.Status.Phase)
+			if test.expPhase != myspec.RestorePhaseFailed {
+				assert.Equal(t, test.expSeeded, restore.Status.SeededNodes)
+			}
+
+			jobs, err := deps.kubeClient.BatchV1().Jobs("fake").List(metav1.ListOptions{})
+			require.NoError(t, err)
+			for _, job := range jobs.Items {
+				assert.Equal(t, int32(1), *job.Spec.Parallelism)
+			}
+
+			// The sets stay stopped until every volume is seeded.
+			assert.Equal(t, []int32{0, 0, 0}, getStatefulSetReplicas(t, deps, cluster))
+		})
+	}
+}
+
+func TestStartRestoredCluster(t *testing.T) {
+	cluster, _ := newBackupTestCluster(t)
+	cluster.Annotations = map[string]string{annotations.RestoreSeeding: "restore-a"}
+	restore, sets := newSeedingRestore(t, cluster, myspec.RestorePhaseStarting, 0)
+	restore.Status.SeededNodes = 3
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: sets,
+		crdObjects:  []runtime.Object{cluster, restore},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// Isolation groups are started one at a time.
+	require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+	assert.Equal(t, []int32{1, 0, 0}, getStatefulSetReplicas(t, deps, cluster))
+	restore = getM3DBRestore(t, deps, "restore-a")
+	assert.Equal(t, myspec.RestorePhaseStarting, restore.Status.Phase)
+	assert.Contains(t, restore.Status.Message, "isolation group group1")
+
+	// Once every group is ready the cluster is resumed.
+	for _, obj := range sets {
+		set := obj.(*appsv1.StatefulSet)
+		set.Spec.Replicas = pointer.Int32Ptr(1)
+		set.Status.Replicas = 1
+		set.Status.ReadyReplicas = 1
+		_, err := deps.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set)
+		require.NoError(t, err)
+	}
+	waitForCache(t, func() bool {
+		sets, err := deps.statefulSetLister.List(klabels.Everything())
+		if err != nil {
+			return false
+		}
+		for _, set := range sets {
+			if set.Status.ReadyReplicas != 1 {
+				return false
+			}
+		}
+		return true
+	})
+	require.NoError(t, controller.handleRestoreUpdate(context.Background(), restore.DeepCopy()))
+
+	restore = getM3DBRestore(t, deps, "restore-a")
+	assert.Equal(t, myspec.RestorePhaseCompleted, restore.Status.Phase)
+	resumed, err := deps.crdClient.OperatorV1alpha1().M3DBClusters("fake").Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, resumed.Annotations, annotations.RestoreSeeding)
+}
+
+func TestRestoredPodIdentityConfig(t *testing.T) {
+	assert.Equal(t, &myspec.PodIdentityConfig{}, restoredPodIdentityConfig(nil))
+	assert.Equal(t, &myspec.PodIdentityConfig{
+		Sources: []myspec.PodIdentitySource{myspec.PodIdentitySourceNodeName},
+	}, restoredPodIdentityConfig(&myspec.PodIdentityConfig{
+		Sources: []myspec.PodIdentitySource{myspec.PodIdentitySourcePodUID, myspec.PodIdentitySourceNodeName},
+	}))
+}
+
+func TestHandleClusterUpdateWhileRestoreSeeding(t *testing.T) {
+	cluster, _ := newBackupTestCluster(t)
+	cluster.Annotations = map[string]string{annotations.RestoreSeeding: "restore-a"}
+	_, sets := newSeedingRestore(t, cluster, myspec.RestorePhaseSeeding, 0)
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: sets,
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// The stopped sets are left to the restore.
+	settled, err := controller.handleClusterUpdate(context.Background(), cluster)
+	require.NoError(t, err)
+	assert.False(t, settled)
+	assert.Equal(t, []int32{0, 0, 0}, getStatefulSetReplicas(t, deps, cluster))
+	for _, action := range deps.kubeClient.Actions() {
+		assert.False(t, action.Matches("update", "statefulsets"))
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Reasons for the Ready, Progressing and Degraded conditions.
+const (
+	reasonClusterReady          = "ClusterReady"
+	reasonPlacementNotCreated   = "PlacementNotInitialized"
+	reasonPlacementUnavailable  = "PlacementUnavailable"
+	reasonShardsUnavailable     = "ShardsUnavailable"
+	reasonReconciled            = "Reconciled"
+	reasonStatefulSetsMissing   = "StatefulSetsMissing"
+	reasonStatefulSetsUpdating  = "StatefulSetsUpdating"
+	reasonGroupsDecommissioning = "IsolationGroupsDecommissioning"
+	reasonReplicationFactor     = "ReplicationFactorChanging"
+	reasonInstancesBootstrap    = "InstancesBootstrapping"
+	reasonFullyReplicated       = "FullyReplicated"
+	reasonShardsUnderReplicated = "ShardsUnderReplicated"
+	reasonReconcileFailed       = "ReconcileFailed"
+)
+
+// clusterHealth is the health of a cluster derived from its StatefulSets and
+// placement.
+type clusterHealth struct {
+	state       myspec.M3DBState
+	message     string
+	ready       conditionState
+	progressing conditionState
+	degraded    conditionState
+}
+
+type conditionState struct {
+	status  corev1.ConditionStatus
+	reason  string
+	message string
+}
+
+// reconcileClusterStatus recomputes the cluster's state and Ready, Progressing
+// and Degraded conditions. The cluster's current generation is only marked as
+// observed once settled, i.e. once the reconcile loop brought the cluster to
+// the state its spec describes. The status is only written if it changed so
+// that we don't trigger an endless stream of cluster update events. If the
+// reconcile loop failed with reconcileErr the cluster is marked Degraded with
+// the error.
+func (c *Controller) reconcileClusterStatus(
+	ctx context.Context,
+	namespace, name string,
+	settled bool,
+	reconcileErr error,
+) error {
+	// Fetch the latest copy of the cluster as the reconcile loop may have
+	// updated its status.
+	cluster, err := c.crdClient.OperatorV1alpha1().M3DBClusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	sets, err := c.getChildStatefulSets(cluster)
+	if err != nil {
+		return err
+	}
+
+	var (
+		pl    placement.Placement
+		plErr error
+	)
+	if cluster.Status.HasInitializedPlacement() {
+		pl, plErr = c.adminClient.placementClientForCluster(cluster).Get(ctx)
+		if plErr != nil {
+			c.logger.Warn("unable to get placement for cluster status",
+				zap.String("cluster", cluster.Name), zap.Error(plErr))
+		}
+	}
+
+	health := computeClusterHealth(cluster, sets, pl, plErr, reconcileErr)
+
+	status := cluster.Status.DeepCopy()
+	status.State = health.state
+	status.Message = health.message
+	if settled {
+		status.ObservedGeneration = cluster.Generation
+	}
+	status.Replicas, status.ReadyInstances = countInstances(cluster, sets)
+	// Pods created by older versions of the operator have no component label,
+	// so select them by the components they aren't.
+	status.LabelSelector = m3dbNodeSelector(cluster).String()
+	if pl != nil && allInstancesAvailable(pl) {
+		// A new replication factor is only in effect once its new replicas are
+		// available.
+		status.ReplicationFactor = int32(pl.ReplicaFactor())
+	}
+	now := c.clock.Now().UTC().Format(time.RFC3339)
+	updateConditionState(status, myspec.ClusterConditionReady, health.ready, now)
+	updateConditionState(status, myspec.ClusterConditionProgressing, health.progressing, now)
+	updateConditionState(status, myspec.ClusterConditionDegraded, health.degraded, now)
+
+	if reflect.DeepEqual(status, &cluster.Status) {
+		return nil
+	}
+
+	cluster.Status = *status
+	if _, err := c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).UpdateStatus(cluster); err != nil {
+		return pkgerrors.WithMessage(err, "error updating cluster status")
+	}
+
+	c.logger.Info("updated cluster status",
+		zap.String("cluster", cluster.Name),
+		zap.String("state", string(health.state)))
+	return nil
+}
+
+// updateConditionState sets a condition to the given state. Times are only
+// updated if the condition actually changed.
+func updateConditionState(status *myspec.M3DBStatus, condType myspec.ClusterConditionType,
+	state conditionState, now string) {
+
+	cond, ok := status.GetCondition(condType)
+	if !ok {
+		cond = myspec.ClusterCondition{
+			Type:   condType,
+			Status: corev1.ConditionUnknown,
+		}
+	}
+
+	if ok && cond.Status == state.status && cond.Reason == state.reason && cond.Message == state.message {
+		return
+	}
+
+	if cond.Status != state.status {
+		cond.LastTransitionTime = now
+	}
+	cond.Status = state.status
+	cond.LastUpdateTime = now
+	cond.Reason = state.reason
+	cond.Message = state.message
+	status.UpdateCondition(cond)
+}
+
+// computeClusterHealth derives the health of a cluster. A cluster is red if it
+// isn't Ready (its placement doesn't exist or can't be fetched, or some shard
+// doesn't have a majority of its replicas available), yellow if it is Ready but
+// Progressing or Degraded, and green otherwise.
+func computeClusterHealth(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet,
+	pl placement.Placement, plErr, reconcileErr error) clusterHealth {
+
+	var (
+		ready = conditionState{
+			status:  corev1.ConditionTrue,
+			reason:  reasonClusterReady,
+			message: "all shards have a majority of replicas available",
+		}
+		degraded = conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonFullyReplicated,
+			message: "all shards have all replicas available",
+		}
+		progressing = computeProgressing(cluster, sets, pl)
+	)
+
+	switch {
+	case plErr != nil:
+		ready = conditionState{
+			status:  corev1.ConditionUnknown,
+			reason:  reasonPlacementUnavailable,
+			message: fmt.Sprintf("unable to get placement: %v", plErr),
+		}
+		degraded = conditionState{
+			status:  corev1.ConditionUnknown,
+			reason:  reasonPlacementUnavailable,
+			message: ready.message,
+		}
+	case pl == nil:
+		ready = conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonPlacementNotCreated,
+			message: "placement has not been initialized",
+		}
+		degraded = conditionState{
+			status:  corev1.ConditionFalse,
+			reason:  reasonPlacementNotCreated,
+			message: ready.message,
+		}
+	default:
+		// The placement's replication factor is the one in effect, the spec's
+		// is higher until a replica has been added for it.
+		rf := pl.ReplicaFactor()
+		majority := rf/2 + 1
+		unavailable, underReplicated := shardAvailability(pl, majority, rf)
+		if unavailable > 0 {
+			ready = conditionState{
+				status:  corev1.ConditionFalse,
+				reason:  reasonShardsUnavailable,
+				message: fmt.Sprintf("%d shards have fewer than %d replicas available", unavailable, majority),
+			}
+		}
+		if underReplicated > 0 {
+			degraded = conditionState{
+				status:  corev1.ConditionTrue,
+				reason:  reasonShardsUnderReplicated,
+				message: fmt.Sprintf("%d shards have fewer than %d replicas available", underReplicated, rf),
+			}
+		}
+	}
+
+	if reconcileErr != nil {
+		degraded = conditionState{
+			status:  corev1.ConditionTrue,
+			reason:  reasonReconcileFailed,
+			message: fmt.Sprintf("error reconciling cluster: %v", reconcileErr),
+		}
+	}
+
+	health := clusterHealth{
+		ready:       ready,
+		progressing: progressing,
+		degraded:    degraded,
+	}
+
+	switch {
+	case ready.status != corev1.ConditionTrue:
+		health.state = myspec.RedState
+		health.message = ready.message
+	case degraded.status == corev1.ConditionTrue:
+		health.state = myspec.YellowState
+		health.message = degraded.message
+	case progressing.status == corev1.ConditionTrue:
+		health.state = myspec.YellowState
+		health.message = progressing.message
+	default:
+		health.state = myspec.GreenState
+		health.message = "cluster is healthy"
+	}
+
+	return health
+}
+
+// computeProgressing returns the state of the Progressing condition.
+func computeProgressing(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet,
+	pl placement.Placement) conditionState {
+
+	setsByGroup := make(map[string]*appsv1.StatefulSet, len(sets))
+	for _, set := range sets {
+		setsByGroup[set.Labels[labels.IsolationGroup]] = set
+	}
+
+	if _, removed := splitRemovedIsolationGroupSets(cluster.Spec.IsolationGroups, sets); len(removed) > 0 {
+		return conditionState{
+			status:  corev1.ConditionTrue,
+			reason:  reasonGroupsDecommissioning,
+			message: fmt.Sprintf("isolation group %s is being decommissioned", removed[0].Labels[labels.IsolationGroup]),
+		}
+	}
+
+	for _, group := range cluster.Spec.IsolationGroups {
+		set, ok := setsByGroup[group.Name]
+		if !ok {
+			return conditionState{
+				status:  corev1.ConditionTrue,
+				reason:  reasonStatefulSetsMissing,
+				message: fmt.Sprintf("statefulset for isolation group %s does not exist", group.Name),
+			}
+		}
+
+		var replicas int32
+		if set.Spec.Replicas != nil {
+			replicas = *set.Spec.Replicas
+		}
+
+		if replicas != cluster.Spec.NumInstances(group) || set.Status.ReadyReplicas != replicas ||
+			isStatefulSetRollingOut(set) {
+			return conditionState{
+				status:  corev1.ConditionTrue,
+				reason:  reasonStatefulSetsUpdating,
+				message: fmt.Sprintf("statefulset %s is being updated", set.Name),
+			}
+		}
+	}
+
+	if pl != nil && pl.ReplicaFactor() != int(cluster.Spec.ReplicationFactor) {
+		return conditionState{
+			status: corev1.ConditionTrue,
+			reason: reasonReplicationFactor,
+			message: fmt.Sprintf("replication factor is changing from %d to %d",
+				pl.ReplicaFactor(), cluster.Spec.ReplicationFactor),
+		}
+	}
+
+	if pl != nil {
+		for _, inst := range pl.Instances() {
+			if !inst.IsAvailable() {
+				return conditionState{
+					status:  corev1.ConditionTrue,
+					reason:  reasonInstancesBootstrap,
+					message: fmt.Sprintf("instance %s is not available", inst.ID()),
+				}
+			}
+		}
+	}
+
+	return conditionState{
+		status:  corev1.ConditionFalse,
+		reason:  reasonReconciled,
+		message: "cluster matches spec",
+	}
+}
+
+// countInstances returns the number of instances in the cluster's smallest
+// isolation group, and the number of ready instances across all isolation
+// groups.
+func countInstances(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet) (replicas, ready int32) {
+	setsByGroup := make(map[string]*appsv1.StatefulSet, len(sets))
+	for _, set := range sets {
+		setsByGroup[set.Labels[labels.IsolationGroup]] = set
+		ready += set.Status.ReadyReplicas
+	}
+
+	for i, group := range cluster.Spec.IsolationGroups {
+		var groupReplicas int32
+		if set, ok := setsByGroup[group.Name]; ok {
+			groupReplicas = set.Status.Replicas
+		}
+		if i == 0 || groupReplicas < replicas {
+			replicas = groupReplicas
+		}
+	}
+
+	return replicas, ready
+}
+
+// allInstancesAvailable returns true if every shard of every instance in the
+// placement is available.
+func allInstancesAvailable(pl placement.Placement) bool {
+	for _, inst := range pl.Instances() {
+		if !inst.IsAvailable() {
+			return false
+		}
+	}
+	return true
+}
+
+// shardAvailability returns the number of shards with fewer than majority
+// replicas serving data, and the number with fewer than rf replicas serving
+// data. Leaving replicas still serve data until the shards they hand off are
+// available elsewhere.
+func shardAvailability(pl placement.Placement, majority, rf int) (unavailable, underReplicated int) {
+	serving := make(map[uint32]int, pl.NumShards())
+	for _, id := range pl.Shards() {
+		serving[id] = 0
+	}
+
+	for _, inst := range pl.Instances() {
+		for _, s := range inst.Shards().All() {
+			if st := s.State(); st == shard.Available || st == shard.Leaving {
+				serving[s.ID()]++
+			}
+		}
+	}
+
+	for _, n := range serving {
+		if n < majority {
+			unavailable++
+		}
+		if n < rf {
+			underReplicated++
+		}
+	}
+
+	return unavailable, underReplicated
+}
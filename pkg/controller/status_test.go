@@ -0,0 +1,305 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readySetsForCluster returns a fully ready StatefulSet for each of the
+// cluster's isolation groups.
+func readySetsForCluster(t *testing.T, cluster *myspec.M3DBCluster) []*appsv1.StatefulSet {
+	var sets []*appsv1.StatefulSet
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, group.NumInstances)
+		require.NoError(t, err)
+		set.Namespace = cluster.Namespace
+		set.Status.Replicas = group.NumInstances
+		set.Status.ReadyReplicas = group.NumInstances
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// placementWithShardStates returns a placement with one instance per replica,
+// where every shard on replica i is in state states[i].
+func placementWithShardStates(numShards int, states ...shard.State) placement.Placement {
+	shardIDs := make([]uint32, numShards)
+	for i := range shardIDs {
+		shardIDs[i] = uint32(i)
+	}
+
+	insts := make([]placement.Instance, len(states))
+	for i, state := range states {
+		shards := make([]shard.Shard, numShards)
+		for j := range shards {
+			shards[j] = shard.NewShard(uint32(j)).SetState(state)
+		}
+		insts[i] = placement.NewInstance().
+			SetID(fmt.Sprintf("inst%d", i)).
+			SetShards(shard.NewShards(shards))
+	}
+
+	return placement.NewPlacement().
+		SetInstances(insts).
+		SetShards(shardIDs).
+		SetReplicaFactor(len(states))
+}
+
+func TestComputeClusterHealth(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	tests := []struct {
+		name           string
+		sets           func() []*appsv1.StatefulSet
+		pl             placement.Placement
+		plErr          error
+		reconcileErr   error
+		expState       myspec.M3DBState
+		expReady       corev1.ConditionStatus
+		expProgressing corev1.ConditionStatus
+		expDegraded    corev1.ConditionStatus
+	}{
+		{
+			name:           "healthy",
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Available),
+			expState:       myspec.GreenState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionFalse,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name:           "no placement",
+			expState:       myspec.RedState,
+			expReady:       corev1.ConditionFalse,
+			expProgressing: corev1.ConditionFalse,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name:           "placement error",
+			plErr:          errors.New("boom"),
+			expState:       myspec.RedState,
+			expReady:       corev1.ConditionUnknown,
+			expProgressing: corev1.ConditionFalse,
+			expDegraded:    corev1.ConditionUnknown,
+		},
+		{
+			name:           "reconcile error",
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Available),
+			reconcileErr:   errors.New("boom"),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionFalse,
+			expDegraded:    corev1.ConditionTrue,
+		},
+		{
+			name:           "one replica initializing",
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Initializing),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionTrue,
+		},
+		{
+			name:           "leaving replicas still serve",
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Leaving),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name:           "majority unavailable",
+			pl:             placementWithShardStates(8, shard.Available, shard.Initializing, shard.Initializing),
+			expState:       myspec.RedState,
+			expReady:       corev1.ConditionFalse,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionTrue,
+		},
+		{
+			name: "statefulset not ready",
+			sets: func() []*appsv1.StatefulSet {
+				sets := readySetsForCluster(t, cluster)
+				sets[1].Status.ReadyReplicas--
+				return sets
+			},
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Available),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name: "statefulset missing",
+			sets: func() []*appsv1.StatefulSet {
+				return readySetsForCluster(t, cluster)[:2]
+			},
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Available),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name:           "replication factor changing",
+			pl:             placementWithShardStates(8, shard.Available, shard.Available),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionFalse,
+		},
+		{
+			name: "isolation group decommissioning",
+			sets: func() []*appsv1.StatefulSet {
+				sets := readySetsForCluster(t, cluster)
+				removed := sets[0].DeepCopy()
+				removed.Name = "removed"
+				removed.Labels[labels.IsolationGroup] = "us-fake1-removed"
+				return append(sets, removed)
+			},
+			pl:             placementWithShardStates(8, shard.Available, shard.Available, shard.Available),
+			expState:       myspec.YellowState,
+			expReady:       corev1.ConditionTrue,
+			expProgressing: corev1.ConditionTrue,
+			expDegraded:    corev1.ConditionFalse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sets := readySetsForCluster(t, cluster)
+			if test.sets != nil {
+				sets = test.sets()
+			}
+
+			health := computeClusterHealth(cluster, sets, test.pl, test.plErr, test.reconcileErr)
+			assert.Equal(t, test.expState, health.state)
+			assert.Equal(t, test.expReady, health.ready.status)
+			assert.Equal(t, test.expProgressing, health.progressing.status)
+			assert.Equal(t, test.expDegraded, health.degraded.status)
+			assert.NotEmpty(t, health.message)
+		})
+	}
+}
+
+func TestReconcileClusterStatus(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	cluster.Generation = 3
+	cluster.Status.UpdateCondition(myspec.ClusterCondition{
+		Type:   myspec.ClusterConditionPlacementInitialized,
+		Status: corev1.ConditionTrue,
+	})
+
+	sets := readySetsForCluster(t, cluster)
+	objects := make([]runtime.Object, len(sets))
+	for i, set := range sets {
+		objects[i] = set
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objects,
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	pl := placementWithShardStates(8, shard.Available, shard.Available, shard.Available)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(pl, nil).Times(3)
+
+	require.NoError(t, controller.reconcileClusterStatus(context.Background(), cluster.Namespace, cluster.Name, true, nil))
+
+	cluster, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, myspec.GreenState, cluster.Status.State)
+	assert.Equal(t, int64(3), cluster.Status.ObservedGeneration)
+	assert.True(t, cluster.Status.IsReady())
+	assert.True(t, cluster.Status.HasInitializedPlacement())
+	assert.Equal(t, int32(3), cluster.Status.Replicas)
+	assert.Equal(t, int32(9), cluster.Status.ReadyInstances)
+	assert.Equal(t, int32(3), cluster.Status.ReplicationFactor)
+	assert.Equal(t, "operator.m3db.io/app=m3db,operator.m3db.io/cluster=cluster-zones,"+
+		"operator.m3db.io/component notin (aggregator,coordinator)",
+		cluster.Status.LabelSelector)
+
+	for _, condType := range []myspec.ClusterConditionType{
+		myspec.ClusterConditionProgressing,
+		myspec.ClusterConditionDegraded,
+	} {
+		cond, ok := cluster.Status.GetCondition(condType)
+		require.True(t, ok)
+		assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	}
+
+	// An unchanged status should not be written again.
+	numActions := len(deps.crdClient.Actions())
+	require.NoError(t, controller.reconcileClusterStatus(context.Background(), cluster.Namespace, cluster.Name, true, nil))
+	for _, action := range deps.crdClient.Actions()[numActions:] {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+	// A new generation isn't observed until the cluster settles on it.
+	cluster.Generation = 4
+	_, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
+	require.NoError(t, err)
+	require.NoError(t, controller.reconcileClusterStatus(context.Background(), cluster.Namespace, cluster.Name, false, nil))
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cluster.Status.ObservedGeneration)
+}
+
+func TestCountInstances(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	sets := readySetsForCluster(t, cluster)
+
+	replicas, ready := countInstances(cluster, sets)
+	assert.Equal(t, int32(3), replicas)
+	assert.Equal(t, int32(9), ready)
+
+	// The smallest group determines the replica count.
+	sets[1].Status.Replicas = 2
+	sets[1].Status.ReadyReplicas = 1
+	replicas, ready = countInstances(cluster, sets)
+	assert.Equal(t, int32(2), replicas)
+	assert.Equal(t, int32(7), ready)
+
+	// A group without a StatefulSet has no replicas.
+	replicas, ready = countInstances(cluster, sets[:2])
+	assert.Equal(t, int32(0), replicas)
+	assert.Equal(t, int32(4), ready)
+}
@@ -21,6 +21,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,7 +32,9 @@ import (
 	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/defaults"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
@@ -59,26 +62,30 @@ var (
 )
 
 // reconcileNamespaces will delete any namespaces currently in the cluster that
-// aren't part of the cluster spec, and create any that are present in the spec
-// but not in the cluster.
-func (c *Controller) reconcileNamespaces(cluster *myspec.M3DBCluster) error {
-	resp, err := c.adminClient.namespaceClientForCluster(cluster).List()
+// aren't part of the cluster spec, create any that are present in the spec
+// but not in the cluster, and update the options of any whose spec has changed.
+func (c *Controller) reconcileNamespaces(ctx context.Context, cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
+	resp, err := c.adminClient.namespaceClientForCluster(cluster).List(ctx)
 	if err != nil {
 		c.logger.Error("failed to get namespace", zap.Error(err))
 		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, err.Error())
-		return err
+		return nil, err
 	}
 
-	if err := c.pruneNamespaces(cluster, resp.Registry); err != nil {
-		return err
+	if err := c.pruneNamespaces(ctx, cluster, resp.Registry); err != nil {
+		return nil, err
+	}
+
+	if err := c.createNamespaces(ctx, cluster, resp.Registry); err != nil {
+		return nil, err
 	}
 
-	return c.createNamespaces(cluster, resp.Registry)
+	return c.updateNamespaces(ctx, cluster, resp.Registry)
 }
 
 // createNamespaces will attempt to create in the cluster all namespaces which
 // are present in the spec but not the cluster.
-func (c *Controller) createNamespaces(cluster *myspec.M3DBCluster, registry *dbns.Registry) error {
+func (c *Controller) createNamespaces(ctx context.Context, cluster *myspec.M3DBCluster, registry *dbns.Registry) error {
 	toCreate := namespacesToCreate(registry, cluster.Spec.Namespaces)
 	for _, ns := range toCreate {
 		req, err := namespace.RequestFromSpec(ns)
@@ -90,7 +97,7 @@ func (c *Controller) createNamespaces(cluster *myspec.M3DBCluster, registry *dbn
 			return fmt.Errorf("error forming request for namespace '%s': %v", ns.Name, err)
 		}
 
-		err = c.adminClient.namespaceClientForCluster(cluster).Create(req)
+		err = c.adminClient.namespaceClientForCluster(cluster).Create(ctx, req)
 		if err != nil {
 			c.logger.Error("error creating namespace",
 				zap.String("namespace", ns.Name),
@@ -105,12 +112,76 @@ func (c *Controller) createNamespaces(cluster *myspec.M3DBCluster, registry *dbn
 	return nil
 }
 
+// updateNamespaces will update the options of any namespaces in the m3db
+// cluster whose options differ from the spec. Changes to options that can't be
+// updated are rejected and reported with an event and the
+// NamespaceUpdateRejected condition.
+func (c *Controller) updateNamespaces(ctx context.Context, cluster *myspec.M3DBCluster, registry *dbns.Registry) (*myspec.M3DBCluster, error) {
+	var rejected []string
+	for _, ns := range cluster.Spec.Namespaces {
+		current, ok := registry.Namespaces[ns.Name]
+		if !ok {
+			continue
+		}
+
+		req, err := namespace.UpdateRequestFromSpec(ns, current)
+		if pkgerrors.Cause(err) == namespace.ErrImmutableOption {
+			c.logger.Warn("rejecting namespace update", zap.String("namespace", ns.Name), zap.Error(err))
+			rejected = append(rejected, err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error forming update request for namespace '%s': %v", ns.Name, err)
+		}
+		if req == nil {
+			continue
+		}
+
+		if err := c.adminClient.namespaceClientForCluster(cluster).Update(ctx, req); err != nil {
+			c.logger.Error("error updating namespace",
+				zap.String("namespace", ns.Name),
+				zap.Error(err))
+
+			return nil, fmt.Errorf("error updating namespace '%s': %v", ns.Name, err)
+		}
+
+		c.recorder.NormalEvent(cluster, eventer.ReasonSuccessfulUpdate, "updated namespace "+ns.Name)
+	}
+
+	status, reason, message := corev1.ConditionFalse, "NamespacesUpdated", "all namespace updates applied"
+	if len(rejected) > 0 {
+		status, reason, message = corev1.ConditionTrue, "ImmutableOptionChanged", strings.Join(rejected, "; ")
+	}
+
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateRejected)
+	if !ok && status == corev1.ConditionFalse {
+		// Don't add the condition until an update has been rejected.
+		return cluster, nil
+	}
+	if ok && cond.Status == status && cond.Reason == reason && cond.Message == message {
+		return cluster, nil
+	}
+
+	// Rejections stay in the spec until the user reverts them, only report
+	// them when they change rather than on every sync.
+	for _, msg := range rejected {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailedToUpdate, "rejected update: %s", msg)
+	}
+
+	return c.setStatus(cluster, myspec.ClusterConditionNamespaceUpdateRejected, status, reason, message)
+}
+
 // pruneNamespaces will delete any namespaces in the m3db cluster that aren't
-// in the spec.
-func (c *Controller) pruneNamespaces(cluster *myspec.M3DBCluster, registry *dbns.Registry) error {
-	toDelete := namespacesToDelete(registry, cluster.Spec.Namespaces)
+// in the spec or managed by an M3DBNamespace.
+func (c *Controller) pruneNamespaces(ctx context.Context, cluster *myspec.M3DBCluster, registry *dbns.Registry) error {
+	keep := append([]myspec.Namespace(nil), cluster.Spec.Namespaces...)
+	for _, ns := range c.clusterNamespaces(cluster) {
+		keep = append(keep, ns.NamespaceSpec())
+	}
+
+	toDelete := namespacesToDelete(registry, keep)
 	for _, ns := range toDelete {
-		err := c.adminClient.namespaceClientForCluster(cluster).Delete(ns)
+		err := c.adminClient.namespaceClientForCluster(cluster).Delete(ctx, ns)
 		if err == nil {
 			c.logger.Info("deleted namespace", zap.String("namespace", ns))
 			c.recorder.NormalEvent(cluster, eventer.ReasonDeleting, "deleted namespace "+ns)
@@ -161,9 +232,9 @@ func namespacesToDelete(registry *dbns.Registry, specNs []myspec.Namespace) (toD
 	return
 }
 
-func (c *Controller) validatePlacementWithStatus(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
+func (c *Controller) validatePlacementWithStatus(ctx context.Context, cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
 	plClient := c.adminClient.placementClientForCluster(cluster)
-	_, err := plClient.Get()
+	_, err := plClient.Get(ctx)
 	if err == nil {
 		if !cluster.Status.HasInitializedPlacement() {
 			return c.setStatusPlacementCreated(cluster)
@@ -209,7 +280,7 @@ func (c *Controller) validatePlacementWithStatus(cluster *myspec.M3DBCluster) (*
 		newPlacement.Instances = append(newPlacement.Instances, instance)
 	}
 
-	if err := plClient.Init(newPlacement); err != nil {
+	if err := plClient.Init(ctx, newPlacement); err != nil {
 		return nil, err
 	}
 
@@ -271,6 +342,20 @@ func (c *Controller) setStatus(cluster *myspec.M3DBCluster, condition myspec.Clu
 	return c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).UpdateStatus(cluster)
 }
 
+// setStatusIfChanged sets a condition like setStatus, but only writes the
+// status if the condition changed. Conditions that may be set on every sync
+// must use it, as every status write triggers another sync.
+func (c *Controller) setStatusIfChanged(cluster *myspec.M3DBCluster, condition myspec.ClusterConditionType,
+	status corev1.ConditionStatus, reason, message string) (*myspec.M3DBCluster, error) {
+
+	cond, ok := cluster.Status.GetCondition(condition)
+	if ok && cond.Status == status && cond.Reason == reason && cond.Message == message {
+		return cluster, nil
+	}
+
+	return c.setStatus(cluster, condition, status, reason, message)
+}
+
 // Updates the cluster if there had been a condition that a pod was
 // bootstrapping but no pods are currently bootstrapping.
 func (c *Controller) reconcileBootstrappingStatus(cluster *myspec.M3DBCluster, placement placement.Placement) (*myspec.M3DBCluster, error) {
@@ -284,7 +369,7 @@ func (c *Controller) reconcileBootstrappingStatus(cluster *myspec.M3DBCluster, p
 		"BootstrapComplete", "no bootstraps in progress")
 }
 
-func (c *Controller) addPodToPlacement(cluster *myspec.M3DBCluster, pod *corev1.Pod) error {
+func (c *Controller) addPodToPlacement(ctx context.Context, cluster *myspec.M3DBCluster, pod *corev1.Pod) error {
 	c.logger.Info("found pod not in placement", zap.String("pod", pod.Name))
 	inst, err := k8sops.PlacementInstanceFromPod(cluster, pod, c.podIDProvider)
 	if err != nil {
@@ -301,7 +386,7 @@ func (c *Controller) addPodToPlacement(cluster *myspec.M3DBCluster, pod *corev1.
 		return err
 	}
 
-	err = c.adminClient.placementClientForCluster(cluster).Add(*inst)
+	err = c.adminClient.placementClientForCluster(cluster).Add(ctx, *inst)
 	if err != nil {
 		err := fmt.Errorf("error adding pod to placement: %s", pod.Name)
 		c.logger.Error(err.Error())
@@ -350,6 +435,7 @@ func (c *Controller) checkPodsForReplacement(
 }
 
 func (c *Controller) replacePodInPlacement(
+	ctx context.Context,
 	cluster *myspec.M3DBCluster,
 	pl placement.Placement,
 	leavingInstanceID string,
@@ -372,7 +458,7 @@ func (c *Controller) replacePodInPlacement(
 		return err
 	}
 
-	err = c.adminClient.placementClientForCluster(cluster).Replace(leavingInstanceID, *newInst)
+	err = c.adminClient.placementClientForCluster(cluster).Replace(ctx, leavingInstanceID, *newInst)
 	if err != nil {
 		err := fmt.Errorf("error replacing pod in placement: %s", leavingInstanceID)
 		c.logger.Error(err.Error())
@@ -384,21 +470,22 @@ func (c *Controller) replacePodInPlacement(
 
 // expandPlacementForSet takes a StatefulSet that has pods in it which need to
 // be added to the placement and chooses a pod to expand to the placement.
-func (c *Controller) expandPlacementForSet(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet,
+func (c *Controller) expandPlacementForSet(ctx context.Context, cluster *myspec.M3DBCluster, set *appsv1.StatefulSet,
 	group myspec.IsolationGroup, placement placement.Placement) error {
 
+	desired := cluster.Spec.NumInstances(group)
 	existInsts := instancesInIsoGroup(placement, group.Name)
-	if len(existInsts) >= int(group.NumInstances) {
+	if len(existInsts) >= int(desired) {
 		c.logger.Warn("not expanding set, already at desired capacity",
-			zap.Int32("groupSize", group.NumInstances),
+			zap.Int32("groupSize", desired),
 			zap.Int("instsInGroup", len(existInsts)))
 		return nil
 	}
 
-	if set.Status.ReadyReplicas < group.NumInstances {
+	if set.Status.ReadyReplicas < desired {
 		c.logger.Error("cannot expand set, ready replicas < desired",
 			zap.Int32("ready", set.Status.ReadyReplicas),
-			zap.Int32("desired", group.NumInstances))
+			zap.Int32("desired", desired))
 		return fmt.Errorf("cannot expand set '%s', not yet ready", set.Name)
 	}
 
@@ -419,7 +506,7 @@ func (c *Controller) expandPlacementForSet(cluster *myspec.M3DBCluster, set *app
 		}
 		_, ok := placement.Instance(idStr)
 		if !ok {
-			return c.addPodToPlacement(cluster, pod)
+			return c.addPodToPlacement(ctx, cluster, pod)
 		}
 	}
 
@@ -429,7 +516,7 @@ func (c *Controller) expandPlacementForSet(cluster *myspec.M3DBCluster, set *app
 // shrinkPlacementForSet takes a StatefulSet that needs to be shrunk and
 // removes the last pod in the StatefulSet from the active placement, enabling
 // the StatefulSet size to be decreased once the remove completes.
-func (c *Controller) shrinkPlacementForSet(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet, pl placement.Placement) error {
+func (c *Controller) shrinkPlacementForSet(ctx context.Context, cluster *myspec.M3DBCluster, set *appsv1.StatefulSet, pl placement.Placement) error {
 	selector := klabels.SelectorFromSet(set.Labels)
 	pods, err := c.podLister.Pods(cluster.Namespace).List(selector)
 	if err != nil {
@@ -444,7 +531,7 @@ func (c *Controller) shrinkPlacementForSet(cluster *myspec.M3DBCluster, set *app
 	}
 
 	c.logger.Info("removing pod from placement", zap.String("instance", removeInst.ID()))
-	return c.adminClient.placementClientForCluster(cluster).Remove(removeInst.ID())
+	return c.adminClient.placementClientForCluster(cluster).Remove(ctx, removeInst.ID())
 }
 
 // findPodInstanceToRemove returns the pod (and associated placement instace)
@@ -496,6 +583,125 @@ func (c *Controller) findPodInPlacement(cluster *myspec.M3DBCluster, pl placemen
 	return inst, nil
 }
 
+// updateStatefulSets compares each of the cluster's StatefulSets against the
+// set generated from the current cluster spec and updates the first one (in
+// isolation group order) that differs. Only one set is updated per call so that
+// changes are rolled out one isolation group at a time; it returns true if a
+// set was updated, in which case the caller should wait for the rollout to
+// complete before doing anything else. Sets without a spec hash are assumed to
+// match the spec, and only annotated with its hash.
+func (c *Controller) updateStatefulSets(cluster *myspec.M3DBCluster, isoGroups []myspec.IsolationGroup,
+	sets []*appsv1.StatefulSet) (bool, error) {
+
+	setsByGroup := make(map[string]*appsv1.StatefulSet, len(sets))
+	for _, set := range sets {
+		if group, ok := set.Labels[labels.IsolationGroup]; ok {
+			setsByGroup[group] = set
+		}
+	}
+
+	for _, group := range isoGroups {
+		set, ok := setsByGroup[group.Name]
+		if !ok {
+			continue
+		}
+
+		// Preserve the current size of the set, resizing is handled separately.
+		var replicas int32
+		if set.Spec.Replicas != nil {
+			replicas = *set.Spec.Replicas
+		}
+
+		desired, err := k8sops.GenerateStatefulSet(cluster, set.Name, group.Name, replicas)
+		if err != nil {
+			return false, err
+		}
+
+		hash, err := k8sops.StatefulSetSpecHash(desired)
+		if err != nil {
+			return false, err
+		}
+
+		setLogger := c.logger.With(
+			zap.String("statefulSet", set.Name),
+			zap.String("isolationGroup", group.Name),
+		)
+
+		current, ok := set.Annotations[annotations.SpecHash]
+		if !ok {
+			// Sets created before their spec hash was recorded have nothing to
+			// compare against. Record the current hash without changing the
+			// template, rather than restart every pod after upgrading the
+			// operator; later spec changes roll out as usual.
+			if set.Annotations == nil {
+				set.Annotations = make(map[string]string)
+			}
+			set.Annotations[annotations.SpecHash] = hash
+			setLogger.Info("recording spec hash of statefulset", zap.String("hash", hash))
+			if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+				return false, pkgerrors.WithMessagef(err, "error annotating statefulset %s", set.Name)
+			}
+			continue
+		}
+		if current == hash {
+			continue
+		}
+
+		// Merge rather than replace metadata so we don't clobber labels or
+		// annotations added by other tools.
+		if set.Labels == nil {
+			set.Labels = make(map[string]string)
+		}
+		for k, v := range desired.Labels {
+			set.Labels[k] = v
+		}
+		if set.Annotations == nil {
+			set.Annotations = make(map[string]string)
+		}
+		for k, v := range desired.Annotations {
+			set.Annotations[k] = v
+		}
+		set.Annotations[annotations.SpecHash] = hash
+		set.Spec.Template = desired.Spec.Template
+
+		setLogger.Info("updating statefulset to match cluster spec", zap.String("hash", hash))
+		if _, err := c.kubeClient.AppsV1().StatefulSets(set.Namespace).Update(set); err != nil {
+			return false, pkgerrors.WithMessagef(err, "error updating statefulset %s", set.Name)
+		}
+
+		c.recorder.NormalEvent(cluster, eventer.ReasonUpdating, "updating statefulset %s", set.Name)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// isStatefulSetRollingOut returns true if the StatefulSet controller has not
+// yet observed the latest spec of a set, or if not every pod in the set is
+// running the set's latest revision.
+func isStatefulSetRollingOut(set *appsv1.StatefulSet) bool {
+	if set.Status.ObservedGeneration < set.Generation {
+		return true
+	}
+
+	return set.Status.UpdateRevision != "" &&
+		set.Status.CurrentRevision != set.Status.UpdateRevision
+}
+
+// annotateSpecHash sets the spec hash annotation of a generated StatefulSet.
+func annotateSpecHash(set *appsv1.StatefulSet) error {
+	hash, err := k8sops.StatefulSetSpecHash(set)
+	if err != nil {
+		return err
+	}
+
+	if set.Annotations == nil {
+		set.Annotations = make(map[string]string)
+	}
+	set.Annotations[annotations.SpecHash] = hash
+	return nil
+}
+
 func (c *Controller) updateFinalizers(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
 	var err error
 	cluster, err = c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
@@ -505,6 +711,26 @@ func (c *Controller) updateFinalizers(cluster *myspec.M3DBCluster) (*myspec.M3DB
 	return cluster, nil
 }
 
+// ensureDefaults writes any defaults missing from the cluster's spec to the
+// cluster.
+func (c *Controller) ensureDefaults(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
+	changed, err := defaults.SetClusterDefaults(cluster)
+	if err != nil {
+		c.recorder.WarningEvent(cluster, eventer.ReasonFailSync, err.Error())
+		return nil, err
+	}
+	if !changed {
+		return cluster, nil
+	}
+
+	c.logger.Info("setting cluster defaults", zap.String("cluster", cluster.Name))
+	cluster, err = c.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Update(cluster)
+	if err != nil {
+		return nil, pkgerrors.WithMessage(err, "error updating cluster defaults")
+	}
+	return cluster, nil
+}
+
 // ensureEtcdFinalizer ensures that the etcd deletion finalizer is present.
 func (c *Controller) ensureEtcdFinalizer(cluster *myspec.M3DBCluster) (*myspec.M3DBCluster, error) {
 	if stringArrayContains(cluster.Finalizers, labels.EtcdDeletionFinalizer) {
@@ -533,12 +759,12 @@ func (c *Controller) removeEtcdFinalizer(cluster *myspec.M3DBCluster) (*myspec.M
 	return c.updateFinalizers(cluster)
 }
 
-func (c *Controller) deleteAllNamespaces(cluster *myspec.M3DBCluster) error {
+func (c *Controller) deleteAllNamespaces(ctx context.Context, cluster *myspec.M3DBCluster) error {
 	clusterLogger := c.logger.With(zap.String("cluster", cluster.Name))
 	clusterLogger.Info("cleaning up cluster namespaces")
 
 	nsClient := c.adminClient.namespaceClientForCluster(cluster)
-	namespaces, err := nsClient.List()
+	namespaces, err := nsClient.List(ctx)
 	if err != nil {
 		return pkgerrors.WithMessage(err, "error listing namespaces for deletion")
 	}
@@ -547,7 +773,7 @@ func (c *Controller) deleteAllNamespaces(cluster *myspec.M3DBCluster) error {
 	}
 
 	for name := range namespaces.Registry.Namespaces {
-		if err := nsClient.Delete(name); err != nil {
+		if err := nsClient.Delete(ctx, name); err != nil {
 			return pkgerrors.WithMessagef(err, "error deleting namespace %s", name)
 		}
 		clusterLogger.Info("deleted namespace during cleanup", zap.String("namespace", name))
@@ -556,7 +782,7 @@ func (c *Controller) deleteAllNamespaces(cluster *myspec.M3DBCluster) error {
 	return nil
 }
 
-func (c *Controller) deletePlacement(cluster *myspec.M3DBCluster) error {
+func (c *Controller) deletePlacement(ctx context.Context, cluster *myspec.M3DBCluster) error {
 	clusterLogger := c.logger.With(zap.String("cluster", cluster.Name))
 	clusterLogger.Info("cleaning up cluster placement")
 
@@ -564,7 +790,7 @@ func (c *Controller) deletePlacement(cluster *myspec.M3DBCluster) error {
 	// the initial Get() once https://github.com/m3db/m3/pull/1701 is merged and
 	// in a release.
 	plClient := c.adminClient.placementClientForCluster(cluster)
-	_, err := plClient.Get()
+	_, err := plClient.Get(ctx)
 	if err != nil {
 		// If the placement is not found there's nothing to do.
 		if pkgerrors.Cause(err) == m3admin.ErrNotFound {
@@ -573,7 +799,7 @@ func (c *Controller) deletePlacement(cluster *myspec.M3DBCluster) error {
 		return pkgerrors.WithMessage(err, "error fetching placement to delete")
 	}
 
-	if err := plClient.Delete(); err != nil {
+	if err := plClient.Delete(ctx); err != nil {
 		return pkgerrors.WithMessagef(err, "error deleting placement for cluster %s", cluster.Name)
 	}
 
@@ -21,6 +21,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -32,9 +33,11 @@ import (
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
 	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
 	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
 	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/cluster/placement"
@@ -85,15 +88,94 @@ func TestReconcileNamespaces(t *testing.T) {
 	resp := &admin.NamespaceGetResponse{
 		Registry: registry,
 	}
-	nsMock.EXPECT().List().Return(resp, nil)
+	nsMock.EXPECT().List(gomock.Any()).Return(resp, nil)
 
-	nsMock.EXPECT().Delete("a").Return(nil)
-	nsMock.EXPECT().Create(namespaceMatcher{"metrics-10s:2d"}).Return(nil)
+	nsMock.EXPECT().Delete(gomock.Any(), "a").Return(nil)
+	nsMock.EXPECT().Create(gomock.Any(), namespaceMatcher{"metrics-10s:2d"}).Return(nil)
 
-	err := controller.reconcileNamespaces(cluster)
+	_, err := controller.reconcileNamespaces(context.Background(), cluster)
 	assert.NoError(t, err)
 }
 
+func TestUpdateNamespaces(t *testing.T) {
+	cluster := getFixture("cluster-simple.yaml", t)
+	cluster.Spec.Namespaces = []myspec.Namespace{
+		{
+			Name:   "unchanged",
+			Preset: string(namespace.PresetTenSecondsTwoDaysIndexed),
+		},
+		{
+			Name:   "mutable",
+			Preset: string(namespace.PresetTenSecondsTwoDaysIndexed),
+		},
+		{
+			Name:   "immutable",
+			Preset: string(namespace.PresetTenSecondsTwoDaysIndexed),
+		},
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+	nsMock := deps.namespaceClient
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	current := func() *dbns.NamespaceOptions {
+		req, err := namespace.RequestFromSpec(cluster.Spec.Namespaces[0])
+		require.NoError(t, err)
+		return req.Options
+	}
+
+	mutable := current()
+	mutable.RetentionOptions.RetentionPeriodNanos = int64(24 * time.Hour)
+	immutable := current()
+	immutable.RetentionOptions.BlockSizeNanos = int64(4 * time.Hour)
+
+	registry := &dbns.Registry{
+		Namespaces: map[string]*dbns.NamespaceOptions{
+			"unchanged": current(),
+			"mutable":   mutable,
+			"immutable": immutable,
+		},
+	}
+
+	nsMock.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *namespace.UpdateRequest) error {
+		assert.Equal(t, "mutable", req.Name)
+		assert.Equal(t, int64(48*time.Hour), req.Options.RetentionOptions.RetentionPeriodNanos)
+		return nil
+	})
+
+	recorder := &recordingPoster{}
+	controller.recorder = recorder
+
+	cluster, err := controller.updateNamespaces(context.Background(), cluster, registry)
+	require.NoError(t, err)
+
+	cond, ok := cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateRejected)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "namespace 'immutable' changes retentionOptions.blockSize")
+	require.Len(t, recorder.warningEvents(), 1)
+	assert.Contains(t, recorder.warningEvents()[0], "rejected update")
+
+	// A rejection that's already reported isn't reported again.
+	registry.Namespaces["mutable"] = current()
+	cluster, err = controller.updateNamespaces(context.Background(), cluster, registry)
+	require.NoError(t, err)
+	assert.Len(t, recorder.warningEvents(), 1)
+
+	// Once the spec no longer makes immutable changes the condition is cleared.
+	registry.Namespaces["mutable"] = current()
+	registry.Namespaces["immutable"] = current()
+	cluster, err = controller.updateNamespaces(context.Background(), cluster, registry)
+	require.NoError(t, err)
+
+	cond, ok = cluster.Status.GetCondition(myspec.ClusterConditionNamespaceUpdateRejected)
+	require.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+}
+
 func TestPruneNamespaces(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
 	cluster.Spec.Namespaces = []myspec.Namespace{}
@@ -109,22 +191,22 @@ func TestPruneNamespaces(t *testing.T) {
 		"foo": {},
 	}}
 
-	nsMock.EXPECT().Delete("foo").Return(nil)
-	err := controller.pruneNamespaces(cluster, registry)
+	nsMock.EXPECT().Delete(gomock.Any(), "foo").Return(nil)
+	err := controller.pruneNamespaces(context.Background(), cluster, registry)
 	assert.NoError(t, err)
 
-	nsMock.EXPECT().Delete("foo").Return(pkgerrors.WithMessage(m3admin.ErrNotFound, "foo"))
-	err = controller.pruneNamespaces(cluster, registry)
+	nsMock.EXPECT().Delete(gomock.Any(), "foo").Return(pkgerrors.WithMessage(m3admin.ErrNotFound, "foo"))
+	err = controller.pruneNamespaces(context.Background(), cluster, registry)
 	assert.NoError(t, err)
 
-	nsMock.EXPECT().Delete("foo").Return(errors.New("foo"))
-	err = controller.pruneNamespaces(cluster, registry)
+	nsMock.EXPECT().Delete(gomock.Any(), "foo").Return(errors.New("foo"))
+	err = controller.pruneNamespaces(context.Background(), cluster, registry)
 	assert.Error(t, err)
 
 	registry.Namespaces["baz"] = &dbns.NamespaceOptions{}
-	nsMock.EXPECT().Delete("foo").Return(nil)
-	nsMock.EXPECT().Delete("baz").Return(nil)
-	err = controller.pruneNamespaces(cluster, registry)
+	nsMock.EXPECT().Delete(gomock.Any(), "foo").Return(nil)
+	nsMock.EXPECT().Delete(gomock.Any(), "baz").Return(nil)
+	err = controller.pruneNamespaces(context.Background(), cluster, registry)
 	assert.NoError(t, err)
 }
 
@@ -144,10 +226,10 @@ func TestCreateNamespaces(t *testing.T) {
 
 	registry := &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{}}
 
-	nsMock.EXPECT().Create(namespaceMatcher{"metrics-10s:2d"}).Return(nil)
-	nsMock.EXPECT().Create(namespaceMatcher{"foo"}).Return(nil)
+	nsMock.EXPECT().Create(gomock.Any(), namespaceMatcher{"metrics-10s:2d"}).Return(nil)
+	nsMock.EXPECT().Create(gomock.Any(), namespaceMatcher{"foo"}).Return(nil)
 
-	err := controller.createNamespaces(cluster, registry)
+	err := controller.createNamespaces(context.Background(), cluster, registry)
 	assert.NoError(t, err)
 }
 
@@ -354,9 +436,9 @@ func TestAddPodToPlacement(t *testing.T) {
 		Weight:         100,
 	}
 
-	deps.placementClient.EXPECT().Add(expInstance)
+	deps.placementClient.EXPECT().Add(gomock.Any(), expInstance)
 
-	err := controller.addPodToPlacement(cluster, pod)
+	err := controller.addPodToPlacement(context.Background(), cluster, pod)
 	assert.NoError(t, err)
 
 	cluster, err = controller.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
@@ -455,7 +537,7 @@ func identifyPods(idProvider *podidentity.MockProvider, pods []*corev1.Pod, opts
 func TestExpandPlacementForSet(t *testing.T) {
 	cluster := getFixture("cluster-3-zones.yaml", t)
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 	set.Status.ReadyReplicas = 3
 
@@ -477,11 +559,11 @@ func TestExpandPlacementForSet(t *testing.T) {
 	instPb, err := k8sops.PlacementInstanceFromPod(cluster, pods[2], idProvider)
 	require.NoError(t, err)
 
-	placementMock.EXPECT().Add(*instPb)
-	err = controller.expandPlacementForSet(cluster, set, group, pl)
+	placementMock.EXPECT().Add(gomock.Any(), *instPb)
+	err = controller.expandPlacementForSet(context.Background(), cluster, set, group, pl)
 	assert.NoError(t, err)
 
-	cluster, err = deps.crdClient.Operator().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	cluster, err = deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
 	require.NoError(t, err)
 	assert.True(t, cluster.Status.HasPodBootstrapping())
 }
@@ -493,7 +575,7 @@ func TestExpandPlacementForSet_Nop(t *testing.T) {
 	defer deps.cleanup()
 
 	cluster := getFixture("cluster-3-zones.yaml", t)
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	pods := podsForClusterSet(cluster, set, 3)
@@ -502,7 +584,7 @@ func TestExpandPlacementForSet_Nop(t *testing.T) {
 	pl := placementFromPods(t, cluster, pods, idProvider)
 	group := cluster.Spec.IsolationGroups[0]
 
-	err = controller.expandPlacementForSet(cluster, set, group, pl)
+	err = controller.expandPlacementForSet(context.Background(), cluster, set, group, pl)
 	// We know this was a noop because the mock expects no calls.
 	assert.NoError(t, err)
 }
@@ -514,7 +596,7 @@ func TestExpandPlacementForSet_Err(t *testing.T) {
 	defer deps.cleanup()
 
 	cluster := getFixture("cluster-3-zones.yaml", t)
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	pods := podsForClusterSet(cluster, set, 2)
@@ -523,14 +605,36 @@ func TestExpandPlacementForSet_Err(t *testing.T) {
 
 	pl := placementFromPods(t, cluster, pods, idProvider)
 	const expErr = "cannot expand set 'cluster-zones-rep0', not yet ready"
-	err = controller.expandPlacementForSet(cluster, set, group, pl)
+	err = controller.expandPlacementForSet(context.Background(), cluster, set, group, pl)
 	assert.Equal(t, expErr, err.Error())
 }
 
+func TestExpandPlacementForSet_InstancesPerIsolationGroup(t *testing.T) {
+	deps := newTestDeps(t, &testOpts{})
+	idProvider := deps.idProvider
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	numInstances := int32(2)
+	cluster.Spec.InstancesPerIsolationGroup = &numInstances
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", numInstances)
+	require.NoError(t, err)
+
+	pods := podsForClusterSet(cluster, set, 2)
+	group := cluster.Spec.IsolationGroups[0]
+	identifyPods(idProvider, pods, nil)
+
+	// The group's numInstances is overridden, so the set is at capacity.
+	pl := placementFromPods(t, cluster, pods, idProvider)
+	err = controller.expandPlacementForSet(context.Background(), cluster, set, group, pl)
+	assert.NoError(t, err)
+}
+
 func TestShrinkPlacementForSet(t *testing.T) {
 	cluster := getFixture("cluster-3-zones.yaml", t)
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	pods := podsForClusterSet(cluster, set, 3)
@@ -545,15 +649,15 @@ func TestShrinkPlacementForSet(t *testing.T) {
 	pl := placementFromPods(t, cluster, pods, deps.idProvider)
 
 	// Expect the last pod to be removed.
-	placementMock.EXPECT().Remove(`{"name":"cluster-zones-rep0-2","uid":"2"}`)
-	err = controller.shrinkPlacementForSet(cluster, set, pl)
+	placementMock.EXPECT().Remove(gomock.Any(), `{"name":"cluster-zones-rep0-2","uid":"2"}`)
+	err = controller.shrinkPlacementForSet(context.Background(), cluster, set, pl)
 	assert.NoError(t, err)
 
 	// If there are more pods in the set then in the placement, we expect the last
 	// in the set to be removed.
 	pl = placementFromPods(t, cluster, pods[:2], deps.idProvider)
-	placementMock.EXPECT().Remove(`{"name":"cluster-zones-rep0-1","uid":"1"}`)
-	err = controller.shrinkPlacementForSet(cluster, set, pl)
+	placementMock.EXPECT().Remove(gomock.Any(), `{"name":"cluster-zones-rep0-1","uid":"1"}`)
+	err = controller.shrinkPlacementForSet(context.Background(), cluster, set, pl)
 	assert.NoError(t, err)
 }
 
@@ -576,9 +680,9 @@ func TestValidatePlacementWithStatus(t *testing.T) {
 
 	controller := deps.newController(t)
 
-	placementMock.EXPECT().Get().AnyTimes()
+	placementMock.EXPECT().Get(gomock.Any()).AnyTimes()
 
-	clusterReturn, err := controller.validatePlacementWithStatus(cluster)
+	clusterReturn, err := controller.validatePlacementWithStatus(context.Background(), cluster)
 
 	require.NoError(t, err)
 	require.NotNil(t, clusterReturn)
@@ -607,7 +711,7 @@ func (p placementInstancesMatcher) String() string {
 
 func TestValidatePlacementWithStatus_ErrNotFound(t *testing.T) {
 	cluster := getFixture("cluster-3-zones.yaml", t)
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 	set.Status.ReadyReplicas = 3
 	pods := podsForClusterSet(cluster, set, 3)
@@ -632,10 +736,10 @@ func TestValidatePlacementWithStatus_ErrNotFound(t *testing.T) {
 	matcher := placementInstancesMatcher{
 		instanceNames: expInsts,
 	}
-	placementMock.EXPECT().Get().Return(nil, pkgerrors.Wrap(m3admin.ErrNotFound, "foo"))
-	placementMock.EXPECT().Init(matcher)
+	placementMock.EXPECT().Get(gomock.Any()).Return(nil, pkgerrors.Wrap(m3admin.ErrNotFound, "foo"))
+	placementMock.EXPECT().Init(gomock.Any(), matcher)
 
-	clusterReturn, err := controller.validatePlacementWithStatus(cluster)
+	clusterReturn, err := controller.validatePlacementWithStatus(context.Background(), cluster)
 
 	require.NoError(t, err)
 	require.NotNil(t, clusterReturn)
@@ -735,7 +839,7 @@ func TestCheckPodsForReplacement(t *testing.T) {
 	idProvider := deps.idProvider
 	defer deps.cleanup()
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	// normal pods in the placement
@@ -781,7 +885,7 @@ func TestReplacePodInPlacement(t *testing.T) {
 	idProvider := deps.idProvider
 	defer deps.cleanup()
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	podsForPlacement := podsForClusterSet(cluster, set, 3)
@@ -818,9 +922,9 @@ func TestReplacePodInPlacement(t *testing.T) {
 		Weight:         100,
 	}
 
-	deps.placementClient.EXPECT().Replace(testLeavingInstanceID, expInstance)
+	deps.placementClient.EXPECT().Replace(gomock.Any(), testLeavingInstanceID, expInstance)
 
-	err = controller.replacePodInPlacement(cluster, pl, testLeavingInstanceID, testNewPod)
+	err = controller.replacePodInPlacement(context.Background(), cluster, pl, testLeavingInstanceID, testNewPod)
 	require.NoError(t, err)
 
 }
@@ -835,7 +939,7 @@ func TestReplacePodInPlacementWithError(t *testing.T) {
 	idProvider := deps.idProvider
 	defer deps.cleanup()
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 
 	podsForPlacement := podsForClusterSet(cluster, set, 3)
@@ -854,7 +958,7 @@ func TestReplacePodInPlacementWithError(t *testing.T) {
 		},
 	}
 
-	err = controller.replacePodInPlacement(cluster, pl, "dummy-id", badPod)
+	err = controller.replacePodInPlacement(context.Background(), cluster, pl, "dummy-id", badPod)
 	require.Error(t, err)
 
 	// error setting bootstrapping
@@ -870,7 +974,7 @@ func TestReplacePodInPlacementWithError(t *testing.T) {
 
 	idProvider.EXPECT().Identity(newPodNameMatcher(okPod.Name, okPod.UID), gomock.Any()).Return(identityForPod(okPod), nil).MaxTimes(2)
 
-	err = controller.replacePodInPlacement(badCluster, pl, "dummy-id", okPod)
+	err = controller.replacePodInPlacement(context.Background(), badCluster, pl, "dummy-id", okPod)
 	require.Error(t, err)
 }
 
@@ -902,7 +1006,7 @@ func TestFindPodInPlacement(t *testing.T) {
 			idProvider := deps.idProvider
 			defer deps.cleanup()
 
-			set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+			set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 			require.NoError(t, err)
 			pods := podsForClusterSet(cluster, set, 3)
 			identifyPods(idProvider, pods, &identifyPodOptions{doErr: test.doErr})
@@ -939,7 +1043,7 @@ func TestFindPodToRemove(t *testing.T) {
 	idProvider := deps.idProvider
 	defer deps.cleanup()
 
-	set, err := k8sops.GenerateStatefulSet(cluster, "us-fake1-a", 3)
+	set, err := k8sops.GenerateStatefulSet(cluster, k8sops.StatefulSetName(cluster.Name, 0), "us-fake1-a", 3)
 	require.NoError(t, err)
 	pods := podsForClusterSet(cluster, set, 3)
 	identifyPods(idProvider, pods, nil)
@@ -1039,6 +1143,36 @@ func TestEtcdFinalizer(t *testing.T) {
 	assert.Equal(t, []string{"foo"}, cluster2.Finalizers)
 }
 
+func TestEnsureDefaults(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+	deps := newTestDeps(t, &testOpts{
+		crdObjects: []runtime.Object{cluster},
+	})
+
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	cluster2, err := controller.ensureDefaults(cluster.DeepCopy())
+	require.NoError(t, err)
+	assert.Equal(t, "1", cluster2.Annotations[annotations.DefaultsVersion])
+	assert.NotNil(t, cluster2.Spec.ProbeOptions)
+
+	stored, err := deps.crdClient.OperatorV1alpha1().M3DBClusters(cluster.Namespace).Get(cluster.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, cluster2.Spec, stored.Spec)
+
+	// A defaulted cluster isn't updated again.
+	controller.crdClient.(*crdfake.Clientset).PrependReactor("update", "m3dbclusters", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("test")
+	})
+	_, err = controller.ensureDefaults(cluster2.DeepCopy())
+	assert.NoError(t, err)
+
+	cluster2.Annotations[annotations.DefaultsVersion] = "1000"
+	_, err = controller.ensureDefaults(cluster2.DeepCopy())
+	assert.Error(t, err)
+}
+
 func TestDeletePlacement(t *testing.T) {
 	cluster := getFixture("cluster-simple.yaml", t)
 
@@ -1048,22 +1182,22 @@ func TestDeletePlacement(t *testing.T) {
 	controller := deps.newController(t)
 	defer deps.cleanup()
 
-	deps.placementClient.EXPECT().Get().Return(nil, errors.New("TEST"))
-	err := controller.deletePlacement(cluster)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(nil, errors.New("TEST"))
+	err := controller.deletePlacement(context.Background(), cluster)
 	assert.EqualError(t, pkgerrors.Cause(err), "TEST")
 
-	deps.placementClient.EXPECT().Get().Return(nil, m3admin.ErrNotFound)
-	err = controller.deletePlacement(cluster)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(nil, m3admin.ErrNotFound)
+	err = controller.deletePlacement(context.Background(), cluster)
 	assert.NoError(t, err)
 
-	deps.placementClient.EXPECT().Get().Return(placement.NewPlacement(), nil)
-	deps.placementClient.EXPECT().Delete().Return(errors.New("TEST2"))
-	err = controller.deletePlacement(cluster)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(placement.NewPlacement(), nil)
+	deps.placementClient.EXPECT().Delete(gomock.Any()).Return(errors.New("TEST2"))
+	err = controller.deletePlacement(context.Background(), cluster)
 	assert.EqualError(t, pkgerrors.Cause(err), "TEST2")
 
-	deps.placementClient.EXPECT().Get().Return(placement.NewPlacement(), nil)
-	deps.placementClient.EXPECT().Delete().Return(nil)
-	err = controller.deletePlacement(cluster)
+	deps.placementClient.EXPECT().Get(gomock.Any()).Return(placement.NewPlacement(), nil)
+	deps.placementClient.EXPECT().Delete(gomock.Any()).Return(nil)
+	err = controller.deletePlacement(context.Background(), cluster)
 	assert.NoError(t, err)
 }
 
@@ -1086,20 +1220,20 @@ func TestDeleteAllNamespaces(t *testing.T) {
 		controller := deps.newController(t)
 		defer deps.cleanup()
 
-		deps.namespaceClient.EXPECT().List().Return(nil, errors.New("TEST"))
-		err := controller.deleteAllNamespaces(cluster)
+		deps.namespaceClient.EXPECT().List(gomock.Any()).Return(nil, errors.New("TEST"))
+		err := controller.deleteAllNamespaces(context.Background(), cluster)
 		assert.EqualError(t, pkgerrors.Cause(err), "TEST")
 
-		deps.namespaceClient.EXPECT().List().Return(&admin.NamespaceGetResponse{}, nil)
-		err = controller.deleteAllNamespaces(cluster)
+		deps.namespaceClient.EXPECT().List(gomock.Any()).Return(&admin.NamespaceGetResponse{}, nil)
+		err = controller.deleteAllNamespaces(context.Background(), cluster)
 		assert.EqualError(t, pkgerrors.Cause(err), errNilNamespaceRegistry.Error())
 
-		deps.namespaceClient.EXPECT().List().Return(testResp, nil)
+		deps.namespaceClient.EXPECT().List(gomock.Any()).Return(testResp, nil)
 		// Because of map iteration order, delete("ns2") may be called and stop
 		// execution before delete("ns1") is called.
-		deps.namespaceClient.EXPECT().Delete("ns1").AnyTimes().Return(nil)
-		deps.namespaceClient.EXPECT().Delete("ns2").Return(errors.New("TEST"))
-		err = controller.deleteAllNamespaces(cluster)
+		deps.namespaceClient.EXPECT().Delete(gomock.Any(), "ns1").AnyTimes().Return(nil)
+		deps.namespaceClient.EXPECT().Delete(gomock.Any(), "ns2").Return(errors.New("TEST"))
+		err = controller.deleteAllNamespaces(context.Background(), cluster)
 		assert.EqualError(t, pkgerrors.Cause(err), "TEST")
 	})
 
@@ -1111,10 +1245,10 @@ func TestDeleteAllNamespaces(t *testing.T) {
 		controller := deps.newController(t)
 		defer deps.cleanup()
 
-		deps.namespaceClient.EXPECT().List().Return(testResp, nil)
-		deps.namespaceClient.EXPECT().Delete("ns1").Return(nil)
-		deps.namespaceClient.EXPECT().Delete("ns2").Return(nil)
-		err := controller.deleteAllNamespaces(cluster)
+		deps.namespaceClient.EXPECT().List(gomock.Any()).Return(testResp, nil)
+		deps.namespaceClient.EXPECT().Delete(gomock.Any(), "ns1").Return(nil)
+		deps.namespaceClient.EXPECT().Delete(gomock.Any(), "ns2").Return(nil)
+		err := controller.deleteAllNamespaces(context.Background(), cluster)
 		assert.NoError(t, err)
 	})
 }
@@ -1152,3 +1286,151 @@ func TestStringArrayContains(t *testing.T) {
 		assert.Equal(t, test.found, found, "expected to find %s in %v", test.s, test.arr)
 	}
 }
+
+func TestUpdateStatefulSets(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	// Generate the existing sets from a copy of the cluster running an older
+	// image.
+	oldCluster := cluster.DeepCopy()
+	oldCluster.Spec.Image = "foo/m3dbnode:old"
+
+	var (
+		sets    []*appsv1.StatefulSet
+		objects []runtime.Object
+	)
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(oldCluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, group.NumInstances)
+		require.NoError(t, err)
+		require.NoError(t, annotateSpecHash(set))
+		set.Namespace = cluster.Namespace
+		sets = append(sets, set)
+		objects = append(objects, set)
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objects,
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	isoGroups := cluster.Spec.IsolationGroups
+	for i := range isoGroups {
+		updated, err := controller.updateStatefulSets(cluster, isoGroups, sets)
+		require.NoError(t, err)
+		assert.True(t, updated)
+
+		// Only the i'th set should have been updated.
+		for j, set := range sets {
+			set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(set.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+
+			image := set.Spec.Template.Spec.Containers[0].Image
+			if j <= i {
+				assert.Equal(t, cluster.Spec.Image, image)
+			} else {
+				assert.Equal(t, oldCluster.Spec.Image, image)
+			}
+			sets[j] = set
+		}
+	}
+
+	updated, err := controller.updateStatefulSets(cluster, isoGroups, sets)
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestUpdateStatefulSetsNoSpecHash(t *testing.T) {
+	cluster := getFixture("cluster-3-zones.yaml", t)
+
+	// Sets created by an operator that didn't record spec hashes, running an
+	// older image.
+	oldCluster := cluster.DeepCopy()
+	oldCluster.Spec.Image = "foo/m3dbnode:old"
+
+	var (
+		sets    []*appsv1.StatefulSet
+		objects []runtime.Object
+	)
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := k8sops.GenerateStatefulSet(oldCluster, k8sops.StatefulSetName(cluster.Name, i), group.Name, group.NumInstances)
+		require.NoError(t, err)
+		set.Namespace = cluster.Namespace
+		sets = append(sets, set)
+		objects = append(objects, set)
+	}
+
+	deps := newTestDeps(t, &testOpts{
+		kubeObjects: objects,
+		crdObjects:  []runtime.Object{cluster},
+	})
+	controller := deps.newController(t)
+	defer deps.cleanup()
+
+	// Every set is annotated with the current spec's hash, and none is rolled
+	// out.
+	updated, err := controller.updateStatefulSets(cluster, cluster.Spec.IsolationGroups, sets)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	for i, group := range cluster.Spec.IsolationGroups {
+		set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(sets[i].Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, oldCluster.Spec.Image, set.Spec.Template.Spec.Containers[0].Image)
+
+		desired, err := k8sops.GenerateStatefulSet(cluster, set.Name, group.Name, group.NumInstances)
+		require.NoError(t, err)
+		hash, err := k8sops.StatefulSetSpecHash(desired)
+		require.NoError(t, err)
+		assert.Equal(t, hash, set.Annotations[annotations.SpecHash])
+		sets[i] = set
+	}
+
+	// Later spec changes are rolled out.
+	cluster.Spec.Image = "foo/m3dbnode:new"
+	updated, err = controller.updateStatefulSets(cluster, cluster.Spec.IsolationGroups, sets)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	set, err := deps.kubeClient.AppsV1().StatefulSets(cluster.Namespace).Get(sets[0].Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, cluster.Spec.Image, set.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestIsStatefulSetRollingOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		meta   metav1.ObjectMeta
+		status appsv1.StatefulSetStatus
+		exp    bool
+	}{
+		{
+			name:   "up to date",
+			meta:   metav1.ObjectMeta{Generation: 2},
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 2, CurrentRevision: "a", UpdateRevision: "a"},
+		},
+		{
+			name:   "generation not observed",
+			meta:   metav1.ObjectMeta{Generation: 3},
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 2, CurrentRevision: "a", UpdateRevision: "a"},
+			exp:    true,
+		},
+		{
+			name:   "revision rolling out",
+			meta:   metav1.ObjectMeta{Generation: 2},
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 2, CurrentRevision: "a", UpdateRevision: "b"},
+			exp:    true,
+		},
+		{
+			name: "no revisions reported",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set := &appsv1.StatefulSet{ObjectMeta: test.meta, Status: test.status}
+			assert.Equal(t, test.exp, isStatefulSetRollingOut(set))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package defaults fills in the defaults of M3DB cluster specs. Defaults are
+// versioned: a cluster records the version of defaults applied to it in an
+// annotation and keeps being defaulted with that version, so that changing a
+// default in a new release of the operator doesn't change existing clusters.
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+// LatestVersion is the version of defaults applied to new clusters.
+const LatestVersion = 1
+
+var (
+	// ErrUnknownVersion is returned when a cluster's defaults version is not
+	// known to this operator.
+	ErrUnknownVersion = errors.New("unknown defaults version")
+
+	// versions maps each version to the function applying its defaults. A
+	// version's defaults must never change once released; add a new version
+	// instead.
+	versions = map[int]func(spec *myspec.ClusterSpec){
+		1: setV1Defaults,
+	}
+)
+
+// Version returns the version of defaults for the cluster, which is the latest
+// version if the cluster doesn't have one yet.
+func Version(cluster *myspec.M3DBCluster) (int, error) {
+	v, ok := cluster.Annotations[annotations.DefaultsVersion]
+	if !ok {
+		return LatestVersion, nil
+	}
+
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, pkgerrors.WithMessagef(ErrUnknownVersion, "version '%s'", v)
+	}
+	if _, ok := versions[version]; !ok {
+		return 0, pkgerrors.WithMessagef(ErrUnknownVersion, "version %d", version)
+	}
+	return version, nil
+}
+
+// SetClusterDefaults fills any unset fields of the cluster's spec with the
+// defaults of its version and records the version on the cluster. It returns
+// true if the cluster was modified.
+func SetClusterDefaults(cluster *myspec.M3DBCluster) (bool, error) {
+	version, err := Version(cluster)
+	if err != nil {
+		return false, err
+	}
+
+	before := cluster.DeepCopy()
+	versions[version](&cluster.Spec)
+
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[annotations.DefaultsVersion] = strconv.Itoa(version)
+
+	return !reflect.DeepEqual(before, cluster), nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package defaults
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCluster() *myspec.M3DBCluster {
+	return &myspec.M3DBCluster{
+		Spec: myspec.ClusterSpec{
+			ReplicationFactor: 2,
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "foo", NumInstances: 3},
+				{Name: "bar"},
+			},
+		},
+	}
+}
+
+func TestSetClusterDefaults(t *testing.T) {
+	cluster := newCluster()
+
+	changed, err := SetClusterDefaults(cluster)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	assert.Equal(t, "1", cluster.Annotations[annotations.DefaultsVersion])
+	assert.Equal(t, v1Image, cluster.Spec.Image)
+	assert.Equal(t, &myspec.PodIdentityConfig{
+		Sources: []myspec.PodIdentitySource{myspec.PodIdentitySourcePodUID},
+	}, cluster.Spec.PodIdentityConfig)
+	assert.Equal(t, &myspec.ProbeOptions{
+		TimeoutSeconds:      30,
+		InitialDelaySeconds: 10,
+		FailureThreshold:    15,
+	}, cluster.Spec.ProbeOptions)
+	assert.Equal(t, int32(3), cluster.Spec.IsolationGroups[0].NumInstances)
+	assert.Equal(t, int32(1), cluster.Spec.IsolationGroups[1].NumInstances)
+
+	// Defaulting is idempotent.
+	changed, err = SetClusterDefaults(cluster)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSetClusterDefaultsKeepsSetFields(t *testing.T) {
+	cluster := newCluster()
+	cluster.Spec.Image = "foo:bar"
+	cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{}
+	cluster.Spec.ProbeOptions = &myspec.ProbeOptions{FailureThreshold: 3}
+
+	_, err := SetClusterDefaults(cluster)
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo:bar", cluster.Spec.Image)
+	assert.Empty(t, cluster.Spec.PodIdentityConfig.Sources)
+	assert.Equal(t, int32(3), cluster.Spec.ProbeOptions.FailureThreshold)
+	assert.Equal(t, int32(30), cluster.Spec.ProbeOptions.TimeoutSeconds)
+}
+
+func TestSetClusterDefaultsUnknownVersion(t *testing.T) {
+	for _, v := range []string{"0", "foo", "1000"} {
+		cluster := newCluster()
+		cluster.ObjectMeta = metav1.ObjectMeta{
+			Annotations: map[string]string{annotations.DefaultsVersion: v},
+		}
+
+		_, err := SetClusterDefaults(cluster)
+		assert.Equal(t, ErrUnknownVersion, pkgerrors.Cause(err))
+		assert.Empty(t, cluster.Spec.Image)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package defaults
+
+import (
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+)
+
+// Version 1 defaults match the behavior of the operator before defaults were
+// written to cluster specs.
+const (
+	v1Image                    = "quay.io/m3db/m3dbnode:latest"
+	v1ProbeTimeoutSeconds      = 30
+	v1ProbeInitialDelaySeconds = 10
+	v1ProbeFailureThreshold    = 15
+	v1NumInstances             = 1
+)
+
+func setV1Defaults(spec *myspec.ClusterSpec) {
+	if spec.Image == "" {
+		spec.Image = v1Image
+	}
+
+	if spec.PodIdentityConfig == nil {
+		spec.PodIdentityConfig = &myspec.PodIdentityConfig{
+			Sources: []myspec.PodIdentitySource{
+				myspec.PodIdentitySourcePodUID,
+			},
+		}
+	}
+
+	if spec.ProbeOptions == nil {
+		spec.ProbeOptions = &myspec.ProbeOptions{}
+	}
+	if spec.ProbeOptions.TimeoutSeconds == 0 {
+		spec.ProbeOptions.TimeoutSeconds = v1ProbeTimeoutSeconds
+	}
+	if spec.ProbeOptions.InitialDelaySeconds == 0 {
+		spec.ProbeOptions.InitialDelaySeconds = v1ProbeInitialDelaySeconds
+	}
+	if spec.ProbeOptions.FailureThreshold == 0 {
+		spec.ProbeOptions.FailureThreshold = v1ProbeFailureThreshold
+	}
+
+	// A group without instances can't hold any replicas of the shards.
+	for i := range spec.IsolationGroups {
+		if spec.IsolationGroups[i].NumInstances == 0 {
+			spec.IsolationGroups[i].NumInstances = v1NumInstances
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var (
+	errNoCACerts   = errors.New("no PEM certificates found in CA bundle")
+	errEmptyHeader = errors.New("admin API header has no name")
+)
+
+// SecretGetter returns the Secret with the given name in the given namespace.
+type SecretGetter func(namespace, name string) (*corev1.Secret, error)
+
+// AdminAPICredentials are the TLS settings and headers of the admin API calls
+// to a cluster, as loaded from the Secrets referenced by its spec.
+type AdminAPICredentials struct {
+	// TLSConfig is nil if the admin API isn't called over HTTPS.
+	TLSConfig *tls.Config
+	Headers   http.Header
+	// Fingerprint identifies the loaded credentials and changes whenever they
+	// do, such as when a Secret is rotated. It is empty if the cluster has no
+	// credentials.
+	Fingerprint string
+}
+
+// AdminAPIUsesTLS returns whether the admin API of a cluster is called over
+// HTTPS.
+func AdminAPIUsesTLS(cluster *myspec.M3DBCluster) bool {
+	return cluster.Spec.AdminAPI != nil && cluster.Spec.AdminAPI.TLS != nil
+}
+
+// LoadAdminAPICredentials reads the Secrets referenced by the admin API spec
+// of a cluster and returns the credentials to call its admin API with.
+func LoadAdminAPICredentials(cluster *myspec.M3DBCluster, getSecret SecretGetter) (AdminAPICredentials, error) {
+	var creds AdminAPICredentials
+	spec := cluster.Spec.AdminAPI
+	if spec == nil {
+		return creds, nil
+	}
+
+	h := fnv.New64a()
+	secretKey := func(sel corev1.SecretKeySelector) ([]byte, error) {
+		secret, err := getSecret(cluster.Namespace, sel.Name)
+		if err != nil {
+			return nil, pkgerrors.WithMessagef(err, "error getting secret '%s'", sel.Name)
+		}
+		value, ok := secret.Data[sel.Key]
+		if !ok {
+			return nil, fmt.Errorf("secret '%s' has no key '%s'", sel.Name, sel.Key)
+		}
+		fmt.Fprintf(h, "%s/%s=", sel.Name, sel.Key)
+		h.Write(value)
+		return value, nil
+	}
+
+	if spec.TLS != nil {
+		creds.TLSConfig = &tls.Config{ServerName: spec.TLS.ServerName}
+		fmt.Fprintf(h, "tls:%s;", spec.TLS.ServerName)
+
+		if sel := spec.TLS.CASecret; sel != nil {
+			caData, err := secretKey(*sel)
+			if err != nil {
+				return creds, err
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(caData) {
+				return creds, pkgerrors.WithMessagef(errNoCACerts, "secret '%s'", sel.Name)
+			}
+			creds.TLSConfig.RootCAs = roots
+		}
+
+		if name := spec.TLS.ClientCertSecret; name != "" {
+			certData, err := secretKey(corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Key:                  corev1.TLSCertKey,
+			})
+			if err != nil {
+				return creds, err
+			}
+			keyData, err := secretKey(corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Key:                  corev1.TLSPrivateKeyKey,
+			})
+			if err != nil {
+				return creds, err
+			}
+			cert, err := tls.X509KeyPair(certData, keyData)
+			if err != nil {
+				return creds, pkgerrors.WithMessagef(err, "error parsing client certificate of secret '%s'", name)
+			}
+			creds.TLSConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	if spec.Auth != nil {
+		creds.Headers = make(http.Header)
+		if sel := spec.Auth.BearerTokenSecret; sel != nil {
+			token, err := secretKey(*sel)
+			if err != nil {
+				return creds, err
+			}
+			creds.Headers.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+
+		for _, header := range spec.Auth.Headers {
+			if header.Name == "" {
+				return creds, errEmptyHeader
+			}
+			value, err := secretKey(header.ValueFrom)
+			if err != nil {
+				return creds, err
+			}
+			fmt.Fprintf(h, "header:%s;", header.Name)
+			creds.Headers.Add(header.Name, strings.TrimSpace(string(value)))
+		}
+	}
+
+	creds.Fingerprint = fmt.Sprintf("%x", h.Sum64())
+	return creds, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate returns a self-signed PEM encoded certificate and key.
+func testCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "m3coordinator"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func secretKeySelector(name, key string) corev1.SecretKeySelector {
+	return corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  key,
+	}
+}
+
+func TestLoadAdminAPICredentials(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	secrets := map[string]*corev1.Secret{
+		"ca": {Data: map[string][]byte{"ca.crt": certPEM}},
+		"client": {Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		}},
+		"token": {Data: map[string][]byte{"token": []byte("foo-token\n")}},
+	}
+	getSecret := func(namespace, name string) (*corev1.Secret, error) {
+		assert.Equal(t, "foo", namespace)
+		secret, ok := secrets[name]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return secret, nil
+	}
+
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	creds, err := LoadAdminAPICredentials(cluster, getSecret)
+	require.NoError(t, err)
+	assert.Nil(t, creds.TLSConfig)
+	assert.Empty(t, creds.Fingerprint)
+
+	caSel := secretKeySelector("ca", "ca.crt")
+	tokenSel := secretKeySelector("token", "token")
+	cluster.Spec.AdminAPI = &myspec.AdminAPISpec{
+		TLS: &myspec.AdminTLSSpec{
+			CASecret:         &caSel,
+			ClientCertSecret: "client",
+			ServerName:       "m3coordinator",
+		},
+		Auth: &myspec.AdminAuthSpec{
+			BearerTokenSecret: &tokenSel,
+			Headers: []myspec.AdminAuthHeader{
+				{Name: "X-Api-Key", ValueFrom: tokenSel},
+			},
+		},
+	}
+
+	creds, err = LoadAdminAPICredentials(cluster, getSecret)
+	require.NoError(t, err)
+	require.NotNil(t, creds.TLSConfig)
+	assert.Equal(t, "m3coordinator", creds.TLSConfig.ServerName)
+	assert.NotNil(t, creds.TLSConfig.RootCAs)
+	assert.Len(t, creds.TLSConfig.Certificates, 1)
+	assert.Equal(t, "Bearer foo-token", creds.Headers.Get("Authorization"))
+	assert.Equal(t, "foo-token", creds.Headers.Get("X-Api-Key"))
+	assert.NotEmpty(t, creds.Fingerprint)
+
+	// Loading the same secrets yields the same fingerprint, rotating one
+	// changes it.
+	again, err := LoadAdminAPICredentials(cluster, getSecret)
+	require.NoError(t, err)
+	assert.Equal(t, creds.Fingerprint, again.Fingerprint)
+
+	secrets["token"] = &corev1.Secret{Data: map[string][]byte{"token": []byte("bar-token")}}
+	rotated, err := LoadAdminAPICredentials(cluster, getSecret)
+	require.NoError(t, err)
+	assert.NotEqual(t, creds.Fingerprint, rotated.Fingerprint)
+	assert.Equal(t, "Bearer bar-token", rotated.Headers.Get("Authorization"))
+}
+
+func TestLoadAdminAPICredentialsErrors(t *testing.T) {
+	secrets := map[string]*corev1.Secret{
+		"ca": {Data: map[string][]byte{"ca.crt": []byte("not a cert")}},
+	}
+	getSecret := func(namespace, name string) (*corev1.Secret, error) {
+		secret, ok := secrets[name]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return secret, nil
+	}
+
+	caSel := secretKeySelector("ca", "ca.crt")
+	missingKeySel := secretKeySelector("ca", "token")
+	missingSel := secretKeySelector("missing", "token")
+	for _, test := range []struct {
+		name string
+		spec *myspec.AdminAPISpec
+	}{
+		{
+			name: "invalid CA bundle",
+			spec: &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{CASecret: &caSel}},
+		},
+		{
+			name: "missing client cert secret",
+			spec: &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{ClientCertSecret: "missing"}},
+		},
+		{
+			name: "missing secret key",
+			spec: &myspec.AdminAPISpec{Auth: &myspec.AdminAuthSpec{BearerTokenSecret: &missingKeySel}},
+		},
+		{
+			name: "missing secret",
+			spec: &myspec.AdminAPISpec{Auth: &myspec.AdminAuthSpec{BearerTokenSecret: &missingSel}},
+		},
+		{
+			name: "header without name",
+			spec: &myspec.AdminAPISpec{Auth: &myspec.AdminAuthSpec{
+				Headers: []myspec.AdminAuthHeader{{ValueFrom: caSel}},
+			}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := getFixture("testM3DBCluster.yaml", t)
+			cluster.Spec.AdminAPI = test.spec
+			_, err := LoadAdminAPICredentials(cluster, getSecret)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCoordinatorURL(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	assert.Equal(t, "http://m3coordinator-m3db-cluster.foo:7201", CoordinatorURL(cluster))
+
+	cluster.Spec.AdminAPI = &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{}}
+	assert.Equal(t, "https://m3coordinator-m3db-cluster.foo:7201", CoordinatorURL(cluster))
+}
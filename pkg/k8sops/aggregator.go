@@ -0,0 +1,239 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"fmt"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+const (
+	_aggregatorImage          = "quay.io/m3db/m3aggregator:latest"
+	_aggregatorNumberOfShards = 64
+
+	_aggregatorConfigurationDirectory    = "/etc/m3aggregator/"
+	_aggregatorConfigurationFileLocation = _aggregatorConfigurationDirectory + _configurationFileName
+
+	// AggregatorHostIDEnvVar is the environment variable aggregators read
+	// their placement instance ID from.
+	AggregatorHostIDEnvVar = "M3AGGREGATOR_HOST_ID"
+)
+
+// AggregatorLabels returns the labels of a cluster's aggregators.
+func AggregatorLabels(cluster *myspec.M3DBCluster) map[string]string {
+	aggLabels := labels.BaseLabels(cluster)
+	aggLabels[labels.Component] = labels.ComponentAggregator
+	return aggLabels
+}
+
+// AggregatorNumberOfShards returns the number of shards of the aggregator
+// placement and topics, using the default if unset.
+func AggregatorNumberOfShards(spec *myspec.AggregatorSpec) int32 {
+	if spec.NumberOfShards == 0 {
+		return _aggregatorNumberOfShards
+	}
+	return spec.NumberOfShards
+}
+
+// GenerateAggregatorStatefulSet creates the aggregator StatefulSet of an
+// isolation group of the cluster's aggregators.
+func GenerateAggregatorStatefulSet(
+	cluster *myspec.M3DBCluster,
+	isolationGroupName string,
+	instanceAmount int32,
+) (*appsv1.StatefulSet, error) {
+	if cluster.Name == "" {
+		return nil, errEmptyClusterName
+	}
+
+	aggregator := cluster.Spec.Aggregator
+	if aggregator == nil {
+		return nil, errNoAggregator
+	}
+
+	stsID := -1
+	var isolationGroup myspec.IsolationGroup
+	for i, g := range aggregator.IsolationGroups {
+		if g.Name == isolationGroupName {
+			isolationGroup = g
+			stsID = i
+			break
+		}
+	}
+
+	if stsID == -1 {
+		return nil, fmt.Errorf("could not find aggregator isogroup '%s' in spec", isolationGroupName)
+	}
+
+	affinity, err := GenerateStatefulSetAffinity(isolationGroup)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "error generating statefulset affinity")
+	}
+
+	configVol, configVolMount, err := buildAggregatorConfigMapComponents(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	image := aggregator.Image
+	if image == "" {
+		image = _aggregatorImage
+	}
+
+	ssName := AggregatorStatefulSetName(cluster.Name, stsID)
+
+	objLabels := AggregatorLabels(cluster)
+	objLabels[labels.IsolationGroup] = isolationGroupName
+	objLabels[labels.StatefulSet] = ssName
+
+	objAnnotations := annotations.BaseAnnotations(cluster)
+
+	probeOpts := clusterProbeOptions(cluster)
+	probe := &v1.Probe{
+		TimeoutSeconds:      probeOpts.TimeoutSeconds,
+		InitialDelaySeconds: probeOpts.InitialDelaySeconds,
+		FailureThreshold:    probeOpts.FailureThreshold,
+		Handler: v1.Handler{
+			HTTPGet: &v1.HTTPGetAction{
+				Port:   intstr.FromInt(PortM3AggregatorHTTP),
+				Path:   _probePathHealth,
+				Scheme: v1.URISchemeHTTP,
+			},
+		},
+	}
+
+	containerPorts := []v1.ContainerPort{}
+	for _, p := range baseAggregatorPorts {
+		containerPorts = append(containerPorts, v1.ContainerPort{
+			Name:          p.name,
+			ContainerPort: int32(p.port),
+			Protocol:      p.protocol,
+		})
+	}
+
+	ownerRef := GenerateOwnerRef(cluster)
+	replicas := instanceAmount
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ssName,
+			Labels:          objLabels,
+			Annotations:     objAnnotations,
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: AggregatorServiceName(cluster.Name),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: objLabels,
+			},
+			Replicas: &replicas,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: objLabels,
+				},
+				Spec: v1.PodSpec{
+					Affinity:          affinity,
+					PriorityClassName: cluster.Spec.PriorityClassName,
+					SecurityContext:   cluster.Spec.PodSecurityContext,
+					Tolerations:       cluster.Spec.Tolerations,
+					Containers: []v1.Container{
+						{
+							Name:            "m3aggregator",
+							SecurityContext: cluster.Spec.SecurityContext,
+							ReadinessProbe:  probe,
+							LivenessProbe:   probe,
+							Command: []string{
+								"m3aggregator",
+							},
+							Args: []string{
+								"-f",
+								_aggregatorConfigurationFileLocation,
+							},
+							Image:           image,
+							ImagePullPolicy: "Always",
+							Resources:       aggregator.ContainerResources,
+							Ports:           containerPorts,
+							Env: []v1.EnvVar{
+								{
+									Name: AggregatorHostIDEnvVar,
+									ValueFrom: &v1.EnvVarSource{
+										FieldRef: &v1.ObjectFieldSelector{
+											FieldPath: "metadata.name",
+										},
+									},
+								},
+							},
+							VolumeMounts: []v1.VolumeMount{
+								configVolMount,
+								{
+									Name:      "cache",
+									MountPath: "/var/lib/m3kv/",
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						configVol,
+						{
+							Name: "cache",
+							VolumeSource: v1.VolumeSource{
+								EmptyDir: &v1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// GenerateAggregatorService creates the headless service giving the cluster's
+// aggregator pods stable addresses.
+func GenerateAggregatorService(cluster *myspec.M3DBCluster) (*v1.Service, error) {
+	if cluster.Name == "" {
+		return nil, errEmptyClusterName
+	}
+
+	svcLabels := AggregatorLabels(cluster)
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        AggregatorServiceName(cluster.Name),
+			Labels:      svcLabels,
+			Annotations: annotations.BaseAnnotations(cluster),
+		},
+		Spec: v1.ServiceSpec{
+			Selector:  svcLabels,
+			Ports:     buildServicePorts(baseAggregatorPorts[:]),
+			ClusterIP: v1.ClusterIPNone,
+			Type:      v1.ServiceTypeClusterIP,
+		},
+	}, nil
+}
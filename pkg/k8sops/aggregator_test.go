@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAggregatorSpec() *myspec.AggregatorSpec {
+	return &myspec.AggregatorSpec{
+		ReplicationFactor: 2,
+		IsolationGroups: []myspec.IsolationGroup{
+			{
+				Name:         "a",
+				NumInstances: 2,
+				NodeAffinityTerms: []myspec.NodeAffinityTerm{
+					{Key: "zone", Values: []string{"a"}},
+				},
+			},
+			{Name: "b", NumInstances: 2},
+		},
+	}
+}
+
+func TestGenerateAggregatorStatefulSet(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	_, err := GenerateAggregatorStatefulSet(cluster, "a", 2)
+	assert.Equal(t, errNoAggregator, err)
+
+	cluster.Spec.Aggregator = newTestAggregatorSpec()
+	_, err = GenerateAggregatorStatefulSet(cluster, "c", 2)
+	assert.Error(t, err)
+
+	sts, err := GenerateAggregatorStatefulSet(cluster, "b", 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, "m3aggregator-m3db-cluster-rep1", sts.Name)
+	assert.Equal(t, "m3aggregator-m3db-cluster", sts.Spec.ServiceName)
+	assert.Equal(t, "m3db-cluster", sts.OwnerReferences[0].Name)
+	assert.Equal(t, int32(2), *sts.Spec.Replicas)
+	assert.Equal(t, labels.ComponentAggregator, sts.Labels[labels.Component])
+	assert.Equal(t, "b", sts.Labels[labels.IsolationGroup])
+	assert.Equal(t, sts.Spec.Selector.MatchLabels, sts.Spec.Template.Labels)
+	assert.Nil(t, sts.Spec.Template.Spec.Affinity)
+
+	container := sts.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "quay.io/m3db/m3aggregator:latest", container.Image)
+	assert.Equal(t, []string{"-f", "/etc/m3aggregator/m3.yml"}, container.Args)
+	assert.Equal(t, "m3aggregator-config-map-m3db-cluster", sts.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+	assert.Equal(t, []corev1.EnvVar{
+		{
+			Name: "M3AGGREGATOR_HOST_ID",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+	}, container.Env)
+
+	cluster.Spec.Aggregator.Image = "m3aggregator:foo"
+	cluster.Spec.Aggregator.ConfigMapName = pointer.StringPtr("my-config")
+	sts, err = GenerateAggregatorStatefulSet(cluster, "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "m3aggregator-m3db-cluster-rep0", sts.Name)
+	assert.NotNil(t, sts.Spec.Template.Spec.Affinity)
+	assert.Equal(t, "m3aggregator:foo", sts.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "my-config", sts.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+}
+
+func TestGenerateAggregatorService(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	svc, err := GenerateAggregatorService(cluster)
+	require.NoError(t, err)
+	assert.Equal(t, "m3aggregator-m3db-cluster", svc.Name)
+	assert.Equal(t, corev1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Equal(t, AggregatorLabels(cluster), svc.Spec.Selector)
+	assert.Len(t, svc.Spec.Ports, 3)
+}
+
+func TestAggregatorNumberOfShards(t *testing.T) {
+	spec := newTestAggregatorSpec()
+	assert.Equal(t, int32(64), AggregatorNumberOfShards(spec))
+	spec.NumberOfShards = 16
+	assert.Equal(t, int32(16), AggregatorNumberOfShards(spec))
+}
@@ -34,6 +34,27 @@ const (
 	AppM3DB = labels.AppM3DB
 	// Cluster is the label identifying what m3db cluster an object is a part of.
 	Cluster = labels.Cluster
+	// SpecHash is a hash of the operator-generated spec of an object, used to
+	// detect when the object needs to be updated to match its cluster.
+	SpecHash = "operator.m3db.io/spec-hash"
+	// ConfigHash is a hash of the operator-rendered config of a pod, used to
+	// roll pods when their config changes.
+	ConfigHash = "operator.m3db.io/config-hash"
+	// DefaultsVersion is set on clusters to the version of defaults that have
+	// been applied to their spec.
+	DefaultsVersion = "operator.m3db.io/defaults-version"
+	// RestoredFrom is set on clusters created by a restore to the name of the
+	// M3DBRestore.
+	RestoredFrom = "operator.m3db.io/restored-from"
+	// RestoreSeeding is set on a restored cluster to the name of its
+	// M3DBRestore while the restore seeds its volumes. The cluster isn't
+	// reconciled while it's set, the restore stops its pods and starts them
+	// again once their volumes are seeded.
+	RestoreSeeding = "operator.m3db.io/restore-seeding"
+	// MetadataRestoring is set on a cluster to the version of the metadata
+	// snapshot its lost placement and namespaces are being restored from, so
+	// that an interrupted restore is resumed with the same snapshot.
+	MetadataRestoring = "operator.m3db.io/metadata-restoring"
 )
 
 // BaseAnnotations returns the base annotations we apply to all objects
@@ -0,0 +1,471 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubernetes/utils/pointer"
+)
+
+const (
+	_backupImage             = "minio/mc:latest"
+	_backupJobBackoffLimit   = 3
+	_backupManifestKey       = "manifest.json"
+	_backupManifestDirectory = "/etc/m3db-backup/"
+	_backupManifestVolume    = "manifest"
+
+	// The keys of the object store's credentials in their secret.
+	_storeAccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	_storeSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+var (
+	errNoBackupPod   = errors.New("pod is not scheduled to a node")
+	errNoRestoreData = errors.New("no shards to restore")
+)
+
+// The scripts run by backup and restore jobs. Each configures the mc alias
+// "store" from the environment, then copies data between the mounted volume
+// and the backup's prefix in the store.
+const (
+	_storeAliasScript = `set -eu
+export MC_HOST_store="${STORE_SCHEME}://${AWS_ACCESS_KEY_ID}:${AWS_SECRET_ACCESS_KEY}@${STORE_ENDPOINT}"
+`
+
+	// Nodes keep writing while they're backed up, so only complete filesets,
+	// those with a checkpoint file, are uploaded. The checkpoint is uploaded
+	// last: a fileset the node removes while it's uploaded is left without one
+	// and is ignored once restored. Other upload errors fail the job.
+	_backupNodeScript = _storeAliasScript + `upload() {
+  if ! mc cp --quiet "$1" "store/${BACKUP_PREFIX}/nodes/${SOURCE_POD}/$1"; then
+    [ ! -e "$1" ] || exit 1
+    return 1
+  fi
+}
+cd /var/lib/m3db
+find data snapshots -name '*-checkpoint.db' 2>/dev/null | while read -r checkpoint; do
+  prefix="${checkpoint%-checkpoint.db}"
+  complete=true
+  for file in "${prefix}.db" "${prefix}"-*.db; do
+    if [ "${file}" = "${checkpoint}" ] || [ ! -e "${file}" ]; then
+      continue
+    fi
+    upload "${file}" || complete=false
+  done
+  if [ "${complete}" = true ]; then
+    upload "${checkpoint}" || true
+  fi
+done
+`
+
+	_backupManifestScript = _storeAliasScript + `mc cp /etc/m3db-backup/manifest.json "store/${BACKUP_PREFIX}/manifest.json"
+`
+
+	// RESTORE_SHARDS lists the shards to download as source-pod:shard pairs.
+	// Whatever the node wrote to the shards before it was stopped is replaced.
+	// Commit logs hold the writes of every shard. They're dropped so that they
+	// aren't replayed over the restored data, but only if RESTORE_FULL_NODE
+	// says every shard the node owns is restored.
+	_restoreNodeScript = _storeAliasScript + `if [ "${RESTORE_FULL_NODE}" = true ]; then
+  rm -f /var/lib/m3db/commitlogs/*
+fi
+for entry in ${RESTORE_SHARDS}; do
+  src="${entry%%:*}"
+  shard="${entry##*:}"
+  for ns in ${RESTORE_NAMESPACES}; do
+    for dir in data snapshots; do
+      from="store/${BACKUP_PREFIX}/nodes/${src}/${dir}/${ns}/${shard}"
+      to="/var/lib/m3db/${dir}/${ns}/${shard}"
+      rm -rf "${to}"
+      if [ -n "$(mc ls "${from}/")" ]; then
+        mc mirror "${from}" "${to}"
+      fi
+    done
+  done
+done
+`
+)
+
+// BackupManifest records what a backup holds: the spec of the backed up
+// cluster, its namespaces and the shards each of its nodes owned. Each node's
+// data is stored under nodes/<pod name> in the backup's prefix.
+type BackupManifest struct {
+	// Cluster and Namespace identify the backed up cluster.
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+
+	// CreatedAt is the time the backup started.
+	CreatedAt string `json:"createdAt"`
+
+	// Spec is the spec of the backed up cluster.
+	Spec myspec.ClusterSpec `json:"spec"`
+
+	// Namespaces are the names of the cluster's M3DB namespaces.
+	Namespaces []string `json:"namespaces"`
+
+	// Instances are the cluster's placement instances.
+	Instances []BackupInstance `json:"instances"`
+}
+
+// BackupInstance is a placement instance of a backed up cluster.
+type BackupInstance struct {
+	ID             string   `json:"id"`
+	PodName        string   `json:"podName"`
+	IsolationGroup string   `json:"isolationGroup"`
+	Shards         []uint32 `json:"shards"`
+}
+
+// ShardSource is a shard of a restored node and the backed up node it's
+// restored from.
+type ShardSource struct {
+	PodName string
+	Shard   uint32
+}
+
+// BackupPrefix returns the path of a backup in its object store, including
+// the bucket.
+func BackupPrefix(backup *myspec.M3DBBackup) string {
+	store := backup.Spec.Store
+	return path.Join(store.Bucket, store.Prefix, backup.Namespace, backup.Name)
+}
+
+// BackupLocation returns the URL of a backup in its object store.
+func BackupLocation(backup *myspec.M3DBBackup) string {
+	return "s3://" + BackupPrefix(backup)
+}
+
+// GenerateBackupOwnerRef generates an owner reference to a backup.
+func GenerateBackupOwnerRef(backup *myspec.M3DBBackup) *metav1.OwnerReference {
+	return metav1.NewControllerRef(backup, schema.GroupVersionKind{
+		Group:   myspec.SchemeGroupVersion.Group,
+		Version: myspec.SchemeGroupVersion.Version,
+		Kind:    m3dboperator.BackupResourceKind,
+	})
+}
+
+// GenerateRestoreOwnerRef generates an owner reference to a restore.
+func GenerateRestoreOwnerRef(restore *myspec.M3DBRestore) *metav1.OwnerReference {
+	return metav1.NewControllerRef(restore, schema.GroupVersionKind{
+		Group:   myspec.SchemeGroupVersion.Group,
+		Version: myspec.SchemeGroupVersion.Version,
+		Kind:    m3dboperator.RestoreResourceKind,
+	})
+}
+
+// GenerateBackupManifestConfigMap creates the ConfigMap holding a backup's
+// manifest, which restores read the manifest from.
+func GenerateBackupManifestConfigMap(backup *myspec.M3DBBackup, manifest *BackupManifest) (*corev1.ConfigMap, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            BackupManifestConfigMapName(backup.Name),
+			Namespace:       backup.Namespace,
+			Labels:          backupLabels(backup),
+			OwnerReferences: []metav1.OwnerReference{*GenerateBackupOwnerRef(backup)},
+		},
+		Data: map[string]string{
+			_backupManifestKey: string(data),
+		},
+	}, nil
+}
+
+// ParseBackupManifest returns the manifest held by a backup's manifest
+// ConfigMap.
+func ParseBackupManifest(cm *corev1.ConfigMap) (*BackupManifest, error) {
+	data, ok := cm.Data[_backupManifestKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s has no %s", cm.Name, _backupManifestKey)
+	}
+
+	manifest := &BackupManifest{}
+	if err := json.Unmarshal([]byte(data), manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest of configmap %s: %v", cm.Name, err)
+	}
+	return manifest, nil
+}
+
+// GenerateBackupJob creates the job uploading the data of an M3DB pod, named
+// after the backup and the pod's index among the backed up pods. The job runs
+// on the pod's node so that it can mount the pod's volume alongside it.
+func GenerateBackupJob(backup *myspec.M3DBBackup, pod *corev1.Pod, index int) (*batchv1.Job, error) {
+	if pod.Spec.NodeName == "" {
+		return nil, errNoBackupPod
+	}
+
+	job := newBackupJob(backup, BackupJobName(backup.Name, index), _backupNodeScript)
+	podSpec := &job.Spec.Template.Spec
+	podSpec.NodeName = pod.Spec.NodeName
+	podSpec.Tolerations = pod.Spec.Tolerations
+	podSpec.Volumes = []corev1.Volume{dataClaimVolume(pod.Name, true)}
+
+	container := &podSpec.Containers[0]
+	container.Env = append(container.Env, corev1.EnvVar{Name: "SOURCE_POD", Value: pod.Name})
+	container.VolumeMounts = []corev1.VolumeMount{
+		{Name: _dataVolumeName, MountPath: _dataDirectory, ReadOnly: true},
+	}
+
+	return job, nil
+}
+
+// GenerateBackupManifestJob creates the job uploading a backup's manifest
+// alongside its data.
+func GenerateBackupManifestJob(backup *myspec.M3DBBackup) *batchv1.Job {
+	job := newBackupJob(backup, BackupManifestJobName(backup.Name), _backupManifestScript)
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = []corev1.Volume{
+		{
+			Name: _backupManifestVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: BackupManifestConfigMapName(backup.Name),
+					},
+				},
+			},
+		},
+	}
+	podSpec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+		{Name: _backupManifestVolume, MountPath: _backupManifestDirectory, ReadOnly: true},
+	}
+
+	return job
+}
+
+// GenerateRestoreJob creates the job seeding the volume of a restored M3DB pod
+// with the given shards of the backup's namespaces. Like backup jobs it runs on
+// the pod's node. The job is created paused, with a parallelism of 0, and must
+// only be started once the pod is stopped. fullNode is whether the shards are
+// every shard the pod owns, in which case the node's commit logs are dropped
+// too; otherwise they're kept for the shards that aren't restored.
+func GenerateRestoreJob(
+	restore *myspec.M3DBRestore,
+	backup *myspec.M3DBBackup,
+	pod *corev1.Pod,
+	index int,
+	namespaces []string,
+	shards []ShardSource,
+	fullNode bool,
+) (*batchv1.Job, error) {
+	if pod.Spec.NodeName == "" {
+		return nil, errNoBackupPod
+	}
+	if len(namespaces) == 0 || len(shards) == 0 {
+		return nil, errNoRestoreData
+	}
+
+	entries := make([]string, 0, len(shards))
+	for _, s := range shards {
+		entries = append(entries, fmt.Sprintf("%s:%d", s.PodName, s.Shard))
+	}
+
+	jobLabels := map[string]string{
+		labels.App:       labels.AppM3DB,
+		labels.Cluster:   pod.Labels[labels.Cluster],
+		labels.Component: labels.ComponentRestore,
+		labels.Restore:   restore.Name,
+	}
+
+	image := restore.Spec.Image
+	if image == "" {
+		image = backupImage(backup)
+	}
+
+	job := newStoreJob(RestoreJobName(restore.Name, index), restore.Namespace, jobLabels,
+		GenerateRestoreOwnerRef(restore), image, backup, _restoreNodeScript)
+	job.Spec.Parallelism = pointer.Int32Ptr(0)
+	podSpec := &job.Spec.Template.Spec
+	podSpec.NodeName = pod.Spec.NodeName
+	podSpec.Tolerations = pod.Spec.Tolerations
+	podSpec.Volumes = []corev1.Volume{dataClaimVolume(pod.Name, false)}
+
+	container := &podSpec.Containers[0]
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "RESTORE_SHARDS", Value: strings.Join(entries, " ")},
+		corev1.EnvVar{Name: "RESTORE_NAMESPACES", Value: strings.Join(namespaces, " ")},
+		corev1.EnvVar{Name: "RESTORE_FULL_NODE", Value: strconv.FormatBool(fullNode)},
+	)
+	container.VolumeMounts = []corev1.VolumeMount{
+		{Name: _dataVolumeName, MountPath: _dataDirectory},
+	}
+
+	return job, nil
+}
+
+// RestoreShardSources returns, for each of the given shards of a restored
+// node, a backed up node that owned the shard. Nodes in the same isolation
+// group are preferred, since they're likely to be in the same zone. Shards no
+// backed up node owned are returned separately.
+func RestoreShardSources(manifest *BackupManifest, isoGroup string, shards []uint32) ([]ShardSource, []uint32) {
+	owners := make(map[uint32][]BackupInstance)
+	for _, inst := range manifest.Instances {
+		for _, shard := range inst.Shards {
+			owners[shard] = append(owners[shard], inst)
+		}
+	}
+
+	var (
+		sources []ShardSource
+		missing []uint32
+	)
+	for _, shard := range shards {
+		candidates := owners[shard]
+		if len(candidates) == 0 {
+			missing = append(missing, shard)
+			continue
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			iSame, jSame := candidates[i].IsolationGroup == isoGroup, candidates[j].IsolationGroup == isoGroup
+			if iSame != jSame {
+				return iSame
+			}
+			return candidates[i].PodName < candidates[j].PodName
+		})
+		sources = append(sources, ShardSource{PodName: candidates[0].PodName, Shard: shard})
+	}
+
+	return sources, missing
+}
+
+func backupLabels(backup *myspec.M3DBBackup) map[string]string {
+	return map[string]string{
+		labels.App:       labels.AppM3DB,
+		labels.Cluster:   backup.Spec.ClusterName,
+		labels.Component: labels.ComponentBackup,
+		labels.Backup:    backup.Name,
+	}
+}
+
+func backupImage(backup *myspec.M3DBBackup) string {
+	if backup.Spec.Image != "" {
+		return backup.Spec.Image
+	}
+	return _backupImage
+}
+
+func newBackupJob(backup *myspec.M3DBBackup, name, script string) *batchv1.Job {
+	return newStoreJob(name, backup.Namespace, backupLabels(backup),
+		GenerateBackupOwnerRef(backup), backupImage(backup), backup, script)
+}
+
+// newStoreJob creates a job running the given script against the object store
+// of a backup.
+func newStoreJob(
+	name, namespace string,
+	jobLabels map[string]string,
+	ownerRef *metav1.OwnerReference,
+	image string,
+	backup *myspec.M3DBBackup,
+	script string,
+) *batchv1.Job {
+	store := backup.Spec.Store
+	scheme := "https"
+	if store.Insecure {
+		scheme = "http"
+	}
+
+	// The job's pods don't carry the app label, so that they aren't mistaken
+	// for the cluster's pods.
+	podLabels := make(map[string]string, len(jobLabels))
+	for k, v := range jobLabels {
+		if k != labels.App && k != labels.Cluster {
+			podLabels[k] = v
+		}
+	}
+
+	credentialEnv := func(key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: store.CredentialsSecretName},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			Labels:          jobLabels,
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(_backupJobBackoffLimit),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podLabels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name:    "mc",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", script},
+							Env: []corev1.EnvVar{
+								{Name: "STORE_SCHEME", Value: scheme},
+								{Name: "STORE_ENDPOINT", Value: store.Endpoint},
+								{Name: "BACKUP_PREFIX", Value: BackupPrefix(backup)},
+								credentialEnv(_storeAccessKeyIDKey),
+								credentialEnv(_storeSecretAccessKeyKey),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataClaimVolume(podName string, readOnly bool) corev1.Volume {
+	return corev1.Volume{
+		Name: _dataVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: DataVolumeClaimName(podName),
+				ReadOnly:  readOnly,
+			},
+		},
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBackup() *myspec.M3DBBackup {
+	return &myspec.M3DBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backup-a",
+			Namespace: "ns",
+			UID:       "backup-uid",
+		},
+		Spec: myspec.M3DBBackupSpec{
+			ClusterName: "cluster-a",
+			Store: myspec.ObjectStore{
+				Endpoint:              "minio:9000",
+				Insecure:              true,
+				Bucket:                "backups",
+				Prefix:                "m3db",
+				CredentialsSecretName: "minio-creds",
+			},
+		},
+	}
+}
+
+func newTestBackupPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{labels.Cluster: "cluster-a"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:    "node-1",
+			Tolerations: []corev1.Toleration{{Key: "dedicated", Value: "m3db"}},
+		},
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestBackupLocation(t *testing.T) {
+	backup := newTestBackup()
+	assert.Equal(t, "backups/m3db/ns/backup-a", BackupPrefix(backup))
+	assert.Equal(t, "s3://backups/m3db/ns/backup-a", BackupLocation(backup))
+
+	backup.Spec.Store.Prefix = ""
+	assert.Equal(t, "s3://backups/ns/backup-a", BackupLocation(backup))
+}
+
+func TestGenerateBackupJob(t *testing.T) {
+	backup := newTestBackup()
+	pod := newTestBackupPod("cluster-a-rep0-1")
+
+	job, err := GenerateBackupJob(backup, pod, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, "m3db-backup-backup-a-3", job.Name)
+	assert.Equal(t, "ns", job.Namespace)
+	assert.Equal(t, "M3DBBackup", job.OwnerReferences[0].Kind)
+	assert.Equal(t, "backup-a", job.Labels[labels.Backup])
+	assert.Equal(t, labels.AppM3DB, job.Labels[labels.App])
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "node-1", podSpec.NodeName)
+	assert.Equal(t, pod.Spec.Tolerations, podSpec.Tolerations)
+	assert.Equal(t, "m3db-data-cluster-a-rep0-1", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.True(t, podSpec.Volumes[0].PersistentVolumeClaim.ReadOnly)
+
+	// The job's pods must not be mistaken for the cluster's.
+	assert.NotContains(t, job.Spec.Template.Labels, labels.App)
+	assert.NotContains(t, job.Spec.Template.Labels, labels.Cluster)
+
+	container := podSpec.Containers[0]
+	assert.Equal(t, "minio/mc:latest", container.Image)
+	assert.Equal(t, "http", envValue(container.Env, "STORE_SCHEME"))
+	assert.Equal(t, "minio:9000", envValue(container.Env, "STORE_ENDPOINT"))
+	assert.Equal(t, "backups/m3db/ns/backup-a", envValue(container.Env, "BACKUP_PREFIX"))
+	assert.Equal(t, "cluster-a-rep0-1", envValue(container.Env, "SOURCE_POD"))
+
+	var secretKeys []string
+	for _, e := range container.Env {
+		if e.ValueFrom != nil {
+			assert.Equal(t, "minio-creds", e.ValueFrom.SecretKeyRef.Name)
+			secretKeys = append(secretKeys, e.ValueFrom.SecretKeyRef.Key)
+		}
+	}
+	assert.Equal(t, []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}, secretKeys)
+
+	pod.Spec.NodeName = ""
+	_, err = GenerateBackupJob(backup, pod, 3)
+	assert.Equal(t, errNoBackupPod, err)
+}
+
+func TestBackupManifestConfigMap(t *testing.T) {
+	backup := newTestBackup()
+	manifest := &BackupManifest{
+		Cluster:    "cluster-a",
+		Namespace:  "ns",
+		Spec:       myspec.ClusterSpec{NumberOfShards: 8, ReplicationFactor: 3},
+		Namespaces: []string{"metrics"},
+		Instances: []BackupInstance{
+			{ID: "a", PodName: "cluster-a-rep0-0", IsolationGroup: "group1", Shards: []uint32{0, 1}},
+		},
+	}
+
+	cm, err := GenerateBackupManifestConfigMap(backup, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, "m3db-backup-backup-a-manifest", cm.Name)
+
+	parsed, err := ParseBackupManifest(cm)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, parsed)
+
+	job := GenerateBackupManifestJob(backup)
+	assert.Equal(t, "m3db-backup-backup-a-manifest", job.Name)
+	assert.Equal(t, cm.Name, job.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	delete(cm.Data, "manifest.json")
+	_, err = ParseBackupManifest(cm)
+	assert.Error(t, err)
+}
+
+func TestGenerateRestoreJob(t *testing.T) {
+	backup := newTestBackup()
+	backup.Spec.Image = "my/mc:1"
+	restore := &myspec.M3DBRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore-a", Namespace: "ns"},
+	}
+	pod := newTestBackupPod("cluster-b-rep1-0")
+	shards := []ShardSource{
+		{PodName: "cluster-a-rep1-0", Shard: 1},
+		{PodName: "cluster-a-rep0-0", Shard: 4},
+	}
+
+	job, err := GenerateRestoreJob(restore, backup, pod, 2, []string{"metrics", "agg"}, shards, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, "m3db-restore-restore-a-2", job.Name)
+	assert.Equal(t, "M3DBRestore", job.OwnerReferences[0].Kind)
+	assert.Equal(t, "restore-a", job.Labels[labels.Restore])
+	assert.Equal(t, int32(0), *job.Spec.Parallelism)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "node-1", podSpec.NodeName)
+	assert.Equal(t, "m3db-data-cluster-b-rep1-0", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.False(t, podSpec.Volumes[0].PersistentVolumeClaim.ReadOnly)
+
+	container := podSpec.Containers[0]
+	assert.Equal(t, "my/mc:1", container.Image)
+	assert.Equal(t, "cluster-a-rep1-0:1 cluster-a-rep0-0:4", envValue(container.Env, "RESTORE_SHARDS"))
+	assert.Equal(t, "metrics agg", envValue(container.Env, "RESTORE_NAMESPACES"))
+	assert.Equal(t, "true", envValue(container.Env, "RESTORE_FULL_NODE"))
+
+	// Commit logs are kept when only some of the node's shards are restored.
+	job, err = GenerateRestoreJob(restore, backup, pod, 2, []string{"metrics"}, shards[:1], false)
+	require.NoError(t, err)
+	assert.Equal(t, "false", envValue(job.Spec.Template.Spec.Containers[0].Env, "RESTORE_FULL_NODE"))
+
+	_, err = GenerateRestoreJob(restore, backup, pod, 2, []string{"metrics"}, nil, true)
+	assert.Equal(t, errNoRestoreData, err)
+}
+
+func TestRestoreShardSources(t *testing.T) {
+	manifest := &BackupManifest{
+		Instances: []BackupInstance{
+			{PodName: "a-rep0-0", IsolationGroup: "group1", Shards: []uint32{0, 1}},
+			{PodName: "a-rep1-0", IsolationGroup: "group2", Shards: []uint32{0, 1, 2}},
+			{PodName: "a-rep1-1", IsolationGroup: "group2", Shards: []uint32{1}},
+		},
+	}
+
+	sources, missing := RestoreShardSources(manifest, "group2", []uint32{0, 1, 2, 3})
+	assert.Equal(t, []ShardSource{
+		{PodName: "a-rep1-0", Shard: 0},
+		{PodName: "a-rep1-0", Shard: 1},
+		{PodName: "a-rep1-0", Shard: 2},
+	}, sources)
+	assert.Equal(t, []uint32{3}, missing)
+
+	sources, _ = RestoreShardSources(manifest, "group1", []uint32{1, 2})
+	assert.Equal(t, []ShardSource{
+		{PodName: "a-rep0-0", Shard: 1},
+		{PodName: "a-rep1-0", Shard: 2},
+	}, sources)
+}
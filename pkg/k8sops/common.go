@@ -23,12 +23,20 @@ package k8sops
 import (
 	"fmt"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 const (
 	headlessServicePrefix    = "m3dbnode-"
 	coordinatorServicePrefix = "m3coordinator-"
+	aggregatorServicePrefix  = "m3aggregator-"
+	backupPrefix             = "m3db-backup-"
+	restorePrefix            = "m3db-restore-"
+	metadataPrefix           = "m3db-metadata-"
 )
 
 // StatefulSetName provides a formatted string to use for naming StatefulSets
@@ -36,6 +44,40 @@ func StatefulSetName(clusterName string, stsID int) string {
 	return fmt.Sprintf("%s-rep%d", clusterName, stsID)
 }
 
+// StatefulSetNames maps each of the cluster's isolation groups to the name of
+// its StatefulSet. A group keeps the name of its existing set, found by the
+// set's isolation group label, so renaming, reordering or removing other
+// groups never changes it. Groups without a set are given the lowest unused
+// StatefulSetName, in spec order.
+func StatefulSetNames(cluster *myspec.M3DBCluster, sets []*appsv1.StatefulSet) map[string]string {
+	names := make(map[string]string, len(cluster.Spec.IsolationGroups))
+	used := make(map[string]struct{}, len(sets))
+	for _, set := range sets {
+		used[set.Name] = struct{}{}
+		if group, ok := set.Labels[labels.IsolationGroup]; ok {
+			names[group] = set.Name
+		}
+	}
+
+	next := 0
+	for _, group := range cluster.Spec.IsolationGroups {
+		if _, ok := names[group.Name]; ok {
+			continue
+		}
+
+		for {
+			name := StatefulSetName(cluster.Name, next)
+			next++
+			if _, ok := used[name]; !ok {
+				names[group.Name] = name
+				break
+			}
+		}
+	}
+
+	return names
+}
+
 // HeadlessServiceName returns a name for the cluster's headless service.
 func HeadlessServiceName(clusterName string) string {
 	return headlessServicePrefix + clusterName
@@ -46,6 +88,71 @@ func CoordinatorServiceName(clusterName string) string {
 	return coordinatorServicePrefix + clusterName
 }
 
+// CoordinatorURL returns the in-cluster URL of a cluster's coordinator API,
+// using HTTPS if the cluster's admin API has TLS configured.
+func CoordinatorURL(cluster *myspec.M3DBCluster) string {
+	scheme := "http"
+	if AdminAPIUsesTLS(cluster) {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s.%s:%d", scheme, CoordinatorServiceName(cluster.Name), cluster.Namespace,
+		ClusterPorts(cluster).Coordinator)
+}
+
+// CoordinatorDeploymentName returns a name for the Deployment of a cluster's
+// separate coordinators.
+func CoordinatorDeploymentName(clusterName string) string {
+	return coordinatorServicePrefix + clusterName
+}
+
+// AggregatorServiceName returns a name for the headless service of a cluster's
+// aggregators.
+func AggregatorServiceName(clusterName string) string {
+	return aggregatorServicePrefix + clusterName
+}
+
+// AggregatorStatefulSetName returns a name for the aggregator StatefulSet of
+// the isolation group with the given index.
+func AggregatorStatefulSetName(clusterName string, stsID int) string {
+	return fmt.Sprintf("%s%s-rep%d", aggregatorServicePrefix, clusterName, stsID)
+}
+
+// DataVolumeClaimName returns the name of the data volume claim of an M3DB
+// pod, as created from its StatefulSet's volume claim template.
+func DataVolumeClaimName(podName string) string {
+	return _dataVolumeName + "-" + podName
+}
+
+// BackupManifestConfigMapName returns a name for the ConfigMap holding a
+// backup's manifest.
+func BackupManifestConfigMapName(backupName string) string {
+	return backupPrefix + backupName + "-manifest"
+}
+
+// BackupJobName returns a name for the job uploading the data of a backup's
+// node with the given index.
+func BackupJobName(backupName string, index int) string {
+	return fmt.Sprintf("%s%s-%d", backupPrefix, backupName, index)
+}
+
+// BackupManifestJobName returns a name for the job uploading a backup's
+// manifest.
+func BackupManifestJobName(backupName string) string {
+	return backupPrefix + backupName + "-manifest"
+}
+
+// RestoreJobName returns a name for the job seeding the volume of a restored
+// node with the given index.
+func RestoreJobName(restoreName string, index int) string {
+	return fmt.Sprintf("%s%s-%d", restorePrefix, restoreName, index)
+}
+
+// MetadataSnapshotConfigMapName returns a name for the ConfigMap holding
+// snapshots of a cluster's placement and namespaces.
+func MetadataSnapshotConfigMapName(clusterName string) string {
+	return metadataPrefix + clusterName
+}
+
 // TODO(schallert): should figure out a better way to abstract this other than
 // exposing all of CoreV1()
 func (k *k8sops) Events(namespace string) typedcorev1.EventInterface {
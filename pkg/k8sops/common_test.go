@@ -23,6 +23,12 @@ package k8sops
 import (
 	"testing"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,3 +36,50 @@ func TestCommon(t *testing.T) {
 	ssName := StatefulSetName("testCluster", 1)
 	require.Equal(t, "testCluster-rep1", ssName)
 }
+
+func TestStatefulSetNames(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: myspec.ClusterSpec{
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "c"},
+				{Name: "a"},
+				{Name: "d"},
+				{Name: "b"},
+			},
+		},
+	}
+
+	set := func(name, group string) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{labels.IsolationGroup: group},
+			},
+		}
+	}
+
+	// Group "a" keeps its set even though it's no longer first in the spec, and
+	// the set of the removed group "old" keeps its name reserved.
+	sets := []*appsv1.StatefulSet{
+		set("cluster-rep0", "a"),
+		set("cluster-rep1", "old"),
+		set("cluster-rep3", "b"),
+	}
+
+	require.Equal(t, map[string]string{
+		"a":   "cluster-rep0",
+		"b":   "cluster-rep3",
+		"c":   "cluster-rep2",
+		"d":   "cluster-rep4",
+		"old": "cluster-rep1",
+	}, StatefulSetNames(cluster, sets))
+
+	// Without any sets, names follow the spec order.
+	require.Equal(t, map[string]string{
+		"c": "cluster-rep0",
+		"a": "cluster-rep1",
+		"d": "cluster-rep2",
+		"b": "cluster-rep3",
+	}, StatefulSetNames(cluster, nil))
+}
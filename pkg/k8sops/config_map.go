@@ -22,7 +22,10 @@ package k8sops
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/fnv"
 	"text/template"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
@@ -34,18 +37,55 @@ import (
 )
 
 const (
-	defaultConfigMapTemplateAssetPath = "/default-config.tmpl"
+	defaultConfigMapTemplateAssetPath            = "/default-config.tmpl"
+	defaultCoordinatorConfigMapTemplateAssetPath = "/default-coordinator-config.tmpl"
+	defaultAggregatorConfigMapTemplateAssetPath  = "/default-aggregator-config.tmpl"
 )
 
 var (
 	errConfigMapNonNil    = errors.New("cannot generate configmap when cluster specified one")
 	errEmptyConfigMapName = errors.New("configMap name cannot be empty if non-nil")
 	errEmptyEtcdEndpoits  = errors.New("etcd endpoints cannot be empty with default configmap")
+	errNoCoordinator      = errors.New("cluster does not run a separate coordinator")
+	errNoAggregator       = errors.New("cluster does not run aggregators")
 )
 
 type configData struct {
-	Env       string
+	Env string
+	// Zone is the zone of the M3 KV service and of the etcd cluster holding it.
+	Zone      string
 	Endpoints []string
+	// Ports are the ports of M3DB nodes and coordinators, with defaults filled
+	// in.
+	Ports myspec.PortsSpec
+	// EmbeddedCoordinator is true if M3DB nodes run the coordinator.
+	EmbeddedCoordinator bool
+	// Aggregator is true if the cluster runs aggregators, in which case the
+	// coordinators downsample through them and ingest their output.
+	Aggregator bool
+	// AggregatedNamespaces are the namespaces aggregated metrics are written
+	// to.
+	AggregatedNamespaces []myspec.AggregatedNamespace
+	// AggregatorTopics are the m3msg topics connecting coordinators and
+	// aggregators.
+	AggregatorTopics AggregatorTopics
+}
+
+// AggregatorTopics are the names of the m3msg topics connecting a cluster's
+// coordinators and aggregators.
+type AggregatorTopics struct {
+	// Ingest is the topic coordinators write unaggregated metrics to, consumed
+	// by the aggregators.
+	Ingest string
+	// Aggregated is the topic aggregators flush aggregated metrics to,
+	// consumed by the coordinators.
+	Aggregated string
+}
+
+// DefaultAggregatorTopics are the m3msg topics used by the default configs.
+var DefaultAggregatorTopics = AggregatorTopics{
+	Ingest:     "aggregator_ingest",
+	Aggregated: "aggregated_metrics",
 }
 
 // GenerateDefaultConfigMap creates a ConfigMap for the clusters with the
@@ -59,48 +99,181 @@ func GenerateDefaultConfigMap(cluster *myspec.M3DBCluster) (*corev1.ConfigMap, e
 		return nil, errEmptyEtcdEndpoits
 	}
 
-	hfs, err := fs.New()
-	if err != nil {
-		return nil, err
+	config := &configData{
+		Env:                 DefaultM3ClusterEnvironmentName(cluster),
+		Zone:                ServiceZone(cluster),
+		Ports:               ClusterPorts(cluster),
+		Endpoints:           cluster.Spec.EtcdEndpoints,
+		EmbeddedCoordinator: cluster.Spec.Coordinator == nil,
 	}
 
-	templateData, err := fs.ReadFile(hfs, defaultConfigMapTemplateAssetPath)
+	data, err := renderConfigTemplate(defaultConfigMapTemplateAssetPath, config)
 	if err != nil {
 		return nil, err
 	}
 
-	tmpl, err := template.New("config").Parse(string(templateData))
+	ownerRef := GenerateOwnerRef(cluster)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            defaultConfigMapName(cluster.Name),
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+		Data: map[string]string{
+			_configurationFileName: data,
+		},
+	}
+
+	return cm, nil
+}
+
+// GenerateCoordinatorConfigMap creates a ConfigMap for the cluster's separate
+// coordinators with the default coordinator config.
+func GenerateCoordinatorConfigMap(cluster *myspec.M3DBCluster) (*corev1.ConfigMap, error) {
+	coordinator := cluster.Spec.Coordinator
+	if coordinator == nil {
+		return nil, errNoCoordinator
+	}
+
+	if coordinator.ConfigMapName != nil {
+		return nil, errConfigMapNonNil
+	}
+
+	if len(cluster.Spec.EtcdEndpoints) == 0 {
+		return nil, errEmptyEtcdEndpoits
+	}
+
+	config := &configData{
+		Env:              DefaultM3ClusterEnvironmentName(cluster),
+		Zone:             ServiceZone(cluster),
+		Ports:            ClusterPorts(cluster),
+		Endpoints:        cluster.Spec.EtcdEndpoints,
+		AggregatorTopics: DefaultAggregatorTopics,
+	}
+	if aggregator := cluster.Spec.Aggregator; aggregator != nil {
+		config.Aggregator = true
+		config.AggregatedNamespaces = aggregator.Namespaces
+	}
+
+	data, err := renderConfigTemplate(defaultCoordinatorConfigMapTemplateAssetPath, config)
 	if err != nil {
 		return nil, err
 	}
 
+	ownerRef := GenerateOwnerRef(cluster)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CoordinatorConfigMapName(cluster.Name),
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+		Data: map[string]string{
+			_configurationFileName: data,
+		},
+	}
+
+	return cm, nil
+}
+
+// GenerateAggregatorConfigMap creates a ConfigMap for the cluster's
+// aggregators with the default aggregator config.
+func GenerateAggregatorConfigMap(cluster *myspec.M3DBCluster) (*corev1.ConfigMap, error) {
+	aggregator := cluster.Spec.Aggregator
+	if aggregator == nil {
+		return nil, errNoAggregator
+	}
+
+	if aggregator.ConfigMapName != nil {
+		return nil, errConfigMapNonNil
+	}
+
+	if len(cluster.Spec.EtcdEndpoints) == 0 {
+		return nil, errEmptyEtcdEndpoits
+	}
+
 	config := &configData{
-		Env:       DefaultM3ClusterEnvironmentName(cluster),
-		Endpoints: cluster.Spec.EtcdEndpoints,
+		Env:              DefaultM3ClusterEnvironmentName(cluster),
+		Zone:             ServiceZone(cluster),
+		Ports:            ClusterPorts(cluster),
+		Endpoints:        cluster.Spec.EtcdEndpoints,
+		Aggregator:       true,
+		AggregatorTopics: DefaultAggregatorTopics,
 	}
 
-	buf := &bytes.Buffer{}
-	tmpl.Execute(buf, &config)
+	data, err := renderConfigTemplate(defaultAggregatorConfigMapTemplateAssetPath, config)
+	if err != nil {
+		return nil, err
+	}
 
 	ownerRef := GenerateOwnerRef(cluster)
 
 	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            defaultConfigMapName(cluster.Name),
+			Name:            AggregatorConfigMapName(cluster.Name),
 			OwnerReferences: []metav1.OwnerReference{*ownerRef},
 		},
 		Data: map[string]string{
-			_configurationFileName: buf.String(),
+			_configurationFileName: data,
 		},
 	}
 
 	return cm, nil
 }
 
+// ConfigMapDataHash returns a hash of the data of a configmap, set on the pod
+// templates mounting it so that pods roll when the config changes.
+func ConfigMapDataHash(cm *corev1.ConfigMap) (string, error) {
+	data, err := json.Marshal(cm.Data)
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
+
+// renderConfigTemplate renders a config template from the registered assets.
+func renderConfigTemplate(assetPath string, config *configData) (string, error) {
+	hfs, err := fs.New()
+	if err != nil {
+		return "", err
+	}
+
+	templateData, err := fs.ReadFile(hfs, assetPath)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("config").Parse(string(templateData))
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, config); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 func defaultConfigMapName(clusterName string) string {
 	return "m3db-config-map-" + clusterName
 }
 
+// CoordinatorConfigMapName returns the name of the default configmap of a
+// cluster's separate coordinators.
+func CoordinatorConfigMapName(clusterName string) string {
+	return "m3coordinator-config-map-" + clusterName
+}
+
+// AggregatorConfigMapName returns the name of the default configmap of a
+// cluster's aggregators.
+func AggregatorConfigMapName(clusterName string) string {
+	return "m3aggregator-config-map-" + clusterName
+}
+
 // DefaultM3ClusterEnvironmentName returns the environment under which cluster
 // topology and runtime configuration will be stored. This ensures that multiple
 // m3db clusters won't conflict with each other when sharing a backing etcd
@@ -141,3 +314,64 @@ func buildConfigMapComponents(cluster *myspec.M3DBCluster) (corev1.Volume, corev
 
 	return vol, vm, nil
 }
+
+// buildCoordinatorConfigMapComponents builds the config volume and volumeMount
+// of the cluster's separate coordinators, mounting the user's configMap if
+// they specified one or the default one otherwise.
+func buildCoordinatorConfigMapComponents(cluster *myspec.M3DBCluster) (corev1.Volume, corev1.VolumeMount, error) {
+	vm := corev1.VolumeMount{
+		Name:      _configurationName,
+		MountPath: _coordinatorConfigurationDirectory,
+	}
+
+	cmName := CoordinatorConfigMapName(cluster.Name)
+	if name := cluster.Spec.Coordinator.ConfigMapName; name != nil {
+		cmName = *name
+	}
+
+	if cmName == "" {
+		return corev1.Volume{}, corev1.VolumeMount{}, errEmptyConfigMapName
+	}
+
+	vol := corev1.Volume{
+		Name: _configurationName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: cmName,
+				},
+			},
+		},
+	}
+
+	return vol, vm, nil
+}
+
+func buildAggregatorConfigMapComponents(cluster *myspec.M3DBCluster) (corev1.Volume, corev1.VolumeMount, error) {
+	vm := corev1.VolumeMount{
+		Name:      _configurationName,
+		MountPath: _aggregatorConfigurationDirectory,
+	}
+
+	cmName := AggregatorConfigMapName(cluster.Name)
+	if name := cluster.Spec.Aggregator.ConfigMapName; name != nil {
+		cmName = *name
+	}
+
+	if cmName == "" {
+		return corev1.Volume{}, corev1.VolumeMount{}, errEmptyConfigMapName
+	}
+
+	vol := corev1.Volume{
+		Name: _configurationName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: cmName,
+				},
+			},
+		},
+	}
+
+	return vol, vm, nil
+}
@@ -26,6 +26,8 @@ import (
 	"strings"
 	"testing"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/kubernetes/utils/pointer"
@@ -38,20 +40,26 @@ func registerValidConfigMap() error {
 	sw := &strings.Builder{}
 	zw := zip.NewWriter(sw)
 
-	// Build a zip fs containing our test config map
-	fw, err := zw.Create("default-config.tmpl")
-	if err != nil {
-		return err
+	// Build a zip fs containing our test config maps
+	for _, name := range []string{
+		"default-config.tmpl",
+		"default-coordinator-config.tmpl",
+		"default-aggregator-config.tmpl",
+	} {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile("../../assets/" + name)
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		if err != nil {
+			return err
+		}
 	}
-	data, err := ioutil.ReadFile("../../assets/default-config.tmpl")
-	if err != nil {
-		return err
-	}
-	_, err = fw.Write(data)
-	if err != nil {
-		return err
-	}
-	err = zw.Close()
+	err := zw.Close()
 	if err != nil {
 		return err
 	}
@@ -75,6 +83,103 @@ func TestGenerateDefaultConfigMap(t *testing.T) {
 	assert.Contains(t, data, `env: "foo/m3db-cluster"`)
 	assert.Contains(t, data, `- "ep0"`)
 	assert.Contains(t, data, `- "ep1"`)
+	assert.Contains(t, data, `zone: "embedded"`)
+	assert.True(t, strings.HasPrefix(data, "coordinator:\n"))
+
+	cluster.Spec.ServiceZone = "us-east1"
+	cm, err = GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	data = cm.Data["m3.yml"]
+	assert.Contains(t, data, `zone: "us-east1"`)
+	assert.NotContains(t, data, "embedded")
+
+	// Nodes don't run the coordinator if the cluster runs separate ones.
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	cm, err = GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	data = cm.Data["m3.yml"]
+	assert.True(t, strings.HasPrefix(data, "db:\n"))
+	assert.Contains(t, data, `env: "foo/m3db-cluster"`)
+}
+
+func TestGenerateCoordinatorConfigMap(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	require.NoError(t, registerValidConfigMap())
+
+	_, err := GenerateCoordinatorConfigMap(cluster)
+	assert.Equal(t, errNoCoordinator, err)
+
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	cm, err := GenerateCoordinatorConfigMap(cluster)
+	require.NoError(t, err)
+	assert.Equal(t, "m3coordinator-config-map-m3db-cluster", cm.Name)
+	assert.Equal(t, "m3db-cluster", cm.OwnerReferences[0].Name)
+
+	data := cm.Data["m3.yml"]
+	assert.Contains(t, data, `env: "foo/m3db-cluster"`)
+	assert.Contains(t, data, `- "ep0"`)
+	assert.Contains(t, data, `- "ep1"`)
+	assert.NotContains(t, data, "db:")
+	assert.NotContains(t, data, "downsample:")
+	assert.NotContains(t, data, "ingest:")
+
+	// Coordinators downsample through and ingest from the aggregators.
+	cluster.Spec.Aggregator = &myspec.AggregatorSpec{
+		Namespaces: []myspec.AggregatedNamespace{
+			{Name: "agg", Resolution: "1m", Retention: "720h"},
+		},
+	}
+	cm, err = GenerateCoordinatorConfigMap(cluster)
+	require.NoError(t, err)
+	data = cm.Data["m3.yml"]
+	assert.Contains(t, data, "downsample:")
+	assert.Contains(t, data, `topicName: "aggregator_ingest"`)
+	assert.Contains(t, data, `listenAddress: "0.0.0.0:7507"`)
+	assert.Contains(t, data, `- namespace: "agg"
+        type: aggregated
+        retention: "720h"
+        resolution: "1m"`)
+
+	cluster.Spec.Coordinator.ConfigMapName = pointer.StringPtr("foo")
+	_, err = GenerateCoordinatorConfigMap(cluster)
+	assert.Equal(t, errConfigMapNonNil, err)
+}
+
+func TestGenerateAggregatorConfigMap(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	require.NoError(t, registerValidConfigMap())
+
+	_, err := GenerateAggregatorConfigMap(cluster)
+	assert.Equal(t, errNoAggregator, err)
+
+	cluster.Spec.Aggregator = &myspec.AggregatorSpec{}
+	cm, err := GenerateAggregatorConfigMap(cluster)
+	require.NoError(t, err)
+	assert.Equal(t, "m3aggregator-config-map-m3db-cluster", cm.Name)
+	assert.Equal(t, "m3db-cluster", cm.OwnerReferences[0].Name)
+
+	data := cm.Data["m3.yml"]
+	assert.Contains(t, data, `env: "foo/m3db-cluster"`)
+	assert.Contains(t, data, `- "ep0"`)
+	assert.Contains(t, data, `- "ep1"`)
+	assert.Contains(t, data, `envVarName: M3AGGREGATOR_HOST_ID`)
+	assert.Contains(t, data, `topicName: "aggregator_ingest"`)
+	assert.Contains(t, data, `topicName: "aggregated_metrics"`)
+
+	hash1, err := ConfigMapDataHash(cm)
+	require.NoError(t, err)
+	cluster.Spec.EtcdEndpoints = []string{"ep2"}
+	cm, err = GenerateAggregatorConfigMap(cluster)
+	require.NoError(t, err)
+	hash2, err := ConfigMapDataHash(cm)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash1, hash2)
+
+	cluster.Spec.Aggregator.ConfigMapName = pointer.StringPtr("foo")
+	_, err = GenerateAggregatorConfigMap(cluster)
+	assert.Equal(t, errConfigMapNonNil, err)
 }
 
 func TestGenerateDefaultConfigMap_Err(t *testing.T) {
@@ -0,0 +1,190 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	_coordinatorImage    = "quay.io/m3db/m3coordinator:latest"
+	_coordinatorReplicas = 1
+
+	_coordinatorConfigurationDirectory    = "/etc/m3coordinator/"
+	_coordinatorConfigurationFileLocation = _coordinatorConfigurationDirectory + _configurationFileName
+)
+
+// CoordinatorLabels returns the labels of a cluster's separate coordinators.
+func CoordinatorLabels(cluster *myspec.M3DBCluster) map[string]string {
+	coordLabels := labels.BaseLabels(cluster)
+	coordLabels[labels.Component] = labels.ComponentCoordinator
+	return coordLabels
+}
+
+// GenerateCoordinatorDeployment creates the Deployment of a cluster's separate
+// coordinators.
+func GenerateCoordinatorDeployment(cluster *myspec.M3DBCluster) (*appsv1.Deployment, error) {
+	if cluster.Name == "" {
+		return nil, errEmptyClusterName
+	}
+
+	coordinator := cluster.Spec.Coordinator
+	if coordinator == nil {
+		return nil, errNoCoordinator
+	}
+
+	image := coordinator.Image
+	if image == "" {
+		image = _coordinatorImage
+	}
+
+	replicas := coordinator.Replicas
+	if replicas == 0 {
+		replicas = _coordinatorReplicas
+	}
+
+	configVol, configVolMount, err := buildCoordinatorConfigMapComponents(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	objLabels := CoordinatorLabels(cluster)
+	objAnnotations := annotations.BaseAnnotations(cluster)
+
+	probeOpts := clusterProbeOptions(cluster)
+	probe := &v1.Probe{
+		TimeoutSeconds:      probeOpts.TimeoutSeconds,
+		InitialDelaySeconds: probeOpts.InitialDelaySeconds,
+		FailureThreshold:    probeOpts.FailureThreshold,
+		Handler: v1.Handler{
+			HTTPGet: &v1.HTTPGetAction{
+				Port:   intstr.FromInt(int(ClusterPorts(cluster).Coordinator)),
+				Path:   _probePathHealth,
+				Scheme: v1.URISchemeHTTP,
+			},
+		},
+	}
+
+	containerPorts := buildContainerPorts(coordinatorPorts(cluster))
+
+	ownerRef := GenerateOwnerRef(cluster)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            CoordinatorDeploymentName(cluster.Name),
+			Labels:          objLabels,
+			Annotations:     objAnnotations,
+			OwnerReferences: []metav1.OwnerReference{*ownerRef},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: objLabels,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: objLabels,
+				},
+				Spec: v1.PodSpec{
+					PriorityClassName: cluster.Spec.PriorityClassName,
+					SecurityContext:   cluster.Spec.PodSecurityContext,
+					Tolerations:       cluster.Spec.Tolerations,
+					Containers: []v1.Container{
+						{
+							Name:            "m3coordinator",
+							SecurityContext: cluster.Spec.SecurityContext,
+							ReadinessProbe:  probe,
+							LivenessProbe:   probe,
+							Command: []string{
+								"m3coordinator",
+							},
+							Args: []string{
+								"-f",
+								_coordinatorConfigurationFileLocation,
+							},
+							Image:           image,
+							ImagePullPolicy: "Always",
+							Resources:       coordinator.ContainerResources,
+							Ports:           containerPorts,
+							VolumeMounts: []v1.VolumeMount{
+								configVolMount,
+								{
+									Name:      "cache",
+									MountPath: "/var/lib/m3kv/",
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						configVol,
+						{
+							Name: "cache",
+							VolumeSource: v1.VolumeSource{
+								EmptyDir: &v1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// DeploymentSpecHash returns a hash of the parts of a Deployment that the
+// operator updates in place, like StatefulSetSpecHash does for StatefulSets.
+func DeploymentSpecHash(deployment *appsv1.Deployment) (string, error) {
+	depAnnotations := make(map[string]string, len(deployment.Annotations))
+	for k, v := range deployment.Annotations {
+		if k != annotations.SpecHash {
+			depAnnotations[k] = v
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Labels      map[string]string  `json:"labels"`
+		Annotations map[string]string  `json:"annotations"`
+		Replicas    *int32             `json:"replicas"`
+		Template    v1.PodTemplateSpec `json:"template"`
+	}{
+		Labels:      deployment.Labels,
+		Annotations: depAnnotations,
+		Replicas:    deployment.Spec.Replicas,
+		Template:    deployment.Spec.Template,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kubernetes/utils/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCoordinatorDeployment(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+
+	_, err := GenerateCoordinatorDeployment(cluster)
+	assert.Equal(t, errNoCoordinator, err)
+
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	dep, err := GenerateCoordinatorDeployment(cluster)
+	require.NoError(t, err)
+
+	assert.Equal(t, "m3coordinator-m3db-cluster", dep.Name)
+	assert.Equal(t, "m3db-cluster", dep.OwnerReferences[0].Name)
+	assert.Equal(t, int32(1), *dep.Spec.Replicas)
+	assert.Equal(t, labels.ComponentCoordinator, dep.Spec.Selector.MatchLabels[labels.Component])
+	assert.Equal(t, dep.Spec.Selector.MatchLabels, dep.Spec.Template.Labels)
+
+	container := dep.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "quay.io/m3db/m3coordinator:latest", container.Image)
+	assert.Equal(t, []string{"-f", "/etc/m3coordinator/m3.yml"}, container.Args)
+	assert.Equal(t, "m3coordinator-config-map-m3db-cluster", dep.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("2"),
+		},
+	}
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{
+		Image:              "m3coordinator:foo",
+		Replicas:           3,
+		ConfigMapName:      pointer.StringPtr("my-config"),
+		ContainerResources: resources,
+	}
+	dep, err = GenerateCoordinatorDeployment(cluster)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), *dep.Spec.Replicas)
+	container = dep.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "m3coordinator:foo", container.Image)
+	assert.Equal(t, resources, container.Resources)
+	assert.Equal(t, "my-config", dep.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	cluster.Spec.Coordinator.ConfigMapName = pointer.StringPtr("")
+	_, err = GenerateCoordinatorDeployment(cluster)
+	assert.Equal(t, errEmptyConfigMapName, err)
+}
+
+func TestDeploymentSpecHash(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+
+	dep, err := GenerateCoordinatorDeployment(cluster)
+	require.NoError(t, err)
+	hash1, err := DeploymentSpecHash(dep)
+	require.NoError(t, err)
+
+	cluster.Spec.Coordinator.Replicas = 2
+	dep, err = GenerateCoordinatorDeployment(cluster)
+	require.NoError(t, err)
+	hash2, err := DeploymentSpecHash(dep)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash1, hash2)
+
+	// The hash annotation itself doesn't change the hash.
+	dep.Annotations["operator.m3db.io/spec-hash"] = hash2
+	hash3, err := DeploymentSpecHash(dep)
+	require.NoError(t, err)
+	assert.Equal(t, hash2, hash3)
+}
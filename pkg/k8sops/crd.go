@@ -21,22 +21,101 @@
 package k8sops
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
 
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-func (k *k8sops) CreateOrUpdateCRD(name string, enableValidation bool) error {
-	if name != myspec.Name {
+// CRDOptions configures the CRDs created by the operator.
+type CRDOptions struct {
+	// EnableValidation controls whether OpenAPI validation is enabled on the
+	// CRD.
+	EnableValidation bool
+
+	// StorageVersion is the API version objects are persisted in. Defaults to
+	// v1alpha1; storing objects as v1beta1 requires a conversion webhook.
+	StorageVersion string
+
+	// ConversionWebhook configures the webhook the API server converts objects
+	// between versions with. v1beta1 is only served when it is set.
+	ConversionWebhook *ConversionWebhook
+}
+
+// ConversionWebhook points the API server at the operator's conversion
+// webhook.
+type ConversionWebhook struct {
+	// ServiceNamespace and ServiceName identify the service fronting the
+	// webhook.
+	ServiceNamespace string
+	ServiceName      string
+
+	// Path is the path the webhook is served on.
+	Path string
+
+	// CABundle is the PEM encoded CA bundle used to verify the webhook's
+	// serving certificate.
+	CABundle []byte
+}
+
+func (o CRDOptions) storageVersion() string {
+	if o.StorageVersion == "" {
+		return myspec.Version
+	}
+	return o.StorageVersion
+}
+
+func (o CRDOptions) validate() error {
+	switch o.storageVersion() {
+	case myspec.Version:
+	case myspec.VersionV1beta1:
+		if o.ConversionWebhook == nil {
+			return fmt.Errorf("storage version '%s' requires a conversion webhook", myspec.VersionV1beta1)
+		}
+	default:
+		return fmt.Errorf("unrecognized storage version '%s'", o.StorageVersion)
+	}
+
+	if wh := o.ConversionWebhook; wh != nil && (wh.ServiceNamespace == "" || wh.ServiceName == "") {
+		return errors.New("conversion webhook service namespace and name cannot be empty")
+	}
+
+	return nil
+}
+
+func (k *k8sops) CreateOrUpdateCRD(name string, opts CRDOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	var newCRD *apiextensionsv1beta1.CustomResourceDefinition
+	conversionWebhook := opts.ConversionWebhook
+	switch name {
+	case myspec.Name:
+		newCRD = GenerateCRD(opts)
+	case myspec.NamespaceName:
+		newCRD = GenerateNamespaceCRD(opts)
+	case myspec.BackupName:
+		newCRD = GenerateBackupCRD(opts)
+		// Backups and restores have a single version, nothing to convert.
+		conversionWebhook = nil
+	case myspec.RestoreName:
+		newCRD = GenerateRestoreCRD(opts)
+		conversionWebhook = nil
+	default:
 		return fmt.Errorf("unrecognized CRD name '%s'", name)
 	}
 
@@ -46,19 +125,23 @@ func (k *k8sops) CreateOrUpdateCRD(name string, enableValidation bool) error {
 		return pkgerrors.WithMessagef(err, "could not fetch CRD '%s'", name)
 	}
 
-	newCRD := GenerateCRD(enableValidation)
 	if apierrors.IsNotFound(err) {
 		_, err := crdClient.Create(newCRD)
 		if err != nil {
 			return pkgerrors.WithMessagef(err, "error creating CRD '%s'", name)
 		}
 		k.logger.Info("created CRD", zap.String("name", name))
-		return nil
+		return k.patchCRDConversion(name, conversionWebhook)
 	}
 
-	// CRD exists, update it
-	curCRD.Spec = newCRD.Spec
-	newCRD, err = crdClient.Update(curCRD)
+	// CRD exists, update it. The conversion settings aren't part of the typed
+	// CRD spec, so rather than a typed update that would drop them, the spec is
+	// updated by a single merge patch that carries them.
+	patch, err := crdSpecPatch(curCRD, newCRD.Spec, conversionWebhook)
+	if err != nil {
+		return pkgerrors.WithMessagef(err, "error generating update of CRD '%s'", name)
+	}
+	newCRD, err = crdClient.Patch(name, types.MergePatchType, patch)
 	if err != nil {
 		return pkgerrors.WithMessagef(err, "error updating CRD '%s'", name)
 	}
@@ -72,17 +155,264 @@ func (k *k8sops) CreateOrUpdateCRD(name string, enableValidation bool) error {
 	return k.waitForCRDReady(name)
 }
 
+// crdSpecPatch returns a merge patch updating the spec of a CRD to the given
+// spec and conversion settings. The patch only applies to the version of the
+// CRD it was generated from.
+func crdSpecPatch(
+	cur *apiextensionsv1beta1.CustomResourceDefinition,
+	spec apiextensionsv1beta1.CustomResourceDefinitionSpec,
+	wh *ConversionWebhook,
+) ([]byte, error) {
+	curFields, err := toJSONFields(cur.Spec)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := toJSONFields(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	specPatch := mergePatch(curFields, newFields)
+	if wh != nil {
+		specPatch["conversion"] = newCRDConversion(wh)
+	} else {
+		specPatch["conversion"] = map[string]interface{}{
+			"strategy":            "None",
+			"webhookClientConfig": nil,
+		}
+	}
+
+	patch := map[string]interface{}{"spec": specPatch}
+	if cur.ResourceVersion != "" {
+		patch["metadata"] = map[string]interface{}{"resourceVersion": cur.ResourceVersion}
+	}
+	return json.Marshal(patch)
+}
+
+func toJSONFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// mergePatch returns a JSON merge patch turning cur into updated: fields that
+// changed are set, and fields that were removed are set to null.
+func mergePatch(cur, updated map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for k, v := range updated {
+		curV, ok := cur[k]
+		if !ok {
+			patch[k] = v
+			continue
+		}
+
+		curMap, curIsMap := curV.(map[string]interface{})
+		updatedMap, updatedIsMap := v.(map[string]interface{})
+		if curIsMap && updatedIsMap {
+			if fieldPatch := mergePatch(curMap, updatedMap); len(fieldPatch) > 0 {
+				patch[k] = fieldPatch
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(curV, v) {
+			patch[k] = v
+		}
+	}
+	for k := range cur {
+		if _, ok := updated[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	return patch
+}
+
+// crdConversion mirrors the conversion settings of a CRD spec, which the
+// vendored apiextensions API predates.
+type crdConversion struct {
+	Strategy                 string               `json:"strategy"`
+	WebhookClientConfig      *webhookClientConfig `json:"webhookClientConfig,omitempty"`
+	ConversionReviewVersions []string             `json:"conversionReviewVersions,omitempty"`
+}
+
+type webhookClientConfig struct {
+	Service  *serviceReference `json:"service,omitempty"`
+	CABundle []byte            `json:"caBundle,omitempty"`
+}
+
+type serviceReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+}
+
+// newCRDConversion returns the conversion settings of a CRD converting objects
+// with the given webhook.
+func newCRDConversion(wh *ConversionWebhook) crdConversion {
+	return crdConversion{
+		Strategy: "Webhook",
+		WebhookClientConfig: &webhookClientConfig{
+			Service: &serviceReference{
+				Namespace: wh.ServiceNamespace,
+				Name:      wh.ServiceName,
+				Path:      wh.Path,
+			},
+			CABundle: wh.CABundle,
+		},
+		ConversionReviewVersions: []string{"v1beta1"},
+	}
+}
+
+// patchCRDConversion configures a newly created CRD to convert objects with
+// the given webhook. The conversion settings aren't part of the typed CRD spec
+// the CRD was created from.
+func (k *k8sops) patchCRDConversion(name string, wh *ConversionWebhook) error {
+	if wh == nil {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"conversion": newCRDConversion(wh),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	crdClient := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions()
+	if _, err := crdClient.Patch(name, types.MergePatchType, patch); err != nil {
+		return pkgerrors.WithMessagef(err, "error configuring conversion of CRD '%s'", name)
+	}
+
+	k.logger.Info("configured CRD conversion webhook", zap.String("name", name))
+	return nil
+}
+
+// MigrateCRDStorageVersion rewrites every object of the CRD so that it's
+// persisted in the CRD's current storage version, then drops all other
+// versions from the CRD's stored versions.
+func (k *k8sops) MigrateCRDStorageVersion(name, storageVersion string) error {
+	crdClient := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions()
+	crd, err := crdClient.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return pkgerrors.WithMessagef(err, "could not fetch CRD '%s'", name)
+	}
+
+	stored := crd.Status.StoredVersions
+	if len(stored) == 1 && stored[0] == storageVersion {
+		return nil
+	}
+
+	var rewritten int
+	switch name {
+	case myspec.Name:
+		rewritten, err = k.rewriteClusters()
+	case myspec.NamespaceName:
+		rewritten, err = k.rewriteNamespaces()
+	default:
+		return fmt.Errorf("unrecognized CRD name '%s'", name)
+	}
+	if err != nil {
+		return pkgerrors.WithMessagef(err, "error migrating objects of CRD '%s'", name)
+	}
+
+	crd.Status.StoredVersions = []string{storageVersion}
+	if _, err := crdClient.UpdateStatus(crd); err != nil {
+		return pkgerrors.WithMessagef(err, "error updating stored versions of CRD '%s'", name)
+	}
+
+	k.logger.Info("migrated CRD storage version",
+		zap.String("name", name),
+		zap.Strings("oldStoredVersions", stored),
+		zap.String("storageVersion", storageVersion),
+		zap.Int("rewritten", rewritten),
+	)
+
+	return nil
+}
+
+// rewriteClusters updates every cluster without changes, which the API server
+// persists in the current storage version. Objects that were deleted or
+// updated concurrently have already been rewritten.
+func (k *k8sops) rewriteClusters() (int, error) {
+	client := k.crdClient.OperatorV1alpha1()
+	clusters, err := client.M3DBClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		_, err := client.M3DBClusters(cluster.Namespace).Update(cluster)
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			return n, pkgerrors.WithMessagef(err, "error rewriting cluster '%s/%s'", cluster.Namespace, cluster.Name)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// rewriteNamespaces is rewriteClusters for M3DBNamespaces.
+func (k *k8sops) rewriteNamespaces() (int, error) {
+	client := k.crdClient.OperatorV1alpha1()
+	namespaces, err := client.M3DBNamespaces(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		_, err := client.M3DBNamespaces(ns.Namespace).Update(ns)
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			return n, pkgerrors.WithMessagef(err, "error rewriting namespace '%s/%s'", ns.Namespace, ns.Name)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
 // waitForCRDReady waits until we can list resources of the given type,
 // indicating that the resource is ready.
 func (k *k8sops) waitForCRDReady(name string) error {
-	if name != myspec.Name {
+	var list func() error
+	switch name {
+	case myspec.Name:
+		list = func() error {
+			_, err := k.crdClient.OperatorV1alpha1().M3DBClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+			return err
+		}
+	case myspec.NamespaceName:
+		list = func() error {
+			_, err := k.crdClient.OperatorV1alpha1().M3DBNamespaces(metav1.NamespaceAll).List(metav1.ListOptions{})
+			return err
+		}
+	case myspec.BackupName:
+		list = func() error {
+			_, err := k.crdClient.OperatorV1alpha1().M3DBBackups(metav1.NamespaceAll).List(metav1.ListOptions{})
+			return err
+		}
+	case myspec.RestoreName:
+		list = func() error {
+			_, err := k.crdClient.OperatorV1alpha1().M3DBRestores(metav1.NamespaceAll).List(metav1.ListOptions{})
+			return err
+		}
+	default:
 		return fmt.Errorf("unrecognized CRD name '%s'", name)
 	}
 
 	// wait until we can list resources of our type without getting resource not
 	// found errors.
 	err := wait.Poll(2*time.Second, 5*time.Minute, func() (bool, error) {
-		_, err := k.crdClient.OperatorV1alpha1().M3DBClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+		err := list()
 		if err == nil {
 			return true, nil
 		}
@@ -21,6 +21,7 @@
 package k8sops
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -41,7 +42,7 @@ import (
 func TestCreateOrUpdateCRD(t *testing.T) {
 	k := newFakeK8sops(t).(*k8sops)
 
-	err := k.CreateOrUpdateCRD("foo", false)
+	err := k.CreateOrUpdateCRD("foo", CRDOptions{})
 	assert.Error(t, err)
 
 	ext := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions()
@@ -50,14 +51,24 @@ func TestCreateOrUpdateCRD(t *testing.T) {
 	assert.Error(t, err)
 
 	// Create the CRD.
-	err = k.CreateOrUpdateCRD(m3dboperator.Name, false)
+	err = k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{})
 	assert.NoError(t, err)
 
 	_, err = ext.Get(m3dboperator.Name, metav1.GetOptions{})
 	assert.NoError(t, err)
 
 	// Update the CRD.
-	err = k.CreateOrUpdateCRD(m3dboperator.Name, false)
+	err = k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{})
+	assert.NoError(t, err)
+
+	// Create and update the namespace CRD.
+	err = k.CreateOrUpdateCRD(m3dboperator.NamespaceName, CRDOptions{})
+	assert.NoError(t, err)
+
+	_, err = ext.Get(m3dboperator.NamespaceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	err = k.CreateOrUpdateCRD(m3dboperator.NamespaceName, CRDOptions{})
 	assert.NoError(t, err)
 }
 
@@ -71,7 +82,7 @@ func TestCreateOrUpdateCRD_Err(t *testing.T) {
 			expErr: "could not fetch",
 		},
 		{
-			action: "update",
+			action: "patch",
 			expErr: "error updating",
 		},
 		{
@@ -87,12 +98,12 @@ func TestCreateOrUpdateCRD_Err(t *testing.T) {
 			})
 
 			// Must create CRD first to have an error updating it.
-			if test.action == "update" {
-				err := k.CreateOrUpdateCRD(m3dboperator.Name, false)
+			if test.action == "patch" {
+				err := k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{})
 				assert.NoError(t, err)
 			}
 
-			err := k.CreateOrUpdateCRD(m3dboperator.Name, false)
+			err := k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{})
 			require.Error(t, err)
 			assert.Contains(t, err.Error(), test.expErr)
 		})
@@ -115,3 +126,148 @@ func TestWaitForCRDReady(t *testing.T) {
 	err = k.waitForCRDReady(m3dboperator.Name)
 	assert.Error(t, err)
 }
+
+func TestCreateOrUpdateCRDOptions(t *testing.T) {
+	k := newFakeK8sops(t).(*k8sops)
+
+	err := k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{StorageVersion: m3dboperator.VersionV1beta1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a conversion webhook")
+
+	err = k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{StorageVersion: "v2"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognized storage version")
+
+	err = k.CreateOrUpdateCRD(m3dboperator.Name, CRDOptions{ConversionWebhook: &ConversionWebhook{}})
+	assert.Error(t, err)
+}
+
+func TestCreateOrUpdateCRDConversion(t *testing.T) {
+	k := newFakeK8sops(t).(*k8sops)
+
+	var patches []ktesting.PatchAction
+	k.kubeExt.(*kubeExtFake.Clientset).Fake.PrependReactor("patch", "customresourcedefinitions", func(action ktesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(ktesting.PatchAction))
+		return true, &extv1beta1.CustomResourceDefinition{}, nil
+	})
+
+	opts := CRDOptions{
+		StorageVersion: m3dboperator.VersionV1beta1,
+		ConversionWebhook: &ConversionWebhook{
+			ServiceNamespace: "operator",
+			ServiceName:      "m3db-operator-webhook",
+			Path:             "/convert",
+			CABundle:         []byte("ca"),
+		},
+	}
+
+	expConversion := `{
+		"strategy":"Webhook",
+		"webhookClientConfig":{
+			"service":{"namespace":"operator","name":"m3db-operator-webhook","path":"/convert"},
+			"caBundle":"Y2E="
+		},
+		"conversionReviewVersions":["v1beta1"]
+	}`
+
+	// The conversion is configured right after a create.
+	require.NoError(t, k.CreateOrUpdateCRD(m3dboperator.Name, opts))
+	require.Len(t, patches, 1)
+	assert.JSONEq(t, `{"spec":{"conversion":`+expConversion+`}}`, string(patches[0].GetPatch()))
+
+	// An update carries the conversion in the same request, so the CRD is
+	// never left without it.
+	require.NoError(t, k.CreateOrUpdateCRD(m3dboperator.Name, opts))
+	require.Len(t, patches, 2)
+
+	var update struct {
+		Spec struct {
+			Conversion json.RawMessage `json:"conversion"`
+		} `json:"spec"`
+	}
+	require.NoError(t, json.Unmarshal(patches[1].GetPatch(), &update))
+	assert.JSONEq(t, expConversion, string(update.Spec.Conversion))
+
+	crd, err := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions().Get(m3dboperator.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, crdVersions(opts), crd.Spec.Versions)
+
+	// Backups and restores only have v1alpha1, so their CRDs aren't converted.
+	require.NoError(t, k.CreateOrUpdateCRD(m3dboperator.BackupName, opts))
+	require.NoError(t, k.CreateOrUpdateCRD(m3dboperator.RestoreName, opts))
+	assert.Len(t, patches, 2)
+}
+
+func TestMigrateCRDStorageVersion(t *testing.T) {
+	k := newFakeK8sops(t).(*k8sops)
+
+	crd := GenerateCRD(CRDOptions{})
+	crd.Status.StoredVersions = []string{m3dboperator.Version, m3dboperator.VersionV1beta1}
+	ext := k.kubeExt.ApiextensionsV1beta1().CustomResourceDefinitions()
+	_, err := ext.Create(crd)
+	require.NoError(t, err)
+
+	for _, ns := range []string{"a", "b"} {
+		_, err := k.crdClient.OperatorV1alpha1().M3DBClusters(ns).Create(&myspec.M3DBCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "cluster"},
+		})
+		require.NoError(t, err)
+	}
+
+	var updated []string
+	k.crdClient.(*clientsetFake.Clientset).Fake.PrependReactor("update", "m3dbclusters", func(action ktesting.Action) (bool, runtime.Object, error) {
+		updated = append(updated, action.GetNamespace())
+		return false, nil, nil
+	})
+
+	err = k.MigrateCRDStorageVersion(m3dboperator.Name, m3dboperator.VersionV1beta1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, updated)
+
+	crd, err = ext.Get(m3dboperator.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{m3dboperator.VersionV1beta1}, crd.Status.StoredVersions)
+
+	// Nothing is rewritten once the objects have been migrated.
+	updated = nil
+	err = k.MigrateCRDStorageVersion(m3dboperator.Name, m3dboperator.VersionV1beta1)
+	require.NoError(t, err)
+	assert.Empty(t, updated)
+
+	err = k.MigrateCRDStorageVersion(m3dboperator.NamespaceName, m3dboperator.VersionV1beta1)
+	assert.Error(t, err)
+}
+
+func TestMergePatch(t *testing.T) {
+	cur := map[string]interface{}{
+		"group": "operator.m3db.io",
+		"validation": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"kept":    "a",
+				"changed": "b",
+				"removed": "c",
+			},
+		},
+		"versions": []interface{}{"v1alpha1"},
+	}
+	updated := map[string]interface{}{
+		"group": "operator.m3db.io",
+		"validation": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"kept":    "a",
+				"changed": "d",
+			},
+		},
+		"versions": []interface{}{"v1alpha1", "v1beta1"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"validation": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"changed": "d",
+				"removed": nil,
+			},
+		},
+		"versions": []interface{}{"v1alpha1", "v1beta1"},
+	}, mergePatch(cur, updated))
+}
@@ -55,6 +55,9 @@ const (
 	_configurationFileName     = "m3.yml"
 	_healthFileName            = "/bin/m3dbnode_bootstrapped.sh"
 	_openAPISpecName           = "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBCluster"
+	_namespaceOpenAPISpecName  = "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBNamespace"
+	_backupOpenAPISpecName     = "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBBackup"
+	_restoreOpenAPISpecName    = "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1.M3DBRestore"
 )
 
 var (
@@ -67,27 +70,226 @@ type m3dbPort struct {
 	protocol v1.Protocol
 }
 
-var baseM3DBPorts = [...]m3dbPort{
-	{"client", PortM3DBNodeClient, v1.ProtocolTCP},
-	{"cluster", PortM3DBNodeCluster, v1.ProtocolTCP},
-	{"http-node", PortM3DBHTTPNode, v1.ProtocolTCP},
-	{"http-cluster", PortM3DBHTTPCluster, v1.ProtocolTCP},
-	{"debug", PortM3DBDebug, v1.ProtocolTCP},
-	{"coordinator", PortM3Coordinator, v1.ProtocolTCP},
-	{"coord-metrics", PortM3CoordinatorMetrics, v1.ProtocolTCP},
+// m3dbPorts returns the ports of the cluster's M3DB nodes, which include the
+// ports of their embedded coordinator.
+func m3dbPorts(cluster *myspec.M3DBCluster) []m3dbPort {
+	ports := ClusterPorts(cluster)
+	return []m3dbPort{
+		{"client", Port(ports.Client), v1.ProtocolTCP},
+		{"cluster", Port(ports.Cluster), v1.ProtocolTCP},
+		{"http-node", Port(ports.HTTPNode), v1.ProtocolTCP},
+		{"http-cluster", Port(ports.HTTPCluster), v1.ProtocolTCP},
+		{"debug", Port(ports.Debug), v1.ProtocolTCP},
+		{"coordinator", Port(ports.Coordinator), v1.ProtocolTCP},
+		{"coord-metrics", Port(ports.CoordinatorMetrics), v1.ProtocolTCP},
+	}
+}
+
+// coordinatorPorts returns the ports of the cluster's separate coordinators.
+func coordinatorPorts(cluster *myspec.M3DBCluster) []m3dbPort {
+	ports := ClusterPorts(cluster)
+	return []m3dbPort{
+		{"coordinator", Port(ports.Coordinator), v1.ProtocolTCP},
+		{"coord-metrics", Port(ports.CoordinatorMetrics), v1.ProtocolTCP},
+		{"coord-m3msg", PortM3CoordinatorM3Msg, v1.ProtocolTCP},
+	}
+}
+
+var baseAggregatorPorts = [...]m3dbPort{
+	{"m3msg", PortM3AggregatorM3Msg, v1.ProtocolTCP},
+	{"http", PortM3AggregatorHTTP, v1.ProtocolTCP},
+	{"metrics", PortM3AggregatorMetrics, v1.ProtocolTCP},
+}
+
+// clusterPrinterColumns are the columns shown by kubectl get m3dbclusters.
+var clusterPrinterColumns = []apiextensionsv1beta1.CustomResourceColumnDefinition{
+	{
+		Name:        "State",
+		Type:        "string",
+		Description: "Health of the cluster",
+		JSONPath:    ".status.state",
+	},
+	{
+		Name:        "Shards",
+		Type:        "integer",
+		Description: "Number of shards",
+		JSONPath:    ".spec.numberOfShards",
+	},
+	{
+		Name:        "RF",
+		Type:        "integer",
+		Description: "Replication factor",
+		JSONPath:    ".spec.replicationFactor",
+	},
+	{
+		Name:        "Ready",
+		Type:        "integer",
+		Description: "Number of ready M3DB pods",
+		JSONPath:    ".status.readyInstances",
+	},
+	{
+		Name:     "Age",
+		Type:     "date",
+		JSONPath: ".metadata.creationTimestamp",
+	},
+}
+
+// backupPrinterColumns are the columns shown by kubectl get m3dbbackups.
+var backupPrinterColumns = []apiextensionsv1beta1.CustomResourceColumnDefinition{
+	{
+		Name:        "Cluster",
+		Type:        "string",
+		Description: "Backed up cluster",
+		JSONPath:    ".spec.clusterName",
+	},
+	{
+		Name:        "Phase",
+		Type:        "string",
+		Description: "Phase of the backup",
+		JSONPath:    ".status.phase",
+	},
+	{
+		Name:        "Location",
+		Type:        "string",
+		Description: "Location of the backup in the object store",
+		JSONPath:    ".status.location",
+	},
+	{
+		Name:     "Age",
+		Type:     "date",
+		JSONPath: ".metadata.creationTimestamp",
+	},
 }
 
-var baseCoordinatorPorts = [...]m3dbPort{
-	{"coordinator", PortM3Coordinator, v1.ProtocolTCP},
-	{"coord-metrics", PortM3CoordinatorMetrics, v1.ProtocolTCP},
+// restorePrinterColumns are the columns shown by kubectl get m3dbrestores.
+var restorePrinterColumns = []apiextensionsv1beta1.CustomResourceColumnDefinition{
+	{
+		Name:        "Backup",
+		Type:        "string",
+		Description: "Restored backup",
+		JSONPath:    ".spec.backupName",
+	},
+	{
+		Name:        "Cluster",
+		Type:        "string",
+		Description: "Restored cluster",
+		JSONPath:    ".status.clusterName",
+	},
+	{
+		Name:        "Phase",
+		Type:        "string",
+		Description: "Phase of the restore",
+		JSONPath:    ".status.phase",
+	},
+	{
+		Name:     "Age",
+		Type:     "date",
+		JSONPath: ".metadata.creationTimestamp",
+	},
+}
+
+// crdVersions returns the versions of the operator's CRDs. v1beta1 is always
+// listed, so it can't be dropped while objects may still be stored in it, but
+// is only served when a conversion webhook is configured.
+func crdVersions(opts CRDOptions) []apiextensionsv1beta1.CustomResourceDefinitionVersion {
+	storage := opts.storageVersion()
+	return []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+		{
+			Name:    m3dboperator.Version,
+			Served:  true,
+			Storage: storage == m3dboperator.Version,
+		},
+		{
+			Name:    m3dboperator.VersionV1beta1,
+			Served:  opts.ConversionWebhook != nil,
+			Storage: storage == m3dboperator.VersionV1beta1,
+		},
+	}
 }
 
 // GenerateCRD generates the crd object needed for the M3DBCluster
-func GenerateCRD(enableValidation bool) *apiextensionsv1beta1.CustomResourceDefinition {
+func GenerateCRD(opts CRDOptions) *apiextensionsv1beta1.CustomResourceDefinition {
 	crd := &apiextensionsv1beta1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: m3dboperator.Name,
 		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:    m3dboperator.GroupName,
+			Versions: crdVersions(opts),
+			Scope:    apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural: m3dboperator.ResourcePlural,
+				Kind:   m3dboperator.ResourceKind,
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				Scale: &apiextensionsv1beta1.CustomResourceSubresourceScale{
+					SpecReplicasPath:   ".spec.instancesPerIsolationGroup",
+					StatusReplicasPath: ".status.replicas",
+					LabelSelectorPath:  pointer.StringPtr(".status.labelSelector"),
+				},
+			},
+			AdditionalPrinterColumns: clusterPrinterColumns,
+		},
+	}
+
+	if opts.EnableValidation {
+		crd.Spec.Validation = crdutils.GetCustomResourceValidation(_openAPISpecName, myspec.GetOpenAPIDefinitions)
+	}
+
+	return crd
+}
+
+// GenerateNamespaceCRD generates the crd object needed for the M3DBNamespace
+func GenerateNamespaceCRD(opts CRDOptions) *apiextensionsv1beta1.CustomResourceDefinition {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: m3dboperator.NamespaceName,
+		},
+		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
+			Group:    m3dboperator.GroupName,
+			Versions: crdVersions(opts),
+			Scope:    apiextensionsv1beta1.NamespaceScoped,
+			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
+				Plural: m3dboperator.NamespaceResourcePlural,
+				Kind:   m3dboperator.NamespaceResourceKind,
+			},
+			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			},
+		},
+	}
+
+	if opts.EnableValidation {
+		crd.Spec.Validation = crdutils.GetCustomResourceValidation(_namespaceOpenAPISpecName, myspec.GetOpenAPIDefinitions)
+	}
+
+	return crd
+}
+
+// GenerateBackupCRD generates the crd object needed for the M3DBBackup. Backups
+// only exist in v1alpha1.
+func GenerateBackupCRD(opts CRDOptions) *apiextensionsv1beta1.CustomResourceDefinition {
+	return generateV1alpha1CRD(m3dboperator.BackupName, m3dboperator.BackupResourcePlural,
+		m3dboperator.BackupResourceKind, _backupOpenAPISpecName, backupPrinterColumns, opts)
+}
+
+// GenerateRestoreCRD generates the crd object needed for the M3DBRestore.
+// Restores only exist in v1alpha1.
+func GenerateRestoreCRD(opts CRDOptions) *apiextensionsv1beta1.CustomResourceDefinition {
+	return generateV1alpha1CRD(m3dboperator.RestoreName, m3dboperator.RestoreResourcePlural,
+		m3dboperator.RestoreResourceKind, _restoreOpenAPISpecName, restorePrinterColumns, opts)
+}
+
+func generateV1alpha1CRD(
+	name, plural, kind, openAPISpecName string,
+	columns []apiextensionsv1beta1.CustomResourceColumnDefinition,
+	opts CRDOptions,
+) *apiextensionsv1beta1.CustomResourceDefinition {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
 		Spec: apiextensionsv1beta1.CustomResourceDefinitionSpec{
 			Group: m3dboperator.GroupName,
 			Versions: []apiextensionsv1beta1.CustomResourceDefinitionVersion{
@@ -99,47 +301,39 @@ func GenerateCRD(enableValidation bool) *apiextensionsv1beta1.CustomResourceDefi
 			},
 			Scope: apiextensionsv1beta1.NamespaceScoped,
 			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
-				Plural: m3dboperator.ResourcePlural,
-				Kind:   m3dboperator.ResourceKind,
+				Plural: plural,
+				Kind:   kind,
 			},
 			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
 				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
 			},
+			AdditionalPrinterColumns: columns,
 		},
 	}
 
-	if enableValidation {
-		crd.Spec.Validation = crdutils.GetCustomResourceValidation(_openAPISpecName, myspec.GetOpenAPIDefinitions)
+	if opts.EnableValidation {
+		crd.Spec.Validation = crdutils.GetCustomResourceValidation(openAPISpecName, myspec.GetOpenAPIDefinitions)
 	}
 
 	return crd
 }
 
-// GenerateStatefulSet provides a statefulset object for a m3db cluster
+// GenerateStatefulSet provides a statefulset object named ssName for an
+// isolation group of a m3db cluster. See StatefulSetNames for how sets are
+// named.
 func GenerateStatefulSet(
 	cluster *myspec.M3DBCluster,
+	ssName string,
 	isolationGroupName string,
 	instanceAmount int32,
 ) (*appsv1.StatefulSet, error) {
 
-	// TODO(schallert): always sort zones alphabetically.
-	stsID := -1
-	var isolationGroup myspec.IsolationGroup
-	for i, g := range cluster.Spec.IsolationGroups {
-		if g.Name == isolationGroupName {
-			isolationGroup = g
-			stsID = i
-			break
-		}
-	}
-
-	if stsID == -1 {
+	isolationGroup, ok := myspec.IsolationGroups(cluster.Spec.IsolationGroups).GetByName(isolationGroupName)
+	if !ok {
 		return nil, fmt.Errorf("could not find isogroup '%s' in spec", isolationGroupName)
 	}
 
 	clusterSpec := cluster.Spec
-	clusterName := cluster.GetName()
-	ssName := StatefulSetName(clusterName, stsID)
 
 	affinity, err := GenerateStatefulSetAffinity(isolationGroup)
 	if err != nil {
@@ -149,7 +343,7 @@ func GenerateStatefulSet(
 	statefulSet := NewBaseStatefulSet(ssName, isolationGroupName, cluster, instanceAmount)
 	m3dbContainer := &statefulSet.Spec.Template.Spec.Containers[0]
 	m3dbContainer.Resources = clusterSpec.ContainerResources
-	m3dbContainer.Ports = generateContainerPorts()
+	m3dbContainer.Ports = buildContainerPorts(m3dbPorts(cluster))
 	statefulSet.Spec.Template.Spec.Affinity = affinity
 	statefulSet.Spec.Template.Spec.Tolerations = cluster.Spec.Tolerations
 
@@ -205,7 +399,7 @@ func GenerateM3DBService(cluster *myspec.M3DBCluster) (*v1.Service, error) {
 		},
 		Spec: v1.ServiceSpec{
 			Selector:  svcLabels,
-			Ports:     generateM3DBServicePorts(),
+			Ports:     buildServicePorts(m3dbPorts(cluster)),
 			ClusterIP: v1.ClusterIPNone,
 			Type:      v1.ServiceTypeClusterIP,
 		},
@@ -219,8 +413,13 @@ func GenerateCoordinatorService(cluster *myspec.M3DBCluster) (*v1.Service, error
 		return nil, errEmptyClusterName
 	}
 
+	// The coordinator embedded in every M3DB node serves the service unless the
+	// cluster runs separate coordinators.
 	selectorLabels := labels.BaseLabels(cluster)
 	selectorLabels[labels.Component] = labels.ComponentM3DBNode
+	if cluster.Spec.Coordinator != nil {
+		selectorLabels = CoordinatorLabels(cluster)
+	}
 
 	serviceLabels := labels.BaseLabels(cluster)
 	serviceLabels[labels.Component] = labels.ComponentCoordinator
@@ -232,7 +431,7 @@ func GenerateCoordinatorService(cluster *myspec.M3DBCluster) (*v1.Service, error
 		},
 		Spec: v1.ServiceSpec{
 			Selector: selectorLabels,
-			Ports:    generateCoordinatorServicePorts(),
+			Ports:    buildServicePorts(coordinatorPorts(cluster)),
 			Type:     v1.ServiceTypeClusterIP,
 		},
 	}, nil
@@ -251,22 +450,13 @@ func buildServicePorts(ports []m3dbPort) []v1.ServicePort {
 	return svcPorts
 }
 
-func generateM3DBServicePorts() []v1.ServicePort {
-	return buildServicePorts(baseM3DBPorts[:])
-}
-
-func generateCoordinatorServicePorts() []v1.ServicePort {
-	return buildServicePorts(baseCoordinatorPorts[:])
-}
-
-// generateContainerPorts will produce default container ports.
-func generateContainerPorts() []v1.ContainerPort {
+func buildContainerPorts(ports []m3dbPort) []v1.ContainerPort {
 	cntPorts := []v1.ContainerPort{}
-	for _, v := range baseM3DBPorts {
+	for _, p := range ports {
 		newPortMapping := v1.ContainerPort{
-			Name:          v.name,
-			ContainerPort: int32(v.port),
-			Protocol:      v.protocol,
+			Name:          p.name,
+			ContainerPort: int32(p.port),
+			Protocol:      p.protocol,
 		}
 		cntPorts = append(cntPorts, newPortMapping)
 	}
@@ -54,6 +54,11 @@ func TestGenerateCRD(t *testing.T) {
 					Served:  true,
 					Storage: true,
 				},
+				{
+					Name:    m3dboperator.VersionV1beta1,
+					Served:  false,
+					Storage: false,
+				},
 			},
 			Scope: apiextensionsv1beta1.NamespaceScoped,
 			Names: apiextensionsv1beta1.CustomResourceDefinitionNames{
@@ -62,19 +67,101 @@ func TestGenerateCRD(t *testing.T) {
 			},
 			Subresources: &apiextensionsv1beta1.CustomResourceSubresources{
 				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+				Scale: &apiextensionsv1beta1.CustomResourceSubresourceScale{
+					SpecReplicasPath:   ".spec.instancesPerIsolationGroup",
+					StatusReplicasPath: ".status.replicas",
+					LabelSelectorPath:  pointer.StringPtr(".status.labelSelector"),
+				},
+			},
+			AdditionalPrinterColumns: []apiextensionsv1beta1.CustomResourceColumnDefinition{
+				{Name: "State", Type: "string", Description: "Health of the cluster", JSONPath: ".status.state"},
+				{Name: "Shards", Type: "integer", Description: "Number of shards", JSONPath: ".spec.numberOfShards"},
+				{Name: "RF", Type: "integer", Description: "Replication factor", JSONPath: ".spec.replicationFactor"},
+				{Name: "Ready", Type: "integer", Description: "Number of ready M3DB pods", JSONPath: ".status.readyInstances"},
+				{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
 			},
 		},
 	}
 
-	newCRD := GenerateCRD(false)
+	newCRD := GenerateCRD(CRDOptions{})
 	assert.Equal(t, crd, newCRD)
 	assert.Nil(t, newCRD.Spec.Validation)
 
-	newCRD = GenerateCRD(true)
+	newCRD = GenerateCRD(CRDOptions{EnableValidation: true})
 	expValidation := crdutils.GetCustomResourceValidation(_openAPISpecName, myspec.GetOpenAPIDefinitions)
 	assert.Equal(t, expValidation, newCRD.Spec.Validation)
 }
 
+func TestGenerateCRDVersions(t *testing.T) {
+	crd := GenerateCRD(CRDOptions{
+		StorageVersion:    m3dboperator.VersionV1beta1,
+		ConversionWebhook: &ConversionWebhook{ServiceNamespace: "ns", ServiceName: "webhook"},
+	})
+	assert.Equal(t, []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+		{
+			Name:    m3dboperator.Version,
+			Served:  true,
+			Storage: false,
+		},
+		{
+			Name:    m3dboperator.VersionV1beta1,
+			Served:  true,
+			Storage: true,
+		},
+	}, crd.Spec.Versions)
+}
+
+func TestGenerateNamespaceCRD(t *testing.T) {
+	newCRD := GenerateNamespaceCRD(CRDOptions{})
+	assert.Equal(t, m3dboperator.NamespaceName, newCRD.Name)
+	assert.Equal(t, m3dboperator.NamespaceResourceKind, newCRD.Spec.Names.Kind)
+	assert.Equal(t, m3dboperator.NamespaceResourcePlural, newCRD.Spec.Names.Plural)
+	assert.NotNil(t, newCRD.Spec.Subresources.Status)
+	assert.Nil(t, newCRD.Spec.Validation)
+
+	newCRD = GenerateNamespaceCRD(CRDOptions{EnableValidation: true})
+	expValidation := crdutils.GetCustomResourceValidation(_namespaceOpenAPISpecName, myspec.GetOpenAPIDefinitions)
+	assert.Equal(t, expValidation, newCRD.Spec.Validation)
+}
+
+func TestGenerateBackupAndRestoreCRDs(t *testing.T) {
+	for _, test := range []struct {
+		crd      *apiextensionsv1beta1.CustomResourceDefinition
+		name     string
+		kind     string
+		plural   string
+		specName string
+	}{
+		{
+			crd:      GenerateBackupCRD(CRDOptions{EnableValidation: true}),
+			name:     m3dboperator.BackupName,
+			kind:     m3dboperator.BackupResourceKind,
+			plural:   m3dboperator.BackupResourcePlural,
+			specName: _backupOpenAPISpecName,
+		},
+		{
+			crd:      GenerateRestoreCRD(CRDOptions{EnableValidation: true}),
+			name:     m3dboperator.RestoreName,
+			kind:     m3dboperator.RestoreResourceKind,
+			plural:   m3dboperator.RestoreResourcePlural,
+			specName: _restoreOpenAPISpecName,
+		},
+	} {
+		t.Run(test.kind, func(t *testing.T) {
+			assert.Equal(t, test.name, test.crd.Name)
+			assert.Equal(t, test.kind, test.crd.Spec.Names.Kind)
+			assert.Equal(t, test.plural, test.crd.Spec.Names.Plural)
+			assert.NotNil(t, test.crd.Spec.Subresources.Status)
+			assert.Equal(t, []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+				{Name: m3dboperator.Version, Served: true, Storage: true},
+			}, test.crd.Spec.Versions)
+
+			expValidation := crdutils.GetCustomResourceValidation(test.specName, myspec.GetOpenAPIDefinitions)
+			assert.Equal(t, expValidation, test.crd.Spec.Validation)
+		})
+	}
+}
+
 func TestGenerateStatefulSet(t *testing.T) {
 	fixture := getFixture("testM3DBCluster.yaml", t)
 	clusterSpec := fixture.Spec
@@ -190,7 +277,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 									},
 								},
 							},
-							Ports: generateContainerPorts(),
+							Ports: buildContainerPorts(m3dbPorts(fixture)),
 							VolumeMounts: []v1.VolumeMount{
 								{
 									Name:      _dataVolumeName,
@@ -287,7 +374,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 
 	// Base config stateful set
 	ss := baseSS.DeepCopy()
-	newSS, err := GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err := GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -297,7 +384,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	fixture = getFixture("testM3DBCluster.yaml", t)
 	fixture.Spec.ConfigMapName = pointer.StringPtr("mymap")
 	ss.Spec.Template.Spec.Volumes[2].VolumeSource.ConfigMap.Name = "mymap"
-	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err = GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -314,7 +401,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 		},
 	})
 
-	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err = GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -325,7 +412,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	fixture.Spec.IsolationGroups[0].StorageClassName = "foo"
 	ss.Spec.VolumeClaimTemplates[0].Spec.StorageClassName = pointer.StringPtr("foo")
 
-	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err = GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -335,7 +422,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	fixture = getFixture("testM3DBCluster.yaml", t)
 	fixture.Spec.IsolationGroups[1].StorageClassName = "foo"
 
-	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err = GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -346,7 +433,7 @@ func TestGenerateStatefulSet(t *testing.T) {
 	fixture = getFixture("testM3DBCluster.yaml", t)
 	fixture.Spec.Tolerations = nil
 
-	newSS, err = GenerateStatefulSet(fixture, isolationGroup, *instanceAmount)
+	newSS, err = GenerateStatefulSet(fixture, ssName, isolationGroup, *instanceAmount)
 	assert.NoError(t, err)
 	assert.NotNil(t, newSS)
 	assert.Equal(t, ss, newSS)
@@ -380,7 +467,7 @@ func TestGenerateM3DBService(t *testing.T) {
 		},
 		Spec: v1.ServiceSpec{
 			Selector:  baseLabels,
-			Ports:     generateM3DBServicePorts(),
+			Ports:     buildServicePorts(m3dbPorts(cluster)),
 			ClusterIP: v1.ClusterIPNone,
 			Type:      v1.ServiceTypeClusterIP,
 		},
@@ -419,10 +506,17 @@ func TestGenerateCoordinatorService(t *testing.T) {
 		},
 		Spec: v1.ServiceSpec{
 			Selector: selectLabels,
-			Ports:    generateCoordinatorServicePorts(),
+			Ports:    buildServicePorts(coordinatorPorts(cluster)),
 			Type:     v1.ServiceTypeClusterIP,
 		},
 	}
 
 	assert.Equal(t, expSvc, svc)
+
+	// Separate coordinators serve the service if the cluster runs them.
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	svc, err = GenerateCoordinatorService(cluster)
+	assert.NoError(t, err)
+	expSvc.Spec.Selector = svcLabels
+	assert.Equal(t, expSvc, svc)
 }
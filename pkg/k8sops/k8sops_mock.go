@@ -58,17 +58,31 @@ func (m *MockK8sops) EXPECT() *MockK8sopsMockRecorder {
 }
 
 // CreateOrUpdateCRD mocks base method
-func (m *MockK8sops) CreateOrUpdateCRD(name string, enableValidation bool) error {
+func (m *MockK8sops) CreateOrUpdateCRD(name string, opts CRDOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrUpdateCRD", name, enableValidation)
+	ret := m.ctrl.Call(m, "CreateOrUpdateCRD", name, opts)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateOrUpdateCRD indicates an expected call of CreateOrUpdateCRD
-func (mr *MockK8sopsMockRecorder) CreateOrUpdateCRD(name, enableValidation interface{}) *gomock.Call {
+func (mr *MockK8sopsMockRecorder) CreateOrUpdateCRD(name, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateCRD", reflect.TypeOf((*MockK8sops)(nil).CreateOrUpdateCRD), name, enableValidation)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateCRD", reflect.TypeOf((*MockK8sops)(nil).CreateOrUpdateCRD), name, opts)
+}
+
+// MigrateCRDStorageVersion mocks base method
+func (m *MockK8sops) MigrateCRDStorageVersion(name, storageVersion string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateCRDStorageVersion", name, storageVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateCRDStorageVersion indicates an expected call of MigrateCRDStorageVersion
+func (mr *MockK8sopsMockRecorder) MigrateCRDStorageVersion(name, storageVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateCRDStorageVersion", reflect.TypeOf((*MockK8sops)(nil).MigrateCRDStorageVersion), name, storageVersion)
 }
 
 // GetService mocks base method
@@ -43,9 +43,25 @@ const (
 	ComponentM3DBNode = "m3dbnode"
 	// ComponentCoordinator indicates a component is a coordinator.
 	ComponentCoordinator = "coordinator"
+	// ComponentAggregator indicates a component is an aggregator.
+	ComponentAggregator = "aggregator"
+	// ComponentBackup indicates a component uploads a backup.
+	ComponentBackup = "backup"
+	// ComponentRestore indicates a component downloads a backup.
+	ComponentRestore = "restore"
+	// ComponentMetadata indicates an object holds snapshots of a cluster's
+	// placement and namespaces.
+	ComponentMetadata = "metadata"
+	// Backup identifies what M3DBBackup an object belongs to.
+	Backup = "operator.m3db.io/backup"
+	// Restore identifies what M3DBRestore an object belongs to.
+	Restore = "operator.m3db.io/restore"
 	// EtcdDeletionFinalizer is the finalizer used to delete cluster data stored
 	// in etcd.
 	EtcdDeletionFinalizer = "operator.m3db.io/etcd-deletion"
+	// NamespaceDeletionFinalizer is the finalizer used to delete an
+	// M3DBNamespace's namespace from its cluster.
+	NamespaceDeletionFinalizer = "operator.m3db.io/namespace-deletion"
 )
 
 // BaseLabels returns the base labels we apply to all objects created by the
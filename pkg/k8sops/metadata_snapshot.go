@@ -0,0 +1,298 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	_metadataSnapshotKeyPrefix = "snapshot-"
+	_metadataSnapshotKeySuffix = ".json.gz"
+)
+
+// MetadataSnapshot is a versioned copy of a cluster's placement and namespace
+// registry, from which they can be restored if etcd loses them.
+type MetadataSnapshot struct {
+	// Version increases by one with each snapshot of a cluster.
+	Version int64
+
+	// CreatedAt is the time the snapshot was taken.
+	CreatedAt string
+
+	// PlacementVersion is the version of the placement in etcd when the
+	// snapshot was taken.
+	PlacementVersion int
+
+	Placement  *placementpb.Placement
+	Namespaces *dbns.Registry
+}
+
+// metadataSnapshotJSON is the serialized form of a snapshot. The placement and
+// registry are protobufs and are serialized as such.
+type metadataSnapshotJSON struct {
+	Version          int64           `json:"version"`
+	CreatedAt        string          `json:"createdAt"`
+	PlacementVersion int             `json:"placementVersion"`
+	Placement        json.RawMessage `json:"placement"`
+	Namespaces       json.RawMessage `json:"namespaces"`
+}
+
+// GenerateMetadataSnapshotConfigMap creates an empty ConfigMap to hold the
+// metadata snapshots of a cluster.
+func GenerateMetadataSnapshotConfigMap(cluster *myspec.M3DBCluster) *corev1.ConfigMap {
+	cmLabels := labels.BaseLabels(cluster)
+	cmLabels[labels.Component] = labels.ComponentMetadata
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            MetadataSnapshotConfigMapName(cluster.Name),
+			Namespace:       cluster.Namespace,
+			Labels:          cmLabels,
+			OwnerReferences: []metav1.OwnerReference{*GenerateOwnerRef(cluster)},
+		},
+	}
+}
+
+// AddMetadataSnapshot adds the snapshot to the ConfigMap as the next version,
+// keeping at most retain snapshots. The snapshot isn't added, and false is
+// returned, if its placement and namespaces match the latest snapshot's.
+// Snapshots are gzipped since placements of large clusters can approach the
+// size limit of a ConfigMap.
+func AddMetadataSnapshot(cm *corev1.ConfigMap, snapshot *MetadataSnapshot, retain int) (bool, error) {
+	latest, err := LatestMetadataSnapshot(cm)
+	if err != nil {
+		return false, err
+	}
+
+	if latest != nil {
+		same, err := sameMetadata(latest, snapshot)
+		if err != nil || same {
+			return false, err
+		}
+		snapshot.Version = latest.Version + 1
+	} else {
+		snapshot.Version = 1
+	}
+
+	data, err := encodeMetadataSnapshot(snapshot)
+	if err != nil {
+		return false, err
+	}
+
+	if cm.BinaryData == nil {
+		cm.BinaryData = make(map[string][]byte)
+	}
+	cm.BinaryData[metadataSnapshotKey(snapshot.Version)] = data
+
+	keys := metadataSnapshotKeys(cm)
+	if retain < 1 {
+		retain = 1
+	}
+	for len(keys) > retain {
+		delete(cm.BinaryData, keys[0])
+		keys = keys[1:]
+	}
+
+	return true, nil
+}
+
+// LatestMetadataSnapshot returns the most recent snapshot in the ConfigMap, or
+// nil if it holds none.
+func LatestMetadataSnapshot(cm *corev1.ConfigMap) (*MetadataSnapshot, error) {
+	keys := metadataSnapshotKeys(cm)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return decodeMetadataSnapshotKey(cm, keys[len(keys)-1])
+}
+
+// MetadataSnapshotVersion returns the snapshot with the given version, or nil
+// if the ConfigMap doesn't hold it (anymore).
+func MetadataSnapshotVersion(cm *corev1.ConfigMap, version int64) (*MetadataSnapshot, error) {
+	key := metadataSnapshotKey(version)
+	if _, ok := cm.BinaryData[key]; !ok {
+		return nil, nil
+	}
+	return decodeMetadataSnapshotKey(cm, key)
+}
+
+// PlacementInitRequestFromSnapshot returns a request initializing a placement
+// with the snapshot's instances, shards and replication factor. Init leaves
+// shard assignments to the placement algorithm, so the snapshot's placement
+// must then be set over the initialized one to restore shard ownership.
+func PlacementInitRequestFromSnapshot(snapshot *MetadataSnapshot) *admin.PlacementInitRequest {
+	ids := make([]string, 0, len(snapshot.Placement.Instances))
+	for id := range snapshot.Placement.Instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	instances := make([]*placementpb.Instance, 0, len(ids))
+	for _, id := range ids {
+		inst := *snapshot.Placement.Instances[id]
+		inst.Shards = nil
+		instances = append(instances, &inst)
+	}
+
+	return &admin.PlacementInitRequest{
+		Instances:         instances,
+		NumShards:         int32(snapshot.Placement.NumShards),
+		ReplicationFactor: int32(snapshot.Placement.ReplicaFactor),
+	}
+}
+
+func decodeMetadataSnapshotKey(cm *corev1.ConfigMap, key string) (*MetadataSnapshot, error) {
+	snapshot, err := decodeMetadataSnapshot(cm.BinaryData[key])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s of configmap %s: %v", key, cm.Name, err)
+	}
+	return snapshot, nil
+}
+
+func metadataSnapshotKey(version int64) string {
+	// Zero padded so that keys sort by version.
+	return fmt.Sprintf("%s%010d%s", _metadataSnapshotKeyPrefix, version, _metadataSnapshotKeySuffix)
+}
+
+// metadataSnapshotKeys returns the ConfigMap's snapshot keys, oldest first.
+func metadataSnapshotKeys(cm *corev1.ConfigMap) []string {
+	var keys []string
+	for key := range cm.BinaryData {
+		if strings.HasPrefix(key, _metadataSnapshotKeyPrefix) && strings.HasSuffix(key, _metadataSnapshotKeySuffix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sameMetadata(a, b *MetadataSnapshot) (bool, error) {
+	aPl, err := marshalProto(a.Placement)
+	if err != nil {
+		return false, err
+	}
+	bPl, err := marshalProto(b.Placement)
+	if err != nil {
+		return false, err
+	}
+	aNs, err := marshalProto(a.Namespaces)
+	if err != nil {
+		return false, err
+	}
+	bNs, err := marshalProto(b.Namespaces)
+	if err != nil {
+		return false, err
+	}
+	return aPl == bPl && aNs == bNs, nil
+}
+
+func marshalProto(msg proto.Message) (string, error) {
+	// Map keys are sorted, so equal messages marshal identically. Enums are
+	// written as numbers so that decoding doesn't depend on their names.
+	return (&jsonpb.Marshaler{EnumsAsInts: true}).MarshalToString(msg)
+}
+
+func encodeMetadataSnapshot(snapshot *MetadataSnapshot) ([]byte, error) {
+	pl, err := marshalProto(snapshot.Placement)
+	if err != nil {
+		return nil, err
+	}
+	ns, err := marshalProto(snapshot.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(metadataSnapshotJSON{
+		Version:          snapshot.Version,
+		CreatedAt:        snapshot.CreatedAt,
+		PlacementVersion: snapshot.PlacementVersion,
+		Placement:        json.RawMessage(pl),
+		Namespaces:       json.RawMessage(ns),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMetadataSnapshot(data []byte) (*MetadataSnapshot, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var wire metadataSnapshotJSON
+	if err := json.Unmarshal(raw, &wire); err != nil {
+		return nil, err
+	}
+
+	snapshot := &MetadataSnapshot{
+		Version:          wire.Version,
+		CreatedAt:        wire.CreatedAt,
+		PlacementVersion: wire.PlacementVersion,
+		Placement:        &placementpb.Placement{},
+		Namespaces:       &dbns.Registry{},
+	}
+	um := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := um.Unmarshal(bytes.NewReader(wire.Placement), snapshot.Placement); err != nil {
+		return nil, err
+	}
+	if err := um.Unmarshal(bytes.NewReader(wire.Namespaces), snapshot.Namespaces); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
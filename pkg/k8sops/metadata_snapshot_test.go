@@ -0,0 +1,130 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	dbns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMetadataSnapshot(numInstances int) *MetadataSnapshot {
+	pl := &placementpb.Placement{
+		Instances:     map[string]*placementpb.Instance{},
+		ReplicaFactor: 1,
+		NumShards:     4,
+		IsSharded:     true,
+	}
+	ids := []string{"b", "a", "c", "d"}
+	for _, id := range ids[:numInstances] {
+		pl.Instances[id] = &placementpb.Instance{
+			Id:             id,
+			IsolationGroup: "group-" + id,
+			Weight:         100,
+			Endpoint:       id + ":9000",
+			Shards: []*placementpb.Shard{
+				{Id: 0, State: placementpb.ShardState_AVAILABLE},
+			},
+		}
+	}
+
+	return &MetadataSnapshot{
+		CreatedAt:        "2019-01-01T00:00:00Z",
+		PlacementVersion: 3,
+		Placement:        pl,
+		Namespaces: &dbns.Registry{Namespaces: map[string]*dbns.NamespaceOptions{
+			"metrics": {BootstrapEnabled: true},
+		}},
+	}
+}
+
+func TestMetadataSnapshotConfigMap(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	cm := GenerateMetadataSnapshotConfigMap(cluster)
+	assert.Equal(t, "m3db-metadata-"+cluster.Name, cm.Name)
+	assert.Equal(t, labels.ComponentMetadata, cm.Labels[labels.Component])
+
+	latest, err := LatestMetadataSnapshot(cm)
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	added, err := AddMetadataSnapshot(cm, newTestMetadataSnapshot(2), 2)
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	latest, err = LatestMetadataSnapshot(cm)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), latest.Version)
+	assert.Equal(t, 3, latest.PlacementVersion)
+	assert.Len(t, latest.Placement.Instances, 2)
+	assert.True(t, latest.Namespaces.Namespaces["metrics"].BootstrapEnabled)
+
+	// An unchanged snapshot isn't added again.
+	added, err = AddMetadataSnapshot(cm, newTestMetadataSnapshot(2), 2)
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	// Only the most recent snapshots are kept.
+	for _, n := range []int{3, 4} {
+		added, err = AddMetadataSnapshot(cm, newTestMetadataSnapshot(n), 2)
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	assert.Equal(t, []string{"snapshot-0000000002.json.gz", "snapshot-0000000003.json.gz"}, metadataSnapshotKeys(cm))
+
+	latest, err = LatestMetadataSnapshot(cm)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), latest.Version)
+	assert.Len(t, latest.Placement.Instances, 4)
+
+	snapshot, err := MetadataSnapshotVersion(cm, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snapshot.Version)
+	assert.Len(t, snapshot.Placement.Instances, 3)
+
+	snapshot, err = MetadataSnapshotVersion(cm, 1)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestPlacementInitRequestFromSnapshot(t *testing.T) {
+	snapshot := newTestMetadataSnapshot(3)
+	req := PlacementInitRequestFromSnapshot(snapshot)
+
+	assert.Equal(t, int32(4), req.NumShards)
+	assert.Equal(t, int32(1), req.ReplicationFactor)
+	require.Len(t, req.Instances, 3)
+	for i, id := range []string{"a", "b", "c"} {
+		inst := req.Instances[i]
+		assert.Equal(t, id, inst.Id)
+		assert.Equal(t, "group-"+id, inst.IsolationGroup)
+		assert.Empty(t, inst.Shards)
+	}
+
+	// The snapshot itself is left untouched.
+	assert.Len(t, snapshot.Placement.Instances["a"].Shards, 1)
+}
@@ -34,8 +34,18 @@ import (
 
 const (
 	_zoneEmbedded = "embedded"
+
+	_defaultInstanceWeight = 100
 )
 
+// ServiceZone returns the zone of the cluster's M3 KV service.
+func ServiceZone(cluster *myspec.M3DBCluster) string {
+	if zone := cluster.Spec.ServiceZone; zone != "" {
+		return zone
+	}
+	return _zoneEmbedded
+}
+
 // PlacementInstanceFromPod creates a new m3cluster placement instance given a
 // pod spec.
 func PlacementInstanceFromPod(cluster *myspec.M3DBCluster, pod *corev1.Pod, idProvider podidentity.Provider) (*placementpb.Instance, error) {
@@ -54,19 +64,66 @@ func PlacementInstanceFromPod(cluster *myspec.M3DBCluster, pod *corev1.Pod, idPr
 		return nil, err
 	}
 
+	zone, weight := ServiceZone(cluster), uint32(_defaultInstanceWeight)
+	if group, ok := myspec.IsolationGroups(cluster.Spec.IsolationGroups).GetByName(isoGroup); ok {
+		if group.Zone != "" {
+			zone = group.Zone
+		}
+		if group.Weight != 0 {
+			weight = group.Weight
+		}
+	}
+
 	setService := HeadlessServiceName(cluster.Name)
 	hostname := pod.Name + "." + setService
+	port := ClusterPorts(cluster).Client
 
-	// TODO(schallert): dynamic zone, portname consts
 	instance := &placementpb.Instance{
 		Id:             idStr,
 		IsolationGroup: isoGroup,
-		Zone:           _zoneEmbedded,
-		Weight:         100,
+		Zone:           zone,
+		Weight:         weight,
 		Hostname:       hostname,
-		Endpoint:       fmt.Sprintf("%s:%d", hostname, 9000),
-		Port:           9000,
+		Endpoint:       fmt.Sprintf("%s:%d", hostname, port),
+		Port:           uint32(port),
 	}
 
 	return instance, nil
 }
+
+// AggregatorPlacementInstance creates the aggregator placement instance of the
+// pod with the given ordinal in the aggregator StatefulSet of an isolation
+// group. Aggregator placements are mirrored: the pods with the same ordinal in
+// every group form one shard set.
+func AggregatorPlacementInstance(cluster *myspec.M3DBCluster, stsID int, isoGroup string, ordinal int) placementpb.Instance {
+	podName := fmt.Sprintf("%s-%d", AggregatorStatefulSetName(cluster.Name, stsID), ordinal)
+	hostname := podName + "." + AggregatorServiceName(cluster.Name)
+
+	return placementpb.Instance{
+		Id:             podName,
+		IsolationGroup: isoGroup,
+		Zone:           ServiceZone(cluster),
+		Weight:         _defaultInstanceWeight,
+		Hostname:       hostname,
+		Endpoint:       fmt.Sprintf("%s:%d", hostname, PortM3AggregatorM3Msg),
+		Port:           uint32(PortM3AggregatorM3Msg),
+		ShardSetId:     uint32(ordinal + 1),
+	}
+}
+
+// CoordinatorPlacementInstance creates the single instance of the coordinator
+// placement, through which aggregators reach the cluster's coordinators to
+// deliver aggregated metrics. It points at the coordinator service, which
+// spreads connections across the coordinators.
+func CoordinatorPlacementInstance(cluster *myspec.M3DBCluster) placementpb.Instance {
+	hostname := CoordinatorServiceName(cluster.Name)
+
+	return placementpb.Instance{
+		Id:       hostname,
+		Zone:     ServiceZone(cluster),
+		Weight:   _defaultInstanceWeight,
+		Hostname: hostname,
+		Endpoint: fmt.Sprintf("%s:%d", hostname, PortM3CoordinatorM3Msg),
+		Port:     uint32(PortM3CoordinatorM3Msg),
+	}
+}
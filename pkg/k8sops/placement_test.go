@@ -49,7 +49,7 @@ func TestPlacementInstanceFromPod(t *testing.T) {
 
 	idProvider := podidentity.NewMockProvider(mc)
 	podID := &myspec.PodIdentity{Name: "pod-a"}
-	idProvider.EXPECT().Identity(pod, cluster).Return(podID, nil)
+	idProvider.EXPECT().Identity(pod, cluster).Return(podID, nil).AnyTimes()
 
 	_, err := PlacementInstanceFromPod(cluster, pod, idProvider)
 	assert.Error(t, err)
@@ -71,4 +71,60 @@ func TestPlacementInstanceFromPod(t *testing.T) {
 	inst, err := PlacementInstanceFromPod(cluster, pod, idProvider)
 	assert.NoError(t, err)
 	assert.Equal(t, expInst, inst)
+
+	// Groups default to the cluster's service zone.
+	cluster.Spec.ServiceZone = "us-east1"
+	cluster.Spec.IsolationGroups = []myspec.IsolationGroup{{Name: "zone-a"}}
+	expInst.Zone = "us-east1"
+
+	inst, err = PlacementInstanceFromPod(cluster, pod, idProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, expInst, inst)
+
+	cluster.Spec.IsolationGroups[0].Zone = "us-east1-b"
+	cluster.Spec.IsolationGroups[0].Weight = 200
+	expInst.Zone = "us-east1-b"
+	expInst.Weight = 200
+
+	inst, err = PlacementInstanceFromPod(cluster, pod, idProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, expInst, inst)
+}
+
+func TestAggregatorPlacementInstance(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-a"},
+	}
+
+	expInst := placementpb.Instance{
+		Id:             "m3aggregator-cluster-a-rep1-2",
+		IsolationGroup: "zone-b",
+		Zone:           "embedded",
+		Weight:         100,
+		Hostname:       "m3aggregator-cluster-a-rep1-2.m3aggregator-cluster-a",
+		Endpoint:       "m3aggregator-cluster-a-rep1-2.m3aggregator-cluster-a:6000",
+		Port:           6000,
+		ShardSetId:     3,
+	}
+	assert.Equal(t, expInst, AggregatorPlacementInstance(cluster, 1, "zone-b", 2))
+
+	cluster.Spec.ServiceZone = "us-east1"
+	expInst.Zone = "us-east1"
+	assert.Equal(t, expInst, AggregatorPlacementInstance(cluster, 1, "zone-b", 2))
+}
+
+func TestCoordinatorPlacementInstance(t *testing.T) {
+	cluster := &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-a"},
+	}
+
+	expInst := placementpb.Instance{
+		Id:       "m3coordinator-cluster-a",
+		Zone:     "embedded",
+		Weight:   100,
+		Hostname: "m3coordinator-cluster-a",
+		Endpoint: "m3coordinator-cluster-a:7507",
+		Port:     7507,
+	}
+	assert.Equal(t, expInst, CoordinatorPlacementInstance(cluster))
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var errNoSelector = errors.New("statefulset has no selector")
+
+// GeneratePodDisruptionBudget creates the PodDisruptionBudget of one of the
+// cluster's M3DB StatefulSets. Each StatefulSet runs one isolation group,
+// which holds a single replica of every shard, so only one of its pods may be
+// disrupted at a time.
+func GeneratePodDisruptionBudget(cluster *myspec.M3DBCluster, set *appsv1.StatefulSet) (*policyv1beta1.PodDisruptionBudget, error) {
+	if set.Spec.Selector == nil {
+		return nil, errNoSelector
+	}
+
+	pdbLabels := make(map[string]string, len(set.Labels))
+	for k, v := range set.Labels {
+		pdbLabels[k] = v
+	}
+
+	maxUnavailable := intstr.FromInt(1)
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            set.Name,
+			Namespace:       cluster.Namespace,
+			Labels:          pdbLabels,
+			OwnerReferences: []metav1.OwnerReference{*GenerateOwnerRef(cluster)},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector:       set.Spec.Selector.DeepCopy(),
+			MaxUnavailable: &maxUnavailable,
+		},
+	}, nil
+}
+
+// PodDisruptionBudgetSpecHash returns a hash of the spec of a
+// PodDisruptionBudget. Budgets can't be updated in place before Kubernetes
+// 1.15, so the hash names the replacement of a budget whose spec changed.
+func PodDisruptionBudgetSpecHash(pdb *policyv1beta1.PodDisruptionBudget) (string, error) {
+	data, err := json.Marshal(pdb.Spec)
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum32()), nil
+}
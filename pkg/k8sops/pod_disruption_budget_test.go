@@ -0,0 +1,82 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePodDisruptionBudget(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	set, err := GenerateStatefulSet(cluster, StatefulSetName(cluster.Name, 0), cluster.Spec.IsolationGroups[0].Name, 3)
+	require.NoError(t, err)
+
+	pdb, err := GeneratePodDisruptionBudget(cluster, set)
+	require.NoError(t, err)
+
+	assert.Equal(t, set.Name, pdb.Name)
+	assert.Equal(t, cluster.Namespace, pdb.Namespace)
+	assert.Equal(t, set.Labels, pdb.Labels)
+	assert.Equal(t, set.Spec.Selector, pdb.Spec.Selector)
+	assert.Equal(t, set.Name, pdb.Spec.Selector.MatchLabels[labels.StatefulSet])
+	assert.Equal(t, intstr.FromInt(1), *pdb.Spec.MaxUnavailable)
+	require.Len(t, pdb.OwnerReferences, 1)
+	assert.Equal(t, cluster.Name, pdb.OwnerReferences[0].Name)
+
+	// The budget doesn't share the set's maps.
+	pdb.Labels["foo"] = "bar"
+	_, ok := set.Labels["foo"]
+	assert.False(t, ok)
+
+	_, err = GeneratePodDisruptionBudget(cluster, &appsv1.StatefulSet{})
+	assert.Error(t, err)
+}
+
+func TestPodDisruptionBudgetSpecHash(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	set, err := GenerateStatefulSet(cluster, StatefulSetName(cluster.Name, 0), cluster.Spec.IsolationGroups[0].Name, 3)
+	require.NoError(t, err)
+
+	pdb, err := GeneratePodDisruptionBudget(cluster, set)
+	require.NoError(t, err)
+	hash, err := PodDisruptionBudgetSpecHash(pdb)
+	require.NoError(t, err)
+
+	// Only the spec is hashed.
+	pdb.Labels["foo"] = "bar"
+	sameHash, err := PodDisruptionBudgetSpecHash(pdb)
+	require.NoError(t, err)
+	assert.Equal(t, hash, sameHash)
+
+	maxUnavailable := intstr.FromInt(2)
+	pdb.Spec.MaxUnavailable = &maxUnavailable
+	newHash, err := PodDisruptionBudgetSpecHash(pdb)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, newHash)
+}
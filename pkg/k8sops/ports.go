@@ -20,10 +20,16 @@
 
 package k8sops
 
+import (
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+)
+
 // Port represents a port number.
 type Port int32
 
-// Ports used by M3DB nodes and coordinator nodes.
+// Default ports used by M3DB nodes, coordinator nodes and aggregator nodes.
+// The M3DB and coordinator API and metrics ports can be overridden by the
+// cluster's ports spec.
 const (
 	PortM3DBNodeClient  Port = 9000
 	PortM3DBNodeCluster      = 9001
@@ -33,4 +39,42 @@ const (
 
 	PortM3Coordinator        = 7201
 	PortM3CoordinatorMetrics = 7203
+	PortM3CoordinatorM3Msg   = 7507
+
+	PortM3AggregatorM3Msg   = 6000
+	PortM3AggregatorHTTP    = 6001
+	PortM3AggregatorMetrics = 6002
 )
+
+const _defaultListenAddress = "0.0.0.0"
+
+// ClusterPorts returns the ports of the cluster's M3DB nodes and coordinators,
+// with unset ports filled in with their defaults.
+func ClusterPorts(cluster *myspec.M3DBCluster) myspec.PortsSpec {
+	ports := myspec.PortsSpec{}
+	if cluster.Spec.Ports != nil {
+		ports = *cluster.Spec.Ports
+	}
+
+	if ports.ListenAddress == "" {
+		ports.ListenAddress = _defaultListenAddress
+	}
+	for _, p := range []struct {
+		port *int32
+		def  Port
+	}{
+		{&ports.Client, PortM3DBNodeClient},
+		{&ports.Cluster, PortM3DBNodeCluster},
+		{&ports.HTTPNode, PortM3DBHTTPNode},
+		{&ports.HTTPCluster, PortM3DBHTTPCluster},
+		{&ports.Debug, PortM3DBDebug},
+		{&ports.Coordinator, PortM3Coordinator},
+		{&ports.CoordinatorMetrics, PortM3CoordinatorMetrics},
+	} {
+		if *p.port == 0 {
+			*p.port = int32(p.def)
+		}
+	}
+
+	return ports
+}
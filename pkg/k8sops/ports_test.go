@@ -0,0 +1,118 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package k8sops
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/k8sops/podidentity"
+
+	"github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterPorts(t *testing.T) {
+	cluster := &myspec.M3DBCluster{}
+	assert.Equal(t, myspec.PortsSpec{
+		ListenAddress:      "0.0.0.0",
+		Client:             9000,
+		Cluster:            9001,
+		HTTPNode:           9002,
+		HTTPCluster:        9003,
+		Debug:              9004,
+		Coordinator:        7201,
+		CoordinatorMetrics: 7203,
+	}, ClusterPorts(cluster))
+
+	cluster.Spec.Ports = &myspec.PortsSpec{ListenAddress: "10.0.0.1", Client: 19000}
+	ports := ClusterPorts(cluster)
+	assert.Equal(t, "10.0.0.1", ports.ListenAddress)
+	assert.Equal(t, int32(19000), ports.Client)
+	assert.Equal(t, int32(9001), ports.Cluster)
+
+	// The spec isn't modified.
+	assert.Equal(t, int32(0), cluster.Spec.Ports.Cluster)
+}
+
+func TestCustomPorts(t *testing.T) {
+	cluster := getFixture("testM3DBCluster.yaml", t)
+	cluster.Spec.Ports = &myspec.PortsSpec{
+		Client:      19000,
+		HTTPNode:    19002,
+		Coordinator: 17201,
+	}
+
+	set, err := GenerateStatefulSet(cluster, StatefulSetName(cluster.Name, 0), cluster.Spec.IsolationGroups[0].Name, 3)
+	require.NoError(t, err)
+	container := set.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Ports, corev1.ContainerPort{Name: "client", ContainerPort: 19000, Protocol: corev1.ProtocolTCP})
+	assert.Contains(t, container.Ports, corev1.ContainerPort{Name: "cluster", ContainerPort: 9001, Protocol: corev1.ProtocolTCP})
+	assert.Equal(t, 19002, container.LivenessProbe.HTTPGet.Port.IntValue())
+	assert.Equal(t, 19002, container.ReadinessProbe.HTTPGet.Port.IntValue())
+
+	svc, err := GenerateM3DBService(cluster)
+	require.NoError(t, err)
+	assert.Contains(t, svc.Spec.Ports, corev1.ServicePort{Name: "client", Port: 19000, Protocol: corev1.ProtocolTCP})
+
+	svc, err = GenerateCoordinatorService(cluster)
+	require.NoError(t, err)
+	assert.Contains(t, svc.Spec.Ports, corev1.ServicePort{Name: "coordinator", Port: 17201, Protocol: corev1.ProtocolTCP})
+	assert.Equal(t, "http://m3coordinator-m3db-cluster.foo:17201", CoordinatorURL(cluster))
+
+	cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	deployment, err := GenerateCoordinatorDeployment(cluster)
+	require.NoError(t, err)
+	container = deployment.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Ports, corev1.ContainerPort{Name: "coordinator", ContainerPort: 17201, Protocol: corev1.ProtocolTCP})
+	assert.Equal(t, 17201, container.LivenessProbe.HTTPGet.Port.IntValue())
+
+	require.NoError(t, registerValidConfigMap())
+	cluster.Spec.Coordinator = nil
+	cluster.Spec.Ports.ListenAddress = "10.0.0.1"
+	cm, err := GenerateDefaultConfigMap(cluster)
+	require.NoError(t, err)
+	data := cm.Data["m3.yml"]
+	assert.Contains(t, data, `value: "10.0.0.1:17201"`)
+	assert.Contains(t, data, "  listenAddress: 10.0.0.1:19000\n")
+	assert.Contains(t, data, "clusterListenAddress: 10.0.0.1:9001\n")
+	assert.Contains(t, data, "httpNodeListenAddress: 10.0.0.1:19002\n")
+
+	mc := gomock.NewController(t)
+	defer mc.Finish()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pod-a",
+			Labels: map[string]string{labels.IsolationGroup: cluster.Spec.IsolationGroups[0].Name},
+		},
+	}
+	idProvider := podidentity.NewMockProvider(mc)
+	idProvider.EXPECT().Identity(pod, cluster).Return(&myspec.PodIdentity{Name: "pod-a"}, nil)
+	inst, err := PlacementInstanceFromPod(cluster, pod, idProvider)
+	require.NoError(t, err)
+	assert.Equal(t, "pod-a.m3dbnode-m3db-cluster:19000", inst.Endpoint)
+	assert.Equal(t, uint32(19000), inst.Port)
+}
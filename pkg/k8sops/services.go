@@ -37,6 +37,8 @@
 package k8sops
 
 import (
+	"reflect"
+
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 
 	"k8s.io/api/core/v1"
@@ -62,9 +64,10 @@ func (k *k8sops) DeleteService(cluster *myspec.M3DBCluster, name string) error {
 	return k.kclient.CoreV1().Services(cluster.GetNamespace()).Delete(name, &metav1.DeleteOptions{})
 }
 
-// EnsureService will create a service by name if it doesn't exist
+// EnsureService will create a service by name if it doesn't exist, and update
+// the selector of an existing service if it has changed.
 func (k *k8sops) EnsureService(cluster *myspec.M3DBCluster, svc *v1.Service) error {
-	_, err := k.GetService(cluster, svc.Name)
+	existing, err := k.GetService(cluster, svc.Name)
 	if errors.IsNotFound(err) {
 		k.logger.Info("service doesn't exist, creating it", zap.String("service", svc.Name))
 		selfRef := metav1.NewControllerRef(cluster, schema.GroupVersionKind{
@@ -81,6 +84,13 @@ func (k *k8sops) EnsureService(cluster *myspec.M3DBCluster, svc *v1.Service) err
 		return nil
 	} else if err != nil {
 		return err
+	} else if !reflect.DeepEqual(existing.Spec.Selector, svc.Spec.Selector) {
+		existing = existing.DeepCopy()
+		existing.Spec.Selector = svc.Spec.Selector
+		if _, err := k.kclient.CoreV1().Services(cluster.GetNamespace()).Update(existing); err != nil {
+			return err
+		}
+		k.logger.Info("updated service selector", zap.String("service", svc.GetName()))
 	}
 	return nil
 }
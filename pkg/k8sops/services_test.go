@@ -23,6 +23,9 @@ package k8sops
 import (
 	"testing"
 
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +50,21 @@ func TestService(t *testing.T) {
 	err = k.DeleteService(fixture, svcName)
 	require.NotNil(t, err)
 }
+
+func TestEnsureServiceUpdatesSelector(t *testing.T) {
+	fixture := getFixture("testM3DBCluster.yaml", t)
+	k := newFakeK8sops(t)
+
+	svcCfg, err := GenerateCoordinatorService(fixture)
+	require.NoError(t, err)
+	require.NoError(t, k.EnsureService(fixture, svcCfg))
+
+	fixture.Spec.Coordinator = &myspec.CoordinatorSpec{}
+	svcCfg, err = GenerateCoordinatorService(fixture)
+	require.NoError(t, err)
+	require.NoError(t, k.EnsureService(fixture, svcCfg))
+
+	svc, err := k.GetService(fixture, svcCfg.Name)
+	require.NoError(t, err)
+	require.Equal(t, labels.ComponentCoordinator, svc.Spec.Selector[labels.Component])
+}
@@ -21,8 +21,10 @@
 package k8sops
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
@@ -46,6 +48,32 @@ var (
 	errEmptyNodeAffinityValues = errors.New("node affinity term values cannot be empty")
 )
 
+// clusterProbeOptions returns the cluster's probe options, using the default
+// for any option that is unset.
+func clusterProbeOptions(cluster *myspec.M3DBCluster) myspec.ProbeOptions {
+	opts := myspec.ProbeOptions{
+		TimeoutSeconds:      _probeTimeoutSeconds,
+		InitialDelaySeconds: _probeInitialDelaySeconds,
+		FailureThreshold:    _probeFailureThreshold,
+	}
+
+	configured := cluster.Spec.ProbeOptions
+	if configured == nil {
+		return opts
+	}
+
+	if configured.TimeoutSeconds != 0 {
+		opts.TimeoutSeconds = configured.TimeoutSeconds
+	}
+	if configured.InitialDelaySeconds != 0 {
+		opts.InitialDelaySeconds = configured.InitialDelaySeconds
+	}
+	if configured.FailureThreshold != 0 {
+		opts.FailureThreshold = configured.FailureThreshold
+	}
+	return opts
+}
+
 // NewBaseProbe returns a probe configured for default ports.
 
 // NewBaseStatefulSet returns a base configured stateful set.
@@ -68,13 +96,15 @@ func NewBaseStatefulSet(ssName, isolationGroup string, cluster *myspec.M3DBClust
 	// TODO(schallert): we're currently using the health of the coordinator for
 	// liveness probes until https://github.com/m3db/m3/issues/996 is fixed. Move
 	// to the dbnode's health endpoint once fixed.
+	probeOpts := clusterProbeOptions(cluster)
+	probePort := intstr.FromInt(int(ClusterPorts(cluster).HTTPNode))
 	probeHealth := &v1.Probe{
-		TimeoutSeconds:      _probeTimeoutSeconds,
-		InitialDelaySeconds: _probeInitialDelaySeconds,
-		FailureThreshold:    _probeFailureThreshold,
+		TimeoutSeconds:      probeOpts.TimeoutSeconds,
+		InitialDelaySeconds: probeOpts.InitialDelaySeconds,
+		FailureThreshold:    probeOpts.FailureThreshold,
 		Handler: v1.Handler{
 			HTTPGet: &v1.HTTPGetAction{
-				Port:   intstr.FromInt(PortM3DBHTTPNode),
+				Port:   probePort,
 				Path:   _probePathHealth,
 				Scheme: v1.URISchemeHTTP,
 			},
@@ -82,12 +112,12 @@ func NewBaseStatefulSet(ssName, isolationGroup string, cluster *myspec.M3DBClust
 	}
 
 	probeReady := &v1.Probe{
-		TimeoutSeconds:      _probeTimeoutSeconds,
-		InitialDelaySeconds: _probeInitialDelaySeconds,
-		FailureThreshold:    _probeFailureThreshold,
+		TimeoutSeconds:      probeOpts.TimeoutSeconds,
+		InitialDelaySeconds: probeOpts.InitialDelaySeconds,
+		FailureThreshold:    probeOpts.FailureThreshold,
 		Handler: v1.Handler{
 			HTTPGet: &v1.HTTPGetAction{
-				Port:   intstr.FromInt(PortM3DBHTTPNode),
+				Port:   probePort,
 				Path:   _probePathReady,
 				Scheme: v1.URISchemeHTTP,
 			},
@@ -229,6 +259,37 @@ func GenerateStatefulSetAffinity(isoGroup myspec.IsolationGroup) (*v1.Affinity,
 	}, nil
 }
 
+// StatefulSetSpecHash returns a hash of the parts of a StatefulSet that the
+// operator updates in place: its labels, its annotations (excluding the hash
+// itself) and its pod template. Comparing hashes of generated sets rather than
+// the sets themselves avoids spurious diffs from fields defaulted by the API
+// server.
+func StatefulSetSpecHash(sts *appsv1.StatefulSet) (string, error) {
+	setAnnotations := make(map[string]string, len(sts.Annotations))
+	for k, v := range sts.Annotations {
+		if k != annotations.SpecHash {
+			setAnnotations[k] = v
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Labels      map[string]string  `json:"labels"`
+		Annotations map[string]string  `json:"annotations"`
+		Template    v1.PodTemplateSpec `json:"template"`
+	}{
+		Labels:      sts.Labels,
+		Annotations: setAnnotations,
+		Template:    sts.Spec.Template,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
+
 // GenerateOwnerRef generates an owner reference to a given m3db cluster.
 func GenerateOwnerRef(cluster *myspec.M3DBCluster) *metav1.OwnerReference {
 	return metav1.NewControllerRef(cluster, schema.GroupVersionKind{
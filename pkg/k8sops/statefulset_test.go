@@ -24,9 +24,11 @@ import (
 	"testing"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/kubernetes/utils/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -179,3 +181,49 @@ func TestGenerateStatefulSetAffinity(t *testing.T) {
 		assert.Equal(t, expTerms, terms[0].MatchExpressions)
 	}
 }
+
+func TestStatefulSetSpecHash(t *testing.T) {
+	fixture := getFixture("testM3DBCluster.yaml", t)
+	sts, err := GenerateStatefulSet(fixture, StatefulSetName(fixture.Name, 0), fixture.Spec.IsolationGroups[0].Name, 1)
+	require.NoError(t, err)
+
+	hash, err := StatefulSetSpecHash(sts)
+	require.NoError(t, err)
+	assert.NotEmpty(t, hash)
+
+	// Replica count and the hash annotation itself don't affect the hash.
+	sts.Spec.Replicas = pointer.Int32Ptr(3)
+	sts.Annotations[annotations.SpecHash] = hash
+	newHash, err := StatefulSetSpecHash(sts)
+	require.NoError(t, err)
+	assert.Equal(t, hash, newHash)
+
+	sts.Spec.Template.Spec.Containers[0].Image = "foo/m3dbnode:latest"
+	newHash, err = StatefulSetSpecHash(sts)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, newHash)
+}
+
+func TestStatefulSetProbeOptions(t *testing.T) {
+	fixture := getFixture("testM3DBCluster.yaml", t)
+	sts, err := GenerateStatefulSet(fixture, StatefulSetName(fixture.Name, 0), fixture.Spec.IsolationGroups[0].Name, 1)
+	require.NoError(t, err)
+
+	container := sts.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, int32(_probeTimeoutSeconds), container.LivenessProbe.TimeoutSeconds)
+	assert.Equal(t, int32(_probeFailureThreshold), container.ReadinessProbe.FailureThreshold)
+
+	fixture.Spec.ProbeOptions = &myspec.ProbeOptions{
+		TimeoutSeconds:   5,
+		FailureThreshold: 3,
+	}
+	sts, err = GenerateStatefulSet(fixture, StatefulSetName(fixture.Name, 0), fixture.Spec.IsolationGroups[0].Name, 1)
+	require.NoError(t, err)
+
+	container = sts.Spec.Template.Spec.Containers[0]
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+		assert.Equal(t, int32(5), probe.TimeoutSeconds)
+		assert.Equal(t, int32(_probeInitialDelaySeconds), probe.InitialDelaySeconds)
+		assert.Equal(t, int32(3), probe.FailureThreshold)
+	}
+}
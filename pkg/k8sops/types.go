@@ -31,7 +31,12 @@ import (
 type K8sops interface {
 	// CreateOrUpdateCRD creates the CRD if it does not exist, or updates it to
 	// contain the latest spec if it does exist.
-	CreateOrUpdateCRD(name string, enableValidation bool) error
+	CreateOrUpdateCRD(name string, opts CRDOptions) error
+
+	// MigrateCRDStorageVersion rewrites all objects of the CRD in the given
+	// storage version and drops all other versions from the CRD's stored
+	// versions.
+	MigrateCRDStorageVersion(name, storageVersion string) error
 
 	// GetService simply gets a service by name
 	GetService(cluster *myspec.M3DBCluster, name string) (*v1.Service, error)
@@ -39,7 +44,8 @@ type K8sops interface {
 	// DeleteService simply deletes a service by name
 	DeleteService(cluster *myspec.M3DBCluster, name string) error
 
-	// EnsureService will create a service by name if it doesn't exist
+	// EnsureService will create a service by name if it doesn't exist, and
+	// update the selector of an existing service if it has changed.
 	EnsureService(cluster *myspec.M3DBCluster, svc *v1.Service) error
 
 	// Events returns an Event interface for a given namespace.
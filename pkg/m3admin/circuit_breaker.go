@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package m3admin
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultBreakerFailureThreshold = 5
+	_defaultBreakerCooldown         = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned for requests that aren't sent because the
+// coordinator failed too many requests in a row.
+var ErrCircuitOpen = errors.New("circuit breaker open, coordinator unavailable")
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failed requests after
+	// which the breaker opens. Defaults to 5.
+	FailureThreshold int
+	// Cooldown is how long an open breaker fails requests before letting one
+	// through to probe the coordinator. Defaults to 30s.
+	Cooldown time.Duration
+}
+
+// CircuitBreaker keeps clients from sending requests to a coordinator that
+// keeps failing them. Once FailureThreshold requests in a row have failed with
+// a connection error or a 5xx status, the breaker opens and requests fail fast
+// with ErrCircuitOpen rather than waiting out their retries. One request per
+// Cooldown is let through while open, and the breaker closes again as soon as
+// one succeeds.
+//
+// A breaker is safe for concurrent use, and is meant to be shared by all the
+// clients of a cluster's coordinator.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+	nowFn            func() time.Time
+
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns a new closed CircuitBreaker.
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	b := &CircuitBreaker{
+		failureThreshold: opts.FailureThreshold,
+		cooldown:         opts.Cooldown,
+		nowFn:            time.Now,
+	}
+	if b.failureThreshold <= 0 {
+		b.failureThreshold = _defaultBreakerFailureThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = _defaultBreakerCooldown
+	}
+	return b
+}
+
+// Allow returns ErrCircuitOpen if a request shouldn't be sent.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.failureThreshold {
+		return nil
+	}
+
+	now := b.nowFn()
+	if now.Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+
+	// Let this request probe the coordinator, and keep failing others until
+	// it completes or another cooldown passes.
+	b.openedAt = now
+	return nil
+}
+
+// Record records the outcome of a request.
+func (b *CircuitBreaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.failureThreshold {
+		b.openedAt = b.nowFn()
+	}
+}
+
+// Open returns whether the breaker is currently failing requests.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.failureThreshold
+}
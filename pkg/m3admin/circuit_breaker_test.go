@@ -0,0 +1,71 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package m3admin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 3, Cooldown: time.Minute})
+	b.nowFn = func() time.Time { return now }
+
+	// Successes reset the count of consecutive failures.
+	b.Record(true)
+	b.Record(true)
+	b.Record(false)
+	b.Record(true)
+	b.Record(true)
+	assert.False(t, b.Open())
+	assert.NoError(t, b.Allow())
+
+	b.Record(true)
+	assert.True(t, b.Open())
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// A single probe is let through after the cooldown.
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// A failed probe keeps the breaker open for another cooldown.
+	b.Record(true)
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// A successful probe closes it.
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, b.Allow())
+	b.Record(false)
+	assert.False(t, b.Open())
+	assert.NoError(t, b.Allow())
+	assert.NoError(t, b.Allow())
+}
+
+func TestCircuitBreakerDefaults(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerOptions{})
+	assert.Equal(t, _defaultBreakerFailureThreshold, b.failureThreshold)
+	assert.Equal(t, _defaultBreakerCooldown, b.cooldown)
+}
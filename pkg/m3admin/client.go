@@ -22,40 +22,48 @@ package m3admin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 
 	retryhttp "github.com/hashicorp/go-retryablehttp"
-	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 const (
 	m3EnvironmentHeader = "Cluster-Environment-Name"
-)
-
-var (
-	// ErrNotOk indicates that HTTP status was not Ok
-	ErrNotOk = errors.New("status not ok")
-
-	// ErrNotFound indicates that HTTP status was not found
-	ErrNotFound = errors.New("status not found")
+	m3ZoneHeader        = "Cluster-Zone-Name"
 )
 
 // Client is an m3admin client.
 type Client interface {
-	DoHTTPRequest(action, url string, data *bytes.Buffer) (*http.Response, error)
+	// DoHTTPRequest sends a request, retrying it as configured until ctx is
+	// done. Responses without a 2xx status are returned as an *HTTPError.
+	DoHTTPRequest(ctx context.Context, action, url string, data *bytes.Buffer, opts ...RequestOption) (*http.Response, error)
+}
+
+// RequestOption configures a single request made by a client.
+type RequestOption func(*http.Request)
+
+// WithHeader sets a header on a request, such as the Topic-Name header of the
+// topic APIs.
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
 }
 
 type client struct {
 	client      *retryhttp.Client
 	logger      *zap.Logger
 	environment string
+	zone        string
+	headers     http.Header
+	breaker     *CircuitBreaker
 }
 
 type nullLogger struct{}
@@ -73,26 +81,66 @@ func NewClient(clientOpts ...Option) Client {
 		client:      opts.client,
 		logger:      opts.logger,
 		environment: opts.environment,
+		zone:        opts.zone,
+		headers:     opts.headers,
+		breaker:     opts.breaker,
 	}
 
 	if client.client == nil {
 		client.client = retryhttp.NewClient()
+		// The default client gets a transport of its own, so it's safe to
+		// configure it.
+		transport, ok := client.client.HTTPClient.Transport.(*http.Transport)
+		if ok && opts.tlsConfig != nil {
+			transport.TLSClientConfig = opts.tlsConfig
+		}
+		if opts.transport != nil {
+			client.client.HTTPClient.Transport = opts.transport
+		}
 	}
 	if client.logger == nil {
 		client.logger = zap.NewNop()
 	}
 
+	if opts.timeout > 0 {
+		client.client.HTTPClient.Timeout = opts.timeout
+	}
+	if p := opts.retryPolicy; p != nil {
+		client.client.RetryMax = p.MaxRetries
+		client.client.RetryWaitMin = p.MinWait
+		client.client.RetryWaitMax = p.MaxWait
+	}
+
 	// We do our own request logging, silence their logger.
 	client.client.Logger = nullLogger{}
 	client.client.ErrorHandler = retryhttp.PassthroughErrorHandler
+	client.client.CheckRetry = client.checkRetry
 
 	return client
 }
 
+// checkRetry records the outcome of each attempt of a request on the circuit
+// breaker, and stops retrying once the breaker opens so that an unreachable
+// coordinator doesn't hold up the caller for all of its retries.
+func (c *client) checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	// A request given up on by its caller says nothing of the coordinator.
+	if c.breaker != nil && ctx.Err() == nil {
+		c.breaker.Record(err != nil || resp.StatusCode >= 500)
+	}
+
+	retry, checkErr := retryhttp.DefaultRetryPolicy(ctx, resp, err)
+	if retry && c.breaker != nil && c.breaker.Open() {
+		return false, nil
+	}
+	return retry, checkErr
+}
+
 // DoHTTPRequest is a simple helper for HTTP requests
 func (c *client) DoHTTPRequest(
+	ctx context.Context,
 	action, url string,
 	data *bytes.Buffer,
+	opts ...RequestOption,
 ) (*http.Response, error) {
 
 	l := c.logger.With(zap.String("action", action), zap.String("url", url))
@@ -114,10 +162,22 @@ func (c *client) DoHTTPRequest(
 		}
 	}
 
+	request = request.WithContext(ctx)
 	request.Header.Add("Content-Type", "application/json")
 	if c.environment != "" {
 		request.Header.Add(m3EnvironmentHeader, c.environment)
 	}
+	if c.zone != "" {
+		request.Header.Add(m3ZoneHeader, c.zone)
+	}
+	for key, values := range c.headers {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	for _, opt := range opts {
+		opt(request.Request)
+	}
 
 	if l.Core().Enabled(zapcore.DebugLevel) {
 		dump, err := httputil.DumpRequest(request.Request, true)
@@ -128,6 +188,13 @@ func (c *client) DoHTTPRequest(
 		}
 	}
 
+	if c.breaker != nil {
+		if err := c.breaker.Allow(); err != nil {
+			l.Debug("not sending request", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	response, err := c.client.Do(request)
 	if err != nil {
 		l.Debug("request error", zap.Error(err))
@@ -160,11 +227,7 @@ func (c *client) DoHTTPRequest(
 		l.Debug("error parsing error response", zap.Error(err))
 	}
 
-	if response.StatusCode == http.StatusNotFound {
-		return nil, pkgerrors.WithMessage(ErrNotFound, errMsg)
-	}
-
-	return nil, pkgerrors.WithMessage(ErrNotOk, errMsg)
+	return nil, &HTTPError{StatusCode: code, Message: errMsg}
 }
 
 func parseResponseError(r *http.Response) (string, error) {
@@ -26,6 +26,7 @@ package m3admin
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"reflect"
 
@@ -56,16 +57,21 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // DoHTTPRequest mocks base method
-func (m *MockClient) DoHTTPRequest(action, url string, data *bytes.Buffer) (*http.Response, error) {
+func (m *MockClient) DoHTTPRequest(ctx context.Context, action, url string, data *bytes.Buffer, opts ...RequestOption) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DoHTTPRequest", action, url, data)
+	varargs := []interface{}{ctx, action, url, data}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DoHTTPRequest", varargs...)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DoHTTPRequest indicates an expected call of DoHTTPRequest
-func (mr *MockClientMockRecorder) DoHTTPRequest(action, url, data interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) DoHTTPRequest(ctx, action, url, data interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoHTTPRequest", reflect.TypeOf((*MockClient)(nil).DoHTTPRequest), action, url, data)
+	varargs := append([]interface{}{ctx, action, url, data}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoHTTPRequest", reflect.TypeOf((*MockClient)(nil).DoHTTPRequest), varargs...)
 }
@@ -21,12 +21,16 @@
 package m3admin
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	retryhttp "github.com/hashicorp/go-retryablehttp"
 	pkgerrors "github.com/pkg/errors"
@@ -64,7 +68,7 @@ func TestClient_DoHTTPRequest(t *testing.T) {
 	}
 
 	cl := newTestClient()
-	resp, err := cl.DoHTTPRequest("GET", s.URL, nil)
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, resp.StatusCode, http.StatusOK)
 	assert.Equal(t, []byte("hello"), readAll(resp.Body))
@@ -74,7 +78,7 @@ func TestClient_DoHTTPRequest(t *testing.T) {
 	require.NoError(t, err)
 
 	cl = NewClient(WithLogger(l), WithHTTPClient(devNullRetry()))
-	resp, err = cl.DoHTTPRequest("GET", s.URL, nil)
+	resp, err = cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, resp.StatusCode, http.StatusOK)
 	assert.Equal(t, []byte("hello"), readAll(resp.Body))
@@ -86,6 +90,10 @@ func TestClient_DoHTTPRequest_Header(t *testing.T) {
 			w.WriteHeader(500)
 			return
 		}
+		if zone := r.Header.Get(m3ZoneHeader); zone != "" && zone != "foo-zone" {
+			w.WriteHeader(500)
+			return
+		}
 		w.Write([]byte("hello"))
 	}))
 	defer s.Close()
@@ -97,14 +105,99 @@ func TestClient_DoHTTPRequest_Header(t *testing.T) {
 	}
 
 	cl := newTestClient(WithEnvironment("fooz-env"))
-	_, err := cl.DoHTTPRequest("GET", s.URL, nil)
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
 	assert.Error(t, err)
 
 	cl = newTestClient(WithEnvironment("foo-env"))
-	resp, err := cl.DoHTTPRequest("GET", s.URL, nil)
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
 	require.NoError(t, err)
 	assert.Equal(t, resp.StatusCode, http.StatusOK)
 	assert.Equal(t, []byte("hello"), readAll(resp.Body))
+
+	cl = newTestClient(WithEnvironment("foo-env"), WithZone("fooz-zone"))
+	_, err = cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.Error(t, err)
+
+	cl = newTestClient(WithEnvironment("foo-env"), WithZone("foo-zone"))
+	resp, err = cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestClient_DoHTTPRequest_RequestHeader(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Topic-Name") != "foo-topic" {
+			w.WriteHeader(500)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	cl := newTestClient()
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.Error(t, err)
+
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil, WithHeader("Topic-Name", "foo-topic"))
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestClient_DoHTTPRequest_Headers(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer foo-token" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	cl := newTestClient()
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.Error(t, err)
+
+	cl = newTestClient(WithHeaders(http.Header{"Authorization": []string{"Bearer foo-token"}}))
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestClient_DoHTTPRequest_TLS(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate())
+
+	cl := NewClient(WithTLSConfig(&tls.Config{RootCAs: roots}))
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestClient_DoHTTPRequest_Transport(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "coordinator:7201", r.Host)
+		w.Write([]byte("hello"))
+	})
+
+	cl := NewClient(WithTransport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Result(), nil
+	})))
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", "http://coordinator:7201/health", nil)
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
 }
 
 func TestClient_DoHTTPRequest_Err(t *testing.T) {
@@ -116,14 +209,31 @@ func TestClient_DoHTTPRequest_Err(t *testing.T) {
 			code:   404,
 			expErr: ErrNotFound,
 		},
+		{
+			code:   400,
+			expErr: ErrBadRequest,
+		},
+		{
+			code:   409,
+			expErr: ErrConflict,
+		},
 		{
 			code:   500,
+			expErr: ErrServerError,
+		},
+		{
+			code:   503,
+			expErr: ErrServerError,
+		},
+		{
+			code:   403,
 			expErr: ErrNotOk,
 		},
 	} {
 		t.Run(strconv.Itoa(test.code), func(t *testing.T) {
 			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(test.code)
+				w.Write([]byte(`{"error":"foo failed"}`))
 			}))
 			defer s.Close()
 
@@ -134,8 +244,93 @@ func TestClient_DoHTTPRequest_Err(t *testing.T) {
 			})
 
 			cl := NewClient(WithHTTPClient(retry))
-			_, err := cl.DoHTTPRequest("GET", s.URL, nil)
+			_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
 			assert.Equal(t, test.expErr, pkgerrors.Cause(err))
+
+			httpErr, ok := AsHTTPError(pkgerrors.WithMessage(err, "wrapped"))
+			require.True(t, ok)
+			assert.Equal(t, test.code, httpErr.StatusCode)
+			assert.Equal(t, "foo failed", httpErr.Message)
+			assert.Equal(t, "foo failed: "+test.expErr.Error(), err.Error())
 		})
 	}
 }
+
+func TestClient_DoHTTPRequest_Context(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The request is not retried once its context is done.
+	cl := NewClient(WithRetryPolicy(RetryPolicy{MaxRetries: 10, MinWait: time.Minute, MaxWait: time.Minute}))
+	start := time.Now()
+	_, err := cl.DoHTTPRequest(ctx, "GET", s.URL, nil)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
+
+func TestClient_DoHTTPRequest_RetryPolicy(t *testing.T) {
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer s.Close()
+
+	cl := NewClient(WithRetryPolicy(RetryPolicy{MaxRetries: 1, MinWait: time.Millisecond, MaxWait: time.Millisecond}))
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.True(t, IsServerError(err))
+	assert.Equal(t, 2, requests)
+
+	resp, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestClient_DoHTTPRequest_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer s.Close()
+	defer close(done)
+
+	cl := NewClient(WithTimeout(10*time.Millisecond), WithRetryPolicy(RetryPolicy{}))
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.Error(t, err)
+}
+
+func TestClient_DoHTTPRequest_CircuitBreaker(t *testing.T) {
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(503)
+	}))
+	defer s.Close()
+
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2, Cooldown: time.Hour})
+	cl := NewClient(
+		WithRetryPolicy(RetryPolicy{MaxRetries: 5, MinWait: time.Millisecond, MaxWait: time.Millisecond}),
+		WithCircuitBreaker(breaker))
+
+	// Retries stop once the breaker opens.
+	_, err := cl.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.True(t, IsServerError(err))
+	assert.Equal(t, 2, requests)
+	assert.True(t, breaker.Open())
+
+	// Further requests, including from other clients sharing the breaker,
+	// aren't sent.
+	other := NewClient(WithCircuitBreaker(breaker))
+	_, err = other.DoHTTPRequest(context.Background(), "GET", s.URL, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, 2, requests)
+}
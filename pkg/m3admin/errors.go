@@ -0,0 +1,121 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package m3admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrNotOk indicates that HTTP status was not Ok
+	ErrNotOk = errors.New("status not ok")
+
+	// ErrNotFound indicates that HTTP status was not found
+	ErrNotFound = errors.New("status not found")
+
+	// ErrBadRequest indicates that the coordinator rejected a request as
+	// invalid.
+	ErrBadRequest = errors.New("status bad request")
+
+	// ErrConflict indicates that a request conflicted with the coordinator's
+	// state, such as an instance already being in the placement.
+	ErrConflict = errors.New("status conflict")
+
+	// ErrServerError indicates that the coordinator failed to serve a request.
+	ErrServerError = errors.New("status server error")
+)
+
+// HTTPError is the error returned for responses without a 2xx status. Its
+// cause is the sentinel error of its status, so that callers can keep
+// comparing pkgerrors.Cause(err) to ErrNotFound and friends.
+type HTTPError struct {
+	// StatusCode is the status of the response.
+	StatusCode int
+	// Message is the error message returned by the coordinator, if any.
+	Message string
+}
+
+// Error implements error.
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return e.Cause().Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Message, e.Cause())
+}
+
+// Cause returns the sentinel error of the response's status.
+func (e *HTTPError) Cause() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode >= 500:
+		return ErrServerError
+	default:
+		return ErrNotOk
+	}
+}
+
+// AsHTTPError returns the HTTPError wrapped by err, if any.
+func AsHTTPError(err error) (*HTTPError, bool) {
+	type causer interface {
+		Cause() error
+	}
+
+	for err != nil {
+		if httpErr, ok := err.(*HTTPError); ok {
+			return httpErr, true
+		}
+		cause, ok := err.(causer)
+		if !ok {
+			return nil, false
+		}
+		err = cause.Cause()
+	}
+	return nil, false
+}
+
+// IsNotFound returns whether err was caused by a 404 response.
+func IsNotFound(err error) bool {
+	return pkgerrors.Cause(err) == ErrNotFound
+}
+
+// IsBadRequest returns whether err was caused by a 400 response.
+func IsBadRequest(err error) bool {
+	return pkgerrors.Cause(err) == ErrBadRequest
+}
+
+// IsConflict returns whether err was caused by a 409 response.
+func IsConflict(err error) bool {
+	return pkgerrors.Cause(err) == ErrConflict
+}
+
+// IsServerError returns whether err was caused by a 5xx response.
+func IsServerError(err error) bool {
+	return pkgerrors.Cause(err) == ErrServerError
+}
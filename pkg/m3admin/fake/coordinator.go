@@ -0,0 +1,411 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package fake provides an in-memory fake of the m3coordinator admin API, so
+// that the operator's interactions with a cluster's placement and namespaces
+// can be exercised without etcd or any M3 binaries.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/zap"
+)
+
+const (
+	servicesPath  = "/api/v1/services/"
+	namespacePath = "/api/v1/namespace"
+)
+
+// Option configures a Coordinator.
+type Option interface {
+	execute(*Coordinator)
+}
+
+type optionFn func(c *Coordinator)
+
+func (f optionFn) execute(c *Coordinator) {
+	f(c)
+}
+
+// WithLogger sets the logger requests are logged to. If not set a noop logger
+// will be used.
+func WithLogger(l *zap.Logger) Option {
+	return optionFn(func(c *Coordinator) {
+		c.logger = l
+	})
+}
+
+// WithAutoMarkAvailable makes every placement change mark the shards it
+// assigns available straight away, as if the M3DB nodes bootstrapped them
+// instantly. Otherwise shards stay initializing until MarkAvailable is called
+// or a client marks them available.
+func WithAutoMarkAvailable(v bool) Option {
+	return optionFn(func(c *Coordinator) {
+		c.autoMarkAvailable = v
+	})
+}
+
+// Coordinator is an in-memory fake of the m3coordinator admin API. It serves
+// the placement endpoints of every service and the namespace endpoints, with
+// the semantics of the real coordinator: shards are spread across isolation
+// groups, start out initializing and are streamed from the instances they're
+// leaving, and placement changes are rejected until all shards are available
+// unless forced. Errors are reported with the same status codes and
+// `{"error": ...}` bodies as the coordinator's.
+//
+// A Coordinator is an http.Handler and is safe for concurrent use.
+type Coordinator struct {
+	logger            *zap.Logger
+	autoMarkAvailable bool
+
+	mu         sync.Mutex
+	placements map[string]m3placement.Placement
+	namespaces map[string]*m3ns.NamespaceOptions
+}
+
+// NewCoordinator returns a Coordinator without any placements or namespaces.
+func NewCoordinator(opts ...Option) *Coordinator {
+	c := &Coordinator{
+		logger:     zap.NewNop(),
+		placements: make(map[string]m3placement.Placement),
+		namespaces: make(map[string]*m3ns.NamespaceOptions),
+	}
+	for _, o := range opts {
+		o.execute(c)
+	}
+	return c
+}
+
+// Placement returns a copy of the placement of a service, such as
+// placement.ServiceM3DB.
+func (c *Coordinator) Placement(service string) (m3placement.Placement, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pl, ok := c.placements[service]
+	if !ok {
+		return nil, false
+	}
+	return pl.Clone(), true
+}
+
+// MarkAvailable marks the initializing shards of the given instances of a
+// service's placement, or of all its instances if none are given, available.
+// It stands in for M3DB nodes finishing bootstrapping their shards.
+func (c *Coordinator) MarkAvailable(service string, instanceIDs ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pl, ok := c.placements[service]
+	if !ok {
+		return fmt.Errorf("no placement for service '%s'", service)
+	}
+	pl, err := markAvailable(pl.Clone(), instanceIDs...)
+	if err != nil {
+		return err
+	}
+	c.store(service, pl)
+	return nil
+}
+
+// Namespaces returns a copy of the namespaces' options, keyed by name.
+func (c *Coordinator) Namespaces() map[string]*m3ns.NamespaceOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	namespaces := make(map[string]*m3ns.NamespaceOptions, len(c.namespaces))
+	for name, opts := range c.namespaces {
+		namespaces[name] = proto.Clone(opts).(*m3ns.NamespaceOptions)
+	}
+	return namespaces
+}
+
+// ServeHTTP serves a request to the admin API.
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.logger.Debug("serving request",
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case path == namespacePath || strings.HasPrefix(path, namespacePath+"/"):
+		name := strings.TrimPrefix(strings.TrimPrefix(path, namespacePath), "/")
+		c.serveNamespace(w, r, name)
+	case strings.HasPrefix(path, servicesPath):
+		// {service}/placement[/{op or instance ID}]
+		parts := strings.SplitN(strings.TrimPrefix(path, servicesPath), "/", 3)
+		if len(parts) < 2 || parts[1] != "placement" {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no endpoint %s", path))
+			return
+		}
+		var op string
+		if len(parts) == 3 {
+			op = parts[2]
+		}
+		c.servePlacement(w, r, parts[0], op)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no endpoint %s", path))
+	}
+}
+
+func (c *Coordinator) servePlacement(w http.ResponseWriter, r *http.Request, service, op string) {
+	current, exists := c.placements[service]
+
+	if op == "init" && r.Method == http.MethodPost {
+		if exists {
+			writeError(w, http.StatusConflict, fmt.Errorf("placement already exists for service '%s'", service))
+			return
+		}
+		req := &admin.PlacementInitRequest{}
+		if err := decodeProto(r.Body, req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		pl, err := initPlacement(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.writePlacement(w, c.update(service, pl))
+		return
+	}
+
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("placement not found for service '%s'", service))
+		return
+	}
+	pl := current.Clone()
+
+	switch {
+	case op == "" && r.Method == http.MethodGet:
+		c.writePlacement(w, current)
+
+	case op == "" && r.Method == http.MethodDelete:
+		delete(c.placements, service)
+		writeJSON(w, struct{}{})
+
+	case op == "" && r.Method == http.MethodPost:
+		req := &admin.PlacementAddRequest{}
+		if err := decodeProto(r.Body, req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.change(w, service, pl, req.Force, func() error {
+			return addInstances(pl, req.Instances)
+		})
+
+	case op == "replace" && r.Method == http.MethodPost:
+		req := &admin.PlacementReplaceRequest{}
+		if err := decodeProto(r.Body, req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.change(w, service, pl, req.Force, func() error {
+			return replaceInstances(pl, req.LeavingInstanceIDs, req.Candidates)
+		})
+
+	case op == "set" && r.Method == http.MethodPost:
+		req := &placement.SetRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.Placement == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid set request: %v", err))
+			return
+		}
+		if int(req.Version) != current.Version() {
+			writeError(w, http.StatusConflict, fmt.Errorf("version mismatch: placement is at version %d, not %d",
+				current.Version(), req.Version))
+			return
+		}
+		newPl, err := m3placement.NewPlacementFromProto(req.Placement)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		c.writePlacement(w, c.store(service, newPl))
+
+	case op != "" && r.Method == http.MethodDelete:
+		c.change(w, service, pl, false, func() error {
+			return removeInstances(pl, []string{op})
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no endpoint %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// change applies fn to pl, a copy of a service's placement, and stores the
+// result. Unless forced, the placement must have all its shards available.
+func (c *Coordinator) change(
+	w http.ResponseWriter,
+	service string,
+	pl m3placement.Placement,
+	force bool,
+	fn func() error,
+) {
+	if !force {
+		if err := allAvailable(pl); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := fn(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	c.writePlacement(w, c.update(service, pl))
+}
+
+// update stores a changed placement, marking its shards available first if
+// configured to.
+func (c *Coordinator) update(service string, pl m3placement.Placement) m3placement.Placement {
+	if c.autoMarkAvailable {
+		// Marking every instance's shards can't fail.
+		pl, _ = markAvailable(pl)
+	}
+	return c.store(service, pl)
+}
+
+// store stores the placement of a service with the next version.
+func (c *Coordinator) store(service string, pl m3placement.Placement) m3placement.Placement {
+	version := 1
+	if current, ok := c.placements[service]; ok {
+		version = current.Version() + 1
+	}
+	pl = pl.SetVersion(version)
+	c.placements[service] = pl
+	return pl
+}
+
+func (c *Coordinator) serveNamespace(w http.ResponseWriter, r *http.Request, name string) {
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		c.writeRegistry(w)
+
+	case name == "" && r.Method == http.MethodPost:
+		req := &admin.NamespaceAddRequest{}
+		if err := decodeProto(r.Body, req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Name == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("namespace name required"))
+			return
+		}
+		if _, ok := c.namespaces[req.Name]; ok {
+			writeError(w, http.StatusConflict, fmt.Errorf("namespace '%s' already exists", req.Name))
+			return
+		}
+		opts := req.Options
+		if opts == nil {
+			opts = &m3ns.NamespaceOptions{}
+		}
+		c.namespaces[req.Name] = opts
+		c.writeRegistry(w)
+
+	case name == "" && r.Method == http.MethodPut:
+		req := &namespace.UpdateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.Options == nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid update request: %v", err))
+			return
+		}
+		current, ok := c.namespaces[req.Name]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("namespace '%s' not found", req.Name))
+			return
+		}
+		if blockSize(current) != blockSize(req.Options) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("namespace '%s' block size can't be changed", req.Name))
+			return
+		}
+		c.namespaces[req.Name] = req.Options
+		c.writeRegistry(w)
+
+	case name != "" && r.Method == http.MethodDelete:
+		if _, ok := c.namespaces[name]; !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("namespace '%s' not found", name))
+			return
+		}
+		delete(c.namespaces, name)
+		writeJSON(w, struct{}{})
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no endpoint %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func blockSize(opts *m3ns.NamespaceOptions) int64 {
+	if opts.RetentionOptions == nil {
+		return 0
+	}
+	return opts.RetentionOptions.BlockSizeNanos
+}
+
+func (c *Coordinator) writePlacement(w http.ResponseWriter, pl m3placement.Placement) {
+	plProto, err := pl.Proto()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, &admin.PlacementGetResponse{
+		Placement: plProto,
+		Version:   int32(pl.Version()),
+	})
+}
+
+func (c *Coordinator) writeRegistry(w http.ResponseWriter) {
+	writeJSON(w, &admin.NamespaceGetResponse{
+		Registry: &m3ns.Registry{Namespaces: c.namespaces},
+	})
+}
+
+func decodeProto(r io.Reader, msg proto.Message) error {
+	um := &jsonpb.Unmarshaler{
+		AllowUnknownFields: true,
+	}
+	return um.Unmarshal(r, msg)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fake
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	retryhttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClients(t *testing.T, c *Coordinator) (placement.Client, namespace.Client, func()) {
+	s := httptest.NewServer(c)
+
+	retry := retryhttp.NewClient()
+	retry.RetryMax = 0
+	adminClient := m3admin.NewClient(m3admin.WithHTTPClient(retry))
+
+	plClient, err := placement.NewClient(placement.WithURL(s.URL), placement.WithClient(adminClient))
+	require.NoError(t, err)
+	nsClient, err := namespace.NewClient(namespace.WithURL(s.URL), namespace.WithClient(adminClient))
+	require.NoError(t, err)
+
+	return plClient, nsClient, s.Close
+}
+
+func testInstance(id, group string) *placementpb.Instance {
+	return &placementpb.Instance{
+		Id:             id,
+		IsolationGroup: group,
+		Zone:           "embedded",
+		Weight:         100,
+		Endpoint:       id + ":9000",
+	}
+}
+
+// requireReplicas checks that every shard has rf replicas that aren't leaving,
+// each in a different isolation group.
+func requireReplicas(t *testing.T, pl m3placement.Placement, rf int) {
+	for _, id := range pl.Shards() {
+		groups := make(map[string]struct{})
+		for _, inst := range pl.InstancesForShard(id) {
+			s, _ := inst.Shards().Shard(id)
+			if s.State() == shard.Leaving {
+				continue
+			}
+			_, dup := groups[inst.IsolationGroup()]
+			require.False(t, dup, "shard %d has two replicas in group %s", id, inst.IsolationGroup())
+			groups[inst.IsolationGroup()] = struct{}{}
+		}
+		require.Len(t, groups, rf, "shard %d", id)
+	}
+}
+
+func numShards(t *testing.T, pl m3placement.Placement, id string, state shard.State) int {
+	inst, ok := pl.Instance(id)
+	require.True(t, ok, "instance %s", id)
+	return inst.Shards().NumShardsForState(state)
+}
+
+func TestCoordinatorPlacementLifecycle(t *testing.T) {
+	c := NewCoordinator()
+	cl, _, closeFn := newTestClients(t, c)
+	defer closeFn()
+	ctx := context.Background()
+
+	_, err := cl.Get(ctx)
+	assert.True(t, m3admin.IsNotFound(err))
+
+	err = cl.Init(ctx, &admin.PlacementInitRequest{
+		NumShards:         12,
+		ReplicationFactor: 3,
+		Instances: []*placementpb.Instance{
+			testInstance("a", "group-a"),
+			testInstance("b", "group-b"),
+			testInstance("c", "group-c"),
+		},
+	})
+	require.NoError(t, err)
+
+	err = cl.Init(ctx, &admin.PlacementInitRequest{NumShards: 1, ReplicationFactor: 1})
+	assert.True(t, m3admin.IsConflict(err))
+
+	pl, err := cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pl.Version())
+	assert.Equal(t, 12, numShards(t, pl, "a", shard.Initializing))
+	requireReplicas(t, pl, 3)
+
+	// Changes are rejected until the nodes have bootstrapped their shards.
+	err = cl.Add(ctx, *testInstance("d", "group-a"))
+	assert.True(t, m3admin.IsBadRequest(err))
+	require.NoError(t, c.MarkAvailable(placement.ServiceM3DB))
+
+	// A new instance takes over shards from the instance in its group.
+	require.NoError(t, cl.Add(ctx, *testInstance("d", "group-a")))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, numShards(t, pl, "d", shard.Initializing))
+	assert.Equal(t, 6, numShards(t, pl, "a", shard.Leaving))
+	assert.Equal(t, 12, numShards(t, pl, "b", shard.Available))
+	requireReplicas(t, pl, 3)
+
+	require.NoError(t, cl.MarkAvailable(ctx, "d"))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, numShards(t, pl, "a", shard.Available))
+	assert.Equal(t, 0, numShards(t, pl, "a", shard.Leaving))
+	assert.Equal(t, 6, numShards(t, pl, "d", shard.Available))
+
+	// A replacement takes over all of the leaving instance's shards, which is
+	// removed once they're available.
+	require.NoError(t, cl.Replace(ctx, "b", *testInstance("e", "group-b")))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 12, numShards(t, pl, "e", shard.Initializing))
+	assert.Equal(t, 12, numShards(t, pl, "b", shard.Leaving))
+	requireReplicas(t, pl, 3)
+
+	require.NoError(t, c.MarkAvailable(placement.ServiceM3DB, "e"))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	_, ok := pl.Instance("b")
+	assert.False(t, ok)
+
+	// Removing an instance hands its shards back to the rest of its group.
+	require.NoError(t, cl.Remove(ctx, "d"))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, numShards(t, pl, "a", shard.Initializing))
+	requireReplicas(t, pl, 3)
+
+	require.NoError(t, cl.MarkAvailable(ctx, "a"))
+	pl, err = cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pl.NumInstances())
+	assert.Equal(t, 12, numShards(t, pl, "a", shard.Available))
+
+	// Too few instances would be left to hold every replica.
+	err = cl.Remove(ctx, "a")
+	assert.True(t, m3admin.IsBadRequest(err))
+
+	require.NoError(t, cl.Delete(ctx))
+	_, err = cl.Get(ctx)
+	assert.True(t, m3admin.IsNotFound(err))
+}
+
+func TestCoordinatorSet(t *testing.T) {
+	c := NewCoordinator(WithAutoMarkAvailable(true))
+	cl, _, closeFn := newTestClients(t, c)
+	defer closeFn()
+	ctx := context.Background()
+
+	require.NoError(t, cl.Init(ctx, &admin.PlacementInitRequest{
+		NumShards:         4,
+		ReplicationFactor: 1,
+		Instances:         []*placementpb.Instance{testInstance("a", "group-a")},
+	}))
+
+	pl, err := cl.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, numShards(t, pl, "a", shard.Available))
+
+	inst, _ := pl.Instance("a")
+	inst.SetWeight(50)
+	newPl, err := cl.Set(ctx, pl)
+	require.NoError(t, err)
+	assert.Equal(t, pl.Version()+1, newPl.Version())
+
+	// Writing back a stale placement is rejected.
+	_, err = cl.Set(ctx, pl)
+	assert.True(t, m3admin.IsConflict(err))
+
+	stored, ok := c.Placement(placement.ServiceM3DB)
+	require.True(t, ok)
+	inst, _ = stored.Instance("a")
+	assert.Equal(t, uint32(50), inst.Weight())
+}
+
+func TestCoordinatorServices(t *testing.T) {
+	c := NewCoordinator()
+	s := httptest.NewServer(c)
+	defer s.Close()
+
+	aggClient, err := placement.NewClient(
+		placement.WithURL(s.URL),
+		placement.WithService(placement.ServiceM3Aggregator))
+	require.NoError(t, err)
+
+	require.NoError(t, aggClient.Init(context.Background(), &admin.PlacementInitRequest{
+		NumShards:         2,
+		ReplicationFactor: 1,
+		Instances:         []*placementpb.Instance{testInstance("agg", "group-a")},
+	}))
+
+	_, ok := c.Placement(placement.ServiceM3Aggregator)
+	assert.True(t, ok)
+	_, ok = c.Placement(placement.ServiceM3DB)
+	assert.False(t, ok)
+}
+
+func TestCoordinatorNamespaces(t *testing.T) {
+	c := NewCoordinator()
+	_, cl, closeFn := newTestClients(t, c)
+	defer closeFn()
+	ctx := context.Background()
+
+	opts := &m3ns.NamespaceOptions{
+		BootstrapEnabled: true,
+		RetentionOptions: &m3ns.RetentionOptions{
+			RetentionPeriodNanos: 172800000000000,
+			BlockSizeNanos:       7200000000000,
+		},
+	}
+	require.NoError(t, cl.Create(ctx, &admin.NamespaceAddRequest{Name: "metrics", Options: opts}))
+
+	err := cl.Create(ctx, &admin.NamespaceAddRequest{Name: "metrics", Options: opts})
+	assert.True(t, m3admin.IsConflict(err))
+
+	got, err := cl.Get(ctx, "metrics")
+	require.NoError(t, err)
+	assert.Equal(t, opts.RetentionOptions.RetentionPeriodNanos, got.RetentionOptions.RetentionPeriodNanos)
+
+	got.RetentionOptions.RetentionPeriodNanos *= 2
+	require.NoError(t, cl.Update(ctx, &namespace.UpdateRequest{Name: "metrics", Options: got}))
+	assert.Equal(t, 2*opts.RetentionOptions.RetentionPeriodNanos,
+		c.Namespaces()["metrics"].RetentionOptions.RetentionPeriodNanos)
+
+	got.RetentionOptions.BlockSizeNanos *= 2
+	err = cl.Update(ctx, &namespace.UpdateRequest{Name: "metrics", Options: got})
+	assert.True(t, m3admin.IsBadRequest(err))
+
+	err = cl.Update(ctx, &namespace.UpdateRequest{Name: "missing", Options: got})
+	assert.True(t, m3admin.IsNotFound(err))
+
+	require.NoError(t, cl.Delete(ctx, "metrics"))
+	assert.True(t, m3admin.IsNotFound(cl.Delete(ctx, "metrics")))
+
+	resp, err := cl.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Registry.Namespaces)
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+)
+
+var (
+	errNotAllAvailable = errors.New("instances do not have all shards available")
+	errNoCandidate     = errors.New("no instance can take the shard without breaking isolation")
+)
+
+// newInstances converts the instances of a request, dropping any shards they
+// came with.
+func newInstances(protos []*placementpb.Instance) ([]m3placement.Instance, error) {
+	instances := make([]m3placement.Instance, 0, len(protos))
+	seen := make(map[string]struct{}, len(protos))
+	for _, p := range protos {
+		if p == nil || p.Id == "" {
+			return nil, errors.New("instance without an ID")
+		}
+		if _, ok := seen[p.Id]; ok {
+			return nil, fmt.Errorf("duplicate instance '%s'", p.Id)
+		}
+		seen[p.Id] = struct{}{}
+
+		inst, err := m3placement.NewInstanceFromProto(p)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst.SetShards(shard.NewShards(nil)))
+	}
+	return instances, nil
+}
+
+// initPlacement builds a new placement, assigning each shard to
+// ReplicationFactor instances in distinct isolation groups. All shards start
+// out initializing, as they do until the M3DB nodes bootstrap them.
+func initPlacement(req *admin.PlacementInitRequest) (m3placement.Placement, error) {
+	if req.NumShards <= 0 || req.ReplicationFactor <= 0 {
+		return nil, errors.New("number of shards and replication factor must be positive")
+	}
+	instances, err := newInstances(req.Instances)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) < int(req.ReplicationFactor) {
+		return nil, fmt.Errorf("%d instances can't hold %d replicas", len(instances), req.ReplicationFactor)
+	}
+
+	shardIDs := make([]uint32, req.NumShards)
+	for i := range shardIDs {
+		shardIDs[i] = uint32(i)
+	}
+	pl := m3placement.NewPlacement().
+		SetInstances(instances).
+		SetShards(shardIDs).
+		SetReplicaFactor(int(req.ReplicationFactor)).
+		SetIsSharded(true)
+
+	for _, id := range shardIDs {
+		for r := 0; r < pl.ReplicaFactor(); r++ {
+			inst := pickInstance(pl, id, "", pl.Instances())
+			if inst == nil {
+				return nil, errNoCandidate
+			}
+			inst.Shards().Add(shard.NewShard(id).SetState(shard.Initializing))
+		}
+	}
+	return pl, nil
+}
+
+// addInstances adds instances to the placement and moves shards from the most
+// loaded instances to them until they hold their share of the replicas.
+func addInstances(pl m3placement.Placement, protos []*placementpb.Instance) error {
+	added, err := newInstances(protos)
+	if err != nil {
+		return err
+	}
+	for _, inst := range added {
+		if _, ok := pl.Instance(inst.ID()); ok {
+			return fmt.Errorf("instance '%s' already in placement", inst.ID())
+		}
+	}
+	pl.SetInstances(append(pl.Instances(), added...))
+
+	for _, inst := range added {
+		inst, _ = pl.Instance(inst.ID())
+		target := pl.NumShards() * pl.ReplicaFactor() / numActiveInstances(pl)
+		for load(inst) < target {
+			if !stealShard(pl, inst) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// removeInstances marks every shard of the instances leaving and hands each
+// to the least loaded instance that can take it. Instances without shards are
+// removed immediately.
+func removeInstances(pl m3placement.Placement, ids []string) error {
+	removing := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := pl.Instance(id); !ok {
+			return fmt.Errorf("instance '%s' not in placement", id)
+		}
+		removing[id] = struct{}{}
+	}
+
+	var candidates []m3placement.Instance
+	for _, inst := range pl.Instances() {
+		if _, ok := removing[inst.ID()]; !ok {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(candidates) < pl.ReplicaFactor() {
+		return fmt.Errorf("%d instances can't hold %d replicas", len(candidates), pl.ReplicaFactor())
+	}
+
+	for _, id := range ids {
+		inst, _ := pl.Instance(id)
+		for _, s := range inst.Shards().All() {
+			target := pickInstance(pl, s.ID(), id, candidates)
+			if target == nil {
+				return errNoCandidate
+			}
+			s.SetState(shard.Leaving)
+			target.Shards().Add(shard.NewShard(s.ID()).SetState(shard.Initializing).SetSourceID(id))
+		}
+	}
+	dropEmptyInstances(pl, removing)
+	return nil
+}
+
+// replaceInstances hands all the shards of each leaving instance to a
+// candidate, preferring candidates in the same isolation group.
+func replaceInstances(pl m3placement.Placement, leavingIDs []string, protos []*placementpb.Instance) error {
+	candidates, err := newInstances(protos)
+	if err != nil {
+		return err
+	}
+	if len(candidates) < len(leavingIDs) {
+		return fmt.Errorf("%d candidates can't replace %d instances", len(candidates), len(leavingIDs))
+	}
+	for _, inst := range candidates {
+		if _, ok := pl.Instance(inst.ID()); ok {
+			return fmt.Errorf("instance '%s' already in placement", inst.ID())
+		}
+	}
+
+	leaving := make(map[string]struct{}, len(leavingIDs))
+	for _, id := range leavingIDs {
+		inst, ok := pl.Instance(id)
+		if !ok {
+			return fmt.Errorf("instance '%s' not in placement", id)
+		}
+		leaving[id] = struct{}{}
+
+		i := 0
+		for j, c := range candidates {
+			if c.IsolationGroup() == inst.IsolationGroup() {
+				i = j
+				break
+			}
+		}
+		newInst := candidates[i]
+		candidates = append(candidates[:i], candidates[i+1:]...)
+
+		for _, s := range inst.Shards().All() {
+			s.SetState(shard.Leaving)
+			newInst.Shards().Add(shard.NewShard(s.ID()).SetState(shard.Initializing).SetSourceID(id))
+		}
+		pl.SetInstances(append(pl.Instances(), newInst))
+	}
+	dropEmptyInstances(pl, leaving)
+	return nil
+}
+
+// markAvailable marks every initializing shard of the given instances, or of
+// all instances if none are given, available.
+func markAvailable(pl m3placement.Placement, instanceIDs ...string) (m3placement.Placement, error) {
+	all := len(instanceIDs) == 0
+	if all {
+		for _, inst := range pl.Instances() {
+			instanceIDs = append(instanceIDs, inst.ID())
+		}
+	}
+
+	var err error
+	for _, id := range instanceIDs {
+		inst, ok := pl.Instance(id)
+		if !ok {
+			if all {
+				// Removed once its last shard was taken over.
+				continue
+			}
+			return nil, fmt.Errorf("instance '%s' not in placement", id)
+		}
+		if inst.Shards().NumShardsForState(shard.Initializing) == 0 {
+			continue
+		}
+		pl, err = placement.MarkShardsAvailable(pl, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pl, nil
+}
+
+// allAvailable returns an error unless every shard of the placement is
+// available, which the coordinator requires before changing the placement
+// unless the request is forced.
+func allAvailable(pl m3placement.Placement) error {
+	for _, inst := range pl.Instances() {
+		if inst.Shards().NumShardsForState(shard.Available) != inst.Shards().NumShards() {
+			return errNotAllAvailable
+		}
+	}
+	return nil
+}
+
+// stealShard moves one shard from the most loaded instance that can give one
+// up to inst. It returns false if no shard could be moved.
+func stealShard(pl m3placement.Placement, inst m3placement.Instance) bool {
+	donors := pl.Instances()
+	sort.SliceStable(donors, func(i, j int) bool {
+		return load(donors[i]) > load(donors[j])
+	})
+
+	for _, donor := range donors {
+		if donor.ID() == inst.ID() || load(donor) <= load(inst)+1 {
+			continue
+		}
+		for _, s := range donor.Shards().ShardsForState(shard.Available) {
+			if inst.Shards().Contains(s.ID()) || !canHold(pl, s.ID(), donor.ID(), inst) {
+				continue
+			}
+			s.SetState(shard.Leaving)
+			inst.Shards().Add(shard.NewShard(s.ID()).SetState(shard.Initializing).SetSourceID(donor.ID()))
+			return true
+		}
+	}
+	return false
+}
+
+// pickInstance returns the least loaded of the candidates that can hold a
+// replica of the shard, or nil if none can.
+func pickInstance(
+	pl m3placement.Placement,
+	shardID uint32,
+	sourceID string,
+	candidates []m3placement.Instance,
+) m3placement.Instance {
+	var picked m3placement.Instance
+	for _, inst := range candidates {
+		if inst.Shards().Contains(shardID) || !canHold(pl, shardID, sourceID, inst) {
+			continue
+		}
+		if picked == nil || load(inst) < load(picked) {
+			picked = inst
+		}
+	}
+	return picked
+}
+
+// canHold returns whether inst can hold a replica of the shard without
+// sharing an isolation group with another replica. The replica being moved
+// away from sourceID doesn't count.
+func canHold(pl m3placement.Placement, shardID uint32, sourceID string, inst m3placement.Instance) bool {
+	for _, other := range pl.InstancesForShard(shardID) {
+		if other.ID() == sourceID || other.ID() == inst.ID() {
+			continue
+		}
+		if s, ok := other.Shards().Shard(shardID); ok && s.State() == shard.Leaving {
+			continue
+		}
+		if other.IsolationGroup() == inst.IsolationGroup() {
+			return false
+		}
+	}
+	return true
+}
+
+// load returns the number of shards an instance holds or is taking on.
+func load(inst m3placement.Instance) int {
+	return inst.Shards().NumShards() - inst.Shards().NumShardsForState(shard.Leaving)
+}
+
+func numActiveInstances(pl m3placement.Placement) int {
+	n := 0
+	for _, inst := range pl.Instances() {
+		if inst.Shards().NumShards() == 0 || load(inst) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// dropEmptyInstances removes the given instances from the placement if they
+// hold no shards.
+func dropEmptyInstances(pl m3placement.Placement, ids map[string]struct{}) {
+	instances := make([]m3placement.Instance, 0, pl.NumInstances())
+	for _, inst := range pl.Instances() {
+		if _, ok := ids[inst.ID()]; ok && inst.Shards().NumShards() == 0 {
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	pl.SetInstances(instances)
+}
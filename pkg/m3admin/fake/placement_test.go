@@ -0,0 +1,128 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fake
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitPlacementErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		req  *admin.PlacementInitRequest
+	}{
+		{
+			name: "no shards",
+			req: &admin.PlacementInitRequest{
+				ReplicationFactor: 1,
+				Instances:         []*placementpb.Instance{testInstance("a", "a")},
+			},
+		},
+		{
+			name: "too few instances",
+			req: &admin.PlacementInitRequest{
+				NumShards:         4,
+				ReplicationFactor: 2,
+				Instances:         []*placementpb.Instance{testInstance("a", "a")},
+			},
+		},
+		{
+			name: "too few isolation groups",
+			req: &admin.PlacementInitRequest{
+				NumShards:         4,
+				ReplicationFactor: 2,
+				Instances: []*placementpb.Instance{
+					testInstance("a", "a"),
+					testInstance("b", "a"),
+				},
+			},
+		},
+		{
+			name: "duplicate instance",
+			req: &admin.PlacementInitRequest{
+				NumShards:         4,
+				ReplicationFactor: 1,
+				Instances: []*placementpb.Instance{
+					testInstance("a", "a"),
+					testInstance("a", "b"),
+				},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := initPlacement(test.req)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInitPlacementBalanced(t *testing.T) {
+	pl, err := initPlacement(&admin.PlacementInitRequest{
+		NumShards:         64,
+		ReplicationFactor: 3,
+		Instances: []*placementpb.Instance{
+			testInstance("a1", "a"),
+			testInstance("a2", "a"),
+			testInstance("b1", "b"),
+			testInstance("b2", "b"),
+			testInstance("c1", "c"),
+			testInstance("c2", "c"),
+		},
+	})
+	require.NoError(t, err)
+	requireReplicas(t, pl, 3)
+
+	for _, inst := range pl.Instances() {
+		assert.Equal(t, 32, inst.Shards().NumShardsForState(shard.Initializing), inst.ID())
+	}
+}
+
+func TestAddInstancesBalances(t *testing.T) {
+	pl, err := initPlacement(&admin.PlacementInitRequest{
+		NumShards:         64,
+		ReplicationFactor: 1,
+		Instances:         []*placementpb.Instance{testInstance("a", "a")},
+	})
+	require.NoError(t, err)
+	pl, err = markAvailable(pl)
+	require.NoError(t, err)
+
+	require.NoError(t, addInstances(pl, []*placementpb.Instance{
+		testInstance("b", "b"),
+		testInstance("c", "c"),
+		testInstance("d", "d"),
+	}))
+	requireReplicas(t, pl, 1)
+
+	pl, err = markAvailable(pl)
+	require.NoError(t, err)
+	for _, inst := range pl.Instances() {
+		assert.Equal(t, 16, inst.Shards().NumShardsForState(shard.Available), inst.ID())
+	}
+	require.NoError(t, allAvailable(pl))
+}
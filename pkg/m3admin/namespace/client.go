@@ -22,6 +22,7 @@ package namespace
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,9 +31,11 @@ import (
 
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
 	"github.com/gogo/protobuf/jsonpb"
+	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -102,13 +105,13 @@ func NewClient(opts ...Option) (Client, error) {
 }
 
 // Create will create a namespace
-func (n *namespaceClient) Create(req *admin.NamespaceAddRequest) error {
+func (n *namespaceClient) Create(ctx context.Context, req *admin.NamespaceAddRequest) error {
 	url := n.url + namespaceBaseURL
-	data, err := json.Marshal(req)
+	data, err := (&jsonpb.Marshaler{}).MarshalToString(req)
 	if err != nil {
 		return err
 	}
-	_, err = n.client.DoHTTPRequest("POST", url, bytes.NewBuffer(data))
+	_, err = n.client.DoHTTPRequest(ctx, "POST", url, bytes.NewBufferString(data))
 	if err != nil {
 		return err
 	}
@@ -117,9 +120,9 @@ func (n *namespaceClient) Create(req *admin.NamespaceAddRequest) error {
 }
 
 // List will retrieve all namespaces
-func (n *namespaceClient) List() (*admin.NamespaceGetResponse, error) {
+func (n *namespaceClient) List(ctx context.Context) (*admin.NamespaceGetResponse, error) {
 	url := n.url + namespaceBaseURL
-	resp, err := n.client.DoHTTPRequest("GET", url, nil)
+	resp, err := n.client.DoHTTPRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -145,10 +148,38 @@ func (n *namespaceClient) List() (*admin.NamespaceGetResponse, error) {
 	return data, nil
 }
 
+// Get will retrieve the options of a namespace
+func (n *namespaceClient) Get(ctx context.Context, name string) (*m3ns.NamespaceOptions, error) {
+	resp, err := n.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts, ok := resp.Registry.Namespaces[name]
+	if !ok || opts == nil {
+		return nil, pkgerrors.WithMessagef(m3admin.ErrNotFound, "namespace '%s'", name)
+	}
+	return opts, nil
+}
+
+// Update will update the options of an existing namespace
+func (n *namespaceClient) Update(ctx context.Context, req *UpdateRequest) error {
+	url := n.url + namespaceBaseURL
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = n.client.DoHTTPRequest(ctx, "PUT", url, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	n.logger.Info("successfully updated namespace", zap.String("namespace", req.Name))
+	return nil
+}
+
 // Delete will delete a namespace
-func (n *namespaceClient) Delete(namespace string) error {
+func (n *namespaceClient) Delete(ctx context.Context, namespace string) error {
 	url := fmt.Sprintf(n.url+namespaceDeleteFmt, namespace)
-	_, err := n.client.DoHTTPRequest("DELETE", url, nil)
+	_, err := n.client.DoHTTPRequest(ctx, "DELETE", url, nil)
 	if err != nil {
 		return err
 	}
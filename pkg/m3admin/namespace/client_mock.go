@@ -25,8 +25,10 @@
 package namespace
 
 import (
+	"context"
 	"reflect"
 
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
 	"github.com/golang/mock/gomock"
@@ -56,44 +58,73 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // Create mocks base method
-func (m *MockClient) Create(request *admin.NamespaceAddRequest) error {
+func (m *MockClient) Create(ctx context.Context, request *admin.NamespaceAddRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", request)
+	ret := m.ctrl.Call(m, "Create", ctx, request)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create
-func (mr *MockClientMockRecorder) Create(request interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Create(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), ctx, request)
 }
 
 // List mocks base method
-func (m *MockClient) List() (*admin.NamespaceGetResponse, error) {
+func (m *MockClient) List(ctx context.Context) (*admin.NamespaceGetResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List")
+	ret := m.ctrl.Call(m, "List", ctx)
 	ret0, _ := ret[0].(*admin.NamespaceGetResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List
-func (mr *MockClientMockRecorder) List() *gomock.Call {
+func (mr *MockClientMockRecorder) List(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClient)(nil).List))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClient)(nil).List), ctx)
+}
+
+// Get mocks base method
+func (m *MockClient) Get(ctx context.Context, name string) (*m3ns.NamespaceOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, name)
+	ret0, _ := ret[0].(*m3ns.NamespaceOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockClientMockRecorder) Get(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), ctx, name)
 }
 
 // Delete mocks base method
-func (m *MockClient) Delete(namespace string) error {
+func (m *MockClient) Delete(ctx context.Context, namespace string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", namespace)
+	ret := m.ctrl.Call(m, "Delete", ctx, namespace)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockClientMockRecorder) Delete(namespace interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Delete(ctx, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ctx, namespace)
+}
+
+// Update mocks base method
+func (m *MockClient) Update(ctx context.Context, request *UpdateRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockClientMockRecorder) Update(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), namespace)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), ctx, request)
 }
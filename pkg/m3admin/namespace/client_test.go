@@ -21,6 +21,8 @@
 package namespace
 
 import (
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -71,7 +73,7 @@ func TestCreate(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	err := client.Create(&admin.NamespaceAddRequest{
+	err := client.Create(context.Background(), &admin.NamespaceAddRequest{
 		Name: "foo",
 		Options: &ns.NamespaceOptions{
 			BootstrapEnabled: true,
@@ -89,7 +91,7 @@ func TestCreateErr(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	err := client.Create(nil)
+	err := client.Create(context.Background(), nil)
 	require.NotNil(t, err)
 }
 
@@ -101,7 +103,7 @@ func TestGet(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	resp, err := client.List()
+	resp, err := client.List(context.Background())
 	require.NotNil(t, resp)
 	require.NoError(t, err)
 }
@@ -114,7 +116,7 @@ func TestGet_Nil(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	_, err := client.List()
+	_, err := client.List(context.Background())
 	assert.Error(t, err)
 }
 
@@ -126,11 +128,41 @@ func TestGetErr(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	resp, err := client.List()
+	resp, err := client.List(context.Background())
 	require.Nil(t, resp)
 	require.NotNil(t, err)
 }
 
+func TestGetByName(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte(`{"registry":{"namespaces":{"default":{"bootstrapEnabled":true,"retentionOptions":{"retentionPeriodNanos":"172800000000000"}}}}}`))
+	}))
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	opts, err := client.Get(context.Background(), "default")
+	require.NoError(t, err)
+	require.NotNil(t, opts)
+	assert.True(t, opts.BootstrapEnabled)
+
+	_, err = client.Get(context.Background(), "missing")
+	assert.True(t, m3admin.IsNotFound(err))
+}
+
+func TestGetByNameErr(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	opts, err := client.Get(context.Background(), "default")
+	assert.Nil(t, opts)
+	assert.True(t, m3admin.IsServerError(err))
+}
+
 func TestDelete(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.String() != "/api/v1/namespace/default" || r.Method != "DELETE" {
@@ -143,10 +175,59 @@ func TestDelete(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	err := client.Delete("default")
+	err := client.Delete(context.Background(), "default")
 	require.Nil(t, err)
 }
 
+func TestUpdate(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/namespace" || r.Method != "PUT" {
+			w.WriteHeader(404)
+			return
+		}
+
+		bytes, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		// The options are encoded with jsonpb, which the coordinator decodes
+		// them with.
+		const exp = `{"name":"foo","options":{"bootstrapEnabled":true,"retentionOptions":{"blockSizeNanos":"7200000000000"}}}`
+		assert.Equal(t, exp, string(bytes))
+
+		req := &UpdateRequest{}
+		require.NoError(t, json.Unmarshal(bytes, req))
+		assert.Equal(t, int64(7200000000000), req.Options.RetentionOptions.BlockSizeNanos)
+
+		w.WriteHeader(200)
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	err := client.Update(context.Background(), &UpdateRequest{
+		Name: "foo",
+		Options: &ns.NamespaceOptions{
+			BootstrapEnabled: true,
+			RetentionOptions: &ns.RetentionOptions{
+				BlockSizeNanos: 7200000000000,
+			},
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateErr(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+	client := newNamespaceClient(t, s.URL)
+
+	err := client.Update(context.Background(), &UpdateRequest{Name: "foo"})
+	require.Error(t, err)
+}
+
 func TestDeleteErr(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(500)
@@ -155,6 +236,6 @@ func TestDeleteErr(t *testing.T) {
 	defer s.Close()
 	client := newNamespaceClient(t, s.URL)
 
-	err := client.Delete("default")
+	err := client.Delete(context.Background(), "default")
 	require.NotNil(t, err)
 }
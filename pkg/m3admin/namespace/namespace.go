@@ -23,14 +23,21 @@ package namespace
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
 
 	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
+// ErrImmutableOption is returned when a namespace spec changes an option that
+// cannot be changed once the namespace has been created.
+var ErrImmutableOption = errors.New("namespace option cannot be changed")
+
 // RequestFromSpec returns a namespace add request from a cluster spec namespace
 // config.
 func RequestFromSpec(ns myspec.Namespace) (*admin.NamespaceAddRequest, error) {
@@ -79,6 +86,80 @@ func RequestFromSpec(ns myspec.Namespace) (*admin.NamespaceAddRequest, error) {
 	}, nil
 }
 
+// UpdateRequestFromSpec returns a request to update an existing namespace with
+// options current to match its spec, or nil if the namespace already matches.
+// Block sizes and whether the namespace is indexed can't be changed once a
+// namespace has been created; if the spec changes them an error wrapping
+// ErrImmutableOption is returned.
+func UpdateRequestFromSpec(ns myspec.Namespace, current *m3ns.NamespaceOptions) (*UpdateRequest, error) {
+	req, err := RequestFromSpec(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := req.Options
+	if current == nil {
+		return &UpdateRequest{Name: ns.Name, Options: desired}, nil
+	}
+
+	curRetention := current.RetentionOptions
+	if curRetention == nil {
+		curRetention = &m3ns.RetentionOptions{}
+	}
+	curIndex := current.IndexOptions
+	if curIndex == nil {
+		curIndex = &m3ns.IndexOptions{}
+	}
+
+	var immutable []string
+	if curRetention.BlockSizeNanos != desired.RetentionOptions.BlockSizeNanos {
+		immutable = append(immutable, "retentionOptions.blockSize")
+	}
+	if curIndex.Enabled != desired.IndexOptions.Enabled {
+		immutable = append(immutable, "indexOptions.enabled")
+	}
+	if curIndex.BlockSizeNanos != desired.IndexOptions.BlockSizeNanos {
+		immutable = append(immutable, "indexOptions.blockSize")
+	}
+	if len(immutable) > 0 {
+		return nil, pkgerrors.WithMessagef(ErrImmutableOption, "namespace '%s' changes %s", ns.Name,
+			strings.Join(immutable, ", "))
+	}
+
+	// Start from the current options so that we preserve any we don't manage.
+	updated := *current
+	updated.BootstrapEnabled = desired.BootstrapEnabled
+	updated.FlushEnabled = desired.FlushEnabled
+	updated.WritesToCommitLog = desired.WritesToCommitLog
+	updated.CleanupEnabled = desired.CleanupEnabled
+	updated.RepairEnabled = desired.RepairEnabled
+	updated.SnapshotEnabled = desired.SnapshotEnabled
+
+	retention := *curRetention
+	retention.RetentionPeriodNanos = desired.RetentionOptions.RetentionPeriodNanos
+	retention.BufferFutureNanos = desired.RetentionOptions.BufferFutureNanos
+	retention.BufferPastNanos = desired.RetentionOptions.BufferPastNanos
+	retention.BlockDataExpiry = desired.RetentionOptions.BlockDataExpiry
+	retention.BlockDataExpiryAfterNotAccessPeriodNanos = desired.RetentionOptions.BlockDataExpiryAfterNotAccessPeriodNanos
+	updated.RetentionOptions = &retention
+
+	if updated.BootstrapEnabled == current.BootstrapEnabled &&
+		updated.FlushEnabled == current.FlushEnabled &&
+		updated.WritesToCommitLog == current.WritesToCommitLog &&
+		updated.CleanupEnabled == current.CleanupEnabled &&
+		updated.RepairEnabled == current.RepairEnabled &&
+		updated.SnapshotEnabled == current.SnapshotEnabled &&
+		retention.RetentionPeriodNanos == curRetention.RetentionPeriodNanos &&
+		retention.BufferFutureNanos == curRetention.BufferFutureNanos &&
+		retention.BufferPastNanos == curRetention.BufferPastNanos &&
+		retention.BlockDataExpiry == curRetention.BlockDataExpiry &&
+		retention.BlockDataExpiryAfterNotAccessPeriodNanos == curRetention.BlockDataExpiryAfterNotAccessPeriodNanos {
+		return nil, nil
+	}
+
+	return &UpdateRequest{Name: ns.Name, Options: &updated}, nil
+}
+
 func m3dbNamespaceOptsFromSpec(opts *myspec.NamespaceOptions) (*m3ns.NamespaceOptions, error) {
 	retentionOpts, err := m3dbRetentionOptsFromSpec(opts.RetentionOptions)
 	if err != nil {
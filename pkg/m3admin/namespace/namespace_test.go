@@ -29,6 +29,7 @@ import (
 	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -167,6 +168,55 @@ func TestRequestFromSpec(t *testing.T) {
 	}
 }
 
+func TestUpdateRequestFromSpec(t *testing.T) {
+	spec := myspec.Namespace{
+		Name:   "foo",
+		Preset: string(PresetTenSecondsTwoDaysIndexed),
+	}
+
+	current, err := m3dbNamespaceOptsFromSpec(&presetTenSecondsTwoDaysIndexed)
+	require.NoError(t, err)
+
+	t.Run("unchanged", func(t *testing.T) {
+		req, err := UpdateRequestFromSpec(spec, current)
+		require.NoError(t, err)
+		assert.Nil(t, req)
+	})
+
+	t.Run("mutable changes", func(t *testing.T) {
+		opts := presetTenSecondsTwoDaysIndexed
+		opts.RetentionOptions.RetentionPeriod = "72h"
+		opts.RetentionOptions.BufferPast = "20m"
+		opts.RepairEnabled = true
+		changed := myspec.Namespace{Name: "foo", Options: &opts}
+
+		req, err := UpdateRequestFromSpec(changed, current)
+		require.NoError(t, err)
+		require.NotNil(t, req)
+
+		assert.Equal(t, "foo", req.Name)
+		assert.Equal(t, (72 * time.Hour).Nanoseconds(), req.Options.RetentionOptions.RetentionPeriodNanos)
+		assert.Equal(t, (20 * time.Minute).Nanoseconds(), req.Options.RetentionOptions.BufferPastNanos)
+		assert.True(t, req.Options.RepairEnabled)
+
+		// The current options must not be modified.
+		assert.Equal(t, (48 * time.Hour).Nanoseconds(), current.RetentionOptions.RetentionPeriodNanos)
+	})
+
+	t.Run("immutable changes", func(t *testing.T) {
+		opts := presetTenSecondsTwoDaysIndexed
+		opts.RetentionOptions.BlockSize = "4h"
+		opts.IndexOptions.BlockSize = "4h"
+		changed := myspec.Namespace{Name: "foo", Options: &opts}
+
+		req, err := UpdateRequestFromSpec(changed, current)
+		assert.Nil(t, req)
+		require.Error(t, err)
+		assert.Equal(t, ErrImmutableOption, pkgerrors.Cause(err))
+		assert.Contains(t, err.Error(), "retentionOptions.blockSize, indexOptions.blockSize")
+	})
+}
+
 func TestRetentionOptsFromAPI(t *testing.T) {
 	opts := myspec.RetentionOptions{
 		RetentionPeriod:                     time.Duration(time.Second).String(),
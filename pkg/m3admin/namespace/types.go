@@ -21,16 +21,69 @@
 package namespace
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	m3ns "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/gogo/protobuf/jsonpb"
 )
 
 // Client provides the interface to interact with the namespace API
 type Client interface {
 	// Create will create a namepace for the given request.
-	Create(request *admin.NamespaceAddRequest) error
+	Create(ctx context.Context, request *admin.NamespaceAddRequest) error
 	// List will retrieve all namespaces in the current cluster. The registry in
 	// the namespace response is guaranteed to be non-nil if err == nil.
-	List() (*admin.NamespaceGetResponse, error)
+	List(ctx context.Context) (*admin.NamespaceGetResponse, error)
+	// Get will retrieve the options of the namespace with the given name. The
+	// error satisfies m3admin.IsNotFound if there is no such namespace.
+	Get(ctx context.Context, name string) (*m3ns.NamespaceOptions, error)
 	// Delete will delete a namespace given a name
-	Delete(namespace string) error
+	Delete(ctx context.Context, namespace string) error
+	// Update will update the options of an existing namespace.
+	Update(ctx context.Context, request *UpdateRequest) error
+}
+
+// UpdateRequest is a request to update the options of an existing namespace.
+type UpdateRequest struct {
+	Name    string
+	Options *m3ns.NamespaceOptions
+}
+
+// updateRequestJSON is the wire format of an UpdateRequest, whose options are
+// encoded with jsonpb like every other proto message sent to the coordinator.
+type updateRequestJSON struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r UpdateRequest) MarshalJSON() ([]byte, error) {
+	req := updateRequestJSON{Name: r.Name, Options: json.RawMessage("null")}
+	if r.Options != nil {
+		opts, err := (&jsonpb.Marshaler{}).MarshalToString(r.Options)
+		if err != nil {
+			return nil, err
+		}
+		req.Options = json.RawMessage(opts)
+	}
+	return json.Marshal(req)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *UpdateRequest) UnmarshalJSON(data []byte) error {
+	req := updateRequestJSON{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	*r = UpdateRequest{Name: req.Name}
+	if len(req.Options) == 0 || string(req.Options) == "null" {
+		return nil
+	}
+	r.Options = &m3ns.NamespaceOptions{}
+	um := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	return um.Unmarshal(bytes.NewReader(req.Options), r.Options)
 }
@@ -21,6 +21,10 @@
 package m3admin
 
 import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
 	retryhttp "github.com/hashicorp/go-retryablehttp"
 	"go.uber.org/zap"
 )
@@ -40,6 +44,24 @@ type options struct {
 	logger      *zap.Logger
 	client      *retryhttp.Client
 	environment string
+	zone        string
+	tlsConfig   *tls.Config
+	transport   http.RoundTripper
+	headers     http.Header
+	timeout     time.Duration
+	retryPolicy *RetryPolicy
+	breaker     *CircuitBreaker
+}
+
+// RetryPolicy configures how requests failing with a connection error or a
+// 5xx status are retried. Requests are not retried once their context is
+// done or the client's circuit breaker opens.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried.
+	MaxRetries int
+	// MinWait and MaxWait bound the exponential backoff between retries.
+	MinWait time.Duration
+	MaxWait time.Duration
 }
 
 // WithLogger configures a logger for the client. If not set a noop logger will
@@ -66,3 +88,62 @@ func WithEnvironment(e string) Option {
 		o.environment = e
 	})
 }
+
+// WithZone controls the Cluster-Zone-Name header to m3coordinator, which
+// selects the zone of the placement and namespace services.
+func WithZone(z string) Option {
+	return optionFn(func(o *options) {
+		o.zone = z
+	})
+}
+
+// WithTLSConfig configures the TLS settings of HTTPS requests, such as the CA
+// bundle the server is verified with and the client certificate. It only
+// applies to the default HTTP client, not one set with WithHTTPClient.
+func WithTLSConfig(c *tls.Config) Option {
+	return optionFn(func(o *options) {
+		o.tlsConfig = c
+	})
+}
+
+// WithTransport sets the transport requests are sent with, such as one serving
+// them in memory in tests. Like WithTLSConfig, it only applies to the default
+// HTTP client, and the TLS config is ignored if it's set.
+func WithTransport(t http.RoundTripper) Option {
+	return optionFn(func(o *options) {
+		o.transport = t
+	})
+}
+
+// WithHeaders sets headers on every request made by the client, such as the
+// Authorization header.
+func WithHeaders(h http.Header) Option {
+	return optionFn(func(o *options) {
+		o.headers = h
+	})
+}
+
+// WithTimeout sets the timeout of each attempt of a request, including reading
+// the response body. Requests only time out through their context if not set.
+func WithTimeout(d time.Duration) Option {
+	return optionFn(func(o *options) {
+		o.timeout = d
+	})
+}
+
+// WithRetryPolicy configures how failed requests are retried. If not set, the
+// retry settings of the HTTP client are kept, which for the default client
+// are 4 retries waiting between 1s and 30s.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return optionFn(func(o *options) {
+		o.retryPolicy = &p
+	})
+}
+
+// WithCircuitBreaker makes the client fail requests fast while the breaker is
+// open, and record the outcome of every attempt of its requests on it.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return optionFn(func(o *options) {
+		o.breaker = b
+	})
+}
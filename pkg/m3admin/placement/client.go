@@ -22,9 +22,11 @@ package placement
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 
@@ -39,24 +41,33 @@ import (
 )
 
 const (
-	placementBaseURL    = "/api/v1/services/m3db/placement"
-	placementInitURL    = placementBaseURL + "/init"
-	placementReplaceURL = placementBaseURL + "/replace"
-	placementRemoveFmt  = placementBaseURL + "/%s"
+	// ServiceM3DB is the placement service name of m3db.
+	ServiceM3DB = "m3db"
+	// ServiceM3Aggregator is the placement service name of m3aggregator.
+	ServiceM3Aggregator = "m3aggregator"
+	// ServiceM3Coordinator is the placement service name of m3coordinator.
+	ServiceM3Coordinator = "m3coordinator"
+
+	placementBaseFmt     = "/api/v1/services/%s/placement"
+	placementInitPath    = "/init"
+	placementReplacePath = "/replace"
+	placementSetPath     = "/set"
 )
 
 type placementClient struct {
-	url    string
-	client m3admin.Client
-	logger *zap.Logger
+	url     string
+	service string
+	client  m3admin.Client
+	logger  *zap.Logger
 }
 
 // NewClient is the constructor the Placement interface
 func NewClient(opts ...Option) (Client, error) {
 	logger := zap.NewNop()
 	pl := &placementClient{
-		client: m3admin.NewClient(),
-		logger: logger,
+		service: ServiceM3DB,
+		client:  m3admin.NewClient(),
+		logger:  logger,
 	}
 
 	for _, o := range opts {
@@ -67,14 +78,19 @@ func NewClient(opts ...Option) (Client, error) {
 	return pl, nil
 }
 
+// baseURL returns the placement endpoint of the configured service.
+func (p *placementClient) baseURL() string {
+	return p.url + fmt.Sprintf(placementBaseFmt, p.service)
+}
+
 // Init will create the placement
-func (p *placementClient) Init(req *admin.PlacementInitRequest) error {
-	url := p.url + placementInitURL
+func (p *placementClient) Init(ctx context.Context, req *admin.PlacementInitRequest) error {
+	url := p.baseURL() + placementInitPath
 	data, err := json.Marshal(req)
 	if err != nil {
 		return err
 	}
-	_, err = p.client.DoHTTPRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	_, err = p.client.DoHTTPRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
@@ -83,9 +99,9 @@ func (p *placementClient) Init(req *admin.PlacementInitRequest) error {
 }
 
 // Delete will delete all current placements
-func (p *placementClient) Delete() error {
-	url := p.url + placementBaseURL
-	_, err := p.client.DoHTTPRequest(http.MethodDelete, url, nil)
+func (p *placementClient) Delete(ctx context.Context) error {
+	url := p.baseURL()
+	_, err := p.client.DoHTTPRequest(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
@@ -94,57 +110,126 @@ func (p *placementClient) Delete() error {
 }
 
 // Get will get current placement
-func (p *placementClient) Get() (m3placement.Placement, error) {
-	url := p.url + placementBaseURL
-	resp, err := p.client.DoHTTPRequest(http.MethodGet, url, nil)
+func (p *placementClient) Get(ctx context.Context) (m3placement.Placement, error) {
+	url := p.baseURL()
+	resp, err := p.client.DoHTTPRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	data := &admin.PlacementGetResponse{}
 	defer func() {
 		ioutil.ReadAll(resp.Body)
 		resp.Body.Close()
 	}()
 
+	pl, err := decodePlacement(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Debug("placement retreived")
+	return pl, nil
+}
+
+// Set will replace the current placement if its version hasn't changed
+func (p *placementClient) Set(ctx context.Context, pl m3placement.Placement) (m3placement.Placement, error) {
+	url := p.baseURL() + placementSetPath
+	plProto, err := pl.Proto()
+	if err != nil {
+		return nil, err
+	}
+	req := &SetRequest{
+		Placement: plProto,
+		Version:   int32(pl.Version()),
+		Confirm:   true,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.DoHTTPRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}()
+
+	newPl, err := decodePlacement(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Info("successfully set placement",
+		zap.Int("previousVersion", pl.Version()),
+		zap.Int("version", newPl.Version()))
+	return newPl, nil
+}
+
+// MarkAvailable will mark initializing shards of an instance available
+func (p *placementClient) MarkAvailable(ctx context.Context, instanceID string, shardIDs ...uint32) error {
+	pl, err := p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	pl, err = MarkShardsAvailable(pl, instanceID, shardIDs...)
+	if err != nil {
+		return err
+	}
+	_, err = p.Set(ctx, pl)
+	return err
+}
+
+// decodePlacement decodes a placement and its version from the response of a
+// placement request.
+func decodePlacement(r io.Reader) (m3placement.Placement, error) {
+	data := &admin.PlacementGetResponse{}
 	um := &jsonpb.Unmarshaler{
 		AllowUnknownFields: true,
 	}
-	if err := um.Unmarshal(resp.Body, data); err != nil {
+	if err := um.Unmarshal(r, data); err != nil {
 		return nil, err
 	}
 	if data.Placement == nil {
 		return nil, errors.New("nil placement fetch")
 	}
-	p.logger.Debug("placement retreived")
-	return m3placement.NewPlacementFromProto(data.Placement)
+	pl, err := m3placement.NewPlacementFromProto(data.Placement)
+	if err != nil {
+		return nil, err
+	}
+	return pl.SetVersion(int(data.Version)), nil
 }
 
-// Add will add an instance to the current placement
-func (p *placementClient) Add(instance placementpb.Instance) error {
-	url := p.url + placementBaseURL
+// Add will add instances to the current placement in a single request
+func (p *placementClient) Add(ctx context.Context, instances ...placementpb.Instance) error {
+	if len(instances) == 0 {
+		return errors.New("no instances to add")
+	}
+	url := p.baseURL()
 	request := &admin.PlacementAddRequest{
-		Instances: []*placementpb.Instance{&instance},
+		Instances: make([]*placementpb.Instance, 0, len(instances)),
+	}
+	for i := range instances {
+		request.Instances = append(request.Instances, &instances[i])
 	}
 	data, err := json.Marshal(request)
 	if err != nil {
 		return err
 	}
-	_, err = p.client.DoHTTPRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	_, err = p.client.DoHTTPRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
-	p.logger.Debug("successfully add instance to placement")
+	p.logger.Debug("successfully add instances to placement", zap.Int("count", len(instances)))
 	return nil
 }
 
-func (p *placementClient) Remove(id string) error {
-	url := fmt.Sprintf(p.url+placementRemoveFmt, id)
-	_, err := p.client.DoHTTPRequest(http.MethodDelete, url, nil)
+func (p *placementClient) Remove(ctx context.Context, id string) error {
+	url := p.baseURL() + "/" + id
+	_, err := p.client.DoHTTPRequest(ctx, http.MethodDelete, url, nil)
 	return err
 }
 
-func (p *placementClient) Replace(leavingInstanceID string, newInst placementpb.Instance) error {
-	url := p.url + placementReplaceURL
+func (p *placementClient) Replace(ctx context.Context, leavingInstanceID string, newInst placementpb.Instance) error {
+	url := p.baseURL() + placementReplacePath
 
 	req := &admin.PlacementReplaceRequest{
 		LeavingInstanceIDs: []string{leavingInstanceID},
@@ -156,6 +241,6 @@ func (p *placementClient) Replace(leavingInstanceID string, newInst placementpb.
 		return err
 	}
 
-	_, err = p.client.DoHTTPRequest(http.MethodPost, url, bytes.NewBuffer(data))
+	_, err = p.client.DoHTTPRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data))
 	return err
 }
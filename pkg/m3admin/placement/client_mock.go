@@ -25,6 +25,7 @@
 package placement
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
@@ -58,86 +59,125 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // Init mocks base method
-func (m *MockClient) Init(request *admin.PlacementInitRequest) error {
+func (m *MockClient) Init(ctx context.Context, request *admin.PlacementInitRequest) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Init", request)
+	ret := m.ctrl.Call(m, "Init", ctx, request)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Init indicates an expected call of Init
-func (mr *MockClientMockRecorder) Init(request interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Init(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), ctx, request)
 }
 
 // Get mocks base method
-func (m *MockClient) Get() (placement.Placement, error) {
+func (m *MockClient) Get(ctx context.Context) (placement.Placement, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get")
+	ret := m.ctrl.Call(m, "Get", ctx)
 	ret0, _ := ret[0].(placement.Placement)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get
-func (mr *MockClientMockRecorder) Get() *gomock.Call {
+func (mr *MockClientMockRecorder) Get(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), ctx)
+}
+
+// Set mocks base method
+func (m *MockClient) Set(ctx context.Context, pl placement.Placement) (placement.Placement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, pl)
+	ret0, _ := ret[0].(placement.Placement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set
+func (mr *MockClientMockRecorder) Set(ctx, pl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockClient)(nil).Set), ctx, pl)
+}
+
+// MarkAvailable mocks base method
+func (m *MockClient) MarkAvailable(ctx context.Context, instanceID string, shardIDs ...uint32) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, instanceID}
+	for _, a := range shardIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MarkAvailable", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAvailable indicates an expected call of MarkAvailable
+func (mr *MockClientMockRecorder) MarkAvailable(ctx, instanceID interface{}, shardIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, instanceID}, shardIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAvailable", reflect.TypeOf((*MockClient)(nil).MarkAvailable), varargs...)
 }
 
 // Delete mocks base method
-func (m *MockClient) Delete() error {
+func (m *MockClient) Delete(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete")
+	ret := m.ctrl.Call(m, "Delete", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockClientMockRecorder) Delete() *gomock.Call {
+func (mr *MockClientMockRecorder) Delete(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), ctx)
 }
 
 // Add mocks base method
-func (m *MockClient) Add(instance placementpb.Instance) error {
+func (m *MockClient) Add(ctx context.Context, instances ...placementpb.Instance) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Add", instance)
+	varargs := []interface{}{ctx}
+	for _, a := range instances {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Add", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Add indicates an expected call of Add
-func (mr *MockClientMockRecorder) Add(instance interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Add(ctx interface{}, instances ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockClient)(nil).Add), instance)
+	varargs := append([]interface{}{ctx}, instances...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockClient)(nil).Add), varargs...)
 }
 
 // Remove mocks base method
-func (m *MockClient) Remove(id string) error {
+func (m *MockClient) Remove(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Remove", id)
+	ret := m.ctrl.Call(m, "Remove", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Remove indicates an expected call of Remove
-func (mr *MockClientMockRecorder) Remove(id interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Remove(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockClient)(nil).Remove), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockClient)(nil).Remove), ctx, id)
 }
 
 // Replace mocks base method
-func (m *MockClient) Replace(leavingInstanceID string, newInstance placementpb.Instance) error {
+func (m *MockClient) Replace(ctx context.Context, leavingInstanceID string, newInstance placementpb.Instance) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Replace", leavingInstanceID, newInstance)
+	ret := m.ctrl.Call(m, "Replace", ctx, leavingInstanceID, newInstance)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Replace indicates an expected call of Replace
-func (mr *MockClientMockRecorder) Replace(leavingInstanceID, newInstance interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Replace(ctx, leavingInstanceID, newInstance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockClient)(nil).Replace), leavingInstanceID, newInstance)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockClient)(nil).Replace), ctx, leavingInstanceID, newInstance)
 }
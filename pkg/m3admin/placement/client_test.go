@@ -21,6 +21,8 @@
 package placement
 
 import (
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -29,6 +31,7 @@ import (
 	"github.com/m3db/m3db-operator/pkg/m3admin"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	m3placement "github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
 
 	retryhttp "github.com/hashicorp/go-retryablehttp"
@@ -65,7 +68,7 @@ func TestDelete(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Delete()
+	err := client.Delete(context.Background())
 	require.Nil(t, err)
 }
 
@@ -78,7 +81,7 @@ func TestDeleteErr(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Delete()
+	err := client.Delete(context.Background())
 	require.NotNil(t, err)
 }
 
@@ -100,10 +103,35 @@ func TestAdd(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Add(placementpb.Instance{Id: "a"})
+	err := client.Add(context.Background(), placementpb.Instance{Id: "a"})
 	require.Nil(t, err)
 }
 
+func TestAddMultiple(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bytes, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+
+		const expected = `{"instances":[{"id":"a"},{"id":"b"}]}`
+		assert.Equal(t, expected, string(bytes))
+
+		w.WriteHeader(200)
+		w.Write([]byte("{}"))
+	}))
+
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	err := client.Add(context.Background(), placementpb.Instance{Id: "a"}, placementpb.Instance{Id: "b"})
+	require.Nil(t, err)
+
+	err = client.Add(context.Background())
+	require.Error(t, err)
+}
+
 func TestAddErr(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(500)
@@ -113,7 +141,7 @@ func TestAddErr(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Add(placementpb.Instance{})
+	err := client.Add(context.Background(), placementpb.Instance{})
 	require.NotNil(t, err)
 }
 
@@ -126,7 +154,7 @@ func TestInit(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Init(&admin.PlacementInitRequest{})
+	err := client.Init(context.Background(), &admin.PlacementInitRequest{})
 	require.Nil(t, err)
 }
 
@@ -139,7 +167,7 @@ func TestInitErr(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	err := client.Init(&admin.PlacementInitRequest{})
+	err := client.Init(context.Background(), &admin.PlacementInitRequest{})
 	require.NotNil(t, err)
 }
 
@@ -151,7 +179,7 @@ func TestGet(t *testing.T) {
 	defer s.Close()
 	client := newPlacementClient(t, s.URL)
 
-	placement, err := client.Get()
+	placement, err := client.Get(context.Background())
 	require.NotNil(t, placement)
 	require.NoError(t, err)
 }
@@ -165,7 +193,7 @@ func TestGetErr(t *testing.T) {
 
 	client := newPlacementClient(t, s.URL)
 
-	placement, err := client.Get()
+	placement, err := client.Get(context.Background())
 	require.Nil(t, placement)
 	require.Error(t, err)
 }
@@ -182,7 +210,29 @@ func TestRemove(t *testing.T) {
 	defer s.Close()
 
 	client := newPlacementClient(t, s.URL)
-	err := client.Remove("instFoo")
+	err := client.Remove(context.Background(), "instFoo")
+	assert.NoError(t, err)
+}
+
+func TestRemoveService(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/services/m3aggregator/placement/instFoo" || r.Method != http.MethodDelete {
+			w.WriteHeader(404)
+			return
+		}
+		w.WriteHeader(200)
+		w.Write([]byte(`{"placement": {}}`))
+	}))
+	defer s.Close()
+
+	client, err := NewClient(
+		WithURL(s.URL),
+		WithClient(newM3adminClient()),
+		WithService(ServiceM3Aggregator),
+	)
+	require.NoError(t, err)
+
+	err = client.Remove(context.Background(), "instFoo")
 	assert.NoError(t, err)
 }
 
@@ -198,6 +248,142 @@ func TestReplace(t *testing.T) {
 	defer s.Close()
 
 	cl := newPlacementClient(t, s.URL)
-	err := cl.Replace("A", placementpb.Instance{})
+	err := cl.Replace(context.Background(), "A", placementpb.Instance{})
 	assert.NoError(t, err)
 }
+
+func newTestPlacementProto() *placementpb.Placement {
+	return &placementpb.Placement{
+		NumShards:     1,
+		ReplicaFactor: 1,
+		IsSharded:     true,
+		Instances: map[string]*placementpb.Instance{
+			"a": {
+				Id: "a",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_LEAVING},
+				},
+			},
+			"b": {
+				Id: "b",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_INITIALIZING, SourceId: "a"},
+				},
+			},
+		},
+	}
+}
+
+func writePlacement(t *testing.T, w http.ResponseWriter, pl *placementpb.Placement, version int32) {
+	data, err := json.Marshal(map[string]interface{}{
+		"placement": pl,
+		"version":   version,
+	})
+	require.NoError(t, err)
+	w.WriteHeader(200)
+	w.Write(data)
+}
+
+func TestGetVersion(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writePlacement(t, w, newTestPlacementProto(), 3)
+	}))
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	pl, err := client.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, pl.Version())
+	assert.Equal(t, 2, pl.NumInstances())
+}
+
+func TestSet(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/services/m3db/placement/set" || r.Method != http.MethodPost {
+			w.WriteHeader(404)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		// The placement is encoded with jsonpb's field names.
+		assert.Contains(t, string(body), `"replicaFactor":`)
+		assert.NotContains(t, string(body), `"replica_factor":`)
+
+		req := &SetRequest{}
+		require.NoError(t, json.Unmarshal(body, req))
+		assert.Equal(t, int32(3), req.Version)
+		assert.True(t, req.Confirm)
+		assert.Len(t, req.Placement.Instances, 2)
+
+		writePlacement(t, w, req.Placement, req.Version+1)
+	}))
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	pl, err := m3placement.NewPlacementFromProto(newTestPlacementProto())
+	require.NoError(t, err)
+
+	newPl, err := client.Set(context.Background(), pl.SetVersion(3))
+	require.NoError(t, err)
+	assert.Equal(t, 4, newPl.Version())
+}
+
+func TestSetConflict(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(409)
+		w.Write([]byte(`{"error": "version mismatch"}`))
+	}))
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	pl, err := m3placement.NewPlacementFromProto(newTestPlacementProto())
+	require.NoError(t, err)
+
+	_, err = client.Set(context.Background(), pl)
+	assert.True(t, m3admin.IsConflict(err))
+}
+
+func TestRemoveErr(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"error": "shards not available"}`))
+	}))
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	err := client.Remove(context.Background(), "instFoo")
+	assert.True(t, m3admin.IsBadRequest(err))
+}
+
+func TestMarkAvailable(t *testing.T) {
+	var set *SetRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			writePlacement(t, w, newTestPlacementProto(), 5)
+		case r.URL.Path == "/api/v1/services/m3db/placement/set":
+			set = &SetRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(set))
+			writePlacement(t, w, set.Placement, set.Version+1)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer s.Close()
+	client := newPlacementClient(t, s.URL)
+
+	err := client.MarkAvailable(context.Background(), "b")
+	require.NoError(t, err)
+
+	require.NotNil(t, set)
+	assert.Equal(t, int32(5), set.Version)
+	require.Len(t, set.Placement.Instances, 1)
+	inst := set.Placement.Instances["b"]
+	require.NotNil(t, inst)
+	require.Len(t, inst.Shards, 1)
+	assert.Equal(t, placementpb.ShardState_AVAILABLE, inst.Shards[0].State)
+
+	err = client.MarkAvailable(context.Background(), "c")
+	assert.Error(t, err)
+}
@@ -21,6 +21,7 @@
 package placement
 
 import (
+	"errors"
 	"net/url"
 
 	"github.com/m3db/m3db-operator/pkg/m3admin"
@@ -66,3 +67,15 @@ func WithClient(cl m3admin.Client) Option {
 		return nil
 	})
 }
+
+// WithService configures the placement service (e.g. m3db or m3aggregator)
+// the client operates on. Defaults to m3db.
+func WithService(service string) Option {
+	return optionFn(func(p *placementClient) error {
+		if service == "" {
+			return errors.New("placement service must be non-empty")
+		}
+		p.service = service
+		return nil
+	})
+}
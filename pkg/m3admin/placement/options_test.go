@@ -35,6 +35,9 @@ func TestOptions(t *testing.T) {
 	opt := WithURL("...")
 	assert.Error(t, opt.execute(client))
 
+	opt = WithService("")
+	assert.Error(t, opt.execute(client))
+
 	const url = "http://localhost:1234"
 	l := zap.NewNop()
 	m3cl := m3admin.NewClient()
@@ -42,6 +45,7 @@ func TestOptions(t *testing.T) {
 		WithURL(url),
 		WithLogger(l),
 		WithClient(m3cl),
+		WithService(ServiceM3Aggregator),
 	}
 
 	for _, opt := range opts {
@@ -51,4 +55,5 @@ func TestOptions(t *testing.T) {
 	assert.Equal(t, l, client.logger)
 	assert.Equal(t, m3cl, client.client)
 	assert.Equal(t, url, client.url)
+	assert.Equal(t, ServiceM3Aggregator, client.service)
 }
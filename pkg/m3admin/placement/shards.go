@@ -0,0 +1,152 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+)
+
+// MarkShardsAvailable returns a copy of the placement in which the given
+// initializing shards of an instance, or all of them if none are given, are
+// available. The leaving copies of the shards on the instances they were
+// streamed from are removed, along with any of those instances left without
+// shards.
+func MarkShardsAvailable(
+	pl m3placement.Placement,
+	instanceID string,
+	shardIDs ...uint32,
+) (m3placement.Placement, error) {
+	pl = pl.Clone()
+	inst, ok := pl.Instance(instanceID)
+	if !ok {
+		return nil, fmt.Errorf("instance '%s' not in placement", instanceID)
+	}
+
+	if len(shardIDs) == 0 {
+		for _, s := range inst.Shards().ShardsForState(shard.Initializing) {
+			shardIDs = append(shardIDs, s.ID())
+		}
+	}
+
+	emptied := make(map[string]struct{})
+	for _, id := range shardIDs {
+		s, ok := inst.Shards().Shard(id)
+		if !ok {
+			return nil, fmt.Errorf("shard %d not assigned to instance '%s'", id, instanceID)
+		}
+		if s.State() != shard.Initializing {
+			return nil, fmt.Errorf("shard %d of instance '%s' is not initializing", id, instanceID)
+		}
+
+		if source, ok := pl.Instance(s.SourceID()); ok {
+			if leaving, ok := source.Shards().Shard(id); ok && leaving.State() == shard.Leaving {
+				source.Shards().Remove(id)
+			}
+			if source.Shards().NumShards() == 0 {
+				emptied[source.ID()] = struct{}{}
+			}
+		}
+		s.SetState(shard.Available)
+	}
+
+	if len(emptied) == 0 {
+		return pl, nil
+	}
+
+	instances := make([]m3placement.Instance, 0, pl.NumInstances())
+	for _, inst := range pl.Instances() {
+		if _, ok := emptied[inst.ID()]; !ok {
+			instances = append(instances, inst)
+		}
+	}
+	return pl.SetInstances(instances), nil
+}
+
+// AddReplica returns a copy of the placement with its replication factor
+// increased by one, and the new replica of every shard assigned to one of the
+// given instances. The instances must form a single isolation group that has no
+// instances in the placement yet, so that no two replicas of a shard share a
+// group. New replicas are initializing without a source so that they're
+// bootstrapped from the existing replicas, and are spread across the instances
+// in proportion to their weights.
+func AddReplica(pl m3placement.Placement, instances ...m3placement.Instance) (m3placement.Placement, error) {
+	if len(instances) == 0 {
+		return nil, errors.New("no instances to hold the new replica")
+	}
+
+	group := instances[0].IsolationGroup()
+	for _, inst := range pl.Instances() {
+		if inst.IsolationGroup() == group {
+			return nil, fmt.Errorf("isolation group '%s' already in placement", group)
+		}
+	}
+
+	added := make([]m3placement.Instance, 0, len(instances))
+	for _, inst := range instances {
+		if inst.IsolationGroup() != group {
+			return nil, fmt.Errorf("instances are in isolation groups '%s' and '%s'", group, inst.IsolationGroup())
+		}
+		if _, ok := pl.Instance(inst.ID()); ok {
+			return nil, fmt.Errorf("instance '%s' already in placement", inst.ID())
+		}
+		if inst.Shards().NumShards() > 0 {
+			return nil, fmt.Errorf("instance '%s' already has shards", inst.ID())
+		}
+		added = append(added, inst.Clone())
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].ID() < added[j].ID()
+	})
+
+	for _, id := range pl.Shards() {
+		// Pick the instance that holds the fewest shards relative to its weight
+		// once it takes this one.
+		var picked m3placement.Instance
+		for _, inst := range added {
+			if picked == nil || lessLoaded(inst, picked) {
+				picked = inst
+			}
+		}
+		picked.Shards().Add(shard.NewShard(id).SetState(shard.Initializing))
+	}
+
+	pl = pl.Clone()
+	return pl.SetInstances(append(pl.Instances(), added...)).SetReplicaFactor(pl.ReplicaFactor() + 1), nil
+}
+
+// lessLoaded returns true if a would hold fewer shards than b relative to
+// its weight after taking on one more shard. Instances without a weight are
+// weighed as 1.
+func lessLoaded(a, b m3placement.Instance) bool {
+	wa, wb := uint64(a.Weight()), uint64(b.Weight())
+	if wa == 0 {
+		wa = 1
+	}
+	if wb == 0 {
+		wb = 1
+	}
+	return uint64(a.Shards().NumShards()+1)*wb < uint64(b.Shards().NumShards()+1)*wa
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReplacePlacement(t *testing.T) m3placement.Placement {
+	pl, err := m3placement.NewPlacementFromProto(&placementpb.Placement{
+		NumShards:     2,
+		ReplicaFactor: 1,
+		IsSharded:     true,
+		Instances: map[string]*placementpb.Instance{
+			"a": {
+				Id: "a",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_LEAVING},
+					{Id: 1, State: placementpb.ShardState_LEAVING},
+				},
+			},
+			"b": {
+				Id: "b",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_INITIALIZING, SourceId: "a"},
+					{Id: 1, State: placementpb.ShardState_INITIALIZING, SourceId: "a"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return pl
+}
+
+func shardState(t *testing.T, pl m3placement.Placement, instanceID string, id uint32) shard.State {
+	inst, ok := pl.Instance(instanceID)
+	require.True(t, ok)
+	s, ok := inst.Shards().Shard(id)
+	require.True(t, ok)
+	return s.State()
+}
+
+func TestMarkShardsAvailable(t *testing.T) {
+	pl := newTestReplacePlacement(t)
+
+	// Marking one shard available only drops its leaving copy.
+	partial, err := MarkShardsAvailable(pl, "b", 1)
+	require.NoError(t, err)
+	assert.Equal(t, shard.Initializing, shardState(t, partial, "b", 0))
+	assert.Equal(t, shard.Available, shardState(t, partial, "b", 1))
+	assert.Equal(t, shard.Leaving, shardState(t, partial, "a", 0))
+	src, ok := partial.Instance("a")
+	require.True(t, ok)
+	assert.False(t, src.Shards().Contains(1))
+
+	// The given placement is left untouched.
+	assert.Equal(t, shard.Initializing, shardState(t, pl, "b", 1))
+	assert.Equal(t, shard.Leaving, shardState(t, pl, "a", 1))
+
+	// Marking the rest removes the emptied source instance.
+	full, err := MarkShardsAvailable(partial, "b")
+	require.NoError(t, err)
+	assert.Equal(t, shard.Available, shardState(t, full, "b", 0))
+	assert.Equal(t, 1, full.NumInstances())
+	_, ok = full.Instance("a")
+	assert.False(t, ok)
+}
+
+func TestMarkShardsAvailableErrors(t *testing.T) {
+	pl := newTestReplacePlacement(t)
+
+	_, err := MarkShardsAvailable(pl, "c")
+	assert.Error(t, err)
+
+	_, err = MarkShardsAvailable(pl, "b", 2)
+	assert.Error(t, err)
+
+	_, err = MarkShardsAvailable(pl, "a", 0)
+	assert.Error(t, err)
+}
+
+func TestAddReplica(t *testing.T) {
+	pl, err := m3placement.NewPlacementFromProto(&placementpb.Placement{
+		NumShards:     6,
+		ReplicaFactor: 1,
+		IsSharded:     true,
+		Instances: map[string]*placementpb.Instance{
+			"a": {
+				Id:             "a",
+				IsolationGroup: "group-a",
+				Shards: []*placementpb.Shard{
+					{Id: 0, State: placementpb.ShardState_AVAILABLE},
+					{Id: 1, State: placementpb.ShardState_AVAILABLE},
+					{Id: 2, State: placementpb.ShardState_AVAILABLE},
+					{Id: 3, State: placementpb.ShardState_AVAILABLE},
+					{Id: 4, State: placementpb.ShardState_AVAILABLE},
+					{Id: 5, State: placementpb.ShardState_AVAILABLE},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	newInstance := func(id, group string, weight uint32) m3placement.Instance {
+		return m3placement.NewInstance().SetID(id).SetIsolationGroup(group).SetWeight(weight)
+	}
+
+	// The heavier instance takes twice the shards of the lighter one.
+	newPl, err := AddReplica(pl, newInstance("b1", "group-b", 200), newInstance("b2", "group-b", 100))
+	require.NoError(t, err)
+	assert.Equal(t, 2, newPl.ReplicaFactor())
+	assert.Equal(t, 1, pl.ReplicaFactor())
+	assert.Equal(t, 3, newPl.NumInstances())
+
+	b1, ok := newPl.Instance("b1")
+	require.True(t, ok)
+	b2, ok := newPl.Instance("b2")
+	require.True(t, ok)
+	assert.Equal(t, 4, b1.Shards().NumShards())
+	assert.Equal(t, 2, b2.Shards().NumShards())
+	for _, inst := range []m3placement.Instance{b1, b2} {
+		for _, s := range inst.Shards().All() {
+			assert.Equal(t, shard.Initializing, s.State())
+			assert.Empty(t, s.SourceID())
+		}
+	}
+
+	// Every shard has exactly one new replica, and the existing ones are kept.
+	for id := uint32(0); id < 6; id++ {
+		assert.NotEqual(t, b1.Shards().Contains(id), b2.Shards().Contains(id))
+		assert.Equal(t, shard.Available, shardState(t, newPl, "a", id))
+	}
+
+	_, err = AddReplica(pl)
+	assert.Error(t, err)
+
+	_, err = AddReplica(pl, newInstance("a2", "group-a", 100))
+	assert.Error(t, err)
+
+	_, err = AddReplica(pl, newInstance("b1", "group-b", 100), newInstance("c1", "group-c", 100))
+	assert.Error(t, err)
+}
@@ -21,23 +21,88 @@
 package placement
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	m3placement "github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/gogo/protobuf/jsonpb"
 )
 
 // Client provides the interface to interact with the placement API
 type Client interface {
 	// Init will initialize a placement give a valid placement request
-	Init(request *admin.PlacementInitRequest) error
-	// Get will provide the current placement
-	Get() (placement m3placement.Placement, err error)
+	Init(ctx context.Context, request *admin.PlacementInitRequest) error
+	// Get will provide the current placement, along with its version.
+	Get(ctx context.Context) (placement m3placement.Placement, err error)
+	// Set replaces the current placement with the given one if the current
+	// placement's version still matches the given one's, and returns the new
+	// placement with its version. The coordinator rejects the request if the
+	// placement changed in the meantime. The placement set API isn't part of
+	// the admin API of the pinned m3 version, so only call it on coordinators
+	// known to serve it.
+	Set(ctx context.Context, pl m3placement.Placement) (m3placement.Placement, error)
+	// MarkAvailable marks the given initializing shards of an instance as
+	// available, or all of them if none are given, removing the leaving shards
+	// they were copied from. The placement is only updated if it didn't change
+	// since it was read. It's built on Set and has the same requirement.
+	MarkAvailable(ctx context.Context, instanceID string, shardIDs ...uint32) error
 	// Delete will delete the current placment
-	Delete() error
-	// Add will add an instance to the placement
-	Add(instance placementpb.Instance) error
+	Delete(ctx context.Context) error
+	// Add will add one or more instances to the placement in a single request.
+	Add(ctx context.Context, instances ...placementpb.Instance) error
 	// Remove removes a given instance with the given ID from the placement.
-	Remove(id string) error
+	// Unless the coordinator is told to force changes, it only removes an
+	// instance while every shard is available, so instances must be removed
+	// one at a time.
+	Remove(ctx context.Context, id string) error
 	// Replace replaces one instance with another.
-	Replace(leavingInstanceID string, newInstance placementpb.Instance) error
+	Replace(ctx context.Context, leavingInstanceID string, newInstance placementpb.Instance) error
+}
+
+// SetRequest is a request to replace a placement, which only succeeds if the
+// stored placement's version is still Version.
+type SetRequest struct {
+	Placement *placementpb.Placement
+	Version   int32
+	Confirm   bool
+}
+
+// setRequestJSON is the wire format of a SetRequest, whose placement is
+// encoded with jsonpb like every other proto message sent to the coordinator.
+type setRequestJSON struct {
+	Placement json.RawMessage `json:"placement,omitempty"`
+	Version   int32           `json:"version"`
+	Confirm   bool            `json:"confirm"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r SetRequest) MarshalJSON() ([]byte, error) {
+	req := setRequestJSON{Version: r.Version, Confirm: r.Confirm}
+	if r.Placement != nil {
+		pl, err := (&jsonpb.Marshaler{}).MarshalToString(r.Placement)
+		if err != nil {
+			return nil, err
+		}
+		req.Placement = json.RawMessage(pl)
+	}
+	return json.Marshal(req)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *SetRequest) UnmarshalJSON(data []byte) error {
+	req := setRequestJSON{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	*r = SetRequest{Version: req.Version, Confirm: req.Confirm}
+	if len(req.Placement) == 0 || string(req.Placement) == "null" {
+		return nil
+	}
+	r.Placement = &placementpb.Placement{}
+	um := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	return um.Unmarshal(bytes.NewReader(req.Placement), r.Placement)
 }
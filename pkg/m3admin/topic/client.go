@@ -0,0 +1,177 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package topic provides a client for the m3coordinator m3msg topic API.
+package topic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+
+	"go.uber.org/zap"
+)
+
+const (
+	topicBaseURL    = "/api/v1/topic"
+	topicInitURL    = topicBaseURL + "/init"
+	topicNameHeader = "Topic-Name"
+)
+
+type topicClient struct {
+	client m3admin.Client
+	logger *zap.Logger
+	url    string
+}
+
+// Option provides an interface that can be used for setter options with the
+// constructor
+type Option interface {
+	execute(*topicClient) error
+}
+
+type optionFn func(t *topicClient) error
+
+func (fn optionFn) execute(t *topicClient) error {
+	return fn(t)
+}
+
+// WithURL is a setter to override the default url of m3coordinator
+func WithURL(u string) Option {
+	return optionFn(func(t *topicClient) error {
+		if _, err := url.ParseRequestURI(u); err != nil {
+			return err
+		}
+		t.url = u
+		return nil
+	})
+}
+
+// WithLogger is a setter to override the default logger
+func WithLogger(logger *zap.Logger) Option {
+	return optionFn(func(t *topicClient) error {
+		t.logger = logger
+		return nil
+	})
+}
+
+// WithClient configures an m3admin client.
+func WithClient(cl m3admin.Client) Option {
+	return optionFn(func(t *topicClient) error {
+		t.client = cl
+		return nil
+	})
+}
+
+// NewClient constructs a new topic client
+func NewClient(opts ...Option) (Client, error) {
+	tc := &topicClient{
+		client: m3admin.NewClient(),
+		logger: zap.NewNop(),
+	}
+	for _, o := range opts {
+		if err := o.execute(tc); err != nil {
+			return nil, err
+		}
+	}
+	return tc, nil
+}
+
+func (t *topicClient) do(ctx context.Context, action, url, topic string, req interface{}) (*http.Response, error) {
+	var buf *bytes.Buffer
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		buf = bytes.NewBuffer(data)
+	}
+	return t.client.DoHTTPRequest(ctx, action, url, buf, m3admin.WithHeader(topicNameHeader, topic))
+}
+
+// Init will initialize a topic
+func (t *topicClient) Init(ctx context.Context, topic string, numberOfShards uint32) error {
+	resp, err := t.do(ctx, http.MethodPost, t.url+topicInitURL, topic, &InitRequest{
+		NumberOfShards: numberOfShards,
+	})
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	t.logger.Info("successfully initialized topic", zap.String("topic", topic))
+	return nil
+}
+
+// Get will retrieve a topic
+func (t *topicClient) Get(ctx context.Context, topic string) (*Topic, error) {
+	resp, err := t.do(ctx, http.MethodGet, t.url+topicBaseURL, topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drain(resp)
+
+	data := &GetResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return nil, err
+	}
+	if data.Topic == nil {
+		return nil, errors.New("nil topic from coordinator")
+	}
+
+	t.logger.Debug("topic retrieved", zap.String("topic", topic))
+	return data.Topic, nil
+}
+
+// AddConsumer will add a consumer service to a topic
+func (t *topicClient) AddConsumer(ctx context.Context, topic string, consumer ConsumerService) error {
+	resp, err := t.do(ctx, http.MethodPost, t.url+topicBaseURL, topic, &AddRequest{
+		ConsumerService: consumer,
+	})
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	t.logger.Info("successfully added topic consumer",
+		zap.String("topic", topic),
+		zap.String("consumer", consumer.ServiceID.Name))
+	return nil
+}
+
+// Delete will delete a topic
+func (t *topicClient) Delete(ctx context.Context, topic string) error {
+	resp, err := t.do(ctx, http.MethodDelete, t.url+topicBaseURL, topic, nil)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	t.logger.Info("successfully deleted topic", zap.String("topic", topic))
+	return nil
+}
+
+func drain(resp *http.Response) {
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+}
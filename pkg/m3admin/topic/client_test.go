@@ -0,0 +1,133 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+
+	retryhttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTopicClient(t *testing.T, url string) Client {
+	retry := retryhttp.NewClient()
+	retry.RetryMax = 0
+
+	cl, err := NewClient(
+		WithURL(url),
+		WithClient(m3admin.NewClient(m3admin.WithHTTPClient(retry))),
+	)
+	require.NoError(t, err)
+	return cl
+}
+
+func TestOptions(t *testing.T) {
+	_, err := NewClient(WithURL("..."))
+	assert.Error(t, err)
+}
+
+func TestInit(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.String() != "/api/v1/topic/init" || r.Method != http.MethodPost ||
+			r.Header.Get("Topic-Name") != "foo" {
+			w.WriteHeader(404)
+			return
+		}
+		assert.Equal(t, `{"numberOfShards":64}`, string(body))
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+
+	cl := newTopicClient(t, s.URL)
+	assert.NoError(t, cl.Init(context.Background(), "foo", 64))
+	assert.Error(t, cl.Init(context.Background(), "bar", 64))
+}
+
+func TestGet(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/topic" || r.Method != http.MethodGet ||
+			r.Header.Get("Topic-Name") != "foo" {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		w.Write([]byte(`{"topic":{"name":"foo","numberOfShards":64,"consumerServices":[` +
+			`{"serviceId":{"name":"m3aggregator","environment":"default/foo","zone":"embedded"},` +
+			`"consumptionType":"REPLICATED","messageTtlNanos":"300000000000"}]},"version":1}`))
+	}))
+	defer s.Close()
+
+	cl := newTopicClient(t, s.URL)
+	topic, err := cl.Get(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", topic.Name)
+	assert.Equal(t, uint32(64), topic.NumberOfShards)
+	assert.True(t, topic.HasConsumer("m3aggregator"))
+	assert.False(t, topic.HasConsumer("m3coordinator"))
+
+	_, err = cl.Get(context.Background(), "bar")
+	assert.Equal(t, m3admin.ErrNotFound, errors.Cause(err))
+}
+
+func TestAddConsumer(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.String() != "/api/v1/topic" || r.Method != http.MethodPost ||
+			r.Header.Get("Topic-Name") != "foo" {
+			w.WriteHeader(404)
+			return
+		}
+		const expected = `{"consumerService":{"serviceId":{"name":"m3coordinator"},"consumptionType":"SHARED"}}`
+		assert.Equal(t, expected, string(body))
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+
+	cl := newTopicClient(t, s.URL)
+	err := cl.AddConsumer(context.Background(), "foo", ConsumerService{
+		ServiceID:       ServiceID{Name: "m3coordinator"},
+		ConsumptionType: ConsumptionTypeShared,
+	})
+	assert.NoError(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/api/v1/topic" || r.Method != http.MethodDelete {
+			w.WriteHeader(500)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer s.Close()
+
+	cl := newTopicClient(t, s.URL)
+	assert.NoError(t, cl.Delete(context.Background(), "foo"))
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topic
+
+import "context"
+
+// ConsumptionType is the way a consumer service consumes a topic.
+type ConsumptionType string
+
+const (
+	// ConsumptionTypeShared means each message is consumed by a single
+	// instance of the consumer service.
+	ConsumptionTypeShared ConsumptionType = "SHARED"
+	// ConsumptionTypeReplicated means each message is consumed by every replica
+	// of a shard in the consumer service.
+	ConsumptionTypeReplicated ConsumptionType = "REPLICATED"
+)
+
+// Client provides the interface to interact with the m3msg topic API.
+type Client interface {
+	// Init will initialize a topic with the given number of shards.
+	Init(ctx context.Context, topic string, numberOfShards uint32) error
+	// Get will retrieve a topic. It returns an error wrapping
+	// m3admin.ErrNotFound if the topic does not exist.
+	Get(ctx context.Context, topic string) (*Topic, error)
+	// AddConsumer will add a consumer service to an existing topic.
+	AddConsumer(ctx context.Context, topic string, consumer ConsumerService) error
+	// Delete will delete a topic.
+	Delete(ctx context.Context, topic string) error
+}
+
+// Topic is an m3msg topic.
+type Topic struct {
+	Name             string            `json:"name"`
+	NumberOfShards   uint32            `json:"numberOfShards"`
+	ConsumerServices []ConsumerService `json:"consumerServices,omitempty"`
+}
+
+// HasConsumer returns whether a consumer with the given service name is
+// registered on the topic.
+func (t *Topic) HasConsumer(service string) bool {
+	for _, c := range t.ConsumerServices {
+		if c.ServiceID.Name == service {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsumerService is a service consuming a topic.
+type ConsumerService struct {
+	ServiceID       ServiceID       `json:"serviceId"`
+	ConsumptionType ConsumptionType `json:"consumptionType"`
+	MessageTTLNanos int64           `json:"messageTtlNanos,omitempty,string"`
+}
+
+// ServiceID identifies a service registered in the cluster KV store.
+type ServiceID struct {
+	Name        string `json:"name"`
+	Environment string `json:"environment,omitempty"`
+	Zone        string `json:"zone,omitempty"`
+}
+
+// InitRequest is a request to initialize a topic.
+type InitRequest struct {
+	NumberOfShards uint32 `json:"numberOfShards"`
+}
+
+// AddRequest is a request to add a consumer service to a topic.
+type AddRequest struct {
+	ConsumerService ConsumerService `json:"consumerService"`
+}
+
+// GetResponse is the response of a topic get request.
+type GetResponse struct {
+	Topic   *Topic `json:"topic"`
+	Version int    `json:"version"`
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package listwatch provides a ListerWatcher spanning several namespaces, for
+// informers restricted to a set of namespaces.
+package listwatch
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NewFunc returns the ListerWatcher of a single namespace.
+type NewFunc func(namespace string) cache.ListerWatcher
+
+// MultiNamespace returns a ListerWatcher of objects in the given namespaces,
+// built from the ListerWatchers newFn returns for each of them. No namespaces
+// means all namespaces.
+func MultiNamespace(namespaces []string, newFn NewFunc) cache.ListerWatcher {
+	var unique []string
+	seen := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		if _, ok := seen[ns]; ok {
+			continue
+		}
+		seen[ns] = struct{}{}
+		unique = append(unique, ns)
+	}
+
+	switch len(unique) {
+	case 0:
+		return newFn(metav1.NamespaceAll)
+	case 1:
+		return newFn(unique[0])
+	}
+
+	lws := make(map[string]cache.ListerWatcher, len(unique))
+	for _, ns := range unique {
+		lws[ns] = newFn(ns)
+	}
+
+	return &multiListerWatcher{
+		namespaces:       unique,
+		lws:              lws,
+		resourceVersions: make(map[string]string, len(unique)),
+	}
+}
+
+// multiListerWatcher merges the lists and watches of several namespaces.
+// Resource versions are only meaningful within a namespace, so rather than
+// resuming watches from the single version the caller last saw, it resumes
+// each namespace's watch from the last version seen in that namespace.
+type multiListerWatcher struct {
+	namespaces []string
+	lws        map[string]cache.ListerWatcher
+
+	mu               sync.Mutex
+	resourceVersions map[string]string
+}
+
+func (m *multiListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	list := &metav1.List{}
+	versions := make(map[string]string, len(m.namespaces))
+	for _, ns := range m.namespaces {
+		nsList, err := m.lws[ns].List(options)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := meta.ExtractList(nsList)
+		if err != nil {
+			return nil, err
+		}
+
+		listMeta, err := meta.ListAccessor(nsList)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			list.Items = append(list.Items, runtime.RawExtension{Object: item})
+		}
+		versions[ns] = listMeta.GetResourceVersion()
+	}
+
+	m.mu.Lock()
+	m.resourceVersions = versions
+	m.mu.Unlock()
+
+	return list, nil
+}
+
+func (m *multiListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	m.mu.Lock()
+	versions := make(map[string]string, len(m.resourceVersions))
+	for ns, rv := range m.resourceVersions {
+		versions[ns] = rv
+	}
+	m.mu.Unlock()
+
+	mw := &multiWatch{
+		result: make(chan watch.Event),
+		stopCh: make(chan struct{}),
+	}
+
+	for _, ns := range m.namespaces {
+		nsOptions := options
+		nsOptions.ResourceVersion = versions[ns]
+		w, err := m.lws[ns].Watch(nsOptions)
+		if err != nil {
+			mw.Stop()
+			return nil, err
+		}
+		mw.watches = append(mw.watches, w)
+	}
+
+	for i, ns := range m.namespaces {
+		ns := ns
+		mw.wg.Add(1)
+		go mw.forward(mw.watches[i], func(rv string) {
+			m.mu.Lock()
+			m.resourceVersions[ns] = rv
+			m.mu.Unlock()
+		})
+	}
+
+	go func() {
+		mw.wg.Wait()
+		close(mw.result)
+	}()
+
+	return mw, nil
+}
+
+// multiWatch merges the events of several watches. It stops as soon as any
+// of them stops, so that the caller restarts all of them.
+type multiWatch struct {
+	watches  []watch.Interface
+	result   chan watch.Event
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func (m *multiWatch) ResultChan() <-chan watch.Event {
+	return m.result
+}
+
+func (m *multiWatch) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+		for _, w := range m.watches {
+			w.Stop()
+		}
+	})
+}
+
+// forward sends the events of w to the merged result, calling observe with
+// the resource version of each object that has been delivered.
+func (m *multiWatch) forward(w watch.Interface, observe func(rv string)) {
+	defer m.wg.Done()
+	defer m.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+
+			select {
+			case <-m.stopCh:
+				return
+			case m.result <- ev:
+			}
+
+			if ev.Type == watch.Error {
+				continue
+			}
+			if obj, err := meta.Accessor(ev.Object); err == nil {
+				observe(obj.GetResourceVersion())
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package listwatch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNamespace struct {
+	pods    []corev1.Pod
+	rv      string
+	listErr error
+
+	watcher   *watch.FakeWatcher
+	watchOpts []metav1.ListOptions
+}
+
+func (f *fakeNamespace) listerWatcher() cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			if f.listErr != nil {
+				return nil, f.listErr
+			}
+			return &corev1.PodList{
+				ListMeta: metav1.ListMeta{ResourceVersion: f.rv},
+				Items:    f.pods,
+			}, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			f.watchOpts = append(f.watchOpts, opts)
+			f.watcher = watch.NewFake()
+			return f.watcher, nil
+		},
+	}
+}
+
+func newPod(namespace, name, rv string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			ResourceVersion: rv,
+		},
+	}
+}
+
+func newFakes(namespaces ...string) (map[string]*fakeNamespace, NewFunc) {
+	fakes := make(map[string]*fakeNamespace, len(namespaces))
+	for _, ns := range namespaces {
+		fakes[ns] = &fakeNamespace{}
+	}
+	return fakes, func(ns string) cache.ListerWatcher {
+		return fakes[ns].listerWatcher()
+	}
+}
+
+func TestMultiNamespaceSingle(t *testing.T) {
+	var namespaces []string
+	newFn := func(ns string) cache.ListerWatcher {
+		namespaces = append(namespaces, ns)
+		return &cache.ListWatch{}
+	}
+
+	lw := MultiNamespace(nil, newFn)
+	assert.IsType(t, &cache.ListWatch{}, lw)
+
+	lw = MultiNamespace([]string{"a", "a"}, newFn)
+	assert.IsType(t, &cache.ListWatch{}, lw)
+
+	assert.Equal(t, []string{metav1.NamespaceAll, "a"}, namespaces)
+}
+
+func TestMultiNamespaceList(t *testing.T) {
+	fakes, newFn := newFakes("a", "b")
+	fakes["a"].pods = []corev1.Pod{*newPod("a", "pod1", "10")}
+	fakes["b"].pods = []corev1.Pod{*newPod("b", "pod2", "20"), *newPod("b", "pod3", "21")}
+
+	lw := MultiNamespace([]string{"a", "b"}, newFn)
+	obj, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+
+	list := obj.(*metav1.List)
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.Object.(*corev1.Pod).Name)
+	}
+	assert.Equal(t, []string{"pod1", "pod2", "pod3"}, names)
+
+	fakes["b"].listErr = errors.New("test")
+	_, err = lw.List(metav1.ListOptions{})
+	assert.Error(t, err)
+}
+
+func TestMultiNamespaceWatch(t *testing.T) {
+	fakes, newFn := newFakes("a", "b")
+	fakes["a"].rv = "10"
+	fakes["b"].rv = "20"
+
+	lw := MultiNamespace([]string{"a", "b"}, newFn)
+	_, err := lw.List(metav1.ListOptions{})
+	require.NoError(t, err)
+
+	// Watches start from each namespace's list version.
+	w, err := lw.Watch(metav1.ListOptions{ResourceVersion: "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, "10", fakes["a"].watchOpts[0].ResourceVersion)
+	assert.Equal(t, "20", fakes["b"].watchOpts[0].ResourceVersion)
+
+	go fakes["a"].watcher.Add(newPod("a", "pod1", "11"))
+	ev := receive(t, w)
+	assert.Equal(t, watch.Added, ev.Type)
+	assert.Equal(t, "pod1", ev.Object.(*corev1.Pod).Name)
+
+	go fakes["b"].watcher.Modify(newPod("b", "pod2", "25"))
+	ev = receive(t, w)
+	assert.Equal(t, watch.Modified, ev.Type)
+	assert.Equal(t, "pod2", ev.Object.(*corev1.Pod).Name)
+
+	// Stopping one namespace's watch ends the merged watch.
+	fakes["a"].watcher.Stop()
+	select {
+	case _, ok := <-w.ResultChan():
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop")
+	}
+
+	// Watches resume from the last version seen in each namespace.
+	w, err = lw.Watch(metav1.ListOptions{ResourceVersion: "25"})
+	require.NoError(t, err)
+	defer w.Stop()
+	assert.Equal(t, "11", fakes["a"].watchOpts[1].ResourceVersion)
+	assert.Equal(t, "25", fakes["b"].watchOpts[1].ResourceVersion)
+}
+
+func receive(t *testing.T, w watch.Interface) watch.Event {
+	select {
+	case ev, ok := <-w.ResultChan():
+		require.True(t, ok)
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return watch.Event{}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"errors"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidObjectStore is returned when a backup's object store is
+	// missing a required field.
+	ErrInvalidObjectStore = errors.New("invalid object store")
+
+	// ErrMissingReference is returned when a backup or restore doesn't name the
+	// object it applies to.
+	ErrMissingReference = errors.New("missing reference")
+)
+
+// ValidateBackup validates a backup's spec.
+func ValidateBackup(backup *myspec.M3DBBackup) error {
+	if backup.Spec.ClusterName == "" {
+		return pkgerrors.WithMessage(ErrMissingReference, "clusterName cannot be empty")
+	}
+
+	store := backup.Spec.Store
+	switch {
+	case store.Endpoint == "":
+		return pkgerrors.WithMessage(ErrInvalidObjectStore, "endpoint cannot be empty")
+	case store.Bucket == "":
+		return pkgerrors.WithMessage(ErrInvalidObjectStore, "bucket cannot be empty")
+	case store.CredentialsSecretName == "":
+		return pkgerrors.WithMessage(ErrInvalidObjectStore, "credentialsSecretName cannot be empty")
+	}
+
+	return nil
+}
+
+// ValidateRestore validates a restore's spec.
+func ValidateRestore(restore *myspec.M3DBRestore) error {
+	if restore.Spec.BackupName == "" {
+		return pkgerrors.WithMessage(ErrMissingReference, "backupName cannot be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBackup(t *testing.T) {
+	newBackup := func() *myspec.M3DBBackup {
+		return &myspec.M3DBBackup{
+			Spec: myspec.M3DBBackupSpec{
+				ClusterName: "cluster-a",
+				Store: myspec.ObjectStore{
+					Endpoint:              "minio:9000",
+					Bucket:                "backups",
+					CredentialsSecretName: "minio-creds",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(b *myspec.M3DBBackup)
+		expErr error
+	}{
+		{
+			name:   "valid",
+			modify: func(b *myspec.M3DBBackup) {},
+		},
+		{
+			name:   "no cluster",
+			modify: func(b *myspec.M3DBBackup) { b.Spec.ClusterName = "" },
+			expErr: ErrMissingReference,
+		},
+		{
+			name:   "no endpoint",
+			modify: func(b *myspec.M3DBBackup) { b.Spec.Store.Endpoint = "" },
+			expErr: ErrInvalidObjectStore,
+		},
+		{
+			name:   "no bucket",
+			modify: func(b *myspec.M3DBBackup) { b.Spec.Store.Bucket = "" },
+			expErr: ErrInvalidObjectStore,
+		},
+		{
+			name:   "no credentials",
+			modify: func(b *myspec.M3DBBackup) { b.Spec.Store.CredentialsSecretName = "" },
+			expErr: ErrInvalidObjectStore,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backup := newBackup()
+			test.modify(backup)
+			err := ValidateBackup(backup)
+			assert.Equal(t, test.expErr, pkgerrors.Cause(err))
+		})
+	}
+}
+
+func TestValidateRestore(t *testing.T) {
+	restore := &myspec.M3DBRestore{}
+	assert.Equal(t, ErrMissingReference, pkgerrors.Cause(ValidateRestore(restore)))
+
+	restore.Spec.BackupName = "backup-a"
+	assert.NoError(t, ValidateRestore(restore))
+}
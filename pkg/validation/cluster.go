@@ -0,0 +1,420 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package validation contains validation of M3DB cluster specs shared by the
+// controller and the admission webhook.
+package validation
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/defaults"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/m3admin/namespace"
+
+	corev1 "k8s.io/api/core/v1"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidNumIsoGroups is returned when the number of isolation groups
+	// doesn't match the replication factor.
+	ErrInvalidNumIsoGroups = errors.New("number of isolationgroups not equal to replication factor")
+
+	// ErrNonUniqueIsoGroups is returned when isolation group names are not
+	// unique.
+	ErrNonUniqueIsoGroups = errors.New("isolation group names are not unique")
+
+	// ErrEmptyConfigMapName is returned when the ConfigMap name is set but
+	// empty.
+	ErrEmptyConfigMapName = errors.New("configMapName cannot be empty if non-nil")
+
+	// ErrInvalidInstancesPerIsolationGroup is returned when the number of
+	// instances per isolation group is set but not positive.
+	ErrInvalidInstancesPerIsolationGroup = errors.New("instancesPerIsolationGroup must be positive if non-nil")
+
+	// ErrInvalidCoordinatorReplicas is returned when the number of separate
+	// coordinators is negative.
+	ErrInvalidCoordinatorReplicas = errors.New("coordinator replicas cannot be negative")
+
+	// ErrAggregatorRequiresCoordinator is returned when a cluster runs
+	// aggregators without separate coordinators.
+	ErrAggregatorRequiresCoordinator = errors.New("aggregator requires coordinator to be set")
+
+	// ErrInvalidAggregatorInstances is returned when the aggregator isolation
+	// groups don't all have the same positive number of instances.
+	ErrInvalidAggregatorInstances = errors.New("aggregator isolation groups must have the same positive number of instances")
+
+	// ErrInvalidAggregatorShards is returned when the number of aggregator
+	// shards is negative.
+	ErrInvalidAggregatorShards = errors.New("aggregator numberOfShards cannot be negative")
+
+	// ErrInvalidAggregatedNamespace is returned when an aggregated namespace
+	// has no name or an unparseable resolution or retention.
+	ErrInvalidAggregatedNamespace = errors.New("invalid aggregated namespace")
+
+	// ErrInvalidNamespace is returned when a namespace in the spec can't be
+	// turned into a valid namespace request.
+	ErrInvalidNamespace = errors.New("invalid namespace")
+
+	// ErrInvalidAutoReplaceGracePeriod is returned when the grace period of
+	// failed pod replacement is negative.
+	ErrInvalidAutoReplaceGracePeriod = errors.New("autoReplace gracePeriodSeconds cannot be negative")
+
+	// ErrDeleteClaimRequiresIdentitySource is returned when the volume claims of
+	// failed pods are deleted but pod identity is derived from the pod name
+	// alone. A pod recreated with a new volume would then have the same
+	// identity, and would serve its instance's shards without their data.
+	ErrDeleteClaimRequiresIdentitySource = errors.New("autoReplace deletePersistentVolumeClaim requires a pod identity source other than the pod name")
+
+	// ErrInvalidPort is returned when a port of the cluster is out of range or
+	// is used twice.
+	ErrInvalidPort = errors.New("ports must be unique and between 1 and 65535")
+
+	// ErrInvalidListenAddress is returned when the listen address of the
+	// cluster's ports is not an IP address.
+	ErrInvalidListenAddress = errors.New("ports listenAddress must be an IP address")
+
+	// ErrInvalidSecretKeySelector is returned when a Secret referenced by the
+	// cluster's admin API spec is missing its name or key.
+	ErrInvalidSecretKeySelector = errors.New("adminAPI secret references must have a name and key")
+
+	// ErrInvalidAdminAPIHeader is returned when an admin API auth header has
+	// no name.
+	ErrInvalidAdminAPIHeader = errors.New("adminAPI auth headers must have a name")
+
+	// ErrInsecureAdminAPI is returned when secure admin API calls are required
+	// but the cluster's admin API isn't called over TLS with credentials.
+	ErrInsecureAdminAPI = errors.New("adminAPI must configure TLS and authentication")
+
+	// ErrImmutableField is returned when an update changes a field that can't
+	// be changed once the cluster's placement has been initialized.
+	ErrImmutableField = errors.New("field cannot be changed once placement is initialized")
+
+	// ErrInvalidReplicationFactorChange is returned when an update changes the
+	// replication factor of a cluster with an initialized placement other than
+	// by increasing it by one and adding one isolation group.
+	ErrInvalidReplicationFactorChange = errors.New("replicationFactor can only be increased by one, along with adding one isolation group")
+)
+
+// ValidateCluster validates a cluster's spec.
+func ValidateCluster(cluster *myspec.M3DBCluster) error {
+	if _, err := defaults.Version(cluster); err != nil {
+		return err
+	}
+
+	if err := ValidateIsolationGroups(cluster); err != nil {
+		return err
+	}
+
+	if name := cluster.Spec.ConfigMapName; name != nil && *name == "" {
+		return ErrEmptyConfigMapName
+	}
+
+	if n := cluster.Spec.InstancesPerIsolationGroup; n != nil && *n < 1 {
+		return pkgerrors.WithMessagef(ErrInvalidInstancesPerIsolationGroup, "got %d", *n)
+	}
+
+	if coord := cluster.Spec.Coordinator; coord != nil {
+		if name := coord.ConfigMapName; name != nil && *name == "" {
+			return pkgerrors.WithMessage(ErrEmptyConfigMapName, "coordinator")
+		}
+		if coord.Replicas < 0 {
+			return pkgerrors.WithMessagef(ErrInvalidCoordinatorReplicas, "got %d", coord.Replicas)
+		}
+	}
+
+	if err := ValidateAggregator(cluster); err != nil {
+		return err
+	}
+
+	if err := ValidateAutoReplace(cluster); err != nil {
+		return err
+	}
+
+	if err := ValidatePorts(cluster); err != nil {
+		return err
+	}
+
+	if err := ValidateAdminAPI(cluster); err != nil {
+		return err
+	}
+
+	for _, ns := range cluster.Spec.Namespaces {
+		// Building the request checks presets and parses all durations.
+		if _, err := namespace.RequestFromSpec(ns); err != nil {
+			return pkgerrors.WithMessagef(ErrInvalidNamespace, "namespace '%s': %v", ns.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateClusterUpdate validates an update from old to cluster. In addition
+// to validating the new spec, the number of shards can't be changed once the
+// placement has been initialized, and the replication factor can only be
+// increased by one at a time.
+func ValidateClusterUpdate(old, cluster *myspec.M3DBCluster) error {
+	if err := ValidateCluster(cluster); err != nil {
+		return err
+	}
+
+	if !old.Status.HasInitializedPlacement() {
+		return nil
+	}
+
+	if old.Spec.NumberOfShards != cluster.Spec.NumberOfShards {
+		return pkgerrors.WithMessagef(ErrImmutableField, "numberOfShards changed from %d to %d",
+			old.Spec.NumberOfShards, cluster.Spec.NumberOfShards)
+	}
+
+	if err := validateReplicationFactorUpdate(old, cluster); err != nil {
+		return err
+	}
+
+	// The client port is the endpoint of every instance in the placement.
+	if oldPort, port := k8sops.ClusterPorts(old).Client, k8sops.ClusterPorts(cluster).Client; oldPort != port {
+		return pkgerrors.WithMessagef(ErrImmutableField, "ports client changed from %d to %d", oldPort, port)
+	}
+
+	// Moving the cluster to another zone would lose its placement and
+	// namespaces, which are stored under the zone.
+	if k8sops.ServiceZone(old) != k8sops.ServiceZone(cluster) {
+		return pkgerrors.WithMessagef(ErrImmutableField, "serviceZone changed from %q to %q",
+			k8sops.ServiceZone(old), k8sops.ServiceZone(cluster))
+	}
+
+	return nil
+}
+
+// validateReplicationFactorUpdate validates a change of the replication
+// factor. Increasing it adds a replica of every shard to the placement, which
+// is held by the one isolation group added along with it, so every existing
+// group has to be kept.
+func validateReplicationFactorUpdate(old, cluster *myspec.M3DBCluster) error {
+	oldRF, rf := old.Spec.ReplicationFactor, cluster.Spec.ReplicationFactor
+	if oldRF == rf {
+		return nil
+	}
+
+	if rf != oldRF+1 {
+		return pkgerrors.WithMessagef(ErrInvalidReplicationFactorChange, "replicationFactor changed from %d to %d",
+			oldRF, rf)
+	}
+
+	groups := myspec.IsolationGroups(cluster.Spec.IsolationGroups)
+	for _, group := range old.Spec.IsolationGroups {
+		if _, ok := groups.GetByName(group.Name); !ok {
+			return pkgerrors.WithMessagef(ErrInvalidReplicationFactorChange,
+				"isolation group '%s' removed while increasing replicationFactor", group.Name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateIsolationGroups validates that the cluster has one uniquely named
+// isolation group per replica.
+func ValidateIsolationGroups(cluster *myspec.M3DBCluster) error {
+	return validateIsolationGroups(cluster.Spec.IsolationGroups, cluster.Spec.ReplicationFactor)
+}
+
+// ValidateAdminAPI validates the references of the cluster's admin API spec to
+// Secrets, if any. Whether the Secrets exist is only checked when the operator
+// calls the admin API.
+func ValidateAdminAPI(cluster *myspec.M3DBCluster) error {
+	spec := cluster.Spec.AdminAPI
+	if spec == nil {
+		return nil
+	}
+
+	if spec.TLS != nil && spec.TLS.CASecret != nil {
+		if err := validateSecretKeySelector(*spec.TLS.CASecret); err != nil {
+			return pkgerrors.WithMessage(err, "tls caSecret")
+		}
+	}
+
+	if spec.Auth != nil {
+		if spec.Auth.BearerTokenSecret != nil {
+			if err := validateSecretKeySelector(*spec.Auth.BearerTokenSecret); err != nil {
+				return pkgerrors.WithMessage(err, "auth bearerTokenSecret")
+			}
+		}
+		for _, header := range spec.Auth.Headers {
+			if header.Name == "" {
+				return ErrInvalidAdminAPIHeader
+			}
+			if err := validateSecretKeySelector(header.ValueFrom); err != nil {
+				return pkgerrors.WithMessagef(err, "auth header '%s'", header.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateSecureAdminAPI validates that the cluster's admin API is called over
+// TLS and authenticated, with either a client certificate or auth headers.
+func ValidateSecureAdminAPI(cluster *myspec.M3DBCluster) error {
+	spec := cluster.Spec.AdminAPI
+	if spec == nil || spec.TLS == nil {
+		return pkgerrors.WithMessage(ErrInsecureAdminAPI, "tls not set")
+	}
+
+	if spec.TLS.ClientCertSecret != "" {
+		return nil
+	}
+	if spec.Auth != nil && (spec.Auth.BearerTokenSecret != nil || len(spec.Auth.Headers) > 0) {
+		return nil
+	}
+
+	return pkgerrors.WithMessage(ErrInsecureAdminAPI, "no client certificate or auth credentials set")
+}
+
+func validateSecretKeySelector(sel corev1.SecretKeySelector) error {
+	if sel.Name == "" || sel.Key == "" {
+		return ErrInvalidSecretKeySelector
+	}
+	return nil
+}
+
+// ValidatePorts validates the cluster's ports section, if any. The ports, with
+// unset ones defaulted, must all differ since M3DB nodes listen on all of them.
+func ValidatePorts(cluster *myspec.M3DBCluster) error {
+	if cluster.Spec.Ports == nil {
+		return nil
+	}
+
+	ports := k8sops.ClusterPorts(cluster)
+	if net.ParseIP(ports.ListenAddress) == nil {
+		return pkgerrors.WithMessagef(ErrInvalidListenAddress, "got %q", ports.ListenAddress)
+	}
+
+	seen := make(map[int32]string)
+	for _, p := range []struct {
+		name string
+		port int32
+	}{
+		{"client", ports.Client},
+		{"cluster", ports.Cluster},
+		{"httpNode", ports.HTTPNode},
+		{"httpCluster", ports.HTTPCluster},
+		{"debug", ports.Debug},
+		{"coordinator", ports.Coordinator},
+		{"coordinatorMetrics", ports.CoordinatorMetrics},
+	} {
+		if p.port < 1 || p.port > 65535 {
+			return pkgerrors.WithMessagef(ErrInvalidPort, "%s port %d", p.name, p.port)
+		}
+		if other, ok := seen[p.port]; ok {
+			return pkgerrors.WithMessagef(ErrInvalidPort, "%s and %s both use port %d", other, p.name, p.port)
+		}
+		seen[p.port] = p.name
+	}
+
+	return nil
+}
+
+// ValidateAutoReplace validates the cluster's autoReplace section, if any.
+func ValidateAutoReplace(cluster *myspec.M3DBCluster) error {
+	autoReplace := cluster.Spec.AutoReplace
+	if autoReplace == nil {
+		return nil
+	}
+
+	if autoReplace.GracePeriodSeconds < 0 {
+		return pkgerrors.WithMessagef(ErrInvalidAutoReplaceGracePeriod, "got %d", autoReplace.GracePeriodSeconds)
+	}
+
+	// An unset config defaults to the pod UID.
+	config := cluster.Spec.PodIdentityConfig
+	if autoReplace.DeletePersistentVolumeClaim && config != nil && len(config.Sources) == 0 {
+		return ErrDeleteClaimRequiresIdentitySource
+	}
+
+	return nil
+}
+
+// ValidateAggregator validates the cluster's aggregator section, if any. Each
+// aggregator shard set is mirrored once per isolation group, so every group
+// must have the same number of instances.
+func ValidateAggregator(cluster *myspec.M3DBCluster) error {
+	agg := cluster.Spec.Aggregator
+	if agg == nil {
+		return nil
+	}
+
+	if cluster.Spec.Coordinator == nil {
+		return ErrAggregatorRequiresCoordinator
+	}
+
+	if err := validateIsolationGroups(agg.IsolationGroups, agg.ReplicationFactor); err != nil {
+		return pkgerrors.WithMessage(err, "aggregator")
+	}
+
+	if name := agg.ConfigMapName; name != nil && *name == "" {
+		return pkgerrors.WithMessage(ErrEmptyConfigMapName, "aggregator")
+	}
+
+	if agg.NumberOfShards < 0 {
+		return pkgerrors.WithMessagef(ErrInvalidAggregatorShards, "got %d", agg.NumberOfShards)
+	}
+
+	for _, g := range agg.IsolationGroups {
+		if g.NumInstances < 1 || g.NumInstances != agg.IsolationGroups[0].NumInstances {
+			return pkgerrors.WithMessagef(ErrInvalidAggregatorInstances, "group '%s' has %d instances, group '%s' has %d",
+				g.Name, g.NumInstances, agg.IsolationGroups[0].Name, agg.IsolationGroups[0].NumInstances)
+		}
+	}
+
+	for _, ns := range agg.Namespaces {
+		if ns.Name == "" {
+			return pkgerrors.WithMessage(ErrInvalidAggregatedNamespace, "name cannot be empty")
+		}
+		if _, err := time.ParseDuration(ns.Resolution); err != nil {
+			return pkgerrors.WithMessagef(ErrInvalidAggregatedNamespace, "namespace '%s' resolution: %v", ns.Name, err)
+		}
+		if _, err := time.ParseDuration(ns.Retention); err != nil {
+			return pkgerrors.WithMessagef(ErrInvalidAggregatedNamespace, "namespace '%s' retention: %v", ns.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateIsolationGroups(groups []myspec.IsolationGroup, rf int32) error {
+	if rf != int32(len(groups)) {
+		return pkgerrors.WithMessagef(ErrInvalidNumIsoGroups, "replication factor is %d but number of isogroups is %d", rf, len(groups))
+	}
+
+	names := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		names[g.Name] = struct{}{}
+	}
+
+	if len(names) != len(groups) {
+		return pkgerrors.WithMessagef(ErrNonUniqueIsoGroups, "found %d isolationGroups but %d unique names", len(groups), len(names))
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package validation
+
+import (
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/defaults"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubernetes/utils/pointer"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCluster() *myspec.M3DBCluster {
+	return &myspec.M3DBCluster{
+		Spec: myspec.ClusterSpec{
+			NumberOfShards:    8,
+			ReplicationFactor: 2,
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "foo"},
+				{Name: "bar"},
+			},
+			Namespaces: []myspec.Namespace{
+				{Name: "metrics-10s:2d", Preset: "10s:2d"},
+			},
+		},
+	}
+}
+
+func TestValidateIsolationGroups(t *testing.T) {
+	tests := []struct {
+		groups   []myspec.IsolationGroup
+		rf       int32
+		doExpErr bool
+		expErr   error
+	}{
+		{
+			rf: 2,
+			groups: []myspec.IsolationGroup{
+				{Name: "foo"},
+				{Name: "bar"},
+			},
+		},
+		{
+			rf: 3,
+			groups: []myspec.IsolationGroup{
+				{Name: "foo"},
+				{Name: "bar"},
+			},
+			expErr:   ErrInvalidNumIsoGroups,
+			doExpErr: true,
+		},
+		{
+			rf: 2,
+			groups: []myspec.IsolationGroup{
+				{Name: "foo"},
+				{Name: "foo"},
+			},
+			expErr:   ErrNonUniqueIsoGroups,
+			doExpErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		cluster := newCluster()
+		cluster.Spec.ReplicationFactor = test.rf
+		cluster.Spec.IsolationGroups = test.groups
+		err := ValidateIsolationGroups(cluster)
+		if test.doExpErr {
+			assert.Error(t, err)
+			assert.Equal(t, test.expErr, pkgerrors.Cause(err))
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func newAggregatorSpec() *myspec.AggregatorSpec {
+	return &myspec.AggregatorSpec{
+		ReplicationFactor: 2,
+		IsolationGroups: []myspec.IsolationGroup{
+			{Name: "a", NumInstances: 2},
+			{Name: "b", NumInstances: 2},
+		},
+		Namespaces: []myspec.AggregatedNamespace{
+			{Name: "agg", Resolution: "1m", Retention: "720h"},
+		},
+	}
+}
+
+func TestValidateCluster(t *testing.T) {
+	emptyName := ""
+
+	tests := []struct {
+		name   string
+		modify func(cluster *myspec.M3DBCluster)
+		expErr error
+	}{
+		{
+			name:   "valid",
+			modify: func(*myspec.M3DBCluster) {},
+		},
+		{
+			name: "unknown defaults version",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Annotations = map[string]string{annotations.DefaultsVersion: "1000"}
+			},
+			expErr: defaults.ErrUnknownVersion,
+		},
+		{
+			name: "invalid isolation groups",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.ReplicationFactor = 3
+			},
+			expErr: ErrInvalidNumIsoGroups,
+		},
+		{
+			name: "empty configmap name",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.ConfigMapName = &emptyName
+			},
+			expErr: ErrEmptyConfigMapName,
+		},
+		{
+			name: "zero instances per isolation group",
+			modify: func(cluster *myspec.M3DBCluster) {
+				n := int32(0)
+				cluster.Spec.InstancesPerIsolationGroup = &n
+			},
+			expErr: ErrInvalidInstancesPerIsolationGroup,
+		},
+		{
+			name: "empty coordinator configmap name",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{
+					ConfigMapName: pointer.StringPtr(""),
+				}
+			},
+			expErr: ErrEmptyConfigMapName,
+		},
+		{
+			name: "negative coordinator replicas",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{Replicas: -1}
+			},
+			expErr: ErrInvalidCoordinatorReplicas,
+		},
+		{
+			name: "valid aggregator",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+				cluster.Spec.Aggregator = newAggregatorSpec()
+			},
+		},
+		{
+			name: "aggregator without coordinator",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Aggregator = newAggregatorSpec()
+			},
+			expErr: ErrAggregatorRequiresCoordinator,
+		},
+		{
+			name: "aggregator isolation groups",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+				cluster.Spec.Aggregator = newAggregatorSpec()
+				cluster.Spec.Aggregator.ReplicationFactor = 3
+			},
+			expErr: ErrInvalidNumIsoGroups,
+		},
+		{
+			name: "uneven aggregator isolation groups",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+				cluster.Spec.Aggregator = newAggregatorSpec()
+				cluster.Spec.Aggregator.IsolationGroups[1].NumInstances = 3
+			},
+			expErr: ErrInvalidAggregatorInstances,
+		},
+		{
+			name: "negative aggregator shards",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+				cluster.Spec.Aggregator = newAggregatorSpec()
+				cluster.Spec.Aggregator.NumberOfShards = -1
+			},
+			expErr: ErrInvalidAggregatorShards,
+		},
+		{
+			name: "invalid aggregated namespace",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Coordinator = &myspec.CoordinatorSpec{}
+				cluster.Spec.Aggregator = newAggregatorSpec()
+				cluster.Spec.Aggregator.Namespaces[0].Resolution = "1 minute"
+			},
+			expErr: ErrInvalidAggregatedNamespace,
+		},
+		{
+			name: "valid autoReplace",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AutoReplace = &myspec.AutoReplaceSpec{GracePeriodSeconds: 300}
+			},
+		},
+		{
+			name: "negative autoReplace grace period",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AutoReplace = &myspec.AutoReplaceSpec{GracePeriodSeconds: -1}
+			},
+			expErr: ErrInvalidAutoReplaceGracePeriod,
+		},
+		{
+			name: "autoReplace with name identity",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AutoReplace = &myspec.AutoReplaceSpec{}
+				cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{}
+			},
+		},
+		{
+			name: "autoReplace deleting claims with name identity",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AutoReplace = &myspec.AutoReplaceSpec{DeletePersistentVolumeClaim: true}
+				cluster.Spec.PodIdentityConfig = &myspec.PodIdentityConfig{}
+			},
+			expErr: ErrDeleteClaimRequiresIdentitySource,
+		},
+		{
+			name: "valid ports",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Ports = &myspec.PortsSpec{ListenAddress: "10.0.0.1", Client: 19000, Coordinator: 17201}
+			},
+		},
+		{
+			name: "negative port",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Ports = &myspec.PortsSpec{Debug: -1}
+			},
+			expErr: ErrInvalidPort,
+		},
+		{
+			name: "port out of range",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Ports = &myspec.PortsSpec{Client: 70000}
+			},
+			expErr: ErrInvalidPort,
+		},
+		{
+			name: "port conflicting with a default",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Ports = &myspec.PortsSpec{Client: 9001}
+			},
+			expErr: ErrInvalidPort,
+		},
+		{
+			name: "invalid listen address",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Ports = &myspec.PortsSpec{ListenAddress: "localhost"}
+			},
+			expErr: ErrInvalidListenAddress,
+		},
+		{
+			name: "valid admin API",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AdminAPI = &myspec.AdminAPISpec{
+					TLS: &myspec.AdminTLSSpec{CASecret: secretKeySelector("ca", "ca.crt")},
+					Auth: &myspec.AdminAuthSpec{
+						Headers: []myspec.AdminAuthHeader{
+							{Name: "X-Api-Key", ValueFrom: *secretKeySelector("auth", "key")},
+						},
+					},
+				}
+			},
+		},
+		{
+			name: "admin API secret without key",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AdminAPI = &myspec.AdminAPISpec{
+					Auth: &myspec.AdminAuthSpec{BearerTokenSecret: secretKeySelector("auth", "")},
+				}
+			},
+			expErr: ErrInvalidSecretKeySelector,
+		},
+		{
+			name: "admin API header without name",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.AdminAPI = &myspec.AdminAPISpec{
+					Auth: &myspec.AdminAuthSpec{
+						Headers: []myspec.AdminAuthHeader{{ValueFrom: *secretKeySelector("auth", "key")}},
+					},
+				}
+			},
+			expErr: ErrInvalidAdminAPIHeader,
+		},
+		{
+			name: "unknown preset",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Namespaces[0].Preset = "1s:1s"
+			},
+			expErr: ErrInvalidNamespace,
+		},
+		{
+			name: "unparseable duration",
+			modify: func(cluster *myspec.M3DBCluster) {
+				cluster.Spec.Namespaces[0] = myspec.Namespace{
+					Name: "foo",
+					Options: &myspec.NamespaceOptions{
+						RetentionOptions: myspec.RetentionOptions{
+							RetentionPeriod: "2 days",
+							BlockSize:       "2h",
+							BufferFuture:    "10m",
+							BufferPast:      "10m",
+						},
+					},
+				}
+			},
+			expErr: ErrInvalidNamespace,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newCluster()
+			test.modify(cluster)
+			err := ValidateCluster(cluster)
+			if test.expErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, test.expErr, pkgerrors.Cause(err))
+			}
+		})
+	}
+}
+
+func secretKeySelector(name, key string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  key,
+	}
+}
+
+func TestValidateSecureAdminAPI(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		spec   *myspec.AdminAPISpec
+		expErr error
+	}{
+		{
+			name:   "unset",
+			expErr: ErrInsecureAdminAPI,
+		},
+		{
+			name: "auth without tls",
+			spec: &myspec.AdminAPISpec{
+				Auth: &myspec.AdminAuthSpec{BearerTokenSecret: secretKeySelector("auth", "token")},
+			},
+			expErr: ErrInsecureAdminAPI,
+		},
+		{
+			name:   "tls without auth",
+			spec:   &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{}},
+			expErr: ErrInsecureAdminAPI,
+		},
+		{
+			name: "tls with bearer token",
+			spec: &myspec.AdminAPISpec{
+				TLS:  &myspec.AdminTLSSpec{},
+				Auth: &myspec.AdminAuthSpec{BearerTokenSecret: secretKeySelector("auth", "token")},
+			},
+		},
+		{
+			name: "tls with client certificate",
+			spec: &myspec.AdminAPISpec{TLS: &myspec.AdminTLSSpec{ClientCertSecret: "client"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newCluster()
+			cluster.Spec.AdminAPI = test.spec
+			err := ValidateSecureAdminAPI(cluster)
+			if test.expErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, test.expErr, pkgerrors.Cause(err))
+			}
+		})
+	}
+}
+
+func TestValidateClusterUpdate(t *testing.T) {
+	old := newCluster()
+	cluster := newCluster()
+	cluster.Spec.NumberOfShards = 16
+	assert.NoError(t, ValidateClusterUpdate(old, cluster))
+
+	old.Status.Conditions = []myspec.ClusterCondition{
+		{
+			Type:   myspec.ClusterConditionPlacementInitialized,
+			Status: corev1.ConditionTrue,
+		},
+	}
+	err := ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrImmutableField, pkgerrors.Cause(err))
+
+	// The replication factor can be increased by one along with adding a group.
+	cluster = newCluster()
+	cluster.Spec.ReplicationFactor = 3
+	cluster.Spec.IsolationGroups = append(cluster.Spec.IsolationGroups, myspec.IsolationGroup{Name: "baz"})
+	assert.NoError(t, ValidateClusterUpdate(old, cluster))
+
+	// But not if an existing group is replaced at the same time.
+	cluster.Spec.IsolationGroups[0].Name = "qux"
+	err = ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrInvalidReplicationFactorChange, pkgerrors.Cause(err))
+
+	cluster = newCluster()
+	cluster.Spec.ReplicationFactor = 4
+	cluster.Spec.IsolationGroups = append(cluster.Spec.IsolationGroups,
+		myspec.IsolationGroup{Name: "baz"}, myspec.IsolationGroup{Name: "qux"})
+	err = ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrInvalidReplicationFactorChange, pkgerrors.Cause(err))
+
+	cluster = newCluster()
+	cluster.Spec.ReplicationFactor = 1
+	cluster.Spec.IsolationGroups = cluster.Spec.IsolationGroups[:1]
+	err = ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrInvalidReplicationFactorChange, pkgerrors.Cause(err))
+
+	cluster = newCluster()
+	cluster.Spec.ServiceZone = "us-east1"
+	err = ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrImmutableField, pkgerrors.Cause(err))
+
+	// Setting the default zone explicitly is not a change.
+	cluster.Spec.ServiceZone = "embedded"
+	assert.NoError(t, ValidateClusterUpdate(old, cluster))
+
+	cluster = newCluster()
+	cluster.Spec.Ports = &myspec.PortsSpec{Client: 19000}
+	err = ValidateClusterUpdate(old, cluster)
+	assert.Equal(t, ErrImmutableField, pkgerrors.Cause(err))
+
+	// Other ports can change, the pods are rolled with the new config.
+	cluster.Spec.Ports = &myspec.PortsSpec{Client: 9000, Coordinator: 17201}
+	assert.NoError(t, ValidateClusterUpdate(old, cluster))
+
+	cluster = newCluster()
+	cluster.Spec.IsolationGroups[0].Zone = "us-east1-b"
+	cluster.Spec.IsolationGroups[0].Weight = 200
+	cluster.Spec.Namespaces = append(cluster.Spec.Namespaces, myspec.Namespace{Name: "foo", Preset: "1m:40d"})
+	assert.NoError(t, ValidateClusterUpdate(old, cluster))
+}
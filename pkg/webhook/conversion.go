@@ -0,0 +1,211 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator"
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1beta1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"go.uber.org/zap"
+)
+
+// ConvertPath is the path the CRD conversion webhook is served on.
+const ConvertPath = "/convert"
+
+// The apiextensions API vendored by the operator predates CRD conversion, so
+// the ConversionReview wire types (apiextensions.k8s.io/v1beta1) are mirrored
+// here.
+
+type conversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *conversionRequest  `json:"request,omitempty"`
+	Response        *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// conversionHandler converts the objects of each ConversionReview to the
+// desired API version.
+func conversionHandler(logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		review := &conversionReview{}
+		if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+			http.Error(w, "invalid conversion review", http.StatusBadRequest)
+			return
+		}
+
+		resp := convertObjects(review.Request)
+		if resp.Result.Status != metav1.StatusSuccess {
+			logger.Error("error converting objects",
+				zap.String("desiredAPIVersion", review.Request.DesiredAPIVersion),
+				zap.String("reason", resp.Result.Message))
+		}
+
+		review.Response = resp
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			logger.Error("error writing conversion response", zap.Error(err))
+		}
+	})
+}
+
+func convertObjects(req *conversionRequest) *conversionResponse {
+	resp := &conversionResponse{UID: req.UID}
+	for _, obj := range req.Objects {
+		converted, err := convertObject(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			resp.ConvertedObjects = nil
+			resp.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			return resp
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	resp.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return resp
+}
+
+// convertObject converts a single encoded M3DBCluster or M3DBNamespace to
+// the desired API version, going through v1alpha1.
+func convertObject(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	meta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, fmt.Errorf("error decoding object: %v", err)
+	}
+
+	if meta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	var (
+		hub runtime.Object
+		err error
+	)
+	switch meta.Kind {
+	case m3dboperator.ResourceKind:
+		hub, err = convertClusterToHub(raw, meta.APIVersion)
+	case m3dboperator.NamespaceResourceKind:
+		hub, err = convertNamespaceToHub(raw, meta.APIVersion)
+	default:
+		return nil, fmt.Errorf("unsupported kind '%s'", meta.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out runtime.Object
+	switch desiredAPIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		out = hub
+	case v1beta1.SchemeGroupVersion.String():
+		switch hub := hub.(type) {
+		case *v1alpha1.M3DBCluster:
+			cluster := &v1beta1.M3DBCluster{}
+			err = cluster.ConvertFrom(hub)
+			out = cluster
+		case *v1alpha1.M3DBNamespace:
+			ns := &v1beta1.M3DBNamespace{}
+			err = ns.ConvertFrom(hub)
+			out = ns
+		}
+	default:
+		return nil, fmt.Errorf("unsupported desired API version '%s'", desiredAPIVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(out)
+}
+
+func convertClusterToHub(raw []byte, apiVersion string) (*v1alpha1.M3DBCluster, error) {
+	switch apiVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		cluster := &v1alpha1.M3DBCluster{}
+		if err := json.Unmarshal(raw, cluster); err != nil {
+			return nil, fmt.Errorf("error decoding cluster: %v", err)
+		}
+		return cluster, nil
+	case v1beta1.SchemeGroupVersion.String():
+		cluster := &v1beta1.M3DBCluster{}
+		if err := json.Unmarshal(raw, cluster); err != nil {
+			return nil, fmt.Errorf("error decoding cluster: %v", err)
+		}
+		hub := &v1alpha1.M3DBCluster{}
+		return hub, cluster.ConvertTo(hub)
+	}
+	return nil, fmt.Errorf("unsupported API version '%s'", apiVersion)
+}
+
+func convertNamespaceToHub(raw []byte, apiVersion string) (*v1alpha1.M3DBNamespace, error) {
+	switch apiVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		ns := &v1alpha1.M3DBNamespace{}
+		if err := json.Unmarshal(raw, ns); err != nil {
+			return nil, fmt.Errorf("error decoding namespace: %v", err)
+		}
+		return ns, nil
+	case v1beta1.SchemeGroupVersion.String():
+		ns := &v1beta1.M3DBNamespace{}
+		if err := json.Unmarshal(raw, ns); err != nil {
+			return nil, fmt.Errorf("error decoding namespace: %v", err)
+		}
+		hub := &v1alpha1.M3DBNamespace{}
+		return hub, ns.ConvertTo(hub)
+	}
+	return nil, fmt.Errorf("unsupported API version '%s'", apiVersion)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1beta1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func convert(t *testing.T, req *conversionRequest) *conversionResponse {
+	body, err := json.Marshal(&conversionReview{Request: req})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	newHandler(zap.NewNop(), nil).ServeHTTP(w, httptest.NewRequest(http.MethodPost, ConvertPath, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &conversionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.NotNil(t, resp.Response)
+	assert.Equal(t, req.UID, resp.Response.UID)
+	return resp.Response
+}
+
+func TestConvertCluster(t *testing.T) {
+	cluster := newCluster()
+	cluster.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	cluster.Kind = "M3DBCluster"
+	configMap := "m3db-config"
+	cluster.Spec.ConfigMapName = &configMap
+
+	resp := convert(t, &conversionRequest{
+		UID:               types.UID("1"),
+		DesiredAPIVersion: v1beta1.SchemeGroupVersion.String(),
+		Objects:           []runtime.RawExtension{rawCluster(t, cluster)},
+	})
+	require.Equal(t, metav1.StatusSuccess, resp.Result.Status)
+	require.Len(t, resp.ConvertedObjects, 1)
+
+	beta := &v1beta1.M3DBCluster{}
+	require.NoError(t, json.Unmarshal(resp.ConvertedObjects[0].Raw, beta))
+	assert.Equal(t, v1beta1.SchemeGroupVersion.String(), beta.APIVersion)
+	assert.Equal(t, "M3DBCluster", beta.Kind)
+	assert.Equal(t, cluster.Name, beta.Name)
+	require.NotNil(t, beta.Spec.ConfigMapRef)
+	assert.Equal(t, configMap, beta.Spec.ConfigMapRef.Name)
+
+	// And back again.
+	resp = convert(t, &conversionRequest{
+		UID:               types.UID("2"),
+		DesiredAPIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Objects:           resp.ConvertedObjects,
+	})
+	require.Equal(t, metav1.StatusSuccess, resp.Result.Status)
+	require.Len(t, resp.ConvertedObjects, 1)
+
+	alpha := &v1alpha1.M3DBCluster{}
+	require.NoError(t, json.Unmarshal(resp.ConvertedObjects[0].Raw, alpha))
+	assert.Equal(t, cluster, alpha)
+}
+
+func TestConvertNamespace(t *testing.T) {
+	ns := &v1beta1.M3DBNamespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1beta1.SchemeGroupVersion.String(),
+			Kind:       "M3DBNamespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "fake"},
+		Spec: v1beta1.M3DBNamespaceSpec{
+			ClusterName: "cluster",
+			Options: &v1beta1.NamespaceOptions{
+				RetentionOptions: v1beta1.RetentionOptions{
+					RetentionPeriod: metav1.Duration{Duration: 48 * time.Hour},
+					BlockSize:       metav1.Duration{Duration: 2 * time.Hour},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(ns)
+	require.NoError(t, err)
+
+	resp := convert(t, &conversionRequest{
+		UID:               types.UID("1"),
+		DesiredAPIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Objects:           []runtime.RawExtension{{Raw: data}},
+	})
+	require.Equal(t, metav1.StatusSuccess, resp.Result.Status)
+	require.Len(t, resp.ConvertedObjects, 1)
+
+	alpha := &v1alpha1.M3DBNamespace{}
+	require.NoError(t, json.Unmarshal(resp.ConvertedObjects[0].Raw, alpha))
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.String(), alpha.APIVersion)
+	assert.Equal(t, "48h0m0s", alpha.Spec.Options.RetentionOptions.RetentionPeriod)
+	assert.Equal(t, "2h0m0s", alpha.Spec.Options.RetentionOptions.BlockSize)
+}
+
+func TestConvertSameVersion(t *testing.T) {
+	cluster := newCluster()
+	cluster.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	cluster.Kind = "M3DBCluster"
+	raw := rawCluster(t, cluster)
+
+	resp := convert(t, &conversionRequest{
+		UID:               types.UID("1"),
+		DesiredAPIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Objects:           []runtime.RawExtension{raw},
+	})
+	require.Equal(t, metav1.StatusSuccess, resp.Result.Status)
+	require.Len(t, resp.ConvertedObjects, 1)
+	assert.JSONEq(t, string(raw.Raw), string(resp.ConvertedObjects[0].Raw))
+}
+
+func TestConvertErrors(t *testing.T) {
+	cluster := newCluster()
+	cluster.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	cluster.Kind = "M3DBCluster"
+
+	resp := convert(t, &conversionRequest{
+		UID:               types.UID("1"),
+		DesiredAPIVersion: "operator.m3db.io/v2",
+		Objects:           []runtime.RawExtension{rawCluster(t, cluster)},
+	})
+	assert.Equal(t, metav1.StatusFailure, resp.Result.Status)
+	assert.Empty(t, resp.ConvertedObjects)
+
+	cluster.Kind = "Unknown"
+	resp = convert(t, &conversionRequest{
+		UID:               types.UID("2"),
+		DesiredAPIVersion: v1beta1.SchemeGroupVersion.String(),
+		Objects:           []runtime.RawExtension{rawCluster(t, cluster)},
+	})
+	assert.Equal(t, metav1.StatusFailure, resp.Result.Status)
+	assert.Contains(t, resp.Result.Message, "unsupported kind")
+
+	ns := &v1alpha1.M3DBNamespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "M3DBNamespace",
+		},
+		Spec: v1alpha1.M3DBNamespaceSpec{
+			ClusterName: "cluster",
+			Options: &v1alpha1.NamespaceOptions{
+				RetentionOptions: v1alpha1.RetentionOptions{RetentionPeriod: "forever"},
+			},
+		},
+	}
+	data, err := json.Marshal(ns)
+	require.NoError(t, err)
+	resp = convert(t, &conversionRequest{
+		UID:               types.UID("3"),
+		DesiredAPIVersion: v1beta1.SchemeGroupVersion.String(),
+		Objects:           []runtime.RawExtension{{Raw: data}},
+	})
+	assert.Equal(t, metav1.StatusFailure, resp.Result.Status)
+	assert.Contains(t, resp.Result.Message, "invalid")
+}
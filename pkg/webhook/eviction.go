@@ -0,0 +1,221 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/m3admin"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// EvictPodPath is the path the pod eviction validating webhook, which keeps
+	// evictions from taking down the last available replicas of a shard, is
+	// served on.
+	EvictPodPath = "/validate-pod-eviction"
+
+	evictionSubResource = "eviction"
+)
+
+// evictionGuard denies the eviction of M3DB pods while another replica of any
+// of the pod's shards is unavailable.
+type evictionGuard struct {
+	kubeClient        kubernetes.Interface
+	crdClient         clientset.Interface
+	placementClientFn func(*myspec.M3DBCluster) (placement.Client, error)
+	logger            *zap.Logger
+}
+
+func newEvictionGuard(
+	kubeClient kubernetes.Interface,
+	crdClient clientset.Interface,
+	logger *zap.Logger,
+) *evictionGuard {
+	return &evictionGuard{
+		kubeClient: kubeClient,
+		crdClient:  crdClient,
+		placementClientFn: func(cluster *myspec.M3DBCluster) (placement.Client, error) {
+			creds, err := k8sops.LoadAdminAPICredentials(cluster,
+				func(namespace, name string) (*corev1.Secret, error) {
+					return kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+				})
+			if err != nil {
+				return nil, err
+			}
+			adminClient := m3admin.NewClient(
+				m3admin.WithLogger(logger),
+				m3admin.WithEnvironment(k8sops.DefaultM3ClusterEnvironmentName(cluster)),
+				m3admin.WithZone(k8sops.ServiceZone(cluster)),
+				m3admin.WithTLSConfig(creds.TLSConfig),
+				m3admin.WithHeaders(creds.Headers))
+			return placement.NewClient(
+				placement.WithURL(k8sops.CoordinatorURL(cluster)),
+				placement.WithClient(adminClient),
+				placement.WithLogger(logger))
+		},
+		logger: logger,
+	}
+}
+
+func (g *evictionGuard) admit(ctx context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	allowed := &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if req.Operation != admissionv1beta1.Create || req.SubResource != evictionSubResource {
+		return allowed
+	}
+
+	pods := g.kubeClient.CoreV1().Pods(req.Namespace)
+	pod, err := pods.Get(req.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return allowed
+	}
+	if err != nil {
+		return deniedEviction(fmt.Errorf("error getting pod: %v", err))
+	}
+
+	clusterName, ok := pod.Labels[labels.Cluster]
+	if !ok {
+		return allowed
+	}
+
+	// Separate coordinators and aggregators aren't part of the M3DB placement.
+	switch pod.Labels[labels.Component] {
+	case labels.ComponentCoordinator, labels.ComponentAggregator:
+		return allowed
+	}
+
+	cluster, err := g.crdClient.OperatorV1alpha1().M3DBClusters(req.Namespace).Get(clusterName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return allowed
+	}
+	if err != nil {
+		return deniedEviction(fmt.Errorf("error getting cluster '%s': %v", clusterName, err))
+	}
+
+	if !cluster.Status.HasInitializedPlacement() {
+		return allowed
+	}
+
+	plClient, err := g.placementClientFn(cluster)
+	if err != nil {
+		return deniedEviction(fmt.Errorf("error creating placement client: %v", err))
+	}
+
+	pl, err := plClient.Get(ctx)
+	if err != nil {
+		return deniedEviction(fmt.Errorf("error getting placement: %v", err))
+	}
+
+	inst, ok := instanceForPod(pl, pod.Name)
+	if !ok {
+		return allowed
+	}
+
+	// Every other replica of the pod's shards must be available, and so must
+	// the pods serving them.
+	peers := make(map[string]struct{})
+	for _, s := range inst.Shards().All() {
+		for _, other := range pl.InstancesForShard(s.ID()) {
+			if other.ID() == inst.ID() {
+				continue
+			}
+
+			otherShard, ok := other.Shards().Shard(s.ID())
+			if !ok || otherShard.State() != shard.Available {
+				return deniedEviction(fmt.Errorf("shard %d is not available on %s", s.ID(), podName(other)))
+			}
+			peers[podName(other)] = struct{}{}
+		}
+	}
+
+	peerNames := make([]string, 0, len(peers))
+	for name := range peers {
+		peerNames = append(peerNames, name)
+	}
+	sort.Strings(peerNames)
+
+	for _, name := range peerNames {
+		peer, err := pods.Get(name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return deniedEviction(fmt.Errorf("pod %s holding replicas of the same shards does not exist", name))
+		}
+		if err != nil {
+			return deniedEviction(fmt.Errorf("error getting pod %s: %v", name, err))
+		}
+		if !podReady(peer) {
+			return deniedEviction(fmt.Errorf("pod %s holding replicas of the same shards is not ready", name))
+		}
+	}
+
+	return allowed
+}
+
+// instanceForPod returns the placement instance of the pod with the given
+// name. Instance hostnames are the pod's name within the cluster's headless
+// service.
+func instanceForPod(pl m3placement.Placement, name string) (m3placement.Instance, bool) {
+	for _, inst := range pl.Instances() {
+		if strings.EqualFold(podName(inst), name) {
+			return inst, true
+		}
+	}
+	return nil, false
+}
+
+func podName(inst m3placement.Instance) string {
+	return strings.Split(inst.Hostname(), ".")[0]
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deniedEviction denies an eviction with a 429, which clients such as kubectl
+// drain retry the same way they do evictions blocked by a disruption budget.
+func deniedEviction(err error) *admissionv1beta1.AdmissionResponse {
+	resp := denied(metav1.StatusReasonTooManyRequests, err)
+	resp.Result.Code = http.StatusTooManyRequests
+	return resp
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/m3db/m3db-operator/pkg/k8sops"
+	"github.com/m3db/m3db-operator/pkg/k8sops/labels"
+	"github.com/m3db/m3db-operator/pkg/m3admin/placement"
+
+	m3placement "github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/golang/mock/gomock"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const evictionTestShards = 4
+
+func newEvictionTestPod(cluster *myspec.M3DBCluster, name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				labels.Cluster:   cluster.Name,
+				labels.Component: labels.ComponentM3DBNode,
+			},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+// newEvictionTestPlacement returns a placement in which each of the named pods
+// holds a replica of every shard.
+func newEvictionTestPlacement(cluster *myspec.M3DBCluster, podNames ...string) m3placement.Placement {
+	insts := make([]m3placement.Instance, 0, len(podNames))
+	for _, name := range podNames {
+		shards := make([]shard.Shard, evictionTestShards)
+		for i := range shards {
+			shards[i] = shard.NewShard(uint32(i)).SetState(shard.Available)
+		}
+		hostname := name + "." + k8sops.HeadlessServiceName(cluster.Name)
+		insts = append(insts, m3placement.NewInstance().
+			SetID(fmt.Sprintf(`{"name":"%s"}`, name)).
+			SetHostname(hostname).
+			SetShards(shard.NewShards(shards)))
+	}
+
+	shardIDs := make([]uint32, evictionTestShards)
+	for i := range shardIDs {
+		shardIDs[i] = uint32(i)
+	}
+
+	return m3placement.NewPlacement().
+		SetInstances(insts).
+		SetShards(shardIDs).
+		SetReplicaFactor(len(podNames))
+}
+
+func evict(t *testing.T, guard *evictionGuard, namespace, name string) *admissionv1beta1.AdmissionResponse {
+	body, err := json.Marshal(&admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:         types.UID("1"),
+			Operation:   admissionv1beta1.Create,
+			SubResource: evictionSubResource,
+			Namespace:   namespace,
+			Name:        name,
+		},
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, EvictPodPath, bytes.NewReader(body))
+	newHandler(zap.NewNop(), guard).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.NotNil(t, resp.Response)
+	return resp.Response
+}
+
+func TestEvictPod(t *testing.T) {
+	podNames := []string{"cluster-rep0-0", "cluster-rep1-0", "cluster-rep2-0"}
+
+	tests := []struct {
+		name string
+		// modify sets up the objects and placement of the test, returning false if
+		// the placement shouldn't be fetched.
+		modify  func(cluster *myspec.M3DBCluster, pods []*corev1.Pod, pl m3placement.Placement) bool
+		plErr   error
+		evict   string
+		allowed bool
+	}{
+		{
+			name:    "all replicas available",
+			evict:   "cluster-rep0-0",
+			allowed: true,
+		},
+		{
+			name:  "other replica initializing",
+			evict: "cluster-rep0-0",
+			modify: func(_ *myspec.M3DBCluster, _ []*corev1.Pod, pl m3placement.Placement) bool {
+				inst, _ := instanceForPod(pl, "cluster-rep2-0")
+				s, _ := inst.Shards().Shard(3)
+				s.SetState(shard.Initializing)
+				return true
+			},
+		},
+		{
+			name:  "other replica not ready",
+			evict: "cluster-rep0-0",
+			modify: func(_ *myspec.M3DBCluster, pods []*corev1.Pod, _ m3placement.Placement) bool {
+				pods[1].Status.Conditions[0].Status = corev1.ConditionFalse
+				return true
+			},
+		},
+		{
+			name:  "evicted pod not ready",
+			evict: "cluster-rep0-0",
+			modify: func(_ *myspec.M3DBCluster, pods []*corev1.Pod, _ m3placement.Placement) bool {
+				pods[0].Status.Conditions[0].Status = corev1.ConditionFalse
+				return true
+			},
+			allowed: true,
+		},
+		{
+			name:  "placement error",
+			evict: "cluster-rep0-0",
+			plErr: errors.New("coordinator unavailable"),
+		},
+		{
+			name:  "pod not in placement",
+			evict: "cluster-rep0-1",
+			modify: func(cluster *myspec.M3DBCluster, pods []*corev1.Pod, _ m3placement.Placement) bool {
+				pods[0].Name = "cluster-rep0-1"
+				return true
+			},
+			allowed: true,
+		},
+		{
+			name:  "pod not found",
+			evict: "cluster-rep9-0",
+			modify: func(_ *myspec.M3DBCluster, _ []*corev1.Pod, _ m3placement.Placement) bool {
+				return false
+			},
+			allowed: true,
+		},
+		{
+			name:  "coordinator pod",
+			evict: "cluster-rep0-0",
+			modify: func(_ *myspec.M3DBCluster, pods []*corev1.Pod, _ m3placement.Placement) bool {
+				pods[0].Labels[labels.Component] = labels.ComponentCoordinator
+				return false
+			},
+			allowed: true,
+		},
+		{
+			name:  "placement not initialized",
+			evict: "cluster-rep0-0",
+			modify: func(cluster *myspec.M3DBCluster, _ []*corev1.Pod, _ m3placement.Placement) bool {
+				cluster.Status.Conditions = nil
+				return false
+			},
+			allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mc := gomock.NewController(t)
+			defer mc.Finish()
+
+			cluster := newCluster()
+			cluster.Status.Conditions = []myspec.ClusterCondition{
+				{
+					Type:   myspec.ClusterConditionPlacementInitialized,
+					Status: corev1.ConditionTrue,
+				},
+			}
+
+			pods := make([]*corev1.Pod, 0, len(podNames))
+			for _, name := range podNames {
+				pods = append(pods, newEvictionTestPod(cluster, name))
+			}
+			pl := newEvictionTestPlacement(cluster, podNames...)
+
+			getsPlacement := true
+			if test.modify != nil {
+				getsPlacement = test.modify(cluster, pods, pl)
+			}
+
+			plClient := placement.NewMockClient(mc)
+			if getsPlacement {
+				plClient.EXPECT().Get(gomock.Any()).Return(pl, test.plErr)
+			}
+
+			kubeObjects := make([]runtime.Object, 0, len(pods))
+			for _, pod := range pods {
+				kubeObjects = append(kubeObjects, pod)
+			}
+
+			guard := newEvictionGuard(kubefake.NewSimpleClientset(kubeObjects...),
+				crdfake.NewSimpleClientset(cluster), zap.NewNop())
+			guard.placementClientFn = func(*myspec.M3DBCluster) (placement.Client, error) {
+				return plClient, nil
+			}
+
+			resp := evict(t, guard, cluster.Namespace, test.evict)
+			assert.Equal(t, test.allowed, resp.Allowed)
+			if !test.allowed {
+				assert.Equal(t, int32(http.StatusTooManyRequests), resp.Result.Code)
+			}
+		})
+	}
+}
+
+func TestEvictPodIgnoresOtherRequests(t *testing.T) {
+	guard := newEvictionGuard(kubefake.NewSimpleClientset(), crdfake.NewSimpleClientset(), zap.NewNop())
+	resp := guard.admit(context.Background(), &admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Delete,
+		Namespace: "fake",
+		Name:      "cluster-rep0-0",
+	})
+	assert.True(t, resp.Allowed)
+
+	// The eviction webhook isn't served without a guard.
+	w := httptest.NewRecorder()
+	newHandler(zap.NewNop(), nil).ServeHTTP(w, httptest.NewRequest(http.MethodPost, EvictPodPath, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
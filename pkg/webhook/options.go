@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"errors"
+
+	clientset "github.com/m3db/m3db-operator/pkg/client/clientset/versioned"
+
+	"k8s.io/client-go/kubernetes"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAddress = ":8443"
+)
+
+// Option configures a webhook server.
+type Option interface {
+	execute(*options)
+}
+
+type options struct {
+	logger   *zap.Logger
+	address  string
+	certFile string
+	keyFile  string
+
+	kubeClient kubernetes.Interface
+	crdClient  clientset.Interface
+}
+
+type optionFn func(o *options)
+
+func (fn optionFn) execute(o *options) {
+	fn(o)
+}
+
+// WithLogger configures the server's logger.
+func WithLogger(l *zap.Logger) Option {
+	return optionFn(func(o *options) {
+		o.logger = l
+	})
+}
+
+// WithAddress configures the address the server listens on. Defaults to
+// :8443.
+func WithAddress(addr string) Option {
+	return optionFn(func(o *options) {
+		o.address = addr
+	})
+}
+
+// WithCertFile configures the TLS certificate served by the server.
+func WithCertFile(f string) Option {
+	return optionFn(func(o *options) {
+		o.certFile = f
+	})
+}
+
+// WithKeyFile configures the private key of the server's TLS certificate.
+func WithKeyFile(f string) Option {
+	return optionFn(func(o *options) {
+		o.keyFile = f
+	})
+}
+
+// WithKubeClient configures the Kubernetes client of the server. Along with
+// WithCRDClient it enables the pod eviction webhook.
+func WithKubeClient(cl kubernetes.Interface) Option {
+	return optionFn(func(o *options) {
+		o.kubeClient = cl
+	})
+}
+
+// WithCRDClient configures the M3DB CRD client of the server. Along with
+// WithKubeClient it enables the pod eviction webhook.
+func WithCRDClient(cl clientset.Interface) Option {
+	return optionFn(func(o *options) {
+		o.crdClient = cl
+	})
+}
+
+func (o *options) validate() error {
+	switch {
+	case o.certFile == "":
+		return errors.New("webhook cert file cannot be empty")
+	case o.keyFile == "":
+		return errors.New("webhook key file cannot be empty")
+	case (o.kubeClient == nil) != (o.crdClient == nil):
+		return errors.New("webhook kube and CRD clients must be set together")
+	}
+	return nil
+}
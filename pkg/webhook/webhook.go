@@ -0,0 +1,256 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package webhook implements the operator's admission and CRD conversion
+// webhooks.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	"github.com/m3db/m3db-operator/pkg/defaults"
+	"github.com/m3db/m3db-operator/pkg/validation"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ValidateClusterPath is the path the M3DBCluster validating webhook is
+	// served on.
+	ValidateClusterPath = "/validate-m3dbcluster"
+
+	// DefaultClusterPath is the path the M3DBCluster mutating webhook, which
+	// fills in cluster defaults, is served on.
+	DefaultClusterPath = "/mutate-m3dbcluster"
+
+	_shutdownTimeout = 10 * time.Second
+)
+
+// admitFunc reviews an admission request. The context is cancelled when the
+// API server abandons the request.
+type admitFunc func(ctx context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse
+
+// Server serves the operator's webhooks over HTTPS.
+type Server struct {
+	logger   *zap.Logger
+	address  string
+	certFile string
+	keyFile  string
+	handler  http.Handler
+}
+
+// New returns a new webhook server.
+func New(opts ...Option) (*Server, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt.execute(o)
+	}
+
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	if o.logger == nil {
+		o.logger = zap.NewNop()
+	}
+
+	if o.address == "" {
+		o.address = defaultAddress
+	}
+
+	var guard *evictionGuard
+	if o.kubeClient != nil {
+		guard = newEvictionGuard(o.kubeClient, o.crdClient, o.logger)
+	}
+
+	return &Server{
+		logger:   o.logger,
+		address:  o.address,
+		certFile: o.certFile,
+		keyFile:  o.keyFile,
+		handler:  newHandler(o.logger, guard),
+	}, nil
+}
+
+// Run serves the webhooks until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:    s.address,
+		Handler: s.handler,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("serving webhooks", zap.String("address", s.address))
+		errCh <- srv.ListenAndServeTLS(s.certFile, s.keyFile)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), _shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// newHandler returns the handler serving the webhooks. The pod eviction webhook
+// is only served when guard is non-nil.
+func newHandler(logger *zap.Logger, guard *evictionGuard) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(ValidateClusterPath, admissionHandler(logger, validateCluster))
+	mux.Handle(DefaultClusterPath, admissionHandler(logger, defaultCluster))
+	mux.Handle(ConvertPath, conversionHandler(logger))
+	if guard != nil {
+		mux.Handle(EvictPodPath, admissionHandler(logger, guard.admit))
+	}
+	return mux
+}
+
+// admissionHandler decodes an AdmissionReview from each request, reviews it
+// with admit and writes the response back.
+func admissionHandler(logger *zap.Logger, admit admitFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		review := &admissionv1beta1.AdmissionReview{}
+		if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		resp := admit(r.Context(), review.Request)
+		resp.UID = review.Request.UID
+		if !resp.Allowed {
+			logger.Info("denied admission request",
+				zap.String("path", r.URL.Path),
+				zap.String("namespace", review.Request.Namespace),
+				zap.String("name", review.Request.Name),
+				zap.String("reason", resp.Result.Message))
+		}
+
+		review.Response = resp
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(review); err != nil {
+			logger.Error("error writing admission response", zap.Error(err))
+		}
+	})
+}
+
+func validateCluster(_ context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	cluster := &myspec.M3DBCluster{}
+	if err := json.Unmarshal(req.Object.Raw, cluster); err != nil {
+		return denied(metav1.StatusReasonBadRequest, fmt.Errorf("error decoding cluster: %v", err))
+	}
+
+	err := validation.ValidateCluster(cluster)
+	if req.Operation == admissionv1beta1.Update {
+		old := &myspec.M3DBCluster{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return denied(metav1.StatusReasonBadRequest, fmt.Errorf("error decoding old cluster: %v", err))
+		}
+		err = validation.ValidateClusterUpdate(old, cluster)
+	}
+
+	if err != nil {
+		return denied(metav1.StatusReasonInvalid, err)
+	}
+
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+// jsonPatchOp is a JSON patch (RFC 6902) operation.
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func defaultCluster(_ context.Context, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	cluster := &myspec.M3DBCluster{}
+	if err := json.Unmarshal(req.Object.Raw, cluster); err != nil {
+		return denied(metav1.StatusReasonBadRequest, fmt.Errorf("error decoding cluster: %v", err))
+	}
+
+	changed, err := defaults.SetClusterDefaults(cluster)
+	if err != nil {
+		return denied(metav1.StatusReasonInvalid, err)
+	}
+	if !changed {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	// "add" replaces the member if it already exists.
+	patch, err := json.Marshal([]jsonPatchOp{
+		{Op: "add", Path: "/metadata/annotations", Value: cluster.Annotations},
+		{Op: "add", Path: "/spec", Value: cluster.Spec},
+	})
+	if err != nil {
+		return denied(metav1.StatusReasonInternalError, fmt.Errorf("error encoding patch: %v", err))
+	}
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+func denied(reason metav1.StatusReason, err error) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  reason,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	myspec "github.com/m3db/m3db-operator/pkg/apis/m3dboperator/v1alpha1"
+	crdfake "github.com/m3db/m3db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/m3db/m3db-operator/pkg/k8sops/annotations"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCluster() *myspec.M3DBCluster {
+	return &myspec.M3DBCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster",
+			Namespace: "fake",
+		},
+		Spec: myspec.ClusterSpec{
+			NumberOfShards:    8,
+			ReplicationFactor: 2,
+			IsolationGroups: []myspec.IsolationGroup{
+				{Name: "foo"},
+				{Name: "bar"},
+			},
+			Namespaces: []myspec.Namespace{
+				{Name: "metrics-10s:2d", Preset: "10s:2d"},
+			},
+		},
+	}
+}
+
+func rawCluster(t *testing.T, cluster *myspec.M3DBCluster) runtime.RawExtension {
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	return runtime.RawExtension{Raw: data}
+}
+
+func review(t *testing.T, path string, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	body, err := json.Marshal(&admissionv1beta1.AdmissionReview{Request: req})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	newHandler(zap.NewNop(), nil).ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := &admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+	require.NotNil(t, resp.Response)
+	assert.Equal(t, req.UID, resp.Response.UID)
+	return resp.Response
+}
+
+func TestOptions(t *testing.T) {
+	opts := &options{}
+	assert.Error(t, opts.validate())
+
+	logger := zap.NewNop()
+	for _, o := range []Option{
+		WithLogger(logger),
+		WithAddress(":9443"),
+		WithCertFile("tls.crt"),
+		WithKeyFile("tls.key"),
+	} {
+		o.execute(opts)
+	}
+
+	assert.Equal(t, logger, opts.logger)
+	assert.Equal(t, ":9443", opts.address)
+	assert.Equal(t, "tls.crt", opts.certFile)
+	assert.Equal(t, "tls.key", opts.keyFile)
+	assert.NoError(t, opts.validate())
+
+	// The eviction webhook needs both clients.
+	WithKubeClient(kubefake.NewSimpleClientset()).execute(opts)
+	assert.Error(t, opts.validate())
+	WithCRDClient(crdfake.NewSimpleClientset()).execute(opts)
+	assert.NoError(t, opts.validate())
+}
+
+func TestValidateClusterCreate(t *testing.T) {
+	resp := review(t, ValidateClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("1"),
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, newCluster()),
+	})
+	assert.True(t, resp.Allowed)
+
+	cluster := newCluster()
+	cluster.Spec.IsolationGroups[1].Name = "foo"
+	resp = review(t, ValidateClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("2"),
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, cluster),
+	})
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, metav1.StatusReasonInvalid, resp.Result.Reason)
+	assert.Contains(t, resp.Result.Message, "isolation group names are not unique")
+}
+
+func TestValidateClusterUpdate(t *testing.T) {
+	old := newCluster()
+	old.Status.Conditions = []myspec.ClusterCondition{
+		{
+			Type:   myspec.ClusterConditionPlacementInitialized,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	cluster := newCluster()
+	cluster.Spec.Namespaces = append(cluster.Spec.Namespaces, myspec.Namespace{Name: "foo", Preset: "1m:40d"})
+	resp := review(t, ValidateClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("1"),
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, cluster),
+		OldObject: rawCluster(t, old),
+	})
+	assert.True(t, resp.Allowed)
+
+	cluster = newCluster()
+	cluster.Spec.NumberOfShards = 16
+	resp = review(t, ValidateClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("2"),
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, cluster),
+		OldObject: rawCluster(t, old),
+	})
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "numberOfShards")
+}
+
+func TestValidateClusterDelete(t *testing.T) {
+	resp := review(t, ValidateClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("1"),
+		Operation: admissionv1beta1.Delete,
+	})
+	assert.True(t, resp.Allowed)
+}
+
+func TestAdmissionHandlerBadRequest(t *testing.T) {
+	handler := newHandler(zap.NewNop(), nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ValidateClusterPath, bytes.NewReader([]byte("{"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ValidateClusterPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestDefaultCluster(t *testing.T) {
+	cluster := newCluster()
+	raw := rawCluster(t, cluster)
+	resp := review(t, DefaultClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("1"),
+		Operation: admissionv1beta1.Create,
+		Object:    raw,
+	})
+	require.True(t, resp.Allowed)
+	require.NotNil(t, resp.PatchType)
+	assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *resp.PatchType)
+
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Patch, &ops))
+	require.Len(t, ops, 2)
+	assert.Equal(t, "add", ops[0].Op)
+	assert.Equal(t, "/metadata/annotations", ops[0].Path)
+	assert.Equal(t, "add", ops[1].Op)
+	assert.Equal(t, "/spec", ops[1].Path)
+
+	defaulted := cluster.DeepCopy()
+	require.NoError(t, json.Unmarshal(ops[0].Value, &defaulted.Annotations))
+	require.NoError(t, json.Unmarshal(ops[1].Value, &defaulted.Spec))
+	assert.Equal(t, "1", defaulted.Annotations[annotations.DefaultsVersion])
+	assert.NotEmpty(t, defaulted.Spec.Image)
+	assert.NotNil(t, defaulted.Spec.ProbeOptions)
+
+	// An already defaulted cluster isn't patched.
+	resp = review(t, DefaultClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("2"),
+		Operation: admissionv1beta1.Update,
+		Object:    rawCluster(t, defaulted),
+	})
+	assert.True(t, resp.Allowed)
+	assert.Nil(t, resp.Patch)
+
+	cluster.Annotations = map[string]string{annotations.DefaultsVersion: "1000"}
+	resp = review(t, DefaultClusterPath, &admissionv1beta1.AdmissionRequest{
+		UID:       types.UID("3"),
+		Operation: admissionv1beta1.Create,
+		Object:    rawCluster(t, cluster),
+	})
+	assert.False(t, resp.Allowed)
+}